@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CriterionLabel is how acceptance criterion i (from 0) is referred to in
+// prompts and answers: AC1, AC2, ...
+func CriterionLabel(i int) string {
+	return "AC" + strconv.Itoa(i+1)
+}
+
+// CheckAcceptance adds the reviewer's answers to a task's acceptance
+// criteria to a parsed review. Like a required checklist item, a
+// criterion that is unanswered or FAIL forces a REJECT and is added to
+// the comments.
+//
+//	ACCEPTANCE:
+//	- AC1: PASS — POST /login returns 401 on bad credentials
+//	- AC2: FAIL — no rate limiting yet
+func CheckAcceptance(review ParsedReview, output string, criteria []string) ParsedReview {
+	if len(criteria) == 0 {
+		return review
+	}
+	review.Acceptance = ParseAcceptance(output, criteria)
+	var failed []string
+	for i, a := range review.Acceptance {
+		switch a.Answer {
+		case "":
+			failed = append(failed, fmt.Sprintf("Acceptance: %s %q was not answered", CriterionLabel(i), a.Item))
+		case "FAIL":
+			msg := fmt.Sprintf("Acceptance: %s %q failed", CriterionLabel(i), a.Item)
+			if a.Note != "" {
+				msg += " — " + a.Note
+			}
+			failed = append(failed, msg)
+		}
+	}
+	if len(failed) > 0 {
+		review.Verdict = "REJECT"
+		review.Comments = append(failed, review.Comments...)
+	}
+	return review
+}
+
+// ParseAcceptance returns the reviewer's answer to each criterion, in
+// order. Entries name a criterion by its label (AC1) or by its text.
+func ParseAcceptance(output string, criteria []string) []ChecklistAnswer {
+	_, entries := listSection(output, "ACCEPTANCE:")
+
+	answers := make([]ChecklistAnswer, len(criteria))
+	for i, c := range criteria {
+		answers[i] = ChecklistAnswer{Item: c, Required: true}
+		label := strings.ToLower(CriterionLabel(i))
+		text := strings.ToLower(strings.TrimSpace(c))
+		for _, e := range entries {
+			e = strings.TrimSpace(strings.ReplaceAll(e, "**", ""))
+			lower := strings.ToLower(e)
+			var rest string
+			switch {
+			case strings.HasPrefix(lower, label) && (len(e) == len(label) || !isWordChar(e[len(label)])):
+				rest = e[len(label):]
+				// "AC1 (criterion text): PASS" — skip a quoted criterion.
+				if j := strings.Index(strings.ToLower(rest), text); j >= 0 {
+					rest = rest[j+len(text):]
+				}
+				rest = strings.TrimLeft(rest, " )")
+			case strings.Contains(lower, text):
+				rest = e[strings.Index(lower, text)+len(text):]
+			default:
+				continue
+			}
+			answer, note := checklistAnswer(rest)
+			if answer != "" {
+				answers[i].Answer, answers[i].Note = answer, note
+				break
+			}
+		}
+	}
+	return answers
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAcceptance(t *testing.T) {
+	criteria := []string{"Returns 401 for a wrong password", "Sets a session cookie", "Logs the attempt"}
+	output := `ACCEPTANCE:
+- AC1: PASS — covered by TestLogin_BadPassword
+- **AC2** (Sets a session cookie): FAIL — cookie is never set
+- logs the attempt: N/A
+
+VERDICT: APPROVE`
+
+	r := CheckAcceptance(ParseReview(output), output, criteria)
+	if r.Verdict != "REJECT" {
+		t.Fatalf("a failed criterion should force REJECT, got %s", r.Verdict)
+	}
+	want := []string{"PASS", "FAIL", "N/A"}
+	for i, a := range r.Acceptance {
+		if a.Answer != want[i] {
+			t.Errorf("%s: expected %s, got %q", a.Item, want[i], a.Answer)
+		}
+	}
+	if len(r.Comments) == 0 || !strings.Contains(r.Comments[0], `AC2 "Sets a session cookie" failed — cookie is never set`) {
+		t.Errorf("expected the failed criterion first in the comments, got %v", r.Comments)
+	}
+}
+
+func TestCheckAcceptance_Unanswered(t *testing.T) {
+	output := "ACCEPTANCE:\n- AC10: PASS\n\nVERDICT: APPROVE"
+	r := CheckAcceptance(ParseReview(output), output, []string{"Returns 401"})
+	if r.Verdict != "REJECT" || !strings.Contains(r.Comments[0], "was not answered") {
+		t.Errorf("AC10 doesn't answer AC1; got %s %v", r.Verdict, r.Comments)
+	}
+
+	r = CheckAcceptance(ParseReview("VERDICT: APPROVE"), "VERDICT: APPROVE", nil)
+	if r.Verdict != "APPROVE" || r.Acceptance != nil {
+		t.Errorf("no criteria should leave the review alone, got %+v", r)
+	}
+}
@@ -5,8 +5,13 @@ package agent
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/metrics"
+	"github.com/imkarma/hive/internal/redact"
+	"github.com/imkarma/hive/internal/sanitize"
+	"github.com/imkarma/hive/internal/tracing"
 )
 
 // Request contains everything an agent needs to work on a task.
@@ -15,6 +20,27 @@ type Request struct {
 	Prompt     string // The full prompt with context
 	WorkDir    string // Working directory (repo root)
 	TimeoutSec int    // Max execution time
+
+	// Messages is the prompt as a conversation (system, prior turns, the
+	// user's latest), for API agents. Prompt stays the flat text CLI
+	// agents get; without Messages API agents get it as one user turn.
+	Messages []Message
+
+	// Images are attached image files (absolute paths). API agents with
+	// vision get them inline, CLI agents get their paths in the prompt.
+	// Left empty, they're looked up for TaskID (see SetImageSource).
+	Images []string
+
+	// Stream, if set, is called with each line of output as the agent
+	// writes it, instead of only getting it all in the Response: a CLI
+	// agent's stdout as it runs, an API agent's answer as it streams in.
+	Stream func(line string)
+
+	// Format, if set, asks for the answer in that shape (FormatPlan,
+	// FormatReview). API agents whose provider supports structured output
+	// answer in JSON, which is validated and rendered as the usual text
+	// format; other agents answer free-form.
+	Format Format
 }
 
 // Response is what we get back from an agent.
@@ -23,6 +49,13 @@ type Response struct {
 	ExitCode int     // 0 = success, non-zero = failure
 	Duration float64 // Execution time in seconds
 	Error    error   // Any execution error
+
+	InputTokens  int // Prompt tokens, when the provider reports usage (API mode)
+	OutputTokens int // Completion tokens, when the provider reports usage
+
+	Truncated     bool // Output stopped at the token limit, even after continuing (API mode)
+	Continuations int  // Follow-up calls made to finish a cut-off answer
+	Structured    bool // Output was rendered from a validated JSON answer (see Request.Format)
 }
 
 // Runner is the interface that all agent adapters must implement.
@@ -33,18 +66,117 @@ type Runner interface {
 	// Name returns the agent's configured name.
 	Name() string
 
-	// Mode returns "cli" or "api".
+	// Mode returns "cli", "api" or "fake".
 	Mode() string
 }
 
 // NewRunner creates the appropriate runner based on agent config.
+// Every call through the runner is recorded in metrics, and in the
+// cassette while recording. While replaying a cassette or simulating, the
+// agent itself never runs. In offline mode api-mode agents are an error.
 func NewRunner(name string, agentCfg config.Agent) (Runner, error) {
+	var r Runner
+	cassetteMu.Lock()
+	c := replay
+	cassetteMu.Unlock()
+	if c != nil {
+		return &instrumentedRunner{Runner: &replayRunner{name: name, cfg: agentCfg, cassette: c}, cfg: agentCfg}, nil
+	}
+	if Simulating() {
+		return &instrumentedRunner{Runner: NewFakeRunner(name, agentCfg), cfg: agentCfg}, nil
+	}
 	switch agentCfg.Mode {
 	case "cli":
-		return NewCLIRunner(name, agentCfg), nil
+		r = NewCLIRunner(name, agentCfg)
+	case "fake":
+		r = NewFakeRunner(name, agentCfg)
 	case "api":
-		return NewAPIRunner(name, agentCfg)
+		if Offline() {
+			return nil, OfflineError(name, agentCfg)
+		}
+		api, err := NewAPIRunner(name, agentCfg)
+		if err != nil {
+			return nil, err
+		}
+		r = api
 	default:
 		return nil, fmt.Errorf("unknown agent mode: %s", agentCfg.Mode)
 	}
+	return &instrumentedRunner{Runner: r, cfg: agentCfg}, nil
+}
+
+// instrumentedRunner records each call in metrics and as a trace span
+// under the task's span. Secrets are masked in the prompt before the
+// agent sees it, and the output is sanitized before anyone stores it.
+// Under chaos, a call may fail without reaching the agent.
+type instrumentedRunner struct {
+	Runner
+	cfg config.Agent
+}
+
+func (m *instrumentedRunner) Run(ctx context.Context, req Request) (*Response, error) {
+	start := time.Now()
+	ctx, span := tracing.Start(tracing.TaskContext(ctx, req.TaskID), "agent."+m.cfg.Role,
+		tracing.String("hive.agent", m.Name()),
+		tracing.String("hive.agent.mode", m.Mode()),
+		tracing.Int("hive.task.id", req.TaskID),
+	)
+	defer span.End()
+
+	req.Prompt = redact.String(req.Prompt)
+	if len(req.Messages) > 0 {
+		msgs := make([]Message, len(req.Messages))
+		for i, msg := range req.Messages {
+			msgs[i] = Message{Role: msg.Role, Content: redact.String(msg.Content)}
+		}
+		req.Messages = msgs
+	}
+	if req.Images == nil {
+		req.Images = taskImages(req.TaskID)
+	}
+	if stream := req.Stream; stream != nil {
+		req.Stream = func(line string) { stream(redact.String(sanitize.Line(line))) }
+	}
+	var resp *Response
+	var err error
+	injected := injectFault(m.Name())
+	if injected != nil {
+		resp = injected
+	} else if rec := currentRecorder(); rec != nil {
+		resp, err = runRecorded(ctx, rec, m.Runner, m.cfg, req)
+	} else {
+		resp, err = m.Runner.Run(ctx, req)
+	}
+	if resp != nil {
+		resp.Output = sanitize.Output(req.TaskID, m.Name(), resp.Output)
+	}
+
+	failed := err != nil || resp == nil || resp.Error != nil || resp.ExitCode != 0
+	call := Call{
+		TaskID: req.TaskID, Agent: m.Name(), Role: m.cfg.Role, Mode: m.Mode(),
+		Duration: time.Since(start).Seconds(), Failed: failed, At: time.Now(),
+	}
+	metrics.ObserveAgentCall(m.Name(), m.cfg.Role, m.Mode(), call.Duration, failed)
+	if resp != nil && (resp.InputTokens > 0 || resp.OutputTokens > 0) {
+		call.InputTokens, call.OutputTokens = resp.InputTokens, resp.OutputTokens
+		call.Cost = m.cfg.Pricing.Cost(resp.InputTokens, resp.OutputTokens)
+		metrics.AddUsage(m.Name(), resp.InputTokens, resp.OutputTokens, call.Cost)
+		span.SetAttr("hive.tokens.input", resp.InputTokens)
+		span.SetAttr("hive.tokens.output", resp.OutputTokens)
+	}
+	if resp != nil && resp.Continuations > 0 {
+		span.SetAttr("hive.continuations", resp.Continuations)
+	}
+	switch {
+	case err != nil:
+		span.Fail(err.Error())
+	case resp != nil && resp.Error != nil:
+		span.Fail(resp.Error.Error())
+	case resp != nil && resp.ExitCode != 0:
+		span.Fail(fmt.Sprintf("exit %d", resp.ExitCode))
+	}
+	if injected == nil {
+		reportCall(call)
+	}
+	return resp, err
 }
@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/imkarma/hive/internal/config"
@@ -19,6 +21,7 @@ type APIRunner struct {
 	cfg    config.Agent
 	apiKey string
 	client *http.Client
+	limits *limiter // Shared by every runner using the same key
 }
 
 // NewAPIRunner creates a runner that calls LLM APIs.
@@ -34,17 +37,122 @@ func NewAPIRunner(name string, cfg config.Agent) (*APIRunner, error) {
 		name:   name,
 		cfg:    cfg,
 		apiKey: apiKey,
-		client: &http.Client{Timeout: timeout},
+		client: &http.Client{Timeout: timeout, Transport: &apiLogTransport{agent: name, provider: cfg.Provider}},
+		limits: limiterFor(cfg.Provider, cfg.APIKeyEnv),
 	}, nil
 }
 
 func (r *APIRunner) Name() string { return r.name }
 func (r *APIRunner) Mode() string { return "api" }
 
-// Run sends the prompt to the configured API provider.
+// maxContinuations is how often an answer cut off at the token limit is
+// continued before hive gives up and uses what it has.
+const maxContinuations = 3
+
+// continuePrompt asks for the rest of an answer cut off at the token limit.
+const continuePrompt = "Your answer was cut off by the length limit. Continue exactly where it stopped: " +
+	"no preamble, don't repeat anything, keep the same format."
+
+// Run sends the prompt to the configured API provider. An answer cut off
+// at the token limit (mid-SPEC or mid-SUBTASKS, say) is continued, up to
+// maxContinuations times, and the parts are stitched together before
+// anyone parses them.
 func (r *APIRunner) Run(ctx context.Context, req Request) (*Response, error) {
+	if req.Format != "" {
+		if r.cfg.StructuredEnabled() {
+			return r.runStructured(ctx, req)
+		}
+		req.Format = ""
+	}
+	start := time.Now()
+	resp, err := r.send(ctx, req, start)
+	for i := 0; i < maxContinuations && err == nil && resp.ExitCode == 0 && resp.Truncated; i++ {
+		more, moreErr := r.send(ctx, continuation(req, resp.Output), start)
+		if moreErr != nil || more.ExitCode != 0 {
+			break // Keep the partial answer; it's still marked Truncated.
+		}
+		resp.Output += more.Output
+		resp.InputTokens += more.InputTokens
+		resp.OutputTokens += more.OutputTokens
+		resp.Truncated = more.Truncated
+		resp.Continuations++
+	}
+	if resp != nil {
+		resp.Duration = time.Since(start).Seconds()
+	}
+	return resp, err
+}
+
+// runStructured asks for a JSON answer in req.Format's shape and renders
+// it in the text format the parsers read. An answer cut off at the token
+// limit or not matching the schema, or a 400 from a model without
+// structured output, is asked for again as free-form text. The answer
+// isn't streamed as it comes in; the rendered text is, once it's there.
+func (r *APIRunner) runStructured(ctx context.Context, req Request) (*Response, error) {
 	start := time.Now()
+	stream := req.Stream
+	req.Stream = nil
+	resp, err := r.send(ctx, req, start)
+	if err != nil {
+		return resp, err
+	}
+	if resp.ExitCode == 0 && !resp.Truncated {
+		if text, renderErr := renderStructured(req.Format, resp.Output); renderErr == nil {
+			resp.Output, resp.Structured = text, true
+			resp.Duration = time.Since(start).Seconds()
+			if stream != nil {
+				for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+					stream(line)
+				}
+			}
+			return resp, nil
+		}
+	}
+	if resp.ExitCode != 0 && resp.ExitCode != http.StatusBadRequest {
+		resp.Duration = time.Since(start).Seconds()
+		return resp, nil
+	}
 
+	req.Format, req.Stream = "", stream
+	free, err := r.Run(ctx, req)
+	if free != nil {
+		free.InputTokens += resp.InputTokens
+		free.OutputTokens += resp.OutputTokens
+		free.Duration = time.Since(start).Seconds()
+	}
+	return free, err
+}
+
+// continuation is req followed by the partial answer and a request to go on.
+func continuation(req Request, partial string) Request {
+	msgs := req.conversation()
+	msgs = append(msgs,
+		Message{Role: RoleAssistant, Content: partial},
+		Message{Role: RoleUser, Content: continuePrompt},
+	)
+	req.Messages = msgs
+	return req
+}
+
+// send makes one call. Calls are paced by the rate limits the provider
+// reports, and a 429 is retried after the limit resets.
+func (r *APIRunner) send(ctx context.Context, req Request, start time.Time) (*Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := r.limits.wait(ctx, estimateTokens(req.Prompt)); err != nil {
+			return &Response{
+				ExitCode: -1,
+				Duration: time.Since(start).Seconds(),
+				Error:    fmt.Errorf("waiting for rate limit: %w", err),
+			}, nil
+		}
+		resp, err := r.call(ctx, req, start)
+		if err != nil || resp.ExitCode != http.StatusTooManyRequests || attempt == maxRateLimitRetries {
+			return resp, err
+		}
+	}
+}
+
+func (r *APIRunner) call(ctx context.Context, req Request, start time.Time) (*Response, error) {
 	switch r.cfg.Provider {
 	case "openai":
 		return r.runOpenAI(ctx, req, start)
@@ -57,14 +165,129 @@ func (r *APIRunner) Run(ctx context.Context, req Request) (*Response, error) {
 	}
 }
 
-// runOpenAI handles OpenAI-compatible APIs (OpenAI, OpenRouter, local proxies).
-func (r *APIRunner) runOpenAI(ctx context.Context, req Request, start time.Time) (*Response, error) {
+// prepare returns the request's conversation and, for a model with
+// vision, its images, which go with the last user turn. Images the model
+// won't get are mentioned in that turn instead.
+func (r *APIRunner) prepare(req Request) ([]Message, []inlineImage) {
+	msgs := req.conversation()
+	if len(req.Images) == 0 {
+		return msgs, nil
+	}
+	var images []inlineImage
+	var unseen []string
+	if r.cfg.Vision {
+		images, unseen = loadImages(req.Images)
+	} else {
+		for _, p := range req.Images {
+			unseen = append(unseen, filepath.Base(p))
+		}
+	}
+	if len(unseen) > 0 {
+		msgs[len(msgs)-1].Content += unseenSection(unseen)
+	}
+	return msgs, images
+}
+
+// openAIBody builds a chat completions request. The images go with the
+// last message.
+func openAIBody(model string, msgs []Message, images []inlineImage) map[string]any {
+	var messages []map[string]any
+	for i, m := range msgs {
+		var content any = m.Content
+		if i == len(msgs)-1 && len(images) > 0 {
+			parts := []map[string]any{{"type": "text", "text": m.Content}}
+			for _, img := range images {
+				parts = append(parts, map[string]any{
+					"type":      "image_url",
+					"image_url": map[string]string{"url": "data:" + img.MIME + ";base64," + img.Data},
+				})
+			}
+			content = parts
+		}
+		messages = append(messages, map[string]any{"role": m.Role, "content": content})
+	}
+	return map[string]any{
+		"model":      model,
+		"messages":   messages,
+		"max_tokens": 4096,
+	}
+}
+
+// anthropicBody builds a Messages API request: the system prompt is its
+// own field, and the first user turn (the task context, which stays the
+// same across fix iterations) is marked for prompt caching.
+func anthropicBody(model string, msgs []Message, images []inlineImage) map[string]any {
+	system, turns := splitSystem(msgs)
+	var messages []map[string]any
+	for i, m := range turns {
+		var content []map[string]any
+		if i == len(turns)-1 {
+			// Images first: Anthropic recommends them before the text.
+			for _, img := range images {
+				content = append(content, map[string]any{
+					"type":   "image",
+					"source": map[string]string{"type": "base64", "media_type": img.MIME, "data": img.Data},
+				})
+			}
+		}
+		text := map[string]any{"type": "text", "text": m.Content}
+		if i == 0 && len(turns) > 1 {
+			text["cache_control"] = map[string]string{"type": "ephemeral"}
+		}
+		content = append(content, text)
+		messages = append(messages, map[string]any{"role": m.Role, "content": content})
+	}
 	body := map[string]any{
-		"model": r.cfg.Model,
-		"messages": []map[string]string{
-			{"role": "user", "content": req.Prompt},
-		},
+		"model":      model,
 		"max_tokens": 4096,
+		"messages":   messages,
+	}
+	if system != "" {
+		body["system"] = system
+	}
+	return body
+}
+
+// googleBody builds a generateContent request. Gemini calls the assistant
+// "model".
+func googleBody(msgs []Message, images []inlineImage) map[string]any {
+	system, turns := splitSystem(msgs)
+	var contents []map[string]any
+	for i, m := range turns {
+		parts := []map[string]any{{"text": m.Content}}
+		if i == len(turns)-1 {
+			for _, img := range images {
+				parts = append(parts, map[string]any{
+					"inline_data": map[string]string{"mime_type": img.MIME, "data": img.Data},
+				})
+			}
+		}
+		role := m.Role
+		if role == RoleAssistant {
+			role = "model"
+		}
+		contents = append(contents, map[string]any{"role": role, "parts": parts})
+	}
+	body := map[string]any{"contents": contents}
+	if system != "" {
+		body["systemInstruction"] = map[string]any{"parts": []map[string]string{{"text": system}}}
+	}
+	return body
+}
+
+// runOpenAI handles OpenAI-compatible APIs (OpenAI, OpenRouter, local proxies).
+func (r *APIRunner) runOpenAI(ctx context.Context, req Request, start time.Time) (*Response, error) {
+	msgs, images := r.prepare(req)
+	body := openAIBody(r.cfg.Model, msgs, images)
+	if req.Format != "" {
+		body["response_format"] = map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"name": string(req.Format), "strict": true, "schema": schemaFor(req.Format)},
+		}
+	}
+	if req.Stream != nil {
+		body["stream"] = true
+		body["stream_options"] = map[string]bool{"include_usage": true}
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -88,6 +311,10 @@ func (r *APIRunner) runOpenAI(ctx context.Context, req Request, start time.Time)
 		}, nil
 	}
 	defer httpResp.Body.Close()
+	r.limits.observe(httpResp.Header, httpResp.StatusCode)
+	if req.Stream != nil && httpResp.StatusCode == http.StatusOK {
+		return readOpenAIStream(httpResp.Body, req.Stream, start)
+	}
 
 	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
@@ -109,32 +336,45 @@ func (r *APIRunner) runOpenAI(ctx context.Context, req Request, start time.Time)
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, fmt.Errorf("parse response: %w", err)
 	}
 
-	output := ""
+	output, truncated := "", false
 	if len(result.Choices) > 0 {
 		output = result.Choices[0].Message.Content
+		truncated = result.Choices[0].FinishReason == "length"
 	}
 
 	return &Response{
-		Output:   output,
-		ExitCode: 0,
-		Duration: time.Since(start).Seconds(),
+		Output:       output,
+		Truncated:    truncated,
+		ExitCode:     0,
+		Duration:     time.Since(start).Seconds(),
+		InputTokens:  result.Usage.PromptTokens,
+		OutputTokens: result.Usage.CompletionTokens,
 	}, nil
 }
 
 // runAnthropic handles Anthropic's Messages API.
 func (r *APIRunner) runAnthropic(ctx context.Context, req Request, start time.Time) (*Response, error) {
-	body := map[string]any{
-		"model":      r.cfg.Model,
-		"max_tokens": 4096,
-		"messages": []map[string]string{
-			{"role": "user", "content": req.Prompt},
-		},
+	msgs, images := r.prepare(req)
+	body := anthropicBody(r.cfg.Model, msgs, images)
+	if req.Format != "" {
+		// Anthropic has no JSON mode; a tool call it must make is one.
+		tool := "submit_" + string(req.Format)
+		body["tools"] = []map[string]any{{"name": tool, "description": "Submit your " + string(req.Format), "input_schema": schemaFor(req.Format)}}
+		body["tool_choice"] = map[string]string{"type": "tool", "name": tool}
+	}
+	if req.Stream != nil {
+		body["stream"] = true
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -159,6 +399,10 @@ func (r *APIRunner) runAnthropic(ctx context.Context, req Request, start time.Ti
 		}, nil
 	}
 	defer httpResp.Body.Close()
+	r.limits.observe(httpResp.Header, httpResp.StatusCode)
+	if req.Stream != nil && httpResp.StatusCode == http.StatusOK {
+		return readAnthropicStream(httpResp.Body, req.Stream, start)
+	}
 
 	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
@@ -177,22 +421,38 @@ func (r *APIRunner) runAnthropic(ctx context.Context, req Request, start time.Ti
 	// Parse Anthropic response.
 	var result struct {
 		Content []struct {
-			Text string `json:"text"`
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Input json.RawMessage `json:"input"` // Of a tool_use block
 		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, fmt.Errorf("parse response: %w", err)
 	}
 
 	output := ""
-	if len(result.Content) > 0 {
-		output = result.Content[0].Text
+	for _, c := range result.Content {
+		if c.Type == "tool_use" {
+			output = string(c.Input)
+			break
+		}
+		if output == "" {
+			output = c.Text
+		}
 	}
 
 	return &Response{
-		Output:   output,
-		ExitCode: 0,
-		Duration: time.Since(start).Seconds(),
+		Output:       output,
+		Truncated:    result.StopReason == "max_tokens",
+		ExitCode:     0,
+		Duration:     time.Since(start).Seconds(),
+		InputTokens:  result.Usage.InputTokens,
+		OutputTokens: result.Usage.OutputTokens,
 	}, nil
 }
 
@@ -204,15 +464,17 @@ func (r *APIRunner) runGoogle(ctx context.Context, req Request, start time.Time)
 	}
 
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, r.apiKey)
+	if req.Stream != nil {
+		url = fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", model, r.apiKey)
+	}
 
-	body := map[string]any{
-		"contents": []map[string]any{
-			{
-				"parts": []map[string]string{
-					{"text": req.Prompt},
-				},
-			},
-		},
+	msgs, images := r.prepare(req)
+	body := googleBody(msgs, images)
+	if req.Format != "" {
+		body["generationConfig"] = map[string]any{
+			"responseMimeType": "application/json",
+			"responseSchema":   googleSchema(schemaFor(req.Format)),
+		}
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -235,6 +497,10 @@ func (r *APIRunner) runGoogle(ctx context.Context, req Request, start time.Time)
 		}, nil
 	}
 	defer httpResp.Body.Close()
+	r.limits.observe(httpResp.Header, httpResp.StatusCode)
+	if req.Stream != nil && httpResp.StatusCode == http.StatusOK {
+		return readGoogleStream(httpResp.Body, req.Stream, start)
+	}
 
 	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
@@ -258,20 +524,31 @@ func (r *APIRunner) runGoogle(ctx context.Context, req Request, start time.Time)
 					Text string `json:"text"`
 				} `json:"parts"`
 			} `json:"content"`
+			FinishReason string `json:"finishReason"`
 		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, fmt.Errorf("parse response: %w", err)
 	}
 
-	output := ""
-	if len(result.Candidates) > 0 && len(result.Candidates[0].Content.Parts) > 0 {
-		output = result.Candidates[0].Content.Parts[0].Text
+	output, truncated := "", false
+	if len(result.Candidates) > 0 {
+		if len(result.Candidates[0].Content.Parts) > 0 {
+			output = result.Candidates[0].Content.Parts[0].Text
+		}
+		truncated = result.Candidates[0].FinishReason == "MAX_TOKENS"
 	}
 
 	return &Response{
-		Output:   output,
-		ExitCode: 0,
-		Duration: time.Since(start).Seconds(),
+		Output:       output,
+		Truncated:    truncated,
+		ExitCode:     0,
+		Duration:     time.Since(start).Seconds(),
+		InputTokens:  result.UsageMetadata.PromptTokenCount,
+		OutputTokens: result.UsageMetadata.CandidatesTokenCount,
 	}, nil
 }
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestAPIRunnerContinuesTruncatedAnswer(t *testing.T) {
+	var requests []map[string]any
+	answers := []string{
+		`{"choices":[{"message":{"content":"SUBTASKS:\n- [high] one"},"finish_reason":"length"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`,
+		`{"choices":[{"message":{"content":"\n- [low] two"},"finish_reason":"stop"}],"usage":{"prompt_tokens":20,"completion_tokens":3}}`,
+	}
+	r := &APIRunner{
+		name:   "gpt",
+		cfg:    config.Agent{Provider: "openai", Model: "gpt-4o"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var body map[string]any
+			json.NewDecoder(req.Body).Decode(&body)
+			requests = append(requests, body)
+			return jsonResponse(answers[len(requests)-1]), nil
+		})},
+	}
+
+	resp, err := r.Run(context.Background(), Request{Prompt: "Plan it"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if resp.Output != "SUBTASKS:\n- [high] one\n- [low] two" {
+		t.Errorf("output not stitched: %q", resp.Output)
+	}
+	if resp.Truncated || resp.Continuations != 1 {
+		t.Errorf("truncated=%v continuations=%d", resp.Truncated, resp.Continuations)
+	}
+	if resp.InputTokens != 30 || resp.OutputTokens != 8 {
+		t.Errorf("tokens not summed: %d in, %d out", resp.InputTokens, resp.OutputTokens)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	msgs := requests[1]["messages"].([]any)
+	if len(msgs) != 3 {
+		t.Fatalf("continuation should send prompt, partial answer and follow-up, got %d messages", len(msgs))
+	}
+	partial := msgs[1].(map[string]any)
+	if partial["role"] != RoleAssistant || partial["content"] != "SUBTASKS:\n- [high] one" {
+		t.Errorf("unexpected partial turn: %v", partial)
+	}
+}
+
+func TestAPIRunnerGivesUpAfterMaxContinuations(t *testing.T) {
+	calls := 0
+	r := &APIRunner{
+		cfg:    config.Agent{Provider: "anthropic", Model: "claude"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return jsonResponse(`{"content":[{"text":"x"}],"stop_reason":"max_tokens"}`), nil
+		})},
+	}
+	resp, err := r.Run(context.Background(), Request{Prompt: "Spec it"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if calls != maxContinuations+1 || !resp.Truncated || resp.Output != strings.Repeat("x", maxContinuations+1) {
+		t.Errorf("calls=%d truncated=%v output=%q", calls, resp.Truncated, resp.Output)
+	}
+}
+
+func TestAPIRunnerStructuredReview(t *testing.T) {
+	var body map[string]any
+	r := &APIRunner{
+		name:   "gpt",
+		cfg:    config.Agent{Provider: "openai", Model: "gpt-4o"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			json.NewDecoder(req.Body).Decode(&body)
+			return jsonResponse(`{"choices":[{"message":{"content":"{\"verdict\":\"APPROVE\",\"comments\":[\"Nice\"],\"checklist\":[],\"acceptance\":[],\"suggestions\":[]}"},"finish_reason":"stop"}]}`), nil
+		})},
+	}
+	var streamed []string
+	resp, err := r.Run(context.Background(), Request{Prompt: "Review it", Format: FormatReview, Stream: func(l string) { streamed = append(streamed, l) }})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !resp.Structured || ParseReview(resp.Output).Verdict != "APPROVE" {
+		t.Errorf("structured=%v output=%q", resp.Structured, resp.Output)
+	}
+	if body["stream"] != nil {
+		t.Error("a structured answer isn't streamed from the API")
+	}
+	format, _ := body["response_format"].(map[string]any)
+	if format["type"] != "json_schema" {
+		t.Errorf("response_format: %v", body["response_format"])
+	}
+	if len(streamed) == 0 || streamed[len(streamed)-1] != "VERDICT: APPROVE" {
+		t.Errorf("rendered answer not streamed: %q", streamed)
+	}
+}
+
+func TestAPIRunnerStructuredFallsBackToFreeForm(t *testing.T) {
+	var requests []map[string]any
+	answers := []string{
+		`{"choices":[{"message":{"content":"Looks fine to me"},"finish_reason":"stop"}]}`,
+		`{"choices":[{"message":{"content":"VERDICT: APPROVE"},"finish_reason":"stop"}]}`,
+	}
+	r := &APIRunner{
+		name:   "gpt",
+		cfg:    config.Agent{Provider: "openai", Model: "gpt-4o"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var body map[string]any
+			json.NewDecoder(req.Body).Decode(&body)
+			requests = append(requests, body)
+			return jsonResponse(answers[len(requests)-1]), nil
+		})},
+	}
+	resp, err := r.Run(context.Background(), Request{Prompt: "Review it", Format: FormatReview})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(requests) != 2 || requests[1]["response_format"] != nil {
+		t.Fatalf("expected a free-form retry, got %d request(s)", len(requests))
+	}
+	if resp.Structured || resp.Output != "VERDICT: APPROVE" {
+		t.Errorf("structured=%v output=%q", resp.Structured, resp.Output)
+	}
+
+	// Turned off, the schema isn't asked for at all.
+	off := false
+	r.cfg.Structured = &off
+	requests, answers = nil, answers[1:]
+	r.Run(context.Background(), Request{Prompt: "Review it", Format: FormatReview})
+	if len(requests) != 1 || requests[0]["response_format"] != nil {
+		t.Errorf("structured: false still asked for JSON: %v", requests)
+	}
+}
+
+func TestAPIRunnerStructuredAnthropicTool(t *testing.T) {
+	var body map[string]any
+	r := &APIRunner{
+		name:   "claude",
+		cfg:    config.Agent{Provider: "anthropic", Model: "claude-sonnet-4-5"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			json.NewDecoder(req.Body).Decode(&body)
+			return jsonResponse(`{"content":[{"type":"tool_use","name":"submit_plan","input":{"subtasks":[{"title":"Add login","description":"POST /login","priority":"high","acceptance":["Returns a token"]}],"blocked":""}}],"stop_reason":"tool_use"}`), nil
+		})},
+	}
+	resp, err := r.Run(context.Background(), Request{Prompt: "Plan it", Format: FormatPlan})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if choice, _ := body["tool_choice"].(map[string]any); choice["name"] != "submit_plan" {
+		t.Errorf("tool_choice: %v", body["tool_choice"])
+	}
+	subs := ParseSubtasks(resp.Output)
+	if !resp.Structured || len(subs) != 1 || subs[0].Title != "Add login" {
+		t.Errorf("structured=%v output=%q", resp.Structured, resp.Output)
+	}
+}
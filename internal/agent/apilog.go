@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/redact"
+)
+
+// API log levels, as in logging.api.
+const (
+	APILogNone     = "none"
+	APILogMetadata = "metadata"
+	APILogFull     = "full"
+)
+
+// APILogger records the HTTP calls of api-mode agents under a directory:
+// a line per call in calls.jsonl and, at the full level, a file per call
+// holding the request and response. Credentials never reach the log:
+// auth headers and the key query parameter are dropped, and everything
+// else passes through redact.String.
+type APILogger struct {
+	dir   string
+	level string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewAPILogger returns a logger writing to dir at level, or nil when the
+// level is none or empty.
+func NewAPILogger(dir, level string) *APILogger {
+	if level == "" || level == APILogNone {
+		return nil
+	}
+	return &APILogger{dir: dir, level: level}
+}
+
+var apiLog atomic.Pointer[APILogger]
+
+// SetAPILog logs every API call with l from now on, or stops logging if l
+// is nil.
+func SetAPILog(l *APILogger) { apiLog.Store(l) }
+
+// APICall is one logged API call: a line of calls.jsonl.
+type APICall struct {
+	Time       time.Time `json:"time"`
+	Agent      string    `json:"agent"`
+	Provider   string    `json:"provider"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Status     int       `json:"status,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	ReqBytes   int       `json:"request_bytes"`
+	RespBytes  int       `json:"response_bytes"`
+	Error      string    `json:"error,omitempty"`
+	File       string    `json:"file,omitempty"` // The full exchange, at the full level
+}
+
+// apiExchange is what the full level saves of a call.
+type apiExchange struct {
+	APICall
+	RequestHeaders  map[string]string `json:"request_headers"`
+	RequestBody     string            `json:"request_body"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body"`
+}
+
+// secretHeaders carry credentials and are never logged.
+var secretHeaders = map[string]bool{
+	"authorization":  true,
+	"x-api-key":      true,
+	"x-goog-api-key": true,
+	"cookie":         true,
+	"set-cookie":     true,
+}
+
+// apiLogTransport logs the calls of one agent when an API log is set. A
+// response is logged once its body is read and closed, so streamed
+// answers are logged whole.
+type apiLogTransport struct {
+	agent    string
+	provider string
+	base     http.RoundTripper
+}
+
+func (t *apiLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	l := apiLog.Load()
+	if l == nil {
+		return base.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+	ex := &apiExchange{
+		APICall: APICall{
+			Time:     time.Now().UTC(),
+			Agent:    t.agent,
+			Provider: t.provider,
+			Method:   req.Method,
+			URL:      logURL(req),
+			ReqBytes: len(reqBody),
+		},
+		RequestHeaders: logHeaders(req.Header),
+		RequestBody:    string(reqBody),
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		ex.DurationMS = time.Since(ex.Time).Milliseconds()
+		ex.Error = err.Error()
+		l.write(ex)
+		return resp, err
+	}
+	ex.Status = resp.StatusCode
+	ex.ResponseHeaders = logHeaders(resp.Header)
+	resp.Body = &loggedBody{ReadCloser: resp.Body, logger: l, ex: ex}
+	return resp, nil
+}
+
+// loggedBody copies a response body as it is read and logs the call when
+// it is closed.
+type loggedBody struct {
+	io.ReadCloser
+	logger *APILogger
+	ex     *apiExchange
+	buf    bytes.Buffer
+	once   sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.ex.DurationMS = time.Since(b.ex.Time).Milliseconds()
+		b.ex.RespBytes = b.buf.Len()
+		b.ex.ResponseBody = b.buf.String()
+		b.logger.write(b.ex)
+	})
+	return err
+}
+
+// write logs a call. Logging is for debugging: a call whose log can't be
+// written still goes through.
+func (l *APILogger) write(ex *apiExchange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return
+	}
+	l.seq++
+	if l.level == APILogFull {
+		ex.RequestBody = redact.String(ex.RequestBody)
+		ex.ResponseBody = redact.String(ex.ResponseBody)
+		data, err := json.MarshalIndent(ex, "", "  ")
+		if err == nil && disk.Check(int64(len(data))) == nil {
+			name := fmt.Sprintf("%s-%03d-%s.json", ex.Time.Format("20060102-150405"), l.seq, safeName(ex.Agent))
+			if os.WriteFile(filepath.Join(l.dir, name), data, 0600) == nil {
+				disk.Wrote(int64(len(data)))
+				ex.File = name
+			}
+		}
+	}
+	line, err := json.Marshal(ex.APICall)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(l.dir, "calls.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append([]byte(redact.String(string(line))), '\n'))
+}
+
+// logURL is the request's URL without the key query parameter, which
+// holds Google's API key.
+func logURL(req *http.Request) string {
+	u := *req.URL
+	q := u.Query()
+	q.Del("key")
+	u.RawQuery = q.Encode()
+	return redact.String(u.String())
+}
+
+// logHeaders flattens headers for the log, without the credentials.
+func logHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if secretHeaders[strings.ToLower(k)] {
+			continue
+		}
+		out[k] = redact.String(strings.Join(v, ", "))
+	}
+	return out
+}
+
+// safeName keeps an agent name usable in a file name.
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' || r == ':' {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func logCall(t *testing.T, level string) (dir string, calls []APICall) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc")
+		io.WriteString(w, `{"choices":[{"message":{"content":"hi"}}]}`)
+	}))
+	defer srv.Close()
+
+	dir = t.TempDir()
+	SetAPILog(NewAPILogger(dir, level))
+	defer SetAPILog(nil)
+
+	client := &http.Client{Transport: &apiLogTransport{agent: "gpt", provider: "openai"}}
+	req, _ := http.NewRequest("POST", srv.URL+"/v1?alt=sse&key=AIzaSECRET", bytes.NewReader([]byte(`{"prompt":"fix it"}`)))
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "calls.jsonl"))
+	if err != nil {
+		t.Fatalf("read calls.jsonl: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var c APICall
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			t.Fatalf("parse %q: %v", line, err)
+		}
+		calls = append(calls, c)
+	}
+	return dir, calls
+}
+
+func TestAPILog_Metadata(t *testing.T) {
+	dir, calls := logCall(t, APILogMetadata)
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(calls))
+	}
+	c := calls[0]
+	if c.Agent != "gpt" || c.Provider != "openai" || c.Status != 200 || c.ReqBytes == 0 || c.RespBytes == 0 {
+		t.Errorf("unexpected call %+v", c)
+	}
+	if strings.Contains(c.URL, "AIzaSECRET") || !strings.Contains(c.URL, "alt=sse") {
+		t.Errorf("expected the key dropped from the URL, got %s", c.URL)
+	}
+	if c.File != "" {
+		t.Errorf("metadata should not save the exchange, got %s", c.File)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only calls.jsonl, got %d files", len(entries))
+	}
+}
+
+func TestAPILog_FullDropsCredentials(t *testing.T) {
+	dir, calls := logCall(t, APILogFull)
+	if len(calls) != 1 || calls[0].File == "" {
+		t.Fatalf("expected one call with its exchange saved, got %+v", calls)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, calls[0].File))
+	if err != nil {
+		t.Fatalf("read exchange: %v", err)
+	}
+	s := string(data)
+	for _, want := range []string{"fix it", `\"content\":\"hi\"`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected %q in the exchange:\n%s", want, s)
+		}
+	}
+	for _, secret := range []string{"sk-secret", "AIzaSECRET", "session=abc"} {
+		if strings.Contains(s, secret) {
+			t.Errorf("exchange leaks %q:\n%s", secret, s)
+		}
+	}
+}
+
+func TestAPILog_None(t *testing.T) {
+	if NewAPILogger(t.TempDir(), APILogNone) != nil || NewAPILogger(t.TempDir(), "") != nil {
+		t.Error("expected no logger for none")
+	}
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+// Call is one finished agent call: how long it took and what it cost.
+// hive auto estimates a run from the calls before it.
+type Call struct {
+	TaskID       int64
+	Agent        string
+	Role         string
+	Mode         string
+	Duration     float64 // Seconds
+	InputTokens  int
+	OutputTokens int
+	Cost         float64 // USD, from the agent's pricing; 0 without usage
+	Failed       bool
+	At           time.Time
+}
+
+// CallHook is called after every agent call that reached an agent.
+type CallHook func(Call)
+
+var (
+	callMu   sync.Mutex
+	callHook CallHook
+)
+
+// SetCallHook installs fn to be told about each finished call, e.g. to
+// keep per-role latency for run estimates.
+func SetCallHook(fn CallHook) {
+	callMu.Lock()
+	callHook = fn
+	callMu.Unlock()
+}
+
+// reportCall passes c to the call hook. Replayed and fake calls say
+// nothing about how long real agents take, so they are left out.
+func reportCall(c Call) {
+	if c.Mode == "fake" || Replaying() {
+		return
+	}
+	callMu.Lock()
+	fn := callHook
+	callMu.Unlock()
+	if fn != nil {
+		fn(c)
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestCallHook(t *testing.T) {
+	var calls []Call
+	SetCallHook(func(c Call) { calls = append(calls, c) })
+	defer SetCallHook(nil)
+
+	for _, cfg := range []config.Agent{
+		{Mode: "cli", Cmd: "true", Role: "coder"},
+		{Mode: "fake", Role: "reviewer"},
+	} {
+		r, err := NewRunner("a", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Run(context.Background(), Request{TaskID: 3, Prompt: "x", WorkDir: t.TempDir()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want only the cli one: %+v", len(calls), calls)
+	}
+	if c := calls[0]; c.TaskID != 3 || c.Role != "coder" || c.Mode != "cli" || c.Failed || c.At.IsZero() {
+		t.Errorf("unexpected call: %+v", c)
+	}
+}
@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/redact"
+)
+
+// Interaction is one recorded agent call: the request as the agent got it
+// (secrets already masked) and what came back. Patch holds the edits a
+// CLI agent made to the work dir during the call.
+type Interaction struct {
+	Seq           int       `json:"seq"`
+	Time          time.Time `json:"time"`
+	Agent         string    `json:"agent"`
+	Role          string    `json:"role"`
+	Mode          string    `json:"mode"`
+	TaskID        int64     `json:"task_id"`
+	Prompt        string    `json:"prompt"`
+	Messages      []Message `json:"messages,omitempty"`
+	Output        string    `json:"output"`
+	ExitCode      int       `json:"exit_code"`
+	Duration      float64   `json:"duration"`
+	Error         string    `json:"error,omitempty"`          // Run failed
+	ResponseError string    `json:"response_error,omitempty"` // Run returned a response with an error
+	InputTokens   int       `json:"input_tokens,omitempty"`
+	OutputTokens  int       `json:"output_tokens,omitempty"`
+	Truncated     bool      `json:"truncated,omitempty"`
+	Continuations int       `json:"continuations,omitempty"`
+	Patch         string    `json:"patch,omitempty"`
+}
+
+// Recorder appends every agent call to a cassette file, one JSON
+// interaction per line. The file is created on the first call, so a
+// command that runs no agents leaves none behind.
+type Recorder struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+	seq  int
+}
+
+// NewRecorder returns a recorder writing to the cassette file at path.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Path returns the cassette file.
+func (r *Recorder) Path() string { return r.path }
+
+// Close closes the cassette file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
+
+func (r *Recorder) record(in Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+			return fmt.Errorf("create cassette: %w", err)
+		}
+		f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return fmt.Errorf("create cassette: %w", err)
+		}
+		r.f = f
+	}
+	r.seq++
+	in.Seq = r.seq
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	_, err = r.f.Write(append(data, '\n'))
+	return err
+}
+
+// Cassette is a recorded run being replayed. The n-th call for a role on
+// a task gets the n-th recorded answer for that role and task, so replay
+// is deterministic even when tasks ran in parallel.
+type Cassette struct {
+	mu    sync.Mutex
+	path  string
+	calls map[string][]Interaction
+	next  map[string]int
+}
+
+// LoadCassette reads a cassette written by a Recorder.
+func LoadCassette(path string) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open cassette: %w", err)
+	}
+	defer f.Close()
+
+	c := &Cassette{path: path, calls: map[string][]Interaction{}, next: map[string]int{}}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 256<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var in Interaction
+		if err := json.Unmarshal(sc.Bytes(), &in); err != nil {
+			return nil, fmt.Errorf("cassette %s line %d: %w", path, line, err)
+		}
+		k := cassetteKey(in.Role, in.TaskID)
+		c.calls[k] = append(c.calls[k], in)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	return c, nil
+}
+
+func cassetteKey(role string, taskID int64) string {
+	return fmt.Sprintf("%s/%d", role, taskID)
+}
+
+// take returns the next recorded call for role on a task.
+func (c *Cassette) take(role string, taskID int64) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := cassetteKey(role, taskID)
+	n := c.next[k]
+	if n >= len(c.calls[k]) {
+		return Interaction{}, fmt.Errorf("cassette %s has no call %d for the %s on task #%d; the run diverged from the recording", c.path, n+1, role, taskID)
+	}
+	c.next[k] = n + 1
+	return c.calls[k][n], nil
+}
+
+var (
+	cassetteMu sync.Mutex
+	recorder   *Recorder
+	replay     *Cassette
+)
+
+// SetRecorder records every agent call to r, or stops recording if r is
+// nil.
+func SetRecorder(r *Recorder) {
+	cassetteMu.Lock()
+	recorder = r
+	cassetteMu.Unlock()
+}
+
+// SetReplay answers every agent call from c instead of running the agent,
+// or stops replaying if c is nil.
+func SetReplay(c *Cassette) {
+	cassetteMu.Lock()
+	replay = c
+	cassetteMu.Unlock()
+}
+
+// Replaying reports whether agent calls are answered from a cassette.
+// Nothing reaches an API then, so offline mode allows api-mode agents.
+func Replaying() bool {
+	cassetteMu.Lock()
+	defer cassetteMu.Unlock()
+	return replay != nil
+}
+
+func currentRecorder() *Recorder {
+	cassetteMu.Lock()
+	defer cassetteMu.Unlock()
+	return recorder
+}
+
+// replayRunner stands in for an agent while replaying a cassette. It
+// returns the recorded answer and re-applies the recorded edits.
+type replayRunner struct {
+	name     string
+	cfg      config.Agent
+	cassette *Cassette
+}
+
+func (r *replayRunner) Name() string { return r.name }
+func (r *replayRunner) Mode() string { return r.cfg.Mode }
+
+func (r *replayRunner) Run(ctx context.Context, req Request) (*Response, error) {
+	in, err := r.cassette.take(r.cfg.Role, req.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Error != "" {
+		return nil, errors.New(in.Error)
+	}
+	if in.Patch != "" {
+		if err := git.New(req.WorkDir).WithContext(ctx).ApplyPatch(in.Patch, false); err != nil {
+			return nil, fmt.Errorf("replay call %d: %w", in.Seq, err)
+		}
+	}
+	streamAll(req.Stream, in.Output)
+	resp := &Response{
+		Output:        in.Output,
+		ExitCode:      in.ExitCode,
+		Duration:      in.Duration,
+		InputTokens:   in.InputTokens,
+		OutputTokens:  in.OutputTokens,
+		Truncated:     in.Truncated,
+		Continuations: in.Continuations,
+	}
+	if in.ResponseError != "" {
+		resp.Error = errors.New(in.ResponseError)
+	}
+	return resp, nil
+}
+
+// runRecorded runs the agent and appends the call to the recorder. For
+// agents that edit files, the work dir is snapshotted around the call so
+// the edits can be replayed.
+func runRecorded(ctx context.Context, rec *Recorder, r Runner, cfg config.Agent, req Request) (*Response, error) {
+	var repo *git.Safety
+	var before string
+	if cfg.Mode != "api" && req.WorkDir != "" {
+		if g := git.New(req.WorkDir); g.IsGitRepo() {
+			if tree, err := g.Snapshot(); err == nil {
+				repo, before = g, tree
+			}
+		}
+	}
+
+	resp, err := r.Run(ctx, req)
+
+	in := Interaction{
+		Time:     time.Now().UTC(),
+		Agent:    r.Name(),
+		Role:     cfg.Role,
+		Mode:     r.Mode(),
+		TaskID:   req.TaskID,
+		Prompt:   req.Prompt,
+		Messages: req.Messages,
+	}
+	if err != nil {
+		in.Error = err.Error()
+	}
+	if resp != nil {
+		in.Output = redact.String(resp.Output)
+		in.ExitCode = resp.ExitCode
+		in.Duration = resp.Duration
+		in.InputTokens = resp.InputTokens
+		in.OutputTokens = resp.OutputTokens
+		in.Truncated = resp.Truncated
+		in.Continuations = resp.Continuations
+		if resp.Error != nil {
+			in.ResponseError = resp.Error.Error()
+		}
+	}
+	if repo != nil {
+		if after, err := repo.Snapshot(); err == nil {
+			in.Patch, _ = repo.SnapshotDiff(before, after)
+		}
+	}
+	rec.record(in)
+	return resp, err
+}
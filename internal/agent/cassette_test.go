@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func initCassetteRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"-c", "user.email=t@t", "-c", "user.name=t", "commit", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	return dir
+}
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	t.Cleanup(func() { SetRecorder(nil); SetReplay(nil) })
+	cfg := config.Agent{Role: "coder", Mode: "fake", Fixtures: t.TempDir()}
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+
+	// Record two calls of a fake coder that edits files.
+	rec := NewRecorder(path)
+	SetRecorder(rec)
+	work := initCassetteRepo(t)
+	r, _ := NewRunner("coder", cfg)
+	var recorded []string
+	for range 2 {
+		resp, err := r.Run(context.Background(), Request{TaskID: 4, Prompt: "do it", WorkDir: work})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded = append(recorded, resp.Output)
+	}
+	SetRecorder(nil)
+	rec.Close()
+
+	// Replay them in a fresh repo: same answers, same edits.
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetReplay(c)
+	replayWork := initCassetteRepo(t)
+	r, _ = NewRunner("coder", config.Agent{Role: "coder", Mode: "api", Provider: "openai"})
+	for i := range 2 {
+		resp, err := r.Run(context.Background(), Request{TaskID: 4, Prompt: "do it", WorkDir: replayWork})
+		if err != nil {
+			t.Fatalf("replay %d: %v", i+1, err)
+		}
+		if resp.Output != recorded[i] {
+			t.Errorf("replay %d: got %q, want %q", i+1, resp.Output, recorded[i])
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(replayWork, "fake", "task-4.txt")); string(data) != "change 2\n" {
+		t.Errorf("edits not replayed: %q", data)
+	}
+
+	// The run asks for more than was recorded.
+	if _, err := r.Run(context.Background(), Request{TaskID: 4, WorkDir: replayWork}); err == nil || !strings.Contains(err.Error(), "diverged") {
+		t.Errorf("expected a divergence error, got %v", err)
+	}
+}
+
+func TestRecorder_NoCallsNoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "empty.jsonl")
+	NewRecorder(path).Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no cassette file, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/imkarma/hive/internal/chaos"
+)
+
+// chaosExit ends the process for an injected crash; tests replace it.
+var chaosExit = os.Exit
+
+// garbageOutput is what an agent answers under agent_garbage: no plan,
+// verdict, spec or files, and no JSON either.
+const garbageOutput = "chaos: ~~~ \x00 garbled output, no sections here ~~~"
+
+// injectFault returns the response of an agent call that chaos makes
+// fail, without running the agent, or nil to run it.
+func injectFault(name string) *Response {
+	switch {
+	case chaos.Hit(chaos.AgentCrash):
+		fmt.Fprintf(os.Stderr, "chaos: injected %s before %s ran; exiting\n", chaos.AgentCrash, name)
+		chaosExit(137)
+		return nil
+	case chaos.Hit(chaos.AgentTimeout):
+		return &Response{ExitCode: -1, Error: chaos.Error(chaos.AgentTimeout, "agent "+name+" timed out")}
+	case chaos.Hit(chaos.AgentError):
+		return &Response{ExitCode: 1, Error: chaos.Error(chaos.AgentError, "agent "+name+" exited with status 1")}
+	case chaos.Hit(chaos.AgentGarbage):
+		return &Response{Output: garbageOutput}
+	}
+	return nil
+}
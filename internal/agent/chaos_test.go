@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/chaos"
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestInjectFault(t *testing.T) {
+	defer chaos.Init(nil, 0)
+	r, err := NewRunner("c", config.Agent{Role: "coder", Mode: "fake"})
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	work := t.TempDir()
+
+	chaos.Init(chaos.Config{chaos.AgentTimeout: 1}, 1)
+	resp, err := r.Run(context.Background(), Request{TaskID: 1, WorkDir: work})
+	if err != nil || resp.ExitCode != -1 || resp.Error == nil || !strings.Contains(resp.Error.Error(), "agent_timeout") {
+		t.Errorf("expected an injected timeout, got %+v, %v", resp, err)
+	}
+
+	chaos.Init(chaos.Config{chaos.AgentGarbage: 1}, 1)
+	resp, _ = r.Run(context.Background(), Request{TaskID: 1, WorkDir: work})
+	if len(ParseFilesChanged(resp.Output)) != 0 || resp.ExitCode != 0 {
+		t.Errorf("expected garbage that parses to nothing, got %q", resp.Output)
+	}
+
+	exited := 0
+	chaosExit = func(code int) { exited = code }
+	defer func() { chaosExit = os.Exit }()
+	chaos.Init(chaos.Config{chaos.AgentCrash: 1}, 1)
+	r.Run(context.Background(), Request{TaskID: 1, WorkDir: work})
+	if exited != 137 {
+		t.Errorf("expected an exit with 137, got %d", exited)
+	}
+
+	chaos.Init(nil, 0)
+	resp, _ = r.Run(context.Background(), Request{TaskID: 1, WorkDir: work})
+	if resp.ExitCode != 0 || len(ParseFilesChanged(resp.Output)) != 1 {
+		t.Errorf("expected the agent to run with chaos off, got %+v", resp)
+	}
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+// ParseReviewWithChecklist is ParseReview for a project with a review
+// checklist. The reviewer's CHECKLIST: block is matched against the
+// configured items; a required item that is unanswered or FAIL forces a
+// REJECT whatever the free-form verdict says, and is added to the
+// comments so the coder knows what to fix.
+//
+//	CHECKLIST:
+//	- tests added: PASS — login_test.go covers the new path
+//	- docs updated: N/A — internal change
+//	- no TODOs left: FAIL — TODO in auth.go:42
+func ParseReviewWithChecklist(output string, checklist []config.ChecklistItem) ParsedReview {
+	review := ParseReview(output)
+	if len(checklist) == 0 {
+		return review
+	}
+
+	review.Checklist = ParseChecklist(output, checklist)
+	var failed []string
+	for _, a := range review.Checklist {
+		if !a.Required {
+			continue
+		}
+		switch a.Answer {
+		case "":
+			failed = append(failed, fmt.Sprintf("Checklist: %q was not answered", a.Item))
+		case "FAIL":
+			msg := fmt.Sprintf("Checklist: %q failed", a.Item)
+			if a.Note != "" {
+				msg += " — " + a.Note
+			}
+			failed = append(failed, msg)
+		}
+	}
+	if len(failed) > 0 {
+		review.Verdict = "REJECT"
+		review.Comments = append(failed, review.Comments...)
+	}
+	return review
+}
+
+// ParseChecklist returns the reviewer's answer to each checklist item, in
+// checklist order. Entries are matched to items by their text, ignoring
+// case, numbering and markdown.
+func ParseChecklist(output string, checklist []config.ChecklistItem) []ChecklistAnswer {
+	_, entries := listSection(output, "CHECKLIST:")
+
+	answers := make([]ChecklistAnswer, len(checklist))
+	for i, item := range checklist {
+		answers[i] = ChecklistAnswer{Item: item.Item, Required: item.IsRequired()}
+		want := strings.ToLower(strings.TrimSpace(item.Item))
+		for _, e := range entries {
+			e = strings.ReplaceAll(e, "**", "")
+			e = strings.TrimLeft(e, "0123456789.) ")
+			idx := strings.Index(strings.ToLower(e), want)
+			if idx < 0 {
+				continue
+			}
+			answer, note := checklistAnswer(e[idx+len(want):])
+			if answer != "" {
+				answers[i].Answer, answers[i].Note = answer, note
+				break
+			}
+		}
+	}
+	return answers
+}
+
+// checklistAnswer parses the text after an item, e.g. ": PASS — reason"
+// or " [FAIL] reason".
+func checklistAnswer(rest string) (answer, note string) {
+	rest = strings.TrimLeft(rest, " :-—–[]`*")
+	upper := strings.ToUpper(rest)
+	for _, a := range []struct{ prefix, answer string }{
+		{"PASSED", "PASS"}, {"PASS", "PASS"}, {"YES", "PASS"}, {"OK", "PASS"}, {"✓", "PASS"},
+		{"FAILED", "FAIL"}, {"FAIL", "FAIL"}, {"NO", "FAIL"}, {"✗", "FAIL"},
+		{"N/A", "N/A"}, {"NA", "N/A"},
+	} {
+		if !strings.HasPrefix(upper, a.prefix) {
+			continue
+		}
+		tail := rest[len(a.prefix):]
+		// "NOT ..." or "NONE" aren't answers.
+		if tail != "" && isWordChar(tail[0]) {
+			continue
+		}
+		return a.answer, strings.TrimSpace(strings.TrimLeft(tail, " :-—–[]()`*"))
+	}
+	return "", ""
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
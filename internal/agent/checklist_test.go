@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func checklistItems(items ...string) []config.ChecklistItem {
+	out := make([]config.ChecklistItem, len(items))
+	for i, item := range items {
+		out[i] = config.ChecklistItem{Item: item}
+	}
+	return out
+}
+
+func TestParseReviewWithChecklist_AllPass(t *testing.T) {
+	output := `Looks solid.
+
+CHECKLIST:
+- Tests added: PASS — login_test.go
+- **docs updated**: N/A (internal change)
+- no TODOs left: ✓
+
+VERDICT: APPROVE`
+
+	r := ParseReviewWithChecklist(output, checklistItems("tests added", "docs updated", "no TODOs left"))
+	if r.Verdict != "APPROVE" {
+		t.Fatalf("expected APPROVE, got %s (comments %v)", r.Verdict, r.Comments)
+	}
+	want := []string{"PASS", "N/A", "PASS"}
+	for i, a := range r.Checklist {
+		if a.Answer != want[i] {
+			t.Errorf("%s: expected %s, got %q", a.Item, want[i], a.Answer)
+		}
+	}
+	if r.Checklist[0].Note != "login_test.go" {
+		t.Errorf("expected the note to be kept, got %q", r.Checklist[0].Note)
+	}
+}
+
+func TestParseReviewWithChecklist_FailForcesReject(t *testing.T) {
+	output := `CHECKLIST:
+- tests added: PASS
+- no TODOs left: FAIL — TODO in auth.go:42
+
+VERDICT: APPROVE`
+
+	r := ParseReviewWithChecklist(output, checklistItems("tests added", "no TODOs left"))
+	if r.Verdict != "REJECT" {
+		t.Fatalf("a failed required item should force REJECT, got %s", r.Verdict)
+	}
+	if len(r.Comments) == 0 || !strings.Contains(r.Comments[0], "TODO in auth.go:42") {
+		t.Errorf("expected the failed item first in comments, got %v", r.Comments)
+	}
+}
+
+func TestParseReviewWithChecklist_UnansweredForcesReject(t *testing.T) {
+	output := `CHECKLIST:
+- tests added: PASS
+
+VERDICT: APPROVE`
+
+	r := ParseReviewWithChecklist(output, checklistItems("tests added", "docs updated"))
+	if r.Verdict != "REJECT" {
+		t.Fatalf("an unanswered required item should force REJECT, got %s", r.Verdict)
+	}
+	if !strings.Contains(r.Comments[0], `"docs updated" was not answered`) {
+		t.Errorf("unexpected comments %v", r.Comments)
+	}
+}
+
+func TestParseReviewWithChecklist_OptionalItem(t *testing.T) {
+	optional := false
+	checklist := []config.ChecklistItem{
+		{Item: "tests added"},
+		{Item: "changelog entry", Required: &optional},
+	}
+	output := `CHECKLIST:
+- tests added: yes
+- changelog entry: no
+
+VERDICT: APPROVE`
+
+	r := ParseReviewWithChecklist(output, checklist)
+	if r.Verdict != "APPROVE" {
+		t.Errorf("a failed optional item should not force REJECT, got %s", r.Verdict)
+	}
+	if r.Checklist[1].Answer != "FAIL" {
+		t.Errorf("expected the optional item to be recorded as FAIL, got %q", r.Checklist[1].Answer)
+	}
+}
+
+func TestParseReviewWithChecklist_NoChecklist(t *testing.T) {
+	r := ParseReviewWithChecklist("VERDICT: APPROVE", nil)
+	if r.Verdict != "APPROVE" || r.Checklist != nil {
+		t.Errorf("expected plain ParseReview behaviour, got %+v", r)
+	}
+}
+
+func TestChecklistAnswer_NotAWord(t *testing.T) {
+	if a, _ := checklistAnswer(": none of the handlers changed"); a != "" {
+		t.Errorf(`"none" should not parse as an answer, got %q`, a)
+	}
+	if a, _ := checklistAnswer(" [FAILED] missing"); a != "FAIL" {
+		t.Errorf("expected FAIL, got %q", a)
+	}
+}
@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
 )
 
 // CLIRunner spawns an external CLI process (claude, gemini, codex, ollama, etc.)
-// and passes the task prompt via stdin or as an argument.
+// and passes the task prompt as an argument, via stdin or in a temp file
+// (prompt_via).
 type CLIRunner struct {
 	name string
 	cfg  config.Agent
@@ -28,10 +33,20 @@ func (r *CLIRunner) Mode() string { return "cli" }
 
 // Run spawns the CLI agent process with the prompt.
 //
-// The prompt is passed as the last argument to the command.
+// By default the prompt is passed as the last argument to the command.
 // For example, if cmd="claude" and args=["--model", "sonnet"],
 // the full command becomes: claude --model sonnet "the prompt text"
 //
+// A prompt too big for the command line goes on stdin with prompt_via:
+// stdin, or into a temp file with prompt_via: file. The file's path
+// replaces {prompt_path} in args, e.g. ["--prompt-file", "{prompt_path}"],
+// or is the last argument when no arg mentions it. The file is removed
+// when the agent exits.
+//
+// Other placeholders in args are filled in per run too: {task_id},
+// {workdir}, {branch} (checked out in the work dir) and {model}. See
+// expandArgs.
+//
 // The agent runs in the specified working directory (repo root)
 // so it has access to the project files.
 func (r *CLIRunner) Run(ctx context.Context, req Request) (*Response, error) {
@@ -39,15 +54,46 @@ func (r *CLIRunner) Run(ctx context.Context, req Request) (*Response, error) {
 
 	// Build the command: effective args (with auto-accept flags) + prompt.
 	args := r.cfg.EffectiveArgs()
+	if len(req.Images) > 0 {
+		req.Prompt += attachmentsSection(req.Images)
+	}
+
+	vars := map[string]string{
+		"task_id": strconv.FormatInt(req.TaskID, 10),
+		"workdir": req.WorkDir,
+		"model":   r.cfg.Model,
+	}
+	if usesVar(args, "branch") {
+		vars["branch"], _ = git.New(req.WorkDir).CurrentBranch()
+	}
 
-	// For gemini, prompt goes via --prompt flag.
-	// For claude, prompt is positional after --print.
-	// For others, prompt is the last positional argument.
-	switch r.cfg.Cmd {
-	case "gemini":
-		args = append(args, "--prompt", req.Prompt)
+	var stdin io.Reader
+	switch r.cfg.PromptMode() {
+	case config.PromptViaStdin:
+		stdin = strings.NewReader(req.Prompt)
+		args = expandArgs(args, vars)
+	case config.PromptViaFile:
+		path, err := writePromptFile(req.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", r.name, err)
+		}
+		defer os.Remove(path)
+		if !usesVar(args, "prompt_path") {
+			args = append(args, "{prompt_path}")
+		}
+		vars["prompt_path"] = path
+		args = expandArgs(args, vars)
 	default:
-		args = append(args, req.Prompt)
+		args = expandArgs(args, vars)
+		// For gemini, prompt goes via --prompt flag.
+		// For claude, prompt is positional after --print.
+		// For others, prompt is the last positional argument.
+		switch r.cfg.Cmd {
+		case "gemini":
+			args = append(args, "--prompt", req.Prompt)
+		default:
+			args = append(args, req.Prompt)
+		}
 	}
 
 	// Apply timeout from config or request.
@@ -60,11 +106,17 @@ func (r *CLIRunner) Run(ctx context.Context, req Request) (*Response, error) {
 
 	cmd := exec.CommandContext(ctx, r.cfg.Cmd, args...)
 	cmd.Dir = req.WorkDir
+	cmd.Stdin = stdin
 
 	// Capture stdout and stderr.
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if req.Stream != nil {
+		lines := newLineWriter(req.Stream)
+		defer lines.Close()
+		cmd.Stdout = io.MultiWriter(&stdout, lines)
+	}
 
 	// Run the process.
 	err := cmd.Run()
@@ -107,6 +159,49 @@ func (r *CLIRunner) Run(ctx context.Context, req Request) (*Response, error) {
 	return resp, nil
 }
 
+// writePromptFile writes a prompt to a new temp file and returns its path.
+func writePromptFile(prompt string) (string, error) {
+	f, err := os.CreateTemp("", "hive-prompt-*.md")
+	if err != nil {
+		return "", fmt.Errorf("write prompt file: %w", err)
+	}
+	if _, err := f.WriteString(prompt); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("write prompt file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("write prompt file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// expandArgs fills in the {name} placeholders of vars in args. Other
+// braces, such as JSON in an argument, are left alone.
+func expandArgs(args []string, vars map[string]string) []string {
+	pairs := make([]string, 0, 2*len(vars))
+	for name, v := range vars {
+		pairs = append(pairs, "{"+name+"}", v)
+	}
+	r := strings.NewReplacer(pairs...)
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = r.Replace(a)
+	}
+	return out
+}
+
+// usesVar reports whether any arg has the {name} placeholder.
+func usesVar(args []string, name string) bool {
+	for _, a := range args {
+		if strings.Contains(a, "{"+name+"}") {
+			return true
+		}
+	}
+	return false
+}
+
 // CLIAvailable checks if the CLI command exists in PATH.
 func CLIAvailable(cmd string) bool {
 	_, err := exec.LookPath(cmd)
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestCLIRunner_PromptVia(t *testing.T) {
+	prompt := "Implement login\nwith 'quotes' and $vars"
+	tests := []struct {
+		name string
+		cfg  config.Agent
+	}{
+		{"arg", config.Agent{Mode: "cli", Cmd: "sh", Args: []string{"-c", `printf %s "$0"`}}},
+		{"stdin", config.Agent{Mode: "cli", Cmd: "cat", PromptVia: config.PromptViaStdin}},
+		{"file", config.Agent{Mode: "cli", Cmd: "sh", Args: []string{"-c", "cat {prompt_path}"}, PromptVia: config.PromptViaFile}},
+		{"file appended", config.Agent{Mode: "cli", Cmd: "cat", PromptVia: config.PromptViaFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewCLIRunner("test", tt.cfg).Run(context.Background(), Request{Prompt: prompt, WorkDir: t.TempDir()})
+			if err != nil || resp.Error != nil {
+				t.Fatalf("Run: %v %v", err, resp.Error)
+			}
+			if resp.Output != prompt {
+				t.Errorf("agent got %q, want %q", resp.Output, prompt)
+			}
+		})
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	vars := map[string]string{"task_id": "7", "prompt_path": "/tmp/p.md", "model": ""}
+	got := expandArgs([]string{"--prompt-file={prompt_path}", "--session", "hive-{task_id}", `{"a": {task}}`, "{model}"}, vars)
+	want := []string{"--prompt-file=/tmp/p.md", "--session", "hive-7", `{"a": {task}}`, ""}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCLIRunner_ArgTemplates(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Agent{Mode: "cli", Cmd: "sh", Model: "sonnet",
+		Args: []string{"-c", `echo "$1 $2 $3"; exit 0`, "sh", "{task_id}", "{workdir}", "{model}"}}
+	resp, err := NewCLIRunner("test", cfg).Run(context.Background(), Request{TaskID: 12, Prompt: "{task_id}", WorkDir: dir})
+	if err != nil || resp.Error != nil {
+		t.Fatalf("Run: %v %v", err, resp.Error)
+	}
+	if got, want := strings.TrimSpace(resp.Output), "12 "+dir+" sonnet"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCLIRunner_PromptFileRemoved(t *testing.T) {
+	cfg := config.Agent{Mode: "cli", Cmd: "echo", PromptVia: config.PromptViaFile}
+	resp, err := NewCLIRunner("test", cfg).Run(context.Background(), Request{Prompt: "hi", WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	path := strings.TrimSpace(resp.Output)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("prompt file %s left behind", path)
+	}
+}
@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+)
+
+// FakeRunner answers from fixture files instead of a model, so whole
+// pipelines (auto, resume, parallel merge) run deterministically in tests
+// and CI. The n-th call for a task is answered by the first file found in
+// the fixtures directory among:
+//
+//	<role>-task<id>-<n>.md   one task, one iteration
+//	<role>-<n>.md            every task's n-th call
+//	<role>.md                every call
+//
+// Without a match the answer is a canned one for the role: the coder
+// writes a file and reports it, the reviewer approves, the PM plans one
+// subtask.
+//
+// Fixtures may contain directives, which are stripped from the output:
+//
+//	=== file: path/in/workdir    write the lines up to "=== end" to the file
+//	=== exit: 1                  exit with this code
+//
+// "{{task}}" and "{{n}}" in a fixture are replaced by the task ID and the
+// call number.
+type FakeRunner struct {
+	name     string
+	cfg      config.Agent
+	fixtures string
+}
+
+var (
+	fakeMu     sync.Mutex
+	fakeCalls  = map[string]int{} // fixtures dir + role + task → calls so far
+	simulation Simulation
+)
+
+// Simulation answers the n-th call for a role on a task, taking precedence
+// over fixtures; ok=false falls back to them.
+type Simulation func(taskID int64, role string, n int) (answer string, ok bool)
+
+// SetSimulation makes every agent a FakeRunner answering from fn, for
+// hive auto --simulate, or turns simulation off if fn is nil.
+func SetSimulation(fn Simulation) {
+	fakeMu.Lock()
+	simulation = fn
+	fakeMu.Unlock()
+}
+
+// Simulating reports whether agents are simulated. No agent runs then, so
+// offline mode allows api-mode ones.
+func Simulating() bool {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return simulation != nil
+}
+
+// NewFakeRunner creates a runner answering from cfg.Fixtures. A relative
+// fixtures directory is relative to the current directory.
+func NewFakeRunner(name string, cfg config.Agent) *FakeRunner {
+	dir := cfg.Fixtures
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	return &FakeRunner{name: name, cfg: cfg, fixtures: dir}
+}
+
+func (r *FakeRunner) Name() string { return r.name }
+func (r *FakeRunner) Mode() string { return "fake" }
+
+// Run answers with the next fixture for the task.
+func (r *FakeRunner) Run(ctx context.Context, req Request) (*Response, error) {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Calls are counted per fixtures directory, so runners recreated for
+	// each task or on resume continue where the last one stopped.
+	key := fmt.Sprintf("%s\x00%s\x00%d", r.fixtures, r.cfg.Role, req.TaskID)
+	fakeMu.Lock()
+	fakeCalls[key]++
+	n := fakeCalls[key]
+	sim := simulation
+	fakeMu.Unlock()
+
+	var text string
+	ok := false
+	if sim != nil {
+		text, ok = sim(req.TaskID, r.cfg.Role, n)
+	}
+	if !ok {
+		text, ok = r.fixture(req.TaskID, n)
+	}
+	if !ok {
+		text = fakeDefault(r.cfg.Role)
+	}
+	text = strings.NewReplacer("{{task}}", strconv.FormatInt(req.TaskID, 10), "{{n}}", strconv.Itoa(n)).Replace(text)
+
+	output, exitCode, err := applyFixture(text, req.WorkDir)
+	streamAll(req.Stream, output)
+	resp := &Response{
+		Output:   output,
+		ExitCode: exitCode,
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		resp.Error = err
+		resp.ExitCode = 1
+	}
+	return resp, nil
+}
+
+// fixture reads the most specific fixture for the n-th call on a task.
+func (r *FakeRunner) fixture(taskID int64, n int) (string, bool) {
+	if r.fixtures == "" {
+		return "", false
+	}
+	role := r.cfg.Role
+	for _, name := range []string{
+		fmt.Sprintf("%s-task%d-%d.md", role, taskID, n),
+		fmt.Sprintf("%s-%d.md", role, n),
+		role + ".md",
+	} {
+		if data, err := os.ReadFile(filepath.Join(r.fixtures, name)); err == nil {
+			return string(data), true
+		}
+	}
+	return "", false
+}
+
+// fakeDefault is the answer for a role without fixtures.
+func fakeDefault(role string) string {
+	switch role {
+	case roles.Coder:
+		return "=== file: fake/task-{{task}}.txt\nchange {{n}}\n=== end\n" +
+			"Done.\n\nFILES_CHANGED:\n- fake/task-{{task}}.txt\n"
+	case roles.Reviewer:
+		return "Looks good.\n\nVERDICT: APPROVE\n"
+	case roles.PM:
+		return "SUBTASKS:\n1. Implement the change - Make the change the epic asks for (priority: high)\n"
+	}
+	return "Done.\n"
+}
+
+// applyFixture carries out the directives in a fixture, writing files under
+// workDir, and returns the remaining text and the exit code.
+func applyFixture(text, workDir string) (string, int, error) {
+	var out []string
+	exitCode := 0
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		directive, ok := strings.CutPrefix(strings.TrimSpace(line), "=== ")
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+		name, arg, _ := strings.Cut(directive, ":")
+		arg = strings.TrimSpace(arg)
+		switch strings.TrimSpace(name) {
+		case "file":
+			var body []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "=== end"; i++ {
+				body = append(body, lines[i])
+			}
+			if err := writeFixtureFile(workDir, arg, strings.Join(body, "\n")+"\n"); err != nil {
+				return "", 1, err
+			}
+		case "exit":
+			code, err := strconv.Atoi(arg)
+			if err != nil {
+				return "", 1, fmt.Errorf("fixture: bad exit code %q", arg)
+			}
+			exitCode = code
+		default:
+			out = append(out, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(out, "\n")), exitCode, nil
+}
+
+// writeFixtureFile writes a fixture's file, refusing paths outside workDir.
+func writeFixtureFile(workDir, rel, content string) error {
+	if rel == "" || filepath.IsAbs(rel) || !filepath.IsLocal(rel) {
+		return fmt.Errorf("fixture: file path %q must be relative to the work dir", rel)
+	}
+	path := filepath.Join(workDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("fixture: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("fixture: %w", err)
+	}
+	return nil
+}
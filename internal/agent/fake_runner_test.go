@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestFakeRunner_FixturesByCall(t *testing.T) {
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "reviewer-1.md"), []byte("Missing tests.\n\nVERDICT: REJECT\n- add tests\n"), 0644)
+	os.WriteFile(filepath.Join(fixtures, "reviewer-task7-2.md"), []byte("VERDICT: APPROVE\n"), 0644)
+	os.WriteFile(filepath.Join(fixtures, "reviewer.md"), []byte("fallback for task {{task}}, call {{n}}\n"), 0644)
+
+	r, err := NewRunner("fake-reviewer", config.Agent{Role: "reviewer", Mode: "fake", Fixtures: fixtures})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs []string
+	for range 3 {
+		resp, err := r.Run(context.Background(), Request{TaskID: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, resp.Output)
+	}
+	if ParseReview(outputs[0]).Verdict != "REJECT" {
+		t.Errorf("call 1: expected REJECT, got %q", outputs[0])
+	}
+	if ParseReview(outputs[1]).Verdict != "APPROVE" {
+		t.Errorf("call 2: expected APPROVE, got %q", outputs[1])
+	}
+	if outputs[2] != "fallback for task 7, call 3" {
+		t.Errorf("call 3: got %q", outputs[2])
+	}
+
+	// A new runner over the same fixtures continues the count.
+	r2, _ := NewRunner("fake-reviewer", config.Agent{Role: "reviewer", Mode: "fake", Fixtures: fixtures})
+	resp, _ := r2.Run(context.Background(), Request{TaskID: 7})
+	if resp.Output != "fallback for task 7, call 4" {
+		t.Errorf("new runner: got %q", resp.Output)
+	}
+}
+
+func TestFakeRunner_Directives(t *testing.T) {
+	fixtures, work := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "coder.md"), []byte(
+		"=== file: pkg/a.go\npackage pkg\n=== end\n=== exit: 2\nWrote a.go\n"), 0644)
+
+	r := NewFakeRunner("fake-coder", config.Agent{Role: "coder", Mode: "fake", Fixtures: fixtures})
+	resp, err := r.Run(context.Background(), Request{TaskID: 1, WorkDir: work})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Output != "Wrote a.go" || resp.ExitCode != 2 {
+		t.Errorf("got output %q, exit %d", resp.Output, resp.ExitCode)
+	}
+	data, err := os.ReadFile(filepath.Join(work, "pkg", "a.go"))
+	if err != nil || string(data) != "package pkg\n" {
+		t.Errorf("file not written: %q, %v", data, err)
+	}
+}
+
+func TestFakeRunner_Defaults(t *testing.T) {
+	work := t.TempDir()
+	coder := NewFakeRunner("c", config.Agent{Role: "coder", Mode: "fake"})
+	resp, _ := coder.Run(context.Background(), Request{TaskID: 3, WorkDir: work})
+	if files := ParseFilesChanged(resp.Output); len(files) != 1 || files[0] != "fake/task-3.txt" {
+		t.Errorf("unexpected FILES_CHANGED: %v", files)
+	}
+	if _, err := os.Stat(filepath.Join(work, "fake", "task-3.txt")); err != nil {
+		t.Errorf("default coder wrote nothing: %v", err)
+	}
+
+	pm := NewFakeRunner("p", config.Agent{Role: "pm", Mode: "fake"})
+	resp, _ = pm.Run(context.Background(), Request{TaskID: 1})
+	if len(ParseSubtasks(resp.Output)) != 1 {
+		t.Errorf("expected one subtask, got %q", resp.Output)
+	}
+}
+
+func TestFakeRunner_RejectsPathsOutsideWorkDir(t *testing.T) {
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "coder.md"), []byte("=== file: ../evil.txt\nx\n=== end\n"), 0644)
+
+	r := NewFakeRunner("c", config.Agent{Role: "coder", Mode: "fake", Fixtures: fixtures})
+	resp, _ := r.Run(context.Background(), Request{TaskID: 1, WorkDir: t.TempDir()})
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "relative to the work dir") {
+		t.Errorf("expected path error, got %v", resp.Error)
+	}
+}
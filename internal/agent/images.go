@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxImageBytes is the largest image sent inline to an API; providers
+// reject bigger ones (Anthropic's limit is 5 MB).
+const maxImageBytes = 5 << 20
+
+// ImageSource returns the image files attached to a task, as absolute
+// paths.
+type ImageSource func(taskID int64) []string
+
+var (
+	imagesMu    sync.Mutex
+	imageSource ImageSource
+)
+
+// SetImageSource installs fn to look up a task's attached images. Runners
+// fill Request.Images from it when the caller left it empty.
+func SetImageSource(fn ImageSource) {
+	imagesMu.Lock()
+	imageSource = fn
+	imagesMu.Unlock()
+}
+
+func taskImages(taskID int64) []string {
+	imagesMu.Lock()
+	fn := imageSource
+	imagesMu.Unlock()
+	if fn == nil || taskID == 0 {
+		return nil
+	}
+	return fn(taskID)
+}
+
+// ImageMIME returns the media type of an image file by extension, or ""
+// if it isn't an image type the providers accept.
+func ImageMIME(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	}
+	return ""
+}
+
+// inlineImage is an image ready for an API payload.
+type inlineImage struct {
+	MIME string
+	Data string // base64
+}
+
+// loadImages reads the images to send inline. Files that can't be read,
+// aren't images or are too large are returned as skipped, with the reason.
+func loadImages(paths []string) (images []inlineImage, skipped []string) {
+	for _, p := range paths {
+		mime := ImageMIME(p)
+		if mime == "" {
+			skipped = append(skipped, filepath.Base(p)+" (not a supported image type)")
+			continue
+		}
+		data, err := os.ReadFile(p)
+		switch {
+		case err != nil:
+			skipped = append(skipped, filepath.Base(p)+" (unreadable)")
+		case len(data) > maxImageBytes:
+			skipped = append(skipped, fmt.Sprintf("%s (%d MB, over the %d MB limit)", filepath.Base(p), len(data)>>20, maxImageBytes>>20))
+		default:
+			images = append(images, inlineImage{MIME: mime, Data: base64.StdEncoding.EncodeToString(data)})
+		}
+	}
+	return images, skipped
+}
+
+// attachmentsSection lists image paths for agents that read files
+// themselves (CLI agents).
+func attachmentsSection(paths []string) string {
+	var sb strings.Builder
+	sb.WriteString("\n\n## Attachments\n")
+	sb.WriteString("Images attached to this task (mockups, screenshots). Open them before you start:\n")
+	for _, p := range paths {
+		sb.WriteString("- " + p + "\n")
+	}
+	return sb.String()
+}
+
+// unseenSection tells an API model about attachments it can't see.
+func unseenSection(names []string) string {
+	return "\n\n## Attachments\nThe task has attached images you cannot see: " + strings.Join(names, ", ") +
+		". If they matter, say so in your answer.\n"
+}
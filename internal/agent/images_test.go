@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestLoadImages(t *testing.T) {
+	dir := t.TempDir()
+	png := filepath.Join(dir, "mockup.png")
+	os.WriteFile(png, []byte("png"), 0644)
+	txt := filepath.Join(dir, "notes.txt")
+	os.WriteFile(txt, []byte("text"), 0644)
+	big := filepath.Join(dir, "huge.jpg")
+	os.WriteFile(big, make([]byte, maxImageBytes+1), 0644)
+
+	images, skipped := loadImages([]string{png, txt, big, filepath.Join(dir, "missing.gif")})
+	if len(images) != 1 || images[0].MIME != "image/png" || images[0].Data != "cG5n" {
+		t.Errorf("unexpected images: %+v", images)
+	}
+	if len(skipped) != 3 {
+		t.Fatalf("expected 3 skipped, got %v", skipped)
+	}
+	for i, want := range []string{"not a supported image", "over the 5 MB limit", "unreadable"} {
+		if !strings.Contains(skipped[i], want) {
+			t.Errorf("skipped[%d] = %q, want it to mention %q", i, skipped[i], want)
+		}
+	}
+}
+
+func TestAPIRunnerImages(t *testing.T) {
+	png := filepath.Join(t.TempDir(), "mockup.png")
+	os.WriteFile(png, []byte("png"), 0644)
+	req := Request{Prompt: "Build the page", Images: []string{png}}
+
+	blind := &APIRunner{cfg: config.Agent{Provider: "openai"}}
+	msgs, images := blind.prepare(req)
+	if len(images) != 0 || !strings.Contains(msgs[0].Content, "cannot see: mockup.png") {
+		t.Errorf("model without vision: prompt %q, %d images", msgs[0].Content, len(images))
+	}
+
+	vision := &APIRunner{cfg: config.Agent{Provider: "openai", Vision: true}}
+	msgs, images = vision.prepare(req)
+	if len(images) != 1 || msgs[0].Content != "Build the page" {
+		t.Errorf("model with vision: prompt %q, %d images", msgs[0].Content, len(images))
+	}
+}
+
+func TestAttachmentsSection(t *testing.T) {
+	got := attachmentsSection([]string{"/p/.hive/runs/task-1-attach-a.png"})
+	if !strings.Contains(got, "## Attachments") || !strings.Contains(got, "- /p/.hive/runs/task-1-attach-a.png") {
+		t.Errorf("unexpected section: %q", got)
+	}
+}
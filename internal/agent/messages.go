@@ -0,0 +1,69 @@
+package agent
+
+import "strings"
+
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one turn of a conversation-style prompt.
+type Message struct {
+	Role    string // system, user or assistant
+	Content string
+}
+
+// conversation returns the request as messages the chat APIs accept: at
+// most one system message first, then user and assistant turns
+// alternating, starting and ending with the user. Without Messages the
+// prompt is a single user turn.
+func (r Request) conversation() []Message {
+	if len(r.Messages) == 0 {
+		return []Message{{Role: RoleUser, Content: r.Prompt}}
+	}
+
+	var system []string
+	var turns []Message
+	for _, m := range r.Messages {
+		content := strings.TrimSpace(m.Content)
+		if content == "" {
+			continue
+		}
+		role := m.Role
+		switch role {
+		case RoleSystem:
+			system = append(system, content)
+			continue
+		case RoleAssistant:
+		default:
+			role = RoleUser
+		}
+		if n := len(turns); n > 0 && turns[n-1].Role == role {
+			turns[n-1].Content += "\n\n" + content
+			continue
+		}
+		if len(turns) == 0 && role == RoleAssistant {
+			turns = append(turns, Message{Role: RoleUser, Content: "Context so far:"})
+		}
+		turns = append(turns, Message{Role: role, Content: content})
+	}
+	if n := len(turns); n == 0 || turns[n-1].Role != RoleUser {
+		turns = append(turns, Message{Role: RoleUser, Content: "Continue."})
+	}
+
+	if len(system) == 0 {
+		return turns
+	}
+	return append([]Message{{Role: RoleSystem, Content: strings.Join(system, "\n\n")}}, turns...)
+}
+
+// splitSystem separates the system message from the turns, for APIs that
+// take it as its own field.
+func splitSystem(msgs []Message) (system string, turns []Message) {
+	if len(msgs) > 0 && msgs[0].Role == RoleSystem {
+		return msgs[0].Content, msgs[1:]
+	}
+	return "", msgs
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConversation(t *testing.T) {
+	got := Request{Prompt: "do it"}.conversation()
+	if want := []Message{{RoleUser, "do it"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("plain prompt: got %v, want %v", got, want)
+	}
+
+	req := Request{Messages: []Message{
+		{RoleSystem, "You are a reviewer."},
+		{RoleUser, "## Task"},
+		{RoleUser, "## Epic"},
+		{RoleAssistant, "REJECT: missing tests"},
+		{RoleAssistant, "   "},
+		{RoleUser, "answer: add them"},
+		{RoleSystem, "Be brief."},
+		{RoleAssistant, "APPROVE"},
+	}}
+	want := []Message{
+		{RoleSystem, "You are a reviewer.\n\nBe brief."},
+		{RoleUser, "## Task\n\n## Epic"},
+		{RoleAssistant, "REJECT: missing tests"},
+		{RoleUser, "answer: add them"},
+		{RoleAssistant, "APPROVE"},
+		{RoleUser, "Continue."},
+	}
+	if got := req.conversation(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
+
+func TestConversationStartsWithUser(t *testing.T) {
+	got := Request{Messages: []Message{{RoleAssistant, "earlier output"}, {RoleUser, "now"}}}.conversation()
+	if got[0].Role != RoleUser || got[1].Role != RoleAssistant || got[2].Role != RoleUser {
+		t.Errorf("roles must start with user and alternate: %v", got)
+	}
+}
+
+func TestAnthropicBody(t *testing.T) {
+	msgs := []Message{{RoleSystem, "sys"}, {RoleUser, "task"}, {RoleAssistant, "review"}, {RoleUser, "fix"}}
+	body := anthropicBody("claude", msgs, []inlineImage{{MIME: "image/png", Data: "AA=="}})
+	if body["system"] != "sys" {
+		t.Errorf("system = %v", body["system"])
+	}
+	messages := body["messages"].([]map[string]any)
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(messages))
+	}
+	first := messages[0]["content"].([]map[string]any)
+	if first[0]["cache_control"] == nil {
+		t.Error("first turn should be marked for caching")
+	}
+	last := messages[2]["content"].([]map[string]any)
+	if len(last) != 2 || last[0]["type"] != "image" || last[1]["text"] != "fix" {
+		t.Errorf("last turn should carry the image then the text: %v", last)
+	}
+}
+
+func TestGoogleBody(t *testing.T) {
+	body := googleBody([]Message{{RoleSystem, "sys"}, {RoleUser, "task"}, {RoleAssistant, "out"}, {RoleUser, "more"}}, nil)
+	contents := body["contents"].([]map[string]any)
+	if len(contents) != 3 || contents[1]["role"] != "model" {
+		t.Errorf("unexpected contents: %v", contents)
+	}
+	if body["systemInstruction"] == nil {
+		t.Error("expected systemInstruction")
+	}
+}
@@ -0,0 +1,24 @@
+package agent
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+// offline forbids api-mode agents, for air-gapped machines where hive may
+// only run local CLI tools.
+var offline atomic.Bool
+
+// SetOffline turns offline mode on or off. While it is on, NewRunner
+// refuses agents in api mode.
+func SetOffline(on bool) { offline.Store(on) }
+
+// Offline reports whether offline mode is on.
+func Offline() bool { return offline.Load() }
+
+// OfflineError explains why an api-mode agent can't run offline.
+func OfflineError(name string, cfg config.Agent) error {
+	return fmt.Errorf("agent %s calls the %s API, which offline mode forbids; use a cli-mode agent or drop --offline", name, cfg.Provider)
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestNewRunnerOffline(t *testing.T) {
+	t.Setenv("HIVE_TEST_KEY", "sk-test")
+	SetOffline(true)
+	defer SetOffline(false)
+
+	api := config.Agent{Role: "coder", Mode: "api", Provider: "openai", Model: "gpt-4o", APIKeyEnv: "HIVE_TEST_KEY"}
+	if _, err := NewRunner("remote", api); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Fatalf("expected offline error for api agent, got %v", err)
+	}
+	if _, err := NewRunner("local", config.Agent{Role: "coder", Mode: "cli", Cmd: "claude"}); err != nil {
+		t.Fatalf("cli agent should run offline: %v", err)
+	}
+
+	SetOffline(false)
+	if _, err := NewRunner("remote", api); err != nil {
+		t.Fatalf("api agent should run online: %v", err)
+	}
+}
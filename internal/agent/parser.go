@@ -2,6 +2,7 @@ package agent
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -9,13 +10,25 @@ import (
 type ParsedSubtask struct {
 	Title       string
 	Description string
-	Priority    string // high, medium, low
+	Priority    string   // high, medium, low
+	Acceptance  []string // Acceptance criteria, one per entry
 }
 
 // ParsedReview represents a review verdict extracted from reviewer agent output.
 type ParsedReview struct {
-	Verdict  string // APPROVE, REJECT
-	Comments []string
+	Verdict     string // APPROVE, REJECT
+	Comments    []string
+	Checklist   []ChecklistAnswer // Set by ParseReviewWithChecklist
+	Acceptance  []ChecklistAnswer // Set by CheckAcceptance
+	Suggestions []string          // Unified diffs from ```diff blocks, see ParseSuggestions
+}
+
+// ChecklistAnswer is the reviewer's answer to one review checklist item.
+type ChecklistAnswer struct {
+	Item     string
+	Answer   string // PASS, FAIL, N/A, or "" if the item wasn't answered
+	Note     string
+	Required bool
 }
 
 // ParseSubtasks extracts subtasks from PM agent output.
@@ -23,9 +36,12 @@ type ParsedReview struct {
 //
 //	SUBTASKS:
 //	1. [Title] - [Description] (priority: high)
+//	   Acceptance:
+//	   - [criterion, which may wrap
+//	     onto more lines]
 //	2. [Title] - [Description] (priority: medium)
 //
-// Also supports:
+// The Acceptance: block under a subtask is optional. Also supports:
 //
 //  1. Title - Description
 //     - Title - Description
@@ -49,9 +65,39 @@ func ParseSubtasks(output string) []ParsedSubtask {
 		}
 	}
 
+	last := -1 // Index of the subtask acceptance criteria attach to
+	inAcceptance := false
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
 
+		// Acceptance criteria under the last subtask: bullets or indented
+		// items, with indented lines continuing the previous criterion.
+		if inSection && last >= 0 && acceptanceHeader(trimmed) {
+			inAcceptance = true
+			_, rest, _ := strings.Cut(strings.ReplaceAll(trimmed, "**", ""), ":")
+			if rest = strings.TrimSpace(rest); rest != "" {
+				subtasks[last].Acceptance = append(subtasks[last].Acceptance, rest)
+			}
+			continue
+		}
+		if inAcceptance && trimmed != "" {
+			indented := len(line) > len(strings.TrimLeft(line, " \t"))
+			bullet := strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "• ")
+			criteria := &subtasks[last].Acceptance
+			switch {
+			case bullet:
+				*criteria = append(*criteria, strings.TrimSpace(trimmed[strings.IndexByte(trimmed, ' '):]))
+				continue
+			case indented && numberedRe.MatchString(trimmed):
+				*criteria = append(*criteria, numberedRe.FindStringSubmatch(trimmed)[1])
+				continue
+			case indented && len(*criteria) > 0:
+				(*criteria)[len(*criteria)-1] += " " + trimmed
+				continue
+			}
+			inAcceptance = false
+		}
+
 		// Detect start of subtasks section.
 		if strings.HasPrefix(strings.ToUpper(trimmed), "SUBTASKS:") {
 			inSection = true
@@ -116,6 +162,7 @@ func ParseSubtasks(output string) []ParsedSubtask {
 
 		// Skip lines that look like section headers, not real subtasks.
 		// These are artifacts from LLMs writing markdown analysis instead of clean lists.
+		last = -1
 		if isGarbageSubtask(title) {
 			continue
 		}
@@ -126,6 +173,7 @@ func ParseSubtasks(output string) []ParsedSubtask {
 				Description: description,
 				Priority:    priority,
 			})
+			last = len(subtasks) - 1
 		}
 	}
 
@@ -137,6 +185,15 @@ func ParseSubtasks(output string) []ParsedSubtask {
 	return subtasks
 }
 
+// acceptanceHeader reports whether a line opens a subtask's acceptance
+// criteria: "Acceptance:", "**Acceptance criteria:**" and the like.
+func acceptanceHeader(line string) bool {
+	line = strings.ReplaceAll(strings.TrimLeft(line, "-*• "), "**", "")
+	head, _, ok := strings.Cut(line, ":")
+	head = strings.ToUpper(strings.TrimSpace(head))
+	return ok && (head == "ACCEPTANCE" || head == "ACCEPTANCE CRITERIA")
+}
+
 // isGarbageSubtask returns true if a title looks like a section header
 // or analysis fragment rather than a real actionable subtask.
 func isGarbageSubtask(title string) bool {
@@ -235,6 +292,8 @@ func ParseReview(output string) ParsedReview {
 		}
 	}
 
+	result.Suggestions = ParseSuggestions(output)
+
 	// Pass 2: If no explicit verdict found, try heuristics.
 	if result.Verdict == "" {
 		result.Verdict = inferVerdict(upper)
@@ -324,3 +383,180 @@ func ParseBlocked(output string) string {
 	}
 	return ""
 }
+
+// ParseFilesChanged extracts the FILES_CHANGED: list a coder ends its
+// response with. Accepts a bulleted list on the following lines or a
+// comma-separated list on the same line; "none" yields an empty list.
+// Descriptions after the path ("path — why", "path: why") are dropped.
+//
+//	FILES_CHANGED:
+//	- internal/auth/login.go
+//	- `internal/auth/login_test.go` — new tests
+func ParseFilesChanged(output string) []string {
+	inline, items := listSection(output, "FILES_CHANGED:")
+
+	var files []string
+	if inline != "" {
+		items = strings.Split(inline, ",")
+	}
+	for _, f := range items {
+		if path := cleanChangedPath(f); path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// ParseHandoff extracts the HANDOFF: notes a coder leaves for later tasks
+// in the same epic — new functions, types, endpoints, changed behaviour.
+//
+//	HANDOFF:
+//	- Added auth.Login(user, pass) (*Session, error)
+//	- POST /api/login now returns 401 instead of 403
+func ParseHandoff(output string) []string {
+	inline, items := listSection(output, "HANDOFF:")
+	if inline != "" {
+		items = []string{inline}
+	}
+
+	var notes []string
+	for _, n := range items {
+		n = strings.TrimSpace(n)
+		if n != "" && !strings.EqualFold(n, "none") {
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// ParseLikelyFiles extracts the LIKELY_FILES: list an analyst returns when
+// localizing a bug, one "path:line — reason" entry each, as written.
+//
+//	LIKELY_FILES:
+//	- internal/auth/session.go:88 — dereferences the expired session
+//	- internal/auth/store.go — returns nil without an error
+func ParseLikelyFiles(output string) []string {
+	inline, items := listSection(output, "LIKELY_FILES:")
+	if inline != "" {
+		items = strings.Split(inline, ",")
+	}
+
+	var files []string
+	for _, f := range items {
+		f = strings.TrimSpace(f)
+		if cleanChangedPath(f) != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// listSection finds the first line starting with label (ignoring markdown
+// bullets and bold) and returns the text after the label on that line, or
+// the bullet items that follow it when that text is empty.
+func listSection(output, label string) (string, []string) {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		cleaned := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), ">*#- "))
+		cleaned = strings.ReplaceAll(cleaned, "**", "")
+		if !strings.HasPrefix(strings.ToUpper(cleaned), label) {
+			continue
+		}
+
+		if rest := strings.TrimSpace(cleaned[len(label):]); rest != "" {
+			return rest, nil
+		}
+
+		var items []string
+		for _, l := range lines[i+1:] {
+			t := strings.TrimSpace(l)
+			if t == "" {
+				if len(items) > 0 {
+					break
+				}
+				continue
+			}
+			if !strings.HasPrefix(t, "-") && !strings.HasPrefix(t, "*") && !strings.HasPrefix(t, "•") {
+				break
+			}
+			items = append(items, strings.TrimLeft(t, "-*• "))
+		}
+		return "", items
+	}
+	return "", nil
+}
+
+// cleanChangedPath strips markdown and trailing descriptions from one
+// FILES_CHANGED entry. Returns "" for "none".
+func cleanChangedPath(entry string) string {
+	entry = strings.TrimSpace(entry)
+	for _, sep := range []string{" — ", " - ", " (", ": "} {
+		if i := strings.Index(entry, sep); i > 0 {
+			entry = entry[:i]
+		}
+	}
+	entry = strings.Trim(strings.TrimSpace(entry), "`*\"'")
+	entry = strings.TrimPrefix(entry, "./")
+	if strings.EqualFold(entry, "none") {
+		return ""
+	}
+	return entry
+}
+
+// CommitGroup is a task a PM made out of existing commits.
+type CommitGroup struct {
+	Title       string
+	Description string
+	Commits     []int // Indexes into the commit list, from 0, in order
+}
+
+var commitsRe = regexp.MustCompile(`\(commits?:\s*([\d,\s]+)\)`)
+
+// ParseCommitGroups extracts how a PM grouped n numbered commits into
+// tasks, from subtasks ending in "(commits: 1, 2)". Every commit ends up
+// in exactly one group: a commit listed twice stays in the first group,
+// and one no group lists joins the group of the commit before it (the
+// first group, for the first commit). Returns nil when no subtask lists a
+// commit.
+func ParseCommitGroups(output string, n int) []CommitGroup {
+	owner := make([]int, n) // Group index+1 per commit; 0 = unclaimed
+	var groups []CommitGroup
+	for _, sub := range ParseSubtasks(output) {
+		m := commitsRe.FindStringSubmatch(sub.Title + " " + sub.Description)
+		if m == nil {
+			continue
+		}
+		g := CommitGroup{
+			Title:       strings.TrimSpace(commitsRe.ReplaceAllString(sub.Title, "")),
+			Description: strings.TrimSpace(commitsRe.ReplaceAllString(sub.Description, "")),
+		}
+		for _, f := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+			if i, err := strconv.Atoi(f); err == nil && i >= 1 && i <= n && owner[i-1] == 0 {
+				owner[i-1] = len(groups) + 1
+			}
+		}
+		groups = append(groups, g)
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	for i := range owner {
+		if owner[i] == 0 {
+			if i == 0 {
+				owner[i] = 1
+			} else {
+				owner[i] = owner[i-1]
+			}
+		}
+		groups[owner[i]-1].Commits = append(groups[owner[i]-1].Commits, i)
+	}
+
+	// Groups left without commits are dropped.
+	var kept []CommitGroup
+	for _, g := range groups {
+		if len(g.Commits) > 0 {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -34,6 +35,34 @@ SUBTASKS:
 	}
 }
 
+func TestParseSubtasks_Acceptance(t *testing.T) {
+	output := `SUBTASKS:
+1. Create login endpoint - POST /auth/login with email/password (priority: high)
+   Acceptance:
+   - Returns 200 and a session cookie for valid
+     credentials
+   - Returns 401 for a wrong password
+2. Add logout - Clear the session (priority: medium)
+3. Rate limit login - Max 5 attempts a minute (priority: medium)
+   **Acceptance criteria:** the 6th attempt within a minute gets 429
+`
+
+	subtasks := ParseSubtasks(output)
+	if len(subtasks) != 3 {
+		t.Fatalf("expected 3 subtasks, got %d: %+v", len(subtasks), subtasks)
+	}
+	want := []string{"Returns 200 and a session cookie for valid credentials", "Returns 401 for a wrong password"}
+	if strings.Join(subtasks[0].Acceptance, "|") != strings.Join(want, "|") {
+		t.Errorf("subtask 0 acceptance: got %q", subtasks[0].Acceptance)
+	}
+	if len(subtasks[1].Acceptance) != 0 {
+		t.Errorf("subtask 1 has no criteria, got %q", subtasks[1].Acceptance)
+	}
+	if len(subtasks[2].Acceptance) != 1 || subtasks[2].Acceptance[0] != "the 6th attempt within a minute gets 429" {
+		t.Errorf("subtask 2 inline criterion: got %q", subtasks[2].Acceptance)
+	}
+}
+
 func TestParseSubtasks_NoPriorityDefaults(t *testing.T) {
 	output := `SUBTASKS:
 1. Do thing A - First thing
@@ -280,3 +309,117 @@ func TestParseBlocked(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFilesChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"bulleted", "Done.\n\nFILES_CHANGED:\n- internal/auth/login.go\n- internal/auth/login_test.go\n", []string{"internal/auth/login.go", "internal/auth/login_test.go"}},
+		{"descriptions and backticks", "FILES_CHANGED:\n- `api/handler.go` — added validation\n* ./api/routes.go: new route\n", []string{"api/handler.go", "api/routes.go"}},
+		{"inline", "FILES_CHANGED: a.go, b/c.go", []string{"a.go", "b/c.go"}},
+		{"markdown bold label", "**FILES_CHANGED:**\n- main.go\n\nSome trailing text", []string{"main.go"}},
+		{"none", "FILES_CHANGED: none", nil},
+		{"missing", "I changed some files.", nil},
+	}
+
+	for _, tc := range tests {
+		got := ParseFilesChanged(tc.input)
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestParseHandoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"bulleted", "Done.\n\nHANDOFF:\n- Added auth.Login(user, pass) (*Session, error)\n- POST /api/login returns 401\n\nFILES_CHANGED:\n- auth.go\n", []string{"Added auth.Login(user, pass) (*Session, error)", "POST /api/login returns 401"}},
+		{"inline keeps commas", "HANDOFF: New config keys: a, b", []string{"New config keys: a, b"}},
+		{"none", "HANDOFF: none", nil},
+		{"missing", "FILES_CHANGED: a.go", nil},
+	}
+
+	for _, tc := range tests {
+		got := ParseHandoff(tc.input)
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestParseLikelyFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"bulleted", "The session expires.\n\nLIKELY_FILES:\n- `auth/session.go:88` — nil session\n* auth/store.go\n\nProbable cause: ...", []string{"`auth/session.go:88` — nil session", "auth/store.go"}},
+		{"bold label", "**LIKELY_FILES:**\n- main.go\n", []string{"main.go"}},
+		{"inline", "LIKELY_FILES: a.go, b.go", []string{"a.go", "b.go"}},
+		{"none", "LIKELY_FILES: none", nil},
+		{"missing", "FILES_CHANGED: a.go", nil},
+	}
+
+	for _, tc := range tests {
+		got := ParseLikelyFiles(tc.input)
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestParseCommitGroups(t *testing.T) {
+	output := `SUBTASKS:
+1. Login form - The form and its validation (commits: 1, 3)
+2. Session store - Keep sessions in sqlite (commits: 2, 3, 9)
+3. Tidy up imports (commits: 5)`
+
+	groups := ParseCommitGroups(output, 5)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	if groups[0].Title != "Login form" || groups[0].Description != "The form and its validation" {
+		t.Errorf("unexpected first group: %+v", groups[0])
+	}
+	if groups[2].Title != "Tidy up imports" {
+		t.Errorf("commits not stripped from title: %q", groups[2].Title)
+	}
+	// 3 stays with the first group that lists it, 9 is out of range, and
+	// 4 isn't listed, so it joins 3.
+	want := [][]int{{0, 2, 3}, {1}, {4}}
+	for i, g := range groups {
+		if fmt.Sprint(g.Commits) != fmt.Sprint(want[i]) {
+			t.Errorf("group %d: commits %v, want %v", i, g.Commits, want[i])
+		}
+	}
+
+	if groups := ParseCommitGroups("SUBTASKS:\n1. Login form - no commits listed", 2); groups != nil {
+		t.Errorf("expected nil without commit lists, got %+v", groups)
+	}
+}
@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateLimitRetries is how often a call that got 429 Too Many Requests
+// is retried after waiting out the limit.
+const maxRateLimitRetries = 3
+
+// maxPace caps the wait before one call, so a bogus reset header can't
+// stall a run.
+const maxPace = 5 * time.Minute
+
+// RateLimit is what a provider last said about an API key's limits.
+// Counts are -1 when the provider didn't report them.
+type RateLimit struct {
+	Key               string // provider/api_key_env: agents sharing a key share its limits
+	Provider          string
+	RequestsLimit     int
+	RequestsRemaining int
+	RequestsReset     time.Time
+	TokensLimit       int
+	TokensRemaining   int
+	TokensReset       time.Time
+	RetryAfter        time.Time // Set by a 429; no calls before it
+	UpdatedAt         time.Time
+}
+
+// RateLimitHook is called whenever a provider reports new limits.
+type RateLimitHook func(RateLimit)
+
+var (
+	limitsMu  sync.Mutex
+	limiters  = map[string]*limiter{}
+	limitHook RateLimitHook
+)
+
+// SetRateLimitHook installs fn to be told about limit changes, e.g. to
+// persist them for hive doctor.
+func SetRateLimitHook(fn RateLimitHook) {
+	limitsMu.Lock()
+	limitHook = fn
+	limitsMu.Unlock()
+}
+
+// limiter paces the calls made with one API key. All runners in the
+// process using that key share it, so parallel workers wait together
+// instead of each running into 429s.
+type limiter struct {
+	mu      sync.Mutex
+	state   RateLimit
+	strikes int // 429s in a row, for backoff when there's no Retry-After
+}
+
+func limiterFor(provider, keyEnv string) *limiter {
+	key := provider + "/" + keyEnv
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	l, ok := limiters[key]
+	if !ok {
+		l = &limiter{state: RateLimit{Key: key, Provider: provider, RequestsLimit: -1, RequestsRemaining: -1, TokensLimit: -1, TokensRemaining: -1}}
+		limiters[key] = l
+	}
+	return l
+}
+
+// wait blocks until a call needing about tokens tokens is unlikely to be
+// refused, then reserves it against the remaining budget. It waits at most
+// maxPace in total.
+func (l *limiter) wait(ctx context.Context, tokens int) error {
+	deadline := time.Now().Add(maxPace)
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		d := l.delay(now, tokens)
+		if d <= 0 || !now.Before(deadline) {
+			if l.state.RequestsRemaining > 0 {
+				l.state.RequestsRemaining--
+			}
+			if l.state.TokensRemaining > 0 {
+				l.state.TokensRemaining = max(l.state.TokensRemaining-tokens, 0)
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		t := time.NewTimer(min(d, deadline.Sub(now)))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// delay is how long to wait before the next call. Caller holds l.mu.
+func (l *limiter) delay(now time.Time, tokens int) time.Duration {
+	var d time.Duration
+	if now.Before(l.state.RetryAfter) {
+		d = l.state.RetryAfter.Sub(now)
+	}
+	if l.state.RequestsRemaining == 0 && now.Before(l.state.RequestsReset) {
+		d = max(d, l.state.RequestsReset.Sub(now))
+	}
+	if l.state.TokensRemaining >= 0 && l.state.TokensRemaining < tokens && now.Before(l.state.TokensReset) {
+		d = max(d, l.state.TokensReset.Sub(now))
+	}
+	return d
+}
+
+// observe records the limits reported in a response. A 429 without a
+// Retry-After header backs off exponentially: 5s, 10s, 20s...
+func (l *limiter) observe(h http.Header, status int) {
+	now := time.Now()
+	l.mu.Lock()
+	changed := parseRateLimit(&l.state, h, now)
+	if status != http.StatusTooManyRequests {
+		l.strikes = 0
+	} else {
+		if !now.Before(l.state.RetryAfter) {
+			l.state.RetryAfter = now.Add(time.Duration(5<<min(l.strikes, 6)) * time.Second)
+			changed = true
+		}
+		l.strikes++
+	}
+	if changed {
+		l.state.UpdatedAt = now
+	}
+	state := l.state
+	l.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	limitsMu.Lock()
+	hook := limitHook
+	limitsMu.Unlock()
+	if hook != nil {
+		hook(state)
+	}
+}
+
+// parseRateLimit updates rl from OpenAI-style (x-ratelimit-*) or
+// Anthropic-style (anthropic-ratelimit-*) headers and Retry-After. It
+// reports whether any were present.
+func parseRateLimit(rl *RateLimit, h http.Header, now time.Time) bool {
+	found := false
+	setInt := func(dst *int, name string) {
+		if v, err := strconv.Atoi(strings.TrimSpace(h.Get(name))); err == nil {
+			*dst, found = v, true
+		}
+	}
+	setReset := func(dst *time.Time, name string) {
+		if t, ok := parseReset(h.Get(name), now); ok {
+			*dst, found = t, true
+		}
+	}
+
+	// OpenAI (and most OpenAI-compatible proxies).
+	setInt(&rl.RequestsLimit, "x-ratelimit-limit-requests")
+	setInt(&rl.RequestsRemaining, "x-ratelimit-remaining-requests")
+	setReset(&rl.RequestsReset, "x-ratelimit-reset-requests")
+	setInt(&rl.TokensLimit, "x-ratelimit-limit-tokens")
+	setInt(&rl.TokensRemaining, "x-ratelimit-remaining-tokens")
+	setReset(&rl.TokensReset, "x-ratelimit-reset-tokens")
+
+	// Anthropic.
+	setInt(&rl.RequestsLimit, "anthropic-ratelimit-requests-limit")
+	setInt(&rl.RequestsRemaining, "anthropic-ratelimit-requests-remaining")
+	setReset(&rl.RequestsReset, "anthropic-ratelimit-requests-reset")
+	setInt(&rl.TokensLimit, "anthropic-ratelimit-tokens-limit")
+	setInt(&rl.TokensRemaining, "anthropic-ratelimit-tokens-remaining")
+	setReset(&rl.TokensReset, "anthropic-ratelimit-tokens-reset")
+
+	if v := strings.TrimSpace(h.Get("retry-after")); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			rl.RetryAfter, found = now.Add(time.Duration(secs*float64(time.Second))), true
+		} else if t, err := http.ParseTime(v); err == nil {
+			rl.RetryAfter, found = t, true
+		}
+	}
+	return found
+}
+
+// parseReset reads a reset header: an RFC 3339 time (Anthropic) or a
+// duration from now such as "1s", "6m0s" or "20ms" (OpenAI).
+func parseReset(v string, now time.Time) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d), true
+	}
+	return time.Time{}, false
+}
+
+// estimateTokens is a rough prompt size in tokens, for pacing against a
+// token budget.
+func estimateTokens(prompt string) int {
+	return len(prompt) / 4
+}
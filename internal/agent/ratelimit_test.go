@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitOpenAI(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := http.Header{}
+	h.Set("x-ratelimit-limit-requests", "500")
+	h.Set("x-ratelimit-remaining-requests", "0")
+	h.Set("x-ratelimit-reset-requests", "6m0s")
+	h.Set("x-ratelimit-limit-tokens", "30000")
+	h.Set("x-ratelimit-remaining-tokens", "1200")
+	h.Set("x-ratelimit-reset-tokens", "20ms")
+
+	rl := RateLimit{RequestsLimit: -1, RequestsRemaining: -1, TokensLimit: -1, TokensRemaining: -1}
+	if !parseRateLimit(&rl, h, now) {
+		t.Fatal("expected headers to be found")
+	}
+	if rl.RequestsLimit != 500 || rl.RequestsRemaining != 0 || rl.TokensLimit != 30000 || rl.TokensRemaining != 1200 {
+		t.Errorf("unexpected counts: %+v", rl)
+	}
+	if !rl.RequestsReset.Equal(now.Add(6*time.Minute)) || !rl.TokensReset.Equal(now.Add(20*time.Millisecond)) {
+		t.Errorf("unexpected resets: %v, %v", rl.RequestsReset, rl.TokensReset)
+	}
+}
+
+func TestParseRateLimitAnthropic(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := http.Header{}
+	h.Set("anthropic-ratelimit-requests-limit", "50")
+	h.Set("anthropic-ratelimit-requests-remaining", "49")
+	h.Set("anthropic-ratelimit-requests-reset", "2026-01-02T03:05:00Z")
+	h.Set("retry-after", "12")
+
+	rl := RateLimit{RequestsLimit: -1, RequestsRemaining: -1, TokensLimit: -1, TokensRemaining: -1}
+	if !parseRateLimit(&rl, h, now) {
+		t.Fatal("expected headers to be found")
+	}
+	if rl.RequestsLimit != 50 || rl.RequestsRemaining != 49 || rl.TokensLimit != -1 {
+		t.Errorf("unexpected counts: %+v", rl)
+	}
+	if want := time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC); !rl.RequestsReset.Equal(want) {
+		t.Errorf("requests reset = %v, want %v", rl.RequestsReset, want)
+	}
+	if !rl.RetryAfter.Equal(now.Add(12 * time.Second)) {
+		t.Errorf("retry after = %v", rl.RetryAfter)
+	}
+
+	if parseRateLimit(&rl, http.Header{}, now) {
+		t.Error("no headers should report nothing found")
+	}
+}
+
+func TestLimiterPaces(t *testing.T) {
+	l := &limiter{state: RateLimit{RequestsLimit: -1, RequestsRemaining: -1, TokensLimit: -1, TokensRemaining: -1}}
+	now := time.Now()
+
+	if d := l.delay(now, 100); d != 0 {
+		t.Errorf("unknown limits should not delay, got %v", d)
+	}
+
+	l.state.RequestsRemaining = 0
+	l.state.RequestsReset = now.Add(2 * time.Second)
+	if d := l.delay(now, 100); d != 2*time.Second {
+		t.Errorf("exhausted requests: delay %v, want 2s", d)
+	}
+
+	l.state.RequestsRemaining = 10
+	l.state.TokensRemaining = 50
+	l.state.TokensReset = now.Add(3 * time.Second)
+	if d := l.delay(now, 100); d != 3*time.Second {
+		t.Errorf("too few tokens: delay %v, want 3s", d)
+	}
+	if d := l.delay(now, 10); d != 0 {
+		t.Errorf("enough tokens should not delay, got %v", d)
+	}
+
+	l.state.RetryAfter = now.Add(5 * time.Second)
+	if d := l.delay(now, 10); d != 5*time.Second {
+		t.Errorf("after 429: delay %v, want 5s", d)
+	}
+}
+
+func TestLimiterWaitReserves(t *testing.T) {
+	l := &limiter{state: RateLimit{RequestsRemaining: 2, TokensRemaining: -1}}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := l.wait(ctx, 0); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if l.state.RequestsRemaining != 0 {
+		t.Errorf("expected both requests reserved, %d left", l.state.RequestsRemaining)
+	}
+
+	// Out of requests until a reset in the future: wait honors ctx.
+	l.state.RequestsReset = time.Now().Add(time.Hour)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, 0); err == nil {
+		t.Error("expected wait to stop at the context deadline")
+	}
+}
+
+func TestLimiterObserve429Backoff(t *testing.T) {
+	l := &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}}
+	before := time.Now()
+	l.observe(http.Header{}, http.StatusTooManyRequests)
+	if got := l.state.RetryAfter.Sub(before); got < 5*time.Second || got > 6*time.Second {
+		t.Errorf("first 429 without Retry-After: backoff %v, want ~5s", got)
+	}
+	if l.strikes != 1 {
+		t.Errorf("strikes = %d, want 1", l.strikes)
+	}
+	l.observe(http.Header{}, http.StatusOK)
+	if l.strikes != 0 {
+		t.Errorf("success should reset strikes, got %d", l.strikes)
+	}
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"strings"
+	"unicode"
+)
+
+// repeatThreshold is how alike two rejections must be, as the Jaccard
+// similarity of their words, to count as the same rejection.
+const repeatThreshold = 0.8
+
+// SameRejection reports whether two rejecting reviews raise essentially
+// the same objections: their comments share most of their words. Line
+// numbers and other digits are ignored, since they shift as the coder
+// edits. Reviews without comments are compared by their full output.
+func SameRejection(a, b ParsedReview, aOutput, bOutput string) bool {
+	if a.Verdict != "REJECT" || b.Verdict != "REJECT" {
+		return false
+	}
+	textA, textB := strings.Join(a.Comments, "\n"), strings.Join(b.Comments, "\n")
+	if textA == "" || textB == "" {
+		textA, textB = aOutput, bOutput
+	}
+	return similarity(textA, textB) >= repeatThreshold
+}
+
+// similarity is the Jaccard index of the word sets of a and b.
+func similarity(a, b string) float64 {
+	wa, wb := wordSet(a), wordSet(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+func wordSet(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
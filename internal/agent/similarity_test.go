@@ -0,0 +1,41 @@
+package agent
+
+import "testing"
+
+func TestSameRejection(t *testing.T) {
+	first := ParsedReview{Verdict: "REJECT", Comments: []string{
+		"[HIGH] auth.go:42: password compared with == instead of constant time",
+		"[HIGH] auth.go:57: session token not invalidated on logout",
+	}}
+	// Same objections; line numbers moved after the coder's edits.
+	again := ParsedReview{Verdict: "REJECT", Comments: []string{
+		"[HIGH] auth.go:45: password compared with == instead of constant time",
+		"[HIGH] auth.go:61: session token not invalidated on logout",
+	}}
+	different := ParsedReview{Verdict: "REJECT", Comments: []string{
+		"[HIGH] store.go:10: migration drops the users table",
+	}}
+
+	if !SameRejection(first, again, "", "") {
+		t.Error("expected rejections differing only in line numbers to match")
+	}
+	if SameRejection(first, different, "", "") {
+		t.Error("expected different objections not to match")
+	}
+
+	approve := ParsedReview{Verdict: "APPROVE", Comments: first.Comments}
+	if SameRejection(first, approve, "", "") {
+		t.Error("an approval is never a repeated rejection")
+	}
+}
+
+func TestSameRejection_NoComments(t *testing.T) {
+	a := ParsedReview{Verdict: "REJECT"}
+	b := ParsedReview{Verdict: "REJECT"}
+	if !SameRejection(a, b, "VERDICT: REJECT\nNo changes were made.", "VERDICT: REJECT\nNo changes were made.") {
+		t.Error("expected identical outputs to match")
+	}
+	if SameRejection(a, b, "VERDICT: REJECT\nNo changes were made.", "VERDICT: REJECT\nThe handler panics on empty input and the tests fail.") {
+		t.Error("expected different outputs not to match")
+	}
+}
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// lineWriter passes output to a Request.Stream callback a line at a time,
+// as it arrives. Close flushes a last line without a newline.
+type lineWriter struct {
+	fn  func(string)
+	buf []byte
+}
+
+func newLineWriter(fn func(string)) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) WriteString(s string) {
+	w.Write([]byte(s))
+}
+
+func (w *lineWriter) Close() {
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// streamAll sends output that arrived in one piece (fake and replayed
+// agents) to a Request.Stream callback.
+func streamAll(fn func(string), output string) {
+	if fn == nil || output == "" {
+		return
+	}
+	w := newLineWriter(fn)
+	w.WriteString(output)
+	w.Close()
+}
+
+// readSSE calls fn with the data of each server-sent event in body until
+// the stream ends or fn returns an error.
+func readSSE(body io.Reader, fn func(data []byte) error) error {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		data, ok := bytes.CutPrefix(sc.Bytes(), []byte("data:"))
+		if !ok {
+			continue // Comments, event: lines and the blank line after each event.
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || string(data) == "[DONE]" {
+			continue
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// streamFailed is the Response for a stream that broke off: what arrived
+// is kept, as with a CLI agent that exits early.
+func streamFailed(output string, start time.Time, err error) *Response {
+	return &Response{
+		Output:   output,
+		ExitCode: -1,
+		Duration: time.Since(start).Seconds(),
+		Error:    fmt.Errorf("API stream failed: %w", err),
+	}
+}
+
+// readOpenAIStream reads a chat completions stream (stream: true with
+// include_usage), passing the text to fn as it arrives.
+func readOpenAIStream(body io.Reader, fn func(string), start time.Time) (*Response, error) {
+	w := newLineWriter(fn)
+	var out strings.Builder
+	resp := &Response{}
+	err := readSSE(body, func(data []byte) error {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("parse stream: %w", err)
+		}
+		for _, c := range chunk.Choices {
+			out.WriteString(c.Delta.Content)
+			w.WriteString(c.Delta.Content)
+			if c.FinishReason != "" {
+				resp.Truncated = c.FinishReason == "length"
+			}
+		}
+		if chunk.Usage != nil {
+			resp.InputTokens = chunk.Usage.PromptTokens
+			resp.OutputTokens = chunk.Usage.CompletionTokens
+		}
+		return nil
+	})
+	w.Close()
+	if err != nil {
+		return streamFailed(out.String(), start, err), nil
+	}
+	resp.Output = out.String()
+	resp.Duration = time.Since(start).Seconds()
+	return resp, nil
+}
+
+// readAnthropicStream reads a Messages API stream, passing the text to fn
+// as it arrives.
+func readAnthropicStream(body io.Reader, fn func(string), start time.Time) (*Response, error) {
+	w := newLineWriter(fn)
+	var out strings.Builder
+	resp := &Response{}
+	err := readSSE(body, func(data []byte) error {
+		var ev struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage struct {
+					InputTokens int `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Text       string `json:"text"`
+				StopReason string `json:"stop_reason"`
+			} `json:"delta"`
+			Usage struct {
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return fmt.Errorf("parse stream: %w", err)
+		}
+		switch ev.Type {
+		case "message_start":
+			resp.InputTokens = ev.Message.Usage.InputTokens
+		case "content_block_delta":
+			out.WriteString(ev.Delta.Text)
+			w.WriteString(ev.Delta.Text)
+		case "message_delta":
+			resp.Truncated = ev.Delta.StopReason == "max_tokens"
+			resp.OutputTokens = ev.Usage.OutputTokens
+		case "error":
+			return fmt.Errorf("%s", ev.Error.Message)
+		}
+		return nil
+	})
+	w.Close()
+	if err != nil {
+		return streamFailed(out.String(), start, err), nil
+	}
+	resp.Output = out.String()
+	resp.Duration = time.Since(start).Seconds()
+	return resp, nil
+}
+
+// readGoogleStream reads a streamGenerateContent stream (alt=sse), passing
+// the text to fn as it arrives.
+func readGoogleStream(body io.Reader, fn func(string), start time.Time) (*Response, error) {
+	w := newLineWriter(fn)
+	var out strings.Builder
+	resp := &Response{}
+	err := readSSE(body, func(data []byte) error {
+		var chunk struct {
+			Candidates []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+				FinishReason string `json:"finishReason"`
+			} `json:"candidates"`
+			UsageMetadata struct {
+				PromptTokenCount     int `json:"promptTokenCount"`
+				CandidatesTokenCount int `json:"candidatesTokenCount"`
+			} `json:"usageMetadata"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("parse stream: %w", err)
+		}
+		if len(chunk.Candidates) > 0 {
+			for _, p := range chunk.Candidates[0].Content.Parts {
+				out.WriteString(p.Text)
+				w.WriteString(p.Text)
+			}
+			if reason := chunk.Candidates[0].FinishReason; reason != "" {
+				resp.Truncated = reason == "MAX_TOKENS"
+			}
+		}
+		// Each chunk carries the totals so far.
+		if chunk.UsageMetadata.PromptTokenCount > 0 {
+			resp.InputTokens = chunk.UsageMetadata.PromptTokenCount
+			resp.OutputTokens = chunk.UsageMetadata.CandidatesTokenCount
+		}
+		return nil
+	})
+	w.Close()
+	if err != nil {
+		return streamFailed(out.String(), start, err), nil
+	}
+	resp.Output = out.String()
+	resp.Duration = time.Since(start).Seconds()
+	return resp, nil
+}
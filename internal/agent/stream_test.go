@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := newLineWriter(func(l string) { lines = append(lines, l) })
+	w.WriteString("first li")
+	w.WriteString("ne\r\nsecond\n\nthi")
+	w.WriteString("rd")
+	w.Close()
+
+	want := []string{"first line", "second", "", "third"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, lines)
+	}
+}
+
+func TestAPIRunnerStreams(t *testing.T) {
+	cases := []struct {
+		provider string
+		events   string
+	}{
+		{"openai", `data: {"choices":[{"delta":{"content":"Looking at "},"finish_reason":null}]}
+
+data: {"choices":[{"delta":{"content":"the code\nVERDICT: "},"finish_reason":null}]}
+
+data: {"choices":[{"delta":{"content":"APPROVE"},"finish_reason":"stop"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":7}}
+
+data: [DONE]
+`},
+		{"anthropic", `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Looking at the code\n"}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"VERDICT: APPROVE"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}
+
+event: message_stop
+data: {"type":"message_stop"}
+`},
+		{"google", `data: {"candidates":[{"content":{"parts":[{"text":"Looking at the code\nVERDICT"}]}}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":3}}
+
+data: {"candidates":[{"content":{"parts":[{"text":": APPROVE"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":7}}
+`},
+	}
+	for _, c := range cases {
+		t.Run(c.provider, func(t *testing.T) {
+			var body map[string]any
+			var url string
+			r := &APIRunner{
+				name:   "api",
+				cfg:    config.Agent{Provider: c.provider, Model: "m"},
+				limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+				client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					url = req.URL.String()
+					json.NewDecoder(req.Body).Decode(&body)
+					return jsonResponse(c.events), nil
+				})},
+			}
+
+			var lines []string
+			resp, err := r.Run(context.Background(), Request{Prompt: "Review it", Stream: func(l string) { lines = append(lines, l) }})
+			if err != nil || resp.Error != nil {
+				t.Fatalf("Run: %v %v", err, resp.Error)
+			}
+			if resp.Output != "Looking at the code\nVERDICT: APPROVE" {
+				t.Errorf("output %q", resp.Output)
+			}
+			if strings.Join(lines, "|") != "Looking at the code|VERDICT: APPROVE" {
+				t.Errorf("streamed %q", lines)
+			}
+			if resp.InputTokens != 12 || resp.OutputTokens != 7 || resp.Truncated {
+				t.Errorf("tokens %d/%d truncated=%v", resp.InputTokens, resp.OutputTokens, resp.Truncated)
+			}
+			if c.provider == "google" {
+				if !strings.Contains(url, ":streamGenerateContent?alt=sse") {
+					t.Errorf("expected the streaming endpoint, got %s", url)
+				}
+			} else if body["stream"] != true {
+				t.Errorf("stream not requested: %v", body)
+			}
+		})
+	}
+}
+
+func TestAPIRunnerStreamTruncated(t *testing.T) {
+	answers := []string{
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"SUBTASKS:\n- [high] one"}}
+data: {"type":"message_delta","delta":{"stop_reason":"max_tokens"},"usage":{"output_tokens":5}}
+`,
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"\n- [low] two"}}
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}
+`,
+	}
+	calls := 0
+	r := &APIRunner{
+		name:   "claude",
+		cfg:    config.Agent{Provider: "anthropic", Model: "m"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return jsonResponse(answers[calls-1]), nil
+		})},
+	}
+
+	var lines []string
+	resp, err := r.Run(context.Background(), Request{Prompt: "Plan it", Stream: func(l string) { lines = append(lines, l) }})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if resp.Output != "SUBTASKS:\n- [high] one\n- [low] two" || resp.Continuations != 1 {
+		t.Errorf("output %q after %d continuations", resp.Output, resp.Continuations)
+	}
+	if resp.OutputTokens != 8 {
+		t.Errorf("expected 8 output tokens, got %d", resp.OutputTokens)
+	}
+	// The continuation streams too.
+	if strings.Join(lines, "|") != "SUBTASKS:|- [high] one||- [low] two" {
+		t.Errorf("streamed %q", lines)
+	}
+}
+
+func TestAPIRunnerStreamError(t *testing.T) {
+	r := &APIRunner{
+		name:   "claude",
+		cfg:    config.Agent{Provider: "anthropic", Model: "m"},
+		limits: &limiter{state: RateLimit{RequestsRemaining: -1, TokensRemaining: -1}},
+		client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"partial"}}
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+`), nil
+		})},
+	}
+	resp, err := r.Run(context.Background(), Request{Prompt: "x", Stream: func(string) {}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if resp.ExitCode == 0 || resp.Error == nil || !strings.Contains(resp.Error.Error(), "Overloaded") {
+		t.Errorf("expected a failed response, got exit %d, %v", resp.ExitCode, resp.Error)
+	}
+	if resp.Output != "partial" {
+		t.Errorf("partial output lost: %q", resp.Output)
+	}
+}
+
+func TestCLIRunnerStreams(t *testing.T) {
+	r := NewCLIRunner("sh", config.Agent{Cmd: "sh", Args: []string{"-c", `printf 'one\ntwo\nthree'`}})
+	var lines []string
+	resp, err := r.Run(context.Background(), Request{Prompt: "ignored", WorkDir: t.TempDir(), Stream: func(l string) { lines = append(lines, l) }})
+	if err != nil || resp.ExitCode != 0 {
+		t.Fatalf("Run: %v (exit %d)", err, resp.ExitCode)
+	}
+	if resp.Output != "one\ntwo\nthree" {
+		t.Errorf("output %q", resp.Output)
+	}
+	if strings.Join(lines, "|") != "one|two|three" {
+		t.Errorf("streamed %q", lines)
+	}
+}
+
+func TestFakeRunnerStreams(t *testing.T) {
+	r := NewFakeRunner("fake", config.Agent{Role: "reviewer", Mode: "fake"})
+	var lines []string
+	resp, err := r.Run(context.Background(), Request{TaskID: 1, WorkDir: t.TempDir(), Stream: func(l string) { lines = append(lines, l) }})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if strings.Join(lines, "\n") != strings.TrimSuffix(resp.Output, "\n") {
+		t.Errorf("streamed %q, output %q", lines, resp.Output)
+	}
+}
+
+func TestRunnerSanitizesOutput(t *testing.T) {
+	r, err := NewRunner("sh", config.Agent{Mode: "cli", Cmd: "sh", Args: []string{"-c", `printf '\033[32mok\033[0m\n⠋ wait\r⠙ wait\rdone\n'`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	resp, err := r.Run(context.Background(), Request{Prompt: "ignored", WorkDir: t.TempDir(), Stream: func(l string) { lines = append(lines, l) }})
+	if err != nil || resp.ExitCode != 0 {
+		t.Fatalf("Run: %v (exit %d)", err, resp.ExitCode)
+	}
+	if resp.Output != "ok\ndone\n" {
+		t.Errorf("output %q", resp.Output)
+	}
+	if strings.Join(lines, "|") != "ok|done" {
+		t.Errorf("streamed %q", lines)
+	}
+}
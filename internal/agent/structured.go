@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Format is the shape of answer a request asks for, for providers that
+// can be held to a JSON schema.
+type Format string
+
+const (
+	FormatPlan   Format = "plan"   // SUBTASKS: or BLOCKED:, see ParseSubtasks
+	FormatReview Format = "review" // VERDICT:, COMMENTS:, CHECKLIST:, ACCEPTANCE:, see ParseReview
+)
+
+// structuredPlan is a plan answered in JSON.
+type structuredPlan struct {
+	Subtasks []struct {
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Priority    string   `json:"priority"`
+		Acceptance  []string `json:"acceptance"`
+	} `json:"subtasks"`
+	Blocked string `json:"blocked"`
+}
+
+// structuredAnswer is a checklist item or acceptance criterion answered
+// in JSON.
+type structuredAnswer struct {
+	Item   string `json:"item"`
+	Answer string `json:"answer"`
+	Note   string `json:"note"`
+}
+
+// structuredReview is a review answered in JSON.
+type structuredReview struct {
+	Verdict     string             `json:"verdict"`
+	Comments    []string           `json:"comments"`
+	Checklist   []structuredAnswer `json:"checklist"`
+	Acceptance  []structuredAnswer `json:"acceptance"`
+	Suggestions []string           `json:"suggestions"`
+}
+
+// schemaFor returns the JSON schema of a format, in the strict form
+// OpenAI wants: every object closed, every property required.
+func schemaFor(f Format) map[string]any {
+	str := func(desc string) map[string]any { return map[string]any{"type": "string", "description": desc} }
+	enum := func(desc string, values ...string) map[string]any {
+		return map[string]any{"type": "string", "enum": values, "description": desc}
+	}
+	list := func(desc string, items map[string]any) map[string]any {
+		return map[string]any{"type": "array", "items": items, "description": desc}
+	}
+	object := func(props map[string]any) map[string]any {
+		required := make([]string, 0, len(props))
+		for name := range props {
+			required = append(required, name)
+		}
+		sort.Strings(required)
+		return map[string]any{"type": "object", "properties": props, "required": required, "additionalProperties": false}
+	}
+	answer := func(item string) map[string]any {
+		return object(map[string]any{
+			"item":   str(item),
+			"answer": enum("", "PASS", "FAIL", "N/A"),
+			"note":   str("Why, in one line"),
+		})
+	}
+
+	switch f {
+	case FormatPlan:
+		return object(map[string]any{
+			"subtasks": list("The subtasks, in the order to do them", object(map[string]any{
+				"title":       str("Specific and actionable, naming the file or component"),
+				"description": str("What to do"),
+				"priority":    enum("", "high", "medium", "low"),
+				"acceptance":  list("What must be true when it is done", str("")),
+			})),
+			"blocked": str("Your question, if the task is unclear even after reading the code; otherwise empty"),
+		})
+	case FormatReview:
+		return object(map[string]any{
+			"verdict":     enum("", "APPROVE", "REJECT"),
+			"comments":    list("Issues found, most severe first", str("")),
+			"checklist":   list("An answer to every review checklist item, if there is a checklist", answer("The checklist item, exactly as given")),
+			"acceptance":  list("An answer to every acceptance criterion, if the task has any", answer("The criterion's label: AC1, AC2, ...")),
+			"suggestions": list("Optional fixes as unified diffs", str("")),
+		})
+	}
+	return nil
+}
+
+// googleSchema is a schema without additionalProperties, which Gemini's
+// responseSchema doesn't accept.
+func googleSchema(schema map[string]any) map[string]any {
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		switch v := v.(type) {
+		case map[string]any:
+			out[k] = googleSchema(v)
+		default:
+			if k != "additionalProperties" {
+				out[k] = v
+			}
+		}
+	}
+	return out
+}
+
+// renderStructured validates a JSON answer of the given format and writes
+// it in the text format the parsers read, so a plan or review parses the
+// same whether it was asked for as JSON or not.
+func renderStructured(f Format, output string) (string, error) {
+	data := strings.TrimSpace(output)
+	// Some models fence JSON even in JSON mode.
+	if rest, ok := strings.CutPrefix(data, "```"); ok {
+		rest = strings.TrimPrefix(rest, "json")
+		data = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(data)))
+	dec.DisallowUnknownFields()
+
+	switch f {
+	case FormatPlan:
+		var plan structuredPlan
+		if err := dec.Decode(&plan); err != nil {
+			return "", fmt.Errorf("plan: %w", err)
+		}
+		return renderPlan(plan)
+	case FormatReview:
+		var review structuredReview
+		if err := dec.Decode(&review); err != nil {
+			return "", fmt.Errorf("review: %w", err)
+		}
+		return renderReview(review)
+	}
+	return "", fmt.Errorf("unknown format %q", f)
+}
+
+func renderPlan(plan structuredPlan) (string, error) {
+	blocked := oneLine(plan.Blocked)
+	switch {
+	case blocked != "" && len(plan.Subtasks) > 0:
+		return "", fmt.Errorf("plan: both subtasks and blocked")
+	case blocked != "":
+		return "BLOCKED: " + blocked + "\n", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SUBTASKS:\n")
+	for i, t := range plan.Subtasks {
+		// " - " separates the title from the description.
+		title := strings.ReplaceAll(oneLine(t.Title), " - ", " – ")
+		if title == "" {
+			return "", fmt.Errorf("plan: subtask %d has no title", i+1)
+		}
+		switch t.Priority {
+		case "high", "medium", "low":
+		default:
+			return "", fmt.Errorf("plan: subtask %d: priority %q", i+1, t.Priority)
+		}
+		line := fmt.Sprintf("%d. %s", i+1, title)
+		if d := oneLine(t.Description); d != "" {
+			line += " - " + d
+		}
+		fmt.Fprintf(&sb, "%s (priority: %s)\n", line, t.Priority)
+		if len(t.Acceptance) > 0 {
+			sb.WriteString("   Acceptance:\n")
+			for _, c := range t.Acceptance {
+				if c = oneLine(c); c != "" {
+					sb.WriteString("   - " + c + "\n")
+				}
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func renderReview(review structuredReview) (string, error) {
+	if review.Verdict != "APPROVE" && review.Verdict != "REJECT" {
+		return "", fmt.Errorf("review: verdict %q", review.Verdict)
+	}
+	var sb strings.Builder
+	section := func(header string, answers []structuredAnswer) error {
+		if len(answers) == 0 {
+			return nil
+		}
+		sb.WriteString(header + "\n")
+		for _, a := range answers {
+			switch a.Answer {
+			case "PASS", "FAIL", "N/A":
+			default:
+				return fmt.Errorf("review: %s %q: answer %q", strings.TrimSuffix(strings.ToLower(header), ":"), a.Item, a.Answer)
+			}
+			line := fmt.Sprintf("- %s: %s", oneLine(a.Item), a.Answer)
+			if n := oneLine(a.Note); n != "" {
+				line += " — " + n
+			}
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("\n")
+		return nil
+	}
+
+	if len(review.Comments) > 0 {
+		sb.WriteString("COMMENTS:\n")
+		for _, c := range review.Comments {
+			if c = oneLine(c); c != "" {
+				sb.WriteString("- " + c + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if err := section("CHECKLIST:", review.Checklist); err != nil {
+		return "", err
+	}
+	if err := section("ACCEPTANCE:", review.Acceptance); err != nil {
+		return "", err
+	}
+	for _, s := range review.Suggestions {
+		if s = strings.Trim(s, "\n"); s != "" {
+			sb.WriteString("```diff\n" + s + "\n```\n\n")
+		}
+	}
+	// The verdict goes last, as the prompt asks for it.
+	sb.WriteString("VERDICT: " + review.Verdict + "\n")
+	return sb.String(), nil
+}
+
+// oneLine joins a multi-line value into one line, as the text formats
+// have one entry per line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestRenderStructuredPlan(t *testing.T) {
+	out, err := renderStructured(FormatPlan, "```json\n"+`{"subtasks":[
+		{"title":"Add rate limit - login","description":"Limit attempts\nper IP","priority":"high","acceptance":["6th attempt gets 429"]},
+		{"title":"Document it","description":"","priority":"low","acceptance":[]}
+	],"blocked":""}`+"\n```")
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	subs := ParseSubtasks(out)
+	if len(subs) != 2 {
+		t.Fatalf("expected 2 subtasks, got %d from:\n%s", len(subs), out)
+	}
+	if subs[0].Title != "Add rate limit – login" || subs[0].Description != "Limit attempts per IP" || subs[0].Priority != "high" {
+		t.Errorf("first subtask: %+v", subs[0])
+	}
+	if len(subs[0].Acceptance) != 1 || subs[0].Acceptance[0] != "6th attempt gets 429" {
+		t.Errorf("acceptance: %q", subs[0].Acceptance)
+	}
+	if subs[1].Title != "Document it" || subs[1].Priority != "low" {
+		t.Errorf("second subtask: %+v", subs[1])
+	}
+
+	out, err = renderStructured(FormatPlan, `{"subtasks":[],"blocked":"Which API?"}`)
+	if err != nil || ParseBlocked(out) != "Which API?" {
+		t.Errorf("blocked: %q, %v", out, err)
+	}
+}
+
+func TestRenderStructuredReview(t *testing.T) {
+	out, err := renderStructured(FormatReview, `{
+		"verdict":"REJECT",
+		"comments":["No test for the 429 path"],
+		"checklist":[{"item":"tests added","answer":"FAIL","note":"none"}],
+		"acceptance":[{"item":"AC1","answer":"PASS","note":""}],
+		"suggestions":["--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b\n"]
+	}`)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	checklist := []config.ChecklistItem{{Item: "tests added"}}
+	review := CheckAcceptance(ParseReviewWithChecklist(out, checklist), out, []string{"Returns 429"})
+	if review.Verdict != "REJECT" {
+		t.Errorf("verdict: %q", review.Verdict)
+	}
+	if review.Checklist[0].Answer != "FAIL" || review.Acceptance[0].Answer != "PASS" {
+		t.Errorf("answers: %+v %+v", review.Checklist, review.Acceptance)
+	}
+	if len(review.Suggestions) != 1 {
+		t.Errorf("suggestions: %q", review.Suggestions)
+	}
+	if !strings.Contains(strings.Join(review.Comments, "\n"), "No test for the 429 path") {
+		t.Errorf("comments: %q", review.Comments)
+	}
+}
+
+func TestRenderStructuredRejectsInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		format Format
+		output string
+	}{
+		{FormatReview, `VERDICT: APPROVE`},
+		{FormatReview, `{"verdict":"MAYBE","comments":[],"checklist":[],"acceptance":[],"suggestions":[]}`},
+		{FormatReview, `{"verdict":"APPROVE","rating":5}`},
+		{FormatReview, `{"verdict":"APPROVE","checklist":[{"item":"x","answer":"YES","note":""}]}`},
+		{FormatPlan, `{"subtasks":[{"title":"","description":"d","priority":"high","acceptance":[]}],"blocked":""}`},
+		{FormatPlan, `{"subtasks":[{"title":"t","description":"d","priority":"urgent","acceptance":[]}],"blocked":""}`},
+		{FormatPlan, `{"subtasks":[{"title":"t","description":"d","priority":"low","acceptance":[]}],"blocked":"why?"}`},
+	} {
+		if out, err := renderStructured(tt.format, tt.output); err == nil {
+			t.Errorf("%s %s: expected an error, got %q", tt.format, tt.output, out)
+		}
+	}
+}
+
+func TestGoogleSchemaDropsAdditionalProperties(t *testing.T) {
+	schema := googleSchema(schemaFor(FormatReview))
+	var walk func(m map[string]any)
+	walk = func(m map[string]any) {
+		if _, ok := m["additionalProperties"]; ok {
+			t.Errorf("additionalProperties left in %v", m)
+		}
+		for _, v := range m {
+			if sub, ok := v.(map[string]any); ok {
+				walk(sub)
+			}
+		}
+	}
+	walk(schema)
+	if schema["type"] != "object" {
+		t.Errorf("schema: %v", schema)
+	}
+}
@@ -0,0 +1,98 @@
+package agent
+
+import "strings"
+
+// ParseSuggestions extracts the patches a reviewer suggests, as fenced
+// ```diff or ```patch blocks holding a unified diff:
+//
+//	```diff
+//	--- a/auth/login.go
+//	+++ b/auth/login.go
+//	@@ -10,3 +10,3 @@
+//	-	if err != nil {
+//	+	if err != nil && !errors.Is(err, io.EOF) {
+//	```
+//
+// Blocks without file headers and a hunk are illustrations, not patches,
+// and are skipped.
+func ParseSuggestions(output string) []string {
+	var patches []string
+	var block []string
+	in := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !in {
+			lang := strings.ToLower(strings.TrimPrefix(trimmed, "```"))
+			if strings.HasPrefix(trimmed, "```") && (lang == "diff" || lang == "patch") {
+				in, block = true, nil
+			}
+			continue
+		}
+		if trimmed == "```" {
+			in = false
+			patch := strings.Join(block, "\n") + "\n"
+			if isUnifiedDiff(patch) {
+				patches = append(patches, patch)
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	return patches
+}
+
+func isUnifiedDiff(patch string) bool {
+	return strings.Contains(patch, "--- ") && strings.Contains(patch, "+++ ") && strings.Contains(patch, "@@")
+}
+
+// PatchFiles returns the files a unified diff changes, from its +++ lines
+// (the --- line for deletions).
+func PatchFiles(patch string) []string {
+	var files []string
+	seen := map[string]bool{}
+	var from string
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			from = patchPath(line[4:])
+		case strings.HasPrefix(line, "+++ "):
+			path := patchPath(line[4:])
+			if path == "/dev/null" {
+				path = from
+			}
+			if path != "" && path != "/dev/null" && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+// patchPath strips the a/ or b/ prefix and any timestamp from a diff
+// header path.
+func patchPath(p string) string {
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i]
+	}
+	p = strings.TrimSpace(p)
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+// PatchSize returns the number of added plus removed lines in a unified
+// diff.
+func PatchSize(patch string) int {
+	n := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package agent
+
+import "testing"
+
+const reviewWithPatch = "VERDICT: REJECT\n\nCOMMENTS:\n- [HIGH] auth.go:3: wrong constant\n\n" +
+	"```diff\n--- a/auth.go\n+++ b/auth.go\n@@ -1,3 +1,3 @@\n package auth\n \n-const maxTries = 0\n+const maxTries = 3\n```\n\n" +
+	"Illustration only:\n```diff\n-old\n+new\n```\n"
+
+func TestParseSuggestions(t *testing.T) {
+	patches := ParseSuggestions(reviewWithPatch)
+	if len(patches) != 1 {
+		t.Fatalf("expected 1 patch (illustrations skipped), got %d: %q", len(patches), patches)
+	}
+	if PatchSize(patches[0]) != 2 {
+		t.Errorf("expected 2 changed lines, got %d", PatchSize(patches[0]))
+	}
+	if files := PatchFiles(patches[0]); len(files) != 1 || files[0] != "auth.go" {
+		t.Errorf("expected [auth.go], got %v", files)
+	}
+
+	if r := ParseReview(reviewWithPatch); len(r.Suggestions) != 1 {
+		t.Errorf("ParseReview should collect suggestions, got %d", len(r.Suggestions))
+	}
+}
+
+func TestParseSuggestions_None(t *testing.T) {
+	if p := ParseSuggestions("VERDICT: APPROVE\n```go\nfunc x() {}\n```\n"); len(p) != 0 {
+		t.Errorf("expected no patches, got %q", p)
+	}
+}
+
+func TestPatchFiles_NewAndDeleted(t *testing.T) {
+	patch := "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package x\n" +
+		"--- a/old.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-package x\n"
+	files := PatchFiles(patch)
+	if len(files) != 2 || files[0] != "new.go" || files[1] != "old.go" {
+		t.Errorf("expected [new.go old.go], got %v", files)
+	}
+}
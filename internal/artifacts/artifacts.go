@@ -0,0 +1,128 @@
+// Package artifacts saves agent outputs under .hive/runs and registers
+// them with the store. It owns where run files live, so callers working
+// in a git worktree or another directory still write to the project's
+// .hive, not a copy relative to their own working directory.
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/redact"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// RunsDir is the runs directory relative to the project root.
+const RunsDir = ".hive/runs"
+
+// TypeImage is the artifact type of images attached to a task with
+// hive task attach. Agents get them with the task's prompt.
+const TypeImage = "image"
+
+// Manager writes run artifacts for one project.
+type Manager struct {
+	store *store.Store
+	root  string // Absolute project root (the directory holding .hive)
+}
+
+// New creates a Manager for the project at root. A relative root is made
+// absolute against the current directory once, here.
+func New(s *store.Store, root string) *Manager {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Manager{store: s, root: root}
+}
+
+// Dir returns the absolute runs directory.
+func (m *Manager) Dir() string {
+	return filepath.Join(m.root, RunsDir)
+}
+
+// Path returns the absolute path of a file in the runs directory.
+func (m *Manager) Path(name string) string {
+	return filepath.Join(m.Dir(), name)
+}
+
+// Resolve turns a recorded artifact path into an absolute one.
+func (m *Manager) Resolve(recorded string) string {
+	if filepath.IsAbs(recorded) {
+		return recorded
+	}
+	return filepath.Join(m.root, recorded)
+}
+
+// Name builds the conventional artifact file name,
+// e.g. Name(3, "auto-code", "iter2") is "task-3-auto-code-iter2.md".
+func Name(taskID int64, parts ...string) string {
+	return fmt.Sprintf("task-%d-%s.md", taskID, strings.Join(parts, "-"))
+}
+
+// PatchName is Name for a patch file, e.g. PatchName(3, "suggestion",
+// "iter1") is "task-3-suggestion-iter1.patch".
+func PatchName(taskID int64, parts ...string) string {
+	return fmt.Sprintf("task-%d-%s.patch", taskID, strings.Join(parts, "-"))
+}
+
+// Save writes content to name in the runs directory and records it as an
+// artifact of the task, with secrets masked. The recorded path is relative to the project root,
+// so the database stays valid if the project moves. Returns the absolute
+// path written.
+func (m *Manager) Save(taskID int64, artifactType, name, content string) (string, error) {
+	data := []byte(redact.String(content))
+	if err := disk.Check(int64(len(data))); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(m.Dir(), 0755); err != nil {
+		return "", fmt.Errorf("create runs dir: %w", err)
+	}
+	path := m.Path(name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write artifact: %w", err)
+	}
+	disk.Wrote(int64(len(data)))
+	if m.store != nil {
+		if err := m.store.AddArtifact(taskID, artifactType, filepath.ToSlash(filepath.Join(RunsDir, name))); err != nil {
+			return path, fmt.Errorf("record artifact: %w", err)
+		}
+	}
+	return path, nil
+}
+
+// Attach copies a user's file into the runs directory as an artifact of
+// the task, e.g. a screenshot. The copy is named after the original and
+// never overwrites an earlier attachment. Returns the absolute path written.
+func (m *Manager) Attach(taskID int64, artifactType, src string) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", fmt.Errorf("read attachment: %w", err)
+	}
+	if err := disk.Check(int64(len(data))); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(m.Dir(), 0755); err != nil {
+		return "", fmt.Errorf("create runs dir: %w", err)
+	}
+	base := filepath.Base(src)
+	name := fmt.Sprintf("task-%d-attach-%s", taskID, base)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(m.Path(name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("task-%d-attach-%d-%s", taskID, i, base)
+	}
+	path := m.Path(name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write attachment: %w", err)
+	}
+	disk.Wrote(int64(len(data)))
+	if m.store != nil {
+		if err := m.store.AddArtifact(taskID, artifactType, filepath.ToSlash(filepath.Join(RunsDir, name))); err != nil {
+			return path, fmt.Errorf("record artifact: %w", err)
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,108 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imkarma/hive/internal/redact"
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestName(t *testing.T) {
+	if got := Name(3, "auto-code", "iter2"); got != "task-3-auto-code-iter2.md" {
+		t.Errorf("unexpected name %q", got)
+	}
+	if got := Name(7, "plan"); got != "task-7-plan.md" {
+		t.Errorf("unexpected name %q", got)
+	}
+}
+
+func TestSave_WritesUnderRootAndRegisters(t *testing.T) {
+	root := t.TempDir()
+	s, err := store.New(filepath.Join(root, "test.db"))
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	defer s.Close()
+	task, _ := s.CreateTask("Task", "", "high", nil)
+
+	// Run from somewhere else entirely, as a worktree worker would.
+	wd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	m := New(s, root)
+	path, err := m.Save(task.ID, "code", Name(task.ID, "code", "iter1"), "output")
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	want := filepath.Join(root, ".hive", "runs", "task-1-code-iter1.md")
+	if path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+	if data, _ := os.ReadFile(want); string(data) != "output" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	arts, err := s.GetArtifacts(task.ID)
+	if err != nil || len(arts) != 1 {
+		t.Fatalf("expected 1 artifact, got %v, %v", arts, err)
+	}
+	if arts[0].FilePath != ".hive/runs/task-1-code-iter1.md" || arts[0].Type != "code" {
+		t.Errorf("unexpected artifact %+v", arts[0])
+	}
+	if m.Resolve(arts[0].FilePath) != want {
+		t.Errorf("Resolve: got %s", m.Resolve(arts[0].FilePath))
+	}
+}
+
+func TestSave_RedactsSecrets(t *testing.T) {
+	t.Setenv("HIVE_TEST_API_KEY", "sk-test-0123456789")
+	redact.Init(true, nil)
+
+	m := New(nil, t.TempDir())
+	path, err := m.Save(1, "code", Name(1, "code"), "key is sk-test-0123456789")
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if got := string(data); got != "key is [REDACTED:HIVE_TEST_API_KEY]" {
+		t.Errorf("artifact not redacted: %q", got)
+	}
+}
+
+func TestAttach_CopiesWithoutOverwriting(t *testing.T) {
+	root := t.TempDir()
+	s, err := store.New(filepath.Join(root, "test.db"))
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	defer s.Close()
+	task, _ := s.CreateTask("Task", "", "high", nil)
+
+	src := filepath.Join(t.TempDir(), "mockup.png")
+	os.WriteFile(src, []byte("png bytes"), 0644)
+
+	m := New(s, root)
+	first, err := m.Attach(task.ID, TypeImage, src)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	second, err := m.Attach(task.ID, TypeImage, src)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if filepath.Base(first) != "task-1-attach-mockup.png" || filepath.Base(second) != "task-1-attach-2-mockup.png" {
+		t.Errorf("unexpected names %s, %s", filepath.Base(first), filepath.Base(second))
+	}
+	if data, _ := os.ReadFile(second); string(data) != "png bytes" {
+		t.Errorf("copy differs: %q", data)
+	}
+
+	arts, _ := s.GetArtifacts(task.ID)
+	if len(arts) != 2 || arts[0].Type != TypeImage || arts[0].FilePath != ".hive/runs/task-1-attach-mockup.png" {
+		t.Errorf("unexpected artifacts: %+v", arts)
+	}
+}
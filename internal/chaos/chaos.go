@@ -0,0 +1,135 @@
+// Package chaos injects failures into agent runs and git merges at random,
+// so the recovery paths (retries, resume, conflict tasks, needs_human) can
+// be exercised on purpose instead of waiting for a provider or a merge to
+// fail. It is for testing hive itself and is off unless configured, with
+// the hidden --chaos flag or $HIVE_CHAOS:
+//
+//	HIVE_CHAOS=agent_timeout:0.1,merge_conflict:0.05
+//
+// Each fault fires with its probability every time hive reaches the point
+// it applies to. $HIVE_CHAOS_SEED makes a run's faults repeatable.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fault is a kind of failure chaos can inject.
+type Fault string
+
+const (
+	AgentTimeout  Fault = "agent_timeout"  // An agent call times out without running
+	AgentError    Fault = "agent_error"    // An agent call exits non-zero
+	AgentGarbage  Fault = "agent_garbage"  // An agent answers with output no parser accepts
+	AgentCrash    Fault = "agent_crash"    // hive exits mid-run, as if killed, before an agent call
+	MergeConflict Fault = "merge_conflict" // A task's work conflicts with the epic branch, or an epic with its base
+)
+
+// Faults are the faults chaos knows.
+var Faults = []Fault{AgentTimeout, AgentError, AgentGarbage, AgentCrash, MergeConflict}
+
+// Config is the probability of each fault, from 0 to 1.
+type Config map[Fault]float64
+
+// Parse reads a spec like "agent_timeout:0.1,merge_conflict:0.05". A
+// fault without a probability always fires.
+func Parse(spec string) (Config, error) {
+	cfg := Config{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, prob, hasProb := strings.Cut(part, ":")
+		f := Fault(strings.TrimSpace(name))
+		if !known(f) {
+			return nil, fmt.Errorf("chaos: unknown fault %q (known: %s)", f, faultNames())
+		}
+		p := 1.0
+		if hasProb {
+			var err error
+			p, err = strconv.ParseFloat(strings.TrimSpace(prob), 64)
+			if err != nil || p < 0 || p > 1 {
+				return nil, fmt.Errorf("chaos: %s: probability must be between 0 and 1, got %q", f, prob)
+			}
+		}
+		cfg[f] = p
+	}
+	return cfg, nil
+}
+
+// String is the spec cfg was parsed from, faults in a fixed order.
+func (c Config) String() string {
+	var parts []string
+	for _, f := range Faults {
+		if p, ok := c[f]; ok {
+			parts = append(parts, fmt.Sprintf("%s:%g", f, p))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func known(f Fault) bool {
+	for _, k := range Faults {
+		if k == f {
+			return true
+		}
+	}
+	return false
+}
+
+func faultNames() string {
+	names := make([]string, len(Faults))
+	for i, f := range Faults {
+		names[i] = string(f)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+var (
+	mu     sync.Mutex
+	active Config
+	rng    *rand.Rand
+)
+
+// Init turns chaos on with cfg, drawing from a generator seeded with seed,
+// or off when cfg is empty.
+func Init(cfg Config, seed int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(cfg) == 0 {
+		active, rng = nil, nil
+		return
+	}
+	active, rng = cfg, rand.New(rand.NewSource(seed))
+}
+
+// Enabled reports whether any fault is configured.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return active != nil
+}
+
+// Hit reports whether fault f fires this time.
+func Hit(f Fault) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	p, ok := active[f]
+	if !ok || p <= 0 {
+		return false
+	}
+	return rng.Float64() < p
+}
+
+// Error marks a failure chaos injected, so logs and events tell it from
+// a real one.
+func Error(f Fault, detail string) error {
+	return fmt.Errorf("chaos: injected %s: %s", f, detail)
+}
@@ -0,0 +1,62 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse("agent_timeout:0.1, merge_conflict:0.05,agent_crash")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg[AgentTimeout] != 0.1 || cfg[MergeConflict] != 0.05 || cfg[AgentCrash] != 1 {
+		t.Errorf("unexpected config %v", cfg)
+	}
+	if got := cfg.String(); got != "agent_timeout:0.1,agent_crash:1,merge_conflict:0.05" {
+		t.Errorf("unexpected spec %q", got)
+	}
+
+	for _, bad := range []string{"disk_full:0.5", "agent_error:2", "agent_error:x"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+	if _, err := Parse("nope"); err == nil || !strings.Contains(err.Error(), "merge_conflict") {
+		t.Errorf("expected the known faults listed, got %v", err)
+	}
+}
+
+func TestHit(t *testing.T) {
+	defer Init(nil, 0)
+
+	if Hit(AgentTimeout) || Enabled() {
+		t.Fatal("chaos should be off until Init")
+	}
+	Init(Config{AgentTimeout: 1, AgentError: 0}, 1)
+	if !Enabled() || !Hit(AgentTimeout) {
+		t.Error("a fault with probability 1 should always fire")
+	}
+	if Hit(AgentError) || Hit(MergeConflict) {
+		t.Error("faults at 0 or not configured should never fire")
+	}
+}
+
+func TestHit_SeedRepeats(t *testing.T) {
+	defer Init(nil, 0)
+
+	draw := func() []bool {
+		Init(Config{MergeConflict: 0.5}, 42)
+		var hits []bool
+		for i := 0; i < 20; i++ {
+			hits = append(hits, Hit(MergeConflict))
+		}
+		return hits
+	}
+	first, second := draw(), draw()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("the same seed gave different faults at draw %d", i)
+		}
+	}
+}
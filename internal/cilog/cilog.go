@@ -0,0 +1,337 @@
+// Package cilog extracts failures from CI output — test runners and
+// compilers — and groups them by package or file, so a red build can be
+// turned into an epic with one task per broken area.
+//
+// Recognized formats: go test and go build/vet, compiler errors in the
+// file:line:col form (gcc, clang, tsc, eslint's unix format and most
+// linters), rustc, pytest and jest. CI noise — ANSI colors and the
+// timestamps GitHub Actions puts on every line — is ignored.
+package cilog
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Failure kinds.
+const (
+	KindTest  = "test"
+	KindBuild = "build"
+)
+
+// maxDetail is how many output lines are kept per failure.
+const maxDetail = 15
+
+// Failure is one failing test or one compile error.
+type Failure struct {
+	Kind    string
+	Name    string   // Test name, or the error message
+	File    string   // Source file, when known
+	Line    int      // 0 when unknown
+	Detail  []string // Output lines that explain it
+	Package string   // Package, module or test file it belongs to
+}
+
+// Location is file:line, or just the file.
+func (f Failure) Location() string {
+	if f.Line > 0 {
+		return f.File + ":" + strconv.Itoa(f.Line)
+	}
+	return f.File
+}
+
+// Group is the failures of one kind in one package or file.
+type Group struct {
+	Name     string
+	Kind     string
+	Failures []Failure
+}
+
+var (
+	ansiRe      = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	timestampRe = regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?Z ?`)
+
+	goFailRe    = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	goPkgFailRe = regexp.MustCompile(`^FAIL\s+(\S+)\s+(?:[\d.]+s|\[build failed\]|\[setup failed\])`)
+	goPkgHdrRe  = regexp.MustCompile(`^# (\S+)`)
+	goRunRe     = regexp.MustCompile(`^=== (?:RUN|CONT|PAUSE)\s+(\S+)`)
+	fileLineRe  = regexp.MustCompile(`^(?:\./)?([\w./\\-]+\.\w+):(\d+)(?::\d+)?:\s*(?:(?:fatal )?error:\s*)?(.+)$`)
+	tscRe       = regexp.MustCompile(`^([\w./\\-]+\.\w+)\((\d+),\d+\): error (TS\d+: .+)$`)
+	rustErrRe   = regexp.MustCompile(`^error(?:\[E\d+\])?: (.+)$`)
+	rustLocRe   = regexp.MustCompile(`^\s*--> ([^:]+):(\d+)`)
+	pytestRe    = regexp.MustCompile(`^FAILED (\S+?)::(\S+)(?: - (.*))?$`)
+	jestFileRe  = regexp.MustCompile(`^\s*FAIL\s+(\S+)`)
+	jestTestRe  = regexp.MustCompile(`^\s*● (.+)$`)
+)
+
+// clean strips colors and CI timestamps from a line.
+func clean(line string) string {
+	line = ansiRe.ReplaceAllString(line, "")
+	line = timestampRe.ReplaceAllString(line, "")
+	return strings.TrimRight(line, " \t\r")
+}
+
+// Parse extracts the failures in a CI log, in the order they appear.
+func Parse(log string) []Failure {
+	var (
+		failures []Failure
+		current  *Failure // Collecting detail lines
+		goTests  []int    // go test failures waiting for their FAIL <pkg> line
+		goRun    int      // Counts FAIL <pkg> lines, to tell same-named tests of different packages apart
+		goPkg    string   // Package from the last "# pkg" header
+		lastRun  string   // Test from the last "=== RUN" (go test -v prints output before the result)
+		runLines = map[string][]string{}
+		jestFile string
+		seen     = map[string]bool{}
+	)
+	key := func(f Failure) string {
+		return f.Kind + "\x00" + f.Package + "\x00" + f.Name + "\x00" + f.Location()
+	}
+	add := func(f Failure) {
+		if seen[key(f)] {
+			current = nil
+			return
+		}
+		seen[key(f)] = true
+		failures = append(failures, f)
+		current = &failures[len(failures)-1]
+	}
+
+	for _, raw := range strings.Split(log, "\n") {
+		line := clean(raw)
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case goFailRe.MatchString(line):
+			name := goFailRe.FindStringSubmatch(line)[1]
+			if i := strings.Index(name, "/"); i > 0 {
+				// Subtests fail with their parent; their output goes
+				// with it.
+				name = name[:i]
+				if n := len(failures); n > 0 && failures[n-1].Kind == KindTest && failures[n-1].Name == name {
+					current = &failures[n-1]
+					continue
+				}
+			}
+			add(Failure{Kind: KindTest, Name: name, Package: goPending + strconv.Itoa(goRun)})
+			if current != nil {
+				goTests = append(goTests, len(failures)-1)
+				for _, d := range runLines[name] {
+					current.addDetail(d)
+				}
+			}
+
+		case goPkgFailRe.MatchString(line):
+			pkg := goPkgFailRe.FindStringSubmatch(line)[1]
+			for _, i := range goTests {
+				failures[i].Package = pkg
+			}
+			goTests = nil
+			goRun++
+			current = nil
+			lastRun = ""
+			clear(runLines)
+
+		case goRunRe.MatchString(line):
+			lastRun = goRunRe.FindStringSubmatch(line)[1]
+			if i := strings.Index(lastRun, "/"); i > 0 {
+				lastRun = lastRun[:i]
+			}
+			current = nil
+
+		case current == nil && lastRun != "" && trimmed != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			if len(runLines[lastRun]) < maxDetail {
+				runLines[lastRun] = append(runLines[lastRun], trimmed)
+			}
+
+		case goPkgHdrRe.MatchString(line):
+			goPkg = goPkgHdrRe.FindStringSubmatch(line)[1]
+			current = nil
+
+		case pytestRe.MatchString(trimmed):
+			m := pytestRe.FindStringSubmatch(trimmed)
+			f := Failure{Kind: KindTest, Name: m[2], File: m[1], Package: m[1]}
+			if m[3] != "" {
+				f.Detail = []string{m[3]}
+			}
+			add(f)
+			current = nil
+
+		case jestFileRe.MatchString(line) && strings.Contains(line, "."):
+			jestFile = jestFileRe.FindStringSubmatch(line)[1]
+			current = nil
+
+		case jestFile != "" && jestTestRe.MatchString(line):
+			name := jestTestRe.FindStringSubmatch(line)[1]
+			add(Failure{Kind: KindTest, Name: name, File: jestFile, Package: jestFile})
+
+		case tscRe.MatchString(trimmed):
+			m := tscRe.FindStringSubmatch(trimmed)
+			n, _ := strconv.Atoi(m[2])
+			add(Failure{Kind: KindBuild, Name: m[3], File: m[1], Line: n, Package: dirOf(m[1])})
+
+		case rustErrRe.MatchString(trimmed) && !strings.HasPrefix(trimmed, "error: could not compile") && !strings.HasPrefix(trimmed, "error: aborting"):
+			// Deduplicated once its location ("--> file:line") follows.
+			failures = append(failures, Failure{Kind: KindBuild, Name: rustErrRe.FindStringSubmatch(trimmed)[1]})
+			current = &failures[len(failures)-1]
+
+		case current != nil && current.Kind == KindBuild && current.File == "" && rustLocRe.MatchString(line):
+			m := rustLocRe.FindStringSubmatch(line)
+			f := failures[len(failures)-1]
+			failures = failures[:len(failures)-1]
+			f.File = strings.TrimSpace(m[1])
+			f.Line, _ = strconv.Atoi(m[2])
+			f.Package = dirOf(f.File)
+			add(f)
+
+		case fileLineRe.MatchString(trimmed) && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t"):
+			// Indented file:line lines are detail of a failing test
+			// (t.Errorf output), not compile errors.
+			m := fileLineRe.FindStringSubmatch(trimmed)
+			n, _ := strconv.Atoi(m[2])
+			pkg := goPkg
+			if pkg == "" || !strings.HasSuffix(m[1], ".go") {
+				pkg = dirOf(m[1])
+			}
+			add(Failure{Kind: KindBuild, Name: m[3], File: m[1], Line: n, Package: pkg})
+
+		case current != nil && trimmed != "":
+			if strings.HasPrefix(line, "=== ") || strings.HasPrefix(line, "--- ") ||
+				trimmed == "FAIL" || strings.HasPrefix(trimmed, "ok ") || strings.HasPrefix(trimmed, "PASS") {
+				current = nil
+				continue
+			}
+			current.addDetail(trimmed)
+		}
+	}
+	for i := range failures {
+		// Output cut off before the FAIL <pkg> line.
+		if strings.HasPrefix(failures[i].Package, goPending) {
+			failures[i].Package = ""
+		}
+	}
+	return failures
+}
+
+// goPending marks go test failures whose package isn't known yet.
+const goPending = "\x00go"
+
+// addDetail adds an output line, taking a failing test's location from
+// the first file:line in it.
+func (f *Failure) addDetail(line string) {
+	if len(f.Detail) < maxDetail {
+		f.Detail = append(f.Detail, line)
+	}
+	if f.File == "" && f.Kind == KindTest {
+		if m := fileLineRe.FindStringSubmatch(line); m != nil {
+			f.File = m[1]
+			f.Line, _ = strconv.Atoi(m[2])
+		}
+	}
+}
+
+// dirOf is the directory of a source file, or the file itself at the top
+// level.
+func dirOf(file string) string {
+	file = strings.ReplaceAll(file, "\\", "/")
+	if dir := path.Dir(file); dir != "." {
+		return dir
+	}
+	return file
+}
+
+// Groups collects failures by kind and package, build errors first (a
+// package that doesn't compile can't run its tests), then by size.
+func Groups(failures []Failure) []Group {
+	index := map[string]int{}
+	var groups []Group
+	for _, f := range failures {
+		name := f.Package
+		if name == "" {
+			name = "(unknown)"
+		}
+		key := f.Kind + "\x00" + name
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Name: name, Kind: f.Kind})
+		}
+		groups[i].Failures = append(groups[i].Failures, f)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Kind != groups[j].Kind {
+			return groups[i].Kind == KindBuild
+		}
+		return len(groups[i].Failures) > len(groups[j].Failures)
+	})
+	return groups
+}
+
+// Title is a task title for fixing the group.
+func (g Group) Title() string {
+	if g.Kind == KindBuild {
+		return "Fix build errors in " + g.Name
+	}
+	return "Fix failing tests in " + g.Name
+}
+
+// Description lists the group's failures with their output, for the task
+// that fixes them.
+func (g Group) Description() string {
+	var sb strings.Builder
+	if g.Kind == KindBuild {
+		sb.WriteString("CI fails to build " + g.Name + ":\n")
+	} else {
+		sb.WriteString("CI test failures in " + g.Name + ":\n")
+	}
+	for _, f := range g.Failures {
+		sb.WriteString("\n- ")
+		if f.Kind == KindBuild {
+			sb.WriteString(f.Name)
+			if loc := f.Location(); loc != "" {
+				sb.WriteString(" (" + loc + ")")
+			}
+		} else {
+			sb.WriteString(f.Name)
+			if loc := f.Location(); loc != "" && f.File != g.Name {
+				sb.WriteString(" (" + loc + ")")
+			}
+		}
+		sb.WriteString("\n")
+		if len(f.Detail) > 0 {
+			sb.WriteString("  ```\n")
+			for _, d := range f.Detail {
+				sb.WriteString("  " + d + "\n")
+			}
+			sb.WriteString("  ```\n")
+		}
+	}
+	return sb.String()
+}
+
+// Summary describes all groups in a few lines, for the epic.
+func Summary(groups []Group) string {
+	var sb strings.Builder
+	total := 0
+	for _, g := range groups {
+		total += len(g.Failures)
+	}
+	sb.WriteString("## Failing CI\n\n")
+	sb.WriteString(strconv.Itoa(total) + " failure(s) in " + strconv.Itoa(len(groups)) + " place(s):\n\n")
+	for _, g := range groups {
+		names := make([]string, 0, 3)
+		for _, f := range g.Failures {
+			if len(names) == 3 {
+				names = append(names, "…")
+				break
+			}
+			names = append(names, f.Name)
+		}
+		sb.WriteString("- **" + g.Name + "** (" + g.Kind + ", " + strconv.Itoa(len(g.Failures)) + "): " + strings.Join(names, "; ") + "\n")
+	}
+	return sb.String()
+}
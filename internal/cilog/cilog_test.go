@@ -0,0 +1,102 @@
+package cilog
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func readLog(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParse_GoTestAndBuild(t *testing.T) {
+	failures := Parse(readLog(t, "gotest.log"))
+
+	type want struct{ kind, name, pkg, loc string }
+	wants := []want{
+		{KindTest, "TestLogin", "github.com/acme/app/internal/auth", "login_test.go:42"},
+		{KindTest, "TestRefresh", "github.com/acme/app/internal/auth", "refresh_test.go:17"},
+		{KindTest, "TestLogin", "github.com/acme/app/internal/api", "api_test.go:9"},
+		{KindBuild, "undefined: sqlOpen", "github.com/acme/app/internal/store", "internal/store/db.go:31"},
+	}
+	if len(failures) != len(wants) {
+		t.Fatalf("expected %d failures, got %d: %+v", len(wants), len(failures), failures)
+	}
+	for i, w := range wants {
+		f := failures[i]
+		if f.Kind != w.kind || f.Name != w.name || f.Package != w.pkg || f.Location() != w.loc {
+			t.Errorf("failure %d: got %s %q in %q at %q, want %+v", i, f.Kind, f.Name, f.Package, f.Location(), w)
+		}
+	}
+	if !strings.Contains(strings.Join(failures[0].Detail, "\n"), "expected 200, got 401") {
+		t.Errorf("missing detail: %v", failures[0].Detail)
+	}
+}
+
+func TestParse_GoTestVerbose(t *testing.T) {
+	failures := Parse(readLog(t, "gotest_v.log"))
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 failure, got %+v", failures)
+	}
+	f := failures[0]
+	if f.Name != "TestParse" || f.Package != "github.com/acme/app/parser" || f.Location() != "parse_test.go:12" {
+		t.Errorf("unexpected failure: %+v", f)
+	}
+}
+
+func TestParse_OtherToolchains(t *testing.T) {
+	failures := Parse(readLog(t, "mixed.log"))
+	got := map[string]Failure{}
+	for _, f := range failures {
+		got[f.Name] = f
+	}
+	if len(failures) != 6 {
+		t.Errorf("expected 6 failures, got %d: %+v", len(failures), failures)
+	}
+	checks := []struct{ name, loc, pkg string }{
+		{"cannot find value `cfg` in this scope", "src/main.rs:14", "src"},
+		{"TS2322: Type 'string' is not assignable to type 'number'.", "src/app.ts:3", "src"},
+		{"'len' undeclared (first use in this function)", "lib/util.c:88", "lib"},
+		{"test_create_user", "tests/test_api.py", "tests/test_api.py"},
+		{"Button › renders the label", "src/components/Button.test.tsx", "src/components/Button.test.tsx"},
+	}
+	for _, c := range checks {
+		f, ok := got[c.name]
+		if !ok {
+			t.Errorf("missing failure %q", c.name)
+			continue
+		}
+		if f.Location() != c.loc || f.Package != c.pkg {
+			t.Errorf("%q: got %q in %q, want %q in %q", c.name, f.Location(), f.Package, c.loc, c.pkg)
+		}
+	}
+}
+
+func TestGroups_BuildFirstThenBySize(t *testing.T) {
+	groups := Groups(Parse(readLog(t, "gotest.log")))
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	if groups[0].Kind != KindBuild || groups[0].Name != "github.com/acme/app/internal/store" {
+		t.Errorf("expected the build failure first, got %s %s", groups[0].Kind, groups[0].Name)
+	}
+	if groups[1].Name != "github.com/acme/app/internal/auth" || len(groups[1].Failures) != 2 {
+		t.Errorf("expected auth with 2 failures second, got %s (%d)", groups[1].Name, len(groups[1].Failures))
+	}
+	if title := groups[0].Title(); title != "Fix build errors in github.com/acme/app/internal/store" {
+		t.Errorf("unexpected title %q", title)
+	}
+	desc := groups[1].Description()
+	if !strings.Contains(desc, "TestRefresh (refresh_test.go:17)") || !strings.Contains(desc, "token not rejected") {
+		t.Errorf("unexpected description:\n%s", desc)
+	}
+	if sum := Summary(groups); !strings.Contains(sum, "4 failure(s) in 3 place(s)") {
+		t.Errorf("unexpected summary:\n%s", sum)
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"strings"
 
 	"github.com/imkarma/hive/internal/agent"
-	"github.com/imkarma/hive/internal/config"
-	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/lock"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 	"github.com/spf13/cobra"
 )
 
@@ -26,6 +28,9 @@ then automatically continues the pipeline for that task:
   3. If architect is happy → coder → reviewer loop
   4. Commits approved work on the epic's safety branch
 
+A task that needs a human (the fix loop gave up) is answered the same
+way: your answer is guidance for another round of the loop.
+
 Use "skip" as the answer to cancel the task instead:
   hive answer 5 skip`,
 	Args: cobra.MinimumNArgs(2),
@@ -54,17 +59,33 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("task #%d not found", id)
 	}
-	if task.Status != store.StatusBlocked {
+	if task.Status != store.StatusBlocked && task.Status != store.StatusNeedsHuman {
 		return fmt.Errorf("task #%d is not blocked (status: %s)", id, task.Status)
 	}
 
 	answer := strings.Join(args[1:], " ")
 
+	// Answering may unpause the epic, so as with hive resume, a run still
+	// holding its lock stops us before anything changes.
+	runLock, err := lockPausedEpic(s, task)
+	if err != nil {
+		return err
+	}
+	defer runLock.Release()
+
+	// A paused epic has nothing to answer itself: resume it by hand.
+	if worker.IsPaused(task) {
+		return resumePausedEpic(cmd, task, runLock)
+	}
+
 	// Special: "skip" cancels the task.
 	if strings.ToLower(strings.TrimSpace(answer)) == "skip" {
 		s.UpdateTaskStatus(id, store.StatusCancelled)
 		s.AddEvent(id, "user", "cancelled", "User skipped blocked task")
 		fmt.Printf("Cancelled task #%d — pipeline will skip it.\n", id)
+		if epic := worker.ResumeEpic(s, id); epic != nil {
+			return resumePausedEpic(cmd, epic, runLock)
+		}
 		return nil
 	}
 
@@ -74,11 +95,25 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Unblocked task #%d\n", id)
-	fmt.Printf("  Question: %s\n", task.BlockedReason)
+	if task.BlockedReason != "" {
+		fmt.Printf("  Question: %s\n", task.BlockedReason)
+	}
 	fmt.Printf("  Answer:   %s\n\n", answer)
 
+	// The epic's pipeline paused on this task: run the whole epic again
+	// rather than just this task.
+	if epic := worker.ResumeEpic(s, id); epic != nil {
+		return resumePausedEpic(cmd, epic, runLock)
+	}
+	if task.ParentID != nil {
+		if epic, err := s.GetTask(*task.ParentID); err == nil && worker.IsPaused(epic) {
+			fmt.Printf("  %s⏸ Epic #%d still %s%s\n\n", colorYellow, epic.ID, epic.BlockedReason, colorReset)
+			return nil
+		}
+	}
+
 	// Load config.
-	cfg, err := config.Load(hivePath("config.yaml"))
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Printf("  %s⚠ No config found — unblocked but not auto-running.%s\n", colorYellow, colorReset)
 		return nil
@@ -101,9 +136,9 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 	}
 
 	// Find agents.
-	archName, archCfg := findAgentByRole(cfg, "architect")
-	coderName, coderCfg := findAgentByRole(cfg, "coder")
-	reviewerName, reviewerCfg := findAgentByRole(cfg, "reviewer")
+	archName, archCfg := findAgentByRole(cfg, roles.Architect)
+	coderName, coderCfg := findAgentByRole(cfg, roles.Coder)
+	reviewerName, reviewerCfg := findAgentByRole(cfg, roles.Reviewer)
 
 	forceAutoAccept(&archCfg)
 	forceAutoAccept(&coderCfg)
@@ -121,7 +156,7 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	ctxBuilder := agentctx.New(s)
+	ctxBuilder := newContextBuilder(s, cfg)
 
 	// Step 1: If no architect spec yet, run architect first.
 	if !hasArchSpec && archName != "" {
@@ -132,7 +167,7 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("create architect runner: %w", err)
 		}
 
-		archPrompt, _ := ctxBuilder.BuildPrompt(task, "architect")
+		archPrompt, _ := ctxBuilder.BuildPrompt(task, roles.Architect)
 		resp, err := archRunner.Run(context.Background(), agent.Request{
 			TaskID: task.ID, Prompt: archPrompt, WorkDir: workDir,
 			TimeoutSec: archCfg.DefaultTimeout(),
@@ -143,10 +178,7 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 		}
 
 		// Save artifact.
-		artifactPath := hivePath("runs", fmt.Sprintf("task-%d-architect.md", task.ID))
-		os.MkdirAll(hivePath("runs"), 0755)
-		os.WriteFile(artifactPath, []byte(resp.Output), 0644)
-		s.AddArtifact(task.ID, "architect", artifactPath)
+		newArtifacts(s).Save(task.ID, "architect", artifacts.Name(task.ID, "architect"), resp.Output)
 
 		// Check if architect blocked again.
 		if b := agent.ParseBlocked(resp.Output); b != "" {
@@ -174,7 +206,7 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("  Starting code → review loop (max %d iterations)\n\n", answerMaxLoops)
 
-	result := autoFixLoop(s, cfg, task, coderName, coderCfg, reviewerName, reviewerCfg, workDir, answerMaxLoops)
+	result := autoFixLoop(s, cfg, task, coderName, coderCfg, reviewerName, reviewerCfg, workDir, answerMaxLoops, nil)
 
 	switch result {
 	case "done":
@@ -194,3 +226,32 @@ func runAnswer(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// lockPausedEpic takes the run lock of task's epic (or of task, if it is
+// the epic) when the epic is paused. Returns nil when it isn't.
+func lockPausedEpic(s *store.Store, task *store.Task) (*lock.Lock, error) {
+	epic := task
+	if task.ParentID != nil {
+		parent, err := s.GetTask(*task.ParentID)
+		if err != nil {
+			return nil, nil
+		}
+		epic = parent
+	}
+	if !worker.IsPaused(epic) {
+		return nil, nil
+	}
+	return lockEpic(epic.ID)
+}
+
+// resumePausedEpic picks up the pipeline of an epic that paused on a
+// blocked task (auto.pause_on_block), as hive auto <epic> would. Like
+// hive resume, it lets go of the epic's lock (taken by lockPausedEpic)
+// only for hive auto to take it again.
+func resumePausedEpic(cmd *cobra.Command, epic *store.Task, runLock *lock.Lock) error {
+	runLock.Release()
+	fmt.Printf("  %s▶ Resuming epic #%d%s\n\n", colorGreen, epic.ID, colorReset)
+	autoMaxLoops = answerMaxLoops
+	autoSkipPlan = false
+	return runAuto(cmd, []string{strconv.FormatInt(epic.ID, 10)})
+}
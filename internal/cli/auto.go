@@ -2,16 +2,31 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/config"
 	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/disk"
 	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/langs"
+	"github.com/imkarma/hive/internal/lock"
+	"github.com/imkarma/hive/internal/metrics"
+	"github.com/imkarma/hive/internal/notify"
+	"github.com/imkarma/hive/internal/perf"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/simulate"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/tracing"
 	"github.com/imkarma/hive/internal/worker"
 	"github.com/spf13/cobra"
 )
@@ -39,28 +54,72 @@ var (
 	autoMaxLoops      int
 	autoSkipPlan      bool
 	autoSkipArchitect bool
+	autoWithArchitect bool
 	autoParallel      int
+	autoSkipDocs      bool
+	autoStash         bool
+	autoFollow        bool
+	autoMetrics       string
+	autoSimulate      string
+	autoYes           bool
+
+	// autoResume holds the checkpoints of the run hive resume continues,
+	// by task; nil for a fresh run.
+	autoResume map[int64]store.TaskCheckpoint
 )
 
 func init() {
 	autoCmd.Flags().IntVar(&autoMaxLoops, "max-loops", 3, "Maximum fix-review iterations per task")
 	autoCmd.Flags().BoolVar(&autoSkipPlan, "skip-plan", false, "Skip planning, run directly on existing tasks")
 	autoCmd.Flags().BoolVar(&autoSkipArchitect, "skip-architect", false, "Skip architect research phase")
+	autoCmd.Flags().BoolVar(&autoWithArchitect, "with-architect", false, "Run the architect phase even if auto.architect is false in the config")
+	autoCmd.Flags().BoolVar(&autoSkipDocs, "skip-docs", false, "Skip the docs phase after all tasks are done")
+	autoCmd.Flags().BoolVar(&autoStash, "stash", false, "Stash uncommitted changes and restore them on the original branch when done")
 	autoCmd.Flags().IntVar(&autoParallel, "parallel", 1, "Number of tasks to run in parallel (uses git worktrees)")
+	autoCmd.Flags().BoolVar(&autoFollow, "follow", false, "With --parallel, stream every worker's log live, prefixed by task")
+	autoCmd.Flags().BoolVar(&streamAgents, "stream", false, "Show agent output live as agents write it (with --parallel, in the task logs and --follow)")
+	autoCmd.Flags().StringVar(&autoMetrics, "metrics", "", "Serve Prometheus metrics on this address while running, e.g. :9090 (overrides metrics.listen)")
+	autoCmd.Flags().StringVar(&autoSimulate, "simulate", "", "Dry-run the pipeline in a sandbox, with agent outcomes from this scenario file")
+	autoCmd.Flags().BoolVarP(&autoYes, "yes", "y", false, "Start without asking even if the run estimate is over auto.confirm_above")
+	autoCmd.MarkFlagsMutuallyExclusive("skip-architect", "with-architect")
 	rootCmd.AddCommand(autoCmd)
 }
 
 func runAuto(cmd *cobra.Command, args []string) error {
+	var scenario *simulate.Scenario
+	if autoSimulate != "" {
+		sc, leave, err := enterSimulation(autoSimulate)
+		if err != nil {
+			return err
+		}
+		defer leave()
+		defer fmt.Printf("\n  %sSimulation over — your board, branches and files are untouched.%s\n", colorDim, colorReset)
+		fmt.Printf("  %sSimulating with %s: no agent runs, nothing leaves the sandbox.%s\n\n", colorYellow, autoSimulate, colorReset)
+		scenario = sc
+	}
+
 	s, err := mustStore()
 	if err != nil {
 		return err
 	}
 	defer s.Close()
+	if scenario != nil {
+		startSimulation(s, scenario)
+		defer agent.SetSimulation(nil)
+	}
 
-	cfg, err := config.Load(hivePath("config.yaml"))
+	cw, err := newConfigWatcher()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	cfg := cw.Config()
+	if err := checkOffline(cfg, roles.NewRegistry(cfg.Roles).Names()...); err != nil {
+		return err
+	}
+	// Better to stop now than when a worktree or artifact can't be written.
+	if err := disk.Check(0); err != nil {
+		return err
+	}
 
 	id, err := strconv.ParseInt(args[0], 10, 64)
 	if err != nil {
@@ -71,6 +130,17 @@ func runAuto(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("task #%d not found", id)
 	}
 
+	// One pipeline per epic: a task's run counts as its epic's.
+	lockID := task.ID
+	if task.ParentID != nil {
+		lockID = *task.ParentID
+	}
+	runLock, err := lockEpic(lockID)
+	if err != nil {
+		return err
+	}
+	defer runLock.Release()
+
 	// Check for interrupted pipeline runs on this epic.
 	if task.Kind == store.KindEpic {
 		active, _ := s.GetActivePipelineRun(task.ID)
@@ -83,20 +153,79 @@ func runAuto(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  → Use %shive resume %d%s to cleanly recover, or continue anyway.\n\n",
 				colorCyan, active.ID, colorReset)
 		}
+		// Running the epic again is how the user resumes a pause by hand.
+		if worker.IsPaused(task) {
+			s.UnblockTask(task.ID, "pipeline resumed")
+			task, _ = s.GetTask(task.ID)
+		}
+	}
+
+	if addr := metricsAddr(cfg, autoMetrics); addr != "" {
+		stop, err := metrics.Serve(addr, s)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		fmt.Printf("  %sMetrics: http://%s/metrics%s\n\n", colorDim, addr, colorReset)
 	}
 
+	stopTracing, err := startTracing(cfg)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	_, pipelineSpan := tracing.StartPipeline(context.Background(), "hive.auto",
+		tracing.Int("hive.task.id", task.ID),
+		tracing.String("hive.task.kind", string(task.Kind)),
+		tracing.Int("hive.parallel", int64(autoParallel)),
+	)
+	defer pipelineSpan.End()
+
 	workDir, _ := os.Getwd()
 
 	// If this is an epic, ensure we're on its safety branch.
 	if task.Kind == store.KindEpic {
 		safety := git.New(workDir)
 		if safety.IsGitRepo() {
+			warnPendingStashes(s, safety, task.ID)
+
+			branch := task.GitBranch
+			if branch == "" {
+				branch = git.BranchName(task.ID)
+			}
+
+			// With stash, the user's changes are set aside before switching
+			// and put back on their branch when auto exits. Without it,
+			// git checkout carries them onto the safety branch.
+			if current, _ := safety.CurrentBranch(); current != branch {
+				if autoStash || cfg.Git.AutoStash {
+					stash, err := stashUserChanges(s, safety, task)
+					if err != nil {
+						return fmt.Errorf("stash changes: %w", err)
+					}
+					if stash != nil {
+						defer func() {
+							// Leave the agents' work committed on the epic
+							// branch so nothing follows the user back.
+							if committed, _ := safety.CommitAll(fmt.Sprintf("hive: work in progress for epic #%d", task.ID)); committed {
+								fmt.Printf("  %sCommitted work in progress on %s%s\n", colorDim, branch, colorReset)
+							}
+							stash.restore(s, safety)
+						}()
+					}
+				} else {
+					warnDirtyTree(safety, branch)
+				}
+			}
+
 			if task.GitBranch == "" {
-				// Create safety branch. git checkout -b carries uncommitted
-				// changes to the new branch, which is what we want — the
-				// safety branch is where all work should happen.
-				branch := git.BranchName(task.ID)
-				if err := safety.CreateBranch(branch); err == nil {
+				var err error
+				if task.BaseBranch != "" {
+					err = safety.CreateBranchFrom(branch, task.BaseBranch)
+				} else {
+					err = safety.CreateBranch(branch)
+				}
+				if err == nil {
 					s.SetGitBranch(task.ID, branch)
 					task.GitBranch = branch
 					fmt.Printf("  Branch: %s\n\n", branch)
@@ -115,10 +244,10 @@ func runAuto(cmd *cobra.Command, args []string) error {
 	}
 
 	// Resolve agents by role.
-	pmName, pmCfg := findAgentByRole(cfg, "pm")
-	archName, archCfg := findAgentByRole(cfg, "architect")
-	coderName, coderCfg := findAgentByRole(cfg, "coder")
-	reviewerName, reviewerCfg := findAgentByRole(cfg, "reviewer")
+	pmName, pmCfg := findAgentByRole(cfg, roles.PM)
+	archName, archCfg := findAgentByRole(cfg, roles.Architect)
+	coderName, coderCfg := findAgentByRole(cfg, roles.Coder)
+	reviewerName, reviewerCfg := findAgentByRole(cfg, roles.Reviewer)
 
 	// In auto pipeline mode, force auto_accept on all CLI agents.
 	// Without it, CLI tools like claude wait for interactive permission
@@ -129,9 +258,12 @@ func runAuto(cmd *cobra.Command, args []string) error {
 	forceAutoAccept(&reviewerCfg)
 
 	label := "Task"
+	titlePrefix := fmt.Sprintf("hive auto #%d", task.ID)
 	if task.Kind == store.KindEpic {
 		label = "Epic"
+		titlePrefix = fmt.Sprintf("hive auto E#%d", task.ID)
 	}
+	term := newTermNotifier(cfg.Terminal, titlePrefix)
 
 	fmt.Printf("%s╔══════════════════════════════════════╗%s\n", colorBold, colorReset)
 	fmt.Printf("%s║  hive auto — full pipeline           ║%s\n", colorBold, colorReset)
@@ -161,8 +293,10 @@ func runAuto(cmd *cobra.Command, args []string) error {
 
 	// Record pipeline run for crash recovery.
 	var pipelineRunID int64
+	pipelineStart := time.Now()
 	if task.Kind == store.KindEpic {
 		pipelineRunID, _ = s.StartPipelineRun(task.ID, autoMaxLoops, autoParallel)
+		notifyPipeline(s, notify.EventPipelineStarted, task, "")
 		if pipelineRunID > 0 {
 			// Ensure we mark the run as ended when we exit (crash safety).
 			defer func() {
@@ -173,6 +307,7 @@ func runAuto(cmd *cobra.Command, args []string) error {
 			}()
 		}
 	}
+	checkpoints := worker.NewCheckpoints(s, newArtifacts(s), pipelineRunID, autoResume)
 
 	// ══════════════════════════════════════
 	// STEP 1: Plan
@@ -191,10 +326,30 @@ func runAuto(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	needsPlan := !autoSkipPlan && len(existing) == 0
+	// A plan an earlier run saved is used instead of asking the PM again;
+	// if that run stopped while creating its tasks, the rest are created.
+	var cached []agent.ParsedSubtask
+	cachedDone, hasCache := false, false
+	if !autoSkipPlan && task.Kind == store.KindEpic {
+		cached, cachedDone, hasCache = cachedPlan(s, task)
+	}
+
+	// Tasks of a finished plan are in existing; only an unfinished one,
+	// or one with no tasks, is picked up here.
+	fromCache := hasCache && (len(cached) == 0 || !cachedDone)
+	needsPlan := !autoSkipPlan && len(existing) == 0 && !fromCache
 
-	if needsPlan {
+	if fromCache && len(cached) == 0 {
+		printPhase("1", "PLAN", "Saved plan "+shortHash(task.PlanHash)+" — skipping the PM")
+		fmt.Printf("  The plan has no structured subtasks. Running coder on main task.\n\n")
+		subtasks = []store.Task{*task}
+	} else if fromCache {
+		printPhase("1", "PLAN", "Finishing the saved plan "+shortHash(task.PlanHash))
+		fmt.Printf("  %sAn earlier run planned this epic but stopped before creating every task — skipping the PM.%s\n", colorDim, colorReset)
+		subtasks = createPlannedTasks(s, task, "hive", cached, existing)
+	} else if needsPlan {
 		printPhase("1", "PLAN", "Breaking task into subtasks")
+		term.title("planning")
 
 		if pmName == "" {
 			fmt.Printf("  %s⚠ No PM agent configured, skipping plan.%s\n", colorYellow, colorReset)
@@ -203,16 +358,22 @@ func runAuto(cmd *cobra.Command, args []string) error {
 		} else {
 			planned, err := autoPlan(s, cfg, task, pmName, pmCfg, workDir)
 			if err != nil {
+				notifyPipeline(s, notify.EventPipelineFailed, task, "plan failed: "+err.Error())
 				return fmt.Errorf("plan failed: %w", err)
 			}
 			if planned == nil {
 				// PM blocked — stop and ask user.
+				term.title("blocked — needs your input")
+				term.bell()
 				return nil
 			}
 			subtasks = planned
 		}
 	} else if len(existing) > 0 {
 		printPhase("1", "PLAN", fmt.Sprintf("Resuming — %d existing tasks", len(existing)))
+		if hasCache {
+			fmt.Printf("  %sPlan %s already made — the PM isn't run again.%s\n\n", colorDim, shortHash(task.PlanHash), colorReset)
+		}
 		subtasks = existing
 	} else {
 		printPhase("1", "PLAN", "Skipped (--skip-plan)")
@@ -224,38 +385,70 @@ func runAuto(cmd *cobra.Command, args []string) error {
 	// ══════════════════════════════════════
 	printPhase("2", "ASSIGN", "Assigning agents to subtasks")
 
+	own, err := loadOwners(cfg)
+	if err != nil {
+		fmt.Printf("  %s⚠ owners: %v%s\n", colorYellow, err, colorReset)
+	}
+	ownerGated := 0
 	for i := range subtasks {
 		t := &subtasks[i]
-		if t.AssignedAgent != "" {
+		owned := taskOwnership(own, cfg, t)
+		switch {
+		case t.AssignedAgent != "":
 			fmt.Printf("  #%d already assigned to %s%s%s\n", t.ID, colorCyan, t.AssignedAgent, colorReset)
-			continue
-		}
-		if coderName != "" {
-			s.AssignTask(t.ID, coderName, "coder")
-			t.AssignedAgent = coderName
-			t.Role = "coder"
-			fmt.Printf("  #%d → %s%s%s (coder)\n", t.ID, colorCyan, coderName, colorReset)
-		} else {
+		case coderName != "" || owned.agent != "":
+			name, via := coderName, ""
+			if owned.agent != "" {
+				name, via = owned.agent, ", owners route"
+			}
+			s.AssignTask(t.ID, name, roles.Coder)
+			t.AssignedAgent = name
+			t.Role = roles.Coder
+			fmt.Printf("  #%d → %s%s%s (coder%s)\n", t.ID, colorCyan, name, colorReset, via)
+			if len(owned.areas) > 0 {
+				s.AddEvent(t.ID, "owners", "owners", "Touches code owned by "+owned.summary())
+				fmt.Printf("    %sowned by %s%s\n", colorDim, owned.summary(), colorReset)
+			}
+		default:
 			fmt.Printf("  %s⚠ #%d has no agent and no coder configured%s\n", colorYellow, t.ID, colorReset)
 		}
+		if t.Status != store.StatusDone && t.Status != store.StatusCancelled && t.Status != store.StatusBlocked && t.Status != store.StatusNeedsHuman && gateOwnedTask(s, t, owned) {
+			fmt.Printf("    %s⚠ waiting for approval from %s%s\n", colorYellow, strings.Join(owned.gates, ", "), colorReset)
+			ownerGated++
+		}
+	}
+	if ownerGated > 0 {
+		fmt.Printf("\n  %s⚠ %d task(s) touch owned code — approve with 'hive answer <id> \"approved\"'%s\n",
+			colorYellow, ownerGated, colorReset)
+		term.bell()
 	}
 	fmt.Println()
 
+	// Project the run's size now the tasks are known, and ask first if it
+	// is over the configured limits.
+	runArchitect := archName != "" && !autoSkipArchitect && (autoWithArchitect || cfg.Auto.ArchitectEnabled())
+	estimated := subtasks
+	if task.Kind == store.KindEpic {
+		if refreshed, err := s.ListTasksByEpic(task.ID); err == nil && len(refreshed) > 0 {
+			estimated = refreshed
+		}
+	}
+	if ok, err := confirmEstimate(s, cfg, task, estimated, runArchitect); !ok {
+		if pipelineRunID > 0 {
+			s.EndPipelineRun(pipelineRunID, "cancelled")
+		}
+		return err
+	}
+
 	// ══════════════════════════════════════
 	// STEP 2.5: Architect research
 	// ══════════════════════════════════════
-	if archName != "" && !autoSkipArchitect {
+	if runArchitect {
 		printPhase("2.5", "ARCHITECT", "Technical research & spec")
+		term.title("architect")
 
 		archBlocked := 0
-		for i := range subtasks {
-			t := &subtasks[i]
-			if t.Status == store.StatusDone || t.Status == store.StatusBlocked || t.Status == store.StatusCancelled {
-				continue
-			}
-
-			fmt.Printf("  #%d %s — ", t.ID, truncateAuto(t.Title, 40))
-			result := autoArchitect(s, t, archName, archCfg, workDir)
+		report := func(result string) {
 			switch result {
 			case "done":
 				fmt.Printf("%s✓ spec written%s\n", colorGreen, colorReset)
@@ -266,17 +459,73 @@ func runAuto(cmd *cobra.Command, args []string) error {
 				fmt.Printf("%s✗ failed%s\n", colorRed, colorReset)
 			}
 		}
+		if autoParallel > 1 && len(subtasks) > 1 {
+			// Research every task at once; the architect only reads.
+			pool := worker.NewPool(worker.PoolConfig{
+				Store:         s,
+				Config:        cfg,
+				WorkDir:       workDir,
+				MaxWorkers:    autoParallel,
+				ArchitectName: archName,
+				ArchitectCfg:  archCfg,
+				OnLog:         followLog(),
+				Stream:        streamAgents,
+			})
+			for _, r := range pool.Architect(subtasks, newContextBuilder(s, cfg)) {
+				fmt.Printf("  #%d %s — ", r.TaskID, truncateAuto(r.Title, 40))
+				report(r.Status)
+			}
+		} else {
+			for i := range subtasks {
+				t := &subtasks[i]
+				if !worker.NeedsArchitect(*t) {
+					continue
+				}
+				fmt.Printf("  #%d %s — ", t.ID, truncateAuto(t.Title, 40))
+				report(autoArchitect(s, cfg, t, archName, archCfg, workDir))
+			}
+		}
 
 		if archBlocked > 0 {
 			fmt.Printf("\n  %s⚠ %d task(s) blocked by architect — answer with 'hive answer <id> \"...\"'%s\n",
 				colorYellow, archBlocked, colorReset)
+			term.bell()
 		}
 		fmt.Println()
 	} else if archName != "" && autoSkipArchitect {
 		printPhase("2.5", "ARCHITECT", "Skipped (--skip-architect)")
+	} else if archName != "" {
+		printPhase("2.5", "ARCHITECT", "Skipped (auto.architect: false; --with-architect runs it)")
+	}
+
+	// ══════════════════════════════════════
+	// STEP 2.6: Custom roles (before_code)
+	// ══════════════════════════════════════
+	if before := roles.NewRegistry(cfg.Roles).ByStage(roles.StageBeforeCode); len(before) > 0 {
+		printPhase("2.6", "ROLES", fmt.Sprintf("%d custom role(s) before coding", len(before)))
+
+		rolesBlocked := 0
+		for i := range subtasks {
+			t, err := s.GetTask(subtasks[i].ID)
+			if err != nil || t.Status == store.StatusDone || t.Status == store.StatusBlocked || t.Status == store.StatusNeedsHuman || t.Status == store.StatusCancelled {
+				continue
+			}
+
+			fmt.Printf("  #%d %s", t.ID, truncateAuto(t.Title, 40))
+			if stage := runRoleStage(s, cfg, roles.StageBeforeCode, t, workDir); stage.Status == worker.StageBlocked {
+				rolesBlocked++
+			}
+		}
+
+		if rolesBlocked > 0 {
+			fmt.Printf("\n  %s⚠ %d task(s) blocked by custom roles — answer with 'hive answer <id> \"...\"'%s\n",
+				colorYellow, rolesBlocked, colorReset)
+			term.bell()
+		}
+		fmt.Println()
 	}
 
-	// Re-read subtasks from DB — architect phase may have blocked some.
+	// Re-read subtasks from DB — architect and role phases may have blocked some.
 	if task.Kind == store.KindEpic {
 		refreshed, err := s.ListTasksByEpic(task.ID)
 		if err == nil && len(refreshed) > 0 {
@@ -284,31 +533,59 @@ func runAuto(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Record a perf baseline from the code as it is before any task runs.
+	if cfg.Perf.Cmd != "" {
+		if baseline, _ := perf.LoadBaseline(cfg.Perf.BaselinePath()); baseline == nil {
+			fmt.Printf("  Recording perf baseline (%s)... ", cfg.Perf.Cmd)
+			if err := recordPerfBaseline(cfg, workDir); err != nil {
+				fmt.Printf("%s⚠ %v%s\n\n", colorYellow, err, colorReset)
+			} else {
+				fmt.Printf("%s✓%s\n\n", colorGreen, colorReset)
+			}
+		}
+	}
+
 	// ══════════════════════════════════════
 	// STEP 3: Code + Review loop per task
 	// ══════════════════════════════════════
 	completed := 0
 	failed := 0
 	blocked := 0
+	// With auto.pause_on_block, the first blocked task stops the epic.
+	pause := cfg.Auto.PauseOnBlock && task.Kind == store.KindEpic
+	var pausedOn *store.Task
 
 	if autoParallel > 1 && len(subtasks) > 1 {
 		// Parallel execution using worker pool.
 		printPhase("3", "WORK", fmt.Sprintf("Running %d tasks (%d parallel)", len(subtasks), autoParallel))
+		term.title("working on %d tasks (%d parallel)", len(subtasks), autoParallel)
 
 		pool := worker.NewPool(worker.PoolConfig{
-			Store:      s,
-			Config:     cfg,
-			WorkDir:    workDir,
-			EpicBranch: task.GitBranch,
-			MaxWorkers: autoParallel,
-			MaxLoops:   autoMaxLoops,
-			CoderName:  coderName,
-			CoderCfg:   coderCfg,
-			ReviewName: reviewerName,
-			ReviewCfg:  reviewerCfg,
+			Store:       s,
+			Config:      cfg,
+			WorkDir:     workDir,
+			EpicBranch:  task.GitBranch,
+			EpicID:      task.ID,
+			MaxWorkers:  autoParallel,
+			MaxLoops:    autoMaxLoops,
+			CoderName:   coderName,
+			CoderCfg:    coderCfg,
+			ReviewName:  reviewerName,
+			ReviewCfg:   reviewerCfg,
+			SetupCmd:    setupCmdFor(cfg, task),
+			OnLog:       followLog(),
+			Stream:      streamAgents,
+			Checkpoints: checkpoints,
+			StopOnBlock: pause,
 		})
 
+		if !autoFollow {
+			fmt.Printf("  %sLive logs: %s (or re-run with --follow)%s\n\n", colorDim, hivePath("runs", "task-N.log"), colorReset)
+		}
 		results := pool.Run(subtasks)
+		if autoFollow {
+			fmt.Println()
+		}
 
 		for _, r := range results {
 			statusIcon := "✗"
@@ -322,6 +599,12 @@ func runAuto(cmd *cobra.Command, args []string) error {
 				statusIcon = "⚠"
 				statusColor = colorYellow
 				blocked++
+				if pause && pausedOn == nil {
+					pausedOn, _ = s.GetTask(r.TaskID)
+				}
+			case "skipped":
+				statusIcon = "⏸"
+				statusColor = colorDim
 			default:
 				failed++
 			}
@@ -339,13 +622,29 @@ func runAuto(cmd *cobra.Command, args []string) error {
 			for _, line := range r.Log {
 				fmt.Printf("    %s%s%s\n", colorDim, line, colorReset)
 			}
+			switch {
+			case len(r.Conflicts) > 0 && r.Branch != "":
+				fmt.Printf("    %s⚠ needs merge: %s conflicts with the epic branch in %s (see hive task show %d)%s\n",
+					colorYellow, r.Branch, strings.Join(r.Conflicts, ", "), r.TaskID, colorReset)
+			case len(r.Conflicts) > 0:
+				fmt.Printf("    %s⚠ needs merge: conflicts with the epic branch in %s (see hive task show %d)%s\n",
+					colorYellow, strings.Join(r.Conflicts, ", "), r.TaskID, colorReset)
+			}
 		}
 		fmt.Println()
+		// Results come in all at once, so one bell covers every blocker,
+		// including tasks that need merging.
+		if blocked > 0 {
+			term.title("%d blocked — needs your input", blocked)
+			term.bell()
+		}
 	} else {
 		// Sequential execution (original behavior).
+	work:
 		for i, subtask := range subtasks {
 			printPhase("3", fmt.Sprintf("WORK %d/%d", i+1, len(subtasks)),
 				fmt.Sprintf("#%d: %s", subtask.ID, subtask.Title))
+			term.title("work %d/%d — #%d", i+1, len(subtasks), subtask.ID)
 
 			if subtask.Status == store.StatusDone {
 				fmt.Printf("  %s✓ Already done%s\n\n", colorGreen, colorReset)
@@ -359,11 +658,27 @@ func runAuto(cmd *cobra.Command, args []string) error {
 				continue
 			}
 
+			if subtask.Status == store.StatusNeedsHuman {
+				fmt.Printf("  %s⚠ Needs a human%s (the fix loop gave up: hive log %d)\n", colorRed, colorReset, subtask.ID)
+				fmt.Printf("  → %shive answer %d \"...\"%s to retry it with your guidance\n\n",
+					colorCyan, subtask.ID, colorReset)
+				blocked++
+				if pause {
+					pausedOn = &subtask
+					break work
+				}
+				continue
+			}
+
 			if subtask.Status == store.StatusBlocked {
 				fmt.Printf("  %s⚠ Blocked: %s%s\n", colorRed, subtask.BlockedReason, colorReset)
 				fmt.Printf("  → %shive answer %d \"...\"%s\n\n",
 					colorCyan, subtask.ID, colorReset)
 				blocked++
+				if pause {
+					pausedOn = &subtask
+					break work
+				}
 				continue
 			}
 
@@ -372,19 +687,64 @@ func runAuto(cmd *cobra.Command, args []string) error {
 				continue
 			}
 
+			// Pick up config edits made while the run was going.
+			if next := reloadAutoConfig(cw); next != nil {
+				cfg = next
+				coderName, coderCfg = findAgentByRole(cfg, roles.Coder)
+				reviewerName, reviewerCfg = findAgentByRole(cfg, roles.Reviewer)
+				forceAutoAccept(&coderCfg)
+				forceAutoAccept(&reviewerCfg)
+			}
+
 			// Run fix loop for this subtask.
-			result := autoFixLoop(s, cfg, &subtask, coderName, coderCfg, reviewerName, reviewerCfg, workDir, autoMaxLoops)
+			_, span := tracing.StartTask(context.Background(), subtask.ID, subtask.Title)
+			taskCoderName, taskCoderCfg := taskCoder(cfg, &subtask, coderName, coderCfg)
+			result := autoFixLoop(s, cfg, &subtask, taskCoderName, taskCoderCfg, reviewerName, reviewerCfg, workDir, autoMaxLoops, checkpoints)
+			span.SetAttr("hive.task.status", result)
+			if result == "failed" {
+				span.Fail("task failed")
+			}
+			span.End()
 
 			switch result {
 			case "done":
 				completed++
 			case "blocked":
 				blocked++
+				term.bell()
+				if pause {
+					pausedOn, _ = s.GetTask(subtask.ID)
+					break work
+				}
 			default:
 				failed++
 			}
 		}
 	}
+	if pausedOn != nil {
+		worker.PauseEpic(s, task.ID, pausedOn)
+	}
+
+	// ══════════════════════════════════════
+	// STEP 4: Docs (epics only, once everything is done)
+	// ══════════════════════════════════════
+	if docsName, docsCfg := findAgentByRole(cfg, roles.Docs); docsName != "" && task.Kind == store.KindEpic && completed == len(subtasks) {
+		if autoSkipDocs {
+			printPhase("4", "DOCS", "Skipped (--skip-docs)")
+		} else {
+			printPhase("4", "DOCS", "Updating documentation for the epic")
+			term.title("docs")
+			forceAutoAccept(&docsCfg)
+
+			if refreshed, err := s.ListTasksByEpic(task.ID); err == nil && len(refreshed) > 0 {
+				subtasks = refreshed
+			}
+			if autoDocs(s, cfg, task, subtasks, docsName, docsCfg, workDir) == "blocked" {
+				fmt.Printf("  %sDocs skipped — the epic can still be accepted.%s\n", colorDim, colorReset)
+			}
+			fmt.Println()
+		}
+	}
 
 	// ══════════════════════════════════════
 	// SUMMARY
@@ -397,12 +757,25 @@ func runAuto(cmd *cobra.Command, args []string) error {
 	if completed > 0 {
 		fmt.Printf("  %s✓ Completed: %d%s\n", colorGreen, completed, colorReset)
 	}
-	if blocked > 0 {
+	if pausedOn != nil {
+		fmt.Printf("  %s⏸ Paused on #%d%s (answer it and the pipeline resumes: hive answer %d \"...\")\n", colorYellow, pausedOn.ID, colorReset, pausedOn.ID)
+	} else if blocked > 0 {
 		fmt.Printf("  %s⚠ Blocked:   %d%s (answer blockers, then re-run with --skip-plan)\n", colorYellow, blocked, colorReset)
 	}
 	if failed > 0 {
 		fmt.Printf("  %s✗ Failed:    %d%s\n", colorRed, failed, colorReset)
+		printTriage(s, task, subtasks)
+	}
+
+	switch {
+	case failed > 0:
+		term.title("finished — %d failed", failed)
+	case blocked > 0:
+		term.title("finished — %d blocked, needs your input", blocked)
+	default:
+		term.title("finished — %d/%d done", completed, len(subtasks))
 	}
+	term.bell()
 
 	// End pipeline run tracking.
 	if pipelineRunID > 0 {
@@ -413,6 +786,14 @@ func runAuto(cmd *cobra.Command, args []string) error {
 			endStatus = "blocked"
 		}
 		s.EndPipelineRun(pipelineRunID, endStatus)
+		metrics.ObservePipeline(endStatus, time.Since(pipelineStart).Seconds())
+		pipelineSpan.SetAttr("hive.pipeline.status", endStatus)
+		if endStatus == "failed" {
+			pipelineSpan.Fail(fmt.Sprintf("%d task(s) failed", failed))
+		}
+	}
+	if failed > 0 {
+		notifyPipeline(s, notify.EventPipelineFailed, task, fmt.Sprintf("%d of %d task(s) failed", failed, len(subtasks)))
 	}
 
 	if completed == len(subtasks) {
@@ -431,7 +812,7 @@ func runAuto(cmd *cobra.Command, args []string) error {
 					fmt.Printf("  Committed changes on %s%s%s\n", colorCyan, task.GitBranch, colorReset)
 				}
 
-				baseBranch, _ := safety.BaseBranch()
+				baseBranch, _ := safety.BaseBranchFor(task.BaseBranch)
 				stat, _ := safety.DiffStat(baseBranch, task.GitBranch)
 				if stat != "" {
 					fmt.Printf("\n  %sChanges:%s\n", colorBold, colorReset)
@@ -453,15 +834,41 @@ func runAuto(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printTriage saves a triage report for the tasks that failed or are
+// blocked as an artifact of the task the pipeline ran on, and prints
+// what to run next for each.
+func printTriage(s *store.Store, task *store.Task, subtasks []store.Task) {
+	failures := worker.Triage(s, subtasks, autoMaxLoops)
+	if len(failures) == 0 {
+		return
+	}
+	path, err := newArtifacts(s).Save(task.ID, "triage", artifacts.Name(task.ID, "triage"), worker.TriageReport(task, failures))
+	if err != nil {
+		fmt.Printf("  %s⚠ triage report: %v%s\n", colorYellow, err, colorReset)
+	}
+
+	fmt.Printf("\n  %sTriage:%s\n", colorBold, colorReset)
+	for _, f := range failures {
+		fmt.Printf("  %s#%d%s %s %s(%s)%s\n", colorYellow, f.TaskID, colorReset, f.Title, colorDim, f.Category, colorReset)
+		fmt.Printf("    %s%s%s\n", colorDim, truncateAuto(f.Reason, 100), colorReset)
+		for _, n := range f.Next {
+			fmt.Printf("    → %s%s%s  %s# %s%s\n", colorCyan, n.Command, colorReset, colorDim, n.Why, colorReset)
+		}
+	}
+	if err == nil {
+		fmt.Printf("  %sFull report: %s%s\n", colorDim, path, colorReset)
+	}
+}
+
 // autoPlan runs the PM agent and creates subtasks.
 func autoPlan(s *store.Store, cfg *config.Config, task *store.Task, pmName string, pmCfg config.Agent, workDir string) ([]store.Task, error) {
-	ctxBuilder := agentctx.New(s)
-	prompt, err := ctxBuilder.BuildPrompt(task, "pm")
+	ctxBuilder := newContextBuilder(s, cfg)
+	prompt, err := ctxBuilder.BuildPrompt(task, roles.PM)
 	if err != nil {
 		return nil, err
 	}
 
-	runner, err := agent.NewRunner(pmName, pmCfg)
+	runner, err := worker.NewRunner(pmName, pmCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -473,16 +880,16 @@ func autoPlan(s *store.Store, cfg *config.Config, task *store.Task, pmName strin
 		Prompt:     prompt,
 		WorkDir:    workDir,
 		TimeoutSec: pmCfg.DefaultTimeout(),
+		Stream:     newLiveOutput(task.ID, pmName, liveIndent).stream(),
+		Format:     agent.FormatPlan,
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	// Save artifact.
-	artifactPath := hivePath("runs", fmt.Sprintf("task-%d-auto-plan.md", task.ID))
-	os.MkdirAll(hivePath("runs"), 0755)
-	os.WriteFile(artifactPath, []byte(resp.Output), 0644)
-	s.AddArtifact(task.ID, "plan", artifactPath)
+	planPath, _ := newArtifacts(s).Save(task.ID, "plan", artifacts.Name(task.ID, "auto-plan"), resp.Output)
+	warnTruncated(s, task.ID, pmName, "The plan", resp)
 
 	// Check for blocker.
 	if b := agent.ParseBlocked(resp.Output); b != "" {
@@ -492,33 +899,118 @@ func autoPlan(s *store.Store, cfg *config.Config, task *store.Task, pmName strin
 		return nil, nil
 	}
 
-	// Parse subtasks.
-	parsed := agent.ParseSubtasks(resp.Output)
+	// Mark the epic planned before creating anything, so a run that
+	// stops halfway finishes this plan rather than making another.
+	if hash := fileHash(planPath); hash != "" {
+		s.SetPlanHash(task.ID, hash)
+		task.PlanHash = hash
+	}
+	return createPlannedTasks(s, task, pmName, agent.ParseSubtasks(resp.Output), nil), nil
+}
 
+// createPlannedTasks creates the subtasks of an epic's plan that aren't
+// among existing yet, matched by title, and returns all the plan's tasks.
+// A plan without structured subtasks runs the coder on the epic itself.
+func createPlannedTasks(s *store.Store, task *store.Task, by string, parsed []agent.ParsedSubtask, existing []store.Task) []store.Task {
 	if len(parsed) == 0 {
 		fmt.Printf("  PM didn't return structured subtasks. Running coder on main task.\n\n")
-		return []store.Task{*task}, nil
+		return []store.Task{*task}
 	}
 
+	byTitle := map[string]store.Task{}
+	for _, t := range existing {
+		byTitle[t.Title] = t
+	}
 	var subtasks []store.Task
+	created := 0
 	for _, sub := range parsed {
-		parentID := task.ID
-		created, err := s.CreateTask(sub.Title, sub.Description, sub.Priority, &parentID)
+		if t, ok := byTitle[sub.Title]; ok {
+			subtasks = append(subtasks, t)
+			delete(byTitle, sub.Title)
+			continue
+		}
+		t, err := createSubtask(s, task.ID, sub)
 		if err != nil {
 			continue
 		}
-		subtasks = append(subtasks, *created)
+		subtasks = append(subtasks, *t)
+		created++
 		priColor := priorityColor(sub.Priority)
-		fmt.Printf("  %s#%d%s %s%s%s [%s]\n", colorYellow, created.ID, colorReset, priColor, sub.Title, colorReset, sub.Priority)
+		fmt.Printf("  %s#%d%s %s%s%s [%s]\n", colorYellow, t.ID, colorReset, priColor, sub.Title, colorReset, sub.Priority)
+	}
+	// Tasks added to the epic by other means stay part of it.
+	for _, t := range existing {
+		if _, ok := byTitle[t.Title]; ok {
+			subtasks = append(subtasks, t)
+		}
 	}
 
-	fmt.Printf("  Created %d subtasks\n\n", len(subtasks))
+	fmt.Printf("  Created %d subtasks\n\n", created)
 
-	s.AddEvent(task.ID, pmName, "planned", fmt.Sprintf("Auto-created %d subtasks", len(subtasks)))
-	return subtasks, nil
+	s.AddEvent(task.ID, by, "planned", fmt.Sprintf("Auto-created %d subtasks (plan %s)", created, shortHash(task.PlanHash)))
+	return subtasks
+}
+
+// cachedPlan returns the subtasks of the plan an earlier run saved for the
+// epic, when the plan artifact still matches the hash recorded on the
+// epic. done reports whether that run went on to create all of them.
+func cachedPlan(s *store.Store, epic *store.Task) (parsed []agent.ParsedSubtask, done, ok bool) {
+	if epic.PlanHash == "" {
+		return nil, false, false
+	}
+	path := newArtifacts(s).Path(artifacts.Name(epic.ID, "auto-plan"))
+	if fileHash(path) != epic.PlanHash {
+		return nil, false, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, false
+	}
+	events, _ := s.GetEvents(epic.ID)
+	for _, e := range events {
+		if e.Type == "planned" && strings.Contains(e.Content, "(plan "+shortHash(epic.PlanHash)+")") {
+			done = true
+		}
+	}
+	return agent.ParseSubtasks(string(data)), done, true
+}
+
+// fileHash returns the hex SHA-256 of a file, or "" if it can't be read.
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// shortHash abbreviates a plan hash for output, as git does.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// lockEpic takes the epic's run lock, so a second hive auto on it stops
+// instead of racing the first over its tasks.
+func lockEpic(epicID int64) (*lock.Lock, error) {
+	l, err := lock.Acquire(hivePath("locks", fmt.Sprintf("epic-%d.lock", epicID)))
+	var held *lock.HeldError
+	if errors.As(err, &held) {
+		who := "another hive auto"
+		if held.PID > 0 {
+			who = fmt.Sprintf("another hive auto (pid %d)", held.PID)
+		}
+		return nil, fmt.Errorf("epic #%d is already running in %s — wait for it, or stop it first", epicID, who)
+	}
+	return l, err
 }
 
 // autoFixLoop runs code → review → fix for a single task. Returns "done", "blocked", or "failed".
+// cps, if set, checkpoints the loop for hive resume and continues it where
+// an interrupted run stopped.
 func autoFixLoop(
 	s *store.Store, cfg *config.Config,
 	task *store.Task,
@@ -526,12 +1018,13 @@ func autoFixLoop(
 	reviewerName string, reviewerCfg config.Agent,
 	workDir string,
 	maxLoops int,
+	cps *worker.Checkpoints,
 ) string {
-	ctxBuilder := agentctx.New(s)
+	ctxBuilder := newContextBuilder(s, cfg)
 
 	// If no reviewer, just run coder and done.
 	if reviewerName == "" {
-		result := runCoderOnce(s, ctxBuilder, task, coderName, coderCfg, workDir, 0)
+		result := runCoderOnce(s, cfg, ctxBuilder, task, coderName, coderCfg, workDir, 0)
 		if result == "blocked" {
 			return "blocked"
 		}
@@ -543,49 +1036,87 @@ func autoFixLoop(
 		return "done"
 	}
 
-	coderRunner, err := agent.NewRunner(coderName, coderCfg)
+	coderRunner, err := worker.NewRunner(coderName, coderCfg)
 	if err != nil {
+		worker.FailTask(s, task.ID, "hive", worker.ConfigProblem, fmt.Sprintf("could not create coder %s: %v", coderName, err))
 		fmt.Printf("  %s✗ Failed to create coder: %v%s\n\n", colorRed, err, colorReset)
 		return "failed"
 	}
 
-	reviewerRunner, err := agent.NewRunner(reviewerName, reviewerCfg)
+	reviewerRunner, err := worker.NewRunner(reviewerName, reviewerCfg)
 	if err != nil {
+		worker.FailTask(s, task.ID, "hive", worker.ConfigProblem, fmt.Sprintf("could not create reviewer %s: %v", reviewerName, err))
 		fmt.Printf("  %s✗ Failed to create reviewer: %v%s\n\n", colorRed, err, colorReset)
 		return "failed"
 	}
 
-	for iteration := 1; iteration <= maxLoops; iteration++ {
+	var applied *agent.Response // A reviewer patch standing in for the next coder run
+	var rejections worker.RejectionTracker
+	var diffGuard worker.DiffGuard
+
+	// After a crash, continue at the iteration the run stopped in; the
+	// coder's finished work is still in the working tree.
+	start, lastReview := 1, ""
+	var resumed *agent.Response
+	if rp, ok := cps.Resume(task.ID, true); ok {
+		start, resumed = rp.Iteration, rp.Coder
+		fmt.Printf("  %s↺ resuming at iteration %d%s\n", colorDim, start, colorReset)
+	}
+	defer cps.Save(task.ID, 0, store.PhaseDone, "", "")
+
+	for iteration := start; iteration <= maxLoops; iteration++ {
+		if iteration > start {
+			waitBackoff(cfg, iteration)
+		}
+
 		// Re-fetch task for latest context.
 		task, _ = s.GetTask(task.ID)
 
+		// === TESTER (before_code) ===
+		if iteration == 1 && resumed == nil && cfg.Testing.TesterStage() == string(roles.StageBeforeCode) {
+			if worker.RunTester(s, newArtifacts(s), cfg, roles.NewRegistry(cfg.Roles), task, workDir, true, stageLogf).Status == worker.StageBlocked {
+				fmt.Printf("    → %shive answer %d \"...\"%s\n\n", colorCyan, task.ID, colorReset)
+				return "blocked"
+			}
+		}
+
 		// === CODER ===
 		s.UpdateTaskStatus(task.ID, store.StatusInProgress)
-		fmt.Printf("  [%d/%d] %s%s%s coding... ", iteration, maxLoops, colorBlue, coderName, colorReset)
-
-		coderPrompt, _ := ctxBuilder.BuildPrompt(task, "coder")
-		coderResp, err := coderRunner.Run(context.Background(), agent.Request{
-			TaskID: task.ID, Prompt: coderPrompt, WorkDir: workDir, TimeoutSec: coderCfg.DefaultTimeout(),
-		})
-		if err != nil {
-			s.UpdateTaskStatus(task.ID, store.StatusFailed)
-			fmt.Printf("%s✗ error%s\n\n", colorRed, colorReset)
-			return "failed"
+		cps.Save(task.ID, iteration, store.PhaseCode, "", lastReview)
+		coderResp := applied
+		fromCheckpoint := resumed != nil
+		if fromCheckpoint {
+			coderResp, resumed = resumed, nil
+			fmt.Printf("  [%d/%d] %scoder output from the checkpoint%s ", iteration, maxLoops, colorBlue, colorReset)
+		} else if applied != nil {
+			applied = nil
+			fmt.Printf("  [%d/%d] %sreviewer patch applied%s ", iteration, maxLoops, colorBlue, colorReset)
+		} else {
+			fmt.Printf("  [%d/%d] %s%s%s coding... ", iteration, maxLoops, colorBlue, coderName, colorReset)
+
+			coderPrompt, _ := ctxBuilder.BuildPrompt(task, roles.Coder)
+			coderMsgs, _ := ctxBuilder.BuildMessages(task, roles.Coder)
+			live := newLiveOutput(task.ID, coderName, liveIndent)
+			coderResp, err = coderRunner.Run(context.Background(), agent.Request{
+				TaskID: task.ID, Prompt: coderPrompt, Messages: coderMsgs, WorkDir: workDir, TimeoutSec: coderCfg.DefaultTimeout(),
+				Stream: live.stream(),
+			})
+			live.resume()
+			if err != nil {
+				worker.FailRun(s, task.ID, coderName, coderResp, err)
+				fmt.Printf("%s✗ error%s\n\n", colorRed, colorReset)
+				return "failed"
+			}
 		}
 
 		// Save artifact.
-		artifactPath := hivePath("runs", fmt.Sprintf("task-%d-auto-code-iter%d.md", task.ID, iteration))
-		os.MkdirAll(hivePath("runs"), 0755)
-		os.WriteFile(artifactPath, []byte(coderResp.Output), 0644)
-		s.AddArtifact(task.ID, "code", artifactPath)
-
-		preview := coderResp.Output
-		if len(preview) > 200 {
-			preview = preview[:200] + "..."
+		codeName := artifacts.Name(task.ID, "auto-code", fmt.Sprintf("iter%d", iteration))
+		if !fromCheckpoint {
+			newArtifacts(s).Save(task.ID, "code", codeName, coderResp.Output)
+			s.AddEvent(task.ID, coderName, "agent_output", cfg.History.Preview(coderResp.Output))
+			fmt.Printf("%.1fs ", coderResp.Duration)
 		}
-		s.AddEvent(task.ID, coderName, "agent_output", preview)
-
-		fmt.Printf("%.1fs ", coderResp.Duration)
+		cps.Save(task.ID, iteration, store.PhaseReview, codeName, lastReview)
 
 		// Check blocked.
 		if b := agent.ParseBlocked(coderResp.Output); b != "" {
@@ -597,35 +1128,137 @@ func autoFixLoop(
 		}
 
 		if coderResp.ExitCode != 0 {
-			s.UpdateTaskStatus(task.ID, store.StatusFailed)
+			worker.FailRun(s, task.ID, coderName, coderResp, nil)
 			fmt.Printf("%s✗ exit %d%s\n\n", colorRed, coderResp.ExitCode, colorReset)
 			return "failed"
 		}
 
-		// === REVIEWER ===
-		s.UpdateTaskStatus(task.ID, store.StatusReview)
-		fmt.Printf("→ %s%s%s reviewing... ", colorMagenta, reviewerName, colorReset)
+		// === FILES CHANGED (cross-check with git) ===
+		if fc := worker.CheckFilesChanged(s, task, iteration, coderName, coderResp.Output, workDir); fc.Empty {
+			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
+			s.AddEvent(task.ID, "git", "reviewed", fc.EmptyDiffFeedback(iteration))
+			fmt.Printf("%s✗ empty diff%s\n", colorRed, colorReset)
+			continue
+		} else if len(fc.Missing) > 0 {
+			fmt.Printf("%s⚠ %d declared file(s) unchanged%s ", colorYellow, len(fc.Missing), colorReset)
+		}
+		if r := diffGuard.Check(s, task, iteration, workDir); r != nil {
+			fmt.Printf("%s⚠ undid %d line(s) from iter %d%s ", colorYellow, r.Undone, r.Previous, colorReset)
+		}
 
-		reviewPrompt, _ := ctxBuilder.BuildReviewPrompt(task)
-		reviewResp, err := reviewerRunner.Run(context.Background(), agent.Request{
-			TaskID: task.ID, Prompt: reviewPrompt, WorkDir: workDir, TimeoutSec: reviewerCfg.DefaultTimeout(),
-		})
-		if err != nil {
-			fmt.Printf("%s✗ error%s\n\n", colorRed, colorReset)
+		// === TESTER (after_code) + test gate ===
+		if iteration == 1 && cfg.Testing.TesterStage() == string(roles.StageAfterCode) {
+			if testerName, _ := findAgentByRole(cfg, roles.Tester); testerName != "" {
+				fmt.Println()
+				if worker.RunTester(s, newArtifacts(s), cfg, roles.NewRegistry(cfg.Roles), task, workDir, true, stageLogf).Status == worker.StageBlocked {
+					fmt.Printf("    → %shive answer %d \"...\"%s\n\n", colorCyan, task.ID, colorReset)
+					return "blocked"
+				}
+				fmt.Print("  ")
+			}
+		}
+		if f := worker.RunTestGate(cfg, workDir); f != nil {
+			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
+			s.AddEvent(task.ID, f.Step, "reviewed", f.Feedback(iteration))
+			fmt.Printf("%s✗ %s failed%s (%s)\n", colorRed, f.Step, colorReset, f.Cmd)
 			continue
+		} else if cfg.Testing.Cmd != "" {
+			fmt.Printf("%stests ✓%s ", colorGreen, colorReset)
 		}
 
-		// Save artifact.
-		reviewPath := hivePath("runs", fmt.Sprintf("task-%d-auto-review-iter%d.md", task.ID, iteration))
-		os.WriteFile(reviewPath, []byte(reviewResp.Output), 0644)
-		s.AddArtifact(task.ID, "review", reviewPath)
+		// === PERF GATE ===
+		if regs, err := worker.RunPerfGate(cfg, workDir); err != nil {
+			fmt.Printf("%s⚠ perf: %v%s ", colorYellow, err, colorReset)
+		} else if len(regs) > 0 && cfg.Perf.FlagOnly() {
+			s.AddEvent(task.ID, "perf", "perf_regression", "Benchmarks regressed:\n"+worker.PerfFindings(regs))
+			fmt.Printf("%s⚠ perf regressed%s ", colorYellow, colorReset)
+		} else if len(regs) > 0 {
+			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
+			s.AddEvent(task.ID, "perf", "reviewed",
+				fmt.Sprintf("REJECTED (iter %d): benchmarks regressed\n%s", iteration, worker.PerfFindings(regs)))
+			fmt.Printf("%s✗ perf regressed%s\n", colorRed, colorReset)
+			for _, r := range regs {
+				fmt.Printf("    %s•%s %s\n", colorRed, colorReset, r)
+			}
+			continue
+		}
 
-		review := agent.ParseReview(reviewResp.Output)
+		// === CUSTOM ROLES (after_code) ===
+		switch stage := runRoleStage(s, cfg, roles.StageAfterCode, task, workDir); stage.Status {
+		case worker.StageBlocked:
+			fmt.Printf("    → %shive answer %d \"...\"%s\n\n", colorCyan, task.ID, colorReset)
+			return "blocked"
+		case worker.StageRejected:
+			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
+			continue
+		}
+
+		// === REVIEWER ===
+		s.UpdateTaskStatus(task.ID, store.StatusReview)
+
+		// A small docs, test or comment-only change is approved without a
+		// review call (review.skip_trivial).
+		var reviewResp *agent.Response
+		var review agent.ParsedReview
+		verdictBy := reviewerName
+		if reason, ok := worker.SkipReview(s, cfg, task, workDir); ok {
+			fmt.Printf("→ %sreview skipped%s (%s) ", colorDim, colorReset, reason)
+			verdictBy, review = "hive", agent.ParsedReview{Verdict: "APPROVE"}
+			reviewResp = &agent.Response{Output: "Review skipped: " + reason}
+		} else {
+			fmt.Printf("→ %s%s%s reviewing... ", colorMagenta, reviewerName, colorReset)
+
+			reviewPrompt, _ := ctxBuilder.BuildReviewPrompt(task)
+			reviewMsgs, _ := ctxBuilder.BuildReviewMessages(task)
+			live := newLiveOutput(task.ID, reviewerName, liveIndent)
+			if chunks := ctxBuilder.ReviewChunks(task); len(chunks) > 1 {
+				// Too large for one prompt: review it part by part.
+				fmt.Printf("in %d parts\n", len(chunks))
+				reviewResp, err = worker.ChunkedReview(reviewerRunner, newArtifacts(s), ctxBuilder, task, chunks, agent.Request{
+					TaskID: task.ID, WorkDir: workDir, TimeoutSec: reviewerCfg.DefaultTimeout(), Stream: live.stream(),
+					Format: agent.FormatReview,
+				}, cfg.Review.Checklist, fmt.Sprintf("auto-review-iter%d", iteration), stageLogf)
+				fmt.Print("    ")
+			} else {
+				reviewResp, err = reviewerRunner.Run(context.Background(), agent.Request{
+					TaskID: task.ID, Prompt: reviewPrompt, Messages: reviewMsgs, WorkDir: workDir, TimeoutSec: reviewerCfg.DefaultTimeout(),
+					Stream: live.stream(), Format: agent.FormatReview,
+				})
+			}
+			live.resume()
+			if err != nil {
+				fmt.Printf("%s✗ error%s\n\n", colorRed, colorReset)
+				continue
+			}
+
+			// Save artifact.
+			lastReview = artifacts.Name(task.ID, "auto-review", fmt.Sprintf("iter%d", iteration))
+			newArtifacts(s).Save(task.ID, "review", lastReview, reviewResp.Output)
+
+			review = agent.CheckAcceptance(agent.ParseReviewWithChecklist(reviewResp.Output, cfg.Review.Checklist), reviewResp.Output, task.Acceptance)
+			worker.SaveSuggestions(newArtifacts(s), task.ID, review, fmt.Sprintf("iter%d", iteration))
+
+			// An unclear or reversed verdict goes to a second reviewer, whose
+			// verdict stands.
+			if reason := worker.TiebreakReason(s, task.ID, review); reason != "" {
+				if _, _, ok := worker.Tiebreaker(cfg, reviewerName); ok {
+					fmt.Println()
+					req := agent.Request{TaskID: task.ID, Prompt: reviewPrompt, Messages: reviewMsgs, WorkDir: workDir, Format: agent.FormatReview}
+					if name, second, out, ok := worker.Tiebreak(s, newArtifacts(s), cfg, task, reviewerName, review, req, reason, fmt.Sprintf("iter%d", iteration), stageLogf); ok {
+						resp := *reviewResp
+						resp.Output = out
+						verdictBy, review, reviewResp = name, second, &resp
+					}
+					fmt.Print("    ")
+				}
+			}
+		}
+
+		repeated := rejections.Add(iteration, review, reviewResp.Output)
 
 		switch review.Verdict {
 		case "APPROVE":
-			s.AddReview(task.ID, reviewerName, "approve", reviewResp.Output)
-			s.UpdateTaskStatus(task.ID, store.StatusDone)
+			s.AddReview(task.ID, verdictBy, "approve", reviewResp.Output)
 			fmt.Printf("%s✓ APPROVED%s (%.1fs)\n", colorGreen+colorBold, colorReset, reviewResp.Duration)
 			if len(review.Comments) > 0 {
 				for _, c := range review.Comments {
@@ -633,6 +1266,14 @@ func autoFixLoop(
 				}
 			}
 
+			// === CUSTOM ROLES (after_review) ===
+			if stage := runRoleStage(s, cfg, roles.StageAfterReview, task, workDir); stage.Status == worker.StageBlocked {
+				fmt.Printf("    → %shive answer %d \"...\"%s\n\n", colorCyan, task.ID, colorReset)
+				return "blocked"
+			}
+			s.UpdateTaskStatus(task.ID, store.StatusDone)
+			worker.RecordHandoff(s, task, coderResp.Output)
+
 			// Commit the approved work on the safety branch.
 			safety := git.New(workDir)
 			if safety.IsGitRepo() {
@@ -643,13 +1284,14 @@ func autoFixLoop(
 				} else if committed {
 					fmt.Printf("    %scommitted%s\n", colorDim, colorReset)
 				}
+				warnSubmodules(safety)
 			}
 
 			fmt.Println()
 			return "done"
 
 		case "REJECT":
-			s.AddReview(task.ID, reviewerName, "reject", reviewResp.Output)
+			s.AddReview(task.ID, verdictBy, "reject", reviewResp.Output)
 			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
 			fmt.Printf("%s✗ REJECTED%s (%.1fs)\n", colorRed, colorReset, reviewResp.Duration)
 			for _, c := range review.Comments {
@@ -660,25 +1302,52 @@ func autoFixLoop(
 			for _, c := range review.Comments {
 				comments.WriteString("- " + c + "\n")
 			}
-			s.AddEvent(task.ID, reviewerName, "reviewed",
+			s.AddEvent(task.ID, verdictBy, "reviewed",
 				fmt.Sprintf("REJECTED (iter %d):\n%s", iteration, comments.String()))
+			if repeated {
+				rejections.MarkNeedsHuman(s, task, verdictBy)
+				fmt.Printf("  %s✗ Same objections as the last review — stopping, needs a human%s\n\n", colorRed, colorReset)
+				return "failed"
+			}
+			if applied = worker.ApplySuggestions(s, cfg, task, verdictBy, review, workDir); applied != nil {
+				fmt.Printf("    %s→ applying the reviewer's patch, skipping the coder%s\n", colorDim, colorReset)
+			}
 
 		default:
 			fmt.Printf("%s? no verdict%s (%.1fs)\n", colorYellow, colorReset, reviewResp.Duration)
-			s.AddEvent(task.ID, reviewerName, "reviewed", "No clear verdict")
+			s.AddEvent(task.ID, verdictBy, "reviewed", "No clear verdict")
 		}
 	}
 
 	// Max iterations reached.
-	s.UpdateTaskStatus(task.ID, store.StatusFailed)
-	fmt.Printf("  %s✗ Max iterations reached%s\n\n", colorRed, colorReset)
+	worker.MarkMaxLoops(s, task.ID, maxLoops)
+	fmt.Printf("  %s✗ Max iterations reached — needs a human%s\n\n", colorRed, colorReset)
 	return "failed"
 }
 
+// waitBackoff waits as configured before fix-loop iteration n, counting
+// down the time left on a terminal.
+func waitBackoff(cfg *config.Config, iteration int) {
+	d := worker.BackoffDelay(cfg, iteration, false)
+	if d <= 0 {
+		return
+	}
+	if !isTerminal(os.Stdout) {
+		fmt.Printf("  %swaiting %s before iteration %d (backoff)%s\n", colorDim, d.Round(time.Second), iteration, colorReset)
+		time.Sleep(d)
+		return
+	}
+	worker.WaitBackoff(d, time.Second, func(left time.Duration) {
+		fmt.Printf("\r\033[K  %s⏳ backoff: %s left before iteration %d%s", colorDim, left, iteration, colorReset)
+	})
+	fmt.Print("\r\033[K")
+}
+
 // runCoderOnce runs coder agent once without review.
-func runCoderOnce(s *store.Store, ctxBuilder *agentctx.Builder, task *store.Task, coderName string, coderCfg config.Agent, workDir string, iteration int) string {
-	runner, err := agent.NewRunner(coderName, coderCfg)
+func runCoderOnce(s *store.Store, cfg *config.Config, ctxBuilder *agentctx.Builder, task *store.Task, coderName string, coderCfg config.Agent, workDir string, iteration int) string {
+	runner, err := worker.NewRunner(coderName, coderCfg)
 	if err != nil {
+		worker.FailTask(s, task.ID, "hive", worker.ConfigProblem, fmt.Sprintf("could not create coder %s: %v", coderName, err))
 		fmt.Printf("  %s✗ Failed: %v%s\n\n", colorRed, err, colorReset)
 		return "failed"
 	}
@@ -686,21 +1355,21 @@ func runCoderOnce(s *store.Store, ctxBuilder *agentctx.Builder, task *store.Task
 	s.UpdateTaskStatus(task.ID, store.StatusInProgress)
 	fmt.Printf("  %s%s%s coding... ", colorBlue, coderName, colorReset)
 
-	prompt, _ := ctxBuilder.BuildPrompt(task, "coder")
+	prompt, _ := ctxBuilder.BuildPrompt(task, roles.Coder)
+	msgs, _ := ctxBuilder.BuildMessages(task, roles.Coder)
+	live := newLiveOutput(task.ID, coderName, liveIndent)
 	resp, err := runner.Run(context.Background(), agent.Request{
-		TaskID: task.ID, Prompt: prompt, WorkDir: workDir, TimeoutSec: coderCfg.DefaultTimeout(),
+		TaskID: task.ID, Prompt: prompt, Messages: msgs, WorkDir: workDir, TimeoutSec: coderCfg.DefaultTimeout(),
+		Stream: live.stream(),
 	})
+	live.resume()
 	if err != nil {
-		s.UpdateTaskStatus(task.ID, store.StatusFailed)
+		worker.FailRun(s, task.ID, coderName, resp, err)
 		fmt.Printf("%s✗ error%s\n", colorRed, colorReset)
 		return "failed"
 	}
 
-	preview := resp.Output
-	if len(preview) > 200 {
-		preview = preview[:200] + "..."
-	}
-	s.AddEvent(task.ID, coderName, "agent_output", preview)
+	s.AddEvent(task.ID, coderName, "agent_output", cfg.History.Preview(resp.Output))
 
 	if b := agent.ParseBlocked(resp.Output); b != "" {
 		s.BlockTask(task.ID, b)
@@ -709,7 +1378,7 @@ func runCoderOnce(s *store.Store, ctxBuilder *agentctx.Builder, task *store.Task
 	}
 
 	if resp.ExitCode != 0 {
-		s.UpdateTaskStatus(task.ID, store.StatusFailed)
+		worker.FailRun(s, task.ID, coderName, resp, nil)
 		fmt.Printf("%s✗ exit %d%s\n", colorRed, resp.ExitCode, colorReset)
 		return "failed"
 	}
@@ -718,62 +1387,99 @@ func runCoderOnce(s *store.Store, ctxBuilder *agentctx.Builder, task *store.Task
 	return "done"
 }
 
-// findAgentByRole returns the first agent with the given role.
+// reloadAutoConfig returns the config if it changed on disk and is usable,
+// else nil. A broken edit is reported and the run carries on with the
+// config it has.
+func reloadAutoConfig(cw *config.Watcher) *config.Config {
+	next, changed, err := cw.Check()
+	if err != nil {
+		fmt.Printf("  %s⚠ Config changed but is invalid, keeping the previous one: %v%s\n", colorYellow, err, colorReset)
+		return nil
+	}
+	if !changed {
+		return nil
+	}
+	if name, _ := findAgentByRole(next, roles.Coder); name == "" {
+		fmt.Printf("  %s⚠ Config changed but has no coder agent, keeping the previous one%s\n", colorYellow, colorReset)
+		return nil
+	}
+	resolveOffline(next)
+	if err := checkOffline(next, roles.NewRegistry(next.Roles).Names()...); err != nil {
+		fmt.Printf("  %s⚠ Config changed but %v; keeping the previous one%s\n", colorYellow, err, colorReset)
+		return nil
+	}
+	applyConfig(next)
+	fmt.Printf("  %s↻ Config reloaded%s\n", colorDim, colorReset)
+	return next
+}
+
+// findAgentByRole returns the agent for a role, the first by name when
+// there are several. Agents that owners routes point at are only picked
+// when no other agent has the role, so they keep to their owners' tasks.
 func findAgentByRole(cfg *config.Config, role string) (string, config.Agent) {
+	routed := map[string]bool{}
+	for _, r := range cfg.Owners.Routes {
+		routed[r.Agent] = true
+	}
+	names := make([]string, 0, len(cfg.Agents))
 	for name, a := range cfg.Agents {
 		if a.Role == role {
-			return name, a
+			names = append(names, name)
 		}
 	}
-	return "", config.Agent{}
-}
-
-// autoArchitect runs the architect agent on a task to produce a technical spec.
-// The spec is saved as an event so the coder can read it via context builder.
-// Returns "done", "blocked", or "failed".
-func autoArchitect(s *store.Store, task *store.Task, archName string, archCfg config.Agent, workDir string) string {
-	ctxBuilder := agentctx.New(s)
-	prompt, err := ctxBuilder.BuildPrompt(task, "architect")
-	if err != nil {
-		return "failed"
+	if len(names) == 0 {
+		return "", config.Agent{}
 	}
+	sort.Slice(names, func(i, j int) bool {
+		if routed[names[i]] != routed[names[j]] {
+			return !routed[names[i]]
+		}
+		return names[i] < names[j]
+	})
+	return names[0], cfg.Agents[names[0]]
+}
 
-	runner, err := agent.NewRunner(archName, archCfg)
-	if err != nil {
-		return "failed"
+// newContextBuilder returns a prompt builder that knows the custom roles
+// and role overrides from config, the review checklist, code owners and
+// whether to inline earlier outputs. A broken owners setup only costs the
+// ownership section; hive auto reports it.
+func newContextBuilder(s *store.Store, cfg *config.Config) *agentctx.Builder {
+	own, _ := loadOwners(cfg)
+	b := agentctx.New(s).WithRoles(roles.NewRegistry(cfg.Roles)).WithChecklist(cfg.Review.Items()).WithOwners(own)
+	if cfg.Review.Blind {
+		b.WithBlind(cfg.AgentRoles())
 	}
-
-	resp, err := runner.Run(context.Background(), agent.Request{
-		TaskID:     task.ID,
-		Prompt:     prompt,
-		WorkDir:    workDir,
-		TimeoutSec: archCfg.DefaultTimeout(),
-	})
-	if err != nil {
-		return "failed"
+	if cfg.History.InlineArtifacts && s != nil {
+		b.WithInlineOutputs(newArtifacts(s), cfg.History.InlineBudget())
 	}
+	b.WithLanguageHints(langs.Resolve(".", cfg.Languages, cfg.LanguageHints))
+	return b
+}
 
-	// Save artifact.
-	artifactPath := hivePath("runs", fmt.Sprintf("task-%d-architect.md", task.ID))
-	os.MkdirAll(hivePath("runs"), 0755)
-	os.WriteFile(artifactPath, []byte(resp.Output), 0644)
-	s.AddArtifact(task.ID, "architect", artifactPath)
-
-	// Check for blocker.
-	if b := agent.ParseBlocked(resp.Output); b != "" {
-		s.BlockTask(task.ID, b)
-		return "blocked"
+// runRoleStage runs the custom roles configured for stage on a task,
+// printing progress under the current task line.
+func runRoleStage(s *store.Store, cfg *config.Config, stage roles.Stage, task *store.Task, workDir string) worker.StageResult {
+	reg := roles.NewRegistry(cfg.Roles)
+	if len(reg.ByStage(stage)) == 0 {
+		return worker.StageResult{Status: worker.StagePassed}
 	}
+	fmt.Println()
+	return worker.RunRoleStage(s, newArtifacts(s), cfg, reg, stage, task, workDir, stageLogf)
+}
 
-	// Save the full spec as an event so the coder gets it via context builder.
-	// Truncate if very long, but keep much more than normal events.
-	spec := resp.Output
-	if len(spec) > 4000 {
-		spec = spec[:4000] + "\n\n... (spec truncated, see full output in artifacts)"
-	}
-	s.AddEvent(task.ID, archName, "architect_spec", spec)
+// stageLogf prints a progress line for a pipeline stage under the current
+// task line.
+func stageLogf(format string, args ...any) {
+	fmt.Printf("    %s•%s %s\n", colorDim, colorReset, fmt.Sprintf(format, args...))
+}
 
-	return "done"
+// autoArchitect runs the architect agent on a task to produce a technical spec.
+// The spec is saved as an event so the coder can read it via context builder.
+// Returns "done", "blocked", or "failed".
+func autoArchitect(s *store.Store, cfg *config.Config, task *store.Task, archName string, archCfg config.Agent, workDir string) string {
+	result, resp := worker.RunArchitect(s, newArtifacts(s), newContextBuilder(s, cfg), task, archName, archCfg, workDir, newLiveOutput(task.ID, archName, liveIndent).stream())
+	warnTruncated(s, task.ID, archName, "The spec", resp)
+	return result
 }
 
 func truncateAuto(s string, max int) string {
@@ -799,3 +1505,23 @@ func forceAutoAccept(cfg *config.Agent) {
 func printPhase(num, label, desc string) {
 	fmt.Printf("%s═══ %s: %s%s — %s\n\n", colorBold, num, label, colorReset, desc)
 }
+
+// followColors tell interleaved workers apart in --follow output.
+var followColors = []string{colorCyan, colorMagenta, colorBlue, colorGreen, colorYellow}
+
+// followLog returns the pool's OnLog for --follow: every line prefixed
+// with its task, like docker-compose logs. Returns nil without --follow.
+func followLog() func(int64, string) {
+	if !autoFollow {
+		return nil
+	}
+	colors := map[int64]string{}
+	return func(taskID int64, line string) {
+		c, ok := colors[taskID]
+		if !ok {
+			c = followColors[len(colors)%len(followColors)]
+			colors[taskID] = c
+		}
+		fmt.Printf("  %s#%-4d |%s %s\n", c, taskID, colorReset, line)
+	}
+}
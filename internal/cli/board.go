@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 	"github.com/spf13/cobra"
 )
 
@@ -47,6 +48,7 @@ func runBoard(cmd *cobra.Command, args []string) error {
 			colorDim, colorReset, colorCyan, colorReset)
 		return nil
 	}
+	warnStaleTasks(s)
 
 	// Group tasks by status.
 	columns := map[store.TaskStatus][]store.Task{
@@ -56,6 +58,7 @@ func runBoard(cmd *cobra.Command, args []string) error {
 		store.StatusReview:     {},
 		store.StatusDone:       {},
 		store.StatusFailed:     {},
+		store.StatusNeedsHuman: {},
 	}
 
 	for _, t := range tasks {
@@ -171,6 +174,29 @@ func runBoard(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	// Show tasks the fix loop gave up on, with what the reviewer objected to.
+	needsHuman := columns[store.StatusNeedsHuman]
+	if len(needsHuman) > 0 {
+		fmt.Printf("%s%s✋ Needs a human (the fix loop gave up)%s\n", colorBold, colorYellow, colorReset)
+		for _, t := range needsHuman {
+			events, _ := s.GetEvents(t.ID)
+			reason, objections := worker.NeedsHumanReason(t.ID, events)
+			fmt.Printf("  %s#%d%s: %s\n", colorYellow, t.ID, colorReset, t.Title)
+			if reason != "" {
+				fmt.Printf("       %s%s%s\n", colorDim, reason, colorReset)
+			}
+			for i, o := range objections {
+				if i == 3 {
+					fmt.Printf("       %s... and %d more (hive log %d)%s\n", colorDim, len(objections)-3, t.ID, colorReset)
+					break
+				}
+				fmt.Printf("       - %s\n", truncate(o, 100))
+			}
+			fmt.Printf("       → %shive answer %d \"your guidance\"%s\n", colorCyan, t.ID, colorReset)
+		}
+		fmt.Println()
+	}
+
 	// Show failed tasks.
 	failed := columns[store.StatusFailed]
 	if len(failed) > 0 {
@@ -201,6 +227,9 @@ func runBoard(cmd *cobra.Command, args []string) error {
 	if blockedCount > 0 {
 		fmt.Printf("  %s⚠ %d blocked%s", colorRed, blockedCount, colorReset)
 	}
+	if len(needsHuman) > 0 {
+		fmt.Printf("  %s✋ %d need a human%s", colorYellow, len(needsHuman), colorReset)
+	}
 	fmt.Println()
 
 	return nil
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/spf13/cobra"
+)
+
+var breakdownCmd = &cobra.Command{
+	Use:   "breakdown [description]",
+	Short: "Break a description into tasks without creating anything",
+	Long: `Runs the PM-role agent on a free-form description and prints the
+subtasks it proposes. Nothing is added to the board unless --create is
+given, which creates an epic with the proposed tasks under it.
+
+Examples:
+  hive breakdown "Add OAuth login with GitHub and Google"
+  hive breakdown --file spec.md
+  hive breakdown --file spec.md --create --title "OAuth login"`,
+	RunE: runBreakdown,
+}
+
+var (
+	breakdownFile   string
+	breakdownCreate bool
+	breakdownTitle  string
+	breakdownAgent  string
+)
+
+func init() {
+	breakdownCmd.Flags().StringVarP(&breakdownFile, "file", "f", "", "Read the description from a file")
+	breakdownCmd.Flags().BoolVar(&breakdownCreate, "create", false, "Create an epic with the proposed tasks")
+	breakdownCmd.Flags().StringVar(&breakdownTitle, "title", "", "Epic title for --create (default: first line of the description)")
+	breakdownCmd.Flags().StringVarP(&breakdownAgent, "agent", "a", "", "Override PM agent name")
+	rootCmd.AddCommand(breakdownCmd)
+}
+
+func runBreakdown(cmd *cobra.Command, args []string) error {
+	description := strings.Join(args, " ")
+	if breakdownFile != "" {
+		if description != "" {
+			return fmt.Errorf("give a description or --file, not both")
+		}
+		data, err := os.ReadFile(breakdownFile)
+		if err != nil {
+			return fmt.Errorf("read description: %w", err)
+		}
+		description = string(data)
+	}
+	if strings.TrimSpace(description) == "" {
+		return fmt.Errorf("nothing to break down: give a description or --file")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	agentName, agentCfg := breakdownAgent, config.Agent{}
+	if agentName == "" {
+		agentName, agentCfg = findAgentByRole(cfg, roles.PM)
+	} else {
+		var ok bool
+		if agentCfg, ok = cfg.Agents[agentName]; !ok {
+			return fmt.Errorf("agent %q not found in config", agentName)
+		}
+	}
+	if agentName == "" {
+		return fmt.Errorf("no PM agent configured. Add an agent with role: pm in .hive/config.yaml")
+	}
+	forceAutoAccept(&agentCfg)
+
+	runner, err := agent.NewRunner(agentName, agentCfg)
+	if err != nil {
+		return fmt.Errorf("create agent: %w", err)
+	}
+
+	workDir, _ := os.Getwd()
+	fmt.Printf("Breaking down: %s\n", breakdownEpicTitle(description))
+	fmt.Printf("  PM Agent: %s\n\n", agentName)
+
+	resp, err := runner.Run(context.Background(), agent.Request{
+		Prompt:     newContextBuilder(nil, cfg).BuildBreakdownPrompt(description),
+		WorkDir:    workDir,
+		TimeoutSec: agentCfg.DefaultTimeout(),
+		Format:     agent.FormatPlan,
+	})
+	if err != nil {
+		return fmt.Errorf("PM agent failed: %w", err)
+	}
+
+	if blocked := agent.ParseBlocked(resp.Output); blocked != "" {
+		fmt.Printf("%s⚠  PM needs more detail:%s %s\n", colorRed+colorBold, colorReset, blocked)
+		fmt.Println("   Add it to the description and run again.")
+		return nil
+	}
+
+	subtasks := agent.ParseSubtasks(resp.Output)
+	if len(subtasks) == 0 {
+		fmt.Println("PM agent didn't return structured subtasks.")
+		fmt.Println("Raw output:")
+		fmt.Println(resp.Output)
+		return nil
+	}
+
+	counts := map[string]int{}
+	fmt.Printf("%sProposed %d tasks:%s\n\n", colorBold, len(subtasks), colorReset)
+	for i, sub := range subtasks {
+		counts[sub.Priority]++
+		fmt.Printf("  %s%d.%s %s%s%s", colorYellow, i+1, colorReset, priorityColor(sub.Priority), sub.Title, colorReset)
+		if sub.Description != "" {
+			fmt.Printf(" %s— %s%s", colorDim, sub.Description, colorReset)
+		}
+		fmt.Printf(" [%s]\n", sub.Priority)
+		for j, c := range sub.Acceptance {
+			fmt.Printf("      %s%s: %s%s\n", colorDim, agent.CriterionLabel(j), c, colorReset)
+		}
+	}
+	fmt.Printf("\n  %d high, %d medium, %d low\n", counts["high"], counts["medium"], counts["low"])
+
+	if !breakdownCreate {
+		fmt.Printf("\nNothing was created. Re-run with %s--create%s to add them as an epic.\n", colorCyan, colorReset)
+		return nil
+	}
+
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	title := breakdownTitle
+	if title == "" {
+		title = breakdownEpicTitle(description)
+	}
+	epic, err := s.CreateEpic(title, strings.TrimSpace(description), "medium")
+	if err != nil {
+		return fmt.Errorf("create epic: %w", err)
+	}
+	for _, sub := range subtasks {
+		if _, err := createSubtask(s, epic.ID, sub); err != nil {
+			fmt.Printf("  %s✗%s Failed to create: %s (%v)\n", colorRed, colorReset, sub.Title, err)
+		}
+	}
+	s.AddEvent(epic.ID, agentName, "planned", fmt.Sprintf("Created %d tasks via breakdown", len(subtasks)))
+
+	fmt.Printf("\nCreated epic %s#%d%s: %s with %d tasks\n", colorYellow, epic.ID, colorReset, epic.Title, len(subtasks))
+	fmt.Printf("Next: %shive auto %d%s to run the pipeline\n", colorCyan, epic.ID, colorReset)
+	return nil
+}
+
+// breakdownEpicTitle derives an epic title from the first non-empty line
+// of a description, without markdown heading marks.
+func breakdownEpicTitle(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line == "" {
+			continue
+		}
+		if r := []rune(line); len(r) > 80 {
+			line = string(r[:77]) + "..."
+		}
+		return line
+	}
+	return "Breakdown"
+}
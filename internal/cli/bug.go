@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/redact"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bugTrace   string
+	bugRepro   string
+	bugAnalyze bool
+	bugAgent   string
+)
+
+// maxTraceLines caps the stack trace kept in the epic; the top of a trace
+// is where the failure is.
+const maxTraceLines = 200
+
+var bugCmd = &cobra.Command{
+	Use:   "bug [title]",
+	Short: "Create a high-priority epic from a bug report and its stack trace",
+	Long: `Creates a high-priority epic whose description holds the stack trace,
+the environment (OS, branch, commit) and a repro section, plus a safety
+branch like 'hive epic create'.
+
+With --analyze, the analyst agent reads the trace and the code and lists
+the source files most likely at fault. The list goes into the epic, so
+the PM plans against the right code.
+
+Secret values from the environment are masked in the trace, as they are
+in prompts and events.
+
+Examples:
+  hive bug "Crash on logout" --trace panic.txt
+  go test ./... 2>&1 | hive bug "Nil map in session store" --trace - --analyze
+  hive bug "Upload hangs" --trace trace.txt --repro "Upload a 2 GB file"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBug,
+}
+
+func init() {
+	bugCmd.Flags().StringVar(&bugTrace, "trace", "", "File with the stack trace or error output (- for stdin)")
+	bugCmd.Flags().StringVar(&bugRepro, "repro", "", "Steps to reproduce")
+	bugCmd.Flags().BoolVar(&bugAnalyze, "analyze", false, "Run the analyst agent to localize the likely source files")
+	bugCmd.Flags().StringVarP(&bugAgent, "agent", "a", "", "Override analyst agent name")
+	bugCmd.MarkFlagRequired("trace")
+	rootCmd.AddCommand(bugCmd)
+}
+
+func runBug(cmd *cobra.Command, args []string) error {
+	// Loading the config first sets up redaction for the trace.
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	trace, err := readTrace(bugTrace)
+	if err != nil {
+		return err
+	}
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+
+	bug := &store.Task{
+		Title:       strings.Join(args, " "),
+		Priority:    "high",
+		Description: bugDescription(trace, bugEnvironment(safety), bugRepro),
+	}
+
+	var analyst string
+	var likely []string
+	if bugAnalyze {
+		analyst, likely, err = localizeBug(cfg, bug, workDir)
+		if err != nil {
+			fmt.Printf("%s⚠  Analysis failed: %v — creating the epic without it%s\n\n", colorYellow, err, colorReset)
+		}
+		if len(likely) > 0 {
+			bug.Description = strings.TrimRight(bug.Description, "\n") + "\n\n## Likely source files\n- " + strings.Join(likely, "\n- ") + "\n"
+		}
+	}
+
+	epic, err := s.CreateEpic(bug.Title, bug.Description, bug.Priority)
+	if err != nil {
+		return err
+	}
+	if analyst != "" {
+		s.AddEvent(epic.ID, analyst, "localized", fmt.Sprintf("%d likely source file(s)", len(likely)))
+	}
+
+	fmt.Printf("Created bug epic %s#%d%s: %s [%s]\n", colorYellow, epic.ID, colorReset, epic.Title, epic.Priority)
+	for _, f := range likely {
+		fmt.Printf("  %s→%s %s\n", colorCyan, colorReset, f)
+	}
+	if safety.IsGitRepo() {
+		if err := createSafetyBranch(s, safety, epic, false); err != nil {
+			return err
+		}
+	}
+	if bugRepro == "" {
+		fmt.Printf("\n%sTip:%s add repro steps to the description before planning.\n", colorDim, colorReset)
+	}
+	fmt.Printf("\nNext: %shive plan %d%s to break it into tasks\n", colorCyan, epic.ID, colorReset)
+	return nil
+}
+
+// readTrace reads the trace for --trace; "-" is stdin. Long traces keep
+// their top.
+func readTrace(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read trace: %w", err)
+	}
+	trace := strings.TrimSpace(string(data))
+	if trace == "" {
+		return "", fmt.Errorf("the trace is empty")
+	}
+	if lines := strings.Split(trace, "\n"); len(lines) > maxTraceLines {
+		trace = strings.Join(lines[:maxTraceLines], "\n") +
+			fmt.Sprintf("\n... (%d more lines)", len(lines)-maxTraceLines)
+	}
+	return redact.String(trace), nil
+}
+
+// bugEnvironment describes where the bug was seen: the platform and, in a
+// git repo, the branch and commit.
+func bugEnvironment(safety *git.Safety) []string {
+	env := []string{"OS: " + runtime.GOOS + "/" + runtime.GOARCH}
+	if !safety.IsGitRepo() {
+		return env
+	}
+	if branch, err := safety.CurrentBranch(); err == nil {
+		env = append(env, "Branch: "+branch)
+	}
+	if commit, err := safety.RevParse("HEAD"); err == nil {
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if safety.HasUncommittedChanges() {
+			commit += " (with uncommitted changes)"
+		}
+		env = append(env, "Commit: "+commit)
+	}
+	return env
+}
+
+// bugDescription lays out the epic: trace, environment and repro. Without
+// repro steps the section is left as a prompt to fill in.
+func bugDescription(trace string, env []string, repro string) string {
+	// A trace containing ``` would end a three-backtick fence early.
+	fence := "```"
+	for strings.Contains(trace, fence) {
+		fence += "`"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Stack trace\n" + fence + "\n" + trace + "\n" + fence + "\n\n")
+	sb.WriteString("## Environment\n")
+	for _, e := range env {
+		sb.WriteString("- " + e + "\n")
+	}
+	sb.WriteString("\n## Repro\n")
+	if repro = strings.TrimSpace(repro); repro != "" {
+		sb.WriteString(repro + "\n")
+	} else {
+		sb.WriteString("1. (steps to reproduce)\n\nExpected: (what should happen)\nActual: the failure above\n")
+	}
+	return sb.String()
+}
+
+// localizeBug runs the analyst on the bug and returns its name and the
+// likely source files it lists.
+func localizeBug(cfg *config.Config, bug *store.Task, workDir string) (string, []string, error) {
+	agentName, agentCfg := bugAgent, config.Agent{}
+	if agentName == "" {
+		agentName, agentCfg = findAgentByRole(cfg, roles.Analyst)
+	} else {
+		var ok bool
+		if agentCfg, ok = cfg.Agents[agentName]; !ok {
+			return "", nil, fmt.Errorf("agent %q not found in config", agentName)
+		}
+	}
+	if agentName == "" {
+		return "", nil, fmt.Errorf("no analyst agent configured. Add an agent with role: analyst in .hive/config.yaml")
+	}
+	forceAutoAccept(&agentCfg)
+
+	runner, err := agent.NewRunner(agentName, agentCfg)
+	if err != nil {
+		return "", nil, fmt.Errorf("create agent: %w", err)
+	}
+
+	fmt.Printf("Localizing: %s\n", bug.Title)
+	fmt.Printf("  Analyst: %s\n\n", agentName)
+
+	resp, err := runner.Run(context.Background(), agent.Request{
+		Prompt:     newContextBuilder(nil, cfg).BuildLocalizePrompt(bug),
+		WorkDir:    workDir,
+		TimeoutSec: agentCfg.DefaultTimeout(),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("analyst agent failed: %w", err)
+	}
+	if resp.Error != nil {
+		return "", nil, fmt.Errorf("analyst agent failed: %w", resp.Error)
+	}
+	likely := agent.ParseLikelyFiles(resp.Output)
+	if len(likely) == 0 {
+		return agentName, nil, fmt.Errorf("the analyst listed no LIKELY_FILES")
+	}
+	return agentName, likely, nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imkarma/hive/internal/agent"
+)
+
+// cassettesDir is where --record writes cassettes, under .hive.
+const cassettesDir = "cassettes"
+
+var (
+	recordFlag bool   // --record
+	replayFlag string // --replay
+)
+
+// startCassette turns on recording or replay of agent calls, as the
+// --record and --replay flags ask.
+func startCassette(cmd *cobra.Command, args []string) error {
+	switch {
+	case recordFlag && replayFlag != "":
+		return fmt.Errorf("--record and --replay can't be combined")
+	case recordFlag:
+		path := hivePath(cassettesDir, time.Now().Format("20060102-150405")+".jsonl")
+		agent.SetRecorder(agent.NewRecorder(path))
+		fmt.Fprintf(os.Stderr, "Recording agent calls to %s\n", path)
+	case replayFlag != "":
+		c, err := agent.LoadCassette(cassettePath(replayFlag))
+		if err != nil {
+			return err
+		}
+		agent.SetReplay(c)
+		fmt.Fprintf(os.Stderr, "Replaying agent calls from %s\n", cassettePath(replayFlag))
+	}
+	return nil
+}
+
+// cassettePath resolves --replay: a file path, or the name of a cassette
+// in .hive/cassettes with or without its .jsonl extension.
+func cassettePath(name string) string {
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+	if !strings.ContainsRune(name, filepath.Separator) {
+		if !strings.HasSuffix(name, ".jsonl") {
+			name += ".jsonl"
+		}
+		return hivePath(cassettesDir, name)
+	}
+	return name
+}
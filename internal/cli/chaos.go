@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/imkarma/hive/internal/chaos"
+)
+
+// chaosFlag is the hidden --chaos flag (see package chaos).
+var chaosFlag string
+
+// startChaos turns on failure injection when --chaos or $HIVE_CHAOS asks
+// for it. $HIVE_CHAOS_SEED repeats an earlier run's faults; the seed is
+// printed so a run that turned up a bug can be repeated.
+func startChaos() error {
+	spec := chaosFlag
+	if spec == "" {
+		spec = os.Getenv("HIVE_CHAOS")
+	}
+	if spec == "" {
+		return nil
+	}
+	cfg, err := chaos.Parse(spec)
+	if err != nil {
+		return err
+	}
+	seed := time.Now().UnixNano()
+	if s := os.Getenv("HIVE_CHAOS_SEED"); s != "" {
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return fmt.Errorf("HIVE_CHAOS_SEED: %w", err)
+		}
+	}
+	chaos.Init(cfg, seed)
+	fmt.Fprintf(os.Stderr, "Chaos: injecting %s (HIVE_CHAOS_SEED=%d)\n", cfg, seed)
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and check hive configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config for unknown keys and invalid values",
+	Long: `Checks .hive/config.yaml together with the user config, the active
+profile and config.local.yaml. Every problem is listed with its file and
+line, including misspelled keys that would otherwise be ignored.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a config value, e.g. agents.claude.timeout_sec",
+	Long: `Prints the effective value of a dotted key, after the user config,
+profile and config.local.yaml are merged. Mappings and lists print as YAML.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a config value, e.g. agents.claude.auto_accept true",
+	Long: `Sets a dotted key in .hive/config.yaml, keeping its comments and key
+order. The value is parsed as YAML, so true, 300 and "[a, b]" keep their
+types. The change is refused if it would make the config invalid.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var (
+	configSetLocal bool
+	configSetUser  bool
+)
+
+func init() {
+	configSetCmd.Flags().BoolVar(&configSetLocal, "local", false, "Write to .hive/config.local.yaml instead")
+	configSetCmd.Flags().BoolVar(&configSetUser, "user", false, "Write to the user config (~/.config/hive/config.yaml) instead")
+
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	issues := config.Validate(hivePath("config.yaml"), activeProfile())
+	if len(issues) == 0 {
+		fmt.Printf("%s✓ Config is valid%s\n", colorGreen, colorReset)
+		return nil
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("  %s✗%s %s\n", colorRed, colorReset, issue)
+	}
+	return fmt.Errorf("%d problem(s) in config", len(issues))
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	value, ok, err := config.Get(hivePath("config.yaml"), activeProfile(), args[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s is not set", args[0])
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	file := hivePath("config.yaml")
+	switch {
+	case configSetLocal && configSetUser:
+		return fmt.Errorf("--local and --user are mutually exclusive")
+	case configSetLocal:
+		file = hivePath(config.LocalFile)
+	case configSetUser:
+		file = config.UserConfigPath()
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+	}
+
+	if err := config.Set(hivePath("config.yaml"), activeProfile(), file, args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("%s✓%s %s = %s %s(%s)%s\n", colorGreen, colorReset, args[0], args[1], colorDim, file, colorReset)
+	return nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
+)
+
+// autoDocs runs the docs agent once for a finished epic. It edits the
+// configured doc paths and the result is committed on the epic branch, so
+// it shows up in `hive epic diff` and is accepted or rejected with the code.
+// Docs are best-effort: a blocker or failure is reported but never fails
+// the epic. Returns "done", "blocked", or "failed".
+func autoDocs(s *store.Store, cfg *config.Config, epic *store.Task, subtasks []store.Task, docsName string, docsCfg config.Agent, workDir string) string {
+	paths := cfg.Docs.TargetPaths()
+	safety := git.New(workDir)
+
+	var diff string
+	if epic.GitBranch != "" && safety.IsGitRepo() {
+		if base, err := safety.BaseBranchFor(epic.BaseBranch); err == nil {
+			diff, _ = safety.Diff(base, epic.GitBranch)
+		}
+	}
+
+	prompt, err := newContextBuilder(s, cfg).BuildDocsPrompt(epic, subtasks, diff, paths)
+	if err != nil {
+		return "failed"
+	}
+
+	runner, err := worker.NewRunner(docsName, docsCfg)
+	if err != nil {
+		fmt.Printf("  %s✗ Failed to create docs agent: %v%s\n", colorRed, err, colorReset)
+		return "failed"
+	}
+
+	// Changes from before the agent ran aren't its to commit or discard.
+	var before []string
+	if safety.IsGitRepo() {
+		before, _ = safety.ChangedFiles()
+	}
+
+	fmt.Printf("  %s%s%s updating %s... ", colorBlue, docsName, colorReset, strings.Join(paths, ", "))
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID: epic.ID, Prompt: prompt, WorkDir: workDir, TimeoutSec: docsCfg.DefaultTimeout(),
+	})
+	if err != nil {
+		fmt.Printf("%s✗ error%s\n", colorRed, colorReset)
+		return "failed"
+	}
+
+	newArtifacts(s).Save(epic.ID, roles.Docs, artifacts.Name(epic.ID, "docs"), resp.Output)
+
+	if b := agent.ParseBlocked(resp.Output); b != "" {
+		s.AddEvent(epic.ID, docsName, "comment", "Docs blocked: "+b)
+		fmt.Printf("%s⚠ BLOCKED%s\n", colorYellow, colorReset)
+		fmt.Printf("    %s\n", b)
+		return "blocked"
+	}
+
+	if resp.ExitCode != 0 {
+		fmt.Printf("%s✗ exit %d%s\n", colorRed, resp.ExitCode, colorReset)
+		return "failed"
+	}
+
+	fmt.Printf("%.1fs %s✓%s\n", resp.Duration, colorGreen, colorReset)
+
+	s.AddEvent(epic.ID, docsName, "agent_output", cfg.History.Preview(resp.Output))
+
+	if !safety.IsGitRepo() {
+		return "done"
+	}
+
+	// The docs agent may only touch doc targets. Only those are committed;
+	// anything else it changed is thrown away, so it can't slip into the
+	// epic unreviewed.
+	changed, _ := safety.ChangedFiles()
+	var docs, outside []string
+	for _, f := range changed {
+		switch {
+		case slices.Contains(before, f): // Not the agent's
+		case docPathAllowed(f, paths):
+			docs = append(docs, f)
+		default:
+			outside = append(outside, f)
+		}
+	}
+	if len(outside) > 0 {
+		if err := safety.DiscardPaths(outside); err != nil {
+			fmt.Printf("    %s⚠ %v%s\n", colorYellow, err, colorReset)
+		} else {
+			fmt.Printf("    %s⚠ discarded changes outside doc paths: %s%s\n", colorYellow, strings.Join(outside, ", "), colorReset)
+			s.AddEvent(epic.ID, docsName, "comment", "Discarded docs changes outside doc paths: "+strings.Join(outside, ", "))
+		}
+	}
+
+	if epic.GitBranch != "" {
+		committed, err := safety.CommitPaths(fmt.Sprintf("hive: docs for epic #%d — %s", epic.ID, epic.Title), docs)
+		if err != nil {
+			fmt.Printf("    %s⚠ commit: %v%s\n", colorYellow, err, colorReset)
+		} else if committed {
+			fmt.Printf("    %scommitted docs%s\n", colorDim, colorReset)
+		} else {
+			fmt.Printf("    %sno doc changes%s\n", colorDim, colorReset)
+		}
+	}
+
+	return "done"
+}
+
+// docPathAllowed reports whether file is one of the doc targets or lives
+// under a target directory.
+func docPathAllowed(file string, paths []string) bool {
+	file = filepath.ToSlash(filepath.Clean(file))
+	for _, p := range paths {
+		p = filepath.ToSlash(filepath.Clean(p))
+		if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
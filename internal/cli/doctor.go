@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find and fix stuck board state",
+	Long: `Looks for board state left behind by crashes:
+
+  - tasks stuck in in_progress or review that no pipeline run or live
+    hive run / hive fix / hive review is working on
+  - interrupted pipeline runs (see hive resume)
+
+It also shows the rate limits API providers last reported, which hive
+uses to pace agent calls, and warns when the disk is too full for a run
+(see disk: in the config).
+
+--fix-stale offers to reset the stuck tasks to backlog; --yes skips the
+question.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+var (
+	doctorFixStale bool
+	doctorYes      bool
+)
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFixStale, "fix-stale", false, "Reset stuck in_progress/review tasks to backlog")
+	doctorCmd.Flags().BoolVarP(&doctorYes, "yes", "y", false, "Don't ask before resetting")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	stale, err := s.ListStaleTasks()
+	if err != nil {
+		return err
+	}
+	runs, err := s.ListInterruptedRuns()
+	if err != nil {
+		return err
+	}
+	if limits, err := s.ListRateLimits(); err == nil && len(limits) > 0 {
+		printRateLimits(limits)
+	}
+	if _, err := loadConfig(); err == nil {
+		if err := disk.Check(0); err != nil {
+			fmt.Printf("%s⚠  %v%s\n\n", colorYellow, err, colorReset)
+		}
+	}
+
+	if len(stale) == 0 && len(runs) == 0 {
+		fmt.Printf("%s✓ Nothing stuck.%s\n", colorGreen, colorReset)
+		return nil
+	}
+
+	if len(runs) > 0 {
+		fmt.Printf("%s⚠  %d pipeline run(s) were interrupted.%s Resume with %shive resume%s\n\n",
+			colorYellow, len(runs), colorReset, colorCyan, colorReset)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	fmt.Printf("%s⚠  %d task(s) stuck with nothing working on them:%s\n", colorYellow, len(stale), colorReset)
+	for _, t := range stale {
+		fmt.Printf("  %s#%-4d%s %s%-12s%s %s %s(since %s)%s\n",
+			colorYellow, t.ID, colorReset,
+			statusToColor(t.Status), t.Status, colorReset,
+			t.Title, colorDim, formatSpan(time.Since(t.UpdatedAt)), colorReset)
+	}
+
+	if !doctorFixStale {
+		fmt.Printf("\nReset them to backlog: %shive doctor --fix-stale%s\n", colorCyan, colorReset)
+		return nil
+	}
+	if !doctorYes && !confirm(fmt.Sprintf("\nReset %d task(s) to backlog?", len(stale))) {
+		fmt.Println("Nothing changed.")
+		return nil
+	}
+	for _, t := range stale {
+		s.UpdateTaskStatus(t.ID, store.StatusBacklog)
+		s.ClearHeartbeat(t.ID)
+		s.AddEvent(t.ID, "doctor", "reset_stale", fmt.Sprintf("Reset from %s: nothing was working on it", t.Status))
+	}
+	fmt.Printf("%s✓ Reset %d task(s) to backlog%s\n", colorGreen, len(stale), colorReset)
+	return nil
+}
+
+// printRateLimits shows each API key's last reported limits and whether
+// calls are being held back right now.
+func printRateLimits(limits []store.RateLimit) {
+	now := time.Now()
+	fmt.Printf("%sAPI rate limits%s\n", colorBold, colorReset)
+	for _, rl := range limits {
+		state := colorGreen + "ok" + colorReset
+		switch {
+		case now.Before(rl.RetryAfter):
+			state = fmt.Sprintf("%sthrottled (429), retry in %s%s", colorRed, formatSpan(rl.RetryAfter.Sub(now)), colorReset)
+		case rl.RequestsRemaining == 0 && now.Before(rl.RequestsReset):
+			state = fmt.Sprintf("%sout of requests, resets in %s%s", colorYellow, formatSpan(rl.RequestsReset.Sub(now)), colorReset)
+		case rl.TokensRemaining == 0 && now.Before(rl.TokensReset):
+			state = fmt.Sprintf("%sout of tokens, resets in %s%s", colorYellow, formatSpan(rl.TokensReset.Sub(now)), colorReset)
+		}
+		fmt.Printf("  %-28s %s\n", rl.Key, state)
+		if rl.RequestsLimit >= 0 {
+			fmt.Printf("    requests: %s%s\n", formatBudget(rl.RequestsRemaining, rl.RequestsLimit), formatReset(rl.RequestsReset, now))
+		}
+		if rl.TokensLimit >= 0 {
+			fmt.Printf("    tokens:   %s%s\n", formatBudget(rl.TokensRemaining, rl.TokensLimit), formatReset(rl.TokensReset, now))
+		}
+		fmt.Printf("    %sreported %s ago%s\n", colorDim, formatSpan(now.Sub(rl.UpdatedAt)), colorReset)
+	}
+	fmt.Println()
+}
+
+func formatBudget(remaining, limit int) string {
+	if remaining < 0 {
+		return fmt.Sprintf("? of %d left", limit)
+	}
+	return fmt.Sprintf("%d of %d left", remaining, limit)
+}
+
+func formatReset(reset, now time.Time) string {
+	if !now.Before(reset) {
+		return ""
+	}
+	return fmt.Sprintf(", resets in %s", formatSpan(reset.Sub(now)))
+}
+
+// confirm asks a yes/no question on stdin. Anything but y/yes is no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// warnStaleTasks prints a one-line hint when tasks are stuck in progress,
+// for commands that show the board.
+func warnStaleTasks(s *store.Store) {
+	if stale, err := s.ListStaleTasks(); err == nil && len(stale) > 0 {
+		fmt.Printf("%s⚠  %d task(s) stuck in progress with nothing running. Check: hive doctor%s\n\n", colorYellow, len(stale), colorReset)
+	}
+}
+
+// startHeartbeat marks a task as being worked on by this process until
+// the returned function is called, so hive doctor doesn't take it for
+// stuck.
+func startHeartbeat(s *store.Store, taskID int64) (stop func()) {
+	s.Beat(taskID, os.Getpid())
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(store.HeartbeatTTL / 4)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				s.Beat(taskID, os.Getpid())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		s.ClearHeartbeat(taskID)
+	}
+}
@@ -5,15 +5,28 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/cilog"
+	"github.com/imkarma/hive/internal/config"
 	"github.com/imkarma/hive/internal/git"
 	"github.com/imkarma/hive/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var (
-	epicPriority    string
-	epicDescription string
+	epicPriority         string
+	epicDescription      string
+	epicUseCurrentBranch bool
+	epicBase             string
+	epicStash            bool
+	epicAcceptBase       string
+	epicAcceptForce      bool
+	epicAcceptDryRun     bool
+	epicFromCILog        string
+	epicSetup            string
+	epicTemplate         string
 )
 
 var epicCmd = &cobra.Command{
@@ -30,12 +43,47 @@ var epicCreateCmd = &cobra.Command{
 	Long: `Creates a new epic on the board and (if in a git repo) creates
 a safety branch for all work related to this epic.
 
+With --use-current-branch, the branch you are on becomes the safety
+branch instead of a new hive/epic-N. Rejecting the epic later resets that
+branch to where it was, rather than deleting it.
+
+With --from-ci-log, the failing tests and compile errors in a CI log are
+grouped by package or file: the epic's description summarizes them and a
+task is created for each group, so 'hive auto' can start right away. The
+title defaults to "Fix the CI build".
+
+With --template, the tasks come from .hive/templates/<name>.yaml instead
+of the PM: recurring work such as a release checklist gets the same tasks
+every time. The template may set the title, description and priority.
+
 Example:
-  hive epic create "Add JWT authentication" -p high -d "With refresh tokens"`,
-	Args: cobra.MinimumNArgs(1),
+  hive epic create "Add JWT authentication" -p high -d "With refresh tokens"
+  hive epic create "Finish login flow" --use-current-branch
+  gh run view --log-failed | hive epic create --from-ci-log -
+  hive epic create "Release 2.4" --template release`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if epicFromCILog == "" && epicTemplate == "" {
+			return cobra.MinimumNArgs(1)(cmd, args)
+		}
+		return nil
+	},
 	RunE: runEpicCreate,
 }
 
+var epicAttachCmd = &cobra.Command{
+	Use:   "attach [id] [branch]",
+	Short: "Use an existing branch as an epic's safety branch",
+	Long: `Adopts an existing branch (e.g. a feature branch you started) as the
+epic's safety branch and switches to it. Agent work is committed on top of
+it. Accepting merges it as usual but keeps the branch; rejecting resets it
+to the commit it pointed at when attached.
+
+Example:
+  hive epic attach 3 feature/login`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEpicAttach,
+}
+
 var epicListCmd = &cobra.Command{
 	Use:   "list [status]",
 	Short: "List all epics",
@@ -80,13 +128,24 @@ var epicDiffCmd = &cobra.Command{
 func init() {
 	epicCreateCmd.Flags().StringVarP(&epicPriority, "priority", "p", "medium", "Priority: high, medium, low")
 	epicCreateCmd.Flags().StringVarP(&epicDescription, "desc", "d", "", "Epic description / acceptance criteria")
-
+	epicCreateCmd.Flags().StringVar(&epicBase, "base", "", "Integration branch to branch from, diff against and merge into (default: main/master)")
+	epicAcceptCmd.Flags().BoolVar(&epicAcceptDryRun, "dry-run", false, "Run the pre-flight checks and conflict preview without merging")
+	epicAcceptCmd.Flags().BoolVar(&epicAcceptForce, "force", false, "Merge even if pre-flight checks fail")
+	epicAcceptCmd.Flags().StringVar(&epicAcceptBase, "base", "", "Merge into this branch instead of the epic's base (saved on the epic)")
+	epicCreateCmd.Flags().BoolVar(&epicStash, "stash", false, "Stash uncommitted changes instead of carrying them onto the safety branch")
+	epicCreateCmd.Flags().BoolVar(&epicUseCurrentBranch, "use-current-branch", false, "Use the current branch as the safety branch instead of creating hive/epic-N")
+	epicCreateCmd.Flags().StringVar(&epicSetup, "setup", "", "Command that prepares each new worktree, e.g. \"npm ci\" (default: setup_cmd from the config)")
+	epicCreateCmd.Flags().StringVar(&epicTemplate, "template", "", "Create the epic's tasks from .hive/templates/<name>.yaml instead of planning")
+	epicCreateCmd.Flags().StringVar(&epicFromCILog, "from-ci-log", "", "Seed the epic and its tasks from the failures in a CI log (- for stdin)")
+
+	epicCreateCmd.MarkFlagsMutuallyExclusive("template", "from-ci-log")
 	epicCmd.AddCommand(epicCreateCmd)
 	epicCmd.AddCommand(epicListCmd)
 	epicCmd.AddCommand(epicShowCmd)
 	epicCmd.AddCommand(epicAcceptCmd)
 	epicCmd.AddCommand(epicRejectCmd)
 	epicCmd.AddCommand(epicDiffCmd)
+	epicCmd.AddCommand(epicAttachCmd)
 
 	rootCmd.AddCommand(epicCmd)
 }
@@ -99,32 +158,264 @@ func runEpicCreate(cmd *cobra.Command, args []string) error {
 	defer s.Close()
 
 	title := strings.Join(args, " ")
+	description := epicDescription
+
+	var ciGroups []cilog.Group
+	if epicFromCILog != "" {
+		if ciGroups, err = readCILog(epicFromCILog); err != nil {
+			return err
+		}
+		if title == "" {
+			title = "Fix the CI build"
+		}
+		if description != "" {
+			description += "\n\n"
+		}
+		description += cilog.Summary(ciGroups)
+	}
+
+	priority := epicPriority
+	var tpl *config.EpicTemplate
+	if epicTemplate != "" {
+		if tpl, err = config.LoadTemplate(hiveDirName, epicTemplate); err != nil {
+			return err
+		}
+		if title == "" {
+			title = tpl.Title
+		}
+		if title == "" {
+			title = epicTemplate
+		}
+		if tpl.Description != "" {
+			if description != "" {
+				description += "\n\n"
+			}
+			description += tpl.Description
+		}
+		if tpl.Priority != "" && !cmd.Flags().Changed("priority") {
+			priority = tpl.Priority
+		}
+	}
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+
+	if epicBase != "" && safety.IsGitRepo() && !safety.BranchExists(epicBase) {
+		return fmt.Errorf("base branch %s does not exist", epicBase)
+	}
 
-	epic, err := s.CreateEpic(title, epicDescription, epicPriority)
+	epic, err := s.CreateEpic(title, description, priority)
 	if err != nil {
 		return err
 	}
+	if epicBase != "" {
+		s.SetBaseBranch(epic.ID, epicBase)
+		epic.BaseBranch = epicBase
+	}
+	if epicSetup != "" {
+		s.SetSetupCmd(epic.ID, epicSetup)
+		epic.SetupCmd = epicSetup
+	}
 
 	fmt.Printf("Created epic %s#%d%s: %s [%s]\n", colorYellow, epic.ID, colorReset, epic.Title, epic.Priority)
+	if len(ciGroups) > 0 {
+		tasks, err := seedCITasks(s, epic, ciGroups)
+		for _, t := range tasks {
+			fmt.Printf("  %s#%d%s %s [%s]\n", colorYellow, t.ID, colorReset, t.Title, t.Priority)
+		}
+		if err != nil {
+			return fmt.Errorf("create tasks: %w", err)
+		}
+	}
+	if tpl != nil {
+		if err := seedTemplateTasks(s, epic, tpl); err != nil {
+			return fmt.Errorf("create tasks: %w", err)
+		}
+	}
 
 	// Create git safety branch if in a git repo.
+	if epicUseCurrentBranch {
+		if !safety.IsGitRepo() {
+			fmt.Printf("\n%s⚠  Not a git repo — --use-current-branch ignored%s\n", colorYellow, colorReset)
+		} else if err := adoptBranch(s, safety, epic, ""); err != nil {
+			fmt.Printf("\n%s⚠  Could not use current branch: %v%s\n", colorYellow, err, colorReset)
+		}
+	} else if safety.IsGitRepo() {
+		if err := createSafetyBranch(s, safety, epic, epicStash); err != nil {
+			return err
+		}
+	}
+
+	if len(ciGroups) > 0 {
+		fmt.Printf("\nNext: %shive auto %d%s to fix them\n", colorCyan, epic.ID, colorReset)
+		return nil
+	}
+	if tpl != nil {
+		fmt.Printf("\nNext: %shive auto %d%s to work through them\n", colorCyan, epic.ID, colorReset)
+		return nil
+	}
+	fmt.Printf("\nNext: %shive plan %d%s to break it into tasks\n", colorCyan, epic.ID, colorReset)
+	return nil
+}
+
+// seedTemplateTasks creates the tasks of an epic template. A task with a
+// role is assigned to the agent with that role, when one is configured.
+func seedTemplateTasks(s *store.Store, epic *store.Task, tpl *config.EpicTemplate) error {
+	cfg, _ := loadConfig()
+
+	created := 0
+	for _, tt := range tpl.Tasks {
+		priority := tt.Priority
+		if priority == "" {
+			priority = "medium"
+		}
+		t, err := createSubtask(s, epic.ID, agent.ParsedSubtask{Title: tt.Title, Description: tt.Description, Priority: priority, Acceptance: tt.Acceptance})
+		if err != nil {
+			return err
+		}
+		created++
+		fmt.Printf("  %s#%d%s %s [%s]\n", colorYellow, t.ID, colorReset, t.Title, t.Priority)
+		if tt.Role == "" {
+			continue
+		}
+		name := ""
+		if cfg != nil {
+			name, _ = findAgentByRole(cfg, tt.Role)
+		}
+		if name == "" {
+			fmt.Printf("    %s⚠ no agent with role %s, left for the coder%s\n", colorYellow, tt.Role, colorReset)
+			continue
+		}
+		s.AssignTask(t.ID, name, tt.Role)
+	}
+	s.AddEvent(epic.ID, "user", "planned", fmt.Sprintf("Created %d tasks from template %s", created, epicTemplate))
+	return nil
+}
+
+// createSafetyBranch creates hive/epic-N for a new epic and records it.
+// Failing to create the branch is only a warning; failing to stash is an
+// error, since the user's changes would otherwise move to the branch.
+func createSafetyBranch(s *store.Store, safety *git.Safety, epic *store.Task, stashChanges bool) error {
+	branch := git.BranchName(epic.ID)
+
+	// With stash, the user's changes stay on their branch: stash, create
+	// the safety branch clean, then go back and restore them.
+	var stash *userStash
+	if stashChanges || autoStashEnabled() {
+		var err error
+		if stash, err = stashUserChanges(s, safety, epic); err != nil {
+			return fmt.Errorf("stash changes: %w", err)
+		}
+	} else {
+		warnDirtyTree(safety, branch)
+	}
+
+	var err error
+	if epic.BaseBranch != "" {
+		err = safety.CreateBranchFrom(branch, epic.BaseBranch)
+	} else {
+		err = safety.CreateBranch(branch)
+	}
+	if err != nil {
+		fmt.Printf("\n%s⚠  Could not create safety branch: %v%s\n", colorYellow, err, colorReset)
+	} else {
+		s.SetGitBranch(epic.ID, branch)
+		fmt.Printf("  Branch: %s%s%s (safety net — all agent work happens here)\n", colorCyan, branch, colorReset)
+	}
+	stash.restore(s, safety)
+	return nil
+}
+
+func runEpicAttach(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid epic ID: %s", args[0])
+	}
+
+	epic, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("epic #%d not found", id)
+	}
+	if epic.Kind != store.KindEpic {
+		return fmt.Errorf("#%d is a task, not an epic", id)
+	}
+
 	workDir, _ := os.Getwd()
 	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return fmt.Errorf("not a git repository")
+	}
+	if !safety.BranchExists(args[1]) {
+		return fmt.Errorf("branch %s does not exist", args[1])
+	}
+	if safety.HasUncommittedChanges() {
+		return fmt.Errorf("uncommitted changes — commit or stash them before switching branches")
+	}
 
-	if safety.IsGitRepo() {
-		branch := git.BranchName(epic.ID)
-		if err := safety.CreateBranch(branch); err != nil {
-			fmt.Printf("\n%s⚠  Could not create safety branch: %v%s\n", colorYellow, err, colorReset)
-		} else {
-			s.SetGitBranch(epic.ID, branch)
-			fmt.Printf("  Branch: %s%s%s (safety net — all agent work happens here)\n", colorCyan, branch, colorReset)
+	previous := epic.GitBranch
+	if err := adoptBranch(s, safety, epic, args[1]); err != nil {
+		return err
+	}
+	if previous != "" && previous != args[1] {
+		fmt.Printf("  Previous branch %s%s%s was left as is.\n", colorDim, previous, colorReset)
+	}
+	return nil
+}
+
+// adoptBranch makes an existing branch the epic's safety branch, switching
+// to it first. An empty branch means the current one. The base branch
+// itself can't be adopted — accept would have nothing to merge into.
+func adoptBranch(s *store.Store, safety *git.Safety, epic *store.Task, branch string) error {
+	if branch == "" {
+		current, err := safety.CurrentBranch()
+		if err != nil {
+			return err
 		}
+		branch = current
 	}
 
-	fmt.Printf("\nNext: %shive plan %d%s to break it into tasks\n", colorCyan, epic.ID, colorReset)
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
+	if err == nil && branch == baseBranch {
+		return fmt.Errorf("%s is the base branch — create a feature branch first", branch)
+	}
+
+	if err := safety.Checkout(branch); err != nil {
+		return err
+	}
+	ref, err := safety.RevParse(branch)
+	if err != nil {
+		return err
+	}
+	if err := s.AdoptGitBranch(epic.ID, branch, ref); err != nil {
+		return err
+	}
+	s.AddEvent(epic.ID, "user", "comment", fmt.Sprintf("Adopted branch %s at %s", branch, shortRef(ref)))
+
+	fmt.Printf("  Branch: %s%s%s (adopted at %s — agent work is committed on top)\n", colorCyan, branch, colorReset, shortRef(ref))
 	return nil
 }
 
+func shortRef(ref string) string {
+	if len(ref) > 7 {
+		return ref[:7]
+	}
+	return ref
+}
+
+// warnSubmodules points out modified submodules, which hive leaves out of
+// its commits so they have to be handled by hand.
+func warnSubmodules(safety *git.Safety) {
+	for _, sub := range safety.ModifiedSubmodules() {
+		fmt.Printf("    %s⚠ submodule %s changed — not committed, handle it manually%s\n", colorYellow, sub, colorReset)
+	}
+}
+
 func runEpicList(cmd *cobra.Command, args []string) error {
 	s, err := mustStore()
 	if err != nil {
@@ -210,6 +501,12 @@ func runEpicShow(cmd *cobra.Command, args []string) error {
 	if epic.GitBranch != "" {
 		fmt.Printf("  Branch:   %s%s%s\n", colorCyan, epic.GitBranch, colorReset)
 	}
+	if epic.BaseBranch != "" {
+		fmt.Printf("  Base:     %s\n", epic.BaseBranch)
+	}
+	if epic.SetupCmd != "" {
+		fmt.Printf("  Setup:    %s\n", epic.SetupCmd)
+	}
 	fmt.Printf("  Created:  %s\n", epic.CreatedAt.Format("2006-01-02 15:04"))
 
 	// Show tasks under this epic.
@@ -239,7 +536,7 @@ func runEpicShow(cmd *cobra.Command, args []string) error {
 	if epic.GitBranch != "" {
 		workDir, _ := os.Getwd()
 		safety := git.New(workDir)
-		baseBranch, err := safety.BaseBranch()
+		baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
 		if err == nil {
 			stat, err := safety.DiffStat(baseBranch, epic.GitBranch)
 			if err == nil && stat != "" {
@@ -301,7 +598,15 @@ func runEpicAccept(cmd *cobra.Command, args []string) error {
 	workDir, _ := os.Getwd()
 	safety := git.New(workDir)
 
-	baseBranch, err := safety.BaseBranch()
+	if epicAcceptBase != "" {
+		if !safety.BranchExists(epicAcceptBase) {
+			return fmt.Errorf("base branch %s does not exist", epicAcceptBase)
+		}
+		s.SetBaseBranch(epic.ID, epicAcceptBase)
+		epic.BaseBranch = epicAcceptBase
+	}
+
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
 	if err != nil {
 		return fmt.Errorf("detect base branch: %w", err)
 	}
@@ -336,31 +641,36 @@ func runEpicAccept(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 
-	// Commit any uncommitted work on the epic branch first.
-	if safety.HasUncommittedChanges() {
-		committed, err := safety.CommitAll(fmt.Sprintf("hive: final changes for epic #%d", epic.ID))
-		if err != nil {
-			return fmt.Errorf("commit pending changes: %w", err)
+	// Pre-flight checks. A broken config shouldn't block an accept, so
+	// fall back to the defaults.
+	cfg, err := loadConfig()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	passed := printPreflight(runPreflight(cfg, safety, epic, baseBranch, workDir))
+	if epicAcceptDryRun {
+		if passed {
+			fmt.Printf("%s✓ Ready to merge.%s Re-run without --dry-run to accept.\n", colorGreen+colorBold, colorReset)
+		} else {
+			fmt.Printf("%s✗ Accept would be blocked by the failed checks.%s\n", colorRed+colorBold, colorReset)
 		}
-		if committed {
-			fmt.Printf("  Committed pending changes.\n")
+		return nil
+	}
+	if !passed {
+		if !epicAcceptForce {
+			fmt.Printf("%s✗ Pre-flight checks failed.%s Fix them, or re-run with --force to merge anyway.\n", colorRed+colorBold, colorReset)
+			return nil
 		}
+		fmt.Printf("  %s⚠ Merging despite failed checks (--force)%s\n\n", colorYellow, colorReset)
 	}
 
-	// Merge.
-	if err := safety.MergeBranch(baseBranch, epic.GitBranch); err != nil {
-		return fmt.Errorf("merge failed: %w", err)
+	if err := mergeEpic(s, safety, epic, baseBranch, commits, stat, "user"); err != nil {
+		return err
 	}
 
-	// Clean up branch.
-	safety.DeleteBranch(epic.GitBranch, false)
-
-	// Mark epic as done.
-	s.UpdateTaskStatus(epic.ID, store.StatusDone)
-	s.AddEvent(epic.ID, "user", "accepted", fmt.Sprintf("Merged %s into %s", epic.GitBranch, baseBranch))
-
 	fmt.Printf("  %s✓ Merged into %s%s\n", colorGreen+colorBold, baseBranch, colorReset)
 	fmt.Printf("  %s✓ Epic #%d done%s\n", colorGreen+colorBold, epic.ID, colorReset)
+	fmt.Printf("  %sHistory saved: hive epic history %d%s\n", colorDim, epic.ID, colorReset)
 
 	return nil
 }
@@ -391,13 +701,18 @@ func runEpicReject(cmd *cobra.Command, args []string) error {
 	workDir, _ := os.Getwd()
 	safety := git.New(workDir)
 
-	baseBranch, err := safety.BaseBranch()
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
 	if err != nil {
 		return fmt.Errorf("detect base branch: %w", err)
 	}
 
-	// Show what will be discarded.
-	stat, _ := safety.DiffStat(baseBranch, epic.GitBranch)
+	// Show what will be discarded. On an adopted branch that is only what
+	// was added since it was attached.
+	from := baseBranch
+	if epic.AdoptedRef != "" {
+		from = epic.AdoptedRef
+	}
+	stat, _ := safety.DiffStat(from, epic.GitBranch)
 	if stat != "" {
 		fmt.Printf("%s═══ Reject Epic #%d: %s ═══%s\n\n", colorBold, epic.ID, epic.Title, colorReset)
 		fmt.Printf("  %sDiscarding changes:%s\n", colorRed, colorReset)
@@ -407,12 +722,90 @@ func runEpicReject(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	if err := safety.RejectBranch(baseBranch, epic.GitBranch); err != nil {
-		return fmt.Errorf("reject failed: %w", err)
+	if err := discardEpic(s, safety, epic, baseBranch, "user", ""); err != nil {
+		return err
+	}
+
+	fmt.Printf("  %s✗ Discarded all changes%s\n", colorRed+colorBold, colorReset)
+	if epic.AdoptedRef != "" {
+		fmt.Printf("  %s%s%s reset to %s\n", colorCyan, epic.GitBranch, colorReset, shortRef(epic.AdoptedRef))
+	} else {
+		fmt.Printf("  Back on %s%s%s\n", colorCyan, baseBranch, colorReset)
+	}
+
+	return nil
+}
+
+// mergeEpic merges an epic's safety branch into baseBranch and closes the
+// epic: pending changes on the branch are committed first, a snapshot is
+// saved for hive epic history, and the branch is deleted unless the user
+// owns it. by names who accepted it in the epic's events. Shared by hive
+// epic accept and the API, which run their checks first.
+func mergeEpic(s *store.Store, safety *git.Safety, epic *store.Task, baseBranch, commits, stat, by string) error {
+	// Commit any uncommitted work on the epic branch first.
+	if safety.HasUncommittedChanges() {
+		committed, err := safety.CommitAll(fmt.Sprintf("hive: final changes for epic #%d", epic.ID))
+		if err != nil {
+			return fmt.Errorf("commit pending changes: %w", err)
+		}
+		if committed {
+			fmt.Printf("  Committed pending changes.\n")
+		}
 	}
+	warnSubmodules(safety)
+
+	// Merge.
+	if err := safety.MergeBranch(baseBranch, epic.GitBranch); err != nil {
+		return fmt.Errorf("merge failed: %w", err)
+	}
+
+	// Record the epic while its branch and runs still exist.
+	now := time.Now()
+	if _, err := s.SaveEpicSnapshot(store.EpicSnapshot{
+		EpicID:     epic.ID,
+		Title:      epic.Title,
+		Branch:     epic.GitBranch,
+		BaseBranch: baseBranch,
+		Report:     epicReport(s, epic, baseBranch, commits, stat, now),
+		CreatedAt:  now,
+	}); err != nil {
+		fmt.Printf("  %s⚠ Could not save history: %v%s\n", colorYellow, err, colorReset)
+	}
+
+	// Clean up branch — but never delete a branch the user owns.
+	if epic.AdoptedRef == "" {
+		safety.DeleteBranch(epic.GitBranch, false)
+	}
+
+	// Mark epic as done.
+	s.UpdateTaskStatus(epic.ID, store.StatusDone)
+	s.AddEvent(epic.ID, by, "accepted", fmt.Sprintf("Merged %s into %s", epic.GitBranch, baseBranch))
+	return nil
+}
+
+// discardEpic throws away an epic's work and fails it with its unfinished
+// tasks. An adopted branch is only reset to where it was attached. by
+// names who rejected it in the epic's events, with reason if given.
+func discardEpic(s *store.Store, safety *git.Safety, epic *store.Task, baseBranch, by, reason string) error {
+	var content string
+	if epic.AdoptedRef != "" {
+		// Adopted branch: drop only what hive added since attaching.
+		if err := safety.ResetBranch(epic.GitBranch, epic.AdoptedRef); err != nil {
+			return fmt.Errorf("reject failed: %w", err)
+		}
+		content = fmt.Sprintf("Reset branch %s to %s", epic.GitBranch, shortRef(epic.AdoptedRef))
+	} else {
+		if err := safety.RejectBranch(baseBranch, epic.GitBranch); err != nil {
+			return fmt.Errorf("reject failed: %w", err)
+		}
+		content = fmt.Sprintf("Discarded branch %s", epic.GitBranch)
+	}
+	if reason != "" {
+		content += ": " + reason
+	}
+	s.AddEvent(epic.ID, by, "rejected", content)
 
 	s.UpdateTaskStatus(epic.ID, store.StatusFailed)
-	s.AddEvent(epic.ID, "user", "rejected", fmt.Sprintf("Discarded branch %s", epic.GitBranch))
 
 	// Mark all tasks as failed too.
 	tasks, _ := s.ListTasksByEpic(epic.ID)
@@ -421,10 +814,6 @@ func runEpicReject(cmd *cobra.Command, args []string) error {
 			s.UpdateTaskStatus(t.ID, store.StatusFailed)
 		}
 	}
-
-	fmt.Printf("  %s✗ Discarded all changes%s\n", colorRed+colorBold, colorReset)
-	fmt.Printf("  Back on %s%s%s\n", colorCyan, baseBranch, colorReset)
-
 	return nil
 }
 
@@ -451,7 +840,7 @@ func runEpicDiff(cmd *cobra.Command, args []string) error {
 	workDir, _ := os.Getwd()
 	safety := git.New(workDir)
 
-	baseBranch, err := safety.BaseBranch()
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
 	if err != nil {
 		return fmt.Errorf("detect base branch: %w", err)
 	}
@@ -494,6 +883,8 @@ func statusToColor(status store.TaskStatus) string {
 		return colorGreen
 	case store.StatusFailed:
 		return colorRed + colorBold
+	case store.StatusNeedsHuman:
+		return colorYellow + colorBold
 	case store.StatusCancelled:
 		return colorDim
 	default:
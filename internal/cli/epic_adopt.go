@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var epicAdoptCmd = &cobra.Command{
+	Use:   "adopt --branch <branch>",
+	Short: "Create an epic from the commits of an existing branch",
+	Long: `Turns work started outside hive into an epic: the branch becomes the
+epic's safety branch (as with epic attach) and its commits since the base
+branch become tasks, ready for review. Each task is reviewed on its own
+commits; once every task is approved, hive epic accept merges the branch.
+
+By default each commit is a task. With --group the PM agent groups the
+commits into logical changes instead.
+
+Examples:
+  hive epic adopt --branch feature/login
+  hive epic adopt --branch feature/login --group --title "Login page"`,
+	Args: cobra.NoArgs,
+	RunE: runEpicAdopt,
+}
+
+var (
+	epicAdoptBranch string
+	epicAdoptTitle  string
+	epicAdoptBase   string
+	epicAdoptGroup  bool
+	epicAdoptAgent  string
+)
+
+func init() {
+	epicAdoptCmd.Flags().StringVarP(&epicAdoptBranch, "branch", "b", "", "Branch to adopt (required)")
+	epicAdoptCmd.Flags().StringVarP(&epicAdoptTitle, "title", "t", "", "Epic title (default: from the branch name)")
+	epicAdoptCmd.Flags().StringVar(&epicAdoptBase, "base", "", "Branch the work started from (default: main/master)")
+	epicAdoptCmd.Flags().BoolVar(&epicAdoptGroup, "group", false, "Have the PM agent group commits into tasks instead of one task per commit")
+	epicAdoptCmd.Flags().StringVarP(&epicAdoptAgent, "agent", "a", "", "Override PM agent name for --group")
+	epicAdoptCmd.MarkFlagRequired("branch")
+	epicCmd.AddCommand(epicAdoptCmd)
+}
+
+func runEpicAdopt(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return fmt.Errorf("not a git repository")
+	}
+	branch := epicAdoptBranch
+	if !safety.BranchExists(branch) {
+		return fmt.Errorf("branch %s does not exist", branch)
+	}
+	if epicAdoptBase != "" && !safety.BranchExists(epicAdoptBase) {
+		return fmt.Errorf("base branch %s does not exist", epicAdoptBase)
+	}
+	baseBranch, err := safety.BaseBranchFor(epicAdoptBase)
+	if err != nil {
+		return fmt.Errorf("detect base branch: %w", err)
+	}
+	if branch == baseBranch {
+		return fmt.Errorf("%s is the base branch — adopt a feature branch", branch)
+	}
+	if safety.HasUncommittedChanges() {
+		return fmt.Errorf("uncommitted changes — commit or stash them before switching branches")
+	}
+
+	commits, err := safety.BranchCommits(baseBranch, branch)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("%s has no commits since %s", branch, baseBranch)
+	}
+
+	title := epicAdoptTitle
+	if title == "" {
+		title = branchTitle(branch)
+	}
+	description := fmt.Sprintf("Adopted from branch %s: %d commit(s) since %s.", branch, len(commits), baseBranch)
+	epic, err := s.CreateEpic(title, description, "medium")
+	if err != nil {
+		return err
+	}
+	if epicAdoptBase != "" {
+		s.SetBaseBranch(epic.ID, epicAdoptBase)
+		epic.BaseBranch = epicAdoptBase
+	}
+	fmt.Printf("Created epic %s#%d%s: %s\n", colorYellow, epic.ID, colorReset, epic.Title)
+	if err := adoptBranch(s, safety, epic, branch); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	var groups []agent.CommitGroup
+	if epicAdoptGroup {
+		groups, err = groupCommits(s, epic, commits)
+		if err != nil {
+			fmt.Printf("%s⚠  %v — one task per commit instead%s\n\n", colorYellow, err, colorReset)
+		}
+	}
+	if len(groups) == 0 {
+		for i, c := range commits {
+			groups = append(groups, agent.CommitGroup{Title: c.Subject, Description: c.Body, Commits: []int{i}})
+		}
+	}
+
+	tasks, err := seedAdoptedTasks(s, epic, commits, groups)
+	fmt.Printf("%sCreated %d task(s) from %d commit(s):%s\n\n", colorBold, len(tasks), len(commits), colorReset)
+	for _, t := range tasks {
+		var short []string
+		for _, c := range t.Commits {
+			short = append(short, shortRef(c))
+		}
+		fmt.Printf("  %s#%d%s %s %s(%s)%s\n", colorYellow, t.ID, colorReset, t.Title, colorDim, strings.Join(short, ", "), colorReset)
+	}
+	if err != nil {
+		return fmt.Errorf("create tasks: %w", err)
+	}
+	s.AddEvent(epic.ID, "user", "planned", fmt.Sprintf("Created %d tasks from %d commits of %s", len(tasks), len(commits), branch))
+
+	fmt.Printf("\nNext: %shive review <task-id>%s for each task, then %shive epic accept %d%s\n",
+		colorCyan, colorReset, colorCyan, epic.ID, colorReset)
+	return nil
+}
+
+// groupCommits has the PM agent group an adopted branch's commits into
+// tasks.
+func groupCommits(s *store.Store, epic *store.Task, commits []git.Commit) ([]agent.CommitGroup, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	agentName, agentCfg := epicAdoptAgent, config.Agent{}
+	if agentName == "" {
+		agentName, agentCfg = findAgentByRole(cfg, roles.PM)
+	} else {
+		var ok bool
+		if agentCfg, ok = cfg.Agents[agentName]; !ok {
+			return nil, fmt.Errorf("agent %q not found in config", agentName)
+		}
+	}
+	if agentName == "" {
+		return nil, fmt.Errorf("no PM agent configured")
+	}
+	forceAutoAccept(&agentCfg)
+
+	runner, err := agent.NewRunner(agentName, agentCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create agent: %w", err)
+	}
+	fmt.Printf("Grouping commits with %s...\n\n", agentName)
+
+	workDir, _ := os.Getwd()
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID:     epic.ID,
+		Prompt:     newContextBuilder(s, cfg).BuildGroupCommitsPrompt(epic, commits),
+		WorkDir:    workDir,
+		TimeoutSec: agentCfg.DefaultTimeout(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PM agent failed: %w", err)
+	}
+	newArtifacts(s).Save(epic.ID, "plan", artifacts.Name(epic.ID, "plan"), resp.Output)
+
+	groups := agent.ParseCommitGroups(resp.Output, len(commits))
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("PM agent didn't group the commits")
+	}
+	return groups, nil
+}
+
+// seedAdoptedTasks creates a task in review for each group of commits,
+// recording its commits so hive review looks at their diff.
+func seedAdoptedTasks(s *store.Store, epic *store.Task, commits []git.Commit, groups []agent.CommitGroup) ([]*store.Task, error) {
+	var tasks []*store.Task
+	for _, g := range groups {
+		var hashes, lines []string
+		for _, i := range g.Commits {
+			hashes = append(hashes, commits[i].Hash)
+			lines = append(lines, fmt.Sprintf("- %s %s", shortRef(commits[i].Hash), commits[i].Subject))
+		}
+		description := strings.TrimSpace(g.Description + "\n\nCommits:\n" + strings.Join(lines, "\n"))
+
+		task, err := s.CreateTask(g.Title, description, "medium", &epic.ID)
+		if err != nil {
+			return tasks, err
+		}
+		if err := s.SetTaskCommits(task.ID, hashes); err != nil {
+			return tasks, err
+		}
+		s.UpdateTaskStatus(task.ID, store.StatusReview)
+		task.Commits = hashes
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// branchTitle makes an epic title from a branch name, e.g. "Login page"
+// from feature/login-page.
+func branchTitle(branch string) string {
+	name := branch[strings.LastIndex(branch, "/")+1:]
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if name == "" {
+		return branch
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/imkarma/hive/internal/cilog"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// maxCITasks caps the tasks seeded from a CI log; the smallest groups
+// beyond it share one task.
+const maxCITasks = 10
+
+// readCILog reads and parses the log for --from-ci-log; "-" is stdin.
+func readCILog(path string) ([]cilog.Group, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read CI log: %w", err)
+	}
+	groups := cilog.Groups(cilog.Parse(string(data)))
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no failing tests or build errors found in the CI log")
+	}
+	return groups, nil
+}
+
+// seedCITasks creates a task under the epic for each group of failures.
+// Build errors are high priority: nothing else can be checked until the
+// code compiles.
+func seedCITasks(s *store.Store, epic *store.Task, groups []cilog.Group) ([]*store.Task, error) {
+	var rest *cilog.Group
+	if len(groups) > maxCITasks {
+		rest = &cilog.Group{Name: fmt.Sprintf("%d more places", len(groups)-maxCITasks+1), Kind: cilog.KindTest}
+		for _, g := range groups[maxCITasks-1:] {
+			rest.Failures = append(rest.Failures, g.Failures...)
+		}
+		groups = groups[:maxCITasks-1]
+	}
+
+	var tasks []*store.Task
+	create := func(title, desc, priority string) error {
+		t, err := s.CreateTask(title, desc, priority, &epic.ID)
+		if err == nil {
+			tasks = append(tasks, t)
+		}
+		return err
+	}
+	for _, g := range groups {
+		priority := epic.Priority
+		if g.Kind == cilog.KindBuild {
+			priority = "high"
+		}
+		if err := create(g.Title(), g.Description(), priority); err != nil {
+			return tasks, err
+		}
+	}
+	if rest != nil {
+		if err := create("Fix the remaining CI failures in "+rest.Name, rest.Description(), epic.Priority); err != nil {
+			return tasks, err
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var epicHistoryCmd = &cobra.Command{
+	Use:   "history [id]",
+	Short: "Show the record of accepted epics",
+	Long: `Accepting an epic saves a snapshot of it: its tasks and review
+verdicts, handoff notes, commits and diffstat as they were when it was
+merged. Snapshots can't be changed, and stay readable after the branch is
+deleted and the run files are cleaned up.
+
+Without an ID, lists every accepted epic.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEpicHistory,
+}
+
+func init() {
+	epicCmd.AddCommand(epicHistoryCmd)
+}
+
+func runEpicHistory(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if len(args) == 0 {
+		snaps, err := s.ListEpicSnapshots(0)
+		if err != nil {
+			return err
+		}
+		if len(snaps) == 0 {
+			fmt.Println("No accepted epics yet.")
+			return nil
+		}
+		for _, snap := range snaps {
+			fmt.Printf("  %s#%-4d%s %s  %s %s→ %s%s\n",
+				colorYellow, snap.EpicID, colorReset,
+				snap.CreatedAt.Local().Format("2006-01-02 15:04"),
+				snap.Title, colorDim, snap.BaseBranch, colorReset)
+		}
+		fmt.Printf("\nShow one with: %shive epic history <id>%s\n", colorCyan, colorReset)
+		return nil
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid epic ID: %s", args[0])
+	}
+	snaps, err := s.ListEpicSnapshots(id)
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		fmt.Printf("Epic #%d has no history — it is saved when the epic is accepted.\n", id)
+		return nil
+	}
+	// An epic reopened and accepted again has one snapshot per accept.
+	for i, snap := range snaps {
+		if i > 0 {
+			fmt.Println("\n---")
+		}
+		fmt.Println(strings.TrimSpace(snap.Report))
+	}
+	return nil
+}
+
+// epicReport renders the Markdown report saved when an epic is accepted:
+// what was asked, what each task came to and how its reviews went, and
+// what was merged.
+func epicReport(s *store.Store, epic *store.Task, baseBranch, commits, stat string, at time.Time) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Epic #%d: %s\n\n", epic.ID, epic.Title)
+	fmt.Fprintf(&sb, "Accepted %s — merged %s into %s\n", at.Local().Format("2006-01-02 15:04"), epic.GitBranch, baseBranch)
+	if desc := strings.TrimSpace(epic.Description); desc != "" {
+		fmt.Fprintf(&sb, "\n%s\n", desc)
+	}
+
+	tasks, _ := s.ListTasksByEpic(epic.ID)
+	if len(tasks) > 0 {
+		sb.WriteString("\n## Tasks\n\n")
+		for _, t := range tasks {
+			fmt.Fprintf(&sb, "- #%d %s (%s)", t.ID, t.Title, t.Status)
+			if summary := reviewSummary(s, t.ID); summary != "" {
+				fmt.Fprintf(&sb, " — %s", summary)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if handoffs, _ := s.ListEpicHandoffs(epic.ID); len(handoffs) > 0 {
+		sb.WriteString("\n## Handoff notes\n\n")
+		for _, h := range handoffs {
+			for _, note := range h.Notes {
+				fmt.Fprintf(&sb, "- #%d: %s\n", h.TaskID, note)
+			}
+		}
+	}
+
+	if commits = strings.TrimSpace(commits); commits != "" {
+		fmt.Fprintf(&sb, "\n## Commits\n\n```\n%s\n```\n", commits)
+	}
+	if stat = strings.Trim(stat, "\n"); stat != "" {
+		fmt.Fprintf(&sb, "\n## Changes\n\n```\n%s\n```\n", stat)
+	}
+	return sb.String()
+}
+
+// reviewSummary sums up a task's reviews, e.g. "approved by claude after
+// 1 rejection(s): missing tests".
+func reviewSummary(s *store.Store, taskID int64) string {
+	reviews, _ := s.GetReviews(taskID)
+	if len(reviews) == 0 {
+		return ""
+	}
+	var rejected int
+	var lastReject string
+	for _, r := range reviews {
+		if r.Verdict == "reject" {
+			rejected++
+			lastReject = r.Comments
+		}
+	}
+	last := reviews[len(reviews)-1]
+	var summary string
+	if last.Verdict == "approve" {
+		summary = "approved by " + last.ReviewerAgent
+	} else {
+		summary = "rejected by " + last.ReviewerAgent
+	}
+	if rejected > 0 && last.Verdict == "approve" {
+		summary += fmt.Sprintf(" after %d rejection(s)", rejected)
+	}
+	if lastReject = strings.Join(strings.Fields(lastReject), " "); lastReject != "" {
+		const maxComment = 200
+		if len(lastReject) > maxComment {
+			lastReject = lastReject[:maxComment] + "..."
+		}
+		summary += ": " + lastReject
+	}
+	return summary
+}
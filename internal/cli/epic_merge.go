@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var epicMergeCmd = &cobra.Command{
+	Use:   "merge [into-id] [from-id]",
+	Short: "Merge one epic into another",
+	Long: `Moves every task of the second epic under the first and merges the
+second epic's safety branch into the first's. The second epic is cancelled
+and its branch deleted.
+
+Both epics' branches must merge cleanly; conflicts are listed and nothing
+changes.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEpicMerge,
+}
+
+var epicSplitCmd = &cobra.Command{
+	Use:   "split [id]",
+	Short: "Move some of an epic's tasks into a new epic",
+	Long: `Creates a new epic with the given tasks moved under it. Its safety
+branch starts from the original epic's branch, so work already done on the
+moved tasks comes along.
+
+Example:
+  hive epic split 1 --tasks 3,4,5 --title "Billing export"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEpicSplit,
+}
+
+var (
+	epicSplitTasks string
+	epicSplitTitle string
+)
+
+func init() {
+	epicSplitCmd.Flags().StringVar(&epicSplitTasks, "tasks", "", "Comma-separated IDs of the tasks to move (required)")
+	epicSplitCmd.Flags().StringVar(&epicSplitTitle, "title", "", "Title of the new epic (default: original title + \"(split)\")")
+	epicSplitCmd.MarkFlagRequired("tasks")
+
+	epicCmd.AddCommand(epicMergeCmd)
+	epicCmd.AddCommand(epicSplitCmd)
+}
+
+// loadEpic parses an epic ID argument and loads the epic.
+func loadEpic(s *store.Store, arg string) (*store.Task, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid epic ID: %s", arg)
+	}
+	epic, err := s.GetTask(id)
+	if err != nil {
+		return nil, fmt.Errorf("epic #%d not found", id)
+	}
+	if epic.Kind != store.KindEpic {
+		return nil, fmt.Errorf("#%d is a task, not an epic", id)
+	}
+	return epic, nil
+}
+
+// checkEpicIdle refuses to reshape an epic while a pipeline runs on it or
+// after it was accepted or rejected.
+func checkEpicIdle(s *store.Store, epic *store.Task) error {
+	if run, _ := s.GetActivePipelineRun(epic.ID); run != nil {
+		return fmt.Errorf("epic #%d has a running pipeline (run #%d); stop it first", epic.ID, run.ID)
+	}
+	if epic.Status == store.StatusDone || epic.Status == store.StatusCancelled {
+		return fmt.Errorf("epic #%d is already %s", epic.ID, epic.Status)
+	}
+	return nil
+}
+
+func runEpicMerge(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	into, err := loadEpic(s, args[0])
+	if err != nil {
+		return err
+	}
+	from, err := loadEpic(s, args[1])
+	if err != nil {
+		return err
+	}
+	if into.ID == from.ID {
+		return fmt.Errorf("cannot merge epic #%d into itself", into.ID)
+	}
+	for _, e := range []*store.Task{into, from} {
+		if err := checkEpicIdle(s, e); err != nil {
+			return err
+		}
+	}
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+	branchMerged := false
+	if safety.IsGitRepo() && from.GitBranch != "" && safety.BranchExists(from.GitBranch) {
+		switch {
+		case into.GitBranch == "" || !safety.BranchExists(into.GitBranch):
+			// Nothing to merge into: the surviving epic takes over the branch.
+			s.SetGitBranch(into.ID, from.GitBranch)
+			fmt.Printf("  Epic #%d now uses branch %s%s%s\n", into.ID, colorCyan, from.GitBranch, colorReset)
+		default:
+			if safety.HasUncommittedChanges() {
+				return fmt.Errorf("working tree has uncommitted changes; commit or stash them before merging branches")
+			}
+			conflicts, err := safety.MergeConflicts(into.GitBranch, from.GitBranch)
+			if err != nil {
+				return fmt.Errorf("check conflicts: %w", err)
+			}
+			if len(conflicts) > 0 {
+				fmt.Printf("%s✗ %s and %s conflict:%s\n", colorRed+colorBold, into.GitBranch, from.GitBranch, colorReset)
+				for _, f := range conflicts {
+					fmt.Printf("  %s\n", f)
+				}
+				return fmt.Errorf("resolve the conflicts between the branches first; nothing was changed")
+			}
+			if err := safety.MergeBranch(into.GitBranch, from.GitBranch); err != nil {
+				return err
+			}
+			branchMerged = true
+			fmt.Printf("  Merged %s%s%s into %s%s%s\n", colorCyan, from.GitBranch, colorReset, colorCyan, into.GitBranch, colorReset)
+		}
+	}
+
+	tasks, err := s.ListTasksByEpic(from.ID)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, t := range tasks {
+		if err := s.SetParent(t.ID, into.ID); err != nil {
+			return err
+		}
+		ids = append(ids, fmt.Sprintf("#%d", t.ID))
+	}
+	moved := "no tasks"
+	if len(ids) > 0 {
+		moved = strings.Join(ids, ", ")
+	}
+
+	s.AddEvent(into.ID, "", "epic_merged", fmt.Sprintf("Merged epic #%d (%s): moved %s", from.ID, from.Title, moved))
+	s.AddEvent(from.ID, "", "epic_merged", fmt.Sprintf("Merged into epic #%d (%s)", into.ID, into.Title))
+	s.UpdateTaskStatus(from.ID, store.StatusCancelled)
+	if branchMerged && from.AdoptedRef == "" { // An adopted branch is the user's; keep it.
+		if err := safety.DeleteBranch(from.GitBranch, false); err != nil {
+			fmt.Printf("  %s⚠  Could not delete %s: %v%s\n", colorYellow, from.GitBranch, err, colorReset)
+		}
+	}
+
+	fmt.Printf("%s✓ Merged epic #%d into #%d%s (%d task(s) moved)\n", colorGreen, from.ID, into.ID, colorReset, len(tasks))
+	return nil
+}
+
+func runEpicSplit(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	orig, err := loadEpic(s, args[0])
+	if err != nil {
+		return err
+	}
+	if err := checkEpicIdle(s, orig); err != nil {
+		return err
+	}
+
+	children, err := s.ListTasksByEpic(orig.ID)
+	if err != nil {
+		return err
+	}
+	inEpic := make(map[int64]bool, len(children))
+	for _, t := range children {
+		inEpic[t.ID] = true
+	}
+	var move []int64
+	for _, f := range strings.Split(epicSplitTasks, ",") {
+		f = strings.TrimPrefix(strings.TrimSpace(f), "#")
+		if f == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid task ID: %s", f)
+		}
+		if !inEpic[id] {
+			return fmt.Errorf("task #%d is not in epic #%d", id, orig.ID)
+		}
+		move = append(move, id)
+	}
+	if len(move) == 0 {
+		return fmt.Errorf("--tasks lists no tasks")
+	}
+	if len(move) == len(children) {
+		return fmt.Errorf("that would move every task of epic #%d; rename it instead", orig.ID)
+	}
+
+	title := epicSplitTitle
+	if title == "" {
+		title = orig.Title + " (split)"
+	}
+	epic, err := s.CreateEpic(title, fmt.Sprintf("Split from epic #%d: %s", orig.ID, orig.Title), orig.Priority)
+	if err != nil {
+		return err
+	}
+	if orig.BaseBranch != "" {
+		s.SetBaseBranch(epic.ID, orig.BaseBranch)
+	}
+
+	var ids []string
+	for _, id := range move {
+		if err := s.SetParent(id, epic.ID); err != nil {
+			return err
+		}
+		ids = append(ids, fmt.Sprintf("#%d", id))
+	}
+	fmt.Printf("Created epic %s#%d%s: %s with %s\n", colorYellow, epic.ID, colorReset, epic.Title, strings.Join(ids, ", "))
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+	if safety.IsGitRepo() && orig.GitBranch != "" && safety.BranchExists(orig.GitBranch) {
+		branch := git.BranchName(epic.ID)
+		if err := safety.BranchAt(branch, orig.GitBranch); err != nil {
+			fmt.Printf("  %s⚠  Could not create safety branch: %v%s\n", colorYellow, err, colorReset)
+		} else {
+			s.SetGitBranch(epic.ID, branch)
+			fmt.Printf("  Branch: %s%s%s (from %s)\n", colorCyan, branch, colorReset, orig.GitBranch)
+		}
+	}
+
+	s.AddEvent(orig.ID, "", "epic_split", fmt.Sprintf("Moved %s to new epic #%d (%s)", strings.Join(ids, ", "), epic.ID, epic.Title))
+	s.AddEvent(epic.ID, "", "epic_split", fmt.Sprintf("Split from epic #%d (%s) with %s", orig.ID, orig.Title, strings.Join(ids, ", ")))
+
+	fmt.Printf("\nNext: %shive auto %d%s to run it\n", colorCyan, epic.ID, colorReset)
+	return nil
+}
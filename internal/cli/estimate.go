@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
+)
+
+// estimateWindow is how far back the call history goes for estimates, so
+// a switch to a faster model shows up within weeks.
+const estimateWindow = 30 * 24 * time.Hour
+
+// confirmEstimate prints the projected calls, duration and cost of
+// running tasks and, when the worst case goes over auto.confirm_above,
+// asks before going on. Returns false if the answer is no. Without a
+// terminal to ask on, a run over the limits needs --yes.
+func confirmEstimate(s *store.Store, cfg *config.Config, epic *store.Task, tasks []store.Task, architect bool) (bool, error) {
+	testerName, _ := findAgentByRole(cfg, roles.Tester)
+	docsName, _ := findAgentByRole(cfg, roles.Docs)
+	stats, _ := s.AgentCallStats(time.Now().Add(-estimateWindow))
+	e := worker.Estimate(worker.EstimatePlan{
+		Tasks:     tasks,
+		MaxLoops:  autoMaxLoops,
+		Parallel:  autoParallel,
+		Architect: architect,
+		Tester:    testerName != "",
+		Docs:      docsName != "" && epic.Kind == store.KindEpic && !autoSkipDocs,
+	}, stats)
+	if e.Tasks == 0 {
+		return true, nil
+	}
+
+	fmt.Printf("  Estimate for %d task(s), up to %d fix loop(s) each:\n", e.Tasks, autoMaxLoops)
+	var byRole []string
+	for _, r := range e.Roles {
+		byRole = append(byRole, r.Role+" "+span(r.MinCalls, r.MaxCalls, strconv.Itoa))
+	}
+	fmt.Printf("    calls     %s  %s(%s)%s\n", span(e.MinCalls, e.MaxCalls, strconv.Itoa), colorDim, strings.Join(byRole, ", "), colorReset)
+	if len(stats) == 0 {
+		fmt.Printf("    duration  %sunknown — no agent calls recorded yet%s\n", colorDim, colorReset)
+	} else {
+		fmt.Printf("    duration  ~%s\n", span(e.MinDuration, e.MaxDuration, formatSpan))
+		if e.MaxCost > 0 {
+			fmt.Printf("    cost      ~%s\n", span(e.MinCost, e.MaxCost, func(c float64) string { return fmt.Sprintf("$%.2f", c) }))
+		}
+		if unknown := e.Unknown(); len(unknown) > 0 {
+			fmt.Printf("    %sno history for %s — left out of duration and cost%s\n", colorDim, strings.Join(unknown, ", "), colorReset)
+		}
+	}
+
+	over := cfg.Auto.ConfirmAbove.Exceeded(e.MaxCalls, e.MaxDuration, e.MaxCost)
+	if len(over) == 0 || autoYes || autoResume != nil || agent.Simulating() || agent.Replaying() {
+		fmt.Println()
+		return true, nil
+	}
+	fmt.Printf("\n  %s⚠ Over auto.confirm_above: %s%s\n", colorYellow, strings.Join(over, ", "), colorReset)
+	if !isTerminal(os.Stdin) {
+		return false, fmt.Errorf("run estimate is over auto.confirm_above (%s); re-run with --yes to start it anyway", strings.Join(over, ", "))
+	}
+	if !confirm("  Start the run?") {
+		fmt.Printf("  %sStopped before any agent ran.%s\n", colorDim, colorReset)
+		return false, nil
+	}
+	fmt.Println()
+	return true, nil
+}
+
+// span formats a min–max range, or one value when they're equal.
+func span[T comparable](min, max T, format func(T) string) string {
+	if min == max {
+		return format(min)
+	}
+	return format(min) + "–" + format(max)
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/spf13/cobra"
+)
+
+var exploreCmd = &cobra.Command{
+	Use:   "explore <epic-id>",
+	Short: "Explore the code an epic touches and write a findings report",
+	Long: `Runs the analyst (or, without one, the architect) on an epic for a
+fixed time and saves what it finds — relevant code, risks, open questions,
+a suggested approach — as an artifact. Nothing is planned and no code is
+changed: in a git repository the agent works in a scratch clone of the
+current branch, so uncommitted changes aren't visible to it.
+
+The report is added to the epic's history, so a later hive plan or
+hive auto sees it.
+
+Examples:
+  hive explore 3
+  hive explore 3 --minutes 20 --focus "how sessions are stored"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplore,
+}
+
+var (
+	exploreMinutes int
+	exploreFocus   string
+	exploreAgent   string
+)
+
+func init() {
+	exploreCmd.Flags().IntVarP(&exploreMinutes, "minutes", "m", 10, "Time box for the exploration")
+	exploreCmd.Flags().StringVar(&exploreFocus, "focus", "", "What to look into in particular")
+	exploreCmd.Flags().StringVarP(&exploreAgent, "agent", "a", "", "Override the exploring agent name")
+	rootCmd.AddCommand(exploreCmd)
+}
+
+func runExplore(cmd *cobra.Command, args []string) error {
+	if exploreMinutes <= 0 {
+		return fmt.Errorf("--minutes must be positive")
+	}
+
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ID: %s", args[0])
+	}
+	epic, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("#%d not found", id)
+	}
+
+	agentName, agentCfg := exploreAgent, config.Agent{}
+	if agentName == "" {
+		if agentName, agentCfg = findAgentByRole(cfg, roles.Analyst); agentName == "" {
+			agentName, agentCfg = findAgentByRole(cfg, roles.Architect)
+		}
+	} else {
+		var ok bool
+		if agentCfg, ok = cfg.Agents[agentName]; !ok {
+			return fmt.Errorf("agent %q not found in config", agentName)
+		}
+	}
+	if agentName == "" {
+		return fmt.Errorf("no analyst or architect agent configured. Add an agent with role: analyst in .hive/config.yaml")
+	}
+	forceAutoAccept(&agentCfg)
+
+	role := agentCfg.Role
+	if role != roles.Architect {
+		role = roles.Analyst
+	}
+	prompt := newContextBuilder(s, cfg).BuildExplorePrompt(epic, role, exploreMinutes, exploreFocus)
+
+	runner, err := agent.NewRunner(agentName, agentCfg)
+	if err != nil {
+		return fmt.Errorf("create agent: %w", err)
+	}
+
+	workDir, cleanup, err := exploreSandbox()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	fmt.Printf("Exploring #%d: %s\n", epic.ID, epic.Title)
+	fmt.Printf("  Agent: %s (%d min)\n\n", agentName, exploreMinutes)
+
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID:     epic.ID,
+		Prompt:     prompt,
+		WorkDir:    workDir,
+		TimeoutSec: exploreMinutes * 60,
+	})
+	// A timed-out agent may still have written most of its report.
+	if resp == nil || strings.TrimSpace(resp.Output) == "" {
+		if err == nil && resp != nil {
+			err = resp.Error
+		}
+		if err == nil {
+			err = fmt.Errorf("no output")
+		}
+		return fmt.Errorf("explore agent failed: %w", err)
+	}
+	if err != nil {
+		fmt.Printf("%s⚠  %v — keeping the partial report%s\n\n", colorYellow, err, colorReset)
+	}
+
+	if blocked := agent.ParseBlocked(resp.Output); blocked != "" {
+		fmt.Printf("%s⚠  %s needs more detail:%s %s\n", colorRed+colorBold, agentName, colorReset, blocked)
+		fmt.Printf("   Answer with: hive answer %d \"...\", then explore again.\n", epic.ID)
+		s.AddEvent(epic.ID, agentName, "comment", "Explore blocked: "+blocked)
+		return nil
+	}
+
+	report := strings.TrimSpace(resp.Output)
+	// Each run keeps its own report, so earlier findings can be compared.
+	name := artifacts.Name(epic.ID, "explore", time.Now().Format("20060102-150405"))
+	if _, err := newArtifacts(s).Save(epic.ID, "explore", name, report+"\n"); err != nil {
+		return fmt.Errorf("save report: %w", err)
+	}
+	s.AddEvent(epic.ID, agentName, "findings", report)
+
+	fmt.Println(report)
+	fmt.Printf("\n%sSaved to %s%s\n", colorDim, filepath.Join(artifacts.RunsDir, name), colorReset)
+	fmt.Printf("Next: %shive plan %d%s to break it into tasks\n", colorCyan, epic.ID, colorReset)
+	return nil
+}
+
+// exploreSandbox returns the directory the explore agent runs in: a
+// scratch clone of the repository, so whatever the agent does can't
+// touch the working tree. Outside a git repository it's the working
+// directory itself, and only the prompt keeps the agent read-only.
+func exploreSandbox() (string, func(), error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", nil, err
+	}
+	repo := git.New(wd)
+	if !repo.IsGitRepo() {
+		fmt.Printf("%s⚠  Not a git repository: the agent runs in place and is only asked not to change files%s\n\n", colorYellow, colorReset)
+		return wd, func() {}, nil
+	}
+	tmp, err := os.MkdirTemp("", "hive-explore-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("create sandbox: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+	// Clone into a subdirectory: git wants to create the target.
+	dir := filepath.Join(tmp, "repo")
+	if err := repo.CloneTo(dir); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
@@ -7,10 +7,11 @@ import (
 	"strconv"
 
 	"github.com/imkarma/hive/internal/agent"
-	"github.com/imkarma/hive/internal/config"
-	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 	"github.com/spf13/cobra"
 )
 
@@ -48,7 +49,7 @@ func runFix(cmd *cobra.Command, args []string) error {
 	}
 	defer s.Close()
 
-	cfg, err := config.Load(hivePath("config.yaml"))
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -69,7 +70,7 @@ func runFix(cmd *cobra.Command, args []string) error {
 		if task.AssignedAgent != "" {
 			coderName = task.AssignedAgent
 		} else {
-			coders := cfg.AgentsByRole("coder")
+			coders := cfg.AgentsByRole(roles.Coder)
 			for name := range coders {
 				coderName = name
 				break
@@ -83,7 +84,7 @@ func runFix(cmd *cobra.Command, args []string) error {
 	// Find reviewer agent.
 	reviewerName := fixReviewAgent
 	if reviewerName == "" {
-		reviewers := cfg.AgentsByRole("reviewer")
+		reviewers := cfg.AgentsByRole(roles.Reviewer)
 		for name := range reviewers {
 			reviewerName = name
 			break
@@ -100,17 +101,17 @@ func runFix(cmd *cobra.Command, args []string) error {
 	forceAutoAccept(&coderCfg)
 	forceAutoAccept(&reviewerCfg)
 
-	coderRunner, err := agent.NewRunner(coderName, coderCfg)
+	coderRunner, err := worker.NewRunner(coderName, coderCfg)
 	if err != nil {
 		return fmt.Errorf("create coder runner: %w", err)
 	}
-	reviewerRunner, err := agent.NewRunner(reviewerName, reviewerCfg)
+	reviewerRunner, err := worker.NewRunner(reviewerName, reviewerCfg)
 	if err != nil {
 		return fmt.Errorf("create reviewer runner: %w", err)
 	}
 
 	workDir, _ := os.Getwd()
-	ctxBuilder := agentctx.New(s)
+	ctxBuilder := newContextBuilder(s, cfg)
 
 	fmt.Printf("%s═══ Fix Loop: Task #%d ═══%s\n", colorBold, task.ID, colorReset)
 	fmt.Printf("  Task:     %s\n", task.Title)
@@ -118,7 +119,13 @@ func runFix(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Reviewer: %s%s%s\n", colorCyan, reviewerName, colorReset)
 	fmt.Printf("  Max loops: %d\n\n", fixMaxLoops)
 
+	defer startHeartbeat(s, task.ID)()
+	var rejections worker.RejectionTracker
+	var diffGuard worker.DiffGuard
 	for iteration := 1; iteration <= fixMaxLoops; iteration++ {
+		if iteration > 1 {
+			waitBackoff(cfg, iteration)
+		}
 		fmt.Printf("%s── Iteration %d/%d ──%s\n\n", colorBold, iteration, fixMaxLoops, colorReset)
 
 		// Re-fetch task to get latest events/history.
@@ -128,14 +135,16 @@ func runFix(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%s[coder]%s %s working...\n", colorBlue, colorReset, coderName)
 		s.UpdateTaskStatus(task.ID, store.StatusInProgress)
 
-		coderPrompt, err := ctxBuilder.BuildPrompt(task, "coder")
+		coderPrompt, err := ctxBuilder.BuildPrompt(task, roles.Coder)
 		if err != nil {
 			return fmt.Errorf("build coder prompt: %w", err)
 		}
+		coderMsgs, _ := ctxBuilder.BuildMessages(task, roles.Coder)
 
 		coderResp, err := coderRunner.Run(context.Background(), agent.Request{
 			TaskID:     task.ID,
 			Prompt:     coderPrompt,
+			Messages:   coderMsgs,
 			WorkDir:    workDir,
 			TimeoutSec: coderCfg.DefaultTimeout(),
 		})
@@ -145,16 +154,9 @@ func runFix(cmd *cobra.Command, args []string) error {
 		}
 
 		// Save coder output.
-		coderArtifact := hivePath("runs", fmt.Sprintf("task-%d-code-iter%d.md", task.ID, iteration))
-		os.MkdirAll(hivePath("runs"), 0755)
-		os.WriteFile(coderArtifact, []byte(coderResp.Output), 0644)
-		s.AddArtifact(task.ID, "code", coderArtifact)
-
-		outputPreview := coderResp.Output
-		if len(outputPreview) > 200 {
-			outputPreview = outputPreview[:200] + "..."
-		}
-		s.AddEvent(task.ID, coderName, "agent_output", outputPreview)
+		newArtifacts(s).Save(task.ID, "code", artifacts.Name(task.ID, "code", fmt.Sprintf("iter%d", iteration)), coderResp.Output)
+
+		s.AddEvent(task.ID, coderName, "agent_output", cfg.History.Preview(coderResp.Output))
 
 		fmt.Printf("  Done (%.1fs)\n", coderResp.Duration)
 
@@ -173,6 +175,18 @@ func runFix(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 
+		if r := diffGuard.Check(s, task, iteration, workDir); r != nil {
+			fmt.Printf("  %s⚠ Undid %d of %d changed line(s) from iteration %d%s\n", colorYellow, r.Undone, r.Changed, r.Previous, colorReset)
+		}
+
+		// === Verify + test gate: failures go back to the coder unreviewed ===
+		if f := worker.RunTestGate(cfg, workDir); f != nil {
+			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
+			s.AddEvent(task.ID, f.Step, "reviewed", f.Feedback(iteration))
+			fmt.Printf("  %s✗ %s failed%s (%s)\n\n", colorRed, f.Step, colorReset, f.Cmd)
+			continue
+		}
+
 		// === STEP 2: Reviewer ===
 		fmt.Printf("%s[reviewer]%s %s reviewing...\n", colorMagenta, colorReset, reviewerName)
 		s.UpdateTaskStatus(task.ID, store.StatusReview)
@@ -181,27 +195,42 @@ func runFix(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("build review prompt: %w", err)
 		}
+		reviewMsgs, _ := ctxBuilder.BuildReviewMessages(task)
 
 		reviewResp, err := reviewerRunner.Run(context.Background(), agent.Request{
 			TaskID:     task.ID,
 			Prompt:     reviewPrompt,
+			Messages:   reviewMsgs,
 			WorkDir:    workDir,
 			TimeoutSec: reviewerCfg.DefaultTimeout(),
+			Format:     agent.FormatReview,
 		})
 		if err != nil {
 			return fmt.Errorf("reviewer failed: %w", err)
 		}
 
 		// Save review output.
-		reviewArtifact := hivePath("runs", fmt.Sprintf("task-%d-review-iter%d.md", task.ID, iteration))
-		os.WriteFile(reviewArtifact, []byte(reviewResp.Output), 0644)
-		s.AddArtifact(task.ID, "review", reviewArtifact)
-
-		review := agent.ParseReview(reviewResp.Output)
+		newArtifacts(s).Save(task.ID, "review", artifacts.Name(task.ID, "review", fmt.Sprintf("iter%d", iteration)), reviewResp.Output)
+
+		review := agent.CheckAcceptance(agent.ParseReviewWithChecklist(reviewResp.Output, cfg.Review.Checklist), reviewResp.Output, task.Acceptance)
+		worker.SaveSuggestions(newArtifacts(s), task.ID, review, fmt.Sprintf("iter%d", iteration))
+
+		// An unclear or reversed verdict goes to a second reviewer, whose
+		// verdict stands.
+		verdictBy := reviewerName
+		if reason := worker.TiebreakReason(s, task.ID, review); reason != "" {
+			req := agent.Request{TaskID: task.ID, Prompt: reviewPrompt, Messages: reviewMsgs, WorkDir: workDir, Format: agent.FormatReview}
+			if name, second, out, ok := worker.Tiebreak(s, newArtifacts(s), cfg, task, reviewerName, review, req, reason, fmt.Sprintf("iter%d", iteration), stageLogf); ok {
+				resp := *reviewResp
+				resp.Output = out
+				verdictBy, review, reviewResp = name, second, &resp
+			}
+		}
+		repeated := rejections.Add(iteration, review, reviewResp.Output)
 
 		switch review.Verdict {
 		case "APPROVE":
-			s.AddReview(task.ID, reviewerName, "approve", reviewResp.Output)
+			s.AddReview(task.ID, verdictBy, "approve", reviewResp.Output)
 			s.UpdateTaskStatus(task.ID, store.StatusDone)
 
 			fmt.Printf("  %s✓ APPROVED%s (%.1fs)\n", colorGreen+colorBold, colorReset, reviewResp.Duration)
@@ -227,7 +256,7 @@ func runFix(cmd *cobra.Command, args []string) error {
 			return nil
 
 		case "REJECT":
-			s.AddReview(task.ID, reviewerName, "reject", reviewResp.Output)
+			s.AddReview(task.ID, verdictBy, "reject", reviewResp.Output)
 			s.UpdateTaskStatus(task.ID, store.StatusBacklog)
 
 			fmt.Printf("  %s✗ REJECTED%s (%.1fs)\n", colorRed+colorBold, colorReset, reviewResp.Duration)
@@ -237,13 +266,21 @@ func runFix(cmd *cobra.Command, args []string) error {
 				}
 			}
 
+			if repeated {
+				rejections.MarkNeedsHuman(s, task, verdictBy)
+				fmt.Printf("\n%s═══ Same objections as the last review. Task #%d needs manual attention. ═══%s\n",
+					colorRed+colorBold, task.ID, colorReset)
+				fmt.Printf("Both reviews: %shive task show %d%s\n", colorCyan, task.ID, colorReset)
+				return nil
+			}
+
 			if iteration < fixMaxLoops {
 				// Add review comments as event so coder sees them next iteration.
 				comments := ""
 				for _, c := range review.Comments {
 					comments += "- " + c + "\n"
 				}
-				s.AddEvent(task.ID, reviewerName, "reviewed",
+				s.AddEvent(task.ID, verdictBy, "reviewed",
 					fmt.Sprintf("REJECTED (iteration %d). Issues:\n%s", iteration, comments))
 				fmt.Printf("\n  Retrying... (iteration %d/%d)\n\n", iteration+1, fixMaxLoops)
 			}
@@ -260,8 +297,8 @@ func runFix(cmd *cobra.Command, args []string) error {
 	}
 
 	// Max loops reached without approval.
-	s.UpdateTaskStatus(task.ID, store.StatusFailed)
-	fmt.Printf("\n%s═══ Max iterations reached (%d). Task #%d needs manual attention. ═══%s\n",
+	worker.MarkMaxLoops(s, task.ID, fixMaxLoops)
+	fmt.Printf("\n%s═══ Max iterations reached (%d). Task #%d needs a human. ═══%s\n",
 		colorRed+colorBold, fixMaxLoops, task.ID, colorReset)
 	fmt.Printf("Check artifacts: %shive log %d%s\n", colorCyan, task.ID, colorReset)
 
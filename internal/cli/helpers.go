@@ -4,8 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/redact"
+	"github.com/imkarma/hive/internal/sanitize"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/tracing"
+	"github.com/imkarma/hive/internal/worker"
 )
 
 const hiveDirName = ".hive"
@@ -16,16 +25,160 @@ func hivePath(parts ...string) string {
 	return filepath.Join(elems...)
 }
 
+// setupCmdFor returns the command that prepares a new worktree of an
+// epic: its own, or setup_cmd from the config.
+func setupCmdFor(cfg *config.Config, epic *store.Task) string {
+	if epic != nil && epic.SetupCmd != "" {
+		return epic.SetupCmd
+	}
+	return cfg.SetupCmd
+}
+
+// newArtifacts returns the artifact manager for the project in the
+// current directory.
+func newArtifacts(s *store.Store) *artifacts.Manager {
+	wd, _ := os.Getwd()
+	return artifacts.New(s, wd)
+}
+
 // mustStore opens the store, returning an error if hive is not initialized.
 func mustStore() (*store.Store, error) {
 	dbPath := hivePath("hive.db")
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("hive not initialized. Run: hive init")
 	}
-	return openStore(dbPath)
+	s, err := openStore(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	s.SetStatusHook(onStatusChange(s))
+	s.SetReviewHook(onReview(s))
+	agent.SetRateLimitHook(func(rl agent.RateLimit) { s.SaveRateLimit(store.RateLimit(rl)) })
+	agent.SetCallHook(func(c agent.Call) { s.RecordAgentCall(store.AgentCall(c)) })
+	agent.SetImageSource(taskImages(s))
+	return s, nil
 }
 
 // openStore opens or creates the SQLite store at the given path.
 func openStore(dbPath string) (*store.Store, error) {
 	return store.New(dbPath)
 }
+
+// metricsAddr returns the address to serve metrics on: the flag when set,
+// else metrics.listen from config. Empty means metrics are off.
+func metricsAddr(cfg *config.Config, flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return cfg.Metrics.Listen
+}
+
+// startTracing starts exporting trace spans when tracing.endpoint is set,
+// or else OTEL_EXPORTER_OTLP_ENDPOINT. The returned function flushes them.
+// Offline, nothing is exported.
+func startTracing(cfg *config.Config) (func(), error) {
+	if cfg.Offline {
+		return func() {}, nil
+	}
+	endpoint := cfg.Tracing.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	return tracing.Init(endpoint, cfg.Tracing.Headers, "hive")
+}
+
+// loadConfig loads .hive/config.yaml with the active profile and
+// config.local.yaml merged over it.
+// Its process-wide settings take effect (see applyConfig).
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadProfile(hivePath("config.yaml"), activeProfile())
+	if err == nil {
+		applyConfig(cfg)
+	}
+	return cfg, err
+}
+
+// newConfigWatcher loads the config like loadConfig and keeps watching it,
+// for commands that run long enough for the user to edit it meanwhile.
+// Callers apply reloaded configs with applyConfig.
+func newConfigWatcher() (*config.Watcher, error) {
+	cw, err := config.NewWatcher(hivePath("config.yaml"), activeProfile())
+	if err == nil {
+		applyConfig(cw.Config())
+	}
+	return cw, err
+}
+
+// applyConfig makes the settings that hold for the whole process take
+// effect: secret redaction, output cleanup, offline mode, the disk guard,
+// the API call log and the provider budgets.
+func applyConfig(cfg *config.Config) {
+	redact.Init(!cfg.Redact.Disabled, cfg.Redact.EnvPatterns)
+	out := sanitize.Options{
+		KeepANSI:        cfg.Output.KeepANSI,
+		KeepToolLogs:    cfg.Output.KeepToolLogs,
+		ToolLogPatterns: cfg.Output.ToolLogPatterns,
+	}
+	if cfg.Output.KeepRaw {
+		out.RawDir = hivePath("runs", "raw")
+	}
+	sanitize.Init(sanitize.New(out))
+	disk.Init(disk.New(hivePath(), cfg.Disk.MinFree(), cfg.Disk.MaxHive()))
+	resolveOffline(cfg)
+	agent.SetOffline(cfg.Offline)
+	agent.SetAPILog(agent.NewAPILogger(hivePath("logs", "api"), cfg.Logging.API))
+	worker.SetProviderLimits(cfg.Providers)
+}
+
+// resolveOffline turns cfg.Offline on when --offline or $HIVE_OFFLINE
+// asks for it, whatever the config says.
+func resolveOffline(cfg *config.Config) {
+	if offlineFlag || envTrue("HIVE_OFFLINE") {
+		cfg.Offline = true
+	}
+}
+
+// envTrue reports whether the environment variable is set to a true value
+// (1, true, yes or on).
+func envTrue(name string) bool {
+	switch strings.ToLower(os.Getenv(name)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// checkOffline fails fast, before any work starts, when offline mode is
+// on and the agent for one of the roles runs in api mode. Replaying a
+// cassette or simulating calls no API, so it's allowed.
+func checkOffline(cfg *config.Config, roleNames ...string) error {
+	if !cfg.Offline || agent.Replaying() || agent.Simulating() {
+		return nil
+	}
+	for _, role := range roleNames {
+		if name, a := findAgentByRole(cfg, role); name != "" && a.Mode == "api" {
+			return agent.OfflineError(name, a)
+		}
+	}
+	return nil
+}
+
+// activeProfile returns the --profile flag, or $HIVE_PROFILE.
+func activeProfile() string {
+	if configProfile != "" {
+		return configProfile
+	}
+	return os.Getenv("HIVE_PROFILE")
+}
+
+// warnTruncated records and reports an API answer that was still cut off
+// at the token limit after hive asked the agent to continue, so a short
+// plan or spec isn't mistaken for a complete one.
+func warnTruncated(s *store.Store, taskID int64, agentName, what string, resp *agent.Response) {
+	if resp == nil || !resp.Truncated {
+		return
+	}
+	msg := fmt.Sprintf("%s hit the token limit after %d continuation(s); it may be incomplete", what, resp.Continuations)
+	s.AddEvent(taskID, agentName, "truncated", msg)
+	fmt.Printf("  %s⚠ %s%s\n", colorYellow, msg, colorReset)
+}
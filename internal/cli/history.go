@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Search past agent runs and reviews across all epics",
+	Long: `Lists agent runs and review verdicts from every epic, newest first.
+Filters combine; --verdict leaves only reviews.
+
+Examples:
+  hive history --agent claude --since 7d
+  hive history --verdict reject --since 2w
+  hive history --epic 3`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+var (
+	historyAgent   string
+	historyVerdict string
+	historySince   string
+	historyEpic    int64
+	historyLimit   int
+)
+
+func init() {
+	historyCmd.Flags().StringVarP(&historyAgent, "agent", "a", "", "Only runs and reviews by this agent")
+	historyCmd.Flags().StringVar(&historyVerdict, "verdict", "", "Only reviews with this verdict: approve, reject")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only the last period, e.g. 12h, 7d, 2w")
+	historyCmd.Flags().Int64Var(&historyEpic, "epic", 0, "Only this epic and its tasks")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 50, "Show at most this many entries (0 = all)")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	filter := store.HistoryFilter{Agent: historyAgent, EpicID: historyEpic, Limit: historyLimit}
+	switch historyVerdict {
+	case "", "approve", "reject":
+		filter.Verdict = historyVerdict
+	default:
+		return fmt.Errorf("invalid verdict %q (use approve or reject)", historyVerdict)
+	}
+	if historySince != "" {
+		d, err := parseSince(historySince)
+		if err != nil {
+			return err
+		}
+		filter.Since = time.Now().Add(-d)
+	}
+
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	entries, err := s.SearchHistory(filter)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No matching runs or reviews.")
+		return nil
+	}
+
+	for _, e := range entries {
+		what := colorCyan + "run    " + colorReset
+		if e.Kind == "review" {
+			if e.Verdict == "approve" {
+				what = colorGreen + "approve" + colorReset
+			} else {
+				what = colorRed + fmt.Sprintf("%-7s", e.Verdict) + colorReset
+			}
+		}
+		epic := ""
+		if e.EpicID != nil {
+			epic = fmt.Sprintf(" %s(epic #%d)%s", colorDim, *e.EpicID, colorReset)
+		}
+		fmt.Printf("  %s  %s  %-10s %s#%-4d%s %s%s\n",
+			e.Timestamp.Local().Format("2006-01-02 15:04"), what, e.Agent,
+			colorYellow, e.TaskID, colorReset, truncate(e.TaskTitle, 50), epic)
+		if line := firstContentLine(e.Content); line != "" {
+			fmt.Printf("      %s%s%s\n", colorDim, truncate(line, 100), colorReset)
+		}
+	}
+	if historyLimit > 0 && len(entries) == historyLimit {
+		fmt.Printf("\n%sShowing the latest %d; use --limit 0 for all.%s\n", colorDim, historyLimit, colorReset)
+	}
+	fmt.Printf("\nFull output: %shive log <task-id>%s\n", colorCyan, colorReset)
+	return nil
+}
+
+// parseSince parses a --since period: a number of days (7d) or weeks
+// (2w), or anything time.ParseDuration accepts (12h, 90m).
+func parseSince(v string) (time.Duration, error) {
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if n := len(v); n > 1 {
+		if u, ok := unit[v[n-1]]; ok {
+			if k, err := strconv.Atoi(v[:n-1]); err == nil && k > 0 {
+				return time.Duration(k) * u, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --since %q (e.g. 12h, 7d, 2w)", v)
+	}
+	return d, nil
+}
+
+// firstContentLine returns the first non-empty line of a run's output or
+// a review's comments, skipping role headers like "[tester]".
+func firstContentLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || (strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) {
+			continue
+		}
+		return line
+	}
+	return ""
+}
@@ -1,19 +1,36 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/langs"
 	"github.com/spf13/cobra"
 )
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize hive in the current directory",
-	Long:  "Creates a .hive/ directory with default config and database.",
-	RunE:  runInit,
+	Long: `Creates a .hive/ directory with a config and the database.
+
+Looks for agent CLIs on PATH (claude, gemini, codex) and API keys in the
+environment (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_API_KEY), and asks
+which to use for the pm, architect, coder and reviewer roles, suggesting
+a reviewer from another vendor than the coder. Without a terminal, or
+with --yes, the suggestions are taken as they are.`,
+	RunE: runInit,
+}
+
+var initYes bool
+
+func init() {
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Use the suggested agents without asking")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -25,18 +42,34 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("hive already initialized in this directory (.hive/ exists)")
 	}
 
+	// Pick agents before creating anything, so a Ctrl+C leaves no
+	// half-initialized .hive behind.
+	cfg := config.DefaultConfig()
+	cfg.Languages = langs.Detect(".")
+	interactive := !initYes && isTerminal(os.Stdin)
+	_, userErr := os.Stat(config.UserConfigPath())
+	hasUserConfig := userErr == nil
+	if !hasUserConfig || (interactive && confirm(fmt.Sprintf("Agents from %s apply here. Add project agents too?", config.UserConfigPath()))) {
+		cfg.Agents = initAgents(interactive)
+	}
+
 	// Create directories.
 	if err := os.MkdirAll(runsDir, 0755); err != nil {
 		return fmt.Errorf("create .hive/runs: %w", err)
 	}
 
-	// Write default config.
+	// Write the config.
 	cfgPath := filepath.Join(hiveDir, "config.yaml")
-	cfg := config.DefaultConfig()
 	if err := config.Save(cfgPath, cfg); err != nil {
 		return fmt.Errorf("write config: %w", err)
 	}
 
+	// The local overlay holds per-machine settings; keep it out of git.
+	ignore := filepath.Join(hiveDir, ".gitignore")
+	if err := os.WriteFile(ignore, []byte(config.LocalFile+"\n"), 0644); err != nil {
+		return fmt.Errorf("write .gitignore: %w", err)
+	}
+
 	// Create database by opening store (migration runs automatically).
 	dbPath := filepath.Join(hiveDir, "hive.db")
 	store, err := openStore(dbPath)
@@ -46,11 +79,163 @@ func runInit(cmd *cobra.Command, args []string) error {
 	store.Close()
 
 	fmt.Println("Initialized hive in .hive/")
+	if len(cfg.Languages) > 0 {
+		fmt.Printf("Detected: %s (agents get their test, format and lint commands; see languages in the config)\n", strings.Join(cfg.Languages, ", "))
+	}
 	fmt.Println("")
 	fmt.Println("Next steps:")
-	fmt.Println("  1. Edit .hive/config.yaml to add your agents")
+	switch {
+	case len(cfg.Agents) > 0:
+		fmt.Println("  1. Check the agents in .hive/config.yaml (hive config validate)")
+	case hasUserConfig:
+		fmt.Printf("  1. Agents from %s apply here; add project-specific ones to .hive/config.yaml\n", config.UserConfigPath())
+	default:
+		fmt.Println("  1. Edit .hive/config.yaml to add your agents")
+	}
 	fmt.Println("  2. Run: hive task \"your task description\"")
 	fmt.Println("  3. Run: hive board")
 
 	return nil
 }
+
+// agentOption is an agent hive init can set up: a CLI found on PATH or
+// an API whose key is in the environment.
+type agentOption struct {
+	label  string // How the wizard lists it
+	prefix string // Start of the agent's name, e.g. "claude" in "claude-coder"
+	vendor string // Who runs the model, to pick a reviewer from another one
+	agent  config.Agent
+}
+
+// detectAgents returns the agents available here, CLIs first, in order of
+// preference for coding.
+func detectAgents() []agentOption {
+	var opts []agentOption
+	for _, c := range []struct{ cmd, vendor string }{
+		{"claude", "anthropic"}, {"codex", "openai"}, {"gemini", "google"},
+	} {
+		if _, err := exec.LookPath(c.cmd); err == nil {
+			opts = append(opts, agentOption{
+				label: c.cmd + " CLI", prefix: c.cmd, vendor: c.vendor,
+				agent: config.Agent{Mode: "cli", Cmd: c.cmd},
+			})
+		}
+	}
+	for _, a := range []struct{ env, provider, model, prefix string }{
+		{"ANTHROPIC_API_KEY", "anthropic", "claude-sonnet-4-5", "claude-api"},
+		{"OPENAI_API_KEY", "openai", "gpt-4o", "gpt"},
+		{"GEMINI_API_KEY", "google", "gemini-2.5-pro", "gemini-api"},
+		{"GOOGLE_API_KEY", "google", "gemini-2.5-pro", "gemini-api"},
+	} {
+		if os.Getenv(a.env) == "" || hasVendorAPI(opts, a.provider) {
+			continue
+		}
+		opts = append(opts, agentOption{
+			label: fmt.Sprintf("%s API (%s, key from %s)", a.provider, a.model, a.env), prefix: a.prefix, vendor: a.provider,
+			agent: config.Agent{Mode: "api", Provider: a.provider, Model: a.model, APIKeyEnv: a.env},
+		})
+	}
+	return opts
+}
+
+func hasVendorAPI(opts []agentOption, provider string) bool {
+	for _, o := range opts {
+		if o.agent.Mode == "api" && o.vendor == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// initRoles are the roles hive init assigns, with their timeouts.
+var initRoles = []struct {
+	role    string
+	timeout int
+}{
+	{"pm", 300}, {"architect", 600}, {"coder", 900}, {"reviewer", 600},
+}
+
+// suggestAgents picks an option for each role: the first one codes and
+// plans, the reviewer comes from another vendor when there is one, and
+// the PM from a third, so no model only ever checks itself.
+func suggestAgents(opts []agentOption) map[string]int {
+	picks := map[string]int{"coder": 0, "architect": 0, "reviewer": 0, "pm": 0}
+	used := map[string]bool{opts[0].vendor: true}
+	for i, o := range opts {
+		if !used[o.vendor] {
+			picks["reviewer"] = i
+			used[o.vendor] = true
+			break
+		}
+	}
+	for i, o := range opts {
+		if !used[o.vendor] {
+			picks["pm"] = i
+			break
+		}
+	}
+	return picks
+}
+
+// initAgents finds the available agents and, interactively, lets the
+// user confirm or change the suggested one for each role. Returns the
+// agents for the config, or none when nothing was found.
+func initAgents(interactive bool) map[string]config.Agent {
+	opts := detectAgents()
+	if len(opts) == 0 {
+		fmt.Println("No agent CLIs (claude, gemini, codex) on PATH and no API keys in the environment;")
+		fmt.Println("add agents to .hive/config.yaml by hand.")
+		fmt.Println()
+		return map[string]config.Agent{}
+	}
+	picks := suggestAgents(opts)
+
+	if interactive {
+		fmt.Println("Found:")
+		for i, o := range opts {
+			fmt.Printf("  %d. %s\n", i+1, o.label)
+		}
+		fmt.Println()
+		in := bufio.NewReader(os.Stdin)
+		for _, r := range initRoles {
+			picks[r.role] = askAgent(in, r.role, opts, picks[r.role])
+		}
+		fmt.Println()
+	}
+
+	agents := make(map[string]config.Agent)
+	var lines []string
+	for _, r := range initRoles {
+		i := picks[r.role]
+		if i < 0 {
+			continue
+		}
+		a := opts[i].agent
+		a.Role, a.TimeoutSec = r.role, r.timeout
+		name := opts[i].prefix + "-" + r.role
+		agents[name] = a
+		lines = append(lines, fmt.Sprintf("  %-10s %-18s %s", r.role, name, opts[i].label))
+	}
+	fmt.Println("Agents:")
+	fmt.Println(strings.Join(lines, "\n"))
+	fmt.Println()
+	return agents
+}
+
+// askAgent asks which option plays a role. Enter keeps the suggestion
+// and "0" leaves the role out; returns the option's index, or -1.
+func askAgent(in *bufio.Reader, role string, opts []agentOption, suggested int) int {
+	for {
+		fmt.Printf("%s [%d, 0 for none]: ", role, suggested+1)
+		line, err := in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" || err != nil {
+			return suggested
+		}
+		n, convErr := strconv.Atoi(line)
+		if convErr == nil && n >= 0 && n <= len(opts) {
+			return n - 1
+		}
+		fmt.Printf("  pick 1-%d, or 0\n", len(opts))
+	}
+}
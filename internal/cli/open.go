@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	openDiff bool
+	openPR   bool
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open [id]",
+	Short: "Open a task's latest artifact, an epic's diff or its PR in external tools",
+	Long: `Opens what hive produced in the tools you already use.
+
+  hive open <task-id>         the task's latest artifact (agent output,
+                              review, patch) in $VISUAL or $EDITOR
+  hive open --diff <epic-id>  the epic's safety branch against its base in
+                              your git difftool (diff.tool), as one
+                              directory diff
+  hive open --pr <epic-id>    the pull request for the epic's branch in the
+                              browser, found with the GitHub CLI (gh)`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOpen,
+}
+
+func init() {
+	openCmd.Flags().BoolVar(&openDiff, "diff", false, "Open the epic's diff in git difftool")
+	openCmd.Flags().BoolVar(&openPR, "pr", false, "Open the pull request for the epic's branch")
+	openCmd.MarkFlagsMutuallyExclusive("diff", "pr")
+	rootCmd.AddCommand(openCmd)
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %s", args[0])
+	}
+	task, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("task #%d not found", id)
+	}
+
+	if openDiff || openPR {
+		if task.GitBranch == "" {
+			return fmt.Errorf("#%d has no safety branch", id)
+		}
+		workDir, _ := os.Getwd()
+		safety := git.New(workDir)
+		if openPR {
+			url, err := pullRequestURL(workDir, task.GitBranch)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Opening %s\n", url)
+			return openURL(url)
+		}
+		baseBranch, err := safety.BaseBranchFor(task.BaseBranch)
+		if err != nil {
+			return fmt.Errorf("detect base branch: %w", err)
+		}
+		return safety.DiffTool(baseBranch, task.GitBranch)
+	}
+
+	// The latest artifact that is text; attached images are skipped.
+	arts, err := s.GetArtifacts(id)
+	if err != nil {
+		return err
+	}
+	var path string
+	for i := len(arts) - 1; i >= 0 && path == ""; i-- {
+		if arts[i].Type != artifacts.TypeImage {
+			path = newArtifacts(s).Resolve(arts[i].FilePath)
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("#%d has no artifacts yet", id)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("artifact %s: %w", path, err)
+	}
+	return openInEditor(path)
+}
+
+// openInEditor opens path in $VISUAL or $EDITOR (vi without either) and
+// waits for it. The variable may carry arguments, e.g. "code --wait".
+func openInEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", fields[0], err)
+	}
+	return nil
+}
+
+// pullRequestURL asks the GitHub CLI for the URL of the pull request whose
+// head is branch.
+func pullRequestURL(workDir, branch string) (string, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", fmt.Errorf("finding the pull request needs the GitHub CLI (gh): https://cli.github.com")
+	}
+	cmd := exec.Command("gh", "pr", "view", branch, "--json", "url", "--jq", ".url")
+	cmd.Dir = workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("no pull request for %s: %s", branch, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// openURL opens url in the default browser.
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open browser: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/owners"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// loadOwners reads CODEOWNERS and the owners rules from config for the
+// project in the current directory.
+func loadOwners(cfg *config.Config) (*owners.Owners, error) {
+	extra := make([]owners.Rule, 0, len(cfg.Owners.Rules))
+	for _, r := range cfg.Owners.Rules {
+		extra = append(extra, owners.Rule{Pattern: r.Path, Owners: r.Owners})
+	}
+	workDir, _ := os.Getwd()
+	return owners.Load(workDir, cfg.Owners.File, extra)
+}
+
+// ownership is what the owners config says about a task, going by the
+// paths named in its title and description.
+type ownership struct {
+	areas []owners.Area
+	agent string   // Coder an owners route sends the task to
+	gates []string // Owners whose approval the task waits for
+}
+
+func taskOwnership(own *owners.Owners, cfg *config.Config, task *store.Task) ownership {
+	var o ownership
+	if own.Empty() {
+		return o
+	}
+	o.areas = own.Touched(owners.Mentions(task.Title + "\n" + task.Description))
+	for _, area := range o.areas {
+		route := cfg.Owners.Routes[area.Owner]
+		if route.Agent != "" && o.agent == "" {
+			if a, ok := cfg.Agents[route.Agent]; ok && a.Role == roles.Coder {
+				o.agent = route.Agent
+			}
+		}
+		if route.Gate {
+			o.gates = append(o.gates, area.Owner)
+		}
+	}
+	return o
+}
+
+// summary describes the owned areas, e.g. "@payments (internal/billing/)".
+func (o ownership) summary() string {
+	parts := make([]string, len(o.areas))
+	for i, area := range o.areas {
+		paths := append([]string(nil), area.Paths...)
+		sort.Strings(paths)
+		parts[i] = fmt.Sprintf("%s (%s)", area.Owner, strings.Join(paths, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// gateOwnedTask blocks a task touching gated owners' code until a human
+// approves it with hive answer. It reports whether the task is waiting.
+func gateOwnedTask(s *store.Store, task *store.Task, o ownership) bool {
+	if len(o.gates) == 0 || ownerApproved(s, task.ID) {
+		return false
+	}
+	s.AddEvent(task.ID, "owners", "owner_gate", "Needs approval from "+strings.Join(o.gates, ", "))
+	reason := fmt.Sprintf("Touches code owned by %s — approve with: hive answer %d \"approved\"", strings.Join(o.gates, ", "), task.ID)
+	s.BlockTask(task.ID, reason)
+	task.Status = store.StatusBlocked
+	task.BlockedReason = reason
+	return true
+}
+
+// ownerApproved reports whether the task's last owner gate was answered.
+func ownerApproved(s *store.Store, taskID int64) bool {
+	events, err := s.GetEvents(taskID)
+	if err != nil {
+		return false
+	}
+	gated := false
+	for _, e := range events {
+		switch e.Type {
+		case "owner_gate":
+			gated = true
+		case "unblocked":
+			if gated {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// taskCoder returns the coder for a task: the agent it is assigned to when
+// that is another coder (e.g. one an owners route picked), else the
+// default one.
+func taskCoder(cfg *config.Config, task *store.Task, coderName string, coderCfg config.Agent) (string, config.Agent) {
+	if task.AssignedAgent == "" || task.AssignedAgent == coderName {
+		return coderName, coderCfg
+	}
+	a, ok := cfg.Agents[task.AssignedAgent]
+	if !ok || a.Role != roles.Coder {
+		return coderName, coderCfg
+	}
+	forceAutoAccept(&a)
+	return task.AssignedAgent, a
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/perf"
+	"github.com/spf13/cobra"
+)
+
+var perfCmd = &cobra.Command{
+	Use:   "perf",
+	Short: "Manage the benchmark baseline used by the perf gate",
+}
+
+var perfBaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Run benchmarks and record the results as the new baseline",
+	Args:  cobra.NoArgs,
+	RunE:  runPerfBaseline,
+}
+
+var perfCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Run benchmarks and compare them to the baseline",
+	Args:  cobra.NoArgs,
+	RunE:  runPerfCheck,
+}
+
+func init() {
+	perfCmd.AddCommand(perfBaselineCmd)
+	perfCmd.AddCommand(perfCheckCmd)
+	rootCmd.AddCommand(perfCmd)
+}
+
+func loadPerfConfig() (*config.Config, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Perf.Cmd == "" {
+		return nil, fmt.Errorf("perf.cmd is not set in .hive/config.yaml")
+	}
+	return cfg, nil
+}
+
+// recordPerfBaseline runs the benchmark command and saves its output as
+// the baseline.
+func recordPerfBaseline(cfg *config.Config, workDir string) error {
+	out, err := perf.Run(cfg.Perf.Cmd, workDir, cfg.Perf.CmdTimeout())
+	if err != nil {
+		return err
+	}
+	if len(perf.Parse(out)) == 0 {
+		return fmt.Errorf("no benchmark results in output of %q", cfg.Perf.Cmd)
+	}
+	return perf.SaveBaseline(cfg.Perf.BaselinePath(), out)
+}
+
+func runPerfBaseline(cmd *cobra.Command, args []string) error {
+	cfg, err := loadPerfConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Running %s...\n", cfg.Perf.Cmd)
+	if err := recordPerfBaseline(cfg, "."); err != nil {
+		return err
+	}
+	fmt.Printf("%s✓ Baseline saved to %s%s\n", colorGreen, cfg.Perf.BaselinePath(), colorReset)
+	return nil
+}
+
+func runPerfCheck(cmd *cobra.Command, args []string) error {
+	cfg, err := loadPerfConfig()
+	if err != nil {
+		return err
+	}
+
+	baseline, err := perf.LoadBaseline(cfg.Perf.BaselinePath())
+	if err != nil {
+		return err
+	}
+	if baseline == nil {
+		return fmt.Errorf("no baseline at %s. Run: hive perf baseline", cfg.Perf.BaselinePath())
+	}
+
+	fmt.Printf("Running %s...\n", cfg.Perf.Cmd)
+	out, err := perf.Run(cfg.Perf.Cmd, ".", cfg.Perf.CmdTimeout())
+	if err != nil {
+		return err
+	}
+
+	regs := perf.Compare(baseline, perf.Parse(out), cfg.Perf.TolerancePct())
+	if len(regs) == 0 {
+		fmt.Printf("%s✓ No regressions beyond %.0f%%%s\n", colorGreen, cfg.Perf.TolerancePct(), colorReset)
+		return nil
+	}
+
+	fmt.Printf("%s✗ %d regression(s) beyond %.0f%%:%s\n", colorRed, len(regs), cfg.Perf.TolerancePct(), colorReset)
+	for _, r := range regs {
+		fmt.Printf("  %s\n", r)
+	}
+	return fmt.Errorf("benchmarks regressed")
+}
@@ -7,9 +7,9 @@ import (
 	"strconv"
 
 	"github.com/imkarma/hive/internal/agent"
-	"github.com/imkarma/hive/internal/config"
-	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -43,7 +43,7 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	}
 	defer s.Close()
 
-	cfg, err := config.Load(hivePath("config.yaml"))
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -91,7 +91,7 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	// Find PM agent.
 	agentName := planAgent
 	if agentName == "" {
-		pmAgents := cfg.AgentsByRole("pm")
+		pmAgents := cfg.AgentsByRole(roles.PM)
 		for name := range pmAgents {
 			agentName = name
 			break
@@ -107,8 +107,8 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build prompt.
-	ctxBuilder := agentctx.New(s)
-	prompt, err := ctxBuilder.BuildPrompt(task, "pm")
+	ctxBuilder := newContextBuilder(s, cfg)
+	prompt, err := ctxBuilder.BuildPrompt(task, roles.PM)
 	if err != nil {
 		return fmt.Errorf("build context: %w", err)
 	}
@@ -135,16 +135,15 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		Prompt:     prompt,
 		WorkDir:    workDir,
 		TimeoutSec: agentCfg.DefaultTimeout(),
+		Format:     agent.FormatPlan,
 	})
 	if err != nil {
 		return fmt.Errorf("PM agent failed: %w", err)
 	}
 
 	// Save output as artifact.
-	artifactPath := hivePath("runs", fmt.Sprintf("task-%d-plan.md", task.ID))
-	os.MkdirAll(hivePath("runs"), 0755)
-	os.WriteFile(artifactPath, []byte(resp.Output), 0644)
-	s.AddArtifact(task.ID, "plan", artifactPath)
+	newArtifacts(s).Save(task.ID, "plan", artifacts.Name(task.ID, "plan"), resp.Output)
+	warnTruncated(s, task.ID, agentName, "The plan", resp)
 
 	// Check for blocker.
 	if blocked := agent.ParseBlocked(resp.Output); blocked != "" {
@@ -168,8 +167,7 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	fmt.Printf("%sCreated %d tasks:%s\n\n", colorBold, len(subtasks), colorReset)
 
 	for _, sub := range subtasks {
-		parentID := task.ID
-		created, err := s.CreateTask(sub.Title, sub.Description, sub.Priority, &parentID)
+		created, err := createSubtask(s, task.ID, sub)
 		if err != nil {
 			fmt.Printf("  %s✗%s Failed to create: %s (%v)\n", colorRed, colorReset, sub.Title, err)
 			continue
@@ -180,6 +178,9 @@ func runPlan(cmd *cobra.Command, args []string) error {
 			fmt.Printf(" %s— %s%s", colorDim, sub.Description, colorReset)
 		}
 		fmt.Printf(" [%s]\n", sub.Priority)
+		for i, c := range sub.Acceptance {
+			fmt.Printf("      %s%s: %s%s\n", colorDim, agent.CriterionLabel(i), c, colorReset)
+		}
 	}
 
 	fmt.Printf("\nNext: %shive auto %d%s to run the full pipeline, or assign agents manually\n", colorCyan, task.ID, colorReset)
@@ -188,3 +189,19 @@ func runPlan(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// createSubtask adds a subtask the PM planned under parentID, with its
+// acceptance criteria.
+func createSubtask(s *store.Store, parentID int64, sub agent.ParsedSubtask) (*store.Task, error) {
+	created, err := s.CreateTask(sub.Title, sub.Description, sub.Priority, &parentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sub.Acceptance) > 0 {
+		if err := s.SetAcceptance(created.ID, sub.Acceptance); err != nil {
+			return nil, err
+		}
+		created, err = s.GetTask(created.ID)
+	}
+	return created, err
+}
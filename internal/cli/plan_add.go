@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var planAddCmd = &cobra.Command{
+	Use:   "add <epic-id> <requirement>",
+	Short: "Add a requirement to a planned epic and plan only the new tasks",
+	Long: `Runs the PM agent with the epic's existing tasks as context and adds
+only the subtasks the new requirement needs. Existing tasks, done or not,
+are left as they are. The requirement is added to the epic's description,
+so the agents working on its tasks see it too.
+
+Example:
+  hive plan add 3 "Also export the report as CSV"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runPlanAdd,
+}
+
+func init() {
+	planAddCmd.Flags().StringVarP(&planAgent, "agent", "a", "", "Override PM agent name")
+	planCmd.AddCommand(planAddCmd)
+}
+
+func runPlanAdd(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid epic ID: %s", args[0])
+	}
+	epic, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("epic #%d not found", id)
+	}
+	if epic.Kind != store.KindEpic {
+		return fmt.Errorf("#%d is a task, not an epic", id)
+	}
+	if epic.Status == store.StatusDone || epic.Status == store.StatusCancelled {
+		return fmt.Errorf("epic #%d is %s — create a new epic instead", id, epic.Status)
+	}
+	requirement := strings.TrimSpace(strings.Join(args[1:], " "))
+	if requirement == "" {
+		return fmt.Errorf("requirement is empty")
+	}
+
+	agentName, agentCfg := planAgent, config.Agent{}
+	if agentName == "" {
+		agentName, agentCfg = findAgentByRole(cfg, roles.PM)
+	} else {
+		var ok bool
+		if agentCfg, ok = cfg.Agents[agentName]; !ok {
+			return fmt.Errorf("agent %q not found in config", agentName)
+		}
+	}
+	if agentName == "" {
+		return fmt.Errorf("no PM agent configured. Add an agent with role: pm in .hive/config.yaml")
+	}
+	forceAutoAccept(&agentCfg)
+
+	runner, err := agent.NewRunner(agentName, agentCfg)
+	if err != nil {
+		return fmt.Errorf("create agent: %w", err)
+	}
+
+	existing, _ := s.ListTasksByEpic(epic.ID)
+	prompt := newContextBuilder(s, cfg).BuildPlanAddPrompt(epic, requirement)
+
+	fmt.Printf("Adding to the plan of epic #%d: %s\n", epic.ID, epic.Title)
+	fmt.Printf("  PM Agent: %s (%d existing tasks)\n\n", agentName, len(existing))
+
+	workDir, _ := os.Getwd()
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID:     epic.ID,
+		Prompt:     prompt,
+		WorkDir:    workDir,
+		TimeoutSec: agentCfg.DefaultTimeout(),
+		Format:     agent.FormatPlan,
+	})
+	if err != nil {
+		return fmt.Errorf("PM agent failed: %w", err)
+	}
+
+	// Keep the original plan: each addition gets its own artifact.
+	newArtifacts(s).Save(epic.ID, "plan", artifacts.Name(epic.ID, "plan", time.Now().Format("20060102-150405")), resp.Output)
+	warnTruncated(s, epic.ID, agentName, "The plan", resp)
+
+	// Work on the epic may be under way, so a question doesn't block it.
+	if blocked := agent.ParseBlocked(resp.Output); blocked != "" {
+		s.AddEvent(epic.ID, agentName, "comment", "Plan add blocked: "+blocked)
+		fmt.Printf("%s⚠  PM needs more detail:%s %s\n", colorRed+colorBold, colorReset, blocked)
+		fmt.Printf("   Nothing was added. Re-run with a more specific requirement.\n")
+		return nil
+	}
+
+	// The PM sometimes restates tasks it was told exist; skip those.
+	planned := map[string]int64{}
+	for _, t := range existing {
+		planned[normalizeTitle(t.Title)] = t.ID
+	}
+	var added []*store.Task
+	for _, sub := range agent.ParseSubtasks(resp.Output) {
+		if dup, ok := planned[normalizeTitle(sub.Title)]; ok {
+			fmt.Printf("  %s= %s (already planned as #%d)%s\n", colorDim, sub.Title, dup, colorReset)
+			continue
+		}
+		created, err := createSubtask(s, epic.ID, sub)
+		if err != nil {
+			fmt.Printf("  %s✗%s Failed to create: %s (%v)\n", colorRed, colorReset, sub.Title, err)
+			continue
+		}
+		planned[normalizeTitle(sub.Title)] = created.ID
+		added = append(added, created)
+		fmt.Printf("  %s+ #%d%s %s%s%s", colorGreen, created.ID, colorReset, priorityColor(sub.Priority), sub.Title, colorReset)
+		if sub.Description != "" {
+			fmt.Printf(" %s— %s%s", colorDim, sub.Description, colorReset)
+		}
+		fmt.Printf(" [%s]\n", sub.Priority)
+		for i, c := range sub.Acceptance {
+			fmt.Printf("      %s%s: %s%s\n", colorDim, agent.CriterionLabel(i), c, colorReset)
+		}
+	}
+
+	s.AppendDescription(epic.ID, "Added requirement: "+requirement)
+	s.AddEvent(epic.ID, agentName, "planned", fmt.Sprintf("Added %d tasks for: %s", len(added), requirement))
+
+	if len(added) == 0 {
+		fmt.Println("The existing plan already covers it — no tasks added. The requirement was added to the epic.")
+		return nil
+	}
+	fmt.Printf("\n%sAdded %d task(s).%s Next: %shive auto %d%s to run them\n",
+		colorBold, len(added), colorReset, colorCyan, epic.ID, colorReset)
+	return nil
+}
+
+// normalizeTitle folds case, punctuation and spacing, so restated task
+// titles match.
+func normalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), " ")
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
+)
+
+// preflightResult is the outcome of one epic accept pre-flight check.
+type preflightResult struct {
+	name   string
+	status string // "pass", "fail", or "skip"
+	detail string
+}
+
+// runPreflight runs the enabled pre-flight checks for merging the epic's
+// safety branch into baseBranch.
+func runPreflight(cfg *config.Config, safety *git.Safety, epic *store.Task, baseBranch, workDir string) []preflightResult {
+	var results []preflightResult
+	current, _ := safety.CurrentBranch()
+
+	if cfg.Accept.Enabled("clean") {
+		r := preflightResult{name: "clean", status: "pass", detail: "working tree clean"}
+		if safety.HasUncommittedChanges() {
+			if current == epic.GitBranch {
+				r.detail = "uncommitted changes will be committed to the epic"
+			} else {
+				r.status = "fail"
+				r.detail = fmt.Sprintf("uncommitted changes on %s — commit or stash them", current)
+			}
+		}
+		results = append(results, r)
+	}
+
+	if cfg.Accept.Enabled("tests") {
+		results = append(results, preflightTests(cfg, safety, epic, current, workDir))
+	}
+
+	if cfg.Accept.Enabled("conflicts") {
+		r := preflightResult{name: "conflicts", status: "pass", detail: "merges cleanly"}
+		files, err := safety.MergeConflicts(baseBranch, epic.GitBranch)
+		switch {
+		case err != nil:
+			r.status, r.detail = "skip", "could not check: "+err.Error()
+		case len(files) > 0:
+			r.status = "fail"
+			r.detail = fmt.Sprintf("this merge will conflict in %d file(s): %s", len(files), strings.Join(files, ", "))
+		}
+		results = append(results, r)
+	}
+
+	if cfg.Accept.Enabled("remote") {
+		r := preflightResult{name: "remote", status: "pass"}
+		var upstream string
+		var behind int
+		var err error
+		if !cfg.Offline {
+			upstream, behind, err = safety.BehindUpstream(baseBranch)
+		}
+		switch {
+		case cfg.Offline:
+			r.status, r.detail = "skip", "offline mode: not fetching"
+		case upstream == "":
+			r.status, r.detail = "skip", baseBranch+" has no upstream"
+		case err != nil:
+			r.status, r.detail = "skip", err.Error()
+		case behind > 0:
+			r.status = "fail"
+			r.detail = fmt.Sprintf("%s is %d commit(s) behind %s — pull first", baseBranch, behind, upstream)
+		default:
+			r.detail = "up to date with " + upstream
+		}
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// preflightTests runs testing.cmd on the safety branch. When the branch
+// isn't checked out it runs in a temporary worktree, so the user's working
+// tree is left alone.
+func preflightTests(cfg *config.Config, safety *git.Safety, epic *store.Task, current, workDir string) preflightResult {
+	r := preflightResult{name: "tests", status: "pass", detail: "passed on " + epic.GitBranch}
+	if cfg.Testing.Cmd == "" {
+		r.status, r.detail = "skip", "no testing.cmd configured"
+		return r
+	}
+
+	dir := workDir
+	if current != epic.GitBranch {
+		tmp, err := os.MkdirTemp("", "hive-preflight-")
+		if err != nil {
+			r.status, r.detail = "fail", err.Error()
+			return r
+		}
+		dir = filepath.Join(tmp, "wt")
+		if err := disk.Check(0); err != nil {
+			os.RemoveAll(tmp)
+			r.status, r.detail = "fail", err.Error()
+			return r
+		}
+		if err := safety.AddWorktree(dir, epic.GitBranch); err != nil {
+			os.RemoveAll(tmp)
+			r.status, r.detail = "fail", err.Error()
+			return r
+		}
+		defer func() {
+			safety.RemoveWorktree(dir)
+			os.RemoveAll(tmp)
+			safety.PruneWorktrees()
+		}()
+		if setup := setupCmdFor(cfg, epic); setup != "" {
+			fmt.Printf("  %sRunning %s...%s\n", colorDim, setup, colorReset)
+			if ok, output, _ := worker.RunSetup(setup, dir); !ok {
+				r.status = "fail"
+				r.detail = "setup failed:\n" + output
+				return r
+			}
+		}
+	}
+
+	fmt.Printf("  %sRunning %s...%s\n", colorDim, cfg.Testing.Cmd, colorReset)
+	// The whole suite, not just the packages left uncommitted, and no
+	// verify step: the suite covers it.
+	full := *cfg
+	full.Testing.PackageCmd = ""
+	full.Testing.Verify = ""
+	if f := worker.RunTestGate(&full, dir); f != nil {
+		r.status = "fail"
+		r.detail = "tests failed:\n" + f.Output
+	}
+	return r
+}
+
+// printPreflight prints the checklist and reports whether every check
+// passed or was skipped.
+func printPreflight(results []preflightResult) bool {
+	if len(results) == 0 {
+		return true
+	}
+
+	ok := true
+	fmt.Printf("  %sPre-flight:%s\n", colorBold, colorReset)
+	for _, r := range results {
+		mark := colorGreen + "✓"
+		switch r.status {
+		case "fail":
+			mark = colorRed + "✗"
+			ok = false
+		case "skip":
+			mark = colorDim + "–"
+		}
+		lines := strings.Split(strings.TrimRight(r.detail, "\n"), "\n")
+		fmt.Printf("    %s%s %-10s %s\n", mark, colorReset, r.name, lines[0])
+		// Only the tail of long output (test failures) is worth showing.
+		rest := lines[1:]
+		if len(rest) > 15 {
+			rest = rest[len(rest)-15:]
+		}
+		for _, l := range rest {
+			fmt.Printf("      %s%s%s\n", colorDim, l, colorReset)
+		}
+	}
+	fmt.Println()
+	return ok
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt [task-id]",
+	Short: "Print the prompt an agent would get for a task right now",
+	Long: `Builds the exact prompt hive would send for a task in the given role,
+with the current history, git diff and role template, and prints it
+without running anything. Use it to debug prompts and develop custom role
+templates.
+
+The role defaults to the task's role, then its assigned agent's role, then
+coder. Reviewer and after_code roles get the diff prompt, docs gets the
+epic docs prompt, exactly as in hive auto.
+
+The prompt goes to stdout and its size to stderr, so it can be piped:
+  hive prompt 4 --role reviewer | less`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPrompt,
+}
+
+var promptRole string
+
+func init() {
+	promptCmd.Flags().StringVarP(&promptRole, "role", "r", "", "Role to build the prompt for (coder, reviewer, pm, architect, tester, docs or a custom role)")
+	rootCmd.AddCommand(promptCmd)
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %s", args[0])
+	}
+	task, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("task #%d not found", id)
+	}
+
+	role := promptRole
+	if role == "" {
+		role = task.Role
+	}
+	if role == "" && task.AssignedAgent != "" {
+		role = cfg.Agents[task.AssignedAgent].Role
+	}
+	if role == "" {
+		role = roles.Coder
+	}
+	reg := roles.NewRegistry(cfg.Roles)
+	if _, ok := reg.Get(role); !ok {
+		return fmt.Errorf("unknown role %q (known: %v)", role, reg.Names())
+	}
+
+	workDir, _ := os.Getwd()
+	prompt, err := buildRolePrompt(s, cfg, reg, task, role, workDir)
+	if err != nil {
+		return fmt.Errorf("build prompt: %w", err)
+	}
+
+	fmt.Println(prompt)
+	fmt.Fprintf(os.Stderr, "%s— %s prompt for #%d: %d chars, ~%d tokens%s\n",
+		colorDim, role, task.ID, len(prompt), len(prompt)/4, colorReset)
+	return nil
+}
+
+// buildRolePrompt builds the prompt the pipeline would use for role on
+// task: the diff prompt for the reviewer and for roles that run after the
+// coder, the docs prompt for an epic's docs, the plain prompt otherwise.
+func buildRolePrompt(s *store.Store, cfg *config.Config, reg *roles.Registry, task *store.Task, role, workDir string) (string, error) {
+	b := newContextBuilder(s, cfg)
+	r, _ := reg.Get(role)
+
+	switch {
+	case role == roles.Reviewer:
+		return b.BuildReviewPrompt(task)
+	case role == roles.Docs:
+		epic := task
+		if task.Kind != store.KindEpic && task.ParentID != nil {
+			parent, err := s.GetTask(*task.ParentID)
+			if err != nil {
+				return "", err
+			}
+			epic = parent
+		}
+		subtasks, _ := s.ListTasksByEpic(epic.ID)
+		var diff string
+		safety := git.New(workDir)
+		if epic.GitBranch != "" && safety.IsGitRepo() {
+			if base, err := safety.BaseBranchFor(epic.BaseBranch); err == nil {
+				diff, _ = safety.Diff(base, epic.GitBranch)
+			}
+		}
+		return b.BuildDocsPrompt(epic, subtasks, diff, cfg.Docs.TargetPaths())
+	case role == roles.Tester && cfg.Testing.TesterStage() != string(roles.StageBeforeCode):
+		return b.BuildDiffPrompt(task, role)
+	case r.Stage == roles.StageAfterCode || r.Stage == roles.StageAfterReview:
+		return b.BuildDiffPrompt(task, role)
+	default:
+		return b.BuildPrompt(task, role)
+	}
+}
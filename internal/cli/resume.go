@@ -21,12 +21,27 @@ With a run ID, resumes that specific pipeline.
 Resuming will:
   1. Reset any tasks stuck in in_progress or review back to backlog
   2. Mark the interrupted pipeline run as ended
-  3. Re-run 'hive auto' on the same epic with the same settings (--skip-plan)`,
+  3. Re-run 'hive auto' on the same epic with the same settings, continuing
+     each unfinished task at the fix-loop iteration it was in
+
+--max-loops and --parallel override the stored settings. Existing tasks
+are never planned again; if planning never finished, it runs now.
+--replan also retries failed tasks and re-runs the architect on every
+unfinished task.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runResume,
 }
 
+var (
+	resumeMaxLoops int
+	resumeParallel int
+	resumeReplan   bool
+)
+
 func init() {
+	resumeCmd.Flags().IntVar(&resumeMaxLoops, "max-loops", 0, "Override the run's max fix-review iterations per task")
+	resumeCmd.Flags().IntVar(&resumeParallel, "parallel", 0, "Override the run's number of parallel tasks")
+	resumeCmd.Flags().BoolVar(&resumeReplan, "replan", false, "Retry failed tasks and re-run the architect on unfinished tasks")
 	rootCmd.AddCommand(resumeCmd)
 }
 
@@ -142,6 +157,13 @@ func resumeRun(s *store.Store, runID int64, cmd *cobra.Command) error {
 	if err != nil {
 		return fmt.Errorf("epic #%d not found: %w", target.EpicID, err)
 	}
+	// A run whose process still holds the lock isn't interrupted. The
+	// lock is let go again for hive auto to take below.
+	runLock, err := lockEpic(epic.ID)
+	if err != nil {
+		return err
+	}
+	runLock.Release()
 
 	fmt.Printf("%s╔══════════════════════════════════════╗%s\n", colorBold, colorReset)
 	fmt.Printf("%s║  hive resume — crash recovery        ║%s\n", colorBold, colorReset)
@@ -163,20 +185,64 @@ func resumeRun(s *store.Store, runID int64, cmd *cobra.Command) error {
 		fmt.Printf("  %s✓ No stale tasks to reset%s\n", colorGreen, colorReset)
 	}
 
+	// Tasks the run left mid-loop continue at their iteration.
+	checkpoints, _ := s.ListCheckpoints(target.ID)
+	midLoop := 0
+	for _, cp := range checkpoints {
+		if cp.Phase != store.PhaseDone {
+			midLoop++
+		}
+	}
+	if midLoop > 0 {
+		fmt.Printf("  %s↺ %d task(s) continue where their fix loop stopped%s\n", colorYellow, midLoop, colorReset)
+	}
+
 	// Step 2: Mark old run as interrupted.
 	if err := s.EndPipelineRun(target.ID, "interrupted"); err != nil {
 		return fmt.Errorf("end old run: %w", err)
 	}
 	fmt.Printf("  %s✓ Marked run #%d as interrupted%s\n\n", colorDim, target.ID, colorReset)
 
-	// Step 3: Re-run auto with same settings.
-	fmt.Printf("  Resuming with: max-loops=%d parallel=%d --skip-plan --skip-architect\n\n", target.MaxLoops, target.Parallel)
+	// Step 3: Optionally retry failed tasks.
+	if resumeReplan {
+		n, err := s.ResetFailedTasks(epic.ID)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			fmt.Printf("  %s↺ Reset %d failed task(s) back to backlog%s\n\n", colorYellow, n, colorReset)
+		}
+	}
 
-	// Set the global flags used by runAuto, then call it.
-	autoMaxLoops = target.MaxLoops
-	autoParallel = target.Parallel
-	autoSkipPlan = true
-	autoSkipArchitect = true
+	// Step 4: Re-run auto. Flags win over the stored settings, which
+	// fall back to auto's defaults for runs recorded without them.
+	autoMaxLoops = resumeSetting(resumeMaxLoops, target.MaxLoops, 3)
+	autoParallel = resumeSetting(resumeParallel, target.Parallel, 1)
+	autoSkipArchitect = !resumeReplan
+	autoWithArchitect = resumeReplan
+	autoResume = checkpoints
+
+	// Never force --skip-plan: auto already skips planning when the epic
+	// has tasks, and a run that died while planning has none to work on.
+	autoSkipPlan = false
+
+	mode := "--skip-architect"
+	if resumeReplan {
+		mode = "--replan"
+	}
+	fmt.Printf("  Resuming with: max-loops=%d parallel=%d %s\n\n", autoMaxLoops, autoParallel, mode)
 
 	return runAuto(cmd, []string{strconv.FormatInt(epic.ID, 10)})
 }
+
+// resumeSetting picks the flag value if given, else the stored run value,
+// else the default.
+func resumeSetting(flag, stored, def int) int {
+	if flag > 0 {
+		return flag
+	}
+	if stored > 0 {
+		return stored
+	}
+	return def
+}
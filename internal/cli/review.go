@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/imkarma/hive/internal/agent"
-	"github.com/imkarma/hive/internal/config"
-	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 	"github.com/spf13/cobra"
 )
 
@@ -25,10 +28,25 @@ If APPROVE, the task is marked as done.`,
 	RunE: runReview,
 }
 
-var reviewAgent string
+var reviewApplyCmd = &cobra.Command{
+	Use:   "apply [task-id]",
+	Short: "Apply the patch the reviewer suggested for a task",
+	Long: `Applies the latest patch suggestion (a diff block in the reviewer's
+output) to the working tree, so a small fix doesn't need another coder run.
+Run hive review again afterwards.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReviewApply,
+}
+
+var (
+	reviewAgent       string
+	reviewApplyDryRun bool
+)
 
 func init() {
 	reviewCmd.Flags().StringVarP(&reviewAgent, "agent", "a", "", "Override reviewer agent name")
+	reviewApplyCmd.Flags().BoolVar(&reviewApplyDryRun, "dry-run", false, "Show the patch and check that it applies, without changing files")
+	reviewCmd.AddCommand(reviewApplyCmd)
 	rootCmd.AddCommand(reviewCmd)
 }
 
@@ -39,7 +57,7 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 	defer s.Close()
 
-	cfg, err := config.Load(hivePath("config.yaml"))
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -57,7 +75,7 @@ func runReview(cmd *cobra.Command, args []string) error {
 	// Find reviewer agent.
 	agentName := reviewAgent
 	if agentName == "" {
-		reviewers := cfg.AgentsByRole("reviewer")
+		reviewers := cfg.AgentsByRole(roles.Reviewer)
 		for name := range reviewers {
 			agentName = name
 			break
@@ -73,7 +91,7 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build review context with git diff.
-	ctxBuilder := agentctx.New(s)
+	ctxBuilder := newContextBuilder(s, cfg)
 	prompt, err := ctxBuilder.BuildReviewPrompt(task)
 	if err != nil {
 		return fmt.Errorf("build review context: %w", err)
@@ -92,16 +110,20 @@ func runReview(cmd *cobra.Command, args []string) error {
 
 	// Move task to review status.
 	s.UpdateTaskStatus(task.ID, store.StatusReview)
+	defer startHeartbeat(s, task.ID)()
 
 	fmt.Printf("Reviewing task #%d: %s\n", task.ID, task.Title)
 	fmt.Printf("  Reviewer: %s\n\n", agentName)
 
 	// Run reviewer.
+	msgs, _ := ctxBuilder.BuildReviewMessages(task)
 	resp, err := runner.Run(context.Background(), agent.Request{
 		TaskID:     task.ID,
 		Prompt:     prompt,
+		Messages:   msgs,
 		WorkDir:    workDir,
 		TimeoutSec: agentCfg.DefaultTimeout(),
+		Format:     agent.FormatReview,
 	})
 	if err != nil {
 		s.UpdateTaskStatus(task.ID, store.StatusFailed)
@@ -109,13 +131,11 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 
 	// Save output as artifact.
-	artifactPath := hivePath("runs", fmt.Sprintf("task-%d-review.md", task.ID))
-	os.MkdirAll(hivePath("runs"), 0755)
-	os.WriteFile(artifactPath, []byte(resp.Output), 0644)
-	s.AddArtifact(task.ID, "review", artifactPath)
+	newArtifacts(s).Save(task.ID, "review", artifacts.Name(task.ID, "review"), resp.Output)
 
 	// Parse review verdict.
-	review := agent.ParseReview(resp.Output)
+	review := agent.CheckAcceptance(agent.ParseReviewWithChecklist(resp.Output, cfg.Review.Checklist), resp.Output, task.Acceptance)
+	suggestions := worker.SaveSuggestions(newArtifacts(s), task.ID, review, "review")
 
 	switch review.Verdict {
 	case "APPROVE":
@@ -141,6 +161,10 @@ func runReview(cmd *cobra.Command, args []string) error {
 			}
 		}
 		fmt.Printf("\nTask #%d moved back to backlog.\n", task.ID)
+		if suggestions != "" {
+			fmt.Printf("The reviewer suggested a patch (%d line(s)). Apply it: %shive review apply %d%s\n",
+				agent.PatchSize(worker.JoinPatches(review.Suggestions)), colorCyan, task.ID, colorReset)
+		}
 		fmt.Printf("Fix and re-run: %shive run %d && hive review %d%s\n", colorCyan, task.ID, task.ID, colorReset)
 
 	default:
@@ -152,3 +176,68 @@ func runReview(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runReviewApply(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %s", args[0])
+	}
+	task, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("task #%d not found", id)
+	}
+
+	arts, err := s.GetArtifacts(task.ID)
+	if err != nil {
+		return err
+	}
+	var latest *store.Artifact
+	for i := range arts {
+		if arts[i].Type == "suggestion" {
+			latest = &arts[i]
+		}
+	}
+	if latest == nil {
+		return fmt.Errorf("no patch suggestions for task #%d", task.ID)
+	}
+	data, err := os.ReadFile(newArtifacts(s).Resolve(latest.FilePath))
+	if err != nil {
+		return fmt.Errorf("read suggestion: %w", err)
+	}
+	patch := string(data)
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return fmt.Errorf("not a git repository")
+	}
+
+	files := agent.PatchFiles(patch)
+	fmt.Printf("Suggested patch for task #%d (%s, %d line(s)):\n", task.ID, latest.Timestamp.Local().Format("2006-01-02 15:04"), agent.PatchSize(patch))
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+
+	if err := safety.ApplyPatch(patch, true); err != nil {
+		return fmt.Errorf("patch doesn't apply cleanly: %w", err)
+	}
+	if reviewApplyDryRun {
+		fmt.Printf("\n%s\n%s✓ Applies cleanly%s (dry run, nothing changed)\n", patch, colorGreen, colorReset)
+		return nil
+	}
+	if err := safety.ApplyPatch(patch, false); err != nil {
+		return err
+	}
+
+	s.AddEvent(task.ID, "user", "suggestion_applied",
+		fmt.Sprintf("Applied reviewer patch to %s", strings.Join(files, ", ")))
+	fmt.Printf("\n%s✓ Applied%s\n", colorGreen, colorReset)
+	fmt.Printf("Re-review: %shive review %d%s\n", colorCyan, task.ID, colorReset)
+	return nil
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/spf13/cobra"
+)
+
+var reviewDiffCmd = &cobra.Command{
+	Use:   "review-diff",
+	Short: "Review an arbitrary diff with the configured reviewer",
+	Long: `Runs the reviewer agent on a diff outside the task pipeline and prints
+its verdict, issues, checklist answers and any suggested patch. No task is
+created or changed.
+
+By default the uncommitted changes in the working tree are reviewed.
+
+Examples:
+  hive review-diff
+  hive review-diff --staged
+  hive review-diff --range main..feature
+  hive review-diff --patch fix.diff
+  git diff HEAD~2 | hive review-diff --patch -`,
+	Args: cobra.NoArgs,
+	RunE: runReviewDiff,
+}
+
+var (
+	reviewDiffStaged bool
+	reviewDiffRange  string
+	reviewDiffPatch  string
+	reviewDiffAgent  string
+)
+
+func init() {
+	reviewDiffCmd.Flags().BoolVar(&reviewDiffStaged, "staged", false, "Review the staged changes")
+	reviewDiffCmd.Flags().StringVar(&reviewDiffRange, "range", "", "Review a revision range, e.g. main..feature")
+	reviewDiffCmd.Flags().StringVar(&reviewDiffPatch, "patch", "", "Review a diff file (- for stdin)")
+	reviewDiffCmd.Flags().StringVarP(&reviewDiffAgent, "agent", "a", "", "Override reviewer agent name")
+	rootCmd.AddCommand(reviewDiffCmd)
+}
+
+func runReviewDiff(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	diff, source, err := reviewDiffInput()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return fmt.Errorf("nothing to review: %s is empty", source)
+	}
+
+	agentName, agentCfg := reviewDiffAgent, config.Agent{}
+	if agentName == "" {
+		agentName, agentCfg = findAgentByRole(cfg, roles.Reviewer)
+	} else {
+		var ok bool
+		if agentCfg, ok = cfg.Agents[agentName]; !ok {
+			return fmt.Errorf("agent %q not found in config", agentName)
+		}
+	}
+	if agentName == "" {
+		return fmt.Errorf("no reviewer agent configured. Add an agent with role: reviewer in .hive/config.yaml")
+	}
+	forceAutoAccept(&agentCfg)
+
+	runner, err := agent.NewRunner(agentName, agentCfg)
+	if err != nil {
+		return fmt.Errorf("create agent: %w", err)
+	}
+
+	prompt := newContextBuilder(nil, cfg).BuildStandaloneReviewPrompt(diff, "")
+	workDir, _ := os.Getwd()
+
+	fmt.Printf("Reviewing %s (%d file(s), %d line(s))\n", source, len(agent.PatchFiles(diff)), agent.PatchSize(diff))
+	fmt.Printf("  Reviewer: %s\n\n", agentName)
+
+	resp, err := runner.Run(context.Background(), agent.Request{
+		Prompt:     prompt,
+		WorkDir:    workDir,
+		TimeoutSec: agentCfg.DefaultTimeout(),
+		Format:     agent.FormatReview,
+	})
+	if err != nil {
+		return fmt.Errorf("reviewer failed: %w", err)
+	}
+
+	review := agent.ParseReviewWithChecklist(resp.Output, cfg.Review.Checklist)
+	printFindings(review, resp.Output)
+	return nil
+}
+
+// reviewDiffInput returns the diff selected by the flags and a short
+// description of where it came from.
+func reviewDiffInput() (diff, source string, err error) {
+	set := 0
+	for _, on := range []bool{reviewDiffStaged, reviewDiffRange != "", reviewDiffPatch != ""} {
+		if on {
+			set++
+		}
+	}
+	if set > 1 {
+		return "", "", fmt.Errorf("use only one of --staged, --range and --patch")
+	}
+
+	if reviewDiffPatch != "" {
+		var data []byte
+		if reviewDiffPatch == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(reviewDiffPatch)
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("read patch: %w", err)
+		}
+		source = reviewDiffPatch
+		if source == "-" {
+			source = "stdin"
+		}
+		return string(data), source, nil
+	}
+
+	workDir, _ := os.Getwd()
+	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return "", "", fmt.Errorf("not a git repository (use --patch to review a diff file)")
+	}
+	switch {
+	case reviewDiffStaged:
+		diff, err = safety.StagedDiff()
+		return diff, "staged changes", err
+	case reviewDiffRange != "":
+		diff, err = safety.RangeDiff(reviewDiffRange)
+		return diff, reviewDiffRange, err
+	default:
+		diff, err = safety.WorkingDiff()
+		return diff, "uncommitted changes", err
+	}
+}
+
+// printFindings prints a parsed review: verdict, issues, checklist and
+// suggested patches. Without a verdict the raw output is shown.
+func printFindings(review agent.ParsedReview, output string) {
+	switch review.Verdict {
+	case "APPROVE":
+		fmt.Printf("%s✓ APPROVED%s\n", colorGreen+colorBold, colorReset)
+	case "REJECT":
+		fmt.Printf("%s✗ REJECTED%s\n", colorRed+colorBold, colorReset)
+	default:
+		fmt.Println("Reviewer didn't return a clear verdict.")
+		fmt.Println("\nRaw output:")
+		fmt.Println(output)
+		return
+	}
+
+	if len(review.Comments) > 0 {
+		fmt.Println("\nFindings:")
+		bullet := colorGreen
+		if review.Verdict == "REJECT" {
+			bullet = colorRed
+		}
+		for _, c := range review.Comments {
+			fmt.Printf("  %s•%s %s\n", bullet, colorReset, c)
+		}
+	}
+
+	if len(review.Checklist) > 0 {
+		fmt.Println("\nChecklist:")
+		for _, a := range review.Checklist {
+			answer, color := a.Answer, colorGreen
+			switch answer {
+			case "":
+				answer, color = "unanswered", colorYellow
+			case "FAIL":
+				color = colorRed
+			case "N/A":
+				color = colorDim
+			}
+			line := fmt.Sprintf("  %s%-10s%s %s", color, answer, colorReset, a.Item)
+			if a.Note != "" {
+				line += colorDim + " — " + a.Note + colorReset
+			}
+			fmt.Println(line)
+		}
+	}
+
+	for _, patch := range review.Suggestions {
+		fmt.Printf("\nSuggested patch (%d line(s)):\n%s\n", agent.PatchSize(patch), strings.TrimRight(patch, "\n"))
+	}
+}
@@ -8,6 +8,13 @@ var rootCmd = &cobra.Command{
 	Use:   "hive",
 	Short: "Kanban for AI agents",
 	Long:  "hive — a CLI tool that gives developers a kanban board for AI agents.\nYou are the PM. Agents are your workers.",
+
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := startChaos(); err != nil {
+			return err
+		}
+		return startCassette(cmd, args)
+	},
 }
 
 // Execute runs the root command.
@@ -15,7 +22,21 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// configProfile is the --profile flag: the config overlay to apply on top
+// of .hive/config.yaml.
+var configProfile string
+
+// offlineFlag is the --offline flag (see config offline).
+var offlineFlag bool
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", "", "Config profile: merge .hive/config.<profile>.yaml over config.yaml (default $HIVE_PROFILE)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false, "Forbid network calls: only CLI agents, no notifications or trace export (default $HIVE_OFFLINE)")
+	rootCmd.PersistentFlags().BoolVar(&recordFlag, "record", false, "Record every agent prompt, answer and edit to .hive/cassettes")
+	rootCmd.PersistentFlags().StringVar(&replayFlag, "replay", "", "Answer agent calls from a recorded cassette instead of running the agents")
+	rootCmd.PersistentFlags().StringVar(&chaosFlag, "chaos", "", "Inject failures at random, e.g. agent_timeout:0.1,merge_conflict:0.05 (default $HIVE_CHAOS)")
+	rootCmd.PersistentFlags().MarkHidden("chaos")
+
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(taskCmd)
 	rootCmd.AddCommand(boardCmd)
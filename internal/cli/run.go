@@ -8,8 +8,9 @@ import (
 	"strings"
 
 	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/config"
-	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -32,7 +33,8 @@ var (
 
 func init() {
 	runCmd.Flags().StringVarP(&runAgent, "agent", "a", "", "Override which agent to use")
-	runCmd.Flags().BoolVar(&runDry, "dry", false, "Show the prompt that would be sent without executing")
+	runCmd.Flags().BoolVar(&runDry, "dry", false, "Show the prompt that would be sent without executing (hive prompt does this for any role)")
+	runCmd.Flags().BoolVar(&streamAgents, "stream", false, "Show the agent's output live as it writes it")
 
 	rootCmd.AddCommand(runCmd)
 }
@@ -45,7 +47,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	defer s.Close()
 
 	// Load config.
-	cfg, err := config.Load(hivePath("config.yaml"))
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -92,7 +94,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build context/prompt.
-	ctxBuilder := agentctx.New(s)
+	ctxBuilder := newContextBuilder(s, cfg)
 	prompt, err := ctxBuilder.BuildPrompt(task, role)
 	if err != nil {
 		return fmt.Errorf("build context: %w", err)
@@ -119,6 +121,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if err := s.UpdateTaskStatus(task.ID, store.StatusInProgress); err != nil {
 		return fmt.Errorf("update task status: %w", err)
 	}
+	defer startHeartbeat(s, task.ID)()
 
 	// Get working directory.
 	workDir, err := os.Getwd()
@@ -132,11 +135,20 @@ func runRun(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Timeout: %ds\n\n", agentCfg.DefaultTimeout())
 
 	// Execute.
+	msgs, _ := ctxBuilder.BuildMessages(task, role)
 	req := agent.Request{
 		TaskID:     task.ID,
 		Prompt:     prompt,
+		Messages:   msgs,
 		WorkDir:    workDir,
 		TimeoutSec: agentCfg.DefaultTimeout(),
+		Stream:     newLiveOutput(task.ID, agentName, "").stream(),
+	}
+	if role == roles.Reviewer {
+		req.Format = agent.FormatReview
+	}
+	if req.Stream != nil {
+		fmt.Printf("--- Agent Output (live) ---\n\n")
 	}
 
 	resp, err := runner.Run(context.Background(), req)
@@ -147,25 +159,21 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Log the output as an event.
-	outputPreview := resp.Output
-	if len(outputPreview) > 500 {
-		outputPreview = outputPreview[:500] + "... (truncated)"
-	}
-	s.AddEvent(task.ID, agentName, "agent_output", outputPreview)
+	s.AddEvent(task.ID, agentName, "agent_output", cfg.History.Preview(resp.Output))
 
 	// Save full output as artifact.
-	artifactPath := hivePath("runs", fmt.Sprintf("task-%d-%s-output.md", task.ID, agentName))
-	os.MkdirAll(hivePath("runs"), 0755)
-	if err := os.WriteFile(artifactPath, []byte(resp.Output), 0644); err != nil {
+	if _, err := newArtifacts(s).Save(task.ID, "output", artifacts.Name(task.ID, agentName, "output"), resp.Output); err != nil {
 		fmt.Printf("Warning: could not save artifact: %v\n", err)
-	} else {
-		s.AddArtifact(task.ID, "output", artifactPath)
 	}
 
-	// Display result.
-	fmt.Printf("--- Agent Output (%.1fs, exit code: %d) ---\n\n", resp.Duration, resp.ExitCode)
-	fmt.Println(resp.Output)
-	fmt.Println()
+	// Display result, unless it was shown as it came.
+	if req.Stream != nil {
+		fmt.Printf("\n--- Done (%.1fs, exit code: %d) ---\n\n", resp.Duration, resp.ExitCode)
+	} else {
+		fmt.Printf("--- Agent Output (%.1fs, exit code: %d) ---\n\n", resp.Duration, resp.ExitCode)
+		fmt.Println(resp.Output)
+		fmt.Println()
+	}
 
 	// Check for BLOCKED pattern in output.
 	if blocked := agent.ParseBlocked(resp.Output); blocked != "" {
@@ -184,8 +192,8 @@ func runRun(cmd *cobra.Command, args []string) error {
 		}
 	} else {
 		// If this is a reviewer, check verdict.
-		if role == "reviewer" {
-			review := agent.ParseReview(resp.Output)
+		if role == roles.Reviewer {
+			review := agent.CheckAcceptance(agent.ParseReviewWithChecklist(resp.Output, cfg.Review.Checklist), resp.Output, task.Acceptance)
 			switch review.Verdict {
 			case "REJECT":
 				s.AddReview(task.ID, agentName, "reject", resp.Output)
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/metrics"
+	"github.com/imkarma/hive/internal/serve"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var serveAddr string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the board over a local REST API",
+	Long: `Serves the board as JSON over HTTP, for dashboards and integrations:
+
+  GET  /epics                       epics with task counts per status (?status=)
+  POST /epics                       create an epic {"title", "description", "priority"}
+  GET  /epics/{id}                  an epic and its tasks
+  GET  /tasks                       tasks (?status=, ?epic=)
+  POST /tasks                       create a task {"title", "description", "priority", "epic_id"}
+  GET  /tasks/{id}                  a task or epic and its events
+  POST /tasks/{id}/answer           answer a blocker {"answer"}; "skip" cancels the task
+  POST /tasks/{id}/run              start hive auto on it in the background
+  GET  /tasks/{id}/artifacts        its artifacts
+  GET  /tasks/{id}/artifacts/{ref}  an artifact's content, by ID or file name
+  GET  /tasks/{id}/diff             an epic's total diff, as hive epic diff
+  POST /tasks/{id}/accept           merge an epic, as hive epic accept (no --force)
+  POST /tasks/{id}/reject           discard an epic's work {"reason"}, as hive epic reject
+  GET  /config/{key}                a config value, e.g. agents.claude.timeout_sec
+  PUT  /config/{key}                set a config value {"value"}, as hive config set
+  GET  /metrics                     Prometheus metrics: task counts per status
+
+Without serve.tokens in the config only requests from localhost are
+answered. With tokens, every request needs "Authorization: Bearer <token>"
+and the token's role decides what it may do (read, operator or admin;
+only admin may read or change the config).
+
+Requests a web page could have sent are refused: without tokens, those
+addressed to any host but localhost or --addr; always, those with another
+site's Origin, and POSTs and PUTs without "Content-Type: application/json".
+Artifacts are served as plain text or markdown, never as HTML.
+
+Example:
+  hive serve --addr 127.0.0.1:7070
+  curl localhost:7070/epics
+  curl -X POST -H 'Content-Type: application/json' localhost:7070/tasks/4/run`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7070", "Address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	auth, err := serve.NewAuthorizer(cfg.Serve)
+	if err != nil {
+		return err
+	}
+	rt := serve.NewRouter(auth)
+	workDir, _ := os.Getwd()
+	serve.NewAPI(s, newArtifacts(s), startPipeline(s)).
+		WithEpics(epicActions{s: s, cfg: cfg, workDir: workDir}).
+		WithConfig(hivePath("config.yaml"), activeProfile()).
+		Register(rt)
+	rt.Handle("GET /metrics", serve.RoleRead, metrics.Default.Handler(s))
+
+	ln, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	rt.AllowHost(serveAddr)
+	rt.AllowHost(ln.Addr().String())
+	srv := &http.Server{Handler: rt, ReadHeaderTimeout: 10 * time.Second}
+
+	fmt.Printf("%shive serve%s on http://%s\n", colorBold, colorReset, ln.Addr())
+	if auth.Open() {
+		fmt.Printf("%sNo serve.tokens configured: answering localhost only.%s\n", colorDim, colorReset)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// startPipeline starts hive auto on a task or epic in the background, as
+// the TUI does, with its output in .hive/runs/api-auto-<id>.log. A task
+// continues its pipeline; an epic is planned first if it has no tasks.
+func startPipeline(s *store.Store) serve.Starter {
+	return func(taskID int64) (string, error) {
+		exe, err := os.Executable()
+		if err != nil {
+			return "", err
+		}
+		task, err := s.GetTask(taskID)
+		if err != nil {
+			return "", err
+		}
+		logPath := hivePath("runs", fmt.Sprintf("api-auto-%d.log", taskID))
+		if err := os.MkdirAll(hivePath("runs"), 0755); err != nil {
+			return "", err
+		}
+		out, err := os.Create(logPath)
+		if err != nil {
+			return "", err
+		}
+
+		args := []string{"auto", strconv.FormatInt(taskID, 10), "--stream", "--yes"}
+		if task.Kind != store.KindEpic {
+			args = append(args, "--skip-plan")
+		}
+		c := exec.Command(exe, args...)
+		c.Stdout = out
+		c.Stderr = out
+		if err := c.Start(); err != nil {
+			out.Close()
+			return "", err
+		}
+		go func() {
+			c.Wait()
+			out.Close()
+		}()
+		return logPath, nil
+	}
+}
+
+// epicActions reviews epics for the API with the same git and store steps
+// as hive epic diff, accept and reject.
+type epicActions struct {
+	s       *store.Store
+	cfg     *config.Config
+	workDir string
+}
+
+func (e epicActions) Diff(epic *store.Task) (string, error) {
+	safety := git.New(e.workDir)
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
+	if err != nil {
+		return "", fmt.Errorf("detect base branch: %w", err)
+	}
+	return safety.Diff(baseBranch, epic.GitBranch)
+}
+
+// Accept runs the pre-flight checks and merges the epic. A failed check
+// refuses the merge; the API has no --force.
+func (e epicActions) Accept(epic *store.Task, by string) error {
+	safety := git.New(e.workDir)
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
+	if err != nil {
+		return fmt.Errorf("detect base branch: %w", err)
+	}
+	stat, err := safety.DiffStat(baseBranch, epic.GitBranch)
+	if err != nil {
+		return fmt.Errorf("diff stat: %w", err)
+	}
+	if stat == "" {
+		return fmt.Errorf("%w: no changes to merge", serve.ErrRefused)
+	}
+	for _, r := range runPreflight(e.cfg, safety, epic, baseBranch, e.workDir) {
+		if r.status == "fail" {
+			return fmt.Errorf("%w: pre-flight check %s failed: %s", serve.ErrRefused, r.name, r.detail)
+		}
+	}
+	commits, _ := safety.LogCommits(baseBranch, epic.GitBranch)
+	return mergeEpic(e.s, safety, epic, baseBranch, commits, stat, by)
+}
+
+func (e epicActions) Reject(epic *store.Task, by, reason string) error {
+	safety := git.New(e.workDir)
+	baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
+	if err != nil {
+		return fmt.Errorf("detect base branch: %w", err)
+	}
+	return discardEpic(e.s, safety, epic, baseBranch, by, reason)
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/simulate"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// sandboxSkip are the .hive entries not copied into a simulation sandbox:
+// run output and scratch state that belong to the real project.
+var sandboxSkip = map[string]bool{"runs": true, "cassettes": true, "worktrees": true}
+
+// enterSimulation loads the scenario and moves the process into a
+// sandbox: a scratch clone of the repo (or an empty directory outside
+// one) with a copy of .hive, so the simulated run can't touch the real
+// board, branches or files. The returned function moves back and
+// removes the sandbox.
+func enterSimulation(scenarioPath string) (*simulate.Scenario, func(), error) {
+	sc, err := simulate.Load(scenarioPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(hivePath("hive.db")); err != nil {
+		return nil, nil, fmt.Errorf("hive not initialized. Run: hive init")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	sandbox, err := os.MkdirTemp("", "hive-simulate-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create sandbox: %w", err)
+	}
+	cleanup := func() {
+		os.Chdir(wd)
+		os.RemoveAll(sandbox)
+	}
+
+	if repo := git.New(wd); repo.IsGitRepo() {
+		// Clone into a subdirectory: git wants to create the target.
+		sandbox = filepath.Join(sandbox, "repo")
+		if err := repo.CloneTo(sandbox); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	if err := copyHiveDir(filepath.Join(wd, hiveDirName), filepath.Join(sandbox, hiveDirName)); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("copy .hive: %w", err)
+	}
+	if err := os.Chdir(sandbox); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if err := addSimulatedAgents(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	// Nothing simulated should reach Slack, webhooks or a trace collector.
+	offlineFlag = true
+	return sc, cleanup, nil
+}
+
+// startSimulation makes every agent answer from the scenario.
+func startSimulation(s *store.Store, sc *simulate.Scenario) {
+	agent.SetSimulation(sc.Agents(func(taskID int64) string {
+		if t, err := s.GetTask(taskID); err == nil {
+			return t.Title
+		}
+		return ""
+	}))
+}
+
+// addSimulatedAgents gives the sandbox's config an agent for each core
+// role it has none for, so the whole pipeline runs even on a fresh config.
+func addSimulatedAgents() error {
+	merged, err := config.LoadProfile(hivePath("config.yaml"), activeProfile())
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	base, err := config.Load(hivePath("config.yaml"))
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	added := false
+	for _, role := range []string{roles.PM, roles.Coder, roles.Reviewer} {
+		if name, _ := findAgentByRole(merged, role); name != "" {
+			continue
+		}
+		if base.Agents == nil {
+			base.Agents = map[string]config.Agent{}
+		}
+		base.Agents["simulated-"+role] = config.Agent{Role: role, Mode: "fake"}
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	return config.Save(hivePath("config.yaml"), base)
+}
+
+// copyHiveDir copies .hive into the sandbox, leaving out sandboxSkip.
+func copyHiveDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() && sandboxSkip[rel] {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// userStash is a stash of the user's uncommitted changes that hive made
+// before switching to a safety branch.
+type userStash struct {
+	id     int64  // Row in the stashes table
+	ref    string // Stash commit hash
+	branch string // Branch to restore the changes on
+}
+
+// stashUserChanges stashes uncommitted changes so they stay out of the
+// safety branch. The stash is recorded in the store first thing, so it can
+// still be found if hive dies before restoring it. Returns nil when the
+// working tree was clean.
+func stashUserChanges(s *store.Store, safety *git.Safety, task *store.Task) (*userStash, error) {
+	if !safety.HasUncommittedChanges() {
+		return nil, nil
+	}
+
+	branch, err := safety.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	ref, err := safety.Stash(fmt.Sprintf("hive: auto-stash for #%d", task.ID))
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		return nil, nil
+	}
+
+	id, err := s.RecordStash(task.ID, ref, branch)
+	if err != nil {
+		// Stashed but not recorded: say where it is rather than lose track.
+		fmt.Printf("  %s⚠ Could not record stash %s: %v%s\n", colorYellow, ref, err, colorReset)
+	}
+	s.AddEvent(task.ID, "hive", "comment", fmt.Sprintf("Stashed uncommitted changes from %s (%s)", branch, shortRef(ref)))
+	fmt.Printf("  Stashed your uncommitted changes (%s)\n", shortRef(ref))
+
+	return &userStash{id: id, ref: ref, branch: branch}, nil
+}
+
+// restore switches back to the branch the changes came from and applies
+// the stash there. On failure the stash is left in place and the command
+// to recover it is printed.
+func (u *userStash) restore(s *store.Store, safety *git.Safety) {
+	if u == nil {
+		return
+	}
+
+	if current, _ := safety.CurrentBranch(); current != u.branch {
+		if err := safety.Checkout(u.branch); err != nil {
+			fmt.Printf("  %s⚠ Could not switch back to %s: %v%s\n", colorYellow, u.branch, err, colorReset)
+			fmt.Printf("    Your changes are safe in the stash: %sgit checkout %s && git stash apply %s%s\n",
+				colorCyan, u.branch, u.ref, colorReset)
+			return
+		}
+	}
+
+	if err := safety.RestoreStash(u.ref); err != nil {
+		fmt.Printf("  %s⚠ Could not restore your stashed changes: %v%s\n", colorYellow, err, colorReset)
+		fmt.Printf("    They are still in the stash: %sgit stash apply %s%s\n", colorCyan, u.ref, colorReset)
+		return
+	}
+	if u.id != 0 {
+		s.MarkStashRestored(u.id)
+	}
+	fmt.Printf("  Restored your uncommitted changes on %s%s%s\n", colorCyan, u.branch, colorReset)
+}
+
+// warnPendingStashes prints stashes hive made for a task that were never
+// restored — left behind by a crash or a failed apply.
+func warnPendingStashes(s *store.Store, safety *git.Safety, taskID int64) {
+	pending, _ := s.ListPendingStashes(taskID)
+	for _, st := range pending {
+		if !safety.HasStash(st.Ref) {
+			// Applied or dropped by hand since — nothing left to recover.
+			s.MarkStashRestored(st.ID)
+			continue
+		}
+		fmt.Printf("  %s⚠ Unrestored auto-stash from %s (%s)%s\n", colorYellow, st.Branch,
+			st.CreatedAt.Local().Format("2006-01-02 15:04"), colorReset)
+		fmt.Printf("    Recover with: %sgit checkout %s && git stash apply %s%s\n", colorCyan, st.Branch, st.Ref, colorReset)
+	}
+}
+
+// autoStashEnabled reports whether git.auto_stash is set in config.
+// A missing or broken config just means no.
+func autoStashEnabled() bool {
+	cfg, err := loadConfig()
+	return err == nil && cfg.Git.AutoStash
+}
+
+// warnDirtyTree tells the user their uncommitted changes are about to be
+// carried onto a safety branch, and how to avoid that.
+func warnDirtyTree(safety *git.Safety, branch string) {
+	if safety.HasUncommittedChanges() {
+		fmt.Printf("  %s⚠ Uncommitted changes will be carried onto %s%s\n", colorYellow, branch, colorReset)
+		fmt.Printf("    Use %s--stash%s (or git.auto_stash in config) to keep them out.\n", colorCyan, colorReset)
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [epic-id]",
+	Short: "Cycle time and time spent in review and blocked",
+	Long: `Reports how long tasks took from creation to done (cycle time) and how
+long they spent in progress, in review and blocked. Without an epic ID all
+tasks are included.
+
+Times come from the status history hive records on every status change.
+Tasks created before it was recorded only have history from their last
+update.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStats,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var tasks []store.Task
+	if len(args) == 1 {
+		epic, err := loadEpic(s, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%sEpic #%d: %s%s\n\n", colorBold, epic.ID, epic.Title, colorReset)
+		tasks, err = s.ListTasksByEpic(epic.ID)
+		if err != nil {
+			return err
+		}
+	} else if tasks, err = s.ListOnlyTasks(""); err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No tasks.")
+		return nil
+	}
+
+	now := time.Now()
+	var cycles, reviews, blocked []time.Duration
+	fmt.Printf("%s%-6s %-12s %9s %9s %9s %9s  %s%s\n", colorDim, "ID", "STATUS", "CYCLE", "WORKING", "REVIEW", "BLOCKED", "TITLE", colorReset)
+	for _, t := range tasks {
+		spans, err := s.GetStatusHistory(t.ID)
+		if err != nil {
+			return err
+		}
+		times := store.Times(spans, now)
+
+		cycle := "-"
+		if times.Done {
+			cycle = formatSpan(times.Cycle)
+			cycles = append(cycles, times.Cycle)
+			reviews = append(reviews, times.Review)
+			blocked = append(blocked, times.Blocked)
+		}
+		fmt.Printf("%s#%-5d%s %s%-12s%s %9s %9s %9s %9s  %s\n",
+			colorYellow, t.ID, colorReset,
+			statusToColor(t.Status), t.Status, colorReset,
+			cycle, formatSpan(times.InProgress), formatSpan(times.Review), formatSpan(times.Blocked),
+			truncate(t.Title, 50))
+	}
+
+	fmt.Printf("\n%s%d of %d task(s) done%s\n", colorBold, len(cycles), len(tasks), colorReset)
+	if len(cycles) == 0 {
+		return nil
+	}
+	fmt.Printf("  %-18s median %s, mean %s\n", "cycle time:", formatSpan(median(cycles)), formatSpan(mean(cycles)))
+	fmt.Printf("  %-18s median %s, mean %s\n", "time in review:", formatSpan(median(reviews)), formatSpan(mean(reviews)))
+	fmt.Printf("  %-18s median %s, mean %s\n", "time blocked:", formatSpan(median(blocked)), formatSpan(mean(blocked)))
+	return nil
+}
+
+// formatSpan renders a duration compactly: 45s, 12m, 3h05m, 2d04h.
+func formatSpan(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return strconv.Itoa(int(d.Seconds())) + "s"
+	case d < time.Hour:
+		return strconv.Itoa(int(d.Minutes())) + "m"
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+func median(ds []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func mean(ds []time.Duration) time.Duration {
+	var sum time.Duration
+	for _, d := range ds {
+		sum += d
+	}
+	return sum / time.Duration(len(ds))
+}
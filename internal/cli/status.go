@@ -40,12 +40,14 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	warnStaleTasks(s)
 	fmt.Printf("%sTasks: %d total%s\n", colorBold, len(tasks), colorReset)
 	fmt.Printf("  %-14s %s%d%s\n", "backlog:", colorWhite, counts[store.StatusBacklog], colorReset)
 	fmt.Printf("  %-14s %s%d%s\n", "in_progress:", colorBlue, counts[store.StatusInProgress], colorReset)
 	fmt.Printf("  %-14s %s%d%s\n", "blocked:", colorRed, counts[store.StatusBlocked], colorReset)
 	fmt.Printf("  %-14s %s%d%s\n", "review:", colorMagenta, counts[store.StatusReview], colorReset)
 	fmt.Printf("  %-14s %s%d%s\n", "done:", colorGreen, counts[store.StatusDone], colorReset)
+	fmt.Printf("  %-14s %s%d%s\n", "needs_human:", colorYellow, counts[store.StatusNeedsHuman], colorReset)
 	fmt.Printf("  %-14s %s%d%s\n", "failed:", colorRed, counts[store.StatusFailed], colorReset)
 
 	if len(blocked) > 0 {
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// streamAgents is --stream on run and auto.
+var streamAgents bool
+
+// liveIndent sets streamed output off under auto's progress lines.
+const liveIndent = "    " + colorDim + "│ "
+
+// liveOutput shows an agent's output while it works (--stream) and
+// appends it to the task's live log, .hive/runs/task-N.log, which the TUI
+// tails. Parallel workers write the same file through the pool.
+type liveOutput struct {
+	agent  string
+	prefix string // Printed before each line
+	path   string
+	lines  int
+}
+
+// newLiveOutput returns the live output of agentName working on taskID,
+// each line printed after prefix. Without --stream it returns nil, and
+// agents answer in one piece.
+func newLiveOutput(taskID int64, agentName, prefix string) *liveOutput {
+	if !streamAgents {
+		return nil
+	}
+	os.MkdirAll(hivePath("runs"), 0755)
+	return &liveOutput{
+		agent:  agentName,
+		prefix: prefix,
+		path:   hivePath("runs", fmt.Sprintf("task-%d.log", taskID)),
+	}
+}
+
+// stream is the agent.Request.Stream for the agent; nil without --stream.
+func (l *liveOutput) stream() func(string) {
+	if l == nil {
+		return nil
+	}
+	return l.line
+}
+
+func (l *liveOutput) line(text string) {
+	if l.lines == 0 && l.prefix != "" {
+		fmt.Println() // Off the progress line the agent started on.
+	}
+	l.lines++
+	fmt.Printf("%s%s%s\n", l.prefix, text, colorReset)
+	if f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+		fmt.Fprintf(f, "%s %s │ %s\n", time.Now().Format("15:04:05"), l.agent, text)
+		f.Close()
+	}
+}
+
+// resume indents the rest of a progress line that streamed output broke
+// up.
+func (l *liveOutput) resume() {
+	if l != nil && l.lines > 0 {
+		fmt.Print("    ")
+	}
+}
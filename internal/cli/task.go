@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/imkarma/hive/internal/agent"
 	"github.com/imkarma/hive/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -175,6 +176,13 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 	if task.Description != "" {
 		fmt.Printf("  Desc:     %s\n", task.Description)
 	}
+	for i, c := range task.Acceptance {
+		label := ""
+		if i == 0 {
+			label = "Accept:"
+		}
+		fmt.Printf("  %-9s %s: %s\n", label, agent.CriterionLabel(i), c)
+	}
 	if task.AssignedAgent != "" {
 		fmt.Printf("  Agent:    %s (%s)\n", task.AssignedAgent, task.Role)
 	}
@@ -187,9 +195,37 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 	if task.GitBranch != "" {
 		fmt.Printf("  Branch:   %s\n", task.GitBranch)
 	}
+	if task.MergeCommit != "" {
+		fmt.Printf("  Merge:    %s (approved, conflicts with the epic branch)\n", task.MergeCommit)
+	}
+	if watchers, err := s.ListWatchers(id); err == nil && len(watchers) > 0 {
+		targets := make([]string, len(watchers))
+		for i, w := range watchers {
+			targets[i] = w.Target
+		}
+		fmt.Printf("  Watchers: %s\n", strings.Join(targets, ", "))
+	}
+	if images := taskImages(s)(id); len(images) > 0 {
+		fmt.Printf("  Images:   %s\n", strings.Join(images, ", "))
+	}
 	fmt.Printf("  Created:  %s\n", task.CreatedAt.Format("2006-01-02 15:04"))
 	fmt.Printf("  Updated:  %s\n", task.UpdatedAt.Format("2006-01-02 15:04"))
 
+	// Show files from the latest coder iteration.
+	if files, err := s.GetTaskFiles(id); err == nil && len(files) > 0 {
+		fmt.Printf("\n  Files (iteration %d):\n", files[0].Iteration)
+		for _, f := range files {
+			note := ""
+			switch {
+			case f.Declared && !f.Changed:
+				note = " (declared, unchanged)"
+			case !f.Declared && f.Changed:
+				note = " (not declared)"
+			}
+			fmt.Printf("    %s%s\n", f.Path, note)
+		}
+	}
+
 	// Show events.
 	events, err := s.GetEvents(id)
 	if err != nil {
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var taskAttachCmd = &cobra.Command{
+	Use:   "attach [id] [image...]",
+	Short: "Attach images (mockups, screenshots) to a task",
+	Long: `Copies images into .hive/runs and attaches them to a task or epic.
+Every agent working on the task gets them: API agents with vision: true
+receive them inline, CLI agents get their paths in the prompt. Images on
+an epic go to all of its tasks.
+
+Supported: .png, .jpg, .jpeg, .gif, .webp.
+
+Example:
+  hive task attach 4 mockup.png error-screenshot.jpg`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runTaskAttach,
+}
+
+func init() {
+	taskCmd.AddCommand(taskAttachCmd)
+}
+
+func runTaskAttach(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %s", args[0])
+	}
+	task, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("task #%d not found", id)
+	}
+
+	for _, src := range args[1:] {
+		if agent.ImageMIME(src) == "" {
+			return fmt.Errorf("%s: not a supported image (png, jpg, gif, webp)", src)
+		}
+	}
+
+	arts := newArtifacts(s)
+	for _, src := range args[1:] {
+		path, err := arts.Attach(task.ID, artifacts.TypeImage, src)
+		if err != nil {
+			return err
+		}
+		s.AddEvent(task.ID, "", "attached", fmt.Sprintf("Attached image %s", src))
+		fmt.Printf("%s✓%s Attached %s to #%d %s(%s)%s\n", colorGreen, colorReset, src, task.ID, colorDim, path, colorReset)
+	}
+	return nil
+}
+
+// taskImages returns the image source for agent requests: the images
+// attached to a task and to its epic.
+func taskImages(s *store.Store) agent.ImageSource {
+	arts := newArtifacts(s)
+	return func(taskID int64) []string {
+		ids := []int64{taskID}
+		if task, err := s.GetTask(taskID); err == nil && task.ParentID != nil {
+			ids = append(ids, *task.ParentID)
+		}
+		var paths []string
+		for _, id := range ids {
+			list, err := s.GetArtifacts(id)
+			if err != nil {
+				continue
+			}
+			for _, a := range list {
+				if a.Type == artifacts.TypeImage {
+					paths = append(paths, arts.Resolve(a.FilePath))
+				}
+			}
+		}
+		return paths
+	}
+}
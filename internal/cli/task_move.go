@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var taskMoveCmd = &cobra.Command{
+	Use:   "move [id]",
+	Short: "Move a task to another epic",
+	Long: `Moves a task under another epic. If the task already has commits on its
+old epic's safety branch, they are cherry-picked onto the new epic's branch
+and reverted on the old one, so each branch only carries its own tasks.
+
+Commits are found by hive's commit messages ("hive: task #N — ..."). If
+they don't apply cleanly the task still moves, but the git work stays
+where it is and you're told which commits to carry over by hand.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTaskMove,
+}
+
+var taskMoveToEpic int64
+
+func init() {
+	taskMoveCmd.Flags().Int64Var(&taskMoveToEpic, "to-epic", 0, "ID of the epic to move the task to (required)")
+	taskMoveCmd.MarkFlagRequired("to-epic")
+	taskCmd.AddCommand(taskMoveCmd)
+}
+
+func runTaskMove(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %s", args[0])
+	}
+	task, err := s.GetTask(id)
+	if err != nil {
+		return fmt.Errorf("task #%d not found", id)
+	}
+	if task.Kind == store.KindEpic {
+		return fmt.Errorf("#%d is an epic; use 'hive epic merge' to combine epics", id)
+	}
+
+	to, err := loadEpic(s, strconv.FormatInt(taskMoveToEpic, 10))
+	if err != nil {
+		return err
+	}
+	if err := checkEpicIdle(s, to); err != nil {
+		return err
+	}
+
+	var from *store.Task
+	if task.ParentID != nil {
+		if *task.ParentID == to.ID {
+			return fmt.Errorf("task #%d is already in epic #%d", task.ID, to.ID)
+		}
+		if from, err = s.GetTask(*task.ParentID); err != nil {
+			return fmt.Errorf("parent #%d not found", *task.ParentID)
+		}
+		if run, _ := s.GetActivePipelineRun(from.ID); run != nil {
+			return fmt.Errorf("epic #%d has a running pipeline (run #%d); stop it first", from.ID, run.ID)
+		}
+	}
+
+	workDir, _ := os.Getwd()
+	moveTaskCommits(s, git.New(workDir), task, from, to)
+
+	if err := s.SetParent(task.ID, to.ID); err != nil {
+		return err
+	}
+	s.AddEvent(to.ID, "", "task_moved", fmt.Sprintf("Task #%d (%s) moved in%s", task.ID, task.Title, fromLabel(from)))
+	if from != nil {
+		s.AddEvent(from.ID, "", "task_moved", fmt.Sprintf("Task #%d (%s) moved to epic #%d", task.ID, task.Title, to.ID))
+	}
+	s.AddEvent(task.ID, "", "task_moved", fmt.Sprintf("Moved to epic #%d%s", to.ID, fromLabel(from)))
+
+	fmt.Printf("%s✓ Task #%d moved to epic #%d%s: %s\n", colorGreen, task.ID, to.ID, colorReset, to.Title)
+	return nil
+}
+
+// moveTaskCommits carries a task's commits from its old epic's branch to
+// the new one's. Problems are reported, not returned: the board move
+// happens either way.
+func moveTaskCommits(s *store.Store, safety *git.Safety, task, from, to *store.Task) {
+	if from == nil || from.GitBranch == "" || !safety.IsGitRepo() || !safety.BranchExists(from.GitBranch) {
+		return
+	}
+	base, err := safety.BaseBranchFor(from.BaseBranch)
+	if err != nil {
+		return
+	}
+	start := base
+	if from.AdoptedRef != "" {
+		start = from.AdoptedRef
+	}
+	commits, err := safety.TaskCommits(start, from.GitBranch, task.ID)
+	if err != nil || len(commits) == 0 {
+		return
+	}
+
+	fmt.Printf("  Task #%d has %d commit(s) on %s\n", task.ID, len(commits), from.GitBranch)
+	manual := func(reason string) {
+		fmt.Printf("  %s⚠  %s; move them by hand:%s\n", colorYellow, reason, colorReset)
+		for _, c := range commits {
+			fmt.Printf("    git cherry-pick %s\n", c[:12])
+		}
+		s.AddEvent(task.ID, "git", "task_moved", fmt.Sprintf("Commits not moved (%s): %d commit(s) remain on %s", reason, len(commits), from.GitBranch))
+	}
+
+	if safety.HasUncommittedChanges() {
+		manual("working tree has uncommitted changes")
+		return
+	}
+	if to.GitBranch == "" || !safety.BranchExists(to.GitBranch) {
+		manual(fmt.Sprintf("epic #%d has no safety branch", to.ID))
+		return
+	}
+
+	current, _ := safety.CurrentBranch()
+	defer func() {
+		if current != "" {
+			safety.Checkout(current)
+		}
+	}()
+
+	if err := safety.CherryPick(to.GitBranch, commits); err != nil {
+		manual(fmt.Sprintf("they don't apply to %s", to.GitBranch))
+		return
+	}
+	fmt.Printf("  Cherry-picked onto %s%s%s\n", colorCyan, to.GitBranch, colorReset)
+	if err := safety.RevertCommits(from.GitBranch, commits); err != nil {
+		fmt.Printf("  %s⚠  Could not revert them on %s: %v%s\n", colorYellow, from.GitBranch, err, colorReset)
+		return
+	}
+	fmt.Printf("  Reverted on %s%s%s\n", colorCyan, from.GitBranch, colorReset)
+}
+
+func fromLabel(from *store.Task) string {
+	if from == nil {
+		return ""
+	}
+	return fmt.Sprintf(" from epic #%d", from.ID)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+// termNotifier signals pipeline progress through the terminal itself:
+// the window title shows the current phase/task, and the bell rings when
+// the user is needed (blocker) or the run finishes. Both are no-ops when
+// stdout is not a terminal, so piped output stays clean.
+type termNotifier struct {
+	cfg    config.Terminal
+	tty    bool
+	prefix string
+}
+
+// newTermNotifier creates a notifier whose titles start with prefix
+// (e.g. "hive auto E#3").
+func newTermNotifier(cfg config.Terminal, prefix string) *termNotifier {
+	return &termNotifier{cfg: cfg, tty: isTerminal(os.Stdout), prefix: prefix}
+}
+
+// title sets the terminal window title to "<prefix> — <status>".
+func (n *termNotifier) title(format string, args ...any) {
+	if n == nil || !n.tty || !n.cfg.TitleEnabled() {
+		return
+	}
+	status := fmt.Sprintf(format, args...)
+	fmt.Printf("\033]0;%s — %s\007", n.prefix, status)
+}
+
+// bell rings the terminal bell.
+func (n *termNotifier) bell() {
+	if n == nil || !n.tty || !n.cfg.BellEnabled() {
+		return
+	}
+	fmt.Print("\a")
+}
+
+// isTerminal reports whether f is attached to a character device.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/notify"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var watchTargets []string
+
+var taskWatchCmd = &cobra.Command{
+	Use:   "watch [id]",
+	Short: "Get notified when a task or epic changes status",
+	Long: `Subscribes targets to status changes of a task, or of an epic and all
+its tasks. Without --notify, lists the current watchers.
+
+Targets:
+  slack:#channel   post to a Slack channel (needs notify.slack_webhook)
+  slack:@user      direct-message a Slack user
+  webhook:<url>    POST a JSON payload to any URL
+
+Example:
+  hive task watch 3 --notify slack:#team --notify slack:@alice`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTaskWatch,
+}
+
+var taskUnwatchCmd = &cobra.Command{
+	Use:   "unwatch [id]",
+	Short: "Stop notifications for a task or epic",
+	Long:  "Removes the given --notify targets from a task, or all of its watchers when none are given.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTaskUnwatch,
+}
+
+func init() {
+	taskWatchCmd.Flags().StringArrayVar(&watchTargets, "notify", nil, "Target to notify (repeatable): slack:#channel, slack:@user, webhook:<url>")
+	taskUnwatchCmd.Flags().StringArrayVar(&watchTargets, "notify", nil, "Target to remove (repeatable; default: all)")
+
+	taskCmd.AddCommand(taskWatchCmd)
+	taskCmd.AddCommand(taskUnwatchCmd)
+}
+
+func runTaskWatch(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	task, err := watchedTask(s, args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(watchTargets) == 0 {
+		watchers, err := s.ListWatchers(task.ID)
+		if err != nil {
+			return err
+		}
+		if len(watchers) == 0 {
+			fmt.Printf("No watchers on #%d. Add one with --notify slack:#channel\n", task.ID)
+			return nil
+		}
+		for _, w := range watchers {
+			fmt.Printf("  %s  %s(since %s)%s\n", w.Target, colorDim, w.CreatedAt.Format("2006-01-02"), colorReset)
+		}
+		return nil
+	}
+
+	for _, raw := range watchTargets {
+		t, err := notify.ParseTarget(raw)
+		if err != nil {
+			return err
+		}
+		if err := s.AddWatcher(task.ID, t.String()); err != nil {
+			return err
+		}
+		fmt.Printf("%s✓%s %s watches #%d %s\n", colorGreen, colorReset, t, task.ID, task.Title)
+	}
+	if task.Kind == store.KindEpic {
+		fmt.Printf("  %sIncludes status changes of the epic's tasks.%s\n", colorDim, colorReset)
+	}
+	if cfg, err := loadConfig(); err == nil && cfg.Offline {
+		fmt.Printf("  %sOffline mode is on: nothing is sent until it's off.%s\n", colorYellow, colorReset)
+	}
+	return nil
+}
+
+func runTaskUnwatch(cmd *cobra.Command, args []string) error {
+	s, err := mustStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	task, err := watchedTask(s, args[0])
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	if len(watchTargets) == 0 {
+		if removed, err = s.RemoveWatcher(task.ID, ""); err != nil {
+			return err
+		}
+	}
+	for _, t := range watchTargets {
+		n, err := s.RemoveWatcher(task.ID, t)
+		if err != nil {
+			return err
+		}
+		removed += n
+	}
+	fmt.Printf("Removed %d watcher(s) from #%d\n", removed, task.ID)
+	return nil
+}
+
+func watchedTask(s *store.Store, arg string) (*store.Task, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %s", arg)
+	}
+	return s.GetTask(id)
+}
+
+// notifyWatchers returns a status hook that tells the watchers of a task
+// (and of its epic) about the change. Delivery failures are recorded as
+// notify_failed events rather than failing the command that changed the
+// status. Offline, nothing is sent.
+func notifyWatchers(s *store.Store) store.StatusHook {
+	return func(taskID int64, status store.TaskStatus, detail string) {
+		targets, err := s.WatchTargets(taskID)
+		if err != nil || len(targets) == 0 {
+			return
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+			resolveOffline(cfg)
+		}
+		if cfg.Offline {
+			return
+		}
+		task, err := s.GetTask(taskID)
+		if err != nil {
+			return
+		}
+
+		msg := statusMessage(task, status, detail)
+		n := notify.New(slackWebhook(cfg))
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		for _, raw := range targets {
+			t, err := notify.ParseTarget(raw)
+			if err == nil {
+				err = n.Send(ctx, t, msg)
+			}
+			if err != nil {
+				s.AddEvent(taskID, "", "notify_failed", err.Error())
+			}
+		}
+	}
+}
+
+// statusMessage describes a status change for watchers.
+func statusMessage(task *store.Task, status store.TaskStatus, detail string) notify.Message {
+	msg := notify.Message{
+		TaskID: task.ID,
+		Kind:   string(task.Kind),
+		Title:  task.Title,
+		Status: string(status),
+		Detail: detail,
+	}
+	label := "Task"
+	if task.Kind == store.KindEpic {
+		label = "Epic"
+	}
+	msg.Text = fmt.Sprintf("%s #%d %q is now %s", label, task.ID, task.Title, status)
+	if task.ParentID != nil {
+		msg.EpicID = *task.ParentID
+		msg.Text = fmt.Sprintf("%s #%d %q (epic #%d) is now %s", label, task.ID, task.Title, *task.ParentID, status)
+	}
+	switch {
+	case status == store.StatusBlocked && detail != "":
+		msg.Text += ": " + detail
+	case detail != "":
+		msg.Text += " (answered: " + detail + ")"
+	}
+	return msg
+}
+
+// slackWebhook returns notify.slack_webhook from config, else
+// $HIVE_SLACK_WEBHOOK.
+func slackWebhook(cfg *config.Config) string {
+	if cfg.Notify.SlackWebhook != "" {
+		return cfg.Notify.SlackWebhook
+	}
+	return os.Getenv("HIVE_SLACK_WEBHOOK")
+}
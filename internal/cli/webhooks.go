@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/notify"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// onStatusChange tells watchers about a status change, and the webhooks in
+// notify.webhooks about the ones that are lifecycle events: a task or epic
+// blocked on a question, and an epic whose tasks are all done.
+func onStatusChange(s *store.Store) store.StatusHook {
+	watchers := notifyWatchers(s)
+	return func(taskID int64, status store.TaskStatus, detail string) {
+		watchers(taskID, status, detail)
+		if status != store.StatusBlocked && status != store.StatusReview {
+			return
+		}
+
+		task, err := s.GetTask(taskID)
+		if err != nil {
+			return
+		}
+		switch {
+		case status == store.StatusBlocked:
+			msg := statusMessage(task, status, detail)
+			msg.Text += fmt.Sprintf(" — answer with: hive answer %d \"...\"", task.ID)
+			sendLifecycleEvent(s, notify.EventTaskBlocked, msg)
+		case status == store.StatusReview && task.Kind == store.KindEpic:
+			msg := statusMessage(task, status, detail)
+			msg.Text = fmt.Sprintf("Epic #%d %q is ready to accept: hive epic accept %d", task.ID, task.Title, task.ID)
+			sendLifecycleEvent(s, notify.EventEpicReady, msg)
+		}
+	}
+}
+
+// onReview tells the webhooks in notify.webhooks about rejected reviews.
+func onReview(s *store.Store) store.ReviewHook {
+	return func(taskID int64, reviewer, verdict, comments string) {
+		if verdict != "reject" {
+			return
+		}
+		task, err := s.GetTask(taskID)
+		if err != nil {
+			return
+		}
+		msg := statusMessage(task, task.Status, "")
+		msg.Status = "rejected"
+		msg.Detail = strings.Join(agent.ParseReview(comments).Comments, "\n")
+		msg.Text = fmt.Sprintf("%s rejected task #%d %q", reviewer, task.ID, task.Title)
+		if first, _, _ := strings.Cut(msg.Detail, "\n"); first != "" {
+			msg.Text += ": " + truncate(first, 200)
+		}
+		sendLifecycleEvent(s, notify.EventReviewRejected, msg)
+	}
+}
+
+// notifyPipeline tells the webhooks in notify.webhooks that a pipeline on
+// task started or failed.
+func notifyPipeline(s *store.Store, event string, task *store.Task, detail string) {
+	msg := statusMessage(task, task.Status, "")
+	msg.Detail = detail
+	label := "task"
+	if task.Kind == store.KindEpic {
+		label = "epic"
+	}
+	switch event {
+	case notify.EventPipelineStarted:
+		msg.Status = "started"
+		msg.Text = fmt.Sprintf("Pipeline started on %s #%d %q", label, task.ID, task.Title)
+	case notify.EventPipelineFailed:
+		msg.Status = "failed"
+		msg.Text = fmt.Sprintf("Pipeline failed on %s #%d %q: %s", label, task.ID, task.Title, detail)
+	}
+	sendLifecycleEvent(s, event, msg)
+}
+
+// sendLifecycleEvent posts msg to every webhook subscribed to event. As
+// with watchers, failures are recorded as notify_failed events, and
+// offline nothing is sent.
+func sendLifecycleEvent(s *store.Store, event string, msg notify.Message) {
+	cfg, err := loadConfig()
+	if err != nil || cfg.Offline || len(cfg.Notify.Webhooks) == 0 {
+		return
+	}
+	msg.Event = event
+
+	n := notify.New("")
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	for i, hook := range cfg.Notify.Webhooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, event) {
+			continue
+		}
+		url := webhookURL(hook)
+		if url == "" {
+			s.AddEvent(msg.TaskID, "", "notify_failed", fmt.Sprintf("notify: webhook %d: $%s is not set", i+1, hook.URLEnv))
+			continue
+		}
+		if err := n.Post(ctx, url, hook.Format, msg); err != nil {
+			s.AddEvent(msg.TaskID, "", "notify_failed", err.Error())
+		}
+	}
+}
+
+// webhookURL returns a webhook's url, else the value of its url_env.
+func webhookURL(hook config.Webhook) string {
+	if hook.URL != "" {
+		return hook.URL
+	}
+	return os.Getenv(hook.URLEnv)
+}
@@ -3,27 +3,635 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config is the root configuration for a hive project.
 type Config struct {
-	Version int              `yaml:"version"`
-	Agents  map[string]Agent `yaml:"agents"`
+	Version  int                `yaml:"version"`
+	Agents   map[string]Agent   `yaml:"agents"`
+	Roles    map[string]RoleDef `yaml:"roles,omitempty"`
+	Auto     Auto               `yaml:"auto,omitempty"`
+	Docs     Docs               `yaml:"docs,omitempty"`
+	Testing  Testing            `yaml:"testing,omitempty"`
+	Perf     Perf               `yaml:"perf,omitempty"`
+	Git      Git                `yaml:"git,omitempty"`
+	Accept   Accept             `yaml:"accept,omitempty"`
+	Review   Review             `yaml:"review,omitempty"`
+	History  History            `yaml:"history,omitempty"`
+	Terminal Terminal           `yaml:"terminal,omitempty"`
+	Metrics  Metrics            `yaml:"metrics,omitempty"`
+	Tracing  Tracing            `yaml:"tracing,omitempty"`
+	Notify   Notify             `yaml:"notify,omitempty"`
+	Serve    Serve              `yaml:"serve,omitempty"`
+	Redact   Redact             `yaml:"redact,omitempty"`
+	Output   Output             `yaml:"output,omitempty"`
+	Owners   Owners             `yaml:"owners,omitempty"`
+	Disk     Disk               `yaml:"disk,omitempty"`
+	Backoff  Backoff            `yaml:"backoff,omitempty"`
+	Logging  Logging            `yaml:"logging,omitempty"`
+
+	// Providers caps the calls to each provider across every agent of a
+	// pipeline run, parallel workers included. Keys are API providers
+	// (openai, anthropic, google) or, for CLI agents, their command
+	// (claude, codex).
+	Providers map[string]ProviderLimit `yaml:"providers,omitempty"`
+
+	// Languages are the project's languages (see KnownLanguages), detected
+	// by hive init. The coder and the reviewer get each one's test and
+	// format commands and idioms; LanguageHints overrides or adds to them.
+	Languages     []string                `yaml:"languages,omitempty"`
+	LanguageHints map[string]LanguageHint `yaml:"language_hints,omitempty"`
+
+	// SetupCmd prepares a fresh worktree before agents work in it, e.g.
+	// "npm ci" or "make deps". An epic can override it (hive epic create
+	// --setup).
+	SetupCmd string `yaml:"setup_cmd,omitempty"`
+
+	// Offline forbids network calls: api-mode agents, notifications and
+	// trace export. Only CLI agents run.
+	Offline bool `yaml:"offline,omitempty"`
+}
+
+// Auto holds defaults for hive auto.
+type Auto struct {
+	Architect    *bool `yaml:"architect,omitempty"`      // Run the architect phase when an architect agent exists (default: true)
+	PauseOnBlock bool  `yaml:"pause_on_block,omitempty"` // Stop the epic's pipeline when a task blocks, and resume it once answered (default: false)
+
+	// ConfirmAbove asks before a run whose estimate (printed after
+	// planning) may go over any of these; --yes skips the question.
+	ConfirmAbove ConfirmAbove `yaml:"confirm_above,omitempty"`
+}
+
+// ConfirmAbove are the limits past which hive auto asks before running.
+// Zero leaves a limit off.
+type ConfirmAbove struct {
+	Calls   int     `yaml:"calls,omitempty"`   // Agent calls, if every task uses all its fix loops
+	Minutes int     `yaml:"minutes,omitempty"` // Projected duration
+	Cost    float64 `yaml:"cost,omitempty"`    // Projected cost in USD
+}
+
+// Exceeded returns the limits a run with the given worst-case calls,
+// duration and cost goes over, e.g. "cost $3.20 > $2.00".
+func (c ConfirmAbove) Exceeded(calls int, duration time.Duration, cost float64) []string {
+	var over []string
+	if c.Calls > 0 && calls > c.Calls {
+		over = append(over, fmt.Sprintf("%d calls > %d", calls, c.Calls))
+	}
+	if c.Minutes > 0 && duration > time.Duration(c.Minutes)*time.Minute {
+		over = append(over, fmt.Sprintf("%s > %dm", duration.Round(time.Minute), c.Minutes))
+	}
+	if c.Cost > 0 && cost > c.Cost {
+		over = append(over, fmt.Sprintf("cost $%.2f > $%.2f", cost, c.Cost))
+	}
+	return over
+}
+
+// ArchitectEnabled reports whether hive auto runs the architect phase
+// without --with-architect or --skip-architect.
+func (a Auto) ArchitectEnabled() bool {
+	return a.Architect == nil || *a.Architect
+}
+
+// Backoff paces the fix loop of hive auto and hive fix: before each
+// iteration after the first, hive waits InitialSec, doubling every
+// iteration up to MaxSec, so a rejected task doesn't go straight back to
+// a rate-limited provider. Parallel workers add up to a quarter more at
+// random, so they don't all retry at once.
+type Backoff struct {
+	InitialSec int `yaml:"initial_sec,omitempty"` // Wait before the second iteration (default: 0, no wait)
+	MaxSec     int `yaml:"max_sec,omitempty"`     // Longest wait (default: 300)
+}
+
+// Delay returns the wait before fix-loop iteration n (from 1); none
+// before the first.
+func (b Backoff) Delay(iteration int) time.Duration {
+	if b.InitialSec <= 0 || iteration <= 1 {
+		return 0
+	}
+	max := b.MaxSec
+	if max <= 0 {
+		max = 300
+	}
+	sec := b.InitialSec
+	for i := 2; i < iteration && sec < max; i++ {
+		sec *= 2
+	}
+	return time.Duration(min(sec, max)) * time.Second
+}
+
+// ProviderLimit is the budget of calls to one provider.
+type ProviderLimit struct {
+	Concurrency int `yaml:"concurrency,omitempty"` // Calls running at once (default: no limit)
+	RPM         int `yaml:"rpm,omitempty"`         // Calls started per minute (default: no limit)
+}
+
+// Logging controls the debug logs hive keeps under .hive/logs.
+type Logging struct {
+	// API is what is logged of api-mode agents' HTTP calls, under
+	// .hive/logs/api: none (default), metadata (a line per call with the
+	// provider, status, duration and sizes) or full (also the request and
+	// response bodies, with secrets masked), to debug answers from a
+	// provider that hive can't parse.
+	API string `yaml:"api,omitempty"`
+}
+
+// APILogLevels are the valid values for Logging.API.
+var APILogLevels = []string{"none", "metadata", "full"}
+
+// Docs configures the docs stage of hive auto: after every task of an
+// epic is approved, the agent with role "docs" updates these paths.
+type Docs struct {
+	Paths []string `yaml:"paths,omitempty"` // Files or directories the docs agent may edit
+}
+
+// Testing configures the tester stage of hive auto. The agent with role
+// "tester" writes or extends tests for each task, and Cmd, when set, must
+// pass before the reviewer sees the changes.
+//
+// In a workspace (go.work, npm/pnpm/yarn or Cargo workspaces) PackageCmd
+// narrows the gate: when a task only changed files inside workspace
+// packages, it runs once per changed package, with {dir} and {name}
+// replaced, instead of Cmd.
+type Testing struct {
+	Stage      string `yaml:"stage,omitempty"`       // When the tester runs: before_code or after_code (default)
+	Verify     string `yaml:"verify,omitempty"`      // Quick check run before Cmd, e.g. "go build ./... && go vet ./..."
+	Cmd        string `yaml:"cmd,omitempty"`         // Test command gating review, e.g. "go test ./..."
+	PackageCmd string `yaml:"package_cmd,omitempty"` // In a monorepo, run this per changed package instead, e.g. "cd {dir} && go test ./..."
+	TimeoutSec int    `yaml:"timeout,omitempty"`     // Timeout for Cmd (default 600)
+}
+
+// TesterStage returns when the tester runs, defaulting to after_code.
+func (t Testing) TesterStage() string {
+	if t.Stage == "" {
+		return "after_code"
+	}
+	return t.Stage
+}
+
+// CmdTimeout returns the timeout of the verify and test commands in
+// seconds.
+func (t Testing) CmdTimeout() int {
+	if t.TimeoutSec > 0 {
+		return t.TimeoutSec
+	}
+	return 600
+}
+
+// Perf configures the benchmark gate of hive auto. After each coder
+// iteration the benchmark command runs and is compared to a baseline.
+type Perf struct {
+	Cmd          string  `yaml:"cmd,omitempty"`           // Benchmark command, e.g. "go test -run=^$ -bench=. ./..."
+	Baseline     string  `yaml:"baseline,omitempty"`      // Baseline file (default .hive/perf-baseline.txt)
+	Tolerance    float64 `yaml:"tolerance,omitempty"`     // Allowed slowdown in percent (default 10)
+	OnRegression string  `yaml:"on_regression,omitempty"` // "fix" sends it back to the coder (default), "flag" reports it to the reviewer
+	TimeoutSec   int     `yaml:"timeout,omitempty"`       // Timeout for Cmd (default 600)
+}
+
+// BaselinePath returns the baseline file path.
+func (p Perf) BaselinePath() string {
+	if p.Baseline == "" {
+		return ".hive/perf-baseline.txt"
+	}
+	return p.Baseline
+}
+
+// TolerancePct returns the allowed slowdown in percent.
+func (p Perf) TolerancePct() float64 {
+	if p.Tolerance > 0 {
+		return p.Tolerance
+	}
+	return 10
+}
+
+// FlagOnly reports whether regressions are only flagged to the reviewer
+// instead of being sent back to the coder.
+func (p Perf) FlagOnly() bool {
+	return p.OnRegression == "flag"
+}
+
+// CmdTimeout returns the benchmark timeout in seconds.
+func (p Perf) CmdTimeout() int {
+	if p.TimeoutSec > 0 {
+		return p.TimeoutSec
+	}
+	return 600
+}
+
+// DefaultDocPaths are used when docs.paths is not set.
+var DefaultDocPaths = []string{"README.md", "docs/"}
+
+// TargetPaths returns the configured doc paths, or DefaultDocPaths.
+func (d Docs) TargetPaths() []string {
+	if len(d.Paths) == 0 {
+		return DefaultDocPaths
+	}
+	return d.Paths
+}
+
+// RoleDef defines a custom agent role, or overrides the prompt text of a
+// built-in one. Agents opt into a role via their role: field.
+type RoleDef struct {
+	Header       string   `yaml:"header,omitempty"`       // Prompt preamble, e.g. "# You are a Security Auditor"
+	Instructions string   `yaml:"instructions,omitempty"` // Process and response format
+	Output       string   `yaml:"output,omitempty"`       // Response format only: replaces a built-in role's "## Response Format" section
+	Stage        string   `yaml:"stage,omitempty"`        // Where it runs in auto: before_code, after_code, after_review
+	Verdict      bool     `yaml:"verdict,omitempty"`      // Parse VERDICT: from output; REJECT sends the task back to the coder
+	Omit         []string `yaml:"omit,omitempty"`         // Prompt sections the role doesn't get (see PromptSections)
+}
+
+// PromptSections are the parts of an agent's prompt a role can omit:
+//
+//	epic      - the parent epic or task
+//	handoffs  - what the epic's finished tasks introduced
+//	owners    - who owns which code (PM and architect)
+//	history   - earlier events on the task: answers, reviews, specs
+//	files     - file contents sent to API coders
+//	checklist - the review checklist
+var PromptSections = []string{"epic", "handoffs", "owners", "history", "files", "checklist"}
+
+// roleStages are the valid values for RoleDef.Stage.
+var roleStages = []string{"before_code", "after_code", "after_review"}
+
+// Built-in role names: the roles hive's own pipeline drives directly.
+// Package roles builds on these; they live here so the config can be
+// validated without importing it.
+const (
+	RolePM        = "pm"
+	RoleArchitect = "architect"
+	RoleCoder     = "coder"
+	RoleReviewer  = "reviewer"
+	RoleTester    = "tester"
+	RoleAnalyst   = "analyst"
+	RoleDocs      = "docs"
+)
+
+// BuiltinRoles lists the built-in role names.
+var BuiltinRoles = []string{RolePM, RoleArchitect, RoleCoder, RoleReviewer, RoleTester, RoleAnalyst, RoleDocs}
+
+// Git controls how hive treats the user's repository.
+type Git struct {
+	// AutoStash stashes uncommitted changes before switching to an epic's
+	// safety branch and restores them on the original branch afterwards,
+	// instead of carrying them onto the safety branch.
+	AutoStash bool `yaml:"auto_stash,omitempty"`
+
+	// KeepWorktrees leaves the worktrees of parallel runs in
+	// .hive/worktrees for the next run, with whatever setup_cmd installed,
+	// instead of removing them when the run ends.
+	KeepWorktrees bool `yaml:"keep_worktrees,omitempty"`
+
+	// TaskBranches puts each parallel task on its own branch,
+	// hive/epic-N-task-M, started from the epic branch. A finished task's
+	// branch is rebased onto the epic branch and fast-forwarded into it;
+	// one that conflicts is kept for resolving by hand.
+	TaskBranches bool `yaml:"task_branches,omitempty"`
+}
+
+// Accept configures the pre-flight checks hive epic accept runs before
+// merging. A failed check blocks the merge unless --force is given.
+type Accept struct {
+	Checks []string `yaml:"checks,omitempty"` // Subset of AcceptChecks to run (default: all)
+}
+
+// AcceptChecks are the known pre-flight checks:
+//
+//	clean     - no uncommitted changes that aren't the epic's
+//	tests     - testing.cmd passes on the safety branch
+//	conflicts - the safety branch merges into base without conflicts
+//	remote    - base is not behind its upstream
+var AcceptChecks = []string{"clean", "tests", "conflicts", "remote"}
+
+// Enabled reports whether the named pre-flight check should run.
+func (a Accept) Enabled(check string) bool {
+	if len(a.Checks) == 0 {
+		return true
+	}
+	return containsAny(a.Checks, check)
+}
+
+// Review configures what the reviewer must check. Each checklist item has
+// to be answered PASS, FAIL or N/A in the reviewer's CHECKLIST: block; a
+// required item that is missing or FAIL turns the verdict into REJECT.
+//
+// Reviewers may also suggest patches in ```diff blocks. With AutoApply,
+// a rejection whose suggestions are trivial (at most TrivialLines changed
+// lines in total) is fixed by applying them instead of another coder run.
+type Review struct {
+	Checklist    []ChecklistItem `yaml:"checklist,omitempty"`
+	AutoApply    bool            `yaml:"auto_apply,omitempty"`    // Apply trivial reviewer patches automatically
+	TrivialLines int             `yaml:"trivial_lines,omitempty"` // Largest patch auto_apply applies (default 10)
+	Blind        bool            `yaml:"blind,omitempty"`         // Hide agent names from each other's prompts, and earlier verdicts from the reviewer
+	Tiebreaker   string          `yaml:"tiebreaker,omitempty"`    // Reviewer asked when a review is unclear or reverses an approval (default: one from another provider)
+	SkipTrivial  bool            `yaml:"skip_trivial,omitempty"`  // Approve small docs, test or comment-only diffs without a reviewer
+	SkipLines    int             `yaml:"skip_lines,omitempty"`    // Largest diff skip_trivial approves (default 20)
+	SkipPaths    []string        `yaml:"skip_paths,omitempty"`    // Extra low-risk paths for skip_trivial (globs; "dir/" for a directory)
+}
+
+// History configures what agents see of a task's earlier iterations.
+// Each agent answer is recorded as an event holding a preview of
+// PreviewChars. With InlineArtifacts the coder and the reviewer also get
+// the latest coder and review output in full, up to InlineTokens.
+type History struct {
+	PreviewChars    int  `yaml:"preview_chars,omitempty"`    // Length of agent output previews in events (default 200)
+	InlineArtifacts bool `yaml:"inline_artifacts,omitempty"` // Put the latest coder and review output in the next prompt
+	InlineTokens    int  `yaml:"inline_tokens,omitempty"`    // Budget for inlined output, about 4 characters a token (default 4000)
+}
+
+// Preview shortens agent output for an event to PreviewChars.
+func (h History) Preview(output string) string {
+	n := h.PreviewChars
+	if n <= 0 {
+		n = 200
+	}
+	if len(output) <= n {
+		return output
+	}
+	return output[:n] + "..."
+}
+
+// InlineBudget returns the token budget for inlined output.
+func (h History) InlineBudget() int {
+	if h.InlineTokens > 0 {
+		return h.InlineTokens
+	}
+	return 4000
+}
+
+// AgentRoles maps each agent's name to its role.
+func (c *Config) AgentRoles() map[string]string {
+	out := make(map[string]string, len(c.Agents))
+	for name, a := range c.Agents {
+		out[name] = a.Role
+	}
+	return out
+}
+
+// TrivialLimit returns the largest suggestion, in changed lines, that
+// auto_apply applies.
+func (r Review) TrivialLimit() int {
+	if r.TrivialLines > 0 {
+		return r.TrivialLines
+	}
+	return 10
+}
+
+// SkipLimit returns the largest diff, in changed lines, that skip_trivial
+// approves without a reviewer.
+func (r Review) SkipLimit() int {
+	if r.SkipLines > 0 {
+		return r.SkipLines
+	}
+	return 20
+}
+
+// ChecklistItem is one review checklist entry. In YAML it is either a
+// plain string (a required item) or a mapping with item and required.
+type ChecklistItem struct {
+	Item     string `yaml:"item"`
+	Required *bool  `yaml:"required,omitempty"` // Default: true
+}
+
+// Items returns the text of each checklist item.
+func (r Review) Items() []string {
+	items := make([]string, len(r.Checklist))
+	for i, c := range r.Checklist {
+		items[i] = c.Item
+	}
+	return items
+}
+
+// IsRequired reports whether the item forces a REJECT when not passed.
+func (c ChecklistItem) IsRequired() bool {
+	return c.Required == nil || *c.Required
+}
+
+// UnmarshalYAML accepts "tests added" as well as {item: ..., required: ...}.
+func (c *ChecklistItem) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Item = node.Value
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: checklist item must be a string or a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if k := node.Content[i].Value; k != "item" && k != "required" {
+			return fmt.Errorf("line %d: unknown key %q in checklist item", node.Content[i].Line, k)
+		}
+	}
+	type plain ChecklistItem
+	return node.Decode((*plain)(c))
+}
+
+// MarshalYAML writes required items back as plain strings.
+func (c ChecklistItem) MarshalYAML() (any, error) {
+	if c.Required == nil {
+		return c.Item, nil
+	}
+	type plain ChecklistItem
+	return plain(c), nil
+}
+
+// Terminal controls how long-running commands signal progress in the
+// terminal itself (window title and bell), so users working in another
+// window notice when hive needs them.
+type Terminal struct {
+	Title *bool `yaml:"title,omitempty"` // Show phase/task progress in the terminal title (default: true)
+	Bell  *bool `yaml:"bell,omitempty"`  // Ring the bell on blockers and when a run finishes (default: true)
+}
+
+// TitleEnabled reports whether terminal title updates are on.
+func (t Terminal) TitleEnabled() bool {
+	return t.Title == nil || *t.Title
+}
+
+// BellEnabled reports whether the terminal bell is on.
+func (t Terminal) BellEnabled() bool {
+	return t.Bell == nil || *t.Bell
+}
+
+// Metrics configures the Prometheus metrics endpoint of long-running
+// commands such as hive auto.
+type Metrics struct {
+	Listen string `yaml:"listen,omitempty"` // Address to serve /metrics on, e.g. ":9090" (default: off)
+}
+
+// Tracing configures OpenTelemetry trace export. Spans cover the pipeline
+// run, each task, each agent call and git operations.
+type Tracing struct {
+	Endpoint string            `yaml:"endpoint,omitempty"` // OTLP/HTTP endpoint, e.g. "http://localhost:4318" (default: off)
+	Headers  map[string]string `yaml:"headers,omitempty"`  // Extra request headers, e.g. an API key for a hosted collector
+}
+
+// Notify configures delivery of watcher notifications (hive task watch)
+// and of pipeline lifecycle events to webhooks.
+type Notify struct {
+	SlackWebhook string    `yaml:"slack_webhook,omitempty"` // Slack incoming webhook URL for slack: targets (default: $HIVE_SLACK_WEBHOOK)
+	Webhooks     []Webhook `yaml:"webhooks,omitempty"`      // Endpoints for lifecycle events, whoever watches what
+}
+
+// Webhook receives pipeline lifecycle events. Webhook URLs are secrets for
+// most services, so the URL can live in an environment variable instead.
+type Webhook struct {
+	URL    string   `yaml:"url,omitempty"`
+	URLEnv string   `yaml:"url_env,omitempty"` // Env var holding the URL, instead of url
+	Format string   `yaml:"format,omitempty"`  // slack, discord or generic (default: from the URL)
+	Events []string `yaml:"events,omitempty"`  // Events to send, from NotifyEvents (default: all)
+}
+
+// NotifyEvents are the lifecycle events a webhook can subscribe to.
+var NotifyEvents = []string{"pipeline_started", "task_blocked", "review_rejected", "epic_ready", "pipeline_failed"}
+
+// WebhookFormats are the valid values for Webhook.Format.
+var WebhookFormats = []string{"generic", "slack", "discord"}
+
+// Serve configures access to hive over HTTP. Each token grants a role:
+//
+//	read     - view the board, events, diffs and artifacts
+//	operator - also answer blockers, run pipelines, accept and reject epics
+//	admin    - also read and change the config
+//
+// Without tokens, only requests from localhost are served.
+type Serve struct {
+	Tokens []ServeToken `yaml:"tokens,omitempty"`
+}
+
+// ServeToken is one API token. The secret itself lives in an environment
+// variable so the config can be committed.
+type ServeToken struct {
+	Name     string `yaml:"name"`      // Who the token is for, e.g. "dashboard"
+	Role     string `yaml:"role"`      // read, operator or admin
+	TokenEnv string `yaml:"token_env"` // Env var holding the token
+}
+
+// ServeRoles are the valid values for ServeToken.Role, least privileged
+// first.
+var ServeRoles = []string{"read", "operator", "admin"}
+
+// KnownLanguages are the languages with built-in hints.
+var KnownLanguages = []string{"go", "node", "python", "rust"}
+
+// LanguageHint is what agents are told about one language. Fields that
+// are set replace the built-in ones; idioms replace the built-in list.
+type LanguageHint struct {
+	Test   string   `yaml:"test,omitempty"`   // Test command, e.g. "go test ./..."
+	Format string   `yaml:"format,omitempty"` // Formatter, e.g. "gofmt -w ."
+	Lint   string   `yaml:"lint,omitempty"`   // Linter, e.g. "go vet ./..."
+	Idioms []string `yaml:"idioms,omitempty"` // Conventions the code should follow
+}
+
+// Redact controls masking of secrets in prompts, events and artifacts.
+// The values of environment variables whose names match the patterns are
+// replaced by [REDACTED:NAME] before they reach an agent or .hive/runs.
+type Redact struct {
+	Disabled    bool     `yaml:"disabled,omitempty"`     // Turn redaction off (default: on)
+	EnvPatterns []string `yaml:"env_patterns,omitempty"` // Extra env var name patterns, e.g. "MY_APP_*" (defaults always apply)
+}
+
+// Output controls the cleanup of agent output before hive stores it in
+// events and artifacts and builds later prompts from it. By default
+// terminal escape codes are stripped and runs of tool chatter (spinners,
+// tool call traces) are collapsed to one line.
+type Output struct {
+	KeepANSI        bool     `yaml:"keep_ansi,omitempty"`         // Keep terminal escape codes
+	KeepToolLogs    bool     `yaml:"keep_tool_logs,omitempty"`    // Don't collapse tool chatter
+	ToolLogPatterns []string `yaml:"tool_log_patterns,omitempty"` // Extra regexps for tool chatter lines (defaults always apply)
+	KeepRaw         bool     `yaml:"keep_raw,omitempty"`          // Also save the untouched output under .hive/runs/raw
+}
+
+// Disk keeps hive from filling the disk. Before it creates a worktree or
+// writes an artifact, it checks that at least MinFreeMB stays free and,
+// when MaxHiveMB is set, that .hive stays under it.
+type Disk struct {
+	MinFreeMB int `yaml:"min_free_mb,omitempty"` // Free space to leave on the disk (default 500; -1 turns the check off)
+	MaxHiveMB int `yaml:"max_hive_mb,omitempty"` // Cap on everything under .hive, worktrees included (default: no cap)
+}
+
+// MinFree returns the free space to leave, in bytes; 0 means no check.
+func (d Disk) MinFree() int64 {
+	switch {
+	case d.MinFreeMB < 0:
+		return 0
+	case d.MinFreeMB == 0:
+		return 500 << 20
+	}
+	return int64(d.MinFreeMB) << 20
+}
+
+// MaxHive returns the cap on .hive in bytes; 0 means no cap.
+func (d Disk) MaxHive() int64 {
+	return int64(d.MaxHiveMB) << 20
+}
+
+// Owners configures code ownership. Owners come from a CODEOWNERS file
+// and from Rules, which are applied after it. The PM and architect see
+// who owns what; tasks naming paths of an owner listed under Routes go to
+// that owner's agent, or wait for a human to approve them.
+type Owners struct {
+	File   string                `yaml:"file,omitempty"`   // CODEOWNERS file (default: .github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS)
+	Rules  []OwnerRule           `yaml:"rules,omitempty"`  // Extra rules, in CODEOWNERS pattern syntax; later ones win
+	Routes map[string]OwnerRoute `yaml:"routes,omitempty"` // By owner, e.g. "@payments"
+}
+
+// OwnerRule assigns owners to the paths matching a pattern.
+type OwnerRule struct {
+	Path   string   `yaml:"path"`
+	Owners []string `yaml:"owners"`
+}
+
+// OwnerRoute is what happens to a task touching an owner's code.
+type OwnerRoute struct {
+	Agent string `yaml:"agent,omitempty"` // Coder agent to assign instead of the default one
+	Gate  bool   `yaml:"gate,omitempty"`  // Block the task until a human approves it (hive answer)
 }
 
 // Agent describes a single AI agent and how to connect to it.
 type Agent struct {
 	Role       string   `yaml:"role"`                  // pm, coder, reviewer, tester, etc.
-	Mode       string   `yaml:"mode"`                  // "cli" or "api"
+	Mode       string   `yaml:"mode"`                  // "cli", "api" or "fake"
 	Cmd        string   `yaml:"cmd,omitempty"`         // CLI command to spawn
 	Args       []string `yaml:"args,omitempty"`        // CLI arguments
 	Provider   string   `yaml:"provider,omitempty"`    // API provider: openai, anthropic, google
-	Model      string   `yaml:"model,omitempty"`       // Model name for API mode
+	Model      string   `yaml:"model,omitempty"`       // Model name for API mode; {model} in CLI args
 	APIKeyEnv  string   `yaml:"api_key_env,omitempty"` // Env var name containing API key
 	TimeoutSec int      `yaml:"timeout_sec,omitempty"` // Timeout in seconds (0 = default 300)
 	AutoAccept bool     `yaml:"auto_accept,omitempty"` // Auto-accept all agent actions (skip permissions)
+	Pricing    Pricing  `yaml:"pricing,omitempty"`     // Token prices, for cost metrics of API agents
+	Vision     bool     `yaml:"vision,omitempty"`      // Model accepts images: attachments are sent inline (API mode)
+	Fixtures   string   `yaml:"fixtures,omitempty"`    // Directory of canned responses (fake mode)
+	PromptVia  string   `yaml:"prompt_via,omitempty"`  // How a CLI agent gets the prompt: arg (default), stdin or file
+
+	// Structured asks the provider for plans and reviews as JSON matching
+	// a schema (API mode). Default on; turn it off for an OpenAI-compatible
+	// endpoint that doesn't support response_format.
+	Structured *bool `yaml:"structured,omitempty"`
+}
+
+// ProviderKey is the key of the agent's calls in Config.Providers: the
+// command of a CLI agent, or its API provider.
+func (a Agent) ProviderKey() string {
+	switch {
+	case a.Mode == "cli" && a.Cmd != "":
+		return filepath.Base(a.Cmd)
+	case a.Provider != "":
+		return a.Provider
+	}
+	return a.Mode
+}
+
+// Pricing is what an API model charges, in USD per million tokens.
+type Pricing struct {
+	Input  float64 `yaml:"input,omitempty"`
+	Output float64 `yaml:"output,omitempty"`
+}
+
+// Cost returns the price of a call with the given token counts.
+func (p Pricing) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
 }
 
 // EffectiveArgs returns the final args for a CLI agent, injecting
@@ -74,6 +682,30 @@ func (a Agent) EffectiveArgs() []string {
 	return args
 }
 
+// How a CLI agent is given its prompt (prompt_via).
+const (
+	PromptViaArg   = "arg"   // The last argument (gemini: --prompt)
+	PromptViaStdin = "stdin" // Written to the process's stdin
+	PromptViaFile  = "file"  // Written to a temp file whose path replaces {prompt_path} in args
+)
+
+// PromptVias lists the valid prompt_via values.
+var PromptVias = []string{PromptViaArg, PromptViaStdin, PromptViaFile}
+
+// PromptMode returns how a CLI agent gets its prompt, defaulting to arg.
+func (a Agent) PromptMode() string {
+	if a.PromptVia == "" {
+		return PromptViaArg
+	}
+	return a.PromptVia
+}
+
+// StructuredEnabled reports whether plans and reviews are asked for as
+// JSON (see Structured).
+func (a Agent) StructuredEnabled() bool {
+	return a.Structured == nil || *a.Structured
+}
+
 // DefaultTimeout returns the effective timeout for the agent.
 func (a Agent) DefaultTimeout() int {
 	if a.TimeoutSec > 0 {
@@ -82,23 +714,10 @@ func (a Agent) DefaultTimeout() int {
 	return 300
 }
 
-// Load reads and parses the config file at the given path.
+// Load reads and parses the config file at the given path, with
+// config.local.yaml from the same directory merged over it.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read config: %w", err)
-	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
-	}
-
-	if err := cfg.validate(); err != nil {
-		return nil, err
-	}
-
-	return &cfg, nil
+	return LoadProfile(path, "")
 }
 
 // Save writes the config to the given path.
@@ -118,27 +737,6 @@ func DefaultConfig() *Config {
 	}
 }
 
-func (c *Config) validate() error {
-	for name, agent := range c.Agents {
-		if agent.Mode == "" {
-			return fmt.Errorf("agent %q: mode is required (cli or api)", name)
-		}
-		if agent.Mode != "cli" && agent.Mode != "api" {
-			return fmt.Errorf("agent %q: mode must be 'cli' or 'api', got %q", name, agent.Mode)
-		}
-		if agent.Mode == "cli" && agent.Cmd == "" {
-			return fmt.Errorf("agent %q: cmd is required for cli mode", name)
-		}
-		if agent.Mode == "api" && agent.Provider == "" {
-			return fmt.Errorf("agent %q: provider is required for api mode", name)
-		}
-		if agent.Role == "" {
-			return fmt.Errorf("agent %q: role is required", name)
-		}
-	}
-	return nil
-}
-
 // containsAny checks if any of the targets exist in the slice.
 func containsAny(slice []string, targets ...string) bool {
 	for _, s := range slice {
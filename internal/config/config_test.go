@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // --- EffectiveArgs tests ---
@@ -430,3 +432,346 @@ func TestAgentsByRole(t *testing.T) {
 		t.Fatalf("expected 0 pm agents, got %d", len(none))
 	}
 }
+
+// --- Terminal tests ---
+
+func TestTerminal_DefaultsEnabled(t *testing.T) {
+	var term Terminal
+	if !term.TitleEnabled() {
+		t.Fatal("expected title updates enabled by default")
+	}
+	if !term.BellEnabled() {
+		t.Fatal("expected bell enabled by default")
+	}
+}
+
+func TestLoad_TerminalDisabled(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "hive.yaml")
+	data := `version: 1
+agents: {}
+terminal:
+  title: false
+  bell: false
+`
+	os.WriteFile(p, []byte(data), 0644)
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Terminal.TitleEnabled() {
+		t.Fatal("expected title updates disabled")
+	}
+	if cfg.Terminal.BellEnabled() {
+		t.Fatal("expected bell disabled")
+	}
+}
+
+func TestLoad_CustomRole(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "hive.yaml")
+	data := `version: 1
+agents:
+  auditor:
+    mode: cli
+    cmd: claude
+    role: security
+roles:
+  security:
+    header: "# You are a Security Auditor"
+    stage: after_code
+    verdict: true
+`
+	os.WriteFile(p, []byte(data), 0644)
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	role, ok := cfg.Roles["security"]
+	if !ok {
+		t.Fatal("expected security role")
+	}
+	if role.Stage != "after_code" || !role.Verdict {
+		t.Fatalf("unexpected role: %+v", role)
+	}
+}
+
+func TestLoad_RoleInvalid(t *testing.T) {
+	cases := map[string]string{
+		"bad stage": `roles:
+  security:
+    stage: whenever
+`,
+		"verdict without after_code": `roles:
+  security:
+    stage: before_code
+    verdict: true
+`,
+		"builtin with stage": `roles:
+  reviewer:
+    stage: after_code
+`,
+		"unknown omitted section": `roles:
+  reviewer:
+    omit: [history, diff]
+`,
+	}
+
+	for name, roles := range cases {
+		dir := t.TempDir()
+		p := filepath.Join(dir, "hive.yaml")
+		os.WriteFile(p, []byte("version: 1\nagents: {}\n"+roles), 0644)
+
+		if _, err := Load(p); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDocs_TargetPaths(t *testing.T) {
+	var d Docs
+	if got := d.TargetPaths(); len(got) != len(DefaultDocPaths) {
+		t.Fatalf("expected default paths, got %v", got)
+	}
+
+	d.Paths = []string{"docs/guide.md"}
+	got := d.TargetPaths()
+	if len(got) != 1 || got[0] != "docs/guide.md" {
+		t.Fatalf("expected configured paths, got %v", got)
+	}
+}
+
+func TestTesting_Defaults(t *testing.T) {
+	var tc Testing
+	if tc.TesterStage() != "after_code" {
+		t.Errorf("expected after_code, got %q", tc.TesterStage())
+	}
+	if tc.CmdTimeout() != 600 {
+		t.Errorf("expected 600, got %d", tc.CmdTimeout())
+	}
+}
+
+func TestLoad_TestingInvalidStage(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "hive.yaml")
+	os.WriteFile(p, []byte("version: 1\nagents: {}\ntesting:\n  stage: after_review\n"), 0644)
+
+	if _, err := Load(p); err == nil {
+		t.Fatal("expected error for invalid testing stage")
+	}
+}
+
+func TestLoad_TiebreakerInvalid(t *testing.T) {
+	cases := map[string]string{
+		"missing":        "review:\n  tiebreaker: nobody\n",
+		"not a reviewer": "agents:\n  claude:\n    role: coder\n    mode: cli\n    cmd: claude\nreview:\n  tiebreaker: claude\n",
+	}
+	for name, body := range cases {
+		p := filepath.Join(t.TempDir(), "hive.yaml")
+		os.WriteFile(p, []byte("version: 1\n"+body), 0644)
+		if _, err := Load(p); err == nil || !strings.Contains(err.Error(), "tiebreaker") {
+			t.Errorf("%s: expected a tiebreaker error, got %v", name, err)
+		}
+	}
+}
+
+func TestPerf_Defaults(t *testing.T) {
+	var p Perf
+	if p.BaselinePath() != ".hive/perf-baseline.txt" {
+		t.Errorf("unexpected baseline path %q", p.BaselinePath())
+	}
+	if p.TolerancePct() != 10 {
+		t.Errorf("expected 10%%, got %v", p.TolerancePct())
+	}
+	if p.FlagOnly() {
+		t.Error("expected regressions to go back to the coder by default")
+	}
+}
+
+func TestDisk_Defaults(t *testing.T) {
+	var d Disk
+	if d.MinFree() != 500<<20 || d.MaxHive() != 0 {
+		t.Errorf("expected 500 MB free and no cap, got %d and %d", d.MinFree(), d.MaxHive())
+	}
+	d = Disk{MinFreeMB: -1, MaxHiveMB: 2048}
+	if d.MinFree() != 0 || d.MaxHive() != 2<<30 {
+		t.Errorf("expected no free-space check and a 2 GB cap, got %d and %d", d.MinFree(), d.MaxHive())
+	}
+
+	p := filepath.Join(t.TempDir(), "hive.yaml")
+	os.WriteFile(p, []byte("version: 1\nagents: {}\ndisk:\n  max_hive_mb: -5\n"), 0644)
+	if _, err := Load(p); err == nil || !strings.Contains(err.Error(), "max_hive_mb") {
+		t.Errorf("expected a max_hive_mb error, got %v", err)
+	}
+}
+
+func TestLogging_APILevel(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "hive.yaml")
+	os.WriteFile(p, []byte("version: 1\nagents: {}\nlogging:\n  api: full\n"), 0644)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Logging.API != "full" {
+		t.Errorf("expected full, got %q", cfg.Logging.API)
+	}
+
+	os.WriteFile(p, []byte("version: 1\nagents: {}\nlogging:\n  api: verbose\n"), 0644)
+	if _, err := Load(p); err == nil || !strings.Contains(err.Error(), "logging: api") {
+		t.Errorf("expected a logging.api error, got %v", err)
+	}
+}
+
+func TestAgent_ProviderKey(t *testing.T) {
+	cases := []struct {
+		agent Agent
+		want  string
+	}{
+		{Agent{Mode: "api", Provider: "anthropic"}, "anthropic"},
+		{Agent{Mode: "cli", Cmd: "/usr/local/bin/claude"}, "claude"},
+		{Agent{Mode: "cli", Cmd: "codex", Provider: "openai"}, "codex"},
+	}
+	for _, c := range cases {
+		if got := c.agent.ProviderKey(); got != c.want {
+			t.Errorf("%+v: expected %q, got %q", c.agent, c.want, got)
+		}
+	}
+
+	p := filepath.Join(t.TempDir(), "hive.yaml")
+	os.WriteFile(p, []byte("version: 1\nagents: {}\nproviders:\n  openai:\n    rpm: -1\n"), 0644)
+	if _, err := Load(p); err == nil || !strings.Contains(err.Error(), "providers: openai: rpm") {
+		t.Errorf("expected a providers rpm error, got %v", err)
+	}
+}
+
+func TestHistory_Defaults(t *testing.T) {
+	var h History
+	long := strings.Repeat("x", 300)
+	if got := h.Preview(long); got != long[:200]+"..." {
+		t.Errorf("expected a 200-char preview, got %d chars", len(got))
+	}
+	if got := h.Preview("short"); got != "short" {
+		t.Errorf("short output should stay whole, got %q", got)
+	}
+	if h.InlineBudget() != 4000 {
+		t.Errorf("expected a 4000-token budget, got %d", h.InlineBudget())
+	}
+	h = History{PreviewChars: 1000, InlineTokens: 500}
+	if got := h.Preview(long); got != long || h.InlineBudget() != 500 {
+		t.Errorf("custom settings ignored: %d chars, budget %d", len(got), h.InlineBudget())
+	}
+}
+
+func TestLoad_PerfInvalidOnRegression(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "hive.yaml")
+	os.WriteFile(p, []byte("version: 1\nagents: {}\nperf:\n  cmd: make bench\n  on_regression: ignore\n"), 0644)
+
+	if _, err := Load(p); err == nil {
+		t.Fatal("expected error for invalid on_regression")
+	}
+}
+
+func TestAccept_Enabled(t *testing.T) {
+	var all Accept
+	for _, c := range AcceptChecks {
+		if !all.Enabled(c) {
+			t.Errorf("expected %s enabled by default", c)
+		}
+	}
+
+	some := Accept{Checks: []string{"conflicts"}}
+	if !some.Enabled("conflicts") || some.Enabled("tests") {
+		t.Errorf("expected only conflicts enabled, got %v", some.Checks)
+	}
+}
+
+func TestLoad_AcceptUnknownCheck(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "hive.yaml")
+	os.WriteFile(p, []byte("version: 1\nagents: {}\naccept:\n  checks: [tests, lint]\n"), 0644)
+
+	if _, err := Load(p); err == nil {
+		t.Fatal("expected error for unknown accept check")
+	}
+}
+
+func TestPricing_Cost(t *testing.T) {
+	p := Pricing{Input: 3, Output: 15}
+	if got := p.Cost(1_000_000, 100_000); got != 4.5 {
+		t.Errorf("Cost = %v, want 4.5", got)
+	}
+	if got := (Pricing{}).Cost(500, 500); got != 0 {
+		t.Errorf("zero pricing Cost = %v, want 0", got)
+	}
+}
+
+func TestLoad_ReviewChecklist(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte(`version: 1
+agents: {}
+review:
+  checklist:
+    - tests added
+    - item: changelog entry
+      required: false
+`), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	list := cfg.Review.Checklist
+	if len(list) != 2 || list[0].Item != "tests added" || !list[0].IsRequired() {
+		t.Fatalf("unexpected checklist %+v", list)
+	}
+	if list[1].Item != "changelog entry" || list[1].IsRequired() {
+		t.Errorf("expected an optional changelog item, got %+v", list[1])
+	}
+}
+
+func TestLoad_ReviewChecklistUnknownKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte(`version: 1
+agents: {}
+review:
+  checklist:
+    - item: tests added
+      mandatory: true
+`), 0644)
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "mandatory") {
+		t.Errorf("expected an unknown key error, got %v", err)
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	if d := (Backoff{}).Delay(3); d != 0 {
+		t.Errorf("expected no wait by default, got %s", d)
+	}
+	b := Backoff{InitialSec: 100}
+	for iteration, want := range map[int]time.Duration{1: 0, 2: 100 * time.Second, 3: 200 * time.Second, 4: 300 * time.Second} {
+		if got := b.Delay(iteration); got != want {
+			t.Errorf("iteration %d: got %s, want %s", iteration, got, want)
+		}
+	}
+}
+
+func TestConfirmAbove_Exceeded(t *testing.T) {
+	if over := (ConfirmAbove{}).Exceeded(500, 10*time.Hour, 100); over != nil {
+		t.Errorf("expected no limits by default, got %v", over)
+	}
+	c := ConfirmAbove{Calls: 20, Minutes: 30, Cost: 2}
+	if over := c.Exceeded(20, 30*time.Minute, 2); over != nil {
+		t.Errorf("expected limits to be inclusive, got %v", over)
+	}
+	over := c.Exceeded(21, 45*time.Minute, 3.2)
+	want := []string{"21 calls > 20", "45m0s > 30m", "cost $3.20 > $2.00"}
+	if strings.Join(over, "|") != strings.Join(want, "|") {
+		t.Errorf("got %v, want %v", over, want)
+	}
+}
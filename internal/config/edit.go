@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// splitKey splits a dotted key like agents.claude.timeout_sec.
+func splitKey(key string) ([]string, error) {
+	parts := strings.Split(key, ".")
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+	}
+	return parts, nil
+}
+
+// Get returns the effective value of a dotted key in the config at path
+// with its layers (see LoadProfile): scalars as plain text, mappings and
+// lists as YAML. ok is false when no layer sets the key.
+func Get(path, profile, key string) (value string, ok bool, err error) {
+	parts, err := splitKey(key)
+	if err != nil {
+		return "", false, err
+	}
+	layers, err := readLayers(path, profile)
+	if err != nil {
+		return "", false, err
+	}
+	merged, err := mergeMaps(layers)
+	if err != nil {
+		return "", false, err
+	}
+
+	var cur any = merged
+	for _, p := range parts {
+		m, isMap := cur.(map[string]any)
+		if !isMap {
+			return "", false, nil
+		}
+		if cur, ok = m[p]; !ok {
+			return "", false, nil
+		}
+	}
+
+	switch v := cur.(type) {
+	case map[string]any, []any:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return "", false, err
+		}
+		return strings.TrimRight(string(out), "\n"), true, nil
+	default:
+		return fmt.Sprint(v), true, nil
+	}
+}
+
+// Set sets a dotted key in the config file file to value, parsed as YAML
+// (so "true", "300" and "[a, b]" keep their types). Comments and key order
+// in the file are preserved. The change is validated together with the
+// other layers of path and profile first, and not written if it would
+// make the config invalid. file may not exist yet, e.g. config.local.yaml.
+func Set(path, profile, file, key, value string) error {
+	parts, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	updated, err := setNode(data, parts, value)
+	if err != nil {
+		return err
+	}
+
+	layers, err := readLayers(path, profile)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range layers {
+		if layers[i].path == file {
+			layers[i].data, replaced = updated, true
+		}
+	}
+	if !replaced {
+		layers = append(layers, layer{file, updated})
+	}
+	if issues := validateLayers(layers); len(issues) > 0 {
+		return fmt.Errorf("%s would be invalid: %s", key, issues[0].Message)
+	}
+
+	return os.WriteFile(file, updated, 0644)
+}
+
+// setNode sets the key path in the YAML document data, creating mappings
+// as needed, and returns the re-encoded document.
+func setNode(data []byte, parts []string, value string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	var val yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &val); err != nil {
+		return nil, fmt.Errorf("parse value: %w", err)
+	}
+	newValue := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	if len(val.Content) > 0 {
+		newValue = val.Content[0]
+	}
+
+	node := doc.Content[0]
+	for i, key := range parts {
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			// "docs:" with nothing under it yet.
+			*node = yaml.Node{Kind: yaml.MappingNode, LineComment: node.LineComment}
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(parts[:i], "."))
+		}
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				next = node.Content[j+1]
+				if i == len(parts)-1 {
+					// Keep a trailing "# comment" on the old value.
+					newValue.LineComment = next.LineComment
+					node.Content[j+1] = newValue
+				}
+				break
+			}
+		}
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			if i == len(parts)-1 {
+				next = newValue
+			}
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, next)
+		}
+		node = next
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the indentation width the file already uses, so
+// an edit doesn't reformat it. Defaults to 2.
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if n := len(line) - len(trimmed); n > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "- ") {
+			return n
+		}
+	}
+	return 2
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const editBase = `# Project config
+version: 1
+agents:
+  claude:
+    role: coder
+    mode: cli
+    cmd: claude
+    timeout_sec: 300 # five minutes
+  codex:
+    role: reviewer
+    mode: cli
+    cmd: codex
+`
+
+func TestSet_PreservesCommentsAndOrder(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": editBase})
+
+	if err := Set(p, "", p, "agents.claude.timeout_sec", "600"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	data, _ := os.ReadFile(p)
+	got := string(data)
+
+	want := strings.Replace(editBase, "timeout_sec: 300", "timeout_sec: 600", 1)
+	if got != want {
+		t.Errorf("unexpected file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSet_TypedValue(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": editBase})
+
+	if err := Set(p, "", p, "agents.claude.auto_accept", "true"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Agents["claude"].AutoAccept {
+		t.Error("expected auto_accept to be the boolean true")
+	}
+}
+
+func TestSet_CreatesNestedKeys(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": editBase})
+
+	if err := Set(p, "", p, "testing.cmd", "go test ./..."); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Testing.Cmd != "go test ./..." {
+		t.Errorf("expected testing.cmd to be set, got %q", cfg.Testing.Cmd)
+	}
+}
+
+func TestSet_LocalFileCreated(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": editBase})
+	local := filepath.Join(filepath.Dir(p), LocalFile)
+
+	if err := Set(p, "", local, "agents.claude.timeout_sec", "900"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	base, _ := os.ReadFile(p)
+	if string(base) != editBase {
+		t.Error("config.yaml should be untouched")
+	}
+	v, ok, err := Get(p, "", "agents.claude.timeout_sec")
+	if err != nil || !ok || v != "900" {
+		t.Errorf("expected the local override, got %q %v %v", v, ok, err)
+	}
+}
+
+func TestSet_RejectsInvalid(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": editBase})
+
+	for key, value := range map[string]string{
+		"agents.claude.mode":       "telepathy",
+		"agents.claude.auto_acept": "true",
+		"version.major":            "1",
+	} {
+		if err := Set(p, "", p, key, value); err == nil {
+			t.Errorf("Set %s=%s: expected an error", key, value)
+		}
+	}
+	data, _ := os.ReadFile(p)
+	if string(data) != editBase {
+		t.Errorf("file should be unchanged, got:\n%s", data)
+	}
+}
+
+func TestGet(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": editBase})
+
+	if v, ok, err := Get(p, "", "agents.claude.timeout_sec"); err != nil || !ok || v != "300" {
+		t.Errorf("expected 300, got %q %v %v", v, ok, err)
+	}
+	if v, ok, _ := Get(p, "", "agents.codex"); !ok || !strings.Contains(v, "cmd: codex") {
+		t.Errorf("expected the mapping as YAML, got %q", v)
+	}
+	if _, ok, _ := Get(p, "", "agents.claude.model"); ok {
+		t.Error("expected an unset key to report ok=false")
+	}
+	if _, _, err := Get(p, "", "agents..cmd"); err == nil {
+		t.Error("expected an error for an empty key segment")
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	if n := detectIndent([]byte("agents:\n    claude:\n        cmd: claude\n")); n != 4 {
+		t.Errorf("expected 4, got %d", n)
+	}
+	if n := detectIndent(nil); n != 2 {
+		t.Errorf("expected default 2, got %d", n)
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocalFile is the per-machine overlay next to config.yaml. It is applied
+// last and is meant to stay out of version control.
+const LocalFile = "config.local.yaml"
+
+// UserConfigPath returns the user-level config that every project's config
+// is layered over: $XDG_CONFIG_HOME/hive/config.yaml, by default
+// ~/.config/hive/config.yaml. Returns "" if no home directory is known.
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "hive", "config.yaml")
+}
+
+// ProfileFile returns the file name of a profile overlay, e.g.
+// config.work.yaml for profile "work".
+func ProfileFile(profile string) string {
+	return "config." + profile + ".yaml"
+}
+
+// OverlayPaths returns the files layered over the base config at path, in
+// the order they apply: the profile (when set), then config.local.yaml.
+func OverlayPaths(path, profile string) []string {
+	dir := filepath.Dir(path)
+	var paths []string
+	if profile != "" {
+		paths = append(paths, filepath.Join(dir, ProfileFile(profile)))
+	}
+	return append(paths, filepath.Join(dir, LocalFile))
+}
+
+// LoadProfile reads the project config at path, layered over the user
+// config (UserConfigPath), and deep-merges the overlays from OverlayPaths
+// over both. Mappings merge key by key; any other value, lists included,
+// replaces the base value, and null removes it. A named profile must
+// exist; the user config and config.local.yaml are optional.
+//
+// Every layer is decoded strictly, so a misspelled key is an error rather
+// than silently ignored.
+func LoadProfile(path, profile string) (*Config, error) {
+	layers, err := readLayers(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range layers {
+		if issues := strictIssues(l); len(issues) > 0 {
+			return nil, fmt.Errorf("%s", issues[0])
+		}
+	}
+	cfg, err := mergeLayers(layers)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// layer is one config file that contributes to the merged config.
+type layer struct {
+	path string
+	data []byte
+}
+
+// readLayers reads the existing config files for path and profile, lowest
+// precedence first.
+func readLayers(path, profile string) ([]layer, error) {
+	if profile != "" && !validProfileName(profile) {
+		return nil, fmt.Errorf("invalid profile name %q", profile)
+	}
+
+	var layers []layer
+	if user := UserConfigPath(); user != "" {
+		data, err := os.ReadFile(user)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("read user config: %w", err)
+		}
+		if err == nil {
+			layers = append(layers, layer{user, data})
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	layers = append(layers, layer{path, data})
+
+	for i, overlay := range OverlayPaths(path, profile) {
+		data, err := os.ReadFile(overlay)
+		if os.IsNotExist(err) {
+			if profile != "" && i == 0 {
+				return nil, fmt.Errorf("profile %q: %s not found", profile, overlay)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		layers = append(layers, layer{overlay, data})
+	}
+	return layers, nil
+}
+
+// mergeLayers deep-merges the layers in order and decodes the result.
+// The merged config is not validated.
+func mergeLayers(layers []layer) (*Config, error) {
+	merged, err := mergeMaps(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("merge config: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// mergeMaps deep-merges the layers in order as generic mappings.
+func mergeMaps(layers []layer) (map[string]any, error) {
+	merged := map[string]any{}
+	for _, l := range layers {
+		m, err := parseMap(l.path, l.data)
+		if err != nil {
+			return nil, err
+		}
+		merged = deepMerge(merged, m)
+	}
+	return merged, nil
+}
+
+// parseMap parses one config file as a generic mapping.
+func parseMap(path string, data []byte) (map[string]any, error) {
+	m := map[string]any{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// deepMerge merges over into base and returns base.
+func deepMerge(base, over map[string]any) map[string]any {
+	for k, v := range over {
+		if v == nil {
+			delete(base, k)
+			continue
+		}
+		bm, bok := base[k].(map[string]any)
+		om, ook := v.(map[string]any)
+		if bok && ook {
+			base[k] = deepMerge(bm, om)
+			continue
+		}
+		base[k] = v
+	}
+	return base
+}
+
+// validProfileName reports whether name can be used as a profile: it
+// becomes part of a file name, so path separators and dots are out.
+func validProfileName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\.`)
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain keeps the developer's own ~/.config/hive out of every test.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "hive-config-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+const overlayBase = `version: 1
+agents:
+  coder:
+    role: coder
+    mode: api
+    provider: anthropic
+    model: claude-sonnet-4
+    api_key_env: ANTHROPIC_API_KEY
+  reviewer:
+    role: reviewer
+    mode: cli
+    cmd: codex
+testing:
+  cmd: go test ./...
+accept:
+  checks: [clean, tests]
+`
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+func TestLoadProfile_DeepMergesProfile(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{
+		"config.yaml": overlayBase,
+		"config.home.yaml": `agents:
+  coder:
+    provider: openai
+    model: qwen2.5-coder
+accept:
+  checks: [clean]
+`,
+	})
+
+	cfg, err := LoadProfile(p, "home")
+	if err != nil {
+		t.Fatalf("LoadProfile: %v", err)
+	}
+	coder := cfg.Agents["coder"]
+	if coder.Model != "qwen2.5-coder" || coder.Provider != "openai" {
+		t.Errorf("profile values not applied: %+v", coder)
+	}
+	if coder.APIKeyEnv != "ANTHROPIC_API_KEY" || coder.Role != "coder" {
+		t.Errorf("base values lost in merge: %+v", coder)
+	}
+	if cfg.Agents["reviewer"].Cmd != "codex" {
+		t.Error("agent absent from the profile should be kept")
+	}
+	if len(cfg.Accept.Checks) != 1 || cfg.Accept.Checks[0] != "clean" {
+		t.Errorf("lists should be replaced, got %v", cfg.Accept.Checks)
+	}
+	if cfg.Testing.Cmd != "go test ./..." {
+		t.Errorf("testing.cmd = %q", cfg.Testing.Cmd)
+	}
+}
+
+func TestLoadProfile_LocalAppliesLast(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{
+		"config.yaml":       overlayBase,
+		"config.work.yaml":  "testing:\n  cmd: make test\n",
+		"config.local.yaml": "testing:\n  cmd: make quick\n",
+	})
+
+	cfg, err := LoadProfile(p, "work")
+	if err != nil {
+		t.Fatalf("LoadProfile: %v", err)
+	}
+	if cfg.Testing.Cmd != "make quick" {
+		t.Errorf("expected local overlay to win, got %q", cfg.Testing.Cmd)
+	}
+
+	// Load applies the local overlay without a profile.
+	cfg, err = Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Testing.Cmd != "make quick" {
+		t.Errorf("Load should apply config.local.yaml, got %q", cfg.Testing.Cmd)
+	}
+}
+
+func TestLoadProfile_NullRemoves(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{
+		"config.yaml":       overlayBase,
+		"config.local.yaml": "agents:\n  reviewer: null\n",
+	})
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := cfg.Agents["reviewer"]; ok {
+		t.Error("null should remove the reviewer agent")
+	}
+}
+
+func TestLoadProfile_MissingProfile(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase})
+
+	_, err := LoadProfile(p, "work")
+	if err == nil || !strings.Contains(err.Error(), "config.work.yaml") {
+		t.Fatalf("expected missing profile error, got %v", err)
+	}
+	if _, err := LoadProfile(p, "../etc"); err == nil {
+		t.Fatal("expected error for invalid profile name")
+	}
+}
+
+func TestLoadProfile_ValidatesMerged(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{
+		"config.yaml":       overlayBase,
+		"config.local.yaml": "agents:\n  reviewer:\n    mode: ssh\n",
+	})
+
+	if _, err := Load(p); err == nil {
+		t.Fatal("expected validation error from overlay value")
+	}
+}
+
+func TestLoad_UserConfigIsBaseLayer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	os.MkdirAll(filepath.Join(home, "hive"), 0755)
+	os.WriteFile(filepath.Join(home, "hive", "config.yaml"), []byte(`agents:
+  claude:
+    role: coder
+    mode: cli
+    cmd: claude
+  gpt:
+    role: reviewer
+    mode: api
+    provider: openai
+    api_key_env: OPENAI_API_KEY
+terminal:
+  bell: false
+`), 0644)
+
+	p := writeConfigFiles(t, map[string]string{
+		"config.yaml": "version: 1\nagents:\n  gpt:\n    model: gpt-4o\n",
+	})
+	if got := UserConfigPath(); got != filepath.Join(home, "hive", "config.yaml") {
+		t.Fatalf("UserConfigPath = %q", got)
+	}
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Agents["claude"].Cmd != "claude" {
+		t.Error("agent from user config missing")
+	}
+	gpt := cfg.Agents["gpt"]
+	if gpt.Model != "gpt-4o" || gpt.Provider != "openai" {
+		t.Errorf("project should merge over user agent: %+v", gpt)
+	}
+	if cfg.Terminal.BellEnabled() {
+		t.Error("user default terminal.bell should apply")
+	}
+}
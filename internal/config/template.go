@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplatesDir is where epic templates live, next to config.yaml.
+const TemplatesDir = "templates"
+
+// EpicTemplate is a reusable epic: hive epic create --template <name>
+// creates its tasks as written, without asking the PM. Meant for
+// recurring work such as a release checklist.
+type EpicTemplate struct {
+	Title       string         `yaml:"title,omitempty"`       // Default epic title when none is given
+	Description string         `yaml:"description,omitempty"` // Epic description
+	Priority    string         `yaml:"priority,omitempty"`    // Epic priority (default: the --priority flag)
+	Tasks       []TemplateTask `yaml:"tasks"`
+}
+
+// TemplateTask is one task of an epic template.
+type TemplateTask struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description,omitempty"`
+	Priority    string   `yaml:"priority,omitempty"`   // high, medium (default) or low
+	Role        string   `yaml:"role,omitempty"`       // Role whose agent does the task (default: the coder)
+	Acceptance  []string `yaml:"acceptance,omitempty"` // Acceptance criteria the reviewer checks
+}
+
+// LoadTemplate reads the epic template name from dir/templates/name.yaml
+// (or .yml), where dir is the .hive directory. Unknown keys are errors,
+// as in config.yaml.
+func LoadTemplate(dir, name string) (*EpicTemplate, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	var data []byte
+	var path string
+	var err error
+	for _, ext := range []string{".yaml", ".yml"} {
+		path = filepath.Join(dir, TemplatesDir, name+ext)
+		if data, err = os.ReadFile(path); err == nil || !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		msg := fmt.Sprintf("no template %q in %s", name, filepath.Join(dir, TemplatesDir))
+		if names := ListTemplates(dir); len(names) > 0 {
+			msg += " (have: " + strings.Join(names, ", ") + ")"
+		}
+		return nil, errors.New(msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read template: %w", err)
+	}
+
+	var t EpicTemplate
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(t.Tasks) == 0 {
+		return nil, fmt.Errorf("%s: template has no tasks", path)
+	}
+	if !validPriority(t.Priority) {
+		return nil, fmt.Errorf("%s: priority must be high, medium or low, got %q", path, t.Priority)
+	}
+	for i, task := range t.Tasks {
+		if strings.TrimSpace(task.Title) == "" {
+			return nil, fmt.Errorf("%s: task %d has no title", path, i+1)
+		}
+		if !validPriority(task.Priority) {
+			return nil, fmt.Errorf("%s: task %d: priority must be high, medium or low, got %q", path, i+1, task.Priority)
+		}
+	}
+	return &t, nil
+}
+
+// validPriority reports whether p is a task priority, or unset.
+func validPriority(p string) bool {
+	switch p {
+	case "", "high", "medium", "low":
+		return true
+	}
+	return false
+}
+
+// ListTemplates returns the names of the epic templates in dir/templates.
+func ListTemplates(dir string) []string {
+	entries, _ := os.ReadDir(filepath.Join(dir, TemplatesDir))
+	var names []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, strings.TrimSuffix(e.Name(), ext))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, dir, file, content string) {
+	t.Helper()
+	os.MkdirAll(filepath.Join(dir, TemplatesDir), 0755)
+	if err := os.WriteFile(filepath.Join(dir, TemplatesDir, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "release.yaml", `title: Release
+priority: high
+tasks:
+  - title: Bump the version
+    priority: high
+  - title: Update the changelog
+    role: docs
+    acceptance:
+      - Lists every merged PR
+`)
+	writeTemplate(t, dir, "hotfix.yml", "tasks:\n  - title: Fix it\n")
+
+	tpl, err := LoadTemplate(dir, "release")
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+	if tpl.Title != "Release" || len(tpl.Tasks) != 2 {
+		t.Fatalf("unexpected template %+v", tpl)
+	}
+	if got := tpl.Tasks[1]; got.Role != "docs" || len(got.Acceptance) != 1 {
+		t.Errorf("unexpected second task %+v", got)
+	}
+	if _, err := LoadTemplate(dir, "hotfix"); err != nil {
+		t.Errorf(".yml template: %v", err)
+	}
+
+	if got := ListTemplates(dir); strings.Join(got, ",") != "hotfix,release" {
+		t.Errorf("ListTemplates = %v", got)
+	}
+	_, err = LoadTemplate(dir, "relase")
+	if err == nil || !strings.Contains(err.Error(), "have: hotfix, release") {
+		t.Errorf("missing template error should list templates, got %v", err)
+	}
+}
+
+func TestLoadTemplate_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "empty.yaml", "title: Nothing\n")
+	writeTemplate(t, dir, "typo.yaml", "tasks:\n  - title: A\n    prority: high\n")
+	writeTemplate(t, dir, "untitled.yaml", "tasks:\n  - description: no title\n")
+	writeTemplate(t, dir, "urgent.yaml", "priority: urgent\ntasks:\n  - title: A\n")
+	writeTemplate(t, dir, "urgent-task.yaml", "tasks:\n  - title: A\n    priority: urgent\n")
+
+	for name, want := range map[string]string{
+		"empty":       "no tasks",
+		"typo":        "prority",
+		"untitled":    "task 1 has no title",
+		"urgent":      "priority must be high, medium or low, got \"urgent\"",
+		"urgent-task": "task 1: priority must be",
+		"../x":        "invalid template name",
+	} {
+		if _, err := LoadTemplate(dir, name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, want, err)
+		}
+	}
+}
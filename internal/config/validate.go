@@ -0,0 +1,390 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Providers are the API providers an agent in api mode can use.
+var Providers = []string{"openai", "anthropic", "google"}
+
+// Issue is one problem found in a config file.
+type Issue struct {
+	File    string // Config file the problem is in; empty if unknown
+	Line    int    // 1-based line, or 0 if unknown
+	Message string
+}
+
+func (i Issue) String() string {
+	switch {
+	case i.File != "" && i.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	case i.File != "":
+		return fmt.Sprintf("%s: %s", i.File, i.Message)
+	default:
+		return i.Message
+	}
+}
+
+// Validate checks the config at path with its layers (see LoadProfile)
+// and returns every problem found, with file and line where known. It
+// returns nil for a valid config.
+func Validate(path, profile string) []Issue {
+	layers, err := readLayers(path, profile)
+	if err != nil {
+		return []Issue{{Message: err.Error()}}
+	}
+	return validateLayers(layers)
+}
+
+// validateLayers is Validate for layers already in memory.
+func validateLayers(layers []layer) []Issue {
+	var issues []Issue
+	for _, l := range layers {
+		issues = append(issues, strictIssues(l)...)
+	}
+
+	cfg, err := mergeLayers(layers)
+	if err != nil {
+		if len(issues) > 0 {
+			return issues // A syntax error, already reported with its line.
+		}
+		return []Issue{{Message: err.Error()}}
+	}
+	for _, p := range cfg.problems() {
+		issue := Issue{Message: p.msg}
+		// The layer with the highest precedence that sets the field is
+		// the one to fix.
+		for i := len(layers) - 1; i >= 0; i-- {
+			if line := lineOf(layers[i].data, p.path); line > 0 {
+				issue.File, issue.Line = layers[i].path, line
+				break
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// yamlLine matches the "line N: " prefix of yaml.v3 error messages.
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// strictIssues decodes one layer rejecting unknown keys, and returns the
+// syntax and schema errors it finds.
+func strictIssues(l layer) []Issue {
+	dec := yaml.NewDecoder(bytes.NewReader(l.data))
+	dec.KnownFields(true)
+	var cfg Config
+	err := dec.Decode(&cfg)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	msgs := []string{err.Error()}
+	var te *yaml.TypeError
+	if errors.As(err, &te) {
+		msgs = te.Errors
+	}
+
+	var issues []Issue
+	for _, msg := range msgs {
+		issue := Issue{File: l.path, Message: msg}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+			issue.Message = friendlyYAMLError(m[2])
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// unknownField matches yaml.v3's "field x not found in type config.Agent".
+var unknownField = regexp.MustCompile(`^field (\S+) not found in type config\.(\w+)$`)
+
+// friendlyYAMLError rewrites yaml.v3 wording that leaks Go type names.
+func friendlyYAMLError(msg string) string {
+	if m := unknownField.FindStringSubmatch(msg); m != nil {
+		return fmt.Sprintf("unknown key %q in %s", m[1], sectionName(m[2]))
+	}
+	return msg
+}
+
+// sectionName names the config section a Go type decodes.
+func sectionName(typeName string) string {
+	switch typeName {
+	case "Config":
+		return "config"
+	case "Agent":
+		return "agent"
+	case "RoleDef":
+		return "role"
+	case "OwnerRule":
+		return "owners rule"
+	case "OwnerRoute":
+		return "owners route"
+	default:
+		return strings.ToLower(typeName)
+	}
+}
+
+// lineOf returns the line of the deepest key along path that data sets,
+// or 0 if it doesn't set the first one. Numeric keys index into lists.
+func lineOf(data []byte, path []string) int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+	node, line := doc.Content[0], 0
+	for _, key := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					line, next = node.Content[i].Line, node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			// List elements are addressed by index.
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line
+}
+
+// problem is a semantic error in a merged config, with the path of the
+// field it concerns.
+type problem struct {
+	path []string
+	msg  string
+}
+
+func (c *Config) validate() error {
+	if p := c.problems(); len(p) > 0 {
+		return errors.New(p[0].msg)
+	}
+	return nil
+}
+
+// problems returns every semantic error in the config, in a stable order.
+func (c *Config) problems() []problem {
+	var out []problem
+	add := func(msg string, path ...string) {
+		out = append(out, problem{path: path, msg: msg})
+	}
+
+	names := make([]string, 0, len(c.Agents))
+	for name := range c.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		agent := c.Agents[name]
+		switch {
+		case agent.Mode == "":
+			add(fmt.Sprintf("agent %q: mode is required (cli or api)", name), "agents", name, "mode")
+		case agent.Mode != "cli" && agent.Mode != "api" && agent.Mode != "fake":
+			add(fmt.Sprintf("agent %q: mode must be 'cli', 'api' or 'fake', got %q", name, agent.Mode), "agents", name, "mode")
+		case agent.Mode == "cli" && agent.Cmd == "":
+			add(fmt.Sprintf("agent %q: cmd is required for cli mode", name), "agents", name, "cmd")
+		case agent.Mode == "api" && agent.Provider == "":
+			add(fmt.Sprintf("agent %q: provider is required for api mode", name), "agents", name, "provider")
+		case agent.Mode == "api" && !containsAny(Providers, agent.Provider):
+			add(fmt.Sprintf("agent %q: provider must be one of %v, got %q", name, Providers, agent.Provider), "agents", name, "provider")
+		}
+		if agent.PromptVia != "" && !containsAny(PromptVias, agent.PromptVia) {
+			add(fmt.Sprintf("agent %q: prompt_via must be one of %v, got %q", name, PromptVias, agent.PromptVia), "agents", name, "prompt_via")
+		} else if agent.PromptMode() != PromptViaFile && strings.Contains(strings.Join(agent.Args, " "), "{prompt_path}") {
+			add(fmt.Sprintf("agent %q: args use {prompt_path}, which needs prompt_via: file", name), "agents", name, "prompt_via")
+		}
+		switch {
+		case agent.Role == "":
+			add(fmt.Sprintf("agent %q: role is required", name), "agents", name, "role")
+		case !containsAny(BuiltinRoles, agent.Role) && !c.hasRole(agent.Role):
+			add(fmt.Sprintf("agent %q: unknown role %q (built-in: %v, or define it under roles:)", name, agent.Role, BuiltinRoles), "agents", name, "role")
+		}
+	}
+
+	if c.Testing.Stage != "" && c.Testing.Stage != "before_code" && c.Testing.Stage != "after_code" {
+		add(fmt.Sprintf("testing: stage must be before_code or after_code, got %q", c.Testing.Stage), "testing", "stage")
+	}
+	if c.Perf.OnRegression != "" && c.Perf.OnRegression != "fix" && c.Perf.OnRegression != "flag" {
+		add(fmt.Sprintf("perf: on_regression must be fix or flag, got %q", c.Perf.OnRegression), "perf", "on_regression")
+	}
+	for _, check := range c.Accept.Checks {
+		if !containsAny(AcceptChecks, check) {
+			add(fmt.Sprintf("accept: unknown check %q (known: %v)", check, AcceptChecks), "accept", "checks")
+		}
+	}
+	for i, item := range c.Review.Checklist {
+		if strings.TrimSpace(item.Item) == "" {
+			add(fmt.Sprintf("review: checklist item %d is empty", i+1), "review", "checklist", strconv.Itoa(i))
+		}
+	}
+	if name := c.Review.Tiebreaker; name != "" {
+		if a, ok := c.Agents[name]; !ok {
+			add(fmt.Sprintf("review: tiebreaker agent %q not found", name), "review", "tiebreaker")
+		} else if a.Role != "reviewer" {
+			add(fmt.Sprintf("review: tiebreaker agent %q must have role reviewer, got %q", name, a.Role), "review", "tiebreaker")
+		}
+	}
+	if c.Review.SkipLines < 0 {
+		add(fmt.Sprintf("review: skip_lines must not be negative, got %d", c.Review.SkipLines), "review", "skip_lines")
+	}
+	for i, p := range c.Review.SkipPaths {
+		if _, err := path.Match(p, ""); err != nil {
+			add(fmt.Sprintf("review: skip_paths pattern %q is invalid", p), "review", "skip_paths", strconv.Itoa(i))
+		}
+	}
+	for i, lang := range c.Languages {
+		if _, ok := c.LanguageHints[lang]; !ok && !containsAny(KnownLanguages, lang) {
+			add(fmt.Sprintf("languages: no hints for %q: add them under language_hints (built in: %v)", lang, KnownLanguages), "languages", strconv.Itoa(i))
+		}
+	}
+	if c.Auto.ConfirmAbove.Calls < 0 {
+		add(fmt.Sprintf("auto.confirm_above: calls must not be negative, got %d", c.Auto.ConfirmAbove.Calls), "auto", "confirm_above", "calls")
+	}
+	if c.Auto.ConfirmAbove.Minutes < 0 {
+		add(fmt.Sprintf("auto.confirm_above: minutes must not be negative, got %d", c.Auto.ConfirmAbove.Minutes), "auto", "confirm_above", "minutes")
+	}
+	if c.Auto.ConfirmAbove.Cost < 0 {
+		add(fmt.Sprintf("auto.confirm_above: cost must not be negative, got %g", c.Auto.ConfirmAbove.Cost), "auto", "confirm_above", "cost")
+	}
+	if c.Backoff.InitialSec < 0 {
+		add(fmt.Sprintf("backoff: initial_sec must not be negative, got %d", c.Backoff.InitialSec), "backoff", "initial_sec")
+	}
+	if c.Backoff.MaxSec < 0 {
+		add(fmt.Sprintf("backoff: max_sec must not be negative, got %d", c.Backoff.MaxSec), "backoff", "max_sec")
+	}
+	if c.History.PreviewChars < 0 {
+		add(fmt.Sprintf("history: preview_chars must not be negative, got %d", c.History.PreviewChars), "history", "preview_chars")
+	}
+	if c.History.InlineTokens < 0 {
+		add(fmt.Sprintf("history: inline_tokens must not be negative, got %d", c.History.InlineTokens), "history", "inline_tokens")
+	}
+	for i, pattern := range c.Output.ToolLogPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add(fmt.Sprintf("output: tool_log_patterns %d: %v", i+1, err), "output", "tool_log_patterns", strconv.Itoa(i))
+		}
+	}
+	if c.Disk.MinFreeMB < -1 {
+		add(fmt.Sprintf("disk: min_free_mb must be -1 (off) or more, got %d", c.Disk.MinFreeMB), "disk", "min_free_mb")
+	}
+	if c.Disk.MaxHiveMB < 0 {
+		add(fmt.Sprintf("disk: max_hive_mb must not be negative, got %d", c.Disk.MaxHiveMB), "disk", "max_hive_mb")
+	}
+	providers := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	for _, name := range providers {
+		l := c.Providers[name]
+		if l.Concurrency < 0 {
+			add(fmt.Sprintf("providers: %s: concurrency must not be negative, got %d", name, l.Concurrency), "providers", name, "concurrency")
+		}
+		if l.RPM < 0 {
+			add(fmt.Sprintf("providers: %s: rpm must not be negative, got %d", name, l.RPM), "providers", name, "rpm")
+		}
+	}
+	if c.Logging.API != "" && !containsAny(APILogLevels, c.Logging.API) {
+		add(fmt.Sprintf("logging: api must be one of %v, got %q", APILogLevels, c.Logging.API), "logging", "api")
+	}
+	for i, tok := range c.Serve.Tokens {
+		idx := strconv.Itoa(i)
+		switch {
+		case tok.Name == "":
+			add(fmt.Sprintf("serve: token %d: name is required", i+1), "serve", "tokens", idx)
+		case !containsAny(ServeRoles, tok.Role):
+			add(fmt.Sprintf("serve: token %q: role must be one of %v, got %q", tok.Name, ServeRoles, tok.Role), "serve", "tokens", idx, "role")
+		case tok.TokenEnv == "":
+			add(fmt.Sprintf("serve: token %q: token_env is required", tok.Name), "serve", "tokens", idx, "token_env")
+		}
+	}
+	for i, hook := range c.Notify.Webhooks {
+		idx := strconv.Itoa(i)
+		if hook.URL == "" && hook.URLEnv == "" {
+			add(fmt.Sprintf("notify: webhook %d: url or url_env is required", i+1), "notify", "webhooks", idx)
+		}
+		if hook.Format != "" && !containsAny(WebhookFormats, hook.Format) {
+			add(fmt.Sprintf("notify: webhook %d: format must be one of %v, got %q", i+1, WebhookFormats, hook.Format), "notify", "webhooks", idx, "format")
+		}
+		for _, ev := range hook.Events {
+			if !containsAny(NotifyEvents, ev) {
+				add(fmt.Sprintf("notify: webhook %d: unknown event %q (want one of %v)", i+1, ev, NotifyEvents), "notify", "webhooks", idx, "events")
+			}
+		}
+	}
+
+	for i, rule := range c.Owners.Rules {
+		if strings.TrimSpace(rule.Path) == "" {
+			add(fmt.Sprintf("owners: rule %d: path is required", i+1), "owners", "rules", strconv.Itoa(i))
+		}
+	}
+	routeOwners := make([]string, 0, len(c.Owners.Routes))
+	for owner := range c.Owners.Routes {
+		routeOwners = append(routeOwners, owner)
+	}
+	sort.Strings(routeOwners)
+	for _, owner := range routeOwners {
+		name := c.Owners.Routes[owner].Agent
+		if name == "" {
+			continue
+		}
+		if a, ok := c.Agents[name]; !ok {
+			add(fmt.Sprintf("owners: route %q: agent %q not found", owner, name), "owners", "routes", owner, "agent")
+		} else if a.Role != "coder" {
+			add(fmt.Sprintf("owners: route %q: agent %q must have role coder, got %q", owner, name, a.Role), "owners", "routes", owner, "agent")
+		}
+	}
+
+	roleNames := make([]string, 0, len(c.Roles))
+	for name := range c.Roles {
+		roleNames = append(roleNames, name)
+	}
+	sort.Strings(roleNames)
+	for _, name := range roleNames {
+		role := c.Roles[name]
+		for _, section := range role.Omit {
+			if !containsAny(PromptSections, section) {
+				add(fmt.Sprintf("role %q: unknown prompt section %q in omit (known: %v)", name, section, PromptSections), "roles", name, "omit")
+			}
+		}
+		if containsAny(BuiltinRoles, name) {
+			if role.Stage != "" || role.Verdict {
+				add(fmt.Sprintf("role %q: built-in roles can only override header, instructions, output and omit", name), "roles", name)
+			}
+			continue
+		}
+		if role.Stage != "" && !containsAny(roleStages, role.Stage) {
+			add(fmt.Sprintf("role %q: stage must be one of %v, got %q", name, roleStages, role.Stage), "roles", name, "stage")
+		}
+		if role.Verdict && role.Stage != "after_code" {
+			add(fmt.Sprintf("role %q: verdict is only supported at stage after_code", name), "roles", name, "verdict")
+		}
+	}
+	return out
+}
+
+// hasRole reports whether a custom role is defined under roles:.
+func (c *Config) hasRole(name string) bool {
+	_, ok := c.Roles[name]
+	return ok
+}
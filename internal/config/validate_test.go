@@ -0,0 +1,275 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidate_Valid(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase})
+	if issues := Validate(p, ""); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidate_UnknownKeyWithLine(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": `version: 1
+agents:
+  claude:
+    role: coder
+    mode: cli
+    cmd: claude
+    auto_acept: true
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	got := issues[0]
+	if got.File != p || got.Line != 7 {
+		t.Errorf("expected %s:7, got %s:%d", p, got.File, got.Line)
+	}
+	if !strings.Contains(got.Message, `unknown key "auto_acept" in agent`) {
+		t.Errorf("unexpected message %q", got.Message)
+	}
+
+	if _, err := Load(p); err == nil || !strings.Contains(err.Error(), "auto_acept") {
+		t.Errorf("Load should reject unknown keys, got %v", err)
+	}
+}
+
+func TestValidate_CollectsEveryProblem(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": `version: 1
+agents:
+  a:
+    role: coder
+    mode: api
+    provider: openia
+  b:
+    role: reviwer
+    mode: cli
+    cmd: codex
+testing:
+  stage: whenever
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %v", issues)
+	}
+	want := []struct {
+		line int
+		text string
+	}{
+		{6, `provider must be one of`},
+		{8, `unknown role "reviwer"`},
+		{12, `stage must be before_code or after_code`},
+	}
+	for i, w := range want {
+		if issues[i].Line != w.line || !strings.Contains(issues[i].Message, w.text) {
+			t.Errorf("issue %d: expected line %d %q, got %s", i, w.line, w.text, issues[i])
+		}
+	}
+}
+
+func TestValidate_PromptVia(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": `version: 1
+agents:
+  a:
+    role: coder
+    mode: cli
+    cmd: aider
+    prompt_via: pipe
+  b:
+    role: reviewer
+    mode: cli
+    cmd: codex
+    args: ["--prompt-file", "{prompt_path}"]
+  c:
+    role: pm
+    mode: cli
+    cmd: codex
+    args: ["--prompt-file", "{prompt_path}"]
+    prompt_via: file
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", issues)
+	}
+	if !strings.Contains(issues[0].Message, `prompt_via must be one of`) {
+		t.Errorf("unexpected message %q", issues[0].Message)
+	}
+	if !strings.Contains(issues[1].Message, `agent "b": args use {prompt_path}`) {
+		t.Errorf("unexpected message %q", issues[1].Message)
+	}
+}
+
+func TestValidate_PointsAtOverridingLayer(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{
+		"config.yaml":       overlayBase,
+		"config.local.yaml": "\nagents:\n  reviewer:\n    mode: ssh\n",
+	})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	if !strings.HasSuffix(issues[0].File, LocalFile) || issues[0].Line != 4 {
+		t.Errorf("expected config.local.yaml:4, got %s", issues[0])
+	}
+}
+
+func TestValidate_CustomRoleAllowed(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": `version: 1
+agents:
+  sec:
+    role: security
+    mode: cli
+    cmd: claude
+roles:
+  security:
+    header: "# Security"
+`})
+	if issues := Validate(p, ""); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidate_SyntaxError(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": "agents:\n  a: [\n"})
+	issues := Validate(p, "")
+	if len(issues) == 0 || issues[0].File != p {
+		t.Fatalf("expected a syntax issue in %s, got %v", p, issues)
+	}
+}
+
+func TestValidate_ServeTokenLine(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `serve:
+  tokens:
+    - name: dashboard
+      role: read
+      token_env: DASH_TOKEN
+    - name: bot
+      role: superuser
+      token_env: BOT_TOKEN
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	if !strings.Contains(issues[0].Message, `token "bot": role must be one of`) {
+		t.Errorf("unexpected message %q", issues[0].Message)
+	}
+	data, _ := os.ReadFile(p)
+	lines := strings.Split(string(data), "\n")
+	if got := lines[issues[0].Line-1]; !strings.Contains(got, "superuser") {
+		t.Errorf("expected the issue on the role line, got line %d: %q", issues[0].Line, got)
+	}
+}
+
+func TestValidate_NotifyWebhooks(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `notify:
+  webhooks:
+    - url_env: HIVE_SLACK_HOOK
+      events: [task_blocked, epic_ready]
+    - url: https://example.com/hook
+      format: teams
+    - events: [task_done]
+`})
+
+	var msgs []string
+	for _, issue := range Validate(p, "") {
+		msgs = append(msgs, issue.Message)
+	}
+	want := []string{
+		`notify: webhook 2: format must be one of`,
+		`notify: webhook 3: url or url_env is required`,
+		`notify: webhook 3: unknown event "task_done"`,
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("expected %d issues, got %v", len(want), msgs)
+	}
+	for i, w := range want {
+		if !strings.Contains(msgs[i], w) {
+			t.Errorf("issue %d: expected %q, got %q", i, w, msgs[i])
+		}
+	}
+}
+
+func TestValidate_OutputPatterns(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `output:
+  tool_log_patterns:
+    - '^\[mcp\] '
+    - '^(unclosed'
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "output: tool_log_patterns 2:") {
+		t.Fatalf("expected one issue for pattern 2, got %v", issues)
+	}
+}
+
+func TestValidate_SkipPaths(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `review:
+  skip_trivial: true
+  skip_paths: ["CHANGELOG*", "examples/", "[docs"]
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, `skip_paths pattern "[docs"`) {
+		t.Fatalf("expected one issue for [docs, got %v", issues)
+	}
+}
+
+func TestValidate_Languages(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `languages: [go, java, elixir]
+language_hints:
+  java:
+    test: ./gradlew test
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, `no hints for "elixir"`) {
+		t.Fatalf("expected one issue for elixir, got %v", issues)
+	}
+}
+
+func TestValidate_OwnerRoutes(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `owners:
+  rules:
+    - path: internal/billing/
+      owners: ["@payments"]
+  routes:
+    "@payments":
+      agent: reviewer
+      gate: true
+    "@docs":
+      agent: scribe
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", issues)
+	}
+	if !strings.Contains(issues[0].Message, `agent "scribe" not found`) || issues[0].Line != 26 {
+		t.Errorf("unexpected first issue %s", issues[0])
+	}
+	if !strings.Contains(issues[1].Message, `must have role coder`) || issues[1].Line != 23 {
+		t.Errorf("unexpected second issue %s", issues[1])
+	}
+}
+
+func TestValidate_Backoff(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase + `backoff:
+  initial_sec: -5
+`})
+
+	issues := Validate(p, "")
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "initial_sec must not be negative") {
+		t.Fatalf("expected one issue for initial_sec, got %v", issues)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+)
+
+// Watcher reloads the config when any of its layers changes on disk, so
+// long-running commands pick up edits without a restart. A change that
+// fails to load or validate is reported and the last good config is kept.
+//
+// Files are polled rather than watched through OS notifications: configs
+// are tiny, a stat per layer is cheap, and editors that replace files on
+// save are handled without special cases.
+type Watcher struct {
+	path    string
+	profile string
+	stamps  map[string]fileStamp
+	current *Config
+}
+
+// fileStamp identifies one version of a file.
+type fileStamp struct {
+	modTime int64 // UnixNano
+	size    int64
+}
+
+// NewWatcher loads the config at path with profile (see LoadProfile) and
+// returns a watcher for it.
+func NewWatcher(path, profile string) (*Watcher, error) {
+	w := &Watcher{path: path, profile: profile}
+	w.stamps = w.stat()
+	cfg, err := LoadProfile(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	w.current = cfg
+	return w, nil
+}
+
+// Config returns the last config that loaded successfully.
+func (w *Watcher) Config() *Config {
+	return w.current
+}
+
+// Check reloads the config if a layer changed since the last check.
+// changed reports a successful reload. On error the previous config stays
+// current and the same broken files are not retried until they change
+// again.
+func (w *Watcher) Check() (cfg *Config, changed bool, err error) {
+	stamps := w.stat()
+	if sameStamps(stamps, w.stamps) {
+		return w.current, false, nil
+	}
+	w.stamps = stamps
+
+	next, err := LoadProfile(w.path, w.profile)
+	if err != nil {
+		return w.current, false, err
+	}
+	w.current = next
+	return next, true, nil
+}
+
+// layers returns every file that contributes to the config.
+func (w *Watcher) layers() []string {
+	var paths []string
+	if user := UserConfigPath(); user != "" {
+		paths = append(paths, user)
+	}
+	paths = append(paths, w.path)
+	return append(paths, OverlayPaths(w.path, w.profile)...)
+}
+
+func (w *Watcher) stat() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, p := range w.layers() {
+		if fi, err := os.Stat(p); err == nil {
+			stamps[p] = fileStamp{modTime: fi.ModTime().UnixNano(), size: fi.Size()}
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// touch rewrites a file with a later mtime so the change is seen even on
+// filesystems with coarse timestamps.
+func touch(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(2 * time.Second)
+	os.Chtimes(path, later, later)
+}
+
+func TestWatcher_ReloadsOnChange(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase})
+	w, err := NewWatcher(p, "")
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	if _, changed, err := w.Check(); changed || err != nil {
+		t.Fatalf("expected no change, got changed=%v err=%v", changed, err)
+	}
+
+	touch(t, p, strings.Replace(overlayBase, "agents:\n", "agents:\n  docs:\n    role: docs\n    mode: cli\n    cmd: claude\n", 1))
+	cfg, changed, err := w.Check()
+	if err != nil || !changed {
+		t.Fatalf("expected reload, got changed=%v err=%v", changed, err)
+	}
+	if _, ok := cfg.Agents["docs"]; !ok {
+		t.Error("new agent missing after reload")
+	}
+	if w.Config() != cfg {
+		t.Error("Config should return the reloaded config")
+	}
+}
+
+func TestWatcher_KeepsLastGoodConfig(t *testing.T) {
+	p := writeConfigFiles(t, map[string]string{"config.yaml": overlayBase})
+	w, _ := NewWatcher(p, "")
+	good := w.Config()
+
+	// A new local overlay counts as a change too.
+	local := filepath.Join(filepath.Dir(p), LocalFile)
+	touch(t, local, "agents:\n  coder:\n    mode: telnet\n")
+
+	cfg, changed, err := w.Check()
+	if err == nil || changed {
+		t.Fatalf("expected validation error, got changed=%v err=%v", changed, err)
+	}
+	if cfg != good || w.Config() != good {
+		t.Error("broken config should leave the previous one current")
+	}
+	if _, _, err := w.Check(); err != nil {
+		t.Error("unchanged broken file should not be reported again")
+	}
+
+	os.Remove(local)
+	if _, changed, err := w.Check(); !changed || err != nil {
+		t.Fatalf("expected reload after fix, got changed=%v err=%v", changed, err)
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/langs"
+	"github.com/imkarma/hive/internal/owners"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
 )
 
@@ -14,12 +20,69 @@ import (
 // and its history. Think of it as building a "Jira ticket" that the
 // agent reads before starting work.
 type Builder struct {
-	store *store.Store
+	store     *store.Store
+	roles     *roles.Registry
+	checklist []string
+	owners    *owners.Owners
+	workDir   string
+	blind     map[string]string // Agent name to role, in blind mode
+	langs     []langs.Hint
+
+	arts         *artifacts.Manager // Set to inline the latest outputs
+	inlineBudget int                // Tokens for them
+
+	chunk *DiffChunk // Set to review one part of a diff, see ForChunk
 }
 
-// New creates a context builder.
+// New creates a context builder that knows only the built-in roles.
 func New(s *store.Store) *Builder {
-	return &Builder{store: s}
+	return &Builder{store: s, roles: roles.Builtin()}
+}
+
+// WithRoles makes the builder resolve role prompts from the given
+// registry (built-ins plus custom roles from config).
+func (b *Builder) WithRoles(r *roles.Registry) *Builder {
+	if r != nil {
+		b.roles = r
+	}
+	return b
+}
+
+// WithChecklist adds a review checklist to review prompts: the reviewer
+// must answer every item in a CHECKLIST: block.
+func (b *Builder) WithChecklist(items []string) *Builder {
+	b.checklist = items
+	return b
+}
+
+// WithOwners shows the PM and the architect who owns which code, so they
+// can keep tasks within one owner's area and name the paths they touch.
+func (b *Builder) WithOwners(o *owners.Owners) *Builder {
+	b.owners = o
+	return b
+}
+
+// WithBlind hides agents' names from each other: history entries by a
+// known agent are attributed to its role, and the reviewer doesn't see
+// earlier verdicts. agents maps agent names to roles. It keeps models
+// from deferring to, or anchoring on, each other — e.g. when the coder
+// and the reviewer come from the same vendor.
+func (b *Builder) WithBlind(agents map[string]string) *Builder {
+	b.blind = agents
+	return b
+}
+
+// WithLanguageHints gives the coder and the reviewer the project's test,
+// format and lint commands and idioms for its languages.
+func (b *Builder) WithLanguageHints(hints []langs.Hint) *Builder {
+	b.langs = hints
+	return b
+}
+
+// shows reports whether role's prompts include a section; roles can omit
+// any of config.PromptSections.
+func (b *Builder) shows(role, section string) bool {
+	return !b.roles.Omits(role, section)
 }
 
 // BuildPrompt creates the full prompt for an agent working on a task.
@@ -38,19 +101,34 @@ func (b *Builder) BuildPrompt(task *store.Task, role string) (string, error) {
 	// 2. Task description.
 	parts = append(parts, b.taskSection(task))
 
-	// 3. Parent task context.
+	// 3. Parent task context, and what earlier tasks in the epic built.
 	if task.ParentID != nil {
-		parentCtx, err := b.parentContext(*task.ParentID)
-		if err == nil && parentCtx != "" {
+		if parentCtx, err := b.parentContext(*task.ParentID); err == nil && parentCtx != "" && b.shows(role, "epic") {
 			parts = append(parts, parentCtx)
 		}
+		if handoffs := b.handoffContext(task); handoffs != "" && b.shows(role, "handoffs") {
+			parts = append(parts, handoffs)
+		}
+	}
+
+	// Code ownership, for the roles that shape tasks.
+	if (role == roles.PM || role == roles.Architect) && b.shows(role, "owners") {
+		if section := b.ownersSection(); section != "" {
+			parts = append(parts, section)
+		}
 	}
 
 	// 4. Event history (user answers, previous agent outputs).
-	eventCtx, err := b.eventHistory(task.ID)
-	if err == nil && eventCtx != "" {
+	if eventCtx, err := b.eventHistory(task.ID, role); err == nil && eventCtx != "" && b.shows(role, "history") {
 		parts = append(parts, eventCtx)
 	}
+	if outputs := b.latestOutputs(task.ID, role); outputs != "" {
+		parts = append(parts, outputs)
+	}
+
+	if role == roles.Reviewer && len(task.Acceptance) > 0 {
+		parts = append(parts, acceptanceSection(task))
+	}
 
 	// 5. Role-specific instructions.
 	parts = append(parts, b.roleInstructions(role))
@@ -61,120 +139,443 @@ func (b *Builder) BuildPrompt(task *store.Task, role string) (string, error) {
 // BuildReviewPrompt creates a specialized prompt for code review.
 // Includes the task context plus git diff to show what changed.
 func (b *Builder) BuildReviewPrompt(task *store.Task) (string, error) {
+	return b.BuildDiffPrompt(task, roles.Reviewer)
+}
+
+// BuildDiffPrompt is BuildReviewPrompt for an arbitrary role: task context,
+// the current git diff and history, framed by that role's header and
+// instructions. Custom roles that run after the coder use it.
+func (b *Builder) BuildDiffPrompt(task *store.Task, role string) (string, error) {
 	var parts []string
 
-	parts = append(parts, b.roleHeader("reviewer"))
+	parts = append(parts, b.roleHeader(role))
 	parts = append(parts, b.taskSection(task))
 
 	// Parent context.
 	if task.ParentID != nil {
-		parentCtx, err := b.parentContext(*task.ParentID)
-		if err == nil && parentCtx != "" {
+		if parentCtx, err := b.parentContext(*task.ParentID); err == nil && parentCtx != "" && b.shows(role, "epic") {
 			parts = append(parts, parentCtx)
 		}
+		if handoffs := b.handoffContext(task); handoffs != "" && b.shows(role, "handoffs") {
+			parts = append(parts, handoffs)
+		}
 	}
 
 	// Git diff — the core of the review.
-	diff := b.gitDiff()
-	if diff != "" {
-		parts = append(parts, "## Changes (git diff)\n```diff\n"+diff+"\n```")
+	if diff := b.diffSection(task); diff != "" {
+		parts = append(parts, diff)
 	}
 
 	// Event history (previous reviews, user answers).
-	eventCtx, err := b.eventHistory(task.ID)
-	if err == nil && eventCtx != "" {
+	if eventCtx, err := b.eventHistory(task.ID, role); err == nil && eventCtx != "" && b.shows(role, "history") {
+		parts = append(parts, eventCtx)
+	}
+	if outputs := b.latestOutputs(task.ID, role); outputs != "" {
+		parts = append(parts, outputs)
+	}
+
+	if role == roles.Reviewer && len(task.Acceptance) > 0 {
+		parts = append(parts, acceptanceSection(task))
+	}
+	if role == roles.Reviewer && len(b.checklist) > 0 && b.shows(role, "checklist") {
+		parts = append(parts, b.checklistSection())
+	}
+
+	parts = append(parts, b.roleInstructions(role))
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// BuildStandaloneReviewPrompt creates a review prompt for a diff that
+// isn't tied to a task, e.g. a branch or patch file under review. notes
+// is optional context from the user about what the change is for.
+func (b *Builder) BuildStandaloneReviewPrompt(diff, notes string) string {
+	var parts []string
+
+	parts = append(parts, b.roleHeader(roles.Reviewer))
+	if notes != "" {
+		parts = append(parts, "## Context\n"+notes+"\n")
+	}
+	parts = append(parts, "## Changes (git diff)\n```diff\n"+truncateDiff(diff)+"\n```")
+	if len(b.checklist) > 0 {
+		parts = append(parts, b.checklistSection())
+	}
+	parts = append(parts, b.roleInstructions(roles.Reviewer))
+
+	return strings.Join(parts, "\n\n")
+}
+
+// BuildBreakdownPrompt creates a PM prompt for a free-form description
+// that isn't on the board, e.g. a spec being estimated before it becomes
+// an epic.
+func (b *Builder) BuildBreakdownPrompt(description string) string {
+	parts := []string{b.roleHeader(roles.PM), "## Request\n" + strings.TrimSpace(description) + "\n"}
+	if section := b.ownersSection(); section != "" && b.shows(roles.PM, "owners") {
+		parts = append(parts, section)
+	}
+	parts = append(parts, b.roleInstructions(roles.PM))
+	return strings.Join(parts, "\n\n")
+}
+
+// BuildPlanAddPrompt creates a PM prompt for a requirement added to an
+// epic that is already planned: the existing tasks are context, and only
+// the work they don't cover is planned.
+func (b *Builder) BuildPlanAddPrompt(epic *store.Task, requirement string) string {
+	parts := []string{b.roleHeader(roles.PM), b.taskSection(epic)}
+
+	if subtasks, err := b.store.ListTasksByEpic(epic.ID); err == nil && len(subtasks) > 0 {
+		var sb strings.Builder
+		sb.WriteString("## Existing Plan\n")
+		for _, t := range subtasks {
+			sb.WriteString(fmt.Sprintf("- #%d [%s]: %s", t.ID, t.Status, t.Title))
+			if desc := strings.TrimSpace(t.Description); desc != "" {
+				sb.WriteString(" - " + strings.SplitN(desc, "\n", 2)[0])
+			}
+			sb.WriteString("\n")
+		}
+		parts = append(parts, sb.String())
+	}
+	if section := b.ownersSection(); section != "" && b.shows(roles.PM, "owners") {
+		parts = append(parts, section)
+	}
+	if eventCtx, err := b.eventHistory(epic.ID, roles.PM); err == nil && eventCtx != "" && b.shows(roles.PM, "history") {
+		parts = append(parts, eventCtx)
+	}
+	parts = append(parts,
+		"## New Requirement\n"+strings.TrimSpace(requirement)+"\n",
+		b.roleInstructions(roles.PM),
+		`## Add to the plan
+The epic is already planned and work on it may have started. Plan only the
+subtasks the new requirement adds. Don't repeat, re-plan or change the
+existing tasks; build on them instead. If the existing plan already covers
+the requirement, list no subtasks and say so.`)
+	return strings.Join(parts, "\n\n")
+}
+
+// BuildGroupCommitsPrompt creates a PM prompt for grouping the commits of
+// an adopted branch into tasks, each a logical change to review on its
+// own.
+func (b *Builder) BuildGroupCommitsPrompt(epic *store.Task, commits []git.Commit) string {
+	var sb strings.Builder
+	sb.WriteString("## Commits\n")
+	for i, c := range commits {
+		sb.WriteString(fmt.Sprintf("%d. %s %s\n", i+1, shortHash(c.Hash), c.Subject))
+		if c.Body != "" {
+			for _, line := range strings.Split(c.Body, "\n") {
+				sb.WriteString("   " + line + "\n")
+			}
+		}
+		if len(c.Files) > 0 {
+			sb.WriteString("   Files: " + strings.Join(c.Files, ", ") + "\n")
+		}
+	}
+
+	return strings.Join([]string{
+		b.roleHeader(roles.PM),
+		b.taskSection(epic),
+		sb.String(),
+		`## Group the commits
+This work is already done: the commits above are on a branch that was
+started outside hive. Group them into tasks, each one logical change that
+can be reviewed on its own — a feature and its tests together, a fix and
+its follow-up together, an unrelated cleanup on its own. Keep commits that
+depend on each other in the same task where you can.
+
+List every commit in exactly one task, by number, and don't plan new work:
+
+SUBTASKS:
+1. [Title] - [What the change does] (commits: 1, 2)
+2. [Title] - [What the change does] (commits: 3)`,
+	}, "\n\n")
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// maxOwnerRules caps the ownership rules listed in a prompt.
+const maxOwnerRules = 40
+
+// ownersSection lists who owns which paths, or "" without owners.
+func (b *Builder) ownersSection() string {
+	var lines []string
+	for _, r := range b.owners.Rules() {
+		if len(r.Owners) == 0 {
+			continue
+		}
+		if len(lines) == maxOwnerRules {
+			lines = append(lines, "- ...")
+			break
+		}
+		lines = append(lines, fmt.Sprintf("- `%s`: %s", r.Pattern, strings.Join(r.Owners, " ")))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "## Code ownership\n" +
+		"These paths have owners (CODEOWNERS syntax; the last matching line wins). " +
+		"Keep each task within one owner's area where you can, and name the files or directories it touches.\n\n" +
+		strings.Join(lines, "\n") + "\n"
+}
+
+// ConflictFile is a file an approved task's commit conflicts in, with its
+// conflicted regions (see git.ConflictHunks).
+type ConflictFile struct {
+	Path  string
+	Hunks []string
+}
+
+// BuildConflictPrompt asks the coder to resolve the conflicts between an
+// approved task's changes and the epic branch, which has moved on with
+// other tasks' work (others) since the task started.
+func (b *Builder) BuildConflictPrompt(task *store.Task, others []store.Task, files []ConflictFile) string {
+	parts := []string{b.roleHeader(roles.Coder), b.taskSection(task)}
+
+	if len(others) > 0 {
+		var sb strings.Builder
+		sb.WriteString("## Work it conflicts with\n")
+		sb.WriteString("These tasks changed the same files on the epic branch after this task started:\n\n")
+		for _, t := range others {
+			sb.WriteString(fmt.Sprintf("### #%d: %s\n", t.ID, t.Title))
+			if desc := strings.TrimSpace(t.Description); desc != "" {
+				sb.WriteString(desc + "\n")
+			}
+			sb.WriteString("\n")
+		}
+		parts = append(parts, sb.String())
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Conflicts\n")
+	sb.WriteString("Between `<<<<<<<` and `=======` is the epic branch as it is now; between `=======` and `>>>>>>>` is this task's change.\n")
+	for _, f := range files {
+		sb.WriteString(fmt.Sprintf("\n### %s\n", f.Path))
+		for _, h := range f.Hunks {
+			sb.WriteString("```\n" + h + "\n```\n")
+		}
+	}
+	parts = append(parts, sb.String(), `## Resolve the conflicts
+This task was approved, but the epic branch changed under it. Edit the
+files above so they keep both this task's change and the other work:
+remove every conflict marker, and don't drop either side unless it is
+truly replaced by the other. Don't change anything else and don't commit;
+hive commits the result. Run the tests if you can.
+
+If the two changes can't be reconciled without a decision from the user:
+BLOCKED: [what conflicts, and the choice to make]`)
+	return strings.Join(parts, "\n\n")
+}
+
+// BuildLocalizePrompt asks the analyst which source files a bug report
+// most likely points at, so the PM plans against the right code.
+func (b *Builder) BuildLocalizePrompt(bug *store.Task) string {
+	parts := []string{b.roleHeader(roles.Analyst), b.taskSection(bug)}
+	if instructions := b.roleInstructions(roles.Analyst); instructions != "" {
+		parts = append(parts, instructions)
+	}
+	parts = append(parts, `## Localize the bug
+Read the stack trace and the code it goes through. Don't change any files.
+List the source files most likely to contain the bug, most likely first,
+each with a line (when you know it) and a short reason:
+
+LIKELY_FILES:
+- path/to/file.go:42 — why it is suspect
+
+Then add a few sentences on the probable cause, if you can tell.`)
+	return strings.Join(parts, "\n\n")
+}
+
+// BuildExplorePrompt asks the analyst or architect (role) to look around
+// the code an epic will touch and write a findings report, without
+// changing files or planning tasks. minutes is the time box the agent is
+// told about.
+func (b *Builder) BuildExplorePrompt(epic *store.Task, role string, minutes int, focus string) string {
+	parts := []string{b.roleHeader(role), b.taskSection(epic)}
+
+	if subtasks, err := b.store.ListTasksByEpic(epic.ID); err == nil && len(subtasks) > 0 {
+		var sb strings.Builder
+		sb.WriteString("## Existing Tasks\n")
+		for _, t := range subtasks {
+			sb.WriteString(fmt.Sprintf("- #%d [%s]: %s\n", t.ID, t.Status, t.Title))
+		}
+		parts = append(parts, sb.String())
+	}
+	if section := b.ownersSection(); section != "" && b.shows(role, "owners") {
+		parts = append(parts, section)
+	}
+	if eventCtx, err := b.eventHistory(epic.ID, role); err == nil && eventCtx != "" && b.shows(role, "history") {
 		parts = append(parts, eventCtx)
 	}
+	if focus = strings.TrimSpace(focus); focus != "" {
+		parts = append(parts, "## Focus\n"+focus+"\n")
+	}
+
+	parts = append(parts, fmt.Sprintf(`## Explore
+This is a time-boxed exploration before anything is planned: you have about
+%d minutes. Read the code this work will touch. Don't change, create or
+delete any files, and don't break the work into tasks — the PM does that
+later with your report.
+
+Write a findings report in markdown with these sections:
+
+## Summary
+A few sentences on what the work involves and how big it is.
+
+## Relevant code
+The files, packages and functions involved, as path:line where you can,
+each with what it does today.
+
+## Risks
+What could go wrong or be harder than it looks: coupling, missing tests,
+migrations, performance, compatibility.
+
+## Open questions
+What the requester should decide before planning. Write BLOCKED: only if
+you can't explore at all without an answer.
+
+## Suggested approach
+How you would go about it, in a few bullets.
+
+Stop exploring and write the report before your time runs out; a partial
+report is better than none.`, minutes))
+	return strings.Join(parts, "\n\n")
+}
+
+// checklistSection asks the reviewer to answer each checklist item.
+func (b *Builder) checklistSection() string {
+	var sb strings.Builder
+	sb.WriteString("## Review Checklist\n")
+	sb.WriteString("Before your VERDICT, answer every item below in a CHECKLIST: block, one line each, ")
+	sb.WriteString("as PASS, FAIL or N/A with a short reason. A missing or failed item means the task is rejected.\n\n")
+	sb.WriteString("CHECKLIST:\n")
+	for _, item := range b.checklist {
+		sb.WriteString("- " + item + ": PASS|FAIL|N/A — reason\n")
+	}
+	return sb.String()
+}
+
+// acceptanceSection asks the reviewer to answer each of the task's
+// acceptance criteria.
+func acceptanceSection(task *store.Task) string {
+	var sb strings.Builder
+	sb.WriteString("## Acceptance Criteria Check\n")
+	sb.WriteString("Before your VERDICT, answer every acceptance criterion of the task in an ACCEPTANCE: block, one line each, ")
+	sb.WriteString("as PASS, FAIL or N/A with a short reason. A missing or failed criterion means the task is rejected.\n\n")
+	sb.WriteString("ACCEPTANCE:\n")
+	for i := range task.Acceptance {
+		sb.WriteString("- " + agent.CriterionLabel(i) + ": PASS|FAIL|N/A — reason\n")
+	}
+	return sb.String()
+}
+
+// BuildDocsPrompt creates the prompt for the docs stage of an epic: the
+// epic, its finished subtasks, the epic diff and the documentation files
+// the agent is allowed to edit. An empty diff falls back to the working
+// tree diff.
+func (b *Builder) BuildDocsPrompt(epic *store.Task, subtasks []store.Task, diff string, paths []string) (string, error) {
+	var parts []string
+
+	parts = append(parts, b.roleHeader(roles.Docs))
+	parts = append(parts, b.taskSection(epic))
+
+	var done strings.Builder
+	for _, t := range subtasks {
+		if t.Status == store.StatusDone {
+			done.WriteString(fmt.Sprintf("- #%d: %s\n", t.ID, t.Title))
+		}
+	}
+	if done.Len() > 0 {
+		parts = append(parts, "## Completed Tasks\n"+done.String())
+	}
+
+	if diff == "" {
+		diff = b.gitDiff()
+	}
+	if diff != "" {
+		diff = truncateDiff(diff)
+		parts = append(parts, "## Changes (git diff)\n```diff\n"+diff+"\n```")
+	}
+
+	var targets strings.Builder
+	targets.WriteString("## Documentation Targets\n")
+	targets.WriteString("You may only edit these files or directories:\n")
+	for _, p := range paths {
+		targets.WriteString("- " + p + "\n")
+	}
+	parts = append(parts, targets.String())
 
-	parts = append(parts, b.roleInstructions("reviewer"))
+	parts = append(parts, b.roleInstructions(roles.Docs))
 
 	return strings.Join(parts, "\n\n"), nil
 }
 
+// taskDiff is the diff a task is reviewed on, in full: the commits it was
+// adopted from, or else the current changes.
+func (b *Builder) taskDiff(task *store.Task) string {
+	if len(task.Commits) == 0 {
+		return b.gitDiff()
+	}
+	out, err := git.New(".").CommitsDiff(task.Commits)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
 // gitDiff returns the current uncommitted changes, or the last commit diff.
+// LFS-tracked files are left out; their diffs are just pointer files.
 func (b *Builder) gitDiff() string {
+	excludes := append([]string{"--"}, git.New(".").LFSExcludes()...)
+	diff := func(args ...string) string {
+		out, err := exec.Command("git", append(args, excludes...)...).Output()
+		if err != nil {
+			return ""
+		}
+		return string(out)
+	}
+
 	// First try uncommitted changes.
-	out, err := exec.Command("git", "diff").Output()
-	if err == nil && len(out) > 0 {
-		return truncateDiff(string(out))
+	if out := diff("diff"); out != "" {
+		return out
 	}
 
 	// Try staged changes.
-	out, err = exec.Command("git", "diff", "--cached").Output()
-	if err == nil && len(out) > 0 {
-		return truncateDiff(string(out))
+	if out := diff("diff", "--cached"); out != "" {
+		return out
 	}
 
 	// Fall back to last commit.
-	out, err = exec.Command("git", "diff", "HEAD~1").Output()
-	if err == nil && len(out) > 0 {
-		return truncateDiff(string(out))
+	if out := diff("diff", "HEAD~1"); out != "" {
+		return out
 	}
 
 	return ""
 }
 
+// maxDiffLen is how much of a diff goes into one prompt.
+const maxDiffLen = 8000
+
 // truncateDiff limits diff size to avoid blowing up the prompt.
 func truncateDiff(diff string) string {
-	const maxLen = 8000
-	if len(diff) <= maxLen {
+	if len(diff) <= maxDiffLen {
 		return diff
 	}
-	return diff[:maxLen] + "\n\n... (diff truncated, " + fmt.Sprintf("%d", len(diff)) + " bytes total)"
+	return diff[:maxDiffLen] + "\n\n... (diff truncated, " + fmt.Sprintf("%d", len(diff)) + " bytes total)"
 }
 
 func (b *Builder) roleHeader(role string) string {
-	switch role {
-	case "pm":
-		return `# You are a Project Manager / Tech Lead
-Your job is to INVESTIGATE the actual project codebase first, then break the task into concrete, actionable subtasks.
-
-CRITICAL RULES:
-- You MUST explore the codebase before creating subtasks. Read the README, look at the project structure, understand what the project does.
-- Each subtask must be specific and actionable — a developer should know exactly what to do from the title and description alone.
-- BAD subtask: "Security vulnerabilities" (too vague, not actionable)
-- GOOD subtask: "Sanitize shell metacharacters in vars/resolver.go Substitute()" (specific file, specific action)
-- If the epic title is vague or misspelled, interpret the intent and create meaningful tasks based on what you find in the actual code.
-- Do NOT create subtasks for things you don't find evidence of in the code.`
-
-	case "architect":
-		return `# You are a Technical Architect / Tech Lead
-Your job is to investigate the codebase for THIS SPECIFIC TASK and produce a detailed technical plan.
-
-CRITICAL RULES:
-- You produce a spec that tells the developer EXACTLY what to change, where, and how.
-- You do NOT write implementation code.
-- If the task is vague, unclear, or doesn't make sense for this codebase — say BLOCKED with a specific question rather than making up work.
-- Only include changes that are directly relevant to this task. Don't expand scope.`
-
-	case "coder":
-		return `# You are a Software Developer
-Your job is to implement the changes specified in this task. You must actually modify files in the project.
-
-CRITICAL RULES:
-- You MUST make real code changes — editing, creating, or deleting files as needed.
-- If a technical spec (architect_spec) is provided in the history, follow it precisely.
-- After making changes, run the project's tests to verify nothing is broken.
-- Do NOT just describe what needs to change — actually change it.
-- Do NOT ask for permission — you have full access to modify files.`
-
-	case "reviewer":
-		return `# You are a Code Reviewer
-Your job is to review the changes made for this task. You use a severity-based approach.
-
-CRITICAL RULES FOR VERDICT:
-- REJECT only for CRITICAL or HIGH severity issues (security vulnerabilities, data loss, crashes, broken core functionality)
-- APPROVE for everything else, even if there are medium/low issues — list them as comments
-- You are pragmatic, not perfectionist. Ship working code, note improvements for later.
-- If the core task is accomplished and there are no critical bugs, APPROVE.`
-
-	case "tester":
-		return "# You are a QA Engineer\nYour job is to verify the implementation works correctly. Run tests and validate the acceptance criteria."
-	case "analyst":
-		return "# You are a Technical Analyst\nYour job is to analyze the requirements and provide technical recommendations."
-	default:
-		return fmt.Sprintf("# You are working as: %s", role)
+	return b.roles.Header(role)
+}
+
+func (b *Builder) roleInstructions(role string) string {
+	instructions := b.roles.Instructions(role)
+	if guidance := langs.Guidance(role, b.langs); guidance != "" {
+		return guidance + "\n" + instructions
 	}
+	return instructions
 }
 
 func (b *Builder) taskSection(task *store.Task) string {
@@ -187,6 +588,12 @@ func (b *Builder) taskSection(task *store.Task) string {
 	if task.Description != "" {
 		sb.WriteString(fmt.Sprintf("\n### Description\n%s\n", task.Description))
 	}
+	if len(task.Acceptance) > 0 {
+		sb.WriteString("\n### Acceptance criteria\n")
+		for i, c := range task.Acceptance {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", agent.CriterionLabel(i), c))
+		}
+	}
 
 	return sb.String()
 }
@@ -211,7 +618,47 @@ func (b *Builder) parentContext(parentID int64) (string, error) {
 	return sb.String(), nil
 }
 
-func (b *Builder) eventHistory(taskID int64) (string, error) {
+// maxHandoffs caps how many earlier tasks are summarised in a prompt; the
+// most recent ones are kept.
+const maxHandoffs = 10
+
+// handoffContext summarises what the other finished tasks of the epic
+// introduced, so agents build on it instead of rediscovering it.
+func (b *Builder) handoffContext(task *store.Task) string {
+	handoffs, err := b.store.ListEpicHandoffs(*task.ParentID)
+	if err != nil {
+		return ""
+	}
+
+	var others []store.Handoff
+	for _, h := range handoffs {
+		if h.TaskID != task.ID {
+			others = append(others, h)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	if len(others) > maxHandoffs {
+		others = others[len(others)-maxHandoffs:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Completed Tasks in This Epic\n")
+	sb.WriteString("Build on this work rather than redoing it:\n")
+	for _, h := range others {
+		sb.WriteString(fmt.Sprintf("\n**#%d: %s**\n", h.TaskID, h.Title))
+		for _, n := range h.Notes {
+			sb.WriteString("- " + n + "\n")
+		}
+		if len(h.Files) > 0 {
+			sb.WriteString("Files: " + strings.Join(h.Files, ", ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func (b *Builder) eventHistory(taskID int64, role string) (string, error) {
 	events, err := b.store.GetEvents(taskID)
 	if err != nil {
 		return "", err
@@ -220,8 +667,7 @@ func (b *Builder) eventHistory(taskID int64) (string, error) {
 	// Filter to relevant events (user answers, agent outputs, reviews, architect specs).
 	var relevant []store.Event
 	for _, e := range events {
-		switch e.Type {
-		case "unblocked", "comment", "reviewed", "completed", "architect_spec":
+		if historyEvent(e.Type) && !b.hidden(e, role) {
 			relevant = append(relevant, e)
 		}
 	}
@@ -235,141 +681,35 @@ func (b *Builder) eventHistory(taskID int64) (string, error) {
 	sb.WriteString("Previous interactions on this task:\n\n")
 
 	for _, e := range relevant {
-		agent := "system"
-		if e.Agent != "" {
-			agent = e.Agent
-		}
-		sb.WriteString(fmt.Sprintf("- **[%s]** %s: %s\n", agent, e.Type, e.Content))
+		sb.WriteString(fmt.Sprintf("- **[%s]** %s: %s\n", b.author(e), e.Type, e.Content))
 	}
 
 	return sb.String(), nil
 }
 
-func (b *Builder) roleInstructions(role string) string {
-	switch role {
-	case "pm":
-		return `## Your Process
-1. FIRST: Explore the project structure. Read the README, list directories, understand the tech stack.
-2. THEN: Read relevant source files to understand what the project actually does and how it's structured.
-3. ONLY THEN: Create subtasks based on what you actually found in the code.
-
-## Rules for Good Subtasks
-- Each subtask title must reference a specific file, module, or component (e.g., "Sanitize shell metacharacters in vars/resolver.go Substitute()")
-- Each subtask must be completable by a single developer in a focused session
-- Do NOT create subtasks for problems you didn't find evidence of in the code
-- Do NOT create "research" or "investigate" subtasks — that's YOUR job, you just did it
-- If the epic title is vague or misspelled, interpret the user's intent based on what you find in the code
-- Create between 3 and 7 subtasks. No more. If you think you need more, combine related work.
-
-## CRITICAL OUTPUT RULES
-Your ENTIRE response must be ONLY the SUBTASKS block below. Nothing else.
-Do NOT write analysis, findings, summaries, explanations, or commentary.
-Do NOT use markdown headers, bold text, or section labels in your output.
-Do NOT write anything before "SUBTASKS:" or after the last subtask line.
-
-## Response Format
-Your complete response must look EXACTLY like this and nothing else:
+// hidden reports whether blind mode keeps an event from role: the
+// reviewer judges the code afresh, without earlier verdicts.
+func (b *Builder) hidden(e store.Event, role string) bool {
+	return b.blind != nil && role == roles.Reviewer && e.Type == "reviewed"
+}
 
-SUBTASKS:
-1. Title of first subtask - Description of what to do (priority: high)
-2. Title of second subtask - Description of what to do (priority: medium)
-3. Title of third subtask - Description of what to do (priority: low)
-
-If the task is unclear and you cannot determine what the user wants even after reading the code:
-BLOCKED: [your specific question about what the user wants]`
-
-	case "architect":
-		return `## Your Process
-1. Read the task description carefully. Understand what is being asked.
-2. Explore the relevant parts of the codebase — find the files, functions, and types involved.
-3. If the task is vague, irrelevant to this project, or doesn't make sense — respond with BLOCKED and a specific question. Do NOT invent work.
-4. Produce a spec that tells a developer exactly what to change.
-
-## Rules
-- Reference actual file paths and function names from the codebase (not guesses)
-- Be specific: "Add a timeout parameter to fetchData() in api/client.go:45" not "consider adding timeouts"
-- Note dependencies between changes (what order they should be done in)
-- Mention edge cases and things that could go wrong
-- Do NOT write implementation code — describe WHAT to change, not the code itself
-- Keep scope tight: only include changes directly needed for this task
-
-## Response Format
-Provide your technical specification:
-
-SPEC:
-For each change:
-- **File**: path/to/file.go (function or type name)
-  **Change**: What to modify and how (mention function names, approaches, constraints)
-  **Reason**: Why this change is needed
-
-SUMMARY:
-One paragraph overview of the approach and key architectural decisions.
-
-If the task is unclear or doesn't apply to this codebase:
-BLOCKED: [your specific question or concern]`
-
-	case "coder":
-		return `## Your Process
-1. Read the task description and any architect_spec in the history section carefully.
-2. If an architect_spec is provided, follow it as your implementation plan — it tells you exactly what files to change and how.
-3. Make the actual code changes — edit files, create new files if needed, delete dead code.
-4. After making changes, run the project's test suite to catch regressions.
-5. If tests fail, fix the issues before finishing.
-
-## Critical Rules
-- You MUST actually modify files. Do NOT just describe what should change — CHANGE IT.
-- Do NOT ask for permission to edit files — you have full access.
-- Do NOT refactor unrelated code. Stay focused on this specific task.
-- Do NOT add features or improvements beyond what the task asks for.
-- If you encounter something genuinely unclear that blocks your work, say: BLOCKED: [your specific question]
-- If tests exist, run them. If they fail because of your changes, fix them.
-- Commit messages are not your job — just make the changes.`
-
-	case "reviewer":
-		return `## Your Process
-1. Read the task description to understand WHAT was supposed to be done.
-2. Check the git diff to see WHAT was actually changed.
-3. If the diff is empty or shows no changes related to this task, that means the coder made no changes — evaluate accordingly.
-4. Check if the changes accomplish the task's goal.
-5. Classify each finding by severity.
-
-## Severity Levels
-- CRITICAL: Security vulnerabilities, data loss, crashes, broken core functionality → REJECT
-- HIGH: Logic errors that will cause bugs in production, missing error handling for critical paths → REJECT
-- MEDIUM: Code quality issues, missing edge cases, suboptimal approaches → APPROVE with comments
-- LOW: Style issues, naming, minor improvements → APPROVE with comments
-
-## Verdict Rules
-- REJECT only if there are CRITICAL or HIGH severity issues in the ACTUAL CODE CHANGES
-- APPROVE if the task is accomplished and there are no critical/high issues, even if medium/low issues exist
-- When approving with issues, list them as comments for future improvement
-- Be pragmatic: working code that solves the problem is better than perfect code that doesn't ship
-- If the diff is empty and the coder didn't make changes, REJECT with a simple note that no changes were made. Do NOT write lengthy analysis about what should have been done.
-
-## IMPORTANT CONSTRAINTS
-- You are reviewing CODE CHANGES only. You review what the diff shows.
-- Do NOT suggest tools, commands, or features that you are not certain exist in this project.
-- Do NOT reference "AskUserQuestion", "interactive mode", or other features unless you see them in the actual code.
-- Keep your review focused and concise. A review should be 5-15 lines, not a multi-page essay.
-
-## Response Format
-You MUST include a verdict line in this exact format:
-
-VERDICT: APPROVE
-or
-VERDICT: REJECT
-
-COMMENTS:
-- [severity] file:line: description of finding
-
-Example:
-VERDICT: APPROVE
-
-COMMENTS:
-- [MEDIUM] api/handler.go:42: Missing input length validation, could accept very large payloads
-- [LOW] api/handler.go:15: Consider renaming "data" to something more descriptive`
-
-	default:
-		return ""
+// author names who wrote an event: the agent, or in blind mode its role.
+func (b *Builder) author(e store.Event) string {
+	if e.Agent == "" {
+		return "system"
+	}
+	if role, ok := b.blind[e.Agent]; ok {
+		return role
+	}
+	return e.Agent
+}
+
+// historyEvent reports whether events of this type belong in a prompt's
+// history.
+func historyEvent(eventType string) bool {
+	switch eventType {
+	case "unblocked", "comment", "reviewed", "completed", "architect_spec", "role_output", "perf_regression", "diff_regression", "findings":
+		return true
 	}
+	return false
 }
@@ -5,6 +5,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/langs"
+	"github.com/imkarma/hive/internal/owners"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
 )
 
@@ -120,6 +126,35 @@ func TestBuildPrompt_WithParentContext(t *testing.T) {
 	}
 }
 
+func TestBuildPrompt_WithHandoffs(t *testing.T) {
+	s := testStore(t)
+	b := New(s)
+
+	epic, _ := s.CreateEpic("Auth", "", "high")
+	epicID := epic.ID
+	login, _ := s.CreateTask("Add login", "", "high", &epicID)
+	logout, _ := s.CreateTask("Add logout", "", "high", &epicID)
+
+	s.SaveHandoff(login.ID, []string{"Added auth.Login(user, pass) (*Session, error)"}, []string{"auth/login.go"})
+
+	prompt, err := b.BuildPrompt(logout, "coder")
+	if err != nil {
+		t.Fatalf("BuildPrompt: %v", err)
+	}
+	if !strings.Contains(prompt, "Completed Tasks in This Epic") {
+		t.Error("prompt missing handoff section")
+	}
+	if !strings.Contains(prompt, "auth.Login(user, pass)") || !strings.Contains(prompt, "auth/login.go") {
+		t.Error("prompt missing handoff notes or files")
+	}
+
+	// A task never sees its own handoff.
+	prompt, _ = b.BuildPrompt(login, "coder")
+	if strings.Contains(prompt, "Completed Tasks in This Epic") {
+		t.Error("expected no handoff section for the task that wrote it")
+	}
+}
+
 func TestBuildPrompt_WithEventHistory(t *testing.T) {
 	s := testStore(t)
 	b := New(s)
@@ -192,3 +227,313 @@ func TestBuildPrompt_AllRoles(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildDocsPrompt(t *testing.T) {
+	s := testStore(t)
+	b := New(s)
+
+	epic, _ := s.CreateEpic("Add custom roles", "", "high")
+	epicID := epic.ID
+	done, _ := s.CreateTask("Add roles package", "", "high", &epicID)
+	s.UpdateTaskStatus(done.ID, store.StatusDone)
+	done, _ = s.GetTask(done.ID)
+	open, _ := s.CreateTask("Wire roles into auto", "", "high", &epicID)
+
+	prompt, err := b.BuildDocsPrompt(epic, []store.Task{*done, *open},
+		"+func NewRegistry() {}", []string{"README.md", "docs/"})
+	if err != nil {
+		t.Fatalf("BuildDocsPrompt: %v", err)
+	}
+
+	for _, want := range []string{"Technical Writer", "Add custom roles", "Add roles package", "NewRegistry", "docs/", "DOCS:"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "Wire roles into auto") {
+		t.Error("prompt should only list completed tasks")
+	}
+}
+
+func TestBuildReviewPrompt_Checklist(t *testing.T) {
+	s := testStore(t)
+	t.Chdir(t.TempDir()) // Keep this repo's own diff out of the prompt.
+	task, _ := s.CreateTask("Implement login", "", "high", nil)
+
+	plain, _ := New(s).BuildReviewPrompt(task)
+	if strings.Contains(plain, "CHECKLIST:") {
+		t.Error("prompt without a checklist should not ask for one")
+	}
+
+	prompt, _ := New(s).WithChecklist([]string{"tests added", "no TODOs left"}).BuildReviewPrompt(task)
+	for _, want := range []string{"## Review Checklist", "CHECKLIST:", "- tests added:", "- no TODOs left:"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("review prompt missing %q", want)
+		}
+	}
+	if strings.Index(prompt, "CHECKLIST:") > strings.LastIndex(prompt, "VERDICT") {
+		t.Error("checklist should come before the verdict instructions")
+	}
+
+	coder, _ := New(s).WithChecklist([]string{"tests added"}).BuildPrompt(task, "coder")
+	if strings.Contains(coder, "CHECKLIST:") {
+		t.Error("coder prompt should not include the review checklist")
+	}
+}
+
+func TestBuildStandaloneReviewPrompt(t *testing.T) {
+	diff := "--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-old\n+new\n"
+	prompt := New(nil).WithChecklist([]string{"tests added"}).BuildStandaloneReviewPrompt(diff, "Fixes the login redirect")
+
+	for _, want := range []string{"## Context\nFixes the login redirect", "```diff\n" + diff, "- tests added:", "VERDICT"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "## Task") {
+		t.Error("standalone prompt should not have a task section")
+	}
+}
+
+func TestBuildPrompt_Owners(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Refund endpoint", "", "high", nil)
+	b := New(s).WithOwners(owners.New(owners.Parse("internal/billing/ @payments\n*.md\n")))
+
+	pm, _ := b.BuildPrompt(task, roles.PM)
+	if !strings.Contains(pm, "## Code ownership") || !strings.Contains(pm, "- `internal/billing/`: @payments") {
+		t.Errorf("PM prompt missing ownership:\n%s", pm)
+	}
+	if strings.Contains(pm, "*.md") {
+		t.Error("rules without owners should not be listed")
+	}
+	coder, _ := b.BuildPrompt(task, roles.Coder)
+	if strings.Contains(coder, "## Code ownership") {
+		t.Error("coder prompt should not list ownership")
+	}
+	if strings.Contains(New(s).BuildBreakdownPrompt("x"), "## Code ownership") {
+		t.Error("no owners, no section")
+	}
+}
+
+func TestBuildLocalizePrompt(t *testing.T) {
+	bug := &store.Task{ID: 7, Title: "Crash on logout", Priority: "high", Description: "## Stack trace\npanic: nil map"}
+	prompt := New(nil).BuildLocalizePrompt(bug)
+	for _, want := range []string{"Technical Analyst", "**#7: Crash on logout**", "panic: nil map", "LIKELY_FILES:"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestBuildBreakdownPrompt(t *testing.T) {
+	prompt := New(nil).BuildBreakdownPrompt("  Add OAuth login\nGitHub and Google.\n")
+	if !strings.Contains(prompt, "## Request\nAdd OAuth login\nGitHub and Google.\n") {
+		t.Errorf("prompt missing the request:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, New(nil).roleInstructions("pm")) {
+		t.Error("prompt missing the PM instructions")
+	}
+}
+
+func TestBuildPrompt_Blind(t *testing.T) {
+	s := testStore(t)
+	t.Chdir(t.TempDir())
+	task, _ := s.CreateTask("Implement login", "POST /auth/login", "high", nil)
+	s.AddEvent(task.ID, "claude", "completed", "Added the handler")
+	s.AddEvent(task.ID, "gpt", "reviewed", "REJECT: no rate limiting")
+	s.AddEvent(task.ID, "user", "unblocked", "Use the existing limiter")
+
+	b := New(s).WithBlind(map[string]string{"claude": "coder", "gpt": "reviewer"})
+
+	review, _ := b.BuildReviewPrompt(task)
+	if strings.Contains(review, "claude") || !strings.Contains(review, "**[coder]** completed") {
+		t.Errorf("reviewer should see the coder by role only:\n%s", review)
+	}
+	if strings.Contains(review, "REJECT: no rate limiting") {
+		t.Error("reviewer should not see earlier verdicts")
+	}
+	if !strings.Contains(review, "**[user]** unblocked") {
+		t.Error("non-agent authors should keep their names")
+	}
+
+	coder, _ := b.BuildPrompt(task, "coder")
+	if strings.Contains(coder, "gpt") || !strings.Contains(coder, "**[reviewer]** reviewed: REJECT: no rate limiting") {
+		t.Errorf("coder should see the review, by role only:\n%s", coder)
+	}
+
+	msgs, _ := b.BuildReviewMessages(task)
+	for _, m := range msgs {
+		if strings.Contains(m.Content, "claude") || strings.Contains(m.Content, "no rate limiting") {
+			t.Errorf("review messages leak names or verdicts: %q", m.Content)
+		}
+	}
+}
+
+func TestBuildPrompt_OmitSections(t *testing.T) {
+	s := testStore(t)
+	t.Chdir(t.TempDir())
+	epic, _ := s.CreateEpic("Auth", "Login for the app", "high")
+	task, _ := s.CreateTask("Implement login", "POST /auth/login", "high", &epic.ID)
+	s.AddEvent(task.ID, "claude", "completed", "Added the handler")
+
+	reg := roles.NewRegistry(map[string]config.RoleDef{
+		"reviewer": {Omit: []string{"history", "epic"}},
+	})
+	b := New(s).WithRoles(reg)
+
+	review, _ := b.BuildReviewPrompt(task)
+	if strings.Contains(review, "## History") || strings.Contains(review, "Login for the app") {
+		t.Errorf("reviewer prompt should omit history and epic:\n%s", review)
+	}
+	msgs, _ := b.BuildReviewMessages(task)
+	if len(msgs) != 3 {
+		t.Errorf("review messages without history = %d, want 3", len(msgs))
+	}
+
+	coder, _ := b.BuildPrompt(task, "coder")
+	if !strings.Contains(coder, "## History") || !strings.Contains(coder, "Login for the app") {
+		t.Error("other roles keep every section")
+	}
+}
+
+func TestBuildPrompt_Acceptance(t *testing.T) {
+	s := testStore(t)
+	t.Chdir(t.TempDir())
+	task, _ := s.CreateTask("Implement login", "", "high", nil)
+	s.SetAcceptance(task.ID, []string{"Returns 401 for a wrong password", "Sets a session cookie"})
+	task, _ = s.GetTask(task.ID)
+
+	coder, _ := New(s).BuildPrompt(task, "coder")
+	if !strings.Contains(coder, "### Acceptance criteria\n") || !strings.Contains(coder, "Sets a session cookie") {
+		t.Errorf("coder prompt should list the criteria:\n%s", coder)
+	}
+	if strings.Contains(coder, "ACCEPTANCE:") {
+		t.Error("coder prompt should not ask for an ACCEPTANCE block")
+	}
+
+	reviewer, _ := New(s).BuildReviewPrompt(task)
+	for _, want := range []string{"ACCEPTANCE:", "AC1", "AC2"} {
+		if !strings.Contains(reviewer, want) {
+			t.Errorf("review prompt missing %q", want)
+		}
+	}
+	if strings.Index(reviewer, "ACCEPTANCE:") > strings.LastIndex(reviewer, "VERDICT") {
+		t.Error("acceptance check should come before the verdict instructions")
+	}
+}
+
+func TestBuildExplorePrompt(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("OAuth login", "Log in with GitHub", "high")
+	s.CreateTask("Add GitHub client", "", "high", &epic.ID)
+
+	prompt := New(s).BuildExplorePrompt(epic, roles.Analyst, 15, "how sessions are stored")
+	for _, want := range []string{"Technical Analyst", "OAuth login", "## Existing Tasks", "Add GitHub client", "## Focus\nhow sessions are stored", "about\n15 minutes", "Don't change"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("explore prompt missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "SUBTASKS:") {
+		t.Error("explore prompt should not ask for subtasks")
+	}
+
+	// Findings become part of the epic's history for the PM.
+	s.AddEvent(epic.ID, "analyst", "findings", "## Summary\nSessions live in Redis")
+	plan, _ := New(s).BuildPrompt(epic, roles.PM)
+	if !strings.Contains(plan, "Sessions live in Redis") {
+		t.Error("PM prompt should include earlier findings")
+	}
+}
+
+func TestBuildGroupCommitsPrompt(t *testing.T) {
+	epic := &store.Task{ID: 1, Kind: store.KindEpic, Title: "Adopt feature/login"}
+	prompt := New(nil).BuildGroupCommitsPrompt(epic, []git.Commit{
+		{Hash: "0123456789abcdef", Subject: "Add login form", Body: "With validation.", Files: []string{"login.go", "login_test.go"}},
+		{Hash: "fedcba9876543210", Subject: "Fix typo"},
+	})
+	for _, want := range []string{
+		"Adopt feature/login",
+		"1. 0123456 Add login form\n   With validation.\n   Files: login.go, login_test.go\n",
+		"2. fedcba9 Fix typo\n",
+		"(commits: 1, 2)",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestBuildPlanAddPrompt(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Reports", "Monthly reports", "high")
+	s.CreateTask("Monthly totals query", "Sum by month\nwith details", "high", &epic.ID)
+
+	prompt := New(s).BuildPlanAddPrompt(epic, "  Also export CSV ")
+	for _, want := range []string{
+		"Monthly reports",
+		"## Existing Plan\n- #2 [backlog]: Monthly totals query - Sum by month\n",
+		"## New Requirement\nAlso export CSV\n",
+		"SUBTASKS:",
+		"Plan only the\nsubtasks the new requirement adds",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "with details") {
+		t.Error("only the first line of a task's description belongs in the plan")
+	}
+}
+
+func TestBuildPrompt_InlineOutputs(t *testing.T) {
+	s := testStore(t)
+	root := t.TempDir()
+	arts := artifacts.New(s, root)
+	task, _ := s.CreateTask("Implement login", "POST /auth/login", "high", nil)
+
+	b := New(s)
+	if prompt, _ := b.WithInlineOutputs(arts, 4000).BuildPrompt(task, "coder"); strings.Contains(prompt, "## Latest Output") {
+		t.Error("a task without artifacts should get no output section")
+	}
+
+	arts.Save(task.ID, "code", artifacts.Name(task.ID, "auto-code", "iter1"), "first attempt")
+	arts.Save(task.ID, "code", artifacts.Name(task.ID, "auto-code", "iter2"), "Added the handler.\n\nFILES_CHANGED:\n- auth.go")
+	arts.Save(task.ID, "review", artifacts.Name(task.ID, "auto-review", "iter2"), strings.Repeat("nit ", 2000)+"\nVERDICT: REJECT")
+
+	prompt, _ := b.BuildPrompt(task, "coder")
+	for _, want := range []string{"### Coder (task-1-auto-code-iter2.md)", "FILES_CHANGED:\n- auth.go", "### Reviewer (task-1-auto-review-iter2.md)", "VERDICT: REJECT"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "first attempt") {
+		t.Error("only the latest coder output should be inlined")
+	}
+
+	// Within a small budget the long review is cut in the middle.
+	prompt, _ = New(s).WithInlineOutputs(arts, 100).BuildPrompt(task, "reviewer")
+	if !strings.Contains(prompt, "characters omitted ...]") || !strings.Contains(prompt, "VERDICT: REJECT") || !strings.Contains(prompt, "- auth.go") {
+		t.Errorf("expected the review cut to the budget with both ends kept, got:\n%s", prompt)
+	}
+
+	if prompt, _ := b.BuildPrompt(task, "pm"); strings.Contains(prompt, "## Latest Output") {
+		t.Error("only the coder and the reviewer get earlier outputs")
+	}
+}
+
+func TestBuildPrompt_LanguageHints(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Implement login", "POST /auth/login", "high", nil)
+	b := New(s).WithLanguageHints(langs.Resolve(t.TempDir(), []string{"go"}, map[string]config.LanguageHint{"go": {Test: "make test"}}))
+
+	for _, role := range []string{"coder", "reviewer"} {
+		prompt, _ := b.BuildPrompt(task, role)
+		if !strings.Contains(prompt, "## Language Conventions") || !strings.Contains(prompt, "- Test: `make test`") {
+			t.Errorf("%s prompt missing the language hints:\n%s", role, prompt)
+		}
+	}
+	if prompt, _ := b.BuildPrompt(task, "pm"); strings.Contains(prompt, "## Language Conventions") {
+		t.Error("only the coder and the reviewer get language hints")
+	}
+}
@@ -0,0 +1,157 @@
+package context
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+// DiffChunk is one part of a diff too large to review in one prompt.
+type DiffChunk struct {
+	Part  int // From 1
+	Parts int
+	Files []string
+	Diff  string
+}
+
+// Label describes the chunk for logs and review summaries, e.g.
+// "part 2/3 (internal/auth/login.go, internal/auth/session.go)".
+func (c DiffChunk) Label() string {
+	files := c.Files
+	if len(files) > 3 {
+		files = append(files[:3:3], fmt.Sprintf("%d more", len(c.Files)-3))
+	}
+	return fmt.Sprintf("part %d/%d (%s)", c.Part, c.Parts, strings.Join(files, ", "))
+}
+
+// ReviewChunks splits the diff a task is reviewed on into parts when it
+// is too large for one prompt, so each part is reviewed in full rather
+// than the whole diff cut off. Returns nil when the diff fits.
+func (b *Builder) ReviewChunks(task *store.Task) []DiffChunk {
+	return SplitDiff(b.taskDiff(task), maxDiffLen)
+}
+
+// ForChunk returns a copy of the builder whose review prompts and
+// messages show only the given part of the diff.
+func (b *Builder) ForChunk(c DiffChunk) *Builder {
+	cp := *b
+	cp.chunk = &c
+	return &cp
+}
+
+// diffSection is the diff part of review prompts: the task's diff, cut
+// to fit, or the chunk the builder is for.
+func (b *Builder) diffSection(task *store.Task) string {
+	if c := b.chunk; c != nil {
+		return fmt.Sprintf("## Changes (git diff, part %d of %d)\n"+
+			"The diff is too large to review at once, so it is reviewed in %d parts. "+
+			"This part covers %s; review only these changes, the other parts are reviewed separately. "+
+			"Answer N/A for checklist items and acceptance criteria this part doesn't show.\n```diff\n%s\n```",
+			c.Part, c.Parts, c.Parts, strings.Join(c.Files, ", "), truncateDiff(c.Diff))
+	}
+	diff := b.taskDiff(task)
+	if diff == "" {
+		return ""
+	}
+	return "## Changes (git diff)\n```diff\n" + truncateDiff(diff) + "\n```"
+}
+
+// SplitDiff splits a unified diff larger than max bytes into parts of
+// whole files. Files of the same directory (package) stay together when
+// they fit; a single file larger than max is a part of its own, and is
+// cut off when reviewed. Returns nil when the diff fits in max.
+func SplitDiff(diff string, max int) []DiffChunk {
+	if len(diff) <= max {
+		return nil
+	}
+
+	type fileDiff struct{ name, dir, diff string }
+	var files []fileDiff
+	for _, section := range splitFiles(diff) {
+		name := diffFileName(section)
+		files = append(files, fileDiff{name, path.Dir(name), section})
+	}
+	if len(files) < 2 {
+		return nil
+	}
+
+	// Group by directory, then pack groups into parts; a group that is
+	// too big on its own is split between files.
+	var chunks []DiffChunk
+	var cur DiffChunk
+	flush := func() {
+		if cur.Diff != "" {
+			chunks = append(chunks, cur)
+		}
+		cur = DiffChunk{}
+	}
+	add := func(f fileDiff) {
+		if cur.Diff != "" && len(cur.Diff)+len(f.diff) > max {
+			flush()
+		}
+		cur.Files = append(cur.Files, f.name)
+		cur.Diff += f.diff
+	}
+	for i := 0; i < len(files); {
+		j, size := i, 0
+		for j < len(files) && files[j].dir == files[i].dir {
+			size += len(files[j].diff)
+			j++
+		}
+		if cur.Diff != "" && len(cur.Diff)+size > max {
+			flush()
+		}
+		for _, f := range files[i:j] {
+			add(f)
+		}
+		i = j
+	}
+	flush()
+
+	for i := range chunks {
+		chunks[i].Part, chunks[i].Parts = i+1, len(chunks)
+	}
+	return chunks
+}
+
+// splitFiles cuts a unified diff into one section per file. Anything
+// before the first "diff --git" header (e.g. a commit line) stays with
+// the first file.
+func splitFiles(diff string) []string {
+	var sections []string
+	var cur strings.Builder
+	header := false
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			if header {
+				sections = append(sections, cur.String())
+				cur.Reset()
+			}
+			header = true
+		}
+		cur.WriteString(line)
+	}
+	return append(sections, cur.String())
+}
+
+// diffFileName returns the path a file section of a diff changes: the
+// new name, or the old one for a deleted file.
+func diffFileName(section string) string {
+	name := "?"
+	for _, line := range strings.Split(section, "\n") {
+		if rest, ok := strings.CutPrefix(line, "diff --git "); ok {
+			if _, b, ok := strings.Cut(rest, " b/"); ok {
+				name = b
+			}
+		}
+		if n, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			return n
+		}
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+	}
+	return name
+}
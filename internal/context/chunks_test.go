@@ -0,0 +1,57 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func fileDiff(name string, lines int) string {
+	return fmt.Sprintf("diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1 +1,%d @@\n%s", name, name, name, name, lines, strings.Repeat("+x := 1\n", lines))
+}
+
+func TestSplitDiff(t *testing.T) {
+	small := fileDiff("a.go", 2)
+	if SplitDiff(small, 1000) != nil {
+		t.Fatal("a diff that fits is not split")
+	}
+
+	diff := fileDiff("auth/login.go", 40) + fileDiff("auth/session.go", 40) + fileDiff("api/routes.go", 40) + fileDiff("README.md", 10)
+	chunks := SplitDiff(diff, 1000)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 parts, got %d: %+v", len(chunks), chunks)
+	}
+	if got := strings.Join(chunks[0].Files, ","); got != "auth/login.go,auth/session.go" {
+		t.Errorf("a package's files stay together: %s", got)
+	}
+	if got := strings.Join(chunks[1].Files, ","); got != "api/routes.go,README.md" {
+		t.Errorf("second part: %s", got)
+	}
+	var joined string
+	for i, c := range chunks {
+		if c.Part != i+1 || c.Parts != 2 {
+			t.Errorf("part %d numbered %d/%d", i, c.Part, c.Parts)
+		}
+		joined += c.Diff
+	}
+	if joined != diff {
+		t.Error("the parts together are the whole diff")
+	}
+}
+
+func TestForChunk_ReviewPrompt(t *testing.T) {
+	s := testStore(t)
+	b := New(s)
+	task, _ := s.CreateTask("Auth", "", "high", nil)
+	c := DiffChunk{Part: 2, Parts: 3, Files: []string{"api/routes.go"}, Diff: fileDiff("api/routes.go", 2)}
+
+	prompt, _ := b.ForChunk(c).BuildReviewPrompt(task)
+	for _, want := range []string{"## Changes (git diff, part 2 of 3)", "This part covers api/routes.go", "+++ b/api/routes.go"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if b.chunk != nil {
+		t.Error("ForChunk must not change the original builder")
+	}
+}
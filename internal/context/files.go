@@ -0,0 +1,166 @@
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/imkarma/hive/internal/owners"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Limits for the files shown to API coders. A file over maxFileLines is
+// cut down to its head and the code around the lines the task names.
+const (
+	maxContextFiles = 8
+	maxFileLines    = 300
+	maxFilesBytes   = 24000
+	fileWindow      = 40 // Lines kept on each side of a referenced line
+)
+
+var fileLineRe = regexp.MustCompile(`([\w.@/-]+\.\w+):(\d+)`)
+
+// WithWorkDir sets the directory files named in a task are read from.
+// It defaults to the current directory.
+func (b *Builder) WithWorkDir(dir string) *Builder {
+	b.workDir = dir
+	return b
+}
+
+// filesSection shows the current content of the files the task and its
+// architect spec refer to, with line numbers. API agents can't read the
+// project, so without it they only know the files by name.
+func (b *Builder) filesSection(task *store.Task) string {
+	text := task.Title + "\n" + task.Description
+	if events, err := b.store.GetEvents(task.ID); err == nil {
+		for _, e := range events {
+			if e.Type == "architect_spec" {
+				text += "\n" + e.Content
+			}
+		}
+	}
+
+	// Paths come from Mentions, plus bare names with a line: "main.go:12".
+	paths := owners.Mentions(text)
+	refs := map[string][]int{}
+	for _, m := range fileLineRe.FindAllStringSubmatch(text, -1) {
+		n, _ := strconv.Atoi(m[2])
+		p := strings.TrimPrefix(m[1], "./")
+		if _, ok := refs[p]; !ok && !contains(paths, p) {
+			paths = append(paths, p)
+		}
+		refs[p] = append(refs[p], n)
+	}
+
+	var sb strings.Builder
+	var skipped []string
+	shown := 0
+	for _, p := range paths {
+		data, ok := b.readProjectFile(p)
+		if !ok {
+			continue
+		}
+		if shown == maxContextFiles {
+			skipped = append(skipped, p)
+			continue
+		}
+		block := numberedFile(p, data, refs[p])
+		if sb.Len()+len(block) > maxFilesBytes {
+			skipped = append(skipped, p)
+			continue
+		}
+		sb.WriteString(block)
+		shown++
+	}
+	if shown == 0 {
+		return ""
+	}
+
+	out := "## Relevant files\nThe current content of the files the task refers to. You can't open files, so work from these.\n\n" + sb.String()
+	if len(skipped) > 0 {
+		out += fmt.Sprintf("(%d more file(s) left out for length: %s)\n", len(skipped), strings.Join(skipped, ", "))
+	}
+	return strings.TrimRight(out, "\n")
+}
+
+// readProjectFile reads a text file inside the work dir.
+func (b *Builder) readProjectFile(p string) ([]byte, bool) {
+	if filepath.IsAbs(p) || strings.HasPrefix(filepath.Clean(p), "..") {
+		return nil, false
+	}
+	dir := b.workDir
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Join(dir, filepath.FromSlash(p))
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return nil, false
+	}
+	return data, true
+}
+
+// numberedFile renders a file with line numbers. Long files keep their
+// first lines and a window around each referenced line; the gaps are
+// marked.
+func numberedFile(p string, data []byte, refs []int) string {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	keep := make([]bool, len(lines))
+	if len(lines) <= maxFileLines {
+		for i := range keep {
+			keep[i] = true
+		}
+	} else {
+		head := maxFileLines
+		if len(refs) > 0 {
+			head = maxFileLines / 3
+		}
+		for i := 0; i < head; i++ {
+			keep[i] = true
+		}
+		sort.Ints(refs)
+		for _, n := range refs {
+			for i := n - 1 - fileWindow; i <= n-1+fileWindow; i++ {
+				if i >= 0 && i < len(lines) {
+					keep[i] = true
+				}
+			}
+		}
+	}
+
+	width := len(strconv.Itoa(len(lines)))
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s (%d lines)\n```\n", p, len(lines))
+	for i := 0; i < len(lines); {
+		if !keep[i] {
+			j := i
+			for j < len(lines) && !keep[j] {
+				j++
+			}
+			fmt.Fprintf(&sb, "... (lines %d-%d omitted)\n", i+1, j)
+			i = j
+			continue
+		}
+		fmt.Fprintf(&sb, "%*d | %s\n", width, i+1, lines[i])
+		i++
+	}
+	sb.WriteString("```\n\n")
+	return sb.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package context
+
+import (
+	"fmt"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// ownEvents maps event types to the role whose output they record. In a
+// conversation for that role they're its own earlier turns.
+var ownEvents = map[string]string{
+	"completed":      roles.Coder,
+	"reviewed":       roles.Reviewer,
+	"architect_spec": roles.Architect,
+}
+
+// BuildMessages is BuildPrompt as a conversation, for API agents: the role
+// header is the system message, the task and its context the first user
+// turn, the history follows as turns (the role's own earlier output as
+// assistant turns, everything else as user turns), and the instructions
+// come last. The first turns stay the same across fix iterations, so
+// providers can cache them.
+func (b *Builder) BuildMessages(task *store.Task, role string) ([]agent.Message, error) {
+	msgs := []agent.Message{
+		{Role: agent.RoleSystem, Content: b.roleHeader(role)},
+		{Role: agent.RoleUser, Content: b.contextTurn(task, role)},
+	}
+	msgs = append(msgs, b.historyTurns(task.ID, role)...)
+	if outputs := b.latestOutputs(task.ID, role); outputs != "" {
+		msgs = append(msgs, agent.Message{Role: agent.RoleUser, Content: outputs})
+	}
+
+	// The coder can't read the files it is to change, so it gets them
+	// here. They change as it works, so they go last, like a diff.
+	last := b.roleInstructions(role)
+	if role == roles.Coder && b.shows(role, "files") {
+		if files := b.filesSection(task); files != "" {
+			last = files + "\n\n" + last
+		}
+	}
+	msgs = append(msgs, agent.Message{Role: agent.RoleUser, Content: last})
+	return msgs, nil
+}
+
+// BuildReviewMessages is BuildReviewPrompt as a conversation.
+func (b *Builder) BuildReviewMessages(task *store.Task) ([]agent.Message, error) {
+	return b.BuildDiffMessages(task, roles.Reviewer)
+}
+
+// BuildDiffMessages is BuildDiffPrompt as a conversation. The diff changes
+// every iteration, so it goes in the last turn, after the history.
+func (b *Builder) BuildDiffMessages(task *store.Task, role string) ([]agent.Message, error) {
+	msgs := []agent.Message{
+		{Role: agent.RoleSystem, Content: b.roleHeader(role)},
+		{Role: agent.RoleUser, Content: b.contextTurn(task, role)},
+	}
+	msgs = append(msgs, b.historyTurns(task.ID, role)...)
+	if outputs := b.latestOutputs(task.ID, role); outputs != "" {
+		msgs = append(msgs, agent.Message{Role: agent.RoleUser, Content: outputs})
+	}
+
+	var last string
+	if diff := b.diffSection(task); diff != "" {
+		last = diff + "\n\n"
+	}
+	if role == roles.Reviewer && len(task.Acceptance) > 0 {
+		last += acceptanceSection(task) + "\n\n"
+	}
+	if role == roles.Reviewer && len(b.checklist) > 0 && b.shows(role, "checklist") {
+		last += b.checklistSection() + "\n\n"
+	}
+	last += b.roleInstructions(role)
+	msgs = append(msgs, agent.Message{Role: agent.RoleUser, Content: last})
+	return msgs, nil
+}
+
+// contextTurn is the task with its epic and the work done before it.
+func (b *Builder) contextTurn(task *store.Task, role string) string {
+	content := b.taskSection(task)
+	if task.ParentID != nil {
+		if parentCtx, err := b.parentContext(*task.ParentID); err == nil && parentCtx != "" && b.shows(role, "epic") {
+			content += "\n\n" + parentCtx
+		}
+		if handoffs := b.handoffContext(task); handoffs != "" && b.shows(role, "handoffs") {
+			content += "\n\n" + handoffs
+		}
+	}
+	return content
+}
+
+// historyTurns turns the task's history into conversation turns for role.
+func (b *Builder) historyTurns(taskID int64, role string) []agent.Message {
+	if !b.shows(role, "history") {
+		return nil
+	}
+	events, err := b.store.GetEvents(taskID)
+	if err != nil {
+		return nil
+	}
+	var msgs []agent.Message
+	for _, e := range events {
+		if !historyEvent(e.Type) || b.hidden(e, role) {
+			continue
+		}
+		if ownEvents[e.Type] == role {
+			msgs = append(msgs, agent.Message{Role: agent.RoleAssistant, Content: e.Content})
+			continue
+		}
+		msgs = append(msgs, agent.Message{Role: agent.RoleUser, Content: fmt.Sprintf("**[%s]** %s: %s", b.author(e), e.Type, e.Content)})
+	}
+	return msgs
+}
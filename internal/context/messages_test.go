@@ -0,0 +1,101 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+)
+
+func TestBuildMessages(t *testing.T) {
+	s := testStore(t)
+	b := New(s)
+
+	epic, _ := s.CreateEpic("Auth", "Login for the app", "high")
+	task, _ := s.CreateTask("Implement login", "POST /auth/login", "high", &epic.ID)
+	s.AddEvent(task.ID, "claude", "completed", "Added the handler")
+	s.AddEvent(task.ID, "gpt", "reviewed", "REJECT: no rate limiting")
+	s.AddEvent(task.ID, "", "unblocked", "Use the existing limiter")
+
+	msgs, err := b.BuildMessages(task, "coder")
+	if err != nil {
+		t.Fatalf("BuildMessages: %v", err)
+	}
+	roles := make([]string, len(msgs))
+	for i, m := range msgs {
+		roles[i] = m.Role
+	}
+	want := []string{agent.RoleSystem, agent.RoleUser, agent.RoleAssistant, agent.RoleUser, agent.RoleUser, agent.RoleUser}
+	if strings.Join(roles, ",") != strings.Join(want, ",") {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+	if !strings.Contains(msgs[0].Content, "Software Developer") {
+		t.Error("system message should be the coder header")
+	}
+	if !strings.Contains(msgs[1].Content, "Implement login") || !strings.Contains(msgs[1].Content, "Login for the app") {
+		t.Error("first user turn should hold the task and its epic")
+	}
+	if msgs[2].Content != "Added the handler" {
+		t.Errorf("coder's own output should be an assistant turn, got %q", msgs[2].Content)
+	}
+	if !strings.Contains(msgs[3].Content, "REJECT: no rate limiting") {
+		t.Errorf("review should be a user turn for the coder, got %q", msgs[3].Content)
+	}
+	if !strings.Contains(msgs[len(msgs)-1].Content, "BLOCKED:") {
+		t.Error("instructions should come last")
+	}
+
+	// For the reviewer, its earlier review is its own turn.
+	msgs, _ = b.BuildReviewMessages(task)
+	var own []string
+	for _, m := range msgs {
+		if m.Role == agent.RoleAssistant {
+			own = append(own, m.Content)
+		}
+	}
+	if len(own) != 1 || own[0] != "REJECT: no rate limiting" {
+		t.Errorf("reviewer assistant turns = %v", own)
+	}
+}
+
+func TestBuildMessages_RelevantFiles(t *testing.T) {
+	s := testStore(t)
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "api"), 0755)
+	os.WriteFile(filepath.Join(dir, "api", "client.go"), []byte("package api\n\nfunc fetch() {}\n"), 0644)
+	var long strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&long, "line %d\n", i)
+	}
+	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(long.String()), 0644)
+	b := New(s).WithWorkDir(dir)
+
+	task, _ := s.CreateTask("Add a timeout", "Change `api/client.go` and docs/missing.md", "high", nil)
+	s.AddEvent(task.ID, "arch", "architect_spec", "Also see big.txt:700")
+
+	msgs, _ := b.BuildMessages(task, "coder")
+	last := msgs[len(msgs)-1].Content
+	if !strings.Contains(last, "## Relevant files") || !strings.Contains(last, "3 | func fetch() {}") {
+		t.Fatalf("last turn should hold numbered file content, got:\n%s", last)
+	}
+	if strings.Contains(last, "docs/missing.md") {
+		t.Error("files that don't exist should be left out")
+	}
+	if !strings.Contains(last, " 700 | line 700") || !strings.Contains(last, "   1 | line 1\n") {
+		t.Error("long file should keep its head and the referenced line")
+	}
+	if strings.Contains(last, "line 500\n") || !strings.Contains(last, "omitted)") {
+		t.Error("long file should be truncated with the gaps marked")
+	}
+	if !strings.Contains(last, "BLOCKED:") {
+		t.Error("instructions should still come last")
+	}
+
+	msgs, _ = b.BuildMessages(task, "reviewer")
+	if strings.Contains(msgs[len(msgs)-1].Content, "## Relevant files") {
+		t.Error("only coders get file contents")
+	}
+}
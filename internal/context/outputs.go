@@ -0,0 +1,90 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/roles"
+)
+
+// inlinedOutputs are the artifact types inlined with WithInlineOutputs,
+// in the order they are shown.
+var inlinedOutputs = []struct{ artifactType, label string }{
+	{"code", "Coder"},
+	{"review", "Reviewer"},
+}
+
+// WithInlineOutputs gives the coder and the reviewer the latest coder and
+// review output in full, read from the task's artifacts, instead of only
+// the short previews in the history. Together they are cut to about
+// budget tokens.
+func (b *Builder) WithInlineOutputs(arts *artifacts.Manager, budget int) *Builder {
+	b.arts = arts
+	b.inlineBudget = budget
+	return b
+}
+
+// latestOutputs is the section with the latest coder and review output.
+func (b *Builder) latestOutputs(taskID int64, role string) string {
+	if b.arts == nil || (role != roles.Coder && role != roles.Reviewer) || !b.shows(role, "history") {
+		return ""
+	}
+	list, err := b.store.GetArtifacts(taskID)
+	if err != nil {
+		return ""
+	}
+
+	type output struct {
+		label, name, text string
+	}
+	var outs []*output
+	for _, kind := range inlinedOutputs {
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].Type != kind.artifactType {
+				continue
+			}
+			if data, err := os.ReadFile(b.arts.Resolve(list[i].FilePath)); err == nil && strings.TrimSpace(string(data)) != "" {
+				outs = append(outs, &output{label: kind.label, name: path.Base(list[i].FilePath), text: strings.TrimSpace(string(data))})
+			}
+			break
+		}
+	}
+	if len(outs) == 0 {
+		return ""
+	}
+
+	// Share the budget out shortest first, so what a short output doesn't
+	// use goes to the longer one.
+	left := b.inlineBudget * 4
+	bySize := append([]*output(nil), outs...)
+	sort.Slice(bySize, func(i, j int) bool { return len(bySize[i].text) < len(bySize[j].text) })
+	for i, o := range bySize {
+		o.text = cutMiddle(o.text, left/(len(bySize)-i))
+		left -= len(o.text)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Latest Output\n")
+	sb.WriteString("The most recent coder and reviewer output on this task, in full:\n")
+	for _, o := range outs {
+		fmt.Fprintf(&sb, "\n### %s (%s)\n%s\n", o.label, o.name, o.text)
+	}
+	return sb.String()
+}
+
+// cutMiddle shortens text to about max characters, keeping its start and
+// its end: agents summarize last (FILES_CHANGED, VERDICT).
+func cutMiddle(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	if max <= 0 {
+		return ""
+	}
+	head, tail := max/2, max-max/2
+	return fmt.Sprintf("%s\n[... %d characters omitted ...]\n%s", text[:head], len(text)-max, text[len(text)-tail:])
+}
@@ -0,0 +1,198 @@
+// Package disk keeps hive from filling the disk halfway through a run.
+// Before it creates a worktree or writes an artifact, hive asks Check
+// whether there is room: enough free space on the disk, and .hive under
+// its configured cap. When there isn't, the error says what takes the
+// space and how to reclaim it, so the run stops cleanly instead of
+// failing on a half-written file.
+//
+// Checks are off until Init configures the process-wide Guard.
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLowSpace is returned (wrapped) by Check when there is no room.
+var ErrLowSpace = errors.New("not enough disk space")
+
+// remeasure is how long a measured .hive size is trusted; writes in
+// between are added to it.
+const remeasure = time.Minute
+
+// Guard checks a .hive directory against the limits.
+type Guard struct {
+	dir     string // The .hive directory
+	minFree int64  // Bytes to leave free on the disk; 0 = no check
+	maxHive int64  // Cap on the size of dir; 0 = no cap
+
+	mu       sync.Mutex
+	used     int64
+	measured time.Time
+}
+
+// New returns a Guard for the .hive directory dir.
+func New(dir string, minFree, maxHive int64) *Guard {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return &Guard{dir: dir, minFree: minFree, maxHive: maxHive}
+}
+
+// Check reports whether need more bytes fit: the disk keeps minFree and
+// .hive stays under its cap. A nil Guard allows everything.
+func (g *Guard) Check(need int64) error {
+	if g == nil {
+		return nil
+	}
+	if g.minFree > 0 {
+		if free, ok := Free(g.dir); ok && free-need < g.minFree {
+			return g.lowSpace(fmt.Sprintf("only %s free on the disk holding %s, and hive keeps %s free (disk.min_free_mb)",
+				Size(free), g.dir, Size(g.minFree)))
+		}
+	}
+	if g.maxHive > 0 {
+		if used := g.usage(); used+need > g.maxHive {
+			return g.lowSpace(fmt.Sprintf("%s is using %s of its %s cap (disk.max_hive_mb)",
+				g.dir, Size(used), Size(g.maxHive)))
+		}
+	}
+	return nil
+}
+
+// Wrote records n bytes written under .hive since it was last measured.
+func (g *Guard) Wrote(n int64) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.used += n
+	g.mu.Unlock()
+}
+
+// usage returns the size of .hive, measuring it at most once a minute.
+func (g *Guard) usage() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.measured) > remeasure {
+		g.used = Usage(g.dir)
+		g.measured = time.Now()
+	}
+	return g.used
+}
+
+// lowSpace builds the error for a failed check, with what takes space
+// under .hive and how to get it back.
+func (g *Guard) lowSpace(reason string) error {
+	var sb strings.Builder
+	sb.WriteString(reason)
+	if tips := Suggestions(g.dir); len(tips) > 0 {
+		sb.WriteString("\nTo free space:")
+		for _, t := range tips {
+			sb.WriteString("\n  - " + t)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrLowSpace, sb.String())
+}
+
+// Usage returns the total size of the files under dir.
+func Usage(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// cleanup says how to reclaim each well-known directory under .hive.
+var cleanup = map[string]string{
+	"worktrees": "leftover worktrees: git worktree remove --force <path>, or rm -rf .hive/worktrees && git worktree prune (git.keep_worktrees keeps them between runs)",
+	"runs":      "run logs and agent outputs: delete the .hive/runs files of finished epics you no longer need",
+	"cassettes": "recorded cassettes: delete the ones in .hive/cassettes you no longer replay",
+}
+
+// Suggestions lists the biggest entries under dir, largest first, with
+// how to clean them up.
+func Suggestions(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type entry struct {
+		name string
+		size int64
+	}
+	var big []entry
+	for _, e := range entries {
+		if size := Usage(filepath.Join(dir, e.Name())); size > 0 {
+			big = append(big, entry{e.Name(), size})
+		}
+	}
+	sort.Slice(big, func(i, j int) bool { return big[i].size > big[j].size })
+
+	var tips []string
+	for _, e := range big {
+		if how, ok := cleanup[e.name]; ok {
+			tips = append(tips, fmt.Sprintf("%s (%s) %s", e.name, Size(e.size), how))
+		}
+	}
+	return tips
+}
+
+// Size formats a byte count for people, e.g. "1.5 GB".
+func Size(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+var (
+	mu      sync.RWMutex
+	current *Guard
+)
+
+// Init sets the process-wide Guard; nil turns the checks off.
+func Init(g *Guard) {
+	mu.Lock()
+	current = g
+	mu.Unlock()
+}
+
+// Default returns the process-wide Guard, or nil when checks are off.
+func Default() *Guard {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Check runs the process-wide Guard's check.
+func Check(need int64) error {
+	return Default().Check(need)
+}
+
+// Wrote tells the process-wide Guard about n bytes written.
+func Wrote(n int64) {
+	Default().Wrote(n)
+}
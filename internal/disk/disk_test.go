@@ -0,0 +1,78 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		512:       "512 B",
+		1536:      "1.5 KB",
+		500 << 20: "500.0 MB",
+		3 << 30:   "3.0 GB",
+		5 << 39:   "2.5 TB",
+	} {
+		if got := Size(n); got != want {
+			t.Errorf("Size(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestGuard_MaxHive(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "runs"), 0755)
+	os.MkdirAll(filepath.Join(dir, "worktrees", "slot-1"), 0755)
+	os.WriteFile(filepath.Join(dir, "runs", "task-1.log"), make([]byte, 1000), 0644)
+	os.WriteFile(filepath.Join(dir, "worktrees", "slot-1", "big.bin"), make([]byte, 3000), 0644)
+
+	if Usage(dir) != 4000 {
+		t.Fatalf("Usage = %d, want 4000", Usage(dir))
+	}
+
+	g := New(dir, 0, 5000)
+	if err := g.Check(500); err != nil {
+		t.Fatalf("4500 of 5000 bytes should fit: %v", err)
+	}
+	g.Wrote(600)
+	err := g.Check(500)
+	if !errors.Is(err, ErrLowSpace) {
+		t.Fatalf("expected ErrLowSpace over the cap, got %v", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "disk.max_hive_mb") {
+		t.Errorf("error should name the setting: %s", msg)
+	}
+	// The biggest directory comes first, each with how to clean it up.
+	wt, runs := strings.Index(msg, "worktrees (2.9 KB)"), strings.Index(msg, "runs (1000 B)")
+	if wt < 0 || runs < 0 || wt > runs {
+		t.Errorf("expected worktrees then runs in the suggestions:\n%s", msg)
+	}
+}
+
+func TestGuard_MinFree(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := Free(dir); !ok {
+		t.Skip("free space unknown on this platform")
+	}
+	if err := New(dir, 1, 0).Check(0); err != nil {
+		t.Errorf("a byte should be free: %v", err)
+	}
+	if err := New(dir, 1<<60, 0).Check(0); !errors.Is(err, ErrLowSpace) || !strings.Contains(err.Error(), "disk.min_free_mb") {
+		t.Errorf("expected ErrLowSpace for an exabyte, got %v", err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defer Init(nil)
+	if err := Check(1 << 62); err != nil {
+		t.Errorf("without Init nothing is checked: %v", err)
+	}
+	Init(New(t.TempDir(), 0, 10))
+	if err := Check(11); !errors.Is(err, ErrLowSpace) {
+		t.Errorf("expected the process-wide guard to apply, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package disk
+
+// Free can't tell the free space on this platform; the check is skipped.
+func Free(path string) (int64, bool) {
+	return 0, false
+}
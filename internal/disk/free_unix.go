@@ -0,0 +1,15 @@
+//go:build unix
+
+package disk
+
+import "syscall"
+
+// Free returns the bytes available to an unprivileged user on the
+// filesystem holding path.
+func Free(path string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true
+}
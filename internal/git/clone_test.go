@@ -0,0 +1,29 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloneTo(t *testing.T) {
+	dir := initTestRepo(t)
+	clone := filepath.Join(t.TempDir(), "clone")
+	if err := New(dir).CloneTo(clone); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	c := New(clone)
+	if branch, _ := c.CurrentBranch(); branch != "main" {
+		t.Errorf("expected clone on main, got %q", branch)
+	}
+	if err := c.CreateBranch("hive/epic-1"); err != nil {
+		t.Fatalf("branch in clone: %v", err)
+	}
+	os.WriteFile(filepath.Join(clone, "x.txt"), []byte("x\n"), 0644)
+	if ok, err := c.CommitAll("in clone"); !ok || err != nil {
+		t.Fatalf("commit in clone: %v", err)
+	}
+	if New(dir).BranchExists("hive/epic-1") {
+		t.Error("branch made in the clone leaked into the repo")
+	}
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TaskCommits returns the commits on epicBranch since baseBranch that hive
+// made for a task ("hive: task #N — ..." and "hive: tests for task #N —
+// ..."), oldest first.
+func (s *Safety) TaskCommits(baseBranch, epicBranch string, taskID int64) ([]string, error) {
+	pattern := fmt.Sprintf("^hive: (tests for )?task #%d — ", taskID)
+	cmd := exec.Command("git", "log", "--format=%H", "--reverse", "--extended-regexp",
+		"--grep", pattern, baseBranch+".."+epicBranch)
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// CherryPick switches to branch and applies commits on top of it, in
+// order. If a commit doesn't apply, the cherry-pick is aborted and branch
+// is left as it was.
+func (s *Safety) CherryPick(branch string, commits []string) error {
+	defer s.span("git.cherry-pick").End()
+	return s.replay(branch, "cherry-pick", commits)
+}
+
+// RevertCommits switches to branch and adds commits undoing the given
+// ones, newest first. If a revert doesn't apply cleanly, it is aborted and
+// branch is left as it was.
+func (s *Safety) RevertCommits(branch string, commits []string) error {
+	defer s.span("git.revert").End()
+	reversed := make([]string, len(commits))
+	for i, c := range commits {
+		reversed[len(commits)-1-i] = c
+	}
+	return s.replay(branch, "revert", reversed)
+}
+
+// replay runs git cherry-pick or git revert for commits on branch.
+func (s *Safety) replay(branch, op string, commits []string) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	if err := s.Checkout(branch); err != nil {
+		return err
+	}
+	before, err := s.RevParse("HEAD")
+	if err != nil {
+		return err
+	}
+
+	args := []string{op}
+	if op == "revert" {
+		args = append(args, "--no-edit")
+	}
+	cmd := exec.Command("git", append(args, commits...)...)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	abort := exec.Command("git", op, "--abort")
+	abort.Dir = s.workDir
+	abort.Run()
+	// With several commits, earlier ones may already be on the branch.
+	s.ResetBranch(branch, before)
+	return fmt.Errorf("%s on %s: %s", op, branch, strings.TrimSpace(string(out)))
+}
+
+// Commit is one commit of a branch, for turning existing work into tasks.
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+	Files   []string
+}
+
+// BranchCommits returns the commits on branch since baseBranch, oldest
+// first, with the files each one changed. Merge commits are left out.
+func (s *Safety) BranchCommits(baseBranch, branch string) ([]Commit, error) {
+	// Each commit starts with a record separator; the fields are NUL
+	// separated and --name-only lists the files after them.
+	cmd := exec.Command("git", "log", "--reverse", "--no-merges", "--name-only",
+		"--format=%x1e%H%x00%s%x00%b%x00", baseBranch+".."+branch)
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var commits []Commit
+	for _, rec := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(rec, "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])}
+		for _, f := range strings.Split(fields[3], "\n") {
+			if f = strings.TrimSpace(f); f != "" {
+				c.Files = append(c.Files, f)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
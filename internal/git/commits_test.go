@@ -0,0 +1,115 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTaskCommits_CherryPickAndRevert(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644)
+	s.CommitAll("hive: task #1 — A")
+	os.WriteFile(filepath.Join(dir, "a_test.go"), []byte("package a\n"), 0644)
+	s.CommitAll("hive: tests for task #1 — A")
+	os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0644)
+	s.CommitAll("hive: task #12 — B")
+
+	commits, err := s.TaskCommits("main", "hive/epic-1", 1)
+	if err != nil {
+		t.Fatalf("TaskCommits: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits for task #1, got %v", commits)
+	}
+
+	s.BranchAt("hive/epic-2", "main")
+	if err := s.CherryPick("hive/epic-2", commits); err != nil {
+		t.Fatalf("CherryPick: %v", err)
+	}
+	for _, f := range []string{"a.go", "a_test.go"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("%s not picked onto hive/epic-2", f)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.go")); err == nil {
+		t.Error("task #12's commit should not be picked")
+	}
+
+	if err := s.RevertCommits("hive/epic-1", commits); err != nil {
+		t.Fatalf("RevertCommits: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.go")); err == nil {
+		t.Error("a.go should be reverted on hive/epic-1")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.go")); err != nil {
+		t.Error("b.go should stay on hive/epic-1")
+	}
+}
+
+func TestCherryPick_ConflictLeavesBranch(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# one\n"), 0644)
+	s.CommitAll("hive: task #1 — one")
+	commit, _ := s.RevParse("HEAD")
+
+	s.CreateBranchFrom("hive/epic-2", "main")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# two\n"), 0644)
+	s.CommitAll("hive: task #2 — two")
+	before, _ := s.RevParse("HEAD")
+
+	if err := s.CherryPick("hive/epic-2", []string{commit}); err == nil {
+		t.Fatal("expected a conflict")
+	}
+	if after, _ := s.RevParse("HEAD"); after != before {
+		t.Errorf("branch moved from %s to %s", before, after)
+	}
+	if s.HasUncommittedChanges() {
+		t.Error("conflict left changes in the working tree")
+	}
+}
+
+func TestBranchCommitsAndCommitsDiff(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("feature/foo")
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0644)
+	s.CommitAll("Add a and b\n\nBoth packages are empty for now.")
+	os.WriteFile(filepath.Join(dir, "c.go"), []byte("package c\n"), 0644)
+	s.CommitAll("Add c")
+
+	commits, err := s.BranchCommits("main", "feature/foo")
+	if err != nil {
+		t.Fatalf("BranchCommits: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %+v", commits)
+	}
+	first := commits[0]
+	if first.Subject != "Add a and b" || first.Body != "Both packages are empty for now." {
+		t.Errorf("unexpected first commit: %+v", first)
+	}
+	if len(first.Files) != 2 || first.Files[0] != "a.go" || first.Files[1] != "b.go" {
+		t.Errorf("unexpected files: %v", first.Files)
+	}
+	if commits[1].Subject != "Add c" || len(commits[1].Files) != 1 {
+		t.Errorf("unexpected second commit: %+v", commits[1])
+	}
+
+	diff, err := s.CommitsDiff([]string{commits[1].Hash})
+	if err != nil {
+		t.Fatalf("CommitsDiff: %v", err)
+	}
+	if !strings.Contains(diff, "Add c") || !strings.Contains(diff, "+package c") || strings.Contains(diff, "package a") {
+		t.Errorf("unexpected diff: %q", diff)
+	}
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// emptyTree is the hash of git's empty tree, the diff base in a repo
+// without commits.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// WorkingDiff returns the uncommitted changes in the working tree against
+// HEAD, staged or not, with untracked files shown as added. LFS-tracked
+// files are left out.
+func (s *Safety) WorkingDiff() (string, error) {
+	defer s.span("git.diff").End()
+	base := "HEAD"
+	if _, err := s.RevParse("HEAD"); err != nil {
+		base = emptyTree
+	}
+	args := append([]string{"diff", base, "--"}, s.LFSExcludes()...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff: %w", err)
+	}
+
+	cmd = exec.Command("git", "ls-files", "--others", "--exclude-standard")
+	cmd.Dir = s.workDir
+	untracked, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-files: %w", err)
+	}
+	var sb strings.Builder
+	sb.Write(out)
+	for _, path := range strings.Split(strings.TrimSpace(string(untracked)), "\n") {
+		if path == "" {
+			continue
+		}
+		// --no-index exits 1 when the files differ, which they always do.
+		cmd := exec.Command("git", "diff", "--no-index", "--", "/dev/null", path)
+		cmd.Dir = s.workDir
+		var buf bytes.Buffer
+		cmd.Stdout = &buf
+		cmd.Run()
+		sb.Write(buf.Bytes())
+	}
+	return sb.String(), nil
+}
+
+// StagedDiff returns the changes staged for commit. LFS-tracked files are
+// left out.
+func (s *Safety) StagedDiff() (string, error) {
+	return s.diff("--cached")
+}
+
+// RangeDiff returns the diff for a revision range such as "main..feature"
+// or "HEAD~3..HEAD" (or "A...B", from the merge base). LFS-tracked files
+// are left out.
+func (s *Safety) RangeDiff(revRange string) (string, error) {
+	if !strings.Contains(revRange, "..") || strings.HasPrefix(revRange, "-") {
+		return "", fmt.Errorf("invalid range %q, want A..B", revRange)
+	}
+	return s.diff(revRange)
+}
+
+// CommitsDiff returns the changes the given commits made, one after the
+// other, each headed by its hash and subject. LFS-tracked files are left
+// out.
+func (s *Safety) CommitsDiff(commits []string) (string, error) {
+	if len(commits) == 0 {
+		return "", nil
+	}
+	defer s.span("git.diff").End()
+	args := append([]string{"show", "--format=commit %h %s"}, commits...)
+	args = append(append(args, "--"), s.LFSExcludes()...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git show: %s", strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// DiffTool opens the changes on epicBranch since it left baseBranch in the
+// user's git difftool, as one directory diff, and waits for it to close.
+func (s *Safety) DiffTool(baseBranch, epicBranch string) error {
+	cmd := exec.Command("git", "difftool", "--dir-diff", "--no-prompt", baseBranch+"..."+epicBranch)
+	cmd.Dir = s.workDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git difftool: %w", err)
+	}
+	return nil
+}
+
+func (s *Safety) diff(args ...string) (string, error) {
+	defer s.span("git.diff").End()
+	args = append(append([]string{"diff"}, args...), "--")
+	cmd := exec.Command("git", append(args, s.LFSExcludes()...)...)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git diff: %s", strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
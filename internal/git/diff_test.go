@@ -0,0 +1,95 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkingDiff(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	if diff, err := s.WorkingDiff(); err != nil || diff != "" {
+		t.Fatalf("clean tree: diff %q, err %v", diff, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# hive\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644)
+
+	diff, err := s.WorkingDiff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"+# hive", "-# test", "+++ b/new.go", "+package main"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+}
+
+func TestStagedAndRangeDiff(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# hive\n"), 0644)
+	if diff, _ := s.StagedDiff(); diff != "" {
+		t.Fatalf("nothing staged, got %q", diff)
+	}
+	cmd := exec.Command("git", "add", "README.md")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %s", out)
+	}
+	if diff, _ := s.StagedDiff(); !strings.Contains(diff, "+# hive") {
+		t.Fatalf("staged diff missing change: %q", diff)
+	}
+	s.CommitAll("rename")
+
+	diff, err := s.RangeDiff("HEAD~1..HEAD")
+	if err != nil || !strings.Contains(diff, "+# hive") {
+		t.Fatalf("range diff %q, err %v", diff, err)
+	}
+	if _, err := s.RangeDiff("HEAD"); err == nil {
+		t.Error("expected an error for a range without ..")
+	}
+	if _, err := s.RangeDiff("nope..HEAD"); err == nil {
+		t.Error("expected an error for an unknown revision")
+	}
+}
+
+func TestDiffTool(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package main\n"), 0644)
+	s.CommitAll("add feature")
+	s.Checkout("main")
+
+	// A difftool that lists the files on the epic side.
+	listing := filepath.Join(t.TempDir(), "listing.txt")
+	for _, kv := range [][2]string{
+		{"diff.tool", "record"},
+		{"difftool.record.cmd", `ls "$REMOTE" > "` + listing + `"`},
+	} {
+		cmd := exec.Command("git", "config", kv[0], kv[1])
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git config: %s", out)
+		}
+	}
+
+	if err := s.DiffTool("main", "hive/epic-1"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(listing)
+	if err != nil {
+		t.Fatalf("difftool did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "feature.go" {
+		t.Errorf("difftool saw %q, want feature.go", got)
+	}
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// LFSPatterns returns the path patterns tracked by Git LFS, read from the
+// top-level .gitattributes.
+func (s *Safety) LFSPatterns() []string {
+	f, err := os.Open(filepath.Join(s.workDir, ".gitattributes"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			if attr == "filter=lfs" {
+				patterns = append(patterns, strings.TrimPrefix(fields[0], "/"))
+				break
+			}
+		}
+	}
+	return patterns
+}
+
+// UsesLFS reports whether the repository tracks any files with Git LFS.
+func (s *Safety) UsesLFS() bool {
+	return len(s.LFSPatterns()) > 0
+}
+
+// LFSExcludes returns pathspecs that leave LFS-tracked files out of a
+// `git diff`. Their diffs are only pointer files, which tell a reviewer
+// nothing and waste prompt space.
+func (s *Safety) LFSExcludes() []string {
+	var specs []string
+	for _, p := range s.LFSPatterns() {
+		specs = append(specs, ":(exclude)"+p)
+	}
+	return specs
+}
+
+// lfsAvailable reports whether the git-lfs extension is installed.
+func lfsAvailable() bool {
+	return exec.Command("git", "lfs", "version").Run() == nil
+}
+
+// setupLFS installs the LFS hooks and filters for a worktree and replaces
+// the pointer files with real content, so agents see the actual files and
+// pushes from the worktree upload LFS objects.
+func (s *Safety) setupLFS() error {
+	if !lfsAvailable() {
+		return fmt.Errorf("repository uses Git LFS but git-lfs is not installed")
+	}
+	for _, args := range [][]string{
+		{"lfs", "install", "--local"},
+		{"lfs", "pull"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = s.workDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLFSPatterns(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	if s.UsesLFS() {
+		t.Fatal("expected no LFS without .gitattributes")
+	}
+
+	attrs := "# assets\n*.psd filter=lfs diff=lfs merge=lfs -text\n/models/*.bin filter=lfs -text\n*.go text eol=lf\n"
+	os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(attrs), 0644)
+
+	got := s.LFSPatterns()
+	if len(got) != 2 || got[0] != "*.psd" || got[1] != "models/*.bin" {
+		t.Fatalf("unexpected patterns: %v", got)
+	}
+	if !s.UsesLFS() {
+		t.Fatal("expected UsesLFS")
+	}
+}
+
+func TestDiff_ExcludesLFSFiles(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("*.psd filter=lfs -text\n"), 0644)
+	s.CommitAll("track psd")
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "logo.psd"), []byte("binary-ish"), 0644)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+	s.CommitAll("work")
+
+	diff, err := s.Diff("main", "hive/epic-1")
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if !strings.Contains(diff, "main.go") {
+		t.Error("expected main.go in diff")
+	}
+	if strings.Contains(diff, "logo.psd") {
+		t.Error("expected LFS file to be excluded from diff")
+	}
+
+	stat, _ := s.DiffStat("main", "hive/epic-1")
+	if !strings.Contains(stat, "logo.psd") {
+		t.Error("expected LFS file to still appear in diff stat")
+	}
+}
+
+func TestAddWorktree_LFSWithoutExtension(t *testing.T) {
+	if lfsAvailable() {
+		t.Skip("git-lfs is installed")
+	}
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("*.bin filter=lfs -text\n"), 0644)
+	s.CommitAll("track bin")
+	s.CreateBranch("hive/epic-1")
+	s.Checkout("main")
+
+	wtPath := filepath.Join(t.TempDir(), "wt")
+	if err := s.AddWorktree(wtPath, "hive/epic-1"); err == nil {
+		t.Fatal("expected error when git-lfs is missing")
+	}
+	if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+		t.Error("expected worktree to be removed")
+	}
+}
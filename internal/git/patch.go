@@ -0,0 +1,26 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ApplyPatch applies a unified diff to the working tree. With checkOnly
+// nothing changes; the patch is only tested. Hunk line counts are
+// recounted, since hand-written and model-written diffs often get them
+// wrong.
+func (s *Safety) ApplyPatch(patch string, checkOnly bool) error {
+	defer s.span("git.apply").End()
+	args := []string{"apply", "--recount", "--whitespace=nowarn"}
+	if checkOnly {
+		args = append(args, "--check")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.workDir
+	cmd.Stdin = strings.NewReader(patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	// Wrong hunk counts on purpose: models get them wrong too.
+	patch := "--- a/README.md\n+++ b/README.md\n@@ -1,5 +1,5 @@\n-# test\n+# hive\n"
+
+	if err := s.ApplyPatch(patch, true); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "# test\n" {
+		t.Fatal("check-only apply changed the file")
+	}
+
+	if err := s.ApplyPatch(patch, false); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "# hive\n" {
+		t.Errorf("patch not applied, got %q", data)
+	}
+
+	if err := s.ApplyPatch(patch, true); err == nil {
+		t.Error("expected an already-applied patch to fail the check")
+	}
+}
@@ -4,9 +4,17 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/chaos"
+	"github.com/imkarma/hive/internal/tracing"
 )
 
 // Safety provides git branch management for safe agent execution.
@@ -15,6 +23,7 @@ import (
 // accepts or rejects at the epic level.
 type Safety struct {
 	workDir string
+	ctx     context.Context // Parent for trace spans; nil means the running pipeline
 }
 
 // New creates a Safety instance for the given working directory.
@@ -22,6 +31,20 @@ func New(workDir string) *Safety {
 	return &Safety{workDir: workDir}
 }
 
+// WithContext returns a copy whose git operations are traced under the
+// span in ctx, e.g. the task a worktree belongs to.
+func (s *Safety) WithContext(ctx context.Context) *Safety {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+// span starts a trace span for one git operation.
+func (s *Safety) span(name string) *tracing.Span {
+	_, sp := tracing.Start(s.ctx, name, tracing.String("git.dir", s.workDir))
+	return sp
+}
+
 // IsGitRepo checks if the working directory is a git repository.
 func (s *Safety) IsGitRepo() bool {
 	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
@@ -55,6 +78,55 @@ func (s *Safety) BaseBranch() (string, error) {
 	return s.CurrentBranch()
 }
 
+// BaseBranchFor returns override when set, otherwise the detected base
+// branch. Epics that recorded a base (e.g. develop) pass it here.
+func (s *Safety) BaseBranchFor(override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	return s.BaseBranch()
+}
+
+// CreateBranchFrom creates branch starting at start (instead of HEAD) and
+// switches to it. If the branch already exists, it just switches to it.
+func (s *Safety) CreateBranchFrom(branch, start string) error {
+	defer s.span("git.create-branch").End()
+	if s.BranchExists(branch) {
+		return s.Checkout(branch)
+	}
+
+	cmd := exec.Command("git", "checkout", "-b", branch, start)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("create branch %s from %s: %s", branch, start, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// BranchAt creates branch pointing at start without switching to it.
+func (s *Safety) BranchAt(branch, start string) error {
+	defer s.span("git.create-branch").End()
+	cmd := exec.Command("git", "branch", branch, start)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("create branch %s at %s: %s", branch, start, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RevParse resolves a ref (branch, tag, HEAD) to its commit hash.
+func (s *Safety) RevParse(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", ref+"^{commit}")
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // BranchName generates the safety branch name for an epic.
 // Format: hive/epic-{id}
 func BranchName(epicID int64) string {
@@ -79,9 +151,98 @@ func (s *Safety) HasUncommittedChanges() bool {
 	return strings.TrimSpace(string(out)) != ""
 }
 
+// ChangedFiles returns the paths with uncommitted changes (modified,
+// added, deleted or untracked), relative to the repository root.
+func (s *Safety) ChangedFiles() ([]string, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "--untracked-files=all")
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		// Renames are reported as "old -> new".
+		if i := strings.Index(path, " -> "); i >= 0 {
+			path = path[i+4:]
+		}
+		files = append(files, strings.Trim(path, `"`))
+	}
+	return files, nil
+}
+
+// Stash saves all uncommitted changes, including untracked files, and
+// returns the stash commit hash. Returns "" when there was nothing to stash.
+func (s *Safety) Stash(message string) (string, error) {
+	defer s.span("git.stash").End()
+	if !s.HasUncommittedChanges() {
+		return "", nil
+	}
+
+	cmd := exec.Command("git", "stash", "push", "--include-untracked", "-m", message)
+	cmd.Dir = s.workDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git stash: %s", strings.TrimSpace(string(out)))
+	}
+	return s.RevParse("stash@{0}")
+}
+
+// RestoreStash applies the stash with the given commit hash to the working
+// tree and drops it from the stash list. If applying fails (e.g. conflicts)
+// the stash is kept so nothing is lost.
+func (s *Safety) RestoreStash(ref string) error {
+	defer s.span("git.stash-apply").End()
+	cmd := exec.Command("git", "stash", "apply", ref)
+	cmd.Dir = s.workDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git stash apply %s: %s", ref, strings.TrimSpace(string(out)))
+	}
+
+	// Find the stash@{n} entry for ref; drop only takes entry names.
+	listCmd := exec.Command("git", "stash", "list", "--format=%gd %H")
+	listCmd.Dir = s.workDir
+	out, err := listCmd.Output()
+	if err != nil {
+		return nil // Applied; leaving the entry behind is harmless.
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == ref {
+			dropCmd := exec.Command("git", "stash", "drop", fields[0])
+			dropCmd.Dir = s.workDir
+			dropCmd.Run()
+			break
+		}
+	}
+	return nil
+}
+
+// HasStash reports whether a stash with the given commit hash is still
+// in the stash list.
+func (s *Safety) HasStash(ref string) bool {
+	cmd := exec.Command("git", "stash", "list", "--format=%H")
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == ref {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateBranch creates a new branch from the current HEAD and switches to it.
 // If the branch already exists, it just switches to it.
 func (s *Safety) CreateBranch(branch string) error {
+	defer s.span("git.create-branch").End()
 	if s.BranchExists(branch) {
 		return s.Checkout(branch)
 	}
@@ -97,6 +258,7 @@ func (s *Safety) CreateBranch(branch string) error {
 
 // Checkout switches to an existing branch.
 func (s *Safety) Checkout(branch string) error {
+	defer s.span("git.checkout").End()
 	cmd := exec.Command("git", "checkout", branch)
 	cmd.Dir = s.workDir
 	out, err := cmd.CombinedOutput()
@@ -107,14 +269,19 @@ func (s *Safety) Checkout(branch string) error {
 }
 
 // CommitAll stages all changes and commits with the given message.
+// Modified submodules are left out (see ModifiedSubmodules).
 // Returns true if a commit was made, false if there was nothing to commit.
 func (s *Safety) CommitAll(message string) (bool, error) {
+	defer s.span("git.commit").End()
 	// Stage all changes.
 	addCmd := exec.Command("git", "add", "-A")
 	addCmd.Dir = s.workDir
 	if out, err := addCmd.CombinedOutput(); err != nil {
 		return false, fmt.Errorf("git add: %s", strings.TrimSpace(string(out)))
 	}
+	if err := s.unstageSubmodules(); err != nil {
+		return false, err
+	}
 
 	// Check if there are staged changes.
 	diffCmd := exec.Command("git", "diff", "--cached", "--quiet")
@@ -134,10 +301,79 @@ func (s *Safety) CommitAll(message string) (bool, error) {
 	return true, nil
 }
 
+// CommitPaths stages and commits only the given paths, leaving any other
+// changes in the working tree uncommitted. Returns true if a commit was
+// made, false if those paths had no changes.
+func (s *Safety) CommitPaths(message string, paths []string) (bool, error) {
+	defer s.span("git.commit").End()
+	paths = s.withoutSubmodules(paths)
+	if len(paths) == 0 {
+		return false, nil
+	}
+
+	addCmd := exec.Command("git", append([]string{"add", "-A", "--"}, paths...)...)
+	addCmd.Dir = s.workDir
+	if out, err := addCmd.CombinedOutput(); err != nil {
+		return false, fmt.Errorf("git add: %s", strings.TrimSpace(string(out)))
+	}
+
+	diffCmd := exec.Command("git", append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...)
+	diffCmd.Dir = s.workDir
+	if err := diffCmd.Run(); err == nil {
+		return false, nil
+	}
+
+	commitCmd := exec.Command("git", append([]string{"commit", "-m", message, "--"}, paths...)...)
+	commitCmd.Dir = s.workDir
+	out, err := commitCmd.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("git commit: %s", strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
+
+// DiscardPaths throws away uncommitted changes to the given paths: files
+// known to HEAD go back to their committed content, new files are
+// removed. Other changes are left alone.
+func (s *Safety) DiscardPaths(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = s.workDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("discard: git %s: %s", args[0], strings.TrimSpace(string(out)))
+		}
+		return string(out), nil
+	}
+	pathspec := append([]string{"--"}, paths...)
+	if _, err := run(append([]string{"reset", "-q"}, pathspec...)...); err != nil {
+		return err
+	}
+	if _, err := run(append([]string{"clean", "-fdq"}, pathspec...)...); err != nil {
+		return err
+	}
+	out, err := run(append([]string{"ls-tree", "-r", "-z", "--name-only", "HEAD"}, pathspec...)...)
+	if err != nil {
+		return err
+	}
+	if tracked := strings.FieldsFunc(out, func(r rune) bool { return r == 0 }); len(tracked) > 0 {
+		if _, err := run(append([]string{"checkout", "HEAD", "--"}, tracked...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Diff returns the diff between the base branch and the given branch.
-// This shows all changes the epic introduced.
+// This shows all changes the epic introduced. LFS-tracked files are left
+// out; DiffStat still lists them.
 func (s *Safety) Diff(baseBranch, epicBranch string) (string, error) {
-	cmd := exec.Command("git", "diff", baseBranch+"..."+epicBranch)
+	defer s.span("git.diff").End()
+	args := append([]string{"diff", baseBranch + "..." + epicBranch, "--"}, s.LFSExcludes()...)
+	cmd := exec.Command("git", args...)
 	cmd.Dir = s.workDir
 	out, err := cmd.Output()
 	if err != nil {
@@ -157,14 +393,123 @@ func (s *Safety) DiffStat(baseBranch, epicBranch string) (string, error) {
 	return string(out), nil
 }
 
+// MergeConflicts reports the files that would conflict when merging
+// epicBranch into baseBranch, without touching the working tree or either
+// branch. It uses git merge-tree, falling back to a trial merge in a
+// temporary worktree on git older than 2.38.
+func (s *Safety) MergeConflicts(baseBranch, epicBranch string) ([]string, error) {
+	defer s.span("git.merge-conflicts").End()
+	cmd := exec.Command("git", "merge-tree", "--write-tree", "--name-only", "--no-messages", baseBranch, epicBranch)
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err == nil {
+		return nil, nil
+	}
+	// Exit status 1 means conflicts; anything else is most likely a git
+	// without --write-tree.
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		return s.trialMergeConflicts(baseBranch, epicBranch)
+	}
+
+	// Output is the tree hash, then one conflicted file per line.
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var files []string
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// trialMergeConflicts runs `git merge --no-commit --no-ff` in a throwaway
+// detached worktree and lists the unmerged files.
+func (s *Safety) trialMergeConflicts(baseBranch, epicBranch string) ([]string, error) {
+	tmp, err := os.MkdirTemp("", "hive-merge-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "wt")
+	add := exec.Command("git", "worktree", "add", "--detach", path, baseBranch)
+	add.Dir = s.workDir
+	if out, err := add.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("add worktree: %s", strings.TrimSpace(string(out)))
+	}
+	defer func() {
+		s.RemoveWorktree(path)
+		s.PruneWorktrees()
+	}()
+
+	merge := exec.Command("git", "merge", "--no-commit", "--no-ff", epicBranch)
+	merge.Dir = path
+	if merge.Run() == nil {
+		return nil, nil
+	}
+
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = path
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("list conflicts: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("trial merge of %s into %s failed", epicBranch, baseBranch)
+	}
+	return files, nil
+}
+
+// BehindUpstream fetches branch's upstream and returns how many commits
+// branch is behind it. Returns an empty upstream when branch doesn't track
+// one. A failed fetch falls back to the last fetched state.
+func (s *Safety) BehindUpstream(branch string) (upstream string, behind int, err error) {
+	defer s.span("git.fetch").End()
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", branch+"@{upstream}")
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", 0, nil
+	}
+	upstream = strings.TrimSpace(string(out))
+
+	if remote, ref, ok := strings.Cut(upstream, "/"); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		fetch := exec.CommandContext(ctx, "git", "fetch", "--quiet", remote, ref)
+		fetch.Dir = s.workDir
+		fetch.Run()
+	}
+
+	cmd = exec.Command("git", "rev-list", "--count", branch+".."+upstream)
+	cmd.Dir = s.workDir
+	out, err = cmd.Output()
+	if err != nil {
+		return upstream, 0, fmt.Errorf("git rev-list: %w", err)
+	}
+	behind, err = strconv.Atoi(strings.TrimSpace(string(out)))
+	return upstream, behind, err
+}
+
 // MergeBranch merges the epic branch into the base branch (fast-forward if possible).
 // This is the "accept" action.
 func (s *Safety) MergeBranch(baseBranch, epicBranch string) error {
+	defer s.span("git.merge").End()
 	// Switch to base branch.
 	if err := s.Checkout(baseBranch); err != nil {
 		return err
 	}
 
+	if chaos.Hit(chaos.MergeConflict) {
+		return chaos.Error(chaos.MergeConflict, "merge "+epicBranch)
+	}
+
 	// Merge.
 	cmd := exec.Command("git", "merge", epicBranch, "--no-ff",
 		"-m", fmt.Sprintf("Merge %s", epicBranch))
@@ -195,12 +540,30 @@ func (s *Safety) DeleteBranch(branch string, force bool) error {
 // RejectBranch switches back to the base branch and force-deletes the epic branch.
 // This is the "reject" action — discard all agent work.
 func (s *Safety) RejectBranch(baseBranch, epicBranch string) error {
+	defer s.span("git.reject").End()
 	if err := s.Checkout(baseBranch); err != nil {
 		return err
 	}
 	return s.DeleteBranch(epicBranch, true)
 }
 
+// ResetBranch checks out branch and hard-resets it to ref, dropping every
+// commit and uncommitted change made after ref. Used to reject an epic on
+// an adopted branch without deleting the user's own work.
+func (s *Safety) ResetBranch(branch, ref string) error {
+	defer s.span("git.reset").End()
+	if err := s.Checkout(branch); err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "reset", "--hard", ref)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("reset %s to %s: %s", branch, ref, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 // LogCommits returns the commit log for the epic branch since it diverged from base.
 func (s *Safety) LogCommits(baseBranch, epicBranch string) (string, error) {
 	cmd := exec.Command("git", "log", "--oneline", baseBranch+".."+epicBranch)
@@ -223,17 +586,64 @@ func WorktreePath(baseDir string, taskID int64) string {
 // Each worktree is an independent working directory sharing the same git repo,
 // so multiple CLI agents can work in parallel without file conflicts.
 func (s *Safety) AddWorktree(path, branch string) error {
-	cmd := exec.Command("git", "worktree", "add", path, branch)
+	return s.addWorktree(path, branch)
+}
+
+// AddDetachedWorktree is AddWorktree at a commit rather than on a branch.
+// Git checks a branch out in one place only; detached, any number of
+// worktrees can start from the epic branch while the main working
+// directory stays on it.
+func (s *Safety) AddDetachedWorktree(path, ref string) error {
+	return s.addWorktree(path, "--detach", ref)
+}
+
+func (s *Safety) addWorktree(path string, args ...string) error {
+	defer s.span("git.worktree-add").End()
+	cmd := exec.Command("git", append([]string{"worktree", "add", path}, args...)...)
 	cmd.Dir = s.workDir
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("add worktree: %s", strings.TrimSpace(string(out)))
 	}
+
+	// A bare checkout has empty submodules and LFS pointer files; an
+	// agent can't work on that, so undo the worktree if it can't be fixed.
+	wt := New(path)
+	if err := wt.initSubmodules(); err != nil {
+		s.RemoveWorktree(path)
+		return err
+	}
+	if wt.UsesLFS() {
+		if err := wt.setupLFS(); err != nil {
+			s.RemoveWorktree(path)
+			return err
+		}
+	}
 	return nil
 }
 
+// ResetWorktree readies a used worktree (s) for the next task: detached
+// at ref, with local changes and untracked files gone. Ignored files —
+// installed dependencies, build caches — are kept; they are why the
+// worktree is reused.
+func (s *Safety) ResetWorktree(ref string) error {
+	defer s.span("git.worktree-reset").End()
+	for _, args := range [][]string{
+		{"checkout", "--quiet", "--force", "--detach", ref},
+		{"clean", "-fdq"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = s.workDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("reset worktree: git %s: %s", args[0], strings.TrimSpace(string(out)))
+		}
+	}
+	return s.initSubmodules()
+}
+
 // RemoveWorktree removes a git worktree.
 func (s *Safety) RemoveWorktree(path string) error {
+	defer s.span("git.worktree-remove").End()
 	cmd := exec.Command("git", "worktree", "remove", path, "--force")
 	cmd.Dir = s.workDir
 	out, err := cmd.CombinedOutput()
@@ -247,6 +657,7 @@ func (s *Safety) RemoveWorktree(path string) error {
 // then cherry-picks or merges them into the epic branch in the main workdir.
 // This is used after a parallel task completes in its worktree.
 func (s *Safety) MergeWorktreeChanges(worktreePath string, taskID int64, taskTitle string) error {
+	defer s.span("git.worktree-merge").End()
 	wt := New(worktreePath)
 
 	// Commit all changes in the worktree.
@@ -267,18 +678,229 @@ func (s *Safety) MergeWorktreeChanges(worktreePath string, taskID int64, taskTit
 		return fmt.Errorf("get commit hash: %w", err)
 	}
 	commitHash := strings.TrimSpace(string(out))
+	if chaos.Hit(chaos.MergeConflict) {
+		return &ConflictError{Commit: commitHash, Files: []string{chaosFile}}
+	}
 
 	// Cherry-pick the commit into the main workdir (which is on the epic branch).
 	cpCmd := exec.Command("git", "cherry-pick", commitHash)
 	cpCmd.Dir = s.workDir
 	cpOut, err := cpCmd.CombinedOutput()
 	if err != nil {
+		// Don't leave the epic branch mid-cherry-pick; the commit stays in
+		// the worktree until it is reset.
+		files := unmergedFiles(s.workDir)
+		s.AbortCherryPick()
+		if len(files) > 0 {
+			return &ConflictError{Commit: commitHash, Files: files}
+		}
 		return fmt.Errorf("cherry-pick: %s", strings.TrimSpace(string(cpOut)))
 	}
 
 	return nil
 }
 
+// ReplayCommit moves a worktree (s) to onto and applies commit there
+// without committing, for resolving a commit that conflicts with onto by
+// hand. Returns the conflicted files, which hold conflict markers; none
+// if the commit applies cleanly after all.
+func (s *Safety) ReplayCommit(onto, commit string) ([]string, error) {
+	defer s.span("git.cherry-pick").End()
+	checkout := exec.Command("git", "checkout", "--quiet", "--force", "--detach", onto)
+	checkout.Dir = s.workDir
+	if out, err := checkout.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("checkout %s: %s", onto, strings.TrimSpace(string(out)))
+	}
+	cmd := exec.Command("git", "cherry-pick", "--no-commit", commit)
+	cmd.Dir = s.workDir
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+	files := unmergedFiles(s.workDir)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("cherry-pick %s: %s", shortHash(commit), strings.TrimSpace(string(out)))
+	}
+	return files, nil
+}
+
+// AbortCherryPick gives up a cherry-pick in progress, putting the tree
+// back as it was before it.
+func (s *Safety) AbortCherryPick() {
+	cmd := exec.Command("git", "cherry-pick", "--abort")
+	cmd.Dir = s.workDir
+	cmd.Run()
+}
+
+// AbortReplay undoes ReplayCommit in a worktree (s), leaving it clean at
+// the commit it was moved to.
+func (s *Safety) AbortReplay() {
+	for _, args := range [][]string{{"cherry-pick", "--quit"}, {"reset", "--quiet", "--hard"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = s.workDir
+		cmd.Run()
+	}
+}
+
+// ConflictHunks returns the conflicted regions of a file's content, from
+// "<<<<<<<" to ">>>>>>>", each with up to context lines around it.
+func ConflictHunks(content string, context int) []string {
+	lines := strings.Split(content, "\n")
+	var hunks []string
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "<<<<<<<") {
+			continue
+		}
+		end := i
+		for end < len(lines) && !strings.HasPrefix(lines[end], ">>>>>>>") {
+			end++
+		}
+		from, to := max(0, i-context), min(len(lines), end+1+context)
+		hunks = append(hunks, strings.Join(lines[from:to], "\n"))
+		i = end
+	}
+	return hunks
+}
+
+// HasConflictMarkers reports whether content still has a conflicted
+// region in it.
+func HasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<<") || strings.HasPrefix(line, ">>>>>>>") {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskIDsTouching returns the tasks, newest first, whose hive commits on
+// ref changed any of paths, from their "hive: task #N — ..." subjects.
+func (s *Safety) TaskIDsTouching(ref string, paths []string, limit int) ([]int64, error) {
+	args := append([]string{"log", "--format=%s", fmt.Sprintf("-%d", limit), ref, "--"}, paths...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, subject := range strings.Split(string(out), "\n") {
+		var id int64
+		rest, ok := strings.CutPrefix(subject, "hive: ")
+		rest = strings.TrimPrefix(rest, "tests for ")
+		if !ok || !strings.HasPrefix(rest, "task #") {
+			continue
+		}
+		if _, err := fmt.Sscanf(rest, "task #%d", &id); err == nil && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// TaskBranchName is the branch a parallel task works on with
+// git.task_branches, e.g. hive/epic-3-task-7 for task 7 of hive/epic-3.
+// It can't live under the epic branch (hive/epic-3/task-7): git can't
+// have both a branch and a directory of branches by one name.
+func TaskBranchName(epicBranch string, taskID int64) string {
+	return fmt.Sprintf("%s-task-%d", epicBranch, taskID)
+}
+
+// StartTaskBranch puts a worktree (s) on branch, created or reset at its
+// current commit.
+func (s *Safety) StartTaskBranch(branch string) error {
+	defer s.span("git.create-branch").End()
+	cmd := exec.Command("git", "checkout", "--quiet", "-B", branch)
+	cmd.Dir = s.workDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("create branch %s: %s", branch, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ConflictError is a task's work that couldn't be put on the epic branch:
+// a task branch that couldn't be rebased onto it (Branch), or a worktree
+// commit that couldn't be cherry-picked (Commit). Either is left as it
+// was, for resolving.
+type ConflictError struct {
+	Branch string
+	Commit string
+	Onto   string
+	Files  []string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Branch == "" {
+		return fmt.Sprintf("cherry-picking %s conflicts in %s", shortHash(e.Commit), strings.Join(e.Files, ", "))
+	}
+	return fmt.Sprintf("rebasing %s onto %s conflicts in %s", e.Branch, e.Onto, strings.Join(e.Files, ", "))
+}
+
+// chaosFile stands for the conflicted files of a conflict chaos injected.
+const chaosFile = "(conflict injected by chaos)"
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// unmergedFiles lists the files a cherry-pick or rebase left conflicted
+// in dir.
+func unmergedFiles(dir string) []string {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = dir
+	out, _ := cmd.Output()
+	return strings.Fields(string(out))
+}
+
+// MergeTaskBranch commits a task's changes on its branch in a worktree,
+// rebases the branch onto the epic branch checked out in the main workdir
+// (s), and fast-forwards the epic branch to it, so tasks land one after
+// the other in the order they finish. The merged branch is deleted. A
+// rebase that conflicts is aborted and returned as a *ConflictError.
+func (s *Safety) MergeTaskBranch(worktreePath, branch, epicBranch string, taskID int64, taskTitle string) error {
+	defer s.span("git.task-branch-merge").End()
+	wt := New(worktreePath)
+	if _, err := wt.CommitAll(fmt.Sprintf("hive: task #%d — %s", taskID, taskTitle)); err != nil {
+		return fmt.Errorf("commit in worktree: %w", err)
+	}
+
+	if chaos.Hit(chaos.MergeConflict) {
+		return &ConflictError{Branch: branch, Onto: epicBranch, Files: []string{chaosFile}}
+	}
+
+	rebase := exec.Command("git", "rebase", "--quiet", epicBranch)
+	rebase.Dir = worktreePath
+	if out, err := rebase.CombinedOutput(); err != nil {
+		files := unmergedFiles(worktreePath)
+		abort := exec.Command("git", "rebase", "--abort")
+		abort.Dir = worktreePath
+		abort.Run()
+		if len(files) > 0 {
+			return &ConflictError{Branch: branch, Onto: epicBranch, Files: files}
+		}
+		return fmt.Errorf("rebase %s onto %s: %s", branch, epicBranch, strings.TrimSpace(string(out)))
+	}
+
+	merge := exec.Command("git", "merge", "--quiet", "--ff-only", branch)
+	merge.Dir = s.workDir
+	if out, err := merge.CombinedOutput(); err != nil {
+		return fmt.Errorf("fast-forward %s to %s: %s", epicBranch, branch, strings.TrimSpace(string(out)))
+	}
+
+	// Git won't delete a branch checked out in a worktree.
+	detach := exec.Command("git", "checkout", "--quiet", "--detach")
+	detach.Dir = worktreePath
+	if detach.Run() == nil {
+		s.DeleteBranch(branch, false)
+	}
+	return nil
+}
+
 // ListWorktrees returns all active worktrees.
 func (s *Safety) ListWorktrees() ([]string, error) {
 	cmd := exec.Command("git", "worktree", "list", "--porcelain")
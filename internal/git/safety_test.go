@@ -1,11 +1,15 @@
 package git
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/imkarma/hive/internal/chaos"
 )
 
 // initTestRepo creates a temporary git repo with an initial commit.
@@ -83,6 +87,63 @@ func TestBaseBranch(t *testing.T) {
 	}
 }
 
+func TestBaseBranchFor(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	if got, _ := s.BaseBranchFor(""); got != "main" {
+		t.Errorf("expected detected 'main', got %q", got)
+	}
+	if got, _ := s.BaseBranchFor("develop"); got != "develop" {
+		t.Errorf("expected override 'develop', got %q", got)
+	}
+}
+
+func TestCreateBranchFrom(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	// develop has a file main doesn't.
+	s.CreateBranch("develop")
+	os.WriteFile(filepath.Join(dir, "dev.txt"), []byte("dev"), 0644)
+	s.CommitAll("develop work")
+	s.Checkout("main")
+
+	if err := s.CreateBranchFrom("hive/epic-1", "develop"); err != nil {
+		t.Fatalf("CreateBranchFrom: %v", err)
+	}
+	if branch, _ := s.CurrentBranch(); branch != "hive/epic-1" {
+		t.Fatalf("expected to be on hive/epic-1, got %q", branch)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dev.txt")); err != nil {
+		t.Fatal("expected epic branch to start from develop")
+	}
+}
+
+func TestBranchAt(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package feature\n"), 0644)
+	s.CommitAll("epic work")
+
+	if err := s.BranchAt("hive/epic-2", "hive/epic-1"); err != nil {
+		t.Fatalf("BranchAt: %v", err)
+	}
+	if branch, _ := s.CurrentBranch(); branch != "hive/epic-1" {
+		t.Fatalf("BranchAt should not switch branches, on %q", branch)
+	}
+	a, _ := s.RevParse("hive/epic-1")
+	b, _ := s.RevParse("hive/epic-2")
+	if a == "" || a != b {
+		t.Errorf("expected hive/epic-2 at %s, got %s", a, b)
+	}
+	if err := s.BranchAt("hive/epic-2", "main"); err == nil {
+		t.Error("expected an error for an existing branch")
+	}
+}
+
 func TestBranchName(t *testing.T) {
 	got := BranchName(42)
 	if got != "hive/epic-42" {
@@ -151,6 +212,76 @@ func TestHasUncommittedChanges(t *testing.T) {
 	}
 }
 
+func TestStash_AndRestore(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	// Clean tree — nothing to stash.
+	ref, err := s.Stash("nothing")
+	if err != nil || ref != "" {
+		t.Fatalf("expected no stash for clean tree, got %q, %v", ref, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Edited\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("wip"), 0644)
+
+	ref, err = s.Stash("hive: test")
+	if err != nil || ref == "" {
+		t.Fatalf("Stash: %q, %v", ref, err)
+	}
+	if s.HasUncommittedChanges() {
+		t.Fatal("expected clean tree after stash")
+	}
+	if !s.HasStash(ref) {
+		t.Fatal("expected stash to be listed")
+	}
+
+	if err := s.RestoreStash(ref); err != nil {
+		t.Fatalf("RestoreStash: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "untracked.txt")); err != nil {
+		t.Fatal("expected untracked file to be restored")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	if string(data) != "# Edited\n" {
+		t.Errorf("expected edit restored, got %q", data)
+	}
+	if s.HasStash(ref) {
+		t.Fatal("expected stash to be dropped after restore")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	files, err := s.ChangedFiles()
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expected no changes, got %v", files)
+	}
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "guide.md"), []byte("guide"), 0644)
+
+	files, err = s.ChangedFiles()
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	want := map[string]bool{"README.md": true, "docs/guide.md": true}
+	if len(files) != len(want) {
+		t.Fatalf("expected %d files, got %v", len(want), files)
+	}
+	for _, f := range files {
+		if !want[f] {
+			t.Errorf("unexpected changed file %q", f)
+		}
+	}
+}
+
 func TestCommitAll(t *testing.T) {
 	dir := initTestRepo(t)
 	s := New(dir)
@@ -181,6 +312,56 @@ func TestCommitAll(t *testing.T) {
 	}
 }
 
+func TestCommitPaths(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	os.WriteFile(filepath.Join(dir, "code.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "code_test.go"), []byte("package main\n"), 0644)
+
+	committed, err := s.CommitPaths("add tests", []string{"code_test.go"})
+	if err != nil {
+		t.Fatalf("CommitPaths: %v", err)
+	}
+	if !committed {
+		t.Fatal("expected a commit")
+	}
+
+	// Only the test file was committed; the code stays in the working tree.
+	files, _ := s.ChangedFiles()
+	if len(files) != 1 || files[0] != "code.go" {
+		t.Fatalf("expected only code.go uncommitted, got %v", files)
+	}
+
+	committed, err = s.CommitPaths("again", []string{"code_test.go"})
+	if err != nil {
+		t.Fatalf("CommitPaths: %v", err)
+	}
+	if committed {
+		t.Fatal("expected no commit when paths are unchanged")
+	}
+}
+
+func TestDiscardPaths(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "keep.go"), []byte("package main\n"), 0644)
+
+	if err := s.DiscardPaths([]string{"README.md", "new.go"}); err != nil {
+		t.Fatalf("DiscardPaths: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "# test\n" {
+		t.Errorf("README.md not restored: %q", data)
+	}
+	files, _ := s.ChangedFiles()
+	if len(files) != 1 || files[0] != "keep.go" {
+		t.Fatalf("expected only keep.go left, got %v", files)
+	}
+}
+
 func TestDiff_And_DiffStat(t *testing.T) {
 	dir := initTestRepo(t)
 	s := New(dir)
@@ -236,6 +417,90 @@ func TestMergeBranch(t *testing.T) {
 	}
 }
 
+func TestMergeConflicts(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# epic\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package x\n"), 0644)
+	s.CommitAll("epic work")
+
+	files, err := s.MergeConflicts("main", "hive/epic-1")
+	if err != nil || len(files) != 0 {
+		t.Fatalf("expected clean merge, got %v, %v", files, err)
+	}
+
+	// Conflicting edit on main.
+	s.Checkout("main")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# main\n"), 0644)
+	s.CommitAll("main work")
+
+	files, err = s.MergeConflicts("main", "hive/epic-1")
+	if err != nil {
+		t.Fatalf("MergeConflicts: %v", err)
+	}
+	if len(files) != 1 || files[0] != "README.md" {
+		t.Fatalf("expected README.md to conflict, got %v", files)
+	}
+	if cur, _ := s.CurrentBranch(); cur != "main" || s.HasUncommittedChanges() {
+		t.Fatal("expected working tree untouched")
+	}
+}
+
+func TestTrialMergeConflicts(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# epic\n"), 0644)
+	s.CommitAll("epic work")
+	s.Checkout("main")
+
+	if files, err := s.trialMergeConflicts("main", "hive/epic-1"); err != nil || len(files) != 0 {
+		t.Fatalf("expected clean trial merge, got %v, %v", files, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# main\n"), 0644)
+	s.CommitAll("main work")
+
+	files, err := s.trialMergeConflicts("main", "hive/epic-1")
+	if err != nil || len(files) != 1 || files[0] != "README.md" {
+		t.Fatalf("expected README.md to conflict, got %v, %v", files, err)
+	}
+	if wts, _ := s.ListWorktrees(); len(wts) != 1 {
+		t.Errorf("expected trial worktree removed, got %v", wts)
+	}
+}
+
+func TestBehindUpstream(t *testing.T) {
+	remote := initTestRepo(t)
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "clone", "-q", remote, dir).CombinedOutput(); err != nil {
+		t.Fatalf("clone: %s", out)
+	}
+	s := New(dir)
+
+	upstream, behind, err := s.BehindUpstream("main")
+	if err != nil || upstream != "origin/main" || behind != 0 {
+		t.Fatalf("expected up to date with origin/main, got %q %d %v", upstream, behind, err)
+	}
+
+	// New commit on the remote; the fetch inside BehindUpstream sees it.
+	os.WriteFile(filepath.Join(remote, "more.txt"), []byte("x"), 0644)
+	New(remote).CommitAll("remote work")
+
+	if _, behind, _ = s.BehindUpstream("main"); behind != 1 {
+		t.Fatalf("expected 1 behind, got %d", behind)
+	}
+
+	// A branch without upstream is not an error.
+	s.CreateBranch("local")
+	if upstream, _, err := s.BehindUpstream("local"); upstream != "" || err != nil {
+		t.Fatalf("expected no upstream, got %q %v", upstream, err)
+	}
+}
+
 func TestDeleteBranch(t *testing.T) {
 	dir := initTestRepo(t)
 	s := New(dir)
@@ -284,6 +549,38 @@ func TestRejectBranch(t *testing.T) {
 	}
 }
 
+func TestResetBranch_KeepsAdoptedWork(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	// User's own feature branch with a commit.
+	s.CreateBranch("feature/login")
+	os.WriteFile(filepath.Join(dir, "mine.go"), []byte("package mine\n"), 0644)
+	s.CommitAll("user work")
+	ref, err := s.RevParse("feature/login")
+	if err != nil {
+		t.Fatalf("RevParse: %v", err)
+	}
+
+	// Agent work on top.
+	os.WriteFile(filepath.Join(dir, "agent.go"), []byte("package agent\n"), 0644)
+	s.CommitAll("agent work")
+
+	if err := s.ResetBranch("feature/login", ref); err != nil {
+		t.Fatalf("ResetBranch: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "mine.go")); err != nil {
+		t.Fatal("expected user's file to survive reset")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "agent.go")); !os.IsNotExist(err) {
+		t.Fatal("expected agent's file to be discarded")
+	}
+	if !s.BranchExists("feature/login") {
+		t.Fatal("expected branch to be kept")
+	}
+}
+
 func TestLogCommits(t *testing.T) {
 	dir := initTestRepo(t)
 	s := New(dir)
@@ -357,6 +654,45 @@ func TestAddAndRemoveWorktree(t *testing.T) {
 	}
 }
 
+func TestDetachedWorktree_Reset(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+	s.CreateBranch("hive/epic-1")
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("deps/\n"), 0644)
+	s.CommitAll("ignore deps")
+
+	// The epic branch stays checked out here; a detached worktree can
+	// still start from it.
+	wtPath := filepath.Join(t.TempDir(), "slot-1")
+	if err := s.AddDetachedWorktree(wtPath, "hive/epic-1"); err != nil {
+		t.Fatalf("AddDetachedWorktree: %v", err)
+	}
+	defer s.RemoveWorktree(wtPath)
+
+	os.WriteFile(filepath.Join(wtPath, "README.md"), []byte("changed\n"), 0644)
+	os.WriteFile(filepath.Join(wtPath, "scratch.go"), []byte("package x\n"), 0644)
+	os.MkdirAll(filepath.Join(wtPath, "deps"), 0755)
+	os.WriteFile(filepath.Join(wtPath, "deps", "lib.a"), []byte("lib"), 0644)
+
+	// A commit on the epic branch shows up after the reset.
+	os.WriteFile(filepath.Join(dir, "next.go"), []byte("package next\n"), 0644)
+	s.CommitAll("next task")
+
+	wt := New(wtPath)
+	if err := wt.ResetWorktree("hive/epic-1"); err != nil {
+		t.Fatalf("ResetWorktree: %v", err)
+	}
+	if wt.HasUncommittedChanges() {
+		t.Error("reset worktree should be clean")
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "next.go")); err != nil {
+		t.Error("reset worktree should be at the tip of the epic branch")
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "deps", "lib.a")); err != nil {
+		t.Error("reset should keep ignored files")
+	}
+}
+
 func TestWorktreeParallelWork(t *testing.T) {
 	dir := initTestRepo(t)
 	s := New(dir)
@@ -479,6 +815,28 @@ func TestMergeWorktreeChanges(t *testing.T) {
 	}
 }
 
+func TestMergeWorktreeChanges_ChaosConflict(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+	s.CreateBranch("hive/epic-1")
+	wtPath := filepath.Join(dir, "wt-chaos")
+	if err := s.AddDetachedWorktree(wtPath, "hive/epic-1"); err != nil {
+		t.Fatalf("AddDetachedWorktree: %v", err)
+	}
+	defer s.RemoveWorktree(wtPath)
+	os.WriteFile(filepath.Join(wtPath, "feature.go"), []byte("package feature\n"), 0644)
+
+	chaos.Init(chaos.Config{chaos.MergeConflict: 1}, 1)
+	defer chaos.Init(nil, 0)
+	var conflict *ConflictError
+	if err := s.MergeWorktreeChanges(wtPath, 1, "add feature"); !errors.As(err, &conflict) || conflict.Commit == "" {
+		t.Fatalf("expected an injected conflict, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.go")); !os.IsNotExist(err) {
+		t.Error("the epic branch should be left as it was")
+	}
+}
+
 func TestFullWorkflow_CreateWorkAcceptReject(t *testing.T) {
 	dir := initTestRepo(t)
 	s := New(dir)
@@ -535,3 +893,90 @@ func TestFullWorkflow_CreateWorkAcceptReject(t *testing.T) {
 		t.Fatal("bad.go should not exist on main after reject")
 	}
 }
+
+func TestMergeTaskBranch(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+	s.CreateBranch("hive/epic-1")
+
+	add := func(taskID int64) (string, string) {
+		t.Helper()
+		path := filepath.Join(dir, fmt.Sprintf("wt-%d", taskID))
+		if err := s.AddDetachedWorktree(path, "hive/epic-1"); err != nil {
+			t.Fatalf("AddDetachedWorktree: %v", err)
+		}
+		t.Cleanup(func() { s.RemoveWorktree(path) })
+		branch := TaskBranchName("hive/epic-1", taskID)
+		if err := New(path).StartTaskBranch(branch); err != nil {
+			t.Fatalf("StartTaskBranch: %v", err)
+		}
+		return path, branch
+	}
+	wt1, b1 := add(1)
+	wt2, b2 := add(2)
+	wt3, b3 := add(3)
+	if b1 != "hive/epic-1-task-1" {
+		t.Fatalf("branch name: %q", b1)
+	}
+
+	os.WriteFile(filepath.Join(wt1, "a.go"), []byte("package a\n"), 0644)
+	os.WriteFile(filepath.Join(wt2, "b.go"), []byte("package b\n"), 0644)
+	os.WriteFile(filepath.Join(wt3, "a.go"), []byte("package other\n"), 0644)
+
+	if err := s.MergeTaskBranch(wt1, b1, "hive/epic-1", 1, "one"); err != nil {
+		t.Fatalf("merge task 1: %v", err)
+	}
+	// Task 2 started before task 1 landed; it is rebased on top of it.
+	if err := s.MergeTaskBranch(wt2, b2, "hive/epic-1", 2, "two"); err != nil {
+		t.Fatalf("merge task 2: %v", err)
+	}
+	for _, f := range []string{"a.go", "b.go"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("expected %s on the epic branch: %v", f, err)
+		}
+	}
+	if s.BranchExists(b1) || s.BranchExists(b2) {
+		t.Error("expected merged task branches deleted")
+	}
+
+	err := s.MergeTaskBranch(wt3, b3, "hive/epic-1", 3, "three")
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Files) != 1 || conflict.Files[0] != "a.go" {
+		t.Fatalf("expected a conflict in a.go, got %v", err)
+	}
+	if !s.BranchExists(b3) {
+		t.Error("expected the conflicted branch kept")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(data) != "package a\n" {
+		t.Errorf("epic branch changed by a conflicted task: %q", data)
+	}
+}
+
+func TestConflictHunks(t *testing.T) {
+	content := "a\nb\n<<<<<<< HEAD\nhello\n=======\ngoodbye\n>>>>>>> abc123\nc\nd\ne\n"
+	hunks := ConflictHunks(content, 1)
+	if len(hunks) != 1 || hunks[0] != "b\n<<<<<<< HEAD\nhello\n=======\ngoodbye\n>>>>>>> abc123\nc" {
+		t.Fatalf("hunks: %q", hunks)
+	}
+	if !HasConflictMarkers(content) || HasConflictMarkers("a\n=======\nb\n") {
+		t.Error("HasConflictMarkers")
+	}
+}
+
+func TestTaskIDsTouching(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+	for _, c := range []struct{ file, msg string }{
+		{"a.go", "hive: task #3 — one"},
+		{"b.go", "hive: task #4 — two"},
+		{"a.go", "hive: tests for task #5 — three"},
+		{"a.go", "fix typo"},
+	} {
+		os.WriteFile(filepath.Join(dir, c.file), []byte(c.msg+"\n"), 0644)
+		s.CommitAll(c.msg)
+	}
+	ids, err := s.TaskIDsTouching("HEAD", []string{"a.go"}, 10)
+	if err != nil || len(ids) != 2 || ids[0] != 5 || ids[1] != 3 {
+		t.Fatalf("ids: %v, %v", ids, err)
+	}
+}
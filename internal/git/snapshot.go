@@ -0,0 +1,48 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Snapshot records the working tree — tracked and untracked files, minus
+// ignored ones — as a git tree object and returns its hash. It uses a
+// scratch index, so the real index and the working tree are untouched.
+func (s *Safety) Snapshot() (string, error) {
+	defer s.span("git.snapshot").End()
+	f, err := os.CreateTemp("", "hive-index-*")
+	if err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	index := f.Name()
+	f.Close()
+	os.Remove(index) // git wants a missing or valid index file
+	defer os.Remove(index)
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+index)
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = s.workDir
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git add: %s", strings.TrimSpace(string(out)))
+	}
+	cmd = exec.Command("git", "write-tree")
+	cmd.Dir = s.workDir
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git write-tree: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// SnapshotDiff returns the changes between two snapshots as a binary-safe
+// patch that ApplyPatch can replay.
+func (s *Safety) SnapshotDiff(from, to string) (string, error) {
+	if from == to {
+		return "", nil
+	}
+	return s.diff("--binary", from, to)
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_DiffReplaysEdits(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	before, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644)
+	after, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	// The real index is untouched: new.txt is still untracked.
+	out, _ := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if string(out) != " M README.md\n?? new.txt\n" {
+		t.Errorf("index changed: %q", out)
+	}
+
+	patch, err := s.SnapshotDiff(before, after)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+
+	// Replay the edits in a fresh copy of the repo.
+	other := initTestRepo(t)
+	if err := New(other).ApplyPatch(patch, false); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(other, "README.md")); string(data) != "# changed\n" {
+		t.Errorf("README not replayed: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(other, "new.txt")); string(data) != "new\n" {
+		t.Errorf("new file not replayed: %q", data)
+	}
+
+	if patch, _ := s.SnapshotDiff(after, after); patch != "" {
+		t.Errorf("expected no diff between equal snapshots, got %q", patch)
+	}
+}
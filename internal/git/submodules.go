@@ -0,0 +1,106 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Submodules returns the paths of the submodules committed on the current
+// branch.
+func (s *Safety) Submodules() ([]string, error) {
+	cmd := exec.Command("git", "ls-tree", "-r", "--full-tree", "HEAD")
+	cmd.Dir = s.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree: %w", err)
+	}
+
+	// Submodules are gitlinks: "160000 commit <hash>\t<path>".
+	var paths []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "160000 ") {
+			continue
+		}
+		if i := strings.IndexByte(line, '\t'); i >= 0 {
+			paths = append(paths, line[i+1:])
+		}
+	}
+	return paths, nil
+}
+
+// ModifiedSubmodules returns the submodules whose checked-out commit or
+// contents differ from what the branch records. hive never commits these:
+// an agent editing a submodule would otherwise move the pointer to a
+// commit that only exists locally.
+func (s *Safety) ModifiedSubmodules() []string {
+	subs, err := s.Submodules()
+	if err != nil || len(subs) == 0 {
+		return nil
+	}
+	changed, err := s.ChangedFiles()
+	if err != nil {
+		return nil
+	}
+
+	isSub := make(map[string]bool, len(subs))
+	for _, p := range subs {
+		isSub[p] = true
+	}
+	var out []string
+	for _, f := range changed {
+		if isSub[strings.TrimSuffix(f, "/")] {
+			out = append(out, strings.TrimSuffix(f, "/"))
+		}
+	}
+	return out
+}
+
+// unstageSubmodules removes submodule pointer changes from the index after
+// a broad `git add`, so commits only carry changes to this repository.
+func (s *Safety) unstageSubmodules() error {
+	modified := s.ModifiedSubmodules()
+	if len(modified) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", append([]string{"reset", "-q", "--"}, modified...)...)
+	cmd.Dir = s.workDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("unstage submodules: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// withoutSubmodules drops submodule paths from paths.
+func (s *Safety) withoutSubmodules(paths []string) []string {
+	subs, _ := s.Submodules()
+	if len(subs) == 0 {
+		return paths
+	}
+	isSub := make(map[string]bool, len(subs))
+	for _, p := range subs {
+		isSub[p] = true
+	}
+	var out []string
+	for _, p := range paths {
+		if !isSub[strings.TrimSuffix(p, "/")] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// initSubmodules checks out submodules in a fresh worktree, which
+// `git worktree add` leaves empty.
+func (s *Safety) initSubmodules() error {
+	subs, err := s.Submodules()
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+	cmd := exec.Command("git", "submodule", "update", "--init", "--recursive")
+	cmd.Dir = s.workDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("init submodules: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
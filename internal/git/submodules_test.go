@@ -0,0 +1,74 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// addTestSubmodule adds a local repo as a submodule at path "lib".
+func addTestSubmodule(t *testing.T, dir string) {
+	t.Helper()
+	lib := initTestRepo(t)
+	cmd := exec.Command("git", "-c", "protocol.file.allow=always", "submodule", "add", lib, "lib")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("submodule add: %s", out)
+	}
+	if _, err := New(dir).CommitAll("add submodule"); err != nil {
+		t.Fatalf("commit submodule: %v", err)
+	}
+}
+
+func TestSubmodules(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+
+	if subs, _ := s.Submodules(); len(subs) != 0 {
+		t.Fatalf("expected no submodules, got %v", subs)
+	}
+
+	addTestSubmodule(t, dir)
+	subs, err := s.Submodules()
+	if err != nil {
+		t.Fatalf("Submodules: %v", err)
+	}
+	if len(subs) != 1 || subs[0] != "lib" {
+		t.Fatalf("expected [lib], got %v", subs)
+	}
+}
+
+func TestCommitAll_SkipsModifiedSubmodule(t *testing.T) {
+	dir := initTestRepo(t)
+	s := New(dir)
+	addTestSubmodule(t, dir)
+
+	// Move the submodule to a new local commit, and change a normal file.
+	sub := New(filepath.Join(dir, "lib"))
+	exec.Command("git", "-C", filepath.Join(dir, "lib"), "config", "user.email", "test@test.com").Run()
+	exec.Command("git", "-C", filepath.Join(dir, "lib"), "config", "user.name", "test").Run()
+	os.WriteFile(filepath.Join(dir, "lib", "local.txt"), []byte("x"), 0644)
+	if _, err := sub.CommitAll("local only"); err != nil {
+		t.Fatalf("commit in submodule: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "app.go"), []byte("package app\n"), 0644)
+
+	if got := s.ModifiedSubmodules(); len(got) != 1 || got[0] != "lib" {
+		t.Fatalf("expected [lib] modified, got %v", got)
+	}
+
+	committed, err := s.CommitAll("agent work")
+	if err != nil || !committed {
+		t.Fatalf("CommitAll: %v, %v", committed, err)
+	}
+
+	// The file is committed; the submodule pointer is not.
+	if got := s.ModifiedSubmodules(); len(got) != 1 {
+		t.Fatalf("expected submodule change to stay uncommitted, got %v", got)
+	}
+	out, _ := exec.Command("git", "-C", dir, "show", "--name-only", "--format=", "HEAD").Output()
+	if string(out) != "app.go\n" {
+		t.Errorf("expected only app.go in commit, got %q", out)
+	}
+}
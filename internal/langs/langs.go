@@ -0,0 +1,194 @@
+// Package langs detects the languages and build tools of a project and
+// knows what agents should be told about each: how to run the tests, how
+// to format, and the idioms a reviewer checks for. The coder and the
+// reviewer get this guidance with their instructions.
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+)
+
+// Languages with built-in hints (config.KnownLanguages).
+const (
+	Go     = "go"
+	Node   = "node"
+	Python = "python"
+	Rust   = "rust"
+)
+
+// markers are the build files that identify each language at the project
+// root, in the order languages are reported.
+var markers = []struct {
+	lang  string
+	files []string
+}{
+	{Go, []string{"go.mod", "go.work"}},
+	{Node, []string{"package.json"}},
+	{Python, []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt"}},
+	{Rust, []string{"Cargo.toml"}},
+}
+
+// Detect returns the languages of the project at root, judged by the
+// build files at its top.
+func Detect(root string) []string {
+	var out []string
+	for _, m := range markers {
+		for _, f := range m.files {
+			if exists(root, f) {
+				out = append(out, m.lang)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Hint is the guidance for one language.
+type Hint struct {
+	Language string
+	Test     string
+	Format   string
+	Lint     string
+	Idioms   []string
+}
+
+// Builtin returns the built-in hint for lang, with the commands of the
+// build tool the project at root uses (pnpm, yarn or npm; uv or poetry).
+func Builtin(lang, root string) (Hint, bool) {
+	switch lang {
+	case Go:
+		return Hint{
+			Language: Go,
+			Test:     "go test ./...",
+			Format:   "gofmt -w .",
+			Lint:     "go vet ./...",
+			Idioms: []string{
+				`Return errors with context (fmt.Errorf("...: %w", err)); don't panic outside main`,
+				"Document exported identifiers with comments that start with their name",
+				"Prefer the standard library; don't add a dependency for something small",
+				"Table-driven tests in _test.go files next to the code",
+			},
+		}, true
+	case Node:
+		pm := "npm"
+		switch {
+		case exists(root, "pnpm-lock.yaml"):
+			pm = "pnpm"
+		case exists(root, "yarn.lock"):
+			pm = "yarn"
+		}
+		idioms := []string{
+			"Use " + pm + ", the package manager the project uses, and keep its lockfile in sync",
+			"Match the module system (ESM or CommonJS) already in use",
+			"async/await over promise chains; never leave a rejection unhandled",
+		}
+		if exists(root, "tsconfig.json") {
+			idioms = append(idioms, "TypeScript: keep the configured strictness; no `any` or `@ts-ignore` to silence errors")
+		}
+		return Hint{
+			Language: Node,
+			Test:     pm + " test",
+			Format:   "npx prettier --write .",
+			Lint:     "npx eslint .",
+			Idioms:   idioms,
+		}, true
+	case Python:
+		run := ""
+		switch {
+		case exists(root, "uv.lock"):
+			run = "uv run "
+		case exists(root, "poetry.lock"):
+			run = "poetry run "
+		}
+		return Hint{
+			Language: Python,
+			Test:     run + "pytest",
+			Format:   run + "ruff format .",
+			Lint:     run + "ruff check .",
+			Idioms: []string{
+				"Type hints on new and changed functions",
+				"PEP 8; pathlib, f-strings and context managers over their older forms",
+				"Catch specific exceptions, never a bare except",
+			},
+		}, true
+	case Rust:
+		return Hint{
+			Language: Rust,
+			Test:     "cargo test",
+			Format:   "cargo fmt",
+			Lint:     "cargo clippy -- -D warnings",
+			Idioms: []string{
+				"Propagate errors with Result and ?; no unwrap or expect outside tests",
+				"Borrow rather than clone where lifetimes allow",
+				"No unsafe unless essential, and then with a SAFETY comment",
+			},
+		}, true
+	}
+	return Hint{}, false
+}
+
+// Resolve returns the hints for the configured languages: the built-in
+// ones with overrides applied, and overrides alone for other languages.
+func Resolve(root string, languages []string, overrides map[string]config.LanguageHint) []Hint {
+	var out []Hint
+	for _, lang := range languages {
+		hint, known := Builtin(lang, root)
+		o, overridden := overrides[lang]
+		if !known && !overridden {
+			continue
+		}
+		hint.Language = lang
+		if o.Test != "" {
+			hint.Test = o.Test
+		}
+		if o.Format != "" {
+			hint.Format = o.Format
+		}
+		if o.Lint != "" {
+			hint.Lint = o.Lint
+		}
+		if len(o.Idioms) > 0 {
+			hint.Idioms = o.Idioms
+		}
+		out = append(out, hint)
+	}
+	return out
+}
+
+// Guidance renders hints for role: the coder is told to use the tools,
+// the reviewer to hold the changes to the conventions. Other roles get
+// nothing.
+func Guidance(role string, hints []Hint) string {
+	if len(hints) == 0 || (role != roles.Coder && role != roles.Reviewer) {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Language Conventions\n")
+	if role == roles.Coder {
+		sb.WriteString("Before you finish, run the tests, formatter and linter for what you changed, and follow these idioms.\n")
+	} else {
+		sb.WriteString("Hold the changes to these conventions. Untested changes and problems the formatter or linter would catch are worth a comment.\n")
+	}
+	for _, h := range hints {
+		sb.WriteString("\n### " + h.Language + "\n")
+		for _, cmd := range []struct{ label, value string }{{"Test", h.Test}, {"Format", h.Format}, {"Lint", h.Lint}} {
+			if cmd.value != "" {
+				sb.WriteString("- " + cmd.label + ": `" + cmd.value + "`\n")
+			}
+		}
+		for _, idiom := range h.Idioms {
+			sb.WriteString("- " + idiom + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func exists(root, name string) bool {
+	_, err := os.Stat(filepath.Join(root, name))
+	return err == nil
+}
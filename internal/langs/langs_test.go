@@ -0,0 +1,80 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	if got := Detect(dir); len(got) != 0 {
+		t.Errorf("empty dir: %v", got)
+	}
+	touch(t, dir, "package.json", "go.mod", "requirements.txt")
+	if got := strings.Join(Detect(dir), ","); got != "go,node,python" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestBuiltin_BuildTools(t *testing.T) {
+	dir := t.TempDir()
+	if h, _ := Builtin(Node, dir); h.Test != "npm test" {
+		t.Errorf("plain node project: %q", h.Test)
+	}
+	touch(t, dir, "pnpm-lock.yaml", "tsconfig.json", "uv.lock")
+	h, _ := Builtin(Node, dir)
+	if h.Test != "pnpm test" || !strings.Contains(strings.Join(h.Idioms, "\n"), "TypeScript") {
+		t.Errorf("pnpm + TypeScript project: %+v", h)
+	}
+	if h, _ := Builtin(Python, dir); h.Test != "uv run pytest" {
+		t.Errorf("uv project: %q", h.Test)
+	}
+	if _, ok := Builtin("cobol", dir); ok {
+		t.Error("no built-in hint expected for cobol")
+	}
+}
+
+func TestResolve_Overrides(t *testing.T) {
+	hints := Resolve(t.TempDir(), []string{"go", "java", "cobol"}, map[string]config.LanguageHint{
+		"go":   {Test: "make test"},
+		"java": {Test: "./gradlew test", Idioms: []string{"Records for value types"}},
+	})
+	if len(hints) != 2 {
+		t.Fatalf("expected go and java, got %+v", hints)
+	}
+	if hints[0].Test != "make test" || hints[0].Format != "gofmt -w ." || len(hints[0].Idioms) == 0 {
+		t.Errorf("go override should keep the other built-ins: %+v", hints[0])
+	}
+	if hints[1].Language != "java" || hints[1].Test != "./gradlew test" {
+		t.Errorf("java from overrides alone: %+v", hints[1])
+	}
+}
+
+func TestGuidance(t *testing.T) {
+	hints := Resolve(t.TempDir(), []string{"rust"}, nil)
+	coder := Guidance("coder", hints)
+	for _, want := range []string{"## Language Conventions", "### rust", "- Test: `cargo test`", "no unwrap"} {
+		if !strings.Contains(coder, want) {
+			t.Errorf("coder guidance missing %q:\n%s", want, coder)
+		}
+	}
+	if reviewer := Guidance("reviewer", hints); !strings.Contains(reviewer, "worth a comment") {
+		t.Errorf("reviewer guidance: %s", reviewer)
+	}
+	if Guidance("pm", hints) != "" || Guidance("coder", nil) != "" {
+		t.Error("only the coder and the reviewer get guidance, and only with hints")
+	}
+}
@@ -0,0 +1,77 @@
+// Package lock keeps two hive processes from running a pipeline on the
+// same epic at once. A run holds a lock file under .hive/locks for as
+// long as it works; a second hive auto on the epic finds it held and
+// stops before touching a task. The lock goes away with the process that
+// holds it, so a crashed run never leaves the epic locked.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrHeld is returned (wrapped in a *HeldError) by Acquire when another
+// process holds the lock.
+var ErrHeld = errors.New("locked by another process")
+
+// HeldError says which process holds a lock.
+type HeldError struct {
+	Path string
+	PID  int // 0 when the holder didn't record itself
+}
+
+func (e *HeldError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("%s is held by pid %d", e.Path, e.PID)
+	}
+	return e.Path + " is held by another process"
+}
+
+func (e *HeldError) Unwrap() error { return ErrHeld }
+
+// Lock is a held lock file.
+type Lock struct {
+	path string
+	f    *os.File
+}
+
+// Acquire takes the lock file at path without waiting, creating it and
+// its directory as needed, and records the current process in it.
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("lock: %w", err)
+	}
+	f, err := acquire(path)
+	if errors.Is(err, ErrHeld) {
+		return nil, &HeldError{Path: path, PID: holder(path)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock: %w", err)
+	}
+	f.Truncate(0)
+	f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	return &Lock{path: path, f: f}, nil
+}
+
+// Release gives the lock up. Calling it again does nothing.
+func (l *Lock) Release() {
+	if l == nil || l.f == nil {
+		return
+	}
+	release(l.path, l.f)
+	l.f = nil
+}
+
+// holder reads the PID recorded in a lock file.
+func holder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
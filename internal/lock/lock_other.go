@@ -0,0 +1,41 @@
+//go:build !unix
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+// acquire creates path exclusively. Without flock a file left by a
+// crashed run stays; it is taken over when the process it names is gone.
+func acquire(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		if pid := holder(path); pid > 0 && alive(pid) {
+			return nil, ErrHeld
+		}
+		os.Remove(path)
+		f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			return nil, ErrHeld
+		}
+	}
+	return f, err
+}
+
+func release(path string, f *os.File) {
+	f.Close()
+	os.Remove(path)
+}
+
+// alive reports whether a process with pid exists; FindProcess fails for
+// one that doesn't outside unix.
+func alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,36 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquire_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "epic-3.lock")
+
+	l, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	_, err = Acquire(path)
+	var held *HeldError
+	if !errors.As(err, &held) || !errors.Is(err, ErrHeld) {
+		t.Fatalf("second Acquire = %v, want HeldError", err)
+	}
+	if held.PID != os.Getpid() {
+		t.Errorf("holder pid = %d, want %d", held.PID, os.Getpid())
+	}
+
+	l.Release()
+	l.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("lock file left after Release")
+	}
+	again, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	again.Release()
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// acquire takes an exclusive flock on path. The kernel drops it when the
+// process exits, however it exits.
+func acquire(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrHeld
+		}
+		return nil, err
+	}
+	// The holder may have removed the file between our open and flock;
+	// the lock is then on a file nobody else will see.
+	fi, err1 := f.Stat()
+	pi, err2 := os.Stat(path)
+	if err1 != nil || err2 != nil || !os.SameFile(fi, pi) {
+		f.Close()
+		return nil, ErrHeld
+	}
+	return f, nil
+}
+
+// release removes the file while still holding the lock, so nothing
+// else locks a file about to disappear.
+func release(path string, f *os.File) {
+	os.Remove(path)
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	f.Close()
+}
@@ -0,0 +1,244 @@
+// Package metrics collects pipeline metrics — agent calls, token usage and
+// cost, pipeline durations, tasks by status — and serves them in the
+// Prometheus text format, so long-running hive processes can be monitored.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+// DurationBuckets are the histogram buckets, in seconds, for agent calls
+// and pipeline runs: agents take seconds to minutes, pipelines up to hours.
+var DurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// Registry holds the metric values. It is safe for concurrent use; parallel
+// workers record into the same registry.
+type Registry struct {
+	mu sync.Mutex
+
+	agentCalls   map[string]*histogram // agent, role, mode
+	agentErrors  map[string]float64    // agent, role, mode
+	tokens       map[string]float64    // agent, direction
+	costUSD      map[string]float64    // agent
+	pipelineRuns map[string]*histogram // status
+	labelsByKey  map[string][]string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		agentCalls:   make(map[string]*histogram),
+		agentErrors:  make(map[string]float64),
+		tokens:       make(map[string]float64),
+		costUSD:      make(map[string]float64),
+		pipelineRuns: make(map[string]*histogram),
+		labelsByKey:  make(map[string][]string),
+	}
+}
+
+// Default is the registry hive records into.
+var Default = NewRegistry()
+
+// ObserveAgentCall records one agent call and whether it failed.
+func ObserveAgentCall(agent, role, mode string, seconds float64, failed bool) {
+	Default.ObserveAgentCall(agent, role, mode, seconds, failed)
+}
+
+// AddUsage records tokens used by an agent call and what they cost.
+func AddUsage(agent string, inputTokens, outputTokens int, costUSD float64) {
+	Default.AddUsage(agent, inputTokens, outputTokens, costUSD)
+}
+
+// ObservePipeline records a finished pipeline run.
+func ObservePipeline(status string, seconds float64) {
+	Default.ObservePipeline(status, seconds)
+}
+
+// ObserveAgentCall records one agent call and whether it failed.
+func (r *Registry) ObserveAgentCall(agent, role, mode string, seconds float64, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := r.key(agent, role, mode)
+	h := r.agentCalls[k]
+	if h == nil {
+		h = newHistogram(DurationBuckets)
+		r.agentCalls[k] = h
+	}
+	h.observe(seconds)
+	if failed {
+		r.agentErrors[k]++
+	} else if _, ok := r.agentErrors[k]; !ok {
+		r.agentErrors[k] = 0
+	}
+}
+
+// AddUsage records tokens used by an agent call and what they cost.
+func (r *Registry) AddUsage(agent string, inputTokens, outputTokens int, costUSD float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[r.key(agent, "input")] += float64(inputTokens)
+	r.tokens[r.key(agent, "output")] += float64(outputTokens)
+	r.costUSD[r.key(agent)] += costUSD
+}
+
+// ObservePipeline records a finished pipeline run.
+func (r *Registry) ObservePipeline(status string, seconds float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := r.key(status)
+	h := r.pipelineRuns[k]
+	if h == nil {
+		h = newHistogram(DurationBuckets)
+		r.pipelineRuns[k] = h
+	}
+	h.observe(seconds)
+}
+
+// key joins label values into a map key and remembers them for output.
+func (r *Registry) key(values ...string) string {
+	k := strings.Join(values, "\x00")
+	if _, ok := r.labelsByKey[k]; !ok {
+		r.labelsByKey[k] = values
+	}
+	return k
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format.
+// tasks, when non-nil, adds the hive_tasks gauge.
+func (r *Registry) WriteTo(w io.Writer, tasks []store.TaskCount) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tasks != nil {
+		fmt.Fprintln(w, "# HELP hive_tasks Epics and tasks by status.")
+		fmt.Fprintln(w, "# TYPE hive_tasks gauge")
+		for _, t := range tasks {
+			fmt.Fprintf(w, "hive_tasks{kind=%q,status=%q} %d\n", t.Kind, t.Status, t.Count)
+		}
+	}
+
+	agentLabels := []string{"agent", "role", "mode"}
+	r.writeHistograms(w, "hive_agent_call_duration_seconds", "Agent call latency.", agentLabels, r.agentCalls)
+	r.writeCounters(w, "hive_agent_call_errors_total", "Agent calls that failed.", agentLabels, r.agentErrors)
+	r.writeCounters(w, "hive_agent_tokens_total", "Tokens sent to and received from API agents.", []string{"agent", "direction"}, r.tokens)
+	r.writeCounters(w, "hive_agent_cost_usd_total", "Estimated API cost from agent pricing config.", []string{"agent"}, r.costUSD)
+	r.writeHistograms(w, "hive_pipeline_duration_seconds", "Duration of hive auto pipeline runs.", []string{"status"}, r.pipelineRuns)
+}
+
+func (r *Registry) writeCounters(w io.Writer, name, help string, labels []string, values map[string]float64) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, formatLabels(labels, r.labelsByKey[k]), formatFloat(values[k]))
+	}
+}
+
+func (r *Registry) writeHistograms(w io.Writer, name, help string, labels []string, values map[string]*histogram) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, k := range sortedKeys(values) {
+		h := values[k]
+		base := formatLabels(labels, r.labelsByKey[k])
+		for i, le := range h.bounds {
+			fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d\n", name, base, formatFloat(le), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, base, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, base, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, base, h.count)
+	}
+}
+
+// Handler serves the registry at /metrics. When s is set, task counts are
+// read from it on every scrape.
+func (r *Registry) Handler(s *store.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var tasks []store.TaskCount
+		if s != nil {
+			tasks, _ = s.CountTasks()
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w, tasks)
+	})
+}
+
+// Serve starts serving the default registry on addr (e.g. ":9090") in the
+// background. The returned function shuts the server down.
+func Serve(addr string, s *store.Store) (stop func(), err error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics listen: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Default.Handler(s))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}, nil
+}
+
+// histogram is a cumulative Prometheus histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] = observations <= bounds[i]
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range h.bounds {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func formatLabels(names, values []string) string {
+	parts := make([]string, len(names))
+	for i, n := range names {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		parts[i] = fmt.Sprintf("%s=%q", n, v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
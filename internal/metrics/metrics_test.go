@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestWriteTo_AgentCallsAndUsage(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveAgentCall("claude", "coder", "cli", 12, false)
+	r.ObserveAgentCall("claude", "coder", "cli", 400, true)
+	r.AddUsage("gpt", 1000, 200, 0.5)
+	r.ObservePipeline("completed", 90)
+
+	var b strings.Builder
+	r.WriteTo(&b, nil)
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE hive_agent_call_duration_seconds histogram",
+		`hive_agent_call_duration_seconds_bucket{agent="claude",role="coder",mode="cli",le="15"} 1`,
+		`hive_agent_call_duration_seconds_bucket{agent="claude",role="coder",mode="cli",le="+Inf"} 2`,
+		`hive_agent_call_duration_seconds_sum{agent="claude",role="coder",mode="cli"} 412`,
+		`hive_agent_call_errors_total{agent="claude",role="coder",mode="cli"} 1`,
+		`hive_agent_tokens_total{agent="gpt",direction="input"} 1000`,
+		`hive_agent_tokens_total{agent="gpt",direction="output"} 200`,
+		`hive_agent_cost_usd_total{agent="gpt"} 0.5`,
+		`hive_pipeline_duration_seconds_count{status="completed"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hive_tasks") {
+		t.Error("hive_tasks should be omitted without task counts")
+	}
+}
+
+func TestHandler_IncludesTaskCounts(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	defer s.Close()
+	epic, _ := s.CreateEpic("Epic", "", "")
+	s.CreateTask("A", "", "", &epic.ID)
+	b, _ := s.CreateTask("B", "", "", &epic.ID)
+	s.UpdateTaskStatus(b.ID, store.StatusDone)
+
+	rec := httptest.NewRecorder()
+	NewRegistry().Handler(s).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("content type = %q", ct)
+	}
+	out := rec.Body.String()
+	for _, want := range []string{
+		`hive_tasks{kind="epic",status="backlog"} 1`,
+		`hive_tasks{kind="task",status="backlog"} 1`,
+		`hive_tasks{kind="task",status="done"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package notify delivers short messages about hive activity to chat
+// channels and webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Target kinds.
+const (
+	KindSlack   = "slack"   // slack:#channel or slack:@user, via a Slack incoming webhook
+	KindWebhook = "webhook" // webhook:https://..., a generic JSON POST
+)
+
+// Target is where a message is delivered.
+type Target struct {
+	Kind string
+	Dest string // Slack channel or user, or the webhook URL
+}
+
+func (t Target) String() string {
+	return t.Kind + ":" + t.Dest
+}
+
+// ParseTarget parses a target such as "slack:#team", "slack:@alice" or
+// "webhook:https://example.com/hook".
+func ParseTarget(s string) (Target, error) {
+	kind, dest, ok := strings.Cut(s, ":")
+	if !ok || dest == "" {
+		return Target{}, fmt.Errorf("invalid target %q (want slack:#channel, slack:@user or webhook:<url>)", s)
+	}
+	t := Target{Kind: kind, Dest: dest}
+	switch kind {
+	case KindSlack:
+		if !strings.HasPrefix(dest, "#") && !strings.HasPrefix(dest, "@") {
+			return Target{}, fmt.Errorf("invalid slack target %q: channel must start with # or user with @", s)
+		}
+	case KindWebhook:
+		if !strings.HasPrefix(dest, "http://") && !strings.HasPrefix(dest, "https://") {
+			return Target{}, fmt.Errorf("invalid webhook target %q: URL must start with http:// or https://", s)
+		}
+	default:
+		return Target{}, fmt.Errorf("unknown target kind %q (want slack or webhook)", kind)
+	}
+	return t, nil
+}
+
+// Message is one notification. Text is the human-readable summary; the
+// other fields are included for webhook consumers.
+type Message struct {
+	Event  string `json:"event,omitempty"` // Lifecycle event, for webhooks in notify.webhooks
+	TaskID int64  `json:"task_id"`
+	EpicID int64  `json:"epic_id,omitempty"`
+	Kind   string `json:"kind"` // epic or task
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"` // Blocker reason or answer
+	Text   string `json:"text"`
+}
+
+// Notifier sends messages to targets.
+type Notifier struct {
+	SlackWebhook string // Incoming webhook URL used for slack: targets
+	Client       *http.Client
+}
+
+// New returns a notifier posting Slack messages through slackWebhook.
+func New(slackWebhook string) *Notifier {
+	return &Notifier{SlackWebhook: slackWebhook, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send delivers msg to target.
+func (n *Notifier) Send(ctx context.Context, target Target, msg Message) error {
+	switch target.Kind {
+	case KindSlack:
+		if n.SlackWebhook == "" {
+			return fmt.Errorf("%s: no Slack webhook configured (notify.slack_webhook)", target)
+		}
+		// Incoming webhooks post to their default channel unless
+		// "channel" overrides it; "@user" sends a direct message.
+		return n.post(ctx, n.SlackWebhook, map[string]string{"channel": target.Dest, "text": msg.Text})
+	case KindWebhook:
+		return n.post(ctx, target.Dest, msg)
+	default:
+		return fmt.Errorf("unknown target kind %q", target.Kind)
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("notify: %s returned %s: %s", redactURL(url), resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// redactURL drops the path of a webhook URL from errors: for Slack and
+// most other services the path is the secret.
+func redactURL(url string) string {
+	scheme, rest, _ := strings.Cut(url, "://")
+	host, _, _ := strings.Cut(rest, "/")
+	return scheme + "://" + host
+}
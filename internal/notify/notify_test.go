@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	valid := []string{"slack:#team", "slack:@alice", "webhook:https://example.com/hook"}
+	for _, s := range valid {
+		got, err := ParseTarget(s)
+		if err != nil {
+			t.Errorf("ParseTarget(%q): %v", s, err)
+		} else if got.String() != s {
+			t.Errorf("ParseTarget(%q) round-trips to %q", s, got)
+		}
+	}
+
+	invalid := []string{"", "slack", "slack:team", "webhook:ftp://x", "email:a@b.c"}
+	for _, s := range invalid {
+		if _, err := ParseTarget(s); err == nil {
+			t.Errorf("ParseTarget(%q): expected an error", s)
+		}
+	}
+}
+
+func TestSend_Slack(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n := New(srv.URL)
+	target, _ := ParseTarget("slack:#team")
+	if err := n.Send(context.Background(), target, Message{Text: "Task #1 is now done"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got["channel"] != "#team" || got["text"] != "Task #1 is now done" {
+		t.Errorf("unexpected payload %v", got)
+	}
+}
+
+func TestSend_SlackWithoutWebhook(t *testing.T) {
+	target, _ := ParseTarget("slack:#team")
+	err := New("").Send(context.Background(), target, Message{})
+	if err == nil || !strings.Contains(err.Error(), "slack_webhook") {
+		t.Errorf("expected a missing webhook error, got %v", err)
+	}
+}
+
+func TestSend_Webhook(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	target, _ := ParseTarget("webhook:" + srv.URL)
+	msg := Message{TaskID: 7, Kind: "task", Status: "blocked", Detail: "which DB?", Text: "blocked"}
+	if err := New("").Send(context.Background(), target, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got != msg {
+		t.Errorf("expected %+v, got %+v", msg, got)
+	}
+}
+
+func TestSend_ErrorHidesWebhookPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	target, _ := ParseTarget("slack:#team")
+	err := New(srv.URL+"/services/T000/B000/secret").Send(context.Background(), target, Message{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks the webhook path: %v", err)
+	}
+}
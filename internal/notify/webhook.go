@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Lifecycle events sent to the webhooks in notify.webhooks.
+const (
+	EventPipelineStarted = "pipeline_started"
+	EventTaskBlocked     = "task_blocked"
+	EventReviewRejected  = "review_rejected"
+	EventEpicReady       = "epic_ready" // All tasks done, waiting for hive epic accept
+	EventPipelineFailed  = "pipeline_failed"
+)
+
+// Webhook payload formats.
+const (
+	FormatGeneric = "generic" // The Message as JSON
+	FormatSlack   = "slack"   // A Slack incoming webhook: {"text": ...}
+	FormatDiscord = "discord" // A Discord webhook: {"content": ...}
+)
+
+// discordLimit is the most characters Discord accepts in a message.
+const discordLimit = 2000
+
+// DetectFormat guesses a webhook's format from its URL: Slack and Discord
+// webhooks by host, anything else generic.
+func DetectFormat(url string) string {
+	_, rest, _ := strings.Cut(url, "://")
+	host, path, _ := strings.Cut(rest, "/")
+	switch {
+	case host == "hooks.slack.com":
+		return FormatSlack
+	case (host == "discord.com" || host == "discordapp.com") && strings.HasPrefix(path, "api/webhooks/"):
+		return FormatDiscord
+	}
+	return FormatGeneric
+}
+
+// Post delivers msg to a webhook URL in the given format, or the one
+// DetectFormat picks when format is empty.
+func (n *Notifier) Post(ctx context.Context, url, format string, msg Message) error {
+	if format == "" {
+		format = DetectFormat(url)
+	}
+	switch format {
+	case FormatSlack:
+		return n.post(ctx, url, map[string]string{"text": msg.Text})
+	case FormatDiscord:
+		text := msg.Text
+		if r := []rune(text); len(r) > discordLimit {
+			text = string(r[:discordLimit-1]) + "…"
+		}
+		return n.post(ctx, url, map[string]string{"content": text})
+	case FormatGeneric:
+		return n.post(ctx, url, msg)
+	default:
+		return fmt.Errorf("unknown webhook format %q", format)
+	}
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]string{
+		"https://hooks.slack.com/services/T0/B0/x":       FormatSlack,
+		"https://discord.com/api/webhooks/1/abc":         FormatDiscord,
+		"https://discordapp.com/api/webhooks/1/abc":      FormatDiscord,
+		"https://discord.com/channels/1":                 FormatGeneric,
+		"https://ci.example.com/hive":                    FormatGeneric,
+		"http://localhost:8080/hooks.slack.com/services": FormatGeneric,
+	}
+	for url, want := range cases {
+		if got := DetectFormat(url); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestPost_Formats(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	msg := Message{Event: EventTaskBlocked, TaskID: 4, Kind: "task", Title: "Add login", Status: "blocked", Text: "Task #4 is blocked"}
+	n := New("")
+
+	if err := n.Post(context.Background(), srv.URL, FormatSlack, msg); err != nil {
+		t.Fatalf("Post slack: %v", err)
+	}
+	if got["text"] != msg.Text || len(got) != 1 {
+		t.Errorf("slack payload: %v", got)
+	}
+
+	if err := n.Post(context.Background(), srv.URL, FormatDiscord, msg); err != nil {
+		t.Fatalf("Post discord: %v", err)
+	}
+	if got["content"] != msg.Text || len(got) != 1 {
+		t.Errorf("discord payload: %v", got)
+	}
+
+	// Generic is the default for a URL that isn't Slack or Discord.
+	if err := n.Post(context.Background(), srv.URL, "", msg); err != nil {
+		t.Fatalf("Post generic: %v", err)
+	}
+	if got["event"] != EventTaskBlocked || got["task_id"] != float64(4) || got["text"] != msg.Text {
+		t.Errorf("generic payload: %v", got)
+	}
+
+	if err := n.Post(context.Background(), srv.URL, "teams", msg); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestPost_DiscordTruncates(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	msg := Message{Text: strings.Repeat("x", 3000)}
+	if err := New("").Post(context.Background(), srv.URL, FormatDiscord, msg); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if n := len([]rune(got["content"])); n != discordLimit {
+		t.Errorf("expected %d characters, got %d", discordLimit, n)
+	}
+}
@@ -0,0 +1,210 @@
+// Package owners reads code ownership — a CODEOWNERS file plus rules from
+// hive's config — and tells who owns a path, so tasks touching owned code
+// can be planned, routed and gated accordingly.
+//
+// Patterns follow CODEOWNERS (gitignore) syntax: "*.go", "docs/",
+// "/build/", "src/**/test". The last matching rule wins, and a rule with
+// no owners makes a path unowned.
+package owners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Locations are where a CODEOWNERS file is looked for, in order.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// Owners answers who owns a path.
+type Owners struct {
+	rules []Rule
+}
+
+// Area is an owner and the paths of theirs that something touches.
+type Area struct {
+	Owner string
+	Paths []string
+}
+
+// Parse reads rules in CODEOWNERS format: a pattern followed by owners,
+// one rule per line, # for comments.
+func Parse(text string) []Rule {
+	var rules []Rule
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		rules = append(rules, Rule{Pattern: fields[0], Owners: fields[1:]})
+	}
+	return rules
+}
+
+// New builds an Owners from rules, later rules taking precedence.
+func New(rules []Rule) *Owners {
+	o := &Owners{}
+	for _, r := range rules {
+		if r.Pattern == "" {
+			continue
+		}
+		r.re = compile(r.Pattern)
+		o.rules = append(o.rules, r)
+	}
+	return o
+}
+
+// Load reads the CODEOWNERS file (file, relative to root, or the first of
+// Locations that exists) and adds extra rules after it. A missing file is
+// only an error when it was named explicitly.
+func Load(root, file string, extra []Rule) (*Owners, error) {
+	var rules []Rule
+	if file != "" {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+		rules = Parse(string(data))
+	} else {
+		for _, loc := range Locations {
+			if data, err := os.ReadFile(filepath.Join(root, loc)); err == nil {
+				rules = Parse(string(data))
+				break
+			}
+		}
+	}
+	return New(append(rules, extra...)), nil
+}
+
+// Empty reports whether there are no rules.
+func (o *Owners) Empty() bool {
+	return o == nil || len(o.rules) == 0
+}
+
+// Rules returns the rules in the order they apply.
+func (o *Owners) Rules() []Rule {
+	if o == nil {
+		return nil
+	}
+	return o.rules
+}
+
+// Of returns the owners of a path relative to the repository root, or nil
+// if it has none. The path may name a directory, which "dir/" rules match.
+func (o *Owners) Of(path string) []string {
+	if o == nil {
+		return nil
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if re := o.rules[i].re; re.MatchString(path) || re.MatchString(path+"/") {
+			return o.rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// Touched groups paths by owner, sorted by owner. Unowned paths are left
+// out; a path with several owners is listed under each.
+func (o *Owners) Touched(paths []string) []Area {
+	byOwner := map[string][]string{}
+	for _, p := range paths {
+		for _, owner := range o.Of(p) {
+			byOwner[owner] = append(byOwner[owner], p)
+		}
+	}
+	areas := make([]Area, 0, len(byOwner))
+	for owner, ps := range byOwner {
+		areas = append(areas, Area{Owner: owner, Paths: ps})
+	}
+	sort.Slice(areas, func(i, j int) bool { return areas[i].Owner < areas[j].Owner })
+	return areas
+}
+
+// compile turns a CODEOWNERS pattern into a regexp over slash-separated
+// paths. A pattern matching a directory matches everything under it.
+func compile(pattern string) *regexp.Regexp {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	// Patterns with a slash other than at the end are relative to the root;
+	// others match at any depth.
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case p[i] == '*':
+			sb.WriteString("[^/]*")
+		case p[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	if dirOnly {
+		sb.WriteString("/.*$")
+	} else {
+		sb.WriteString("(?:/.*)?$")
+	}
+	return regexp.MustCompile(sb.String())
+}
+
+var (
+	backtickRe   = regexp.MustCompile("`([^`\\s]+)`")
+	lineSuffixRe = regexp.MustCompile(`(?::\d+)+$`) // file.go:42:7
+	pathRe       = regexp.MustCompile(`(?:^|[\s(\[,"'])((?:\.{0,2}/)?[\w.@-]+(?:/[\w.@*-]+)+/?)`)
+)
+
+// Mentions returns the paths named in free text, such as a task's title
+// and description: anything in backticks, and words with a slash that
+// aren't URLs.
+func Mentions(text string) []string {
+	seen := map[string]bool{}
+	var paths []string
+	add := func(p string) {
+		p = strings.TrimRight(p, ".,:;)")
+		p = strings.TrimPrefix(p, "./")
+		if p == "" || strings.Contains(p, "://") || seen[p] {
+			return
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	for _, m := range backtickRe.FindAllStringSubmatch(text, -1) {
+		p := lineSuffixRe.ReplaceAllString(m[1], "")
+		if strings.ContainsAny(p, "/.") {
+			add(p)
+		}
+	}
+	for _, m := range pathRe.FindAllStringSubmatch(text, -1) {
+		add(lineSuffixRe.ReplaceAllString(m[1], ""))
+	}
+	return paths
+}
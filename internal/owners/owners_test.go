@@ -0,0 +1,95 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const codeowners = `# Default owners
+*                   @org/everyone
+
+*.sql               @dba   # migrations too
+/docs/              @writers
+internal/billing/   @payments @alice
+**/testdata         @qa
+/build/logs         @ops
+internal/billing/legacy.go
+`
+
+func TestOf(t *testing.T) {
+	o := New(Parse(codeowners))
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@org/everyone"}},
+		{"db/001_init.sql", []string{"@dba"}},
+		{"docs/guide.md", []string{"@writers"}},
+		{"web/docs/guide.md", []string{"@org/everyone"}}, // /docs/ is anchored
+		{"internal/billing/invoice.go", []string{"@payments", "@alice"}},
+		{"internal/billing", []string{"@payments", "@alice"}}, // the directory itself
+		{"internal/billing/legacy.go", []string{}},            // no owners: unowned
+		{"pkg/a/testdata/x.json", []string{"@qa"}},
+		{"build/logs/today.log", []string{"@ops"}},
+		{"./docs/index.md", []string{"@writers"}},
+	}
+	for _, tc := range tests {
+		got := o.Of(tc.path)
+		if len(got) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Of(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestTouched(t *testing.T) {
+	o := New(Parse("internal/billing/ @payments\n*.sql @dba @payments\n"))
+	got := o.Touched([]string{"internal/billing/a.go", "README.md", "db/x.sql"})
+	want := []Area{
+		{Owner: "@dba", Paths: []string{"db/x.sql"}},
+		{Owner: "@payments", Paths: []string{"internal/billing/a.go", "db/x.sql"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Touched = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".github"), 0755)
+	os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte("*.go @gophers\n"), 0644)
+
+	o, err := Load(root, "", []Rule{{Pattern: "cmd/", Owners: []string{"@cli"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := o.Of("lib/a.go"); !reflect.DeepEqual(got, []string{"@gophers"}) {
+		t.Errorf("lib/a.go owners = %v", got)
+	}
+	// Config rules come after the file, so they win.
+	if got := o.Of("cmd/main.go"); !reflect.DeepEqual(got, []string{"@cli"}) {
+		t.Errorf("cmd/main.go owners = %v", got)
+	}
+
+	if _, err := Load(root, "OWNERS", nil); err == nil {
+		t.Error("expected an error for a missing named file")
+	}
+	o, err = Load(t.TempDir(), "", nil)
+	if err != nil || !o.Empty() {
+		t.Errorf("no CODEOWNERS: got %v, %v; want empty", o, err)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	text := "Fix the nil map in `internal/auth/session.go:88` and update internal/auth/store.go.\n" +
+		"See https://example.com/docs/page and docs/auth.md (the `config.yaml` too)."
+	got := Mentions(text)
+	want := []string{"internal/auth/session.go", "config.yaml", "internal/auth/store.go", "docs/auth.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mentions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+// Package perf runs a project's benchmarks and compares them against a
+// recorded baseline, so hive can catch performance regressions an agent
+// introduced before the reviewer approves the change.
+package perf
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// benchLine matches Go benchmark output:
+//
+//	BenchmarkParse-8   	  120000	      9871 ns/op	    512 B/op
+var benchLine = regexp.MustCompile(`^(Benchmark\S+)\s+\d+\s+([0-9.]+)\s+ns/op`)
+
+// Results maps a benchmark name to its ns/op.
+type Results map[string]float64
+
+// Parse extracts ns/op per benchmark from benchmark output. The -N CPU
+// suffix is stripped so results compare across machines with different
+// GOMAXPROCS. Repeated runs of one benchmark (-count) are averaged.
+func Parse(output string) Results {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := benchLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		name := stripCPUSuffix(m[1])
+		sums[name] += v
+		counts[name]++
+	}
+
+	res := make(Results, len(sums))
+	for name, sum := range sums {
+		res[name] = sum / float64(counts[name])
+	}
+	return res
+}
+
+func stripCPUSuffix(name string) string {
+	if i := strings.LastIndex(name, "-"); i > 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+// Regression is one benchmark that got slower than the tolerance allows.
+type Regression struct {
+	Name     string
+	Baseline float64 // ns/op
+	Current  float64 // ns/op
+	DeltaPct float64 // How much slower, in percent
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %.0f → %.0f ns/op (+%.1f%%)", r.Name, r.Baseline, r.Current, r.DeltaPct)
+}
+
+// Compare returns the benchmarks in current that are more than
+// tolerancePct slower than in baseline, sorted by name. Benchmarks that
+// only exist on one side are ignored.
+func Compare(baseline, current Results, tolerancePct float64) []Regression {
+	var out []Regression
+	for name, cur := range current {
+		base, ok := baseline[name]
+		if !ok || base <= 0 {
+			continue
+		}
+		delta := (cur - base) / base * 100
+		if delta > tolerancePct {
+			out = append(out, Regression{Name: name, Baseline: base, Current: cur, DeltaPct: delta})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Run executes the benchmark command in workDir and returns its output.
+func Run(command, workDir string, timeoutSec int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = workDir
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(out), fmt.Errorf("benchmark timed out after %ds", timeoutSec)
+	}
+	if err != nil {
+		return string(out), fmt.Errorf("benchmark command failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// LoadBaseline reads and parses a saved baseline. A missing file returns
+// nil results and no error.
+func LoadBaseline(path string) (Results, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read baseline: %w", err)
+	}
+	return Parse(string(data)), nil
+}
+
+// SaveBaseline stores raw benchmark output as the new baseline.
+func SaveBaseline(path, output string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(output), 0644)
+}
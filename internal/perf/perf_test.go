@@ -0,0 +1,83 @@
+package perf
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const sampleOutput = `goos: linux
+goarch: amd64
+pkg: example.com/app
+BenchmarkParse-8   	  120000	      1000 ns/op	     512 B/op	       4 allocs/op
+BenchmarkParse-8   	  120000	      1200 ns/op	     512 B/op	       4 allocs/op
+BenchmarkRender-8  	   50000	     20000 ns/op
+PASS
+ok  	example.com/app	3.2s
+`
+
+func TestParse(t *testing.T) {
+	res := Parse(sampleOutput)
+
+	if len(res) != 2 {
+		t.Fatalf("expected 2 benchmarks, got %v", res)
+	}
+	if res["BenchmarkParse"] != 1100 {
+		t.Errorf("expected averaged 1100 ns/op, got %v", res["BenchmarkParse"])
+	}
+	if res["BenchmarkRender"] != 20000 {
+		t.Errorf("expected 20000 ns/op, got %v", res["BenchmarkRender"])
+	}
+}
+
+func TestParse_NoBenchmarks(t *testing.T) {
+	if res := Parse("PASS\nok\n"); len(res) != 0 {
+		t.Fatalf("expected no results, got %v", res)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := Results{"BenchmarkA": 1000, "BenchmarkB": 1000, "BenchmarkGone": 10}
+	current := Results{"BenchmarkA": 1050, "BenchmarkB": 1500, "BenchmarkNew": 99}
+
+	regs := Compare(baseline, current, 10)
+	if len(regs) != 1 {
+		t.Fatalf("expected 1 regression, got %v", regs)
+	}
+	if regs[0].Name != "BenchmarkB" || regs[0].DeltaPct != 50 {
+		t.Errorf("unexpected regression: %+v", regs[0])
+	}
+}
+
+func TestRun(t *testing.T) {
+	out, err := Run("echo BenchmarkX-4 10 5 ns/op", t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if Parse(out)["BenchmarkX"] != 5 {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if _, err := Run("exit 3", t.TempDir(), 10); err == nil {
+		t.Error("expected error for failing command")
+	}
+}
+
+func TestBaseline_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "baseline.txt")
+
+	res, err := LoadBaseline(path)
+	if err != nil || res != nil {
+		t.Fatalf("expected nil for missing baseline, got %v, %v", res, err)
+	}
+
+	if err := SaveBaseline(path, sampleOutput); err != nil {
+		t.Fatalf("SaveBaseline: %v", err)
+	}
+	res, err = LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("expected 2 benchmarks, got %v", res)
+	}
+}
@@ -0,0 +1,139 @@
+// Package redact masks secrets before they leave the process or land on
+// disk. A secret is the value of an environment variable whose name
+// matches one of the configured patterns (API keys, tokens, passwords);
+// every occurrence of such a value is replaced by [REDACTED:NAME].
+//
+// Redaction is on with DefaultPatterns until Init configures it. Prompts
+// sent to agents, events and artifacts under .hive/runs pass through
+// String.
+package redact
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultPatterns match the names of environment variables that usually
+// hold credentials. Patterns use path.Match syntax and are compared
+// case-insensitively.
+var DefaultPatterns = []string{
+	"*_KEY",
+	"*_TOKEN",
+	"*SECRET*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*_CREDENTIALS",
+}
+
+// MinLength is the shortest value that is masked. Shorter values ("1",
+// "true", "dev") would mask unrelated text everywhere.
+const MinLength = 8
+
+// Redactor replaces known secret values in text.
+type Redactor struct {
+	secrets []secret // Longest value first, so overlapping values mask fully
+}
+
+type secret struct {
+	name  string
+	value string
+}
+
+// New builds a Redactor for the variables in environ ("NAME=value", as
+// from os.Environ) whose names match patterns. Invalid patterns are
+// ignored.
+func New(patterns, environ []string) *Redactor {
+	r := &Redactor{}
+	seen := make(map[string]bool)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || len(value) < MinLength || seen[value] || !matchAny(patterns, name) {
+			continue
+		}
+		seen[value] = true
+		r.secrets = append(r.secrets, secret{name: name, value: value})
+	}
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i].value) > len(r.secrets[j].value)
+	})
+	return r
+}
+
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the names of the variables whose values are masked.
+func (r *Redactor) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, len(r.secrets))
+	for i, s := range r.secrets {
+		names[i] = s.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns text with every secret value replaced. A nil Redactor
+// returns text unchanged.
+func (r *Redactor) String(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, s := range r.secrets {
+		if strings.Contains(text, s.value) {
+			text = strings.ReplaceAll(text, s.value, "[REDACTED:"+s.name+"]")
+		}
+	}
+	return text
+}
+
+var (
+	mu      sync.RWMutex
+	current *Redactor
+	inited  bool
+)
+
+// Init configures the process-wide redactor from the current environment.
+// Extra patterns are added to DefaultPatterns; enabled=false turns
+// redaction off.
+func Init(enabled bool, extra []string) {
+	var r *Redactor
+	if enabled {
+		r = New(append(append([]string(nil), DefaultPatterns...), extra...), os.Environ())
+	}
+	mu.Lock()
+	current, inited = r, true
+	mu.Unlock()
+}
+
+// Default returns the process-wide redactor, initializing it with
+// DefaultPatterns if Init was never called. It is nil when redaction is off.
+func Default() *Redactor {
+	mu.RLock()
+	r, ok := current, inited
+	mu.RUnlock()
+	if !ok {
+		Init(true, nil)
+		mu.RLock()
+		r = current
+		mu.RUnlock()
+	}
+	return r
+}
+
+// String masks secrets in text with the process-wide redactor.
+func String(text string) string {
+	return Default().String(text)
+}
@@ -0,0 +1,67 @@
+package redact
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewMatchesPatterns(t *testing.T) {
+	r := New(DefaultPatterns, []string{
+		"OPENAI_API_KEY=sk-abcdef123456",
+		"GITHUB_TOKEN=ghp_0123456789",
+		"db_password=hunter2hunter2",
+		"SHORT_TOKEN=abc",
+		"HOME=/home/someone",
+		"MALFORMED",
+	})
+	got := r.Names()
+	want := []string{"GITHUB_TOKEN", "OPENAI_API_KEY", "db_password"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestString(t *testing.T) {
+	r := New([]string{"*_KEY", "*_TOKEN"}, []string{
+		"API_KEY=sk-abcdef123456",
+		"LONG_TOKEN=sk-abcdef123456-extended",
+	})
+
+	in := "export API_KEY=sk-abcdef123456\ncurl -H 'Bearer sk-abcdef123456-extended'"
+	want := "export API_KEY=[REDACTED:API_KEY]\ncurl -H 'Bearer [REDACTED:LONG_TOKEN]'"
+	if got := r.String(in); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := r.String("nothing secret"); got != "nothing secret" {
+		t.Errorf("String() changed clean text: %q", got)
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	if got := r.String("sk-abcdef123456"); got != "sk-abcdef123456" {
+		t.Errorf("nil String() = %q", got)
+	}
+	if r.Names() != nil {
+		t.Error("nil Names() should be nil")
+	}
+}
+
+func TestInit(t *testing.T) {
+	t.Setenv("HIVE_TEST_CUSTOM", "custom-secret-value")
+	t.Setenv("HIVE_TEST_TOKEN", "token-secret-value")
+	defer Init(true, nil)
+
+	Init(true, []string{"HIVE_TEST_CUSTOM"})
+	if got := String("a custom-secret-value b token-secret-value"); got != "a [REDACTED:HIVE_TEST_CUSTOM] b [REDACTED:HIVE_TEST_TOKEN]" {
+		t.Errorf("String() = %q", got)
+	}
+
+	Init(false, nil)
+	if Default() != nil {
+		t.Error("Default() should be nil when disabled")
+	}
+	if got := String("token-secret-value"); got != "token-secret-value" {
+		t.Errorf("disabled String() = %q", got)
+	}
+}
@@ -0,0 +1,196 @@
+// Package roles is the registry of agent roles hive knows about: the
+// built-in pipeline roles (pm, architect, coder, reviewer, tester, analyst,
+// docs)
+// plus any custom roles defined in config.yaml. A role decides how an
+// agent's prompt is framed and, for custom roles, where it runs in auto.
+package roles
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+// Built-in role names (see config.BuiltinRoles).
+const (
+	PM        = config.RolePM
+	Architect = config.RoleArchitect
+	Coder     = config.RoleCoder
+	Reviewer  = config.RoleReviewer
+	Tester    = config.RoleTester
+	Analyst   = config.RoleAnalyst
+	Docs      = config.RoleDocs
+)
+
+// Stage is the point in the auto pipeline where a custom role runs.
+type Stage string
+
+const (
+	StageNone        Stage = ""             // Not part of the auto pipeline (built-ins, or run manually)
+	StageBeforeCode  Stage = "before_code"  // After architect, before the first coder iteration
+	StageAfterCode   Stage = "after_code"   // After each coder iteration, before the reviewer
+	StageAfterReview Stage = "after_review" // After the reviewer approves, before commit
+)
+
+// Role describes one agent role.
+type Role struct {
+	Name         string
+	Header       string   // Prompt preamble ("# You are a ...")
+	Instructions string   // Process + response format appended to the prompt
+	Stage        Stage    // Pipeline position (custom roles only)
+	Verdict      bool     // Output is parsed for VERDICT: like a reviewer
+	Omit         []string // Prompt sections the role doesn't get
+	BuiltIn      bool
+}
+
+// Registry resolves role names to their definitions.
+type Registry struct {
+	roles map[string]Role
+}
+
+// Builtin returns a registry with only the built-in roles.
+func Builtin() *Registry {
+	return NewRegistry(nil)
+}
+
+// NewRegistry returns the built-in roles merged with the custom role
+// definitions from config. A definition with a built-in name overrides
+// that role's header/instructions but keeps its place in the pipeline.
+func NewRegistry(defs map[string]config.RoleDef) *Registry {
+	r := &Registry{roles: make(map[string]Role)}
+	for _, name := range config.BuiltinRoles {
+		r.roles[name] = Role{
+			Name:         name,
+			Header:       builtinHeaders[name],
+			Instructions: builtinInstructions[name],
+			BuiltIn:      true,
+		}
+	}
+
+	for name, def := range defs {
+		role, ok := r.roles[name]
+		if !ok {
+			role = Role{
+				Name:    name,
+				Stage:   Stage(def.Stage),
+				Verdict: def.Verdict,
+			}
+		}
+		if def.Header != "" {
+			role.Header = def.Header
+		}
+		if def.Instructions != "" {
+			role.Instructions = def.Instructions
+		}
+		if def.Output != "" {
+			role.Instructions = withOutput(role.Instructions, def.Output)
+		}
+		role.Omit = def.Omit
+		r.roles[name] = role
+	}
+	return r
+}
+
+// withOutput replaces the "## Response Format" section that ends
+// instructions, or adds one, with output.
+func withOutput(instructions, output string) string {
+	if i := strings.Index(instructions, responseFormat); i >= 0 {
+		instructions = instructions[:i]
+	}
+	output = strings.TrimSpace(output)
+	if !strings.HasPrefix(output, "#") {
+		output = responseFormat + "\n" + output
+	}
+	if instructions = strings.TrimSpace(instructions); instructions == "" {
+		return output
+	}
+	return instructions + "\n\n" + output
+}
+
+// Get returns the role with the given name.
+func (r *Registry) Get(name string) (Role, bool) {
+	role, ok := r.roles[name]
+	return role, ok
+}
+
+// IsBuiltIn reports whether name is one of hive's built-in roles.
+func IsBuiltIn(name string) bool {
+	return slices.Contains(config.BuiltinRoles, name)
+}
+
+// Header returns the prompt header for a role. Unknown roles get a
+// generic one-liner so ad-hoc role names still produce a usable prompt.
+func (r *Registry) Header(name string) string {
+	if role, ok := r.roles[name]; ok && role.Header != "" {
+		return role.Header
+	}
+	return fmt.Sprintf("# You are working as: %s", name)
+}
+
+// Instructions returns the process/response-format section for a role.
+// Verdict roles always end with the VERDICT format the pipeline parses,
+// even when their custom instructions forget to ask for it.
+func (r *Registry) Instructions(name string) string {
+	role, ok := r.roles[name]
+	if !ok {
+		return ""
+	}
+	if role.Verdict && !strings.Contains(strings.ToUpper(role.Instructions), "VERDICT:") {
+		return strings.TrimSpace(role.Instructions + "\n\n" + verdictFormat)
+	}
+	return role.Instructions
+}
+
+// Omits reports whether the role's prompts leave out a section (one of
+// config.PromptSections).
+func (r *Registry) Omits(name, section string) bool {
+	for _, s := range r.roles[name].Omit {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// ByStage returns the custom roles that run at the given pipeline stage,
+// sorted by name so runs are deterministic.
+func (r *Registry) ByStage(stage Stage) []Role {
+	var out []Role
+	for _, role := range r.roles {
+		if stage != StageNone && role.Stage == stage {
+			out = append(out, role)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Names returns all known role names, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.roles))
+	for name := range r.roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// responseFormat heads the part of a role's instructions that says how to
+// answer.
+const responseFormat = "## Response Format"
+
+const verdictFormat = `## Response Format
+You MUST include a verdict line in this exact format:
+
+VERDICT: APPROVE
+or
+VERDICT: REJECT
+
+COMMENTS:
+- [severity] file:line: description of finding
+
+If you cannot proceed without more information:
+BLOCKED: [your specific question]`
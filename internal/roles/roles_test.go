@@ -0,0 +1,122 @@
+package roles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestBuiltin_KnownRoles(t *testing.T) {
+	r := Builtin()
+
+	if len(builtinHeaders) != len(config.BuiltinRoles) {
+		t.Errorf("%d role headers for %d built-in roles", len(builtinHeaders), len(config.BuiltinRoles))
+	}
+	for _, name := range config.BuiltinRoles {
+		role, ok := r.Get(name)
+		if !ok {
+			t.Fatalf("missing built-in role %q", name)
+		}
+		if !role.BuiltIn {
+			t.Errorf("%s: expected BuiltIn", name)
+		}
+		if role.Header == "" {
+			t.Errorf("%s: empty header", name)
+		}
+	}
+}
+
+func TestHeader_UnknownRole(t *testing.T) {
+	r := Builtin()
+	if got := r.Header("designer"); !strings.Contains(got, "designer") {
+		t.Errorf("expected generic header with role name, got %q", got)
+	}
+	if got := r.Instructions("designer"); got != "" {
+		t.Errorf("expected no instructions for unknown role, got %q", got)
+	}
+}
+
+func TestNewRegistry_OverrideBuiltin(t *testing.T) {
+	r := NewRegistry(map[string]config.RoleDef{
+		Coder: {Header: "# You are a Go expert"},
+	})
+
+	if got := r.Header(Coder); got != "# You are a Go expert" {
+		t.Errorf("header not overridden: %q", got)
+	}
+	// Instructions were not overridden, so the built-in text stays.
+	if !strings.Contains(r.Instructions(Coder), "BLOCKED:") {
+		t.Error("expected built-in coder instructions to be kept")
+	}
+	role, _ := r.Get(Coder)
+	if !role.BuiltIn || role.Stage != StageNone {
+		t.Errorf("override changed built-in role: %+v", role)
+	}
+}
+
+func TestOmits(t *testing.T) {
+	r := NewRegistry(map[string]config.RoleDef{
+		Reviewer: {Omit: []string{"history", "handoffs"}},
+	})
+	if !r.Omits(Reviewer, "history") || !r.Omits(Reviewer, "handoffs") {
+		t.Error("reviewer should omit history and handoffs")
+	}
+	if r.Omits(Reviewer, "epic") || r.Omits(Coder, "history") || r.Omits("nobody", "history") {
+		t.Error("only the listed sections of the listed role are omitted")
+	}
+}
+
+func TestInstructions_VerdictRoleGetsFormat(t *testing.T) {
+	r := NewRegistry(map[string]config.RoleDef{
+		"security": {Instructions: "Check for injection bugs.", Stage: "after_code", Verdict: true},
+	})
+
+	got := r.Instructions("security")
+	if !strings.Contains(got, "Check for injection bugs.") {
+		t.Error("missing custom instructions")
+	}
+	if !strings.Contains(got, "VERDICT: APPROVE") {
+		t.Error("verdict role missing VERDICT format")
+	}
+}
+
+func TestByStage_SortedCustomOnly(t *testing.T) {
+	r := NewRegistry(map[string]config.RoleDef{
+		"zeta":      {Stage: "after_code"},
+		"alpha":     {Stage: "after_code"},
+		"changelog": {Stage: "after_review"},
+		"notes":     {},
+	})
+
+	got := r.ByStage(StageAfterCode)
+	if len(got) != 2 || got[0].Name != "alpha" || got[1].Name != "zeta" {
+		t.Fatalf("unexpected after_code roles: %+v", got)
+	}
+	if len(r.ByStage(StageAfterReview)) != 1 {
+		t.Error("expected one after_review role")
+	}
+	if len(r.ByStage(StageNone)) != 0 {
+		t.Error("StageNone should never match")
+	}
+}
+
+func TestNewRegistry_Output(t *testing.T) {
+	r := NewRegistry(map[string]config.RoleDef{
+		Architect:    {Output: "SPEC:\n- one bullet per file"},
+		"docs-audit": {Instructions: "Check the docs match the code.", Output: "FINDINGS:\n- file: problem"},
+	})
+
+	got := r.Instructions(Architect)
+	if !strings.Contains(got, "## Your Process") {
+		t.Error("the architect's process should be kept")
+	}
+	if strings.Count(got, "## Response Format") != 1 || !strings.HasSuffix(got, "## Response Format\nSPEC:\n- one bullet per file") {
+		t.Errorf("the response format should be replaced:\n%s", got)
+	}
+
+	want := "Check the docs match the code.\n\n## Response Format\nFINDINGS:\n- file: problem"
+	if got := r.Instructions("docs-audit"); got != want {
+		t.Errorf("custom role instructions:\n%s\nwant:\n%s", got, want)
+	}
+}
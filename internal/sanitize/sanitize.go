@@ -0,0 +1,203 @@
+// Package sanitize cleans up agent output before hive stores it. CLI
+// agents write for a terminal: color codes, spinners redrawn with carriage
+// returns, and long traces of tool calls. Left in, they pollute artifacts,
+// event previews and the prompts built from them.
+//
+// Cleaning is on with the defaults until Init configures it. Every agent
+// response passes through Output.
+package sanitize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/redact"
+)
+
+// DefaultToolLogPatterns match the chatter CLI agents print while they
+// work. They are matched against lines with leading space trimmed.
+var DefaultToolLogPatterns = []string{
+	// Braille spinners: ⠋ Thinking...
+	`^[\x{2800}-\x{28FF}]`,
+	// Tool calls and their results: ⏺ Read(main.go), ⎿ Read 40 lines
+	`^[⏺●] \w+\(`,
+	`^⎿`,
+	// Generic tool traces.
+	`^(Running|Executing|Calling) (tool|command)\b`,
+	`^\[tool(_call|_result)?\]`,
+}
+
+// minRun is the shortest run of tool log lines that is collapsed; one or
+// two lines stay, they may be what the agent is talking about.
+const minRun = 3
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// Options configure a Sanitizer.
+type Options struct {
+	KeepANSI        bool     // Leave escape codes and carriage-return redraws
+	KeepToolLogs    bool     // Leave runs of tool chatter
+	ToolLogPatterns []string // Regexps for tool chatter besides DefaultToolLogPatterns
+	RawDir          string   // When set, untouched output is also saved here
+}
+
+// Sanitizer cleans agent output.
+type Sanitizer struct {
+	opts     Options
+	toolLogs []*regexp.Regexp
+}
+
+// New creates a Sanitizer. Patterns that don't compile are skipped; config
+// validation reports them.
+func New(opts Options) *Sanitizer {
+	s := &Sanitizer{opts: opts}
+	for _, p := range append(append([]string(nil), DefaultToolLogPatterns...), opts.ToolLogPatterns...) {
+		if re, err := regexp.Compile(p); err == nil {
+			s.toolLogs = append(s.toolLogs, re)
+		}
+	}
+	return s
+}
+
+// String returns the cleaned text.
+func (s *Sanitizer) String(text string) string {
+	if !s.opts.KeepANSI {
+		text = StripANSI(text)
+	}
+	if !s.opts.KeepToolLogs {
+		text = s.collapse(text)
+	}
+	return text
+}
+
+// Line cleans one line of streamed output. Tool chatter can't be collapsed
+// a line at a time, so only escape codes go.
+func (s *Sanitizer) Line(line string) string {
+	if s.opts.KeepANSI {
+		return line
+	}
+	return StripANSI(line)
+}
+
+// Output cleans an agent's output for taskID. When the Sanitizer keeps raw
+// output and cleaning changed something, the original is saved to RawDir
+// first, with secrets masked.
+func (s *Sanitizer) Output(taskID int64, agentName, output string) string {
+	clean := s.String(output)
+	if s.opts.RawDir != "" && clean != output {
+		s.saveRaw(taskID, agentName, output)
+	}
+	return clean
+}
+
+func (s *Sanitizer) saveRaw(taskID int64, agentName, output string) {
+	data := []byte(redact.String(output))
+	if disk.Check(int64(len(data))) != nil {
+		return
+	}
+	if err := os.MkdirAll(s.opts.RawDir, 0755); err != nil {
+		return
+	}
+	name := fmt.Sprintf("task-%d-%s-%s.log", taskID, agentName, time.Now().Format("20060102-150405.000"))
+	if os.WriteFile(filepath.Join(s.opts.RawDir, name), data, 0644) == nil {
+		disk.Wrote(int64(len(data)))
+	}
+}
+
+// StripANSI removes terminal escape sequences and, where a line was
+// redrawn with carriage returns (spinners, progress bars), keeps only what
+// was drawn last.
+func StripANSI(text string) string {
+	text = ansiRe.ReplaceAllString(text, "")
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapse replaces each run of at least minRun tool log lines with a note
+// saying how many lines were left out.
+func (s *Sanitizer) collapse(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		j := i
+		for j < len(lines) && s.isToolLog(lines[j]) {
+			j++
+		}
+		switch {
+		case j-i >= minRun:
+			out = append(out, fmt.Sprintf("[%d lines of tool output collapsed]", j-i))
+			i = j
+		case j > i:
+			out = append(out, lines[i:j]...)
+			i = j
+		default:
+			out = append(out, lines[i])
+			i++
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func (s *Sanitizer) isToolLog(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	for _, re := range s.toolLogs {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu      sync.RWMutex
+	current *Sanitizer
+)
+
+// Init sets the process-wide Sanitizer.
+func Init(s *Sanitizer) {
+	mu.Lock()
+	current = s
+	mu.Unlock()
+}
+
+// Default returns the process-wide Sanitizer, one with the default options
+// if Init was never called.
+func Default() *Sanitizer {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s == nil {
+		s = New(Options{})
+		Init(s)
+	}
+	return s
+}
+
+// Output cleans an agent's output with the process-wide Sanitizer.
+func Output(taskID int64, agentName, output string) string {
+	return Default().Output(taskID, agentName, output)
+}
+
+// Line cleans a line of streamed output with the process-wide Sanitizer.
+func Line(line string) string {
+	return Default().Line(line)
+}
@@ -0,0 +1,82 @@
+package sanitize
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	cases := map[string]string{
+		"\x1b[1;32m✓\x1b[0m done":            "✓ done",
+		"\x1b]0;title\x07plain":              "plain",
+		"⠋ working\r⠙ working\rfinished\r\n": "finished\n",
+		"no escapes":                         "no escapes",
+		"a\r\nb":                             "a\nb",
+	}
+	for in, want := range cases {
+		if got := StripANSI(in); got != want {
+			t.Errorf("StripANSI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCollapseToolLogs(t *testing.T) {
+	s := New(Options{ToolLogPatterns: []string{`^\[mcp\] `}})
+	in := strings.Join([]string{
+		"I'll fix the handler.",
+		"⏺ Read(handler.go)",
+		"  ⎿  Read 120 lines",
+		"⏺ Edit(handler.go)",
+		"  ⎿  Updated handler.go",
+		"Done.",
+		"⏺ Bash(go test ./...)",
+		"[mcp] github: ok",
+		"",
+		"FILES_CHANGED:",
+		"- handler.go",
+	}, "\n")
+	want := strings.Join([]string{
+		"I'll fix the handler.",
+		"[4 lines of tool output collapsed]",
+		"Done.",
+		"⏺ Bash(go test ./...)",
+		"[mcp] github: ok",
+		"",
+		"FILES_CHANGED:",
+		"- handler.go",
+	}, "\n")
+	if got := s.String(in); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	keep := New(Options{KeepToolLogs: true, KeepANSI: true})
+	if got := keep.String(in + "\x1b[0m"); got != in+"\x1b[0m" {
+		t.Errorf("keep options changed the output: %q", got)
+	}
+}
+
+func TestOutputKeepsRaw(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "raw")
+	s := New(Options{RawDir: dir})
+
+	if got := s.Output(3, "claude", "clean output"); got != "clean output" {
+		t.Fatalf("got %q", got)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("raw output saved although nothing was cleaned")
+	}
+
+	raw := "\x1b[31merror\x1b[0m fixed"
+	if got := s.Output(3, "claude", raw); got != "error fixed" {
+		t.Fatalf("got %q", got)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "task-3-claude-*.log"))
+	if len(files) != 1 {
+		t.Fatalf("expected one raw file, got %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != raw {
+		t.Errorf("raw file holds %q", data)
+	}
+}
@@ -0,0 +1,541 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Starter starts a pipeline on a task or epic in the background and
+// returns the file its output goes to.
+type Starter func(taskID int64) (logPath string, err error)
+
+// ErrRefused is wrapped by Epics errors for work that can't be merged as
+// it is, e.g. a failed pre-flight check; the API answers 409.
+var ErrRefused = errors.New("refused")
+
+// Epics diffs, accepts and rejects an epic's work, as hive epic diff,
+// accept and reject do. The API has checked that epic is an epic with a
+// safety branch. by names the caller in the epic's events.
+type Epics interface {
+	Diff(epic *store.Task) (string, error)
+	Accept(epic *store.Task, by string) error
+	Reject(epic *store.Task, by, reason string) error
+}
+
+// API is the REST API for the board: epics, tasks, their events and
+// artifacts, answering blockers, starting pipelines, reviewing epics'
+// work, and the config.
+type API struct {
+	store *store.Store
+	arts  *artifacts.Manager
+	start Starter
+	epics Epics // Nil refuses diff, accept and reject
+
+	configPath string // .hive/config.yaml; empty refuses /config
+	profile    string
+}
+
+// NewAPI returns the API over s. Without start, POST /tasks/{id}/run is
+// refused.
+func NewAPI(s *store.Store, arts *artifacts.Manager, start Starter) *API {
+	return &API{store: s, arts: arts, start: start}
+}
+
+// WithEpics serves epics' diffs, and accepting and rejecting them, with e.
+func (a *API) WithEpics(e Epics) *API {
+	a.epics = e
+	return a
+}
+
+// WithConfig lets admins read and change the config at path, with the
+// given profile's overlay, over /config.
+func (a *API) WithConfig(path, profile string) *API {
+	a.configPath, a.profile = path, profile
+	return a
+}
+
+// Register adds the API's routes to rt.
+func (a *API) Register(rt *Router) {
+	rt.HandleFunc("GET /epics", RoleRead, a.listEpics)
+	rt.HandleFunc("POST /epics", RoleOperator, a.createEpic)
+	rt.HandleFunc("GET /epics/{id}", RoleRead, a.getEpic)
+	rt.HandleFunc("GET /tasks", RoleRead, a.listTasks)
+	rt.HandleFunc("POST /tasks", RoleOperator, a.createTask)
+	rt.HandleFunc("GET /tasks/{id}", RoleRead, a.getTask)
+	rt.HandleFunc("POST /tasks/{id}/answer", RoleOperator, a.answer)
+	rt.HandleFunc("POST /tasks/{id}/run", RoleOperator, a.run)
+	rt.HandleFunc("GET /tasks/{id}/artifacts", RoleRead, a.listArtifacts)
+	rt.HandleFunc("GET /tasks/{id}/artifacts/{artifact}", RoleRead, a.getArtifact)
+	rt.HandleFunc("GET /tasks/{id}/diff", RoleRead, a.diff)
+	rt.HandleFunc("POST /tasks/{id}/accept", RoleOperator, a.accept)
+	rt.HandleFunc("POST /tasks/{id}/reject", RoleOperator, a.reject)
+	rt.HandleFunc("GET /config/{key}", RoleAdmin, a.getConfig)
+	rt.HandleFunc("PUT /config/{key}", RoleAdmin, a.setConfig)
+}
+
+// epicSummary is an epic with how many of its tasks are in each status.
+type epicSummary struct {
+	store.Task
+	Tasks map[store.TaskStatus]int `json:"tasks"`
+}
+
+func (a *API) listEpics(w http.ResponseWriter, r *http.Request) {
+	epics, err := a.store.ListEpics(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]epicSummary, 0, len(epics))
+	for _, e := range epics {
+		sum := epicSummary{Task: e, Tasks: map[store.TaskStatus]int{}}
+		tasks, err := a.store.ListTasksByEpic(e.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, t := range tasks {
+			sum.Tasks[t.Status]++
+		}
+		out = append(out, sum)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// newItem is the body of POST /epics and POST /tasks.
+type newItem struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Priority    string `json:"priority"`
+	EpicID      *int64 `json:"epic_id"` // Tasks only
+}
+
+func (a *API) createEpic(w http.ResponseWriter, r *http.Request) {
+	var body newItem
+	if !readItem(w, r, &body) {
+		return
+	}
+	epic, err := a.store.CreateEpic(body.Title, body.Description, body.Priority)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, epic)
+}
+
+func (a *API) getEpic(w http.ResponseWriter, r *http.Request) {
+	epic, ok := a.item(w, r)
+	if !ok {
+		return
+	}
+	if epic.Kind != store.KindEpic {
+		writeError(w, http.StatusNotFound, fmt.Errorf("#%d is a task, not an epic", epic.ID))
+		return
+	}
+	tasks, err := a.store.ListTasksByEpic(epic.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []store.Task{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"epic": epic, "tasks": tasks})
+}
+
+func (a *API) listTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var tasks []store.Task
+	var err error
+	if epic := q.Get("epic"); epic != "" {
+		id, perr := strconv.ParseInt(epic, 10, 64)
+		if perr != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid epic ID: %s", epic))
+			return
+		}
+		tasks, err = a.store.ListTasksByEpic(id)
+		if status := q.Get("status"); err == nil && status != "" {
+			tasks = filterStatus(tasks, store.TaskStatus(status))
+		}
+	} else {
+		tasks, err = a.store.ListOnlyTasks(q.Get("status"))
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []store.Task{}
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func filterStatus(tasks []store.Task, status store.TaskStatus) []store.Task {
+	var out []store.Task
+	for _, t := range tasks {
+		if t.Status == status {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (a *API) createTask(w http.ResponseWriter, r *http.Request) {
+	var body newItem
+	if !readItem(w, r, &body) {
+		return
+	}
+	if body.EpicID != nil {
+		if epic, err := a.store.GetTask(*body.EpicID); err != nil || epic.Kind != store.KindEpic {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("epic #%d not found", *body.EpicID))
+			return
+		}
+	}
+	task, err := a.store.CreateTask(body.Title, body.Description, body.Priority, body.EpicID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (a *API) getTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := a.item(w, r)
+	if !ok {
+		return
+	}
+	events, err := a.store.GetEvents(task.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if events == nil {
+		events = []store.Event{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"task": task, "events": events})
+}
+
+// answer resolves a blocker, as hive answer does without continuing the
+// pipeline; POST /tasks/{id}/run does that. "skip" cancels the task.
+func (a *API) answer(w http.ResponseWriter, r *http.Request) {
+	task, ok := a.item(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Answer string `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Answer) == "" {
+		writeError(w, http.StatusBadRequest, errors.New(`body must be {"answer": "..."}`))
+		return
+	}
+	if task.Status != store.StatusBlocked {
+		writeError(w, http.StatusConflict, fmt.Errorf("#%d is not blocked (status: %s)", task.ID, task.Status))
+		return
+	}
+
+	var err error
+	if strings.EqualFold(strings.TrimSpace(body.Answer), "skip") {
+		if err = a.store.UpdateTaskStatus(task.ID, store.StatusCancelled); err == nil {
+			a.store.AddEvent(task.ID, principalName(r), "cancelled", "User skipped blocked task")
+		}
+	} else {
+		err = a.store.UnblockTask(task.ID, body.Answer)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	task, _ = a.store.GetTask(task.ID)
+	writeJSON(w, http.StatusOK, task)
+}
+
+// run starts hive auto on a task or epic. One pipeline per epic at a time.
+func (a *API) run(w http.ResponseWriter, r *http.Request) {
+	task, ok := a.item(w, r)
+	if !ok {
+		return
+	}
+	if a.start == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("starting pipelines is not available"))
+		return
+	}
+	if task.Kind == store.KindEpic {
+		if active, err := a.store.GetActivePipelineRun(task.ID); err == nil && active != nil {
+			writeError(w, http.StatusConflict, fmt.Errorf("a pipeline is already running on epic #%d", task.ID))
+			return
+		}
+	}
+	logPath, err := a.start(task.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.store.AddEvent(task.ID, principalName(r), "comment", "Pipeline started over the API")
+	writeJSON(w, http.StatusAccepted, map[string]any{"task_id": task.ID, "log": logPath})
+}
+
+func (a *API) listArtifacts(w http.ResponseWriter, r *http.Request) {
+	task, ok := a.item(w, r)
+	if !ok {
+		return
+	}
+	list, err := a.store.GetArtifacts(task.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if list == nil {
+		list = []store.Artifact{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// getArtifact returns an artifact's content; {artifact} is its ID or file
+// name.
+func (a *API) getArtifact(w http.ResponseWriter, r *http.Request) {
+	task, ok := a.item(w, r)
+	if !ok {
+		return
+	}
+	list, err := a.store.GetArtifacts(task.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	want := r.PathValue("artifact")
+	for _, art := range list {
+		if strconv.FormatInt(art.ID, 10) != want && path.Base(art.FilePath) != want {
+			continue
+		}
+		data, err := os.ReadFile(a.arts.Resolve(art.FilePath))
+		if err != nil {
+			writeError(w, http.StatusNotFound, fmt.Errorf("artifact %s: file is gone", want))
+			return
+		}
+		// Agents write artifacts, so they are never served as anything a
+		// browser would run: images as images, the rest as text.
+		contentType := "text/plain; charset=utf-8"
+		switch detected := http.DetectContentType(data); {
+		case strings.HasSuffix(art.FilePath, ".md"):
+			contentType = "text/markdown; charset=utf-8"
+		case strings.HasPrefix(detected, "image/"):
+			contentType = detected
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Write(data)
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("#%d has no artifact %s", task.ID, want))
+}
+
+// diff returns the total diff of an epic's safety branch against its
+// base, as plain text.
+func (a *API) diff(w http.ResponseWriter, r *http.Request) {
+	epic, ok := a.reviewable(w, r)
+	if !ok {
+		return
+	}
+	diff, err := a.epics.Diff(epic)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write([]byte(diff))
+}
+
+// accept merges an epic's safety branch into its base, as hive epic
+// accept does. Every task must be finished and the pre-flight checks must
+// pass; there is no --force over the API.
+func (a *API) accept(w http.ResponseWriter, r *http.Request) {
+	epic, ok := a.reviewable(w, r)
+	if !ok {
+		return
+	}
+	tasks, _ := a.store.ListTasksByEpic(epic.ID)
+	var pending []string
+	for _, t := range tasks {
+		if t.Status != store.StatusDone && t.Status != store.StatusCancelled {
+			pending = append(pending, fmt.Sprintf("#%d (%s)", t.ID, t.Status))
+		}
+	}
+	if len(pending) > 0 {
+		writeError(w, http.StatusConflict, fmt.Errorf("%d task(s) not finished: %s", len(pending), strings.Join(pending, ", ")))
+		return
+	}
+	if err := a.epics.Accept(epic, principalName(r)); err != nil {
+		writeError(w, reviewStatus(err), err)
+		return
+	}
+	epic, _ = a.store.GetTask(epic.ID)
+	writeJSON(w, http.StatusOK, epic)
+}
+
+// reject discards an epic's work, as hive epic reject does. The body may
+// give a reason {"reason"}.
+func (a *API) reject(w http.ResponseWriter, r *http.Request) {
+	epic, ok := a.reviewable(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %w", err))
+		return
+	}
+	if err := a.epics.Reject(epic, principalName(r), strings.TrimSpace(body.Reason)); err != nil {
+		writeError(w, reviewStatus(err), err)
+		return
+	}
+	epic, _ = a.store.GetTask(epic.ID)
+	writeJSON(w, http.StatusOK, epic)
+}
+
+// reviewable loads the epic named by {id} for diff, accept or reject,
+// answering itself when it isn't one with work to review, or a pipeline
+// is still working on it.
+func (a *API) reviewable(w http.ResponseWriter, r *http.Request) (*store.Task, bool) {
+	if a.epics == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("reviewing epics is not available"))
+		return nil, false
+	}
+	epic, ok := a.item(w, r)
+	if !ok {
+		return nil, false
+	}
+	switch {
+	case epic.Kind != store.KindEpic:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("#%d is a task, not an epic", epic.ID))
+		return nil, false
+	case epic.GitBranch == "":
+		writeError(w, http.StatusConflict, fmt.Errorf("epic #%d has no safety branch", epic.ID))
+		return nil, false
+	}
+	if r.Method != http.MethodGet {
+		if active, err := a.store.GetActivePipelineRun(epic.ID); err == nil && active != nil {
+			writeError(w, http.StatusConflict, fmt.Errorf("a pipeline is still running on epic #%d", epic.ID))
+			return nil, false
+		}
+	}
+	return epic, true
+}
+
+// reviewStatus is the status code for an Epics error.
+func reviewStatus(err error) int {
+	if errors.Is(err, ErrRefused) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// getConfig returns the effective value of a dotted config key, as hive
+// config get does.
+func (a *API) getConfig(w http.ResponseWriter, r *http.Request) {
+	if a.configPath == "" {
+		writeError(w, http.StatusNotImplemented, errors.New("the config is not available"))
+		return
+	}
+	key := r.PathValue("key")
+	value, ok, err := config.Get(a.configPath, a.profile, key)
+	switch {
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+	case !ok:
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s is not set", key))
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{"key": key, "value": value})
+	}
+}
+
+// setConfig sets a dotted key in .hive/config.yaml, as hive config set
+// does: {"value"} is parsed as YAML, and a change that would make the
+// config invalid is refused. A running hive auto picks it up between
+// tasks.
+func (a *API) setConfig(w http.ResponseWriter, r *http.Request) {
+	if a.configPath == "" {
+		writeError(w, http.StatusNotImplemented, errors.New("the config is not available"))
+		return
+	}
+	var body struct {
+		Value *string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Value == nil {
+		writeError(w, http.StatusBadRequest, errors.New(`body must be {"value": "..."}`))
+		return
+	}
+	key := r.PathValue("key")
+	if err := config.Set(a.configPath, a.profile, a.configPath, key, *body.Value); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"key": key, "value": *body.Value})
+}
+
+// item loads the task or epic named by the {id} path value, answering 400
+// or 404 itself when it can't.
+func (a *API) item(w http.ResponseWriter, r *http.Request) (*store.Task, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid ID: %s", r.PathValue("id")))
+		return nil, false
+	}
+	task, err := a.store.GetTask(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("#%d not found", id))
+		return nil, false
+	}
+	return task, true
+}
+
+// readItem decodes the body of a create request.
+func readItem(w http.ResponseWriter, r *http.Request, body *newItem) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %w", err))
+		return false
+	}
+	if strings.TrimSpace(body.Title) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("title is required"))
+		return false
+	}
+	switch body.Priority {
+	case "", "high", "medium", "low":
+		return true
+	}
+	writeError(w, http.StatusBadRequest, fmt.Errorf("priority must be high, medium or low, got %q", body.Priority))
+	return false
+}
+
+// principalName names the caller in events.
+func principalName(r *http.Request) string {
+	if p, ok := PrincipalFrom(r.Context()); ok {
+		return p.Name
+	}
+	return "api"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,340 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/store"
+)
+
+func testAPI(t *testing.T, start Starter) (*Router, *store.Store, *artifacts.Manager) {
+	t.Helper()
+	root := t.TempDir()
+	s, err := store.New(filepath.Join(root, "hive.db"))
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	arts := artifacts.New(s, root)
+
+	auth, _ := NewAuthorizer(config.Serve{})
+	rt := NewRouter(auth)
+	NewAPI(s, arts, start).Register(rt)
+	return rt, s, arts
+}
+
+// call sends a request from localhost and decodes the JSON answer into out.
+func call(t *testing.T, rt *Router, method, path, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr, req.Host = "127.0.0.1:5000", "localhost:4000"
+	if method != "GET" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAPI_EpicsAndTasks(t *testing.T) {
+	rt, _, _ := testAPI(t, nil)
+
+	var epic store.Task
+	if code := call(t, rt, "POST", "/epics", `{"title": "Auth", "description": "Login", "priority": "high"}`, &epic); code != http.StatusCreated {
+		t.Fatalf("create epic: %d", code)
+	}
+	var task store.Task
+	body := `{"title": "Login form", "epic_id": ` + strconv.FormatInt(epic.ID, 10) + `}`
+	if code := call(t, rt, "POST", "/tasks", body, &task); code != http.StatusCreated || task.ParentID == nil || *task.ParentID != epic.ID {
+		t.Fatalf("create task: %d %+v", code, task)
+	}
+
+	var errBody map[string]string
+	if code := call(t, rt, "POST", "/tasks", `{"title": ""}`, &errBody); code != http.StatusBadRequest || errBody["error"] == "" {
+		t.Errorf("empty title: %d %v", code, errBody)
+	}
+	if code := call(t, rt, "POST", "/tasks", `{"title": "x", "epic_id": 999}`, nil); code != http.StatusBadRequest {
+		t.Errorf("unknown epic: %d", code)
+	}
+	if code := call(t, rt, "POST", "/epics", `{"title": "x", "priority": "urgent"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("bad priority: %d", code)
+	}
+
+	var epics []epicSummary
+	call(t, rt, "GET", "/epics", "", &epics)
+	if len(epics) != 1 || epics[0].Title != "Auth" || epics[0].Tasks[store.StatusBacklog] != 1 {
+		t.Errorf("list epics: %+v", epics)
+	}
+
+	var detail struct {
+		Epic  store.Task   `json:"epic"`
+		Tasks []store.Task `json:"tasks"`
+	}
+	call(t, rt, "GET", "/epics/"+strconv.FormatInt(epic.ID, 10), "", &detail)
+	if detail.Epic.ID != epic.ID || len(detail.Tasks) != 1 {
+		t.Errorf("get epic: %+v", detail)
+	}
+	if code := call(t, rt, "GET", "/epics/"+strconv.FormatInt(task.ID, 10), "", nil); code != http.StatusNotFound {
+		t.Errorf("a task is not an epic: %d", code)
+	}
+
+	var tasks []store.Task
+	call(t, rt, "GET", "/tasks?epic="+strconv.FormatInt(epic.ID, 10)+"&status=backlog", "", &tasks)
+	if len(tasks) != 1 {
+		t.Errorf("list tasks: %+v", tasks)
+	}
+	call(t, rt, "GET", "/tasks?status=done", "", &tasks)
+	if len(tasks) != 0 {
+		t.Errorf("no task is done: %+v", tasks)
+	}
+
+	var withEvents struct {
+		Task   store.Task    `json:"task"`
+		Events []store.Event `json:"events"`
+	}
+	call(t, rt, "GET", "/tasks/"+strconv.FormatInt(task.ID, 10), "", &withEvents)
+	if withEvents.Task.Title != "Login form" || len(withEvents.Events) == 0 || withEvents.Events[0].Type != "created" {
+		t.Errorf("get task: %+v", withEvents)
+	}
+	if code := call(t, rt, "GET", "/tasks/999", "", nil); code != http.StatusNotFound {
+		t.Errorf("missing task: %d", code)
+	}
+}
+
+func TestAPI_AnswerAndRun(t *testing.T) {
+	var started []int64
+	rt, s, _ := testAPI(t, func(id int64) (string, error) {
+		started = append(started, id)
+		return "/tmp/run.log", nil
+	})
+	task, _ := s.CreateTask("Login form", "", "", nil)
+
+	if code := call(t, rt, "POST", "/tasks/1/answer", `{"answer": "use JWT"}`, nil); code != http.StatusConflict {
+		t.Errorf("answering an unblocked task: %d", code)
+	}
+	s.BlockTask(task.ID, "Which auth scheme?")
+	if code := call(t, rt, "POST", "/tasks/1/answer", `{}`, nil); code != http.StatusBadRequest {
+		t.Errorf("empty answer: %d", code)
+	}
+	var answered store.Task
+	if code := call(t, rt, "POST", "/tasks/1/answer", `{"answer": "use JWT"}`, &answered); code != http.StatusOK || answered.Status != store.StatusBacklog {
+		t.Errorf("answer: %d %+v", code, answered)
+	}
+
+	s.BlockTask(task.ID, "Still there?")
+	call(t, rt, "POST", "/tasks/1/answer", `{"answer": "skip"}`, &answered)
+	if answered.Status != store.StatusCancelled {
+		t.Errorf("skip should cancel, got %s", answered.Status)
+	}
+
+	var run map[string]any
+	if code := call(t, rt, "POST", "/tasks/1/run", "", &run); code != http.StatusAccepted || run["log"] != "/tmp/run.log" {
+		t.Errorf("run: %d %v", code, run)
+	}
+	if len(started) != 1 || started[0] != task.ID {
+		t.Errorf("started %v", started)
+	}
+
+	epic, _ := s.CreateEpic("Auth", "", "")
+	s.StartPipelineRun(epic.ID, 3, 1)
+	if code := call(t, rt, "POST", "/tasks/"+strconv.FormatInt(epic.ID, 10)+"/run", "", nil); code != http.StatusConflict {
+		t.Errorf("second pipeline on an epic: %d", code)
+	}
+}
+
+func TestAPI_RunUnavailable(t *testing.T) {
+	rt, s, _ := testAPI(t, nil)
+	s.CreateTask("Login form", "", "", nil)
+	if code := call(t, rt, "POST", "/tasks/1/run", "", nil); code != http.StatusNotImplemented {
+		t.Errorf("run without a starter: %d", code)
+	}
+}
+
+func TestAPI_Artifacts(t *testing.T) {
+	rt, s, arts := testAPI(t, nil)
+	task, _ := s.CreateTask("Login form", "", "", nil)
+	arts.Save(task.ID, "review", artifacts.Name(task.ID, "review"), "VERDICT: APPROVE\n")
+
+	var list []store.Artifact
+	call(t, rt, "GET", "/tasks/1/artifacts", "", &list)
+	if len(list) != 1 || list[0].Type != "review" {
+		t.Fatalf("list artifacts: %+v", list)
+	}
+
+	for _, ref := range []string{strconv.FormatInt(list[0].ID, 10), "task-1-review.md"} {
+		req := httptest.NewRequest("GET", "/tasks/1/artifacts/"+ref, nil)
+		req.RemoteAddr, req.Host = "127.0.0.1:5000", "localhost:4000"
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "VERDICT: APPROVE\n" || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/markdown") {
+			t.Errorf("artifact %s: %d %q %s", ref, rec.Code, rec.Body.String(), rec.Header().Get("Content-Type"))
+		}
+	}
+
+	arts.Save(task.ID, "test", "task-1-report.html", "<html><script>alert(1)</script></html>")
+	req := httptest.NewRequest("GET", "/tasks/1/artifacts/task-1-report.html", nil)
+	req.RemoteAddr, req.Host = "127.0.0.1:5000", "localhost:4000"
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("HTML artifact served as %q, nosniff %q", ct, rec.Header().Get("X-Content-Type-Options"))
+	}
+	if code := call(t, rt, "GET", "/tasks/1/artifacts/nope.md", "", nil); code != http.StatusNotFound {
+		t.Errorf("missing artifact: %d", code)
+	}
+}
+
+func TestAPI_RolesApply(t *testing.T) {
+	t.Setenv("TEST_READ_TOKEN", "r-secret")
+	s, _ := store.New(filepath.Join(t.TempDir(), "hive.db"))
+	defer s.Close()
+	auth, _ := NewAuthorizer(config.Serve{Tokens: []config.ServeToken{{Name: "dashboard", Role: "read", TokenEnv: "TEST_READ_TOKEN"}}})
+	rt := NewRouter(auth)
+	NewAPI(s, artifacts.New(s, t.TempDir()), nil).Register(rt)
+
+	if rec := do(rt, "GET", "/epics", "r-secret"); rec.Code != http.StatusOK {
+		t.Errorf("read token listing epics: %d", rec.Code)
+	}
+	if rec := do(rt, "POST", "/epics", "r-secret"); rec.Code != http.StatusForbidden {
+		t.Errorf("read token creating an epic: %d", rec.Code)
+	}
+}
+
+func TestAPI_ConfigNeedsAdmin(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("TEST_OP_TOKEN", "o-secret")
+	t.Setenv("TEST_ADMIN_TOKEN", "a-secret")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("version: 1\nagents:\n  claude:\n    role: coder\n    mode: cli\n    cmd: claude\n"), 0644)
+
+	s, _ := store.New(filepath.Join(t.TempDir(), "hive.db"))
+	defer s.Close()
+	auth, _ := NewAuthorizer(config.Serve{Tokens: []config.ServeToken{
+		{Name: "bot", Role: "operator", TokenEnv: "TEST_OP_TOKEN"},
+		{Name: "me", Role: "admin", TokenEnv: "TEST_ADMIN_TOKEN"},
+	}})
+	rt := NewRouter(auth)
+	NewAPI(s, artifacts.New(s, t.TempDir()), nil).WithConfig(path, "").Register(rt)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, method := range []string{"GET", "PUT"} {
+		if rec := send(method, "/config/agents.claude.timeout_sec", "o-secret", `{"value": "600"}`); rec.Code != http.StatusForbidden {
+			t.Errorf("operator token, %s /config: %d", method, rec.Code)
+		}
+	}
+	if rec := send("PUT", "/config/agents.claude.timeout_sec", "a-secret", `{"value": "600"}`); rec.Code != http.StatusOK {
+		t.Fatalf("admin setting the config: %d %s", rec.Code, rec.Body)
+	}
+	if rec := send("GET", "/config/agents.claude.timeout_sec", "a-secret", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"600"`) {
+		t.Errorf("admin reading the config: %d %s", rec.Code, rec.Body)
+	}
+	if rec := send("PUT", "/config/agents.claude.mode", "a-secret", `{"value": "telepathy"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid value: %d %s", rec.Code, rec.Body)
+	}
+	if rec := send("GET", "/config/metrics.listen", "a-secret", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unset key: %d", rec.Code)
+	}
+}
+
+// fakeEpics records what the API asked of it.
+type fakeEpics struct {
+	accepted, rejected []string
+	acceptErr          error
+}
+
+func (f *fakeEpics) Diff(epic *store.Task) (string, error) {
+	return "diff --git a/x b/x\n+<script>\n", nil
+}
+
+func (f *fakeEpics) Accept(epic *store.Task, by string) error {
+	if f.acceptErr != nil {
+		return f.acceptErr
+	}
+	f.accepted = append(f.accepted, by)
+	return nil
+}
+
+func (f *fakeEpics) Reject(epic *store.Task, by, reason string) error {
+	f.rejected = append(f.rejected, by+": "+reason)
+	return nil
+}
+
+func TestAPI_DiffAcceptReject(t *testing.T) {
+	t.Setenv("TEST_READ_TOKEN", "r-secret")
+	t.Setenv("TEST_OP_TOKEN", "o-secret")
+	s, _ := store.New(filepath.Join(t.TempDir(), "hive.db"))
+	defer s.Close()
+	auth, _ := NewAuthorizer(config.Serve{Tokens: []config.ServeToken{
+		{Name: "dashboard", Role: "read", TokenEnv: "TEST_READ_TOKEN"},
+		{Name: "bot", Role: "operator", TokenEnv: "TEST_OP_TOKEN"},
+	}})
+	epics := &fakeEpics{}
+	rt := NewRouter(auth)
+	NewAPI(s, artifacts.New(s, t.TempDir()), nil).WithEpics(epics).Register(rt)
+
+	epic, _ := s.CreateEpic("Auth", "", "")
+	s.SetGitBranch(epic.ID, "hive/epic-1")
+	task, _ := s.CreateTask("Login form", "", "", &epic.ID)
+	path := "/tasks/" + strconv.FormatInt(epic.ID, 10)
+
+	rec := do(rt, "GET", path+"/diff", "r-secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "+<script>") ||
+		rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("diff: %d %q %v", rec.Code, rec.Body, rec.Header())
+	}
+	if rec := do(rt, "GET", "/tasks/"+strconv.FormatInt(task.ID, 10)+"/diff", "r-secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("diff of a task: %d", rec.Code)
+	}
+	if rec := do(rt, "POST", path+"/accept", "r-secret"); rec.Code != http.StatusForbidden {
+		t.Errorf("read token accepting: %d", rec.Code)
+	}
+	if rec := do(rt, "POST", path+"/accept", "o-secret"); rec.Code != http.StatusConflict || len(epics.accepted) != 0 {
+		t.Errorf("accepting with an unfinished task: %d %s", rec.Code, rec.Body)
+	}
+
+	s.UpdateTaskStatus(task.ID, store.StatusDone)
+	epics.acceptErr = fmt.Errorf("%w: pre-flight check tests failed", ErrRefused)
+	if rec := do(rt, "POST", path+"/accept", "o-secret"); rec.Code != http.StatusConflict {
+		t.Errorf("failed pre-flight: %d", rec.Code)
+	}
+	epics.acceptErr = nil
+	if rec := do(rt, "POST", path+"/accept", "o-secret"); rec.Code != http.StatusOK || len(epics.accepted) != 1 || epics.accepted[0] != "bot" {
+		t.Errorf("accept: %d %s %v", rec.Code, rec.Body, epics.accepted)
+	}
+
+	req := httptest.NewRequest("POST", path+"/reject", strings.NewReader(`{"reason": "wrong approach"}`))
+	req.Header.Set("Authorization", "Bearer o-secret")
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || len(epics.rejected) != 1 || epics.rejected[0] != "bot: wrong approach" {
+		t.Errorf("reject: %d %s %v", rec.Code, rec.Body, epics.rejected)
+	}
+
+	s.StartPipelineRun(epic.ID, 3, 1)
+	if rec := do(rt, "POST", path+"/reject", "o-secret"); rec.Code != http.StatusConflict {
+		t.Errorf("rejecting while a pipeline runs: %d", rec.Code)
+	}
+}
@@ -0,0 +1,183 @@
+// Package serve exposes hive over HTTP.
+package serve
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+// Role is what a token may do. Each role includes the ones below it.
+type Role int
+
+const (
+	RoleRead     Role = iota + 1 // View the board, events, diffs and artifacts
+	RoleOperator                 // Answer blockers, run pipelines, accept and reject
+	RoleAdmin                    // Read and change the config
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleRead:
+		return "read"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+}
+
+// ParseRole parses a role name from config.
+func ParseRole(s string) (Role, error) {
+	for r := RoleRead; r <= RoleAdmin; r++ {
+		if r.String() == s {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q (want one of %v)", s, config.ServeRoles)
+}
+
+// Principal is the caller a request was authenticated as.
+type Principal struct {
+	Name string
+	Role Role
+}
+
+type principalKey struct{}
+
+// PrincipalFrom returns the caller of a request that passed Require.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Authorizer checks bearer tokens against the configured ones.
+type Authorizer struct {
+	tokens []token
+}
+
+type token struct {
+	hash [sha256.Size]byte
+	Principal
+}
+
+// NewAuthorizer reads the secret of every configured token from its
+// environment variable. A token whose variable is unset is an error, so a
+// typo can't silently lock someone out or leave an endpoint open.
+func NewAuthorizer(cfg config.Serve) (*Authorizer, error) {
+	a := &Authorizer{}
+	for _, t := range cfg.Tokens {
+		role, err := ParseRole(t.Role)
+		if err != nil {
+			return nil, fmt.Errorf("serve token %q: %w", t.Name, err)
+		}
+		secret := os.Getenv(t.TokenEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("serve token %q: $%s is not set", t.Name, t.TokenEnv)
+		}
+		a.tokens = append(a.tokens, token{
+			hash:      sha256.Sum256([]byte(secret)),
+			Principal: Principal{Name: t.Name, Role: role},
+		})
+	}
+	return a, nil
+}
+
+// Open reports whether no tokens are configured. An open authorizer only
+// admits requests from localhost, as admin.
+func (a *Authorizer) Open() bool {
+	return len(a.tokens) == 0
+}
+
+// authenticate returns the caller of r.
+func (a *Authorizer) authenticate(r *http.Request) (Principal, bool) {
+	if a.Open() {
+		if isLoopback(r.RemoteAddr) {
+			return Principal{Name: "local", Role: RoleAdmin}, true
+		}
+		return Principal{}, false
+	}
+
+	secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || secret == "" {
+		return Principal{}, false
+	}
+	// Compare fixed-size hashes so neither the match nor the token length
+	// shows in the timing.
+	hash := sha256.Sum256([]byte(secret))
+	var found Principal
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(hash[:], t.hash[:]) == 1 {
+			found = t.Principal
+		}
+	}
+	return found, found.Role != 0
+}
+
+// Require wraps h so it only runs for callers with at least role. Others
+// get 401 (no or unknown token) or 403 (role too low).
+func (a *Authorizer) Require(role Role, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := a.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="hive"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if p.Role < role {
+			http.Error(w, fmt.Sprintf("forbidden: %s role required", role), http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Router is a ServeMux whose routes each declare the role they need.
+// Requests a browser page may have forged are refused first (see guard).
+type Router struct {
+	mux   *http.ServeMux
+	auth  *Authorizer
+	hosts []string // Host names besides localhost an open server answers
+}
+
+// NewRouter returns an empty router checking callers with auth.
+func NewRouter(auth *Authorizer) *Router {
+	return &Router{mux: http.NewServeMux(), auth: auth}
+}
+
+// Handle registers h for pattern (ServeMux syntax, e.g. "POST /tasks/{id}/answer")
+// for callers with at least role.
+func (rt *Router) Handle(pattern string, role Role, h http.Handler) {
+	rt.mux.Handle(pattern, rt.auth.Require(role, h))
+}
+
+// HandleFunc is Handle for a function.
+func (rt *Router) HandleFunc(pattern string, role Role, h func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(pattern, role, http.HandlerFunc(h))
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := rt.guard(r); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	rt.mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,170 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func testRouter(t *testing.T) *Router {
+	t.Helper()
+	t.Setenv("TEST_READ_TOKEN", "r-secret")
+	t.Setenv("TEST_OP_TOKEN", "o-secret")
+	t.Setenv("TEST_ADMIN_TOKEN", "a-secret")
+	auth, err := NewAuthorizer(config.Serve{Tokens: []config.ServeToken{
+		{Name: "dashboard", Role: "read", TokenEnv: "TEST_READ_TOKEN"},
+		{Name: "bot", Role: "operator", TokenEnv: "TEST_OP_TOKEN"},
+		{Name: "me", Role: "admin", TokenEnv: "TEST_ADMIN_TOKEN"},
+	}})
+	if err != nil {
+		t.Fatalf("NewAuthorizer: %v", err)
+	}
+
+	rt := NewRouter(auth)
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		p, _ := PrincipalFrom(r.Context())
+		w.Write([]byte(p.Name))
+	}
+	rt.HandleFunc("GET /board", RoleRead, ok)
+	rt.HandleFunc("POST /tasks/{id}/answer", RoleOperator, ok)
+	rt.HandleFunc("DELETE /tasks/{id}", RoleAdmin, ok)
+	return rt
+}
+
+func do(rt *Router, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if method != "GET" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequire_Roles(t *testing.T) {
+	rt := testRouter(t)
+
+	cases := []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/board", "", http.StatusUnauthorized},
+		{"GET", "/board", "wrong", http.StatusUnauthorized},
+		{"GET", "/board", "r-secret", http.StatusOK},
+		{"POST", "/tasks/1/answer", "r-secret", http.StatusForbidden},
+		{"POST", "/tasks/1/answer", "o-secret", http.StatusOK},
+		{"DELETE", "/tasks/1", "o-secret", http.StatusForbidden},
+		{"DELETE", "/tasks/1", "a-secret", http.StatusOK},
+		{"GET", "/board", "a-secret", http.StatusOK},
+	}
+	for _, c := range cases {
+		rec := do(rt, c.method, c.path, c.token)
+		if rec.Code != c.want {
+			t.Errorf("%s %s with %q: expected %d, got %d", c.method, c.path, c.token, c.want, rec.Code)
+		}
+	}
+}
+
+func TestRequire_SetsPrincipal(t *testing.T) {
+	rt := testRouter(t)
+	rec := do(rt, "POST", "/tasks/1/answer", "o-secret")
+	if rec.Body.String() != "bot" {
+		t.Errorf("expected principal bot, got %q", rec.Body.String())
+	}
+}
+
+func TestOpen_LocalhostOnly(t *testing.T) {
+	auth, err := NewAuthorizer(config.Serve{})
+	if err != nil {
+		t.Fatalf("NewAuthorizer: %v", err)
+	}
+	h := auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for addr, want := range map[string]int{
+		"127.0.0.1:4000":   http.StatusOK,
+		"[::1]:4000":       http.StatusOK,
+		"203.0.113.7:4000": http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", addr, want, rec.Code)
+		}
+	}
+}
+
+func TestNewAuthorizer_MissingEnv(t *testing.T) {
+	_, err := NewAuthorizer(config.Serve{Tokens: []config.ServeToken{
+		{Name: "ci", Role: "read", TokenEnv: "TEST_UNSET_TOKEN"},
+	}})
+	if err == nil {
+		t.Fatal("expected an error for an unset token variable")
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	for _, name := range config.ServeRoles {
+		r, err := ParseRole(name)
+		if err != nil || r.String() != name {
+			t.Errorf("ParseRole(%q) = %v, %v", name, r, err)
+		}
+	}
+	if _, err := ParseRole("root"); err == nil {
+		t.Error("expected an error for an unknown role")
+	}
+}
+
+func TestGuard_BrowserRequests(t *testing.T) {
+	auth, err := NewAuthorizer(config.Serve{})
+	if err != nil {
+		t.Fatalf("NewAuthorizer: %v", err)
+	}
+	rt := NewRouter(auth)
+	rt.AllowHost("hive.lan:7070")
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	rt.HandleFunc("GET /board", RoleRead, ok)
+	rt.HandleFunc("POST /tasks/{id}/run", RoleOperator, ok)
+
+	cases := []struct {
+		name, method, host, origin, contentType string
+		want                                    int
+	}{
+		{"localhost", "GET", "localhost:7070", "", "", http.StatusOK},
+		{"loopback IP", "GET", "[::1]:7070", "", "", http.StatusOK},
+		{"allowed host", "GET", "hive.lan:7070", "", "", http.StatusOK},
+		{"rebound host", "GET", "evil.example:7070", "", "", http.StatusMisdirectedRequest},
+		{"same origin", "POST", "localhost:7070", "http://localhost:7070", "application/json", http.StatusOK},
+		{"cross origin", "GET", "localhost:7070", "https://evil.example", "", http.StatusForbidden},
+		{"null origin", "POST", "localhost:7070", "null", "application/json", http.StatusForbidden},
+		{"JSON with charset", "POST", "localhost:7070", "", "application/json; charset=utf-8", http.StatusOK},
+		{"form post", "POST", "localhost:7070", "", "text/plain", http.StatusUnsupportedMediaType},
+		{"no content type", "POST", "localhost:7070", "", "", http.StatusUnsupportedMediaType},
+	}
+	for _, c := range cases {
+		path := "/board"
+		if c.method == "POST" {
+			path = "/tasks/1/run"
+		}
+		req := httptest.NewRequest(c.method, path, nil)
+		req.RemoteAddr, req.Host = "127.0.0.1:5000", c.host
+		if c.origin != "" {
+			req.Header.Set("Origin", c.origin)
+		}
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: expected %d, got %d", c.name, c.want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package serve
+
+import (
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Browsers let any web page send requests to localhost, so a few checks
+// come before authentication: without them a page the user opens could
+// start pipelines through an open (token-less) server, or read the board
+// after pointing its own host name at 127.0.0.1 (DNS rebinding).
+//
+//   - An open server only answers requests addressed to its listen
+//     address or to localhost. With tokens, proxies may use other names.
+//   - A request from a browser page on another origin is refused.
+//   - Requests that change things must be JSON, which a page can't send
+//     cross-site without the server agreeing to CORS first.
+
+// AllowHost adds a host name requests to an open server may be addressed
+// to, e.g. the listen address. localhost, 127.0.0.1 and ::1 always are.
+func (rt *Router) AllowHost(host string) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	rt.hosts = append(rt.hosts, strings.ToLower(strings.Trim(host, "[]")))
+}
+
+// guard returns why r is refused, with its status code, or 0.
+func (rt *Router) guard(r *http.Request) (int, error) {
+	if rt.auth.Open() && !rt.hostAllowed(r.Host) {
+		return http.StatusMisdirectedRequest, errors.New("unknown host " + r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+		return http.StatusForbidden, errors.New("cross-origin requests are not allowed")
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+			return http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json")
+		}
+	}
+	return 0, nil
+}
+
+func (rt *Router) hostAllowed(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, h := range rt.hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin reports whether an Origin header names the host the request
+// was sent to.
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
@@ -0,0 +1,180 @@
+// Package simulate runs the auto pipeline against canned agent outcomes
+// from a scenario file, so dependency ordering, parallel limits, gates and
+// hooks can be checked in seconds, without any model.
+package simulate
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/roles"
+)
+
+// Outcomes of one fix iteration.
+const (
+	Success = "success" // The coder finishes, the reviewer approves
+	Reject  = "reject"  // The reviewer rejects, with a new objection
+	Repeat  = "repeat"  // The reviewer rejects with the same objection as last time
+	Block   = "block"   // The coder asks a question
+	Fail    = "fail"    // The coder exits non-zero
+)
+
+var outcomes = []string{Success, Reject, Repeat, Block, Fail}
+
+// Scenario says how every simulated call goes.
+//
+//	default: success
+//	plan:
+//	  - title: Add login form
+//	    priority: high
+//	  - title: Wire the API
+//	tasks:
+//	  login form: [reject, success]       # by title (substring, case-insensitive)
+//	  "#12": [block]                      # or by ID
+type Scenario struct {
+	Default string              `yaml:"default"` // Outcome of iterations not listed (default success)
+	Plan    []PlannedTask       `yaml:"plan"`    // Subtasks the PM plans (default one)
+	Tasks   map[string][]string `yaml:"tasks"`   // Outcome per fix iteration, by task title or #id
+}
+
+// PlannedTask is a subtask the simulated PM plans.
+type PlannedTask struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Priority    string `yaml:"priority"`
+}
+
+// Load reads and checks a scenario file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+// Validate checks the outcomes and the plan.
+func (sc *Scenario) Validate() error {
+	if sc.Default != "" && !validOutcome(sc.Default) {
+		return fmt.Errorf("default: unknown outcome %q (want one of %v)", sc.Default, outcomes)
+	}
+	for key, list := range sc.Tasks {
+		for i, o := range list {
+			if !validOutcome(o) {
+				return fmt.Errorf("tasks %q iteration %d: unknown outcome %q (want one of %v)", key, i+1, o, outcomes)
+			}
+		}
+	}
+	for i, t := range sc.Plan {
+		if strings.TrimSpace(t.Title) == "" {
+			return fmt.Errorf("plan item %d: title is required", i+1)
+		}
+	}
+	return nil
+}
+
+func validOutcome(o string) bool {
+	for _, v := range outcomes {
+		if o == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Outcome returns the outcome of the n-th fix iteration of a task.
+func (sc *Scenario) Outcome(taskID int64, title string, n int) string {
+	list, ok := sc.Tasks["#"+strconv.FormatInt(taskID, 10)]
+	if !ok {
+		// The longest matching key wins, so "login" and "login form" can
+		// both be listed.
+		lower, best := strings.ToLower(title), ""
+		for key, l := range sc.Tasks {
+			if strings.HasPrefix(key, "#") || key == "" || !strings.Contains(lower, strings.ToLower(key)) {
+				continue
+			}
+			if len(key) > len(best) || (len(key) == len(best) && key < best) {
+				list, ok, best = l, true, key
+			}
+		}
+	}
+	if ok && n <= len(list) {
+		return list[n-1]
+	}
+	if sc.Default != "" {
+		return sc.Default
+	}
+	return Success
+}
+
+// Agents returns the simulation to install with agent.SetSimulation.
+// titleOf looks up a task's title, for scenarios keyed by title.
+func (sc *Scenario) Agents(titleOf func(taskID int64) string) agent.Simulation {
+	return func(taskID int64, role string, n int) (string, bool) {
+		switch role {
+		case roles.PM:
+			return sc.planAnswer(), true
+		case roles.Coder:
+			switch sc.Outcome(taskID, titleOf(taskID), n) {
+			case Block:
+				return fmt.Sprintf("BLOCKED: simulated question on iteration %d — which approach should I take?", n), true
+			case Fail:
+				return "=== exit: 1\nsimulated failure", true
+			}
+			return "", false // The fake coder's default edit
+		case roles.Reviewer:
+			switch sc.Outcome(taskID, titleOf(taskID), n) {
+			case Reject:
+				return "VERDICT: REJECT\n- " + objections[(n-1)%len(objections)], true
+			case Repeat:
+				return "VERDICT: REJECT\n- " + objections[max(n-2, 0)%len(objections)], true
+			}
+			return "VERDICT: APPROVE", true
+		}
+		// Tester, docs and custom roles pass.
+		return "Simulated " + role + ": done.\n\nVERDICT: APPROVE", true
+	}
+}
+
+// objections are the simulated reviewer's rejections, all different so
+// that only Repeat trips the repeated-rejection check.
+var objections = []string{
+	"missing error handling when the input is empty",
+	"tests don't cover the failure path",
+	"naming doesn't follow the package conventions",
+	"the new helper duplicates an existing function",
+}
+
+// planAnswer is the PM's plan in the SUBTASKS format.
+func (sc *Scenario) planAnswer() string {
+	plan := sc.Plan
+	if len(plan) == 0 {
+		plan = []PlannedTask{{Title: "Implement the change", Description: "Make the change the epic asks for"}}
+	}
+	var sb strings.Builder
+	sb.WriteString("SUBTASKS:\n")
+	for i, t := range plan {
+		desc := t.Description
+		if desc == "" {
+			desc = t.Title
+		}
+		prio := t.Priority
+		if prio == "" {
+			prio = "medium"
+		}
+		fmt.Fprintf(&sb, "%d. %s - %s (priority: %s)\n", i+1, t.Title, desc, prio)
+	}
+	return sb.String()
+}
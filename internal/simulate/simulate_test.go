@@ -0,0 +1,104 @@
+package simulate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+)
+
+func writeScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	os.WriteFile(path, []byte(content), 0644)
+	return path
+}
+
+func TestLoad_RejectsUnknownOutcome(t *testing.T) {
+	_, err := Load(writeScenario(t, "tasks:\n  login: [success, explode]\n"))
+	if err == nil || !strings.Contains(err.Error(), `unknown outcome "explode"`) {
+		t.Errorf("expected unknown outcome error, got %v", err)
+	}
+}
+
+func TestOutcome_ByIDTitleAndDefault(t *testing.T) {
+	sc, err := Load(writeScenario(t, `
+default: block
+tasks:
+  login: [reject]
+  login form: [fail, success]
+  "#9": [repeat]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		id    int64
+		title string
+		n     int
+		want  string
+	}{
+		{9, "Login form", 1, Repeat},   // ID beats title
+		{2, "Add Login Form", 1, Fail}, // longest title match
+		{2, "Add Login Form", 2, Success},
+		{3, "Fix login", 1, Reject},
+		{3, "Fix login", 2, Block},      // past the list: default
+		{4, "Something else", 1, Block}, // unlisted: default
+	}
+	for _, c := range cases {
+		if got := sc.Outcome(c.id, c.title, c.n); got != c.want {
+			t.Errorf("Outcome(%d, %q, %d) = %q, want %q", c.id, c.title, c.n, got, c.want)
+		}
+	}
+}
+
+func TestAgents_Answers(t *testing.T) {
+	sc := &Scenario{
+		Plan:  []PlannedTask{{Title: "Add login form", Priority: "high"}, {Title: "Wire the API"}},
+		Tasks: map[string][]string{"login": {Reject, Repeat, Success}, "api": {Block}, "docs": {Fail}},
+	}
+	titles := map[int64]string{1: "Add login form", 2: "Wire the API", 3: "Write docs"}
+	sim := sc.Agents(func(id int64) string { return titles[id] })
+
+	plan, _ := sim(0, "pm", 1)
+	subtasks := agent.ParseSubtasks(plan)
+	if len(subtasks) != 2 || subtasks[0].Title != "Add login form" || subtasks[0].Priority != "high" {
+		t.Errorf("unexpected plan: %+v", subtasks)
+	}
+
+	var reviews []agent.ParsedReview
+	var outputs []string
+	for n := 1; n <= 3; n++ {
+		out, _ := sim(1, "reviewer", n)
+		reviews = append(reviews, agent.ParseReview(out))
+		outputs = append(outputs, out)
+	}
+	if reviews[0].Verdict != "REJECT" || reviews[1].Verdict != "REJECT" || reviews[2].Verdict != "APPROVE" {
+		t.Errorf("unexpected verdicts: %+v", reviews)
+	}
+	if !agent.SameRejection(reviews[0], reviews[1], outputs[0], outputs[1]) {
+		t.Error("repeat should raise the same objection as the review before")
+	}
+
+	if _, ok := sim(1, "coder", 1); ok {
+		t.Error("a succeeding coder should fall back to the fake coder's edit")
+	}
+	if out, _ := sim(2, "coder", 1); agent.ParseBlocked(out) == "" {
+		t.Errorf("expected a blocked coder, got %q", out)
+	}
+	if out, _ := sim(3, "coder", 1); !strings.Contains(out, "=== exit: 1") {
+		t.Errorf("expected a failing coder, got %q", out)
+	}
+}
+
+func TestAgents_RejectsDiffer(t *testing.T) {
+	sc := &Scenario{Default: Reject}
+	sim := sc.Agents(func(int64) string { return "" })
+	a, _ := sim(1, "reviewer", 1)
+	b, _ := sim(1, "reviewer", 2)
+	if agent.SameRejection(agent.ParseReview(a), agent.ParseReview(b), a, b) {
+		t.Error("consecutive rejections should raise different objections")
+	}
+}
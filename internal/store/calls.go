@@ -0,0 +1,46 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecordAgentCall adds a finished agent call to the call history.
+func (s *Store) RecordAgentCall(c AgentCall) error {
+	_, err := s.exec(
+		`INSERT INTO agent_calls (task_id, agent, role, mode, duration, input_tokens, output_tokens, cost, failed, at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.TaskID, c.Agent, c.Role, c.Mode, c.Duration, c.InputTokens, c.OutputTokens, c.Cost, c.Failed, c.At.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("record agent call: %w", err)
+	}
+	return nil
+}
+
+// AgentCallStats returns each role's average call duration and cost over
+// the calls since the given time. Failed calls are left out: they tend to
+// end early and would make a run look quicker than it is.
+func (s *Store) AgentCallStats(since time.Time) (map[string]CallStats, error) {
+	rows, err := s.db.Query(
+		`SELECT role, COUNT(*), AVG(duration), AVG(cost)
+		 FROM agent_calls WHERE failed = 0 AND at >= ?
+		 GROUP BY role`,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("agent call stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := map[string]CallStats{}
+	for rows.Next() {
+		var role string
+		var st CallStats
+		if err := rows.Scan(&role, &st.Calls, &st.AvgDuration, &st.AvgCost); err != nil {
+			return nil, fmt.Errorf("scan agent call stats: %w", err)
+		}
+		stats[role] = st
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgentCallStats(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UTC()
+
+	for _, c := range []AgentCall{
+		{Role: "coder", Duration: 60, Cost: 0.10, At: now},
+		{Role: "coder", Duration: 120, Cost: 0.30, At: now},
+		{Role: "coder", Duration: 5, Failed: true, At: now},
+		{Role: "coder", Duration: 900, At: now.Add(-60 * 24 * time.Hour)},
+		{Role: "reviewer", Duration: 30, At: now},
+	} {
+		if err := s.RecordAgentCall(c); err != nil {
+			t.Fatalf("RecordAgentCall: %v", err)
+		}
+	}
+
+	stats, err := s.AgentCallStats(now.Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("AgentCallStats: %v", err)
+	}
+	if got := stats["coder"]; got.Calls != 2 || got.AvgDuration != 90 || got.AvgCost < 0.199 || got.AvgCost > 0.201 {
+		t.Errorf("coder stats = %+v, want 2 calls averaging 90s and $0.20", got)
+	}
+	if got := stats["reviewer"]; got.Calls != 1 || got.AvgDuration != 30 || got.AvgCost != 0 {
+		t.Errorf("reviewer stats = %+v", got)
+	}
+	if _, ok := stats["tester"]; ok {
+		t.Error("tester has no calls, want no stats")
+	}
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// HeartbeatTTL is how long a heartbeat counts as live. Commands that work
+// on a task outside a pipeline run beat well within it.
+const HeartbeatTTL = 2 * time.Minute
+
+// Beat records that process pid is working on a task right now.
+func (s *Store) Beat(taskID int64, pid int) error {
+	_, err := s.exec(
+		`INSERT INTO heartbeats (task_id, pid, beat_at) VALUES (?, ?, ?)
+		 ON CONFLICT(task_id) DO UPDATE SET pid = excluded.pid, beat_at = excluded.beat_at`,
+		taskID, pid, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("heartbeat: %w", err)
+	}
+	return nil
+}
+
+// ClearHeartbeat removes a task's heartbeat when the work on it ends.
+func (s *Store) ClearHeartbeat(taskID int64) error {
+	_, err := s.exec(`DELETE FROM heartbeats WHERE task_id = ?`, taskID)
+	return err
+}
+
+// ListStaleTasks returns tasks that are in_progress or review although
+// nothing is working on them: no running pipeline on their epic and no
+// heartbeat newer than HeartbeatTTL. These are left over from a hive run
+// or hive fix that crashed.
+func (s *Store) ListStaleTasks() ([]Task, error) {
+	tasks, err := s.queryTasks(
+		`SELECT `+taskColumns+` FROM tasks t
+		 WHERE t.kind = ? AND t.status IN (?, ?)
+		   AND NOT EXISTS (SELECT 1 FROM pipeline_runs r WHERE r.epic_id = t.parent_id AND r.status = 'running')
+		 ORDER BY t.id`,
+		string(KindTask), string(StatusInProgress), string(StatusReview),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-HeartbeatTTL)
+	var stale []Task
+	for _, t := range tasks {
+		var beat time.Time
+		err := s.db.QueryRow(`SELECT beat_at FROM heartbeats WHERE task_id = ?`, t.ID).Scan(&beat)
+		if err == nil && beat.After(cutoff) {
+			continue
+		}
+		stale = append(stale, t)
+	}
+	return stale, nil
+}
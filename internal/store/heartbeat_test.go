@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListStaleTasks(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "high")
+	crashed, _ := s.CreateTask("Crashed run", "", "high", nil)
+	live, _ := s.CreateTask("Live run", "", "high", nil)
+	piped, _ := s.CreateTask("In a pipeline", "", "high", &epic.ID)
+	s.CreateTask("Backlog", "", "high", nil)
+	for _, task := range []*Task{crashed, live, piped} {
+		s.UpdateTaskStatus(task.ID, StatusInProgress)
+	}
+
+	s.Beat(live.ID, 1234)
+	s.StartPipelineRun(epic.ID, 3, 1)
+
+	stale, err := s.ListStaleTasks()
+	if err != nil {
+		t.Fatalf("ListStaleTasks: %v", err)
+	}
+	if len(stale) != 1 || stale[0].ID != crashed.ID {
+		t.Fatalf("expected only #%d stale, got %+v", crashed.ID, stale)
+	}
+
+	// An old heartbeat doesn't count.
+	s.exec(`UPDATE heartbeats SET beat_at = ? WHERE task_id = ?`, time.Now().UTC().Add(-2*HeartbeatTTL), live.ID)
+	if stale, _ := s.ListStaleTasks(); len(stale) != 2 {
+		t.Errorf("expected 2 stale tasks after the heartbeat expired, got %+v", stale)
+	}
+
+	s.ClearHeartbeat(live.ID)
+	s.UpdateTaskStatus(live.ID, StatusDone)
+	if stale, _ := s.ListStaleTasks(); len(stale) != 1 {
+		t.Errorf("expected 1 stale task, got %+v", stale)
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// recordStatus closes the task's open status span and opens one for
+// status. Setting the status a task already has changes nothing.
+func (s *Store) recordStatus(id int64, status TaskStatus, at time.Time) {
+	s.exec(
+		`UPDATE status_history SET left_at = ? WHERE task_id = ? AND left_at IS NULL AND status != ?`,
+		at, id, string(status),
+	)
+	s.exec(
+		`INSERT INTO status_history (task_id, status, entered_at)
+		 SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM status_history WHERE task_id = ? AND left_at IS NULL)`,
+		id, string(status), at, id,
+	)
+}
+
+// backfillStatusHistory opens a span in the current status for tasks from
+// before status history was recorded, starting at their last update.
+func (s *Store) backfillStatusHistory() {
+	s.db.Exec(
+		`INSERT INTO status_history (task_id, status, entered_at)
+		 SELECT id, status, updated_at FROM tasks
+		 WHERE id NOT IN (SELECT task_id FROM status_history)`,
+	)
+}
+
+// GetStatusHistory returns the statuses a task went through, oldest first.
+func (s *Store) GetStatusHistory(taskID int64) ([]StatusSpan, error) {
+	rows, err := s.db.Query(
+		`SELECT task_id, status, entered_at, left_at FROM status_history
+		 WHERE task_id = ? ORDER BY entered_at, id`, taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get status history: %w", err)
+	}
+	defer rows.Close()
+
+	var spans []StatusSpan
+	for rows.Next() {
+		var sp StatusSpan
+		var left sql.NullTime
+		if err := rows.Scan(&sp.TaskID, &sp.Status, &sp.EnteredAt, &left); err != nil {
+			return nil, err
+		}
+		if left.Valid {
+			sp.LeftAt = &left.Time
+		}
+		spans = append(spans, sp)
+	}
+	return spans, rows.Err()
+}
+
+// TaskTimes is how long a task spent in each part of its life.
+type TaskTimes struct {
+	Cycle      time.Duration // First status until done; zero unless Done
+	InProgress time.Duration
+	Review     time.Duration
+	Blocked    time.Duration
+	Done       bool
+}
+
+// Times sums a task's status history. Open spans count up to now.
+func Times(spans []StatusSpan, now time.Time) TaskTimes {
+	var t TaskTimes
+	if len(spans) == 0 {
+		return t
+	}
+	for _, sp := range spans {
+		end := now
+		if sp.LeftAt != nil {
+			end = *sp.LeftAt
+		}
+		d := end.Sub(sp.EnteredAt)
+		switch sp.Status {
+		case StatusInProgress:
+			t.InProgress += d
+		case StatusReview:
+			t.Review += d
+		case StatusBlocked:
+			t.Blocked += d
+		}
+	}
+	if last := spans[len(spans)-1]; last.Status == StatusDone {
+		t.Done = true
+		t.Cycle = last.EnteredAt.Sub(spans[0].EnteredAt)
+	}
+	return t
+}
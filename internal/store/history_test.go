@@ -0,0 +1,83 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusHistory(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "high")
+	task, _ := s.CreateTask("Task", "", "high", &epic.ID)
+
+	s.UpdateTaskStatus(task.ID, StatusInProgress)
+	s.UpdateTaskStatus(task.ID, StatusInProgress) // No-op: same status.
+	s.BlockTask(task.ID, "which db?")
+	s.UnblockTask(task.ID, "sqlite")
+	s.UpdateTaskStatus(task.ID, StatusReview)
+	s.UpdateTaskStatus(task.ID, StatusDone)
+
+	spans, err := s.GetStatusHistory(task.ID)
+	if err != nil {
+		t.Fatalf("GetStatusHistory: %v", err)
+	}
+	want := []TaskStatus{StatusBacklog, StatusInProgress, StatusBlocked, StatusBacklog, StatusReview, StatusDone}
+	if len(spans) != len(want) {
+		t.Fatalf("expected %d spans, got %+v", len(want), spans)
+	}
+	for i, sp := range spans {
+		if sp.Status != want[i] {
+			t.Errorf("span %d: status %s, want %s", i, sp.Status, want[i])
+		}
+		if last := i == len(spans)-1; (sp.LeftAt == nil) != last {
+			t.Errorf("span %d (%s): left_at %v", i, sp.Status, sp.LeftAt)
+		}
+	}
+
+	times := Times(spans, time.Now())
+	if !times.Done || times.Cycle <= 0 {
+		t.Errorf("expected a cycle time for a done task, got %+v", times)
+	}
+}
+
+func TestStatusHistory_Resets(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "high")
+	task, _ := s.CreateTask("Task", "", "high", &epic.ID)
+	s.UpdateTaskStatus(task.ID, StatusFailed)
+
+	if n, _ := s.ResetFailedTasks(epic.ID); n != 1 {
+		t.Fatalf("expected 1 reset, got %d", n)
+	}
+	spans, _ := s.GetStatusHistory(task.ID)
+	if len(spans) != 3 || spans[2].Status != StatusBacklog {
+		t.Errorf("expected the reset to backlog recorded, got %+v", spans)
+	}
+}
+
+func TestTimes(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(h int) *time.Time { t := start.Add(time.Duration(h) * time.Hour); return &t }
+	spans := []StatusSpan{
+		{Status: StatusBacklog, EnteredAt: start, LeftAt: at(1)},
+		{Status: StatusInProgress, EnteredAt: *at(1), LeftAt: at(3)},
+		{Status: StatusBlocked, EnteredAt: *at(3), LeftAt: at(7)},
+		{Status: StatusInProgress, EnteredAt: *at(7), LeftAt: at(8)},
+		{Status: StatusReview, EnteredAt: *at(8)},
+	}
+
+	open := Times(spans, *at(10))
+	if open.Done || open.Cycle != 0 {
+		t.Errorf("unfinished task has a cycle time: %+v", open)
+	}
+	if open.InProgress != 3*time.Hour || open.Blocked != 4*time.Hour || open.Review != 2*time.Hour {
+		t.Errorf("unexpected times %+v", open)
+	}
+
+	spans[4].LeftAt = at(9)
+	spans = append(spans, StatusSpan{Status: StatusDone, EnteredAt: *at(9)})
+	done := Times(spans, *at(30))
+	if !done.Done || done.Cycle != 9*time.Hour || done.Review != time.Hour {
+		t.Errorf("unexpected times %+v", done)
+	}
+}
@@ -12,6 +12,7 @@ const (
 	StatusReview     TaskStatus = "review"
 	StatusDone       TaskStatus = "done"
 	StatusFailed     TaskStatus = "failed"
+	StatusNeedsHuman TaskStatus = "needs_human" // The fix loop gave up: out of iterations or the same objections again
 	StatusCancelled  TaskStatus = "cancelled"
 )
 
@@ -37,11 +38,25 @@ type Task struct {
 	Role          string     `json:"role,omitempty"`
 	Priority      string     `json:"priority,omitempty"` // high, medium, low
 	BlockedReason string     `json:"blocked_reason,omitempty"`
-	GitBranch     string     `json:"git_branch,omitempty"` // Safety branch for this epic/task
+	GitBranch     string     `json:"git_branch,omitempty"`   // Safety branch for this epic/task
+	AdoptedRef    string     `json:"adopted_ref,omitempty"`  // Commit an adopted (user-owned) branch pointed at; empty for hive/epic-N
+	BaseBranch    string     `json:"base_branch,omitempty"`  // Integration branch to diff/merge against; empty = auto-detect
+	Acceptance    []string   `json:"acceptance,omitempty"`   // Acceptance criteria from the PM
+	SetupCmd      string     `json:"setup_cmd,omitempty"`    // Prepares a new worktree of the epic; empty = setup_cmd from the config
+	Commits       []string   `json:"commits,omitempty"`      // Existing commits the task was adopted from; reviewed instead of the working tree
+	MergeCommit   string     `json:"merge_commit,omitempty"` // Approved commit that conflicted with the epic branch; set while the task needs merging
+	PlanHash      string     `json:"plan_hash,omitempty"`    // SHA-256 of the plan artifact the epic's tasks came from; empty = not planned by hive auto
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
+// TaskCount is the number of epics or tasks in one status.
+type TaskCount struct {
+	Kind   TaskKind   `json:"kind"`
+	Status TaskStatus `json:"status"`
+	Count  int        `json:"count"`
+}
+
 // Event represents something that happened to a task.
 type Event struct {
 	ID        int64     `json:"id"`
@@ -56,7 +71,7 @@ type Event struct {
 type Artifact struct {
 	ID        int64     `json:"id"`
 	TaskID    int64     `json:"task_id"`
-	Type      string    `json:"type"` // diff, plan, log, review
+	Type      string    `json:"type"` // diff, plan, log, review, image
 	FilePath  string    `json:"file_path"`
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -81,3 +96,140 @@ type PipelineRun struct {
 	StartedAt time.Time `json:"started_at"`
 	EndedAt   time.Time `json:"ended_at,omitempty"`
 }
+
+// Checkpoint phases: where a task was in its fix loop.
+const (
+	PhaseCode   = "code"   // The coder is working on the iteration
+	PhaseReview = "review" // The coder finished the iteration; checks and review are next
+	PhaseDone   = "done"   // The loop ended (approved, blocked or failed)
+)
+
+// TaskCheckpoint records how far a pipeline run got with one task, so
+// hive resume can continue its fix loop where it stopped.
+type TaskCheckpoint struct {
+	RunID          int64     `json:"run_id"`
+	TaskID         int64     `json:"task_id"`
+	Iteration      int       `json:"iteration"`
+	Phase          string    `json:"phase"`
+	CodeArtifact   string    `json:"code_artifact,omitempty"`   // The iteration's coder output, in the runs directory
+	ReviewArtifact string    `json:"review_artifact,omitempty"` // The last review, in the runs directory
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TaskFile is one file a coder iteration touched: what the coder declared
+// in FILES_CHANGED and whether git actually shows it as changed.
+type TaskFile struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Iteration int       `json:"iteration"`
+	Path      string    `json:"path"`
+	Declared  bool      `json:"declared"` // Listed by the coder in FILES_CHANGED
+	Changed   bool      `json:"changed"`  // Shown as changed by git status
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Handoff is what a finished task passes on to later tasks in its epic:
+// the coder's HANDOFF notes and the files it changed.
+type Handoff struct {
+	TaskID    int64     `json:"task_id"`
+	Title     string    `json:"title"`
+	Notes     []string  `json:"notes"`
+	Files     []string  `json:"files"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HistoryEntry is one agent run or review in hive history.
+type HistoryEntry struct {
+	Kind      string    `json:"kind"` // run, review
+	TaskID    int64     `json:"task_id"`
+	EpicID    *int64    `json:"epic_id,omitempty"`
+	TaskTitle string    `json:"task_title"`
+	Agent     string    `json:"agent"`
+	Verdict   string    `json:"verdict,omitempty"` // Reviews only
+	Content   string    `json:"content"`           // Run output or review comments
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HistoryFilter narrows SearchHistory. Zero fields match everything.
+type HistoryFilter struct {
+	Agent   string
+	Verdict string // Only reviews with this verdict
+	Since   time.Time
+	EpicID  int64
+	Limit   int
+}
+
+// EpicSnapshot is the record of an accepted epic: its report, commits,
+// diffstat and reviews as they were at accept time.
+type EpicSnapshot struct {
+	ID         int64     `json:"id"`
+	EpicID     int64     `json:"epic_id"`
+	Title      string    `json:"title"`
+	Branch     string    `json:"branch"`
+	BaseBranch string    `json:"base_branch"`
+	Report     string    `json:"report"` // Markdown
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Stash records user changes hive stashed before switching branches.
+type Stash struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Ref       string    `json:"stash_ref"` // Stash commit hash (stable, unlike stash@{n})
+	Branch    string    `json:"branch"`    // Branch the changes were stashed from
+	Status    string    `json:"status"`    // stashed, restored
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Watcher subscribes a notification target to status changes of a task,
+// or of an epic and all its tasks.
+type Watcher struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Target    string    `json:"target"` // e.g. slack:#team, slack:@alice, webhook:https://...
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StatusSpan is one stretch of time a task spent in a status.
+type StatusSpan struct {
+	TaskID    int64      `json:"task_id"`
+	Status    TaskStatus `json:"status"`
+	EnteredAt time.Time  `json:"entered_at"`
+	LeftAt    *time.Time `json:"left_at,omitempty"` // Nil while the task is still in the status
+}
+
+// RateLimit is the last rate-limit state an API provider reported for a
+// key. Counts are -1 when the provider didn't report them.
+type RateLimit struct {
+	Key               string    `json:"key"` // provider/api_key_env
+	Provider          string    `json:"provider"`
+	RequestsLimit     int       `json:"requests_limit"`
+	RequestsRemaining int       `json:"requests_remaining"`
+	RequestsReset     time.Time `json:"requests_reset"`
+	TokensLimit       int       `json:"tokens_limit"`
+	TokensRemaining   int       `json:"tokens_remaining"`
+	TokensReset       time.Time `json:"tokens_reset"`
+	RetryAfter        time.Time `json:"retry_after"` // Zero unless the provider answered 429
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// AgentCall is one finished agent call.
+type AgentCall struct {
+	TaskID       int64     `json:"task_id"`
+	Agent        string    `json:"agent"`
+	Role         string    `json:"role"`
+	Mode         string    `json:"mode"`
+	Duration     float64   `json:"duration"` // Seconds
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"` // USD
+	Failed       bool      `json:"failed"`
+	At           time.Time `json:"at"`
+}
+
+// CallStats sums up a role's recent agent calls.
+type CallStats struct {
+	Calls       int     `json:"calls"`
+	AvgDuration float64 `json:"avg_duration"` // Seconds
+	AvgCost     float64 `json:"avg_cost"`     // USD; 0 when no call reported usage
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveRateLimit records the latest rate-limit state of an API key,
+// replacing the previous one.
+func (s *Store) SaveRateLimit(rl RateLimit) error {
+	_, err := s.exec(
+		`INSERT INTO rate_limits (key, provider, requests_limit, requests_remaining, requests_reset,
+		                          tokens_limit, tokens_remaining, tokens_reset, retry_after, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET
+		   provider = excluded.provider,
+		   requests_limit = excluded.requests_limit, requests_remaining = excluded.requests_remaining,
+		   requests_reset = excluded.requests_reset,
+		   tokens_limit = excluded.tokens_limit, tokens_remaining = excluded.tokens_remaining,
+		   tokens_reset = excluded.tokens_reset,
+		   retry_after = excluded.retry_after, updated_at = excluded.updated_at`,
+		rl.Key, rl.Provider, rl.RequestsLimit, rl.RequestsRemaining, nullTime(rl.RequestsReset),
+		rl.TokensLimit, rl.TokensRemaining, nullTime(rl.TokensReset), nullTime(rl.RetryAfter),
+		rl.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("save rate limit: %w", err)
+	}
+	return nil
+}
+
+// ListRateLimits returns the recorded rate-limit state of every API key,
+// most recently updated first.
+func (s *Store) ListRateLimits() ([]RateLimit, error) {
+	rows, err := s.db.Query(
+		`SELECT key, provider, requests_limit, requests_remaining, requests_reset,
+		        tokens_limit, tokens_remaining, tokens_reset, retry_after, updated_at
+		 FROM rate_limits ORDER BY updated_at DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list rate limits: %w", err)
+	}
+	defer rows.Close()
+
+	var limits []RateLimit
+	for rows.Next() {
+		var rl RateLimit
+		var reqReset, tokReset, retry sql.NullTime
+		if err := rows.Scan(&rl.Key, &rl.Provider, &rl.RequestsLimit, &rl.RequestsRemaining, &reqReset,
+			&rl.TokensLimit, &rl.TokensRemaining, &tokReset, &retry, &rl.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan rate limit: %w", err)
+		}
+		rl.RequestsReset, rl.TokensReset, rl.RetryAfter = reqReset.Time, tokReset.Time, retry.Time
+		limits = append(limits, rl)
+	}
+	return limits, rows.Err()
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
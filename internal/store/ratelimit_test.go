@@ -0,0 +1,43 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveRateLimit(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	first := RateLimit{Key: "openai/OPENAI_API_KEY", Provider: "openai",
+		RequestsLimit: 500, RequestsRemaining: 499, RequestsReset: now.Add(time.Second),
+		TokensLimit: -1, TokensRemaining: -1, UpdatedAt: now}
+	if err := s.SaveRateLimit(first); err != nil {
+		t.Fatalf("SaveRateLimit: %v", err)
+	}
+	updated := first
+	updated.RequestsRemaining = 0
+	updated.RetryAfter = now.Add(30 * time.Second)
+	updated.UpdatedAt = now.Add(time.Second)
+	if err := s.SaveRateLimit(updated); err != nil {
+		t.Fatalf("SaveRateLimit: %v", err)
+	}
+
+	limits, err := s.ListRateLimits()
+	if err != nil {
+		t.Fatalf("ListRateLimits: %v", err)
+	}
+	if len(limits) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(limits))
+	}
+	got := limits[0]
+	if got.RequestsRemaining != 0 || got.RequestsLimit != 500 || got.TokensLimit != -1 {
+		t.Errorf("unexpected counts: %+v", got)
+	}
+	if !got.RetryAfter.Equal(updated.RetryAfter) || !got.RequestsReset.Equal(first.RequestsReset) {
+		t.Errorf("unexpected times: retry %v, reset %v", got.RetryAfter, got.RequestsReset)
+	}
+	if !got.TokensReset.IsZero() {
+		t.Errorf("unset reset should be zero, got %v", got.TokensReset)
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// runEvents are the event types that record an agent run.
+var runEvents = []string{"agent_output", "role_output", "findings"}
+
+// SearchHistory returns agent runs and reviews across all epics, newest
+// first. A verdict filter leaves only reviews.
+func (s *Store) SearchHistory(f HistoryFilter) ([]HistoryEntry, error) {
+	var parts []string
+	var args []any
+
+	// where builds the filters shared by both halves of the query.
+	where := func(agentCol, timeCol string) string {
+		var conds []string
+		if f.Agent != "" {
+			conds = append(conds, agentCol+" = ?")
+			args = append(args, f.Agent)
+		}
+		if !f.Since.IsZero() {
+			conds = append(conds, timeCol+" >= ?")
+			args = append(args, f.Since.UTC())
+		}
+		if f.EpicID != 0 {
+			conds = append(conds, "(t.id = ? OR t.parent_id = ?)")
+			args = append(args, f.EpicID, f.EpicID)
+		}
+		if len(conds) == 0 {
+			return ""
+		}
+		return " AND " + strings.Join(conds, " AND ")
+	}
+
+	if f.Verdict == "" {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(runEvents)), ", ")
+		for _, t := range runEvents {
+			args = append(args, t)
+		}
+		parts = append(parts, `SELECT 'run', e.task_id, t.parent_id, t.title, e.agent, '', e.content, e.timestamp
+			FROM events e JOIN tasks t ON t.id = e.task_id
+			WHERE e.event_type IN (`+placeholders+`)`+where("e.agent", "e.timestamp"))
+	}
+
+	review := `SELECT 'review', r.task_id, t.parent_id, t.title, r.reviewer_agent, r.verdict, r.comments, r.timestamp
+		FROM reviews r JOIN tasks t ON t.id = r.task_id WHERE 1 = 1`
+	if f.Verdict != "" {
+		review += " AND r.verdict = ?"
+		args = append(args, f.Verdict)
+	}
+	parts = append(parts, review+where("r.reviewer_agent", "r.timestamp"))
+
+	query := strings.Join(parts, " UNION ALL ") + " ORDER BY 8 DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var epicID sql.NullInt64
+		if err := rows.Scan(&e.Kind, &e.TaskID, &epicID, &e.TaskTitle, &e.Agent, &e.Verdict, &e.Content, &e.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan history: %w", err)
+		}
+		if epicID.Valid {
+			e.EpicID = &epicID.Int64
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
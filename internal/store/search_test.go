@@ -0,0 +1,55 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchHistory(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "high")
+	task, _ := s.CreateTask("Task", "", "high", &epic.ID)
+	other, _ := s.CreateEpic("Other", "", "low")
+	otherTask, _ := s.CreateTask("Other task", "", "low", &other.ID)
+
+	s.AddEvent(task.ID, "claude", "agent_output", "wrote the handler")
+	s.AddEvent(task.ID, "claude", "comment", "not a run")
+	s.AddReview(task.ID, "codex", "reject", "missing tests")
+	s.AddReview(task.ID, "codex", "approve", "")
+	s.AddEvent(otherTask.ID, "gemini", "role_output", "[tester]\nok")
+
+	all, err := s.SearchHistory(HistoryFilter{})
+	if err != nil {
+		t.Fatalf("SearchHistory: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("expected 2 runs and 2 reviews, got %+v", all)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Timestamp.After(all[i-1].Timestamp) {
+			t.Errorf("not newest first: %+v", all)
+		}
+	}
+
+	rejects, _ := s.SearchHistory(HistoryFilter{Verdict: "reject"})
+	if len(rejects) != 1 || rejects[0].Kind != "review" || rejects[0].Content != "missing tests" ||
+		rejects[0].EpicID == nil || *rejects[0].EpicID != epic.ID {
+		t.Errorf("unexpected rejects: %+v", rejects)
+	}
+
+	if got, _ := s.SearchHistory(HistoryFilter{Agent: "claude"}); len(got) != 1 || got[0].Kind != "run" {
+		t.Errorf("unexpected runs by claude: %+v", got)
+	}
+	if got, _ := s.SearchHistory(HistoryFilter{EpicID: other.ID}); len(got) != 1 || got[0].Agent != "gemini" {
+		t.Errorf("unexpected entries for other epic: %+v", got)
+	}
+	if got, _ := s.SearchHistory(HistoryFilter{Since: time.Now().Add(time.Hour)}); len(got) != 0 {
+		t.Errorf("expected nothing in the future, got %+v", got)
+	}
+	if got, _ := s.SearchHistory(HistoryFilter{Since: time.Now().Add(-time.Hour)}); len(got) != 4 {
+		t.Errorf("expected everything in the last hour, got %+v", got)
+	}
+	if got, _ := s.SearchHistory(HistoryFilter{Limit: 2}); len(got) != 2 {
+		t.Errorf("expected limit of 2, got %d", len(got))
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// SaveEpicSnapshot records an accepted epic. Snapshots can't be changed
+// or deleted afterwards. Returns the snapshot ID.
+func (s *Store) SaveEpicSnapshot(snap EpicSnapshot) (int64, error) {
+	if snap.CreatedAt.IsZero() {
+		snap.CreatedAt = time.Now()
+	}
+	res, err := s.exec(
+		`INSERT INTO epic_snapshots (epic_id, title, branch, base_branch, report, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		snap.EpicID, snap.Title, snap.Branch, snap.BaseBranch, snap.Report, snap.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("save epic snapshot: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListEpicSnapshots returns the snapshots of an epic, or of every epic
+// when epicID is 0, newest first.
+func (s *Store) ListEpicSnapshots(epicID int64) ([]EpicSnapshot, error) {
+	query := `SELECT id, epic_id, title, branch, base_branch, report, created_at FROM epic_snapshots`
+	var args []any
+	if epicID != 0 {
+		query += ` WHERE epic_id = ?`
+		args = append(args, epicID)
+	}
+	rows, err := s.db.Query(query+` ORDER BY created_at DESC, id DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list epic snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snaps []EpicSnapshot
+	for rows.Next() {
+		var e EpicSnapshot
+		if err := rows.Scan(&e.ID, &e.EpicID, &e.Title, &e.Branch, &e.BaseBranch, &e.Report, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan epic snapshot: %w", err)
+		}
+		snaps = append(snaps, e)
+	}
+	return snaps, rows.Err()
+}
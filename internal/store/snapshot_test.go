@@ -0,0 +1,37 @@
+package store
+
+import "testing"
+
+func TestEpicSnapshots(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "high")
+	other, _ := s.CreateEpic("Other", "", "low")
+
+	id, err := s.SaveEpicSnapshot(EpicSnapshot{EpicID: epic.ID, Title: "Epic", Branch: "hive/epic-1", BaseBranch: "main", Report: "# Epic"})
+	if err != nil {
+		t.Fatalf("SaveEpicSnapshot: %v", err)
+	}
+	s.SaveEpicSnapshot(EpicSnapshot{EpicID: other.ID, Title: "Other"})
+
+	snaps, err := s.ListEpicSnapshots(epic.ID)
+	if err != nil {
+		t.Fatalf("ListEpicSnapshots: %v", err)
+	}
+	if len(snaps) != 1 || snaps[0].ID != id || snaps[0].Report != "# Epic" || snaps[0].BaseBranch != "main" {
+		t.Fatalf("unexpected snapshots: %+v", snaps)
+	}
+	if all, _ := s.ListEpicSnapshots(0); len(all) != 2 {
+		t.Errorf("expected 2 snapshots in all, got %d", len(all))
+	}
+
+	// Snapshots are immutable.
+	if _, err := s.db.Exec(`UPDATE epic_snapshots SET report = 'changed' WHERE id = ?`, id); err == nil {
+		t.Error("expected update to fail")
+	}
+	if _, err := s.db.Exec(`DELETE FROM epic_snapshots WHERE id = ?`, id); err == nil {
+		t.Error("expected delete to fail")
+	}
+	if snaps, _ := s.ListEpicSnapshots(epic.ID); len(snaps) != 1 || snaps[0].Report != "# Epic" {
+		t.Errorf("snapshot changed: %+v", snaps)
+	}
+}
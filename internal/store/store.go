@@ -1,21 +1,36 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/imkarma/hive/internal/redact"
+
 	_ "modernc.org/sqlite"
 )
 
 // Store provides access to the hive database.
 type Store struct {
-	db *sql.DB
+	db           *sql.DB
+	writeSlot    chan struct{} // Held by the one write in flight
+	writeTimeout time.Duration
+	statusHook   StatusHook
+	reviewHook   ReviewHook
 }
 
+// busyTimeout is how long SQLite itself waits for a lock held by another
+// process (hive auto and hive tui side by side) before a statement fails
+// with SQLITE_BUSY. Set on every connection through the DSN, so reads
+// wait too; writes are retried on top of it, see withWrite.
+const busyTimeout = 5 * time.Second
+
 // New opens (or creates) the SQLite database at the given path.
 func New(dbPath string) (*Store, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)", dbPath, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
@@ -26,7 +41,7 @@ func New(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("set WAL mode: %w", err)
 	}
 
-	s := &Store{db: db}
+	s := &Store{db: db, writeSlot: make(chan struct{}, 1), writeTimeout: DefaultWriteTimeout}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
@@ -53,6 +68,11 @@ func (s *Store) migrate() error {
 		priority        TEXT DEFAULT 'medium',
 		blocked_reason  TEXT DEFAULT '',
 		git_branch      TEXT DEFAULT '',
+		adopted_ref     TEXT DEFAULT '',
+		base_branch     TEXT DEFAULT '',
+		acceptance      TEXT DEFAULT '',
+		setup_cmd       TEXT DEFAULT '',
+		commits         TEXT DEFAULT '',
 		created_at      DATETIME NOT NULL,
 		updated_at      DATETIME NOT NULL
 	);
@@ -100,9 +120,162 @@ func (s *Store) migrate() error {
 	);
 	`)
 
+	// Where each task of a pipeline run was in its fix loop.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS pipeline_task_runs (
+		run_id           INTEGER NOT NULL REFERENCES pipeline_runs(id),
+		task_id          INTEGER NOT NULL REFERENCES tasks(id),
+		iteration        INTEGER NOT NULL DEFAULT 1,
+		phase            TEXT NOT NULL DEFAULT 'code',
+		code_artifact    TEXT NOT NULL DEFAULT '',
+		review_artifact  TEXT NOT NULL DEFAULT '',
+		updated_at       DATETIME NOT NULL,
+		PRIMARY KEY (run_id, task_id)
+	);
+	`)
+
+	// Files each coder iteration declared/changed.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS task_files (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id     INTEGER NOT NULL REFERENCES tasks(id),
+		iteration   INTEGER NOT NULL DEFAULT 0,
+		path        TEXT NOT NULL,
+		declared    INTEGER NOT NULL DEFAULT 0,
+		changed     INTEGER NOT NULL DEFAULT 0,
+		timestamp   DATETIME NOT NULL
+	);
+	`)
+
+	// Auto-stashes of user changes, so they can be found after a crash.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS stashes (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id      INTEGER NOT NULL REFERENCES tasks(id),
+		stash_ref    TEXT NOT NULL,
+		branch       TEXT NOT NULL DEFAULT '',
+		status       TEXT NOT NULL DEFAULT 'stashed',
+		created_at   DATETIME NOT NULL,
+		restored_at  DATETIME
+	);
+	`)
+
+	// What a finished task hands on to later tasks in its epic.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS task_handoffs (
+		task_id     INTEGER PRIMARY KEY REFERENCES tasks(id),
+		notes       TEXT NOT NULL DEFAULT '',
+		files       TEXT NOT NULL DEFAULT '',
+		created_at  DATETIME NOT NULL
+	);
+	`)
+
+	// Who wants to hear about status changes of a task or epic.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS watchers (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id     INTEGER NOT NULL REFERENCES tasks(id),
+		target      TEXT NOT NULL,
+		created_at  DATETIME NOT NULL,
+		UNIQUE (task_id, target)
+	);
+	`)
+
+	// When each task entered and left each status, for cycle-time stats.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS status_history (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id     INTEGER NOT NULL REFERENCES tasks(id),
+		status      TEXT NOT NULL,
+		entered_at  DATETIME NOT NULL,
+		left_at     DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_status_history_task ON status_history(task_id);
+	`)
+	s.backfillStatusHistory()
+
+	// Liveness of commands working on a task outside a pipeline run.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS heartbeats (
+		task_id  INTEGER PRIMARY KEY REFERENCES tasks(id),
+		pid      INTEGER NOT NULL DEFAULT 0,
+		beat_at  DATETIME NOT NULL
+	);
+	`)
+
+	// Last rate-limit state reported by each API key's provider.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS rate_limits (
+		key                TEXT PRIMARY KEY,
+		provider           TEXT NOT NULL DEFAULT '',
+		requests_limit     INTEGER NOT NULL DEFAULT -1,
+		requests_remaining INTEGER NOT NULL DEFAULT -1,
+		requests_reset     DATETIME,
+		tokens_limit       INTEGER NOT NULL DEFAULT -1,
+		tokens_remaining   INTEGER NOT NULL DEFAULT -1,
+		tokens_reset       DATETIME,
+		retry_after        DATETIME,
+		updated_at         DATETIME NOT NULL
+	);
+	`)
+
+	// Every finished agent call, for estimating a run from past ones.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS agent_calls (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id       INTEGER NOT NULL DEFAULT 0,
+		agent         TEXT NOT NULL DEFAULT '',
+		role          TEXT NOT NULL DEFAULT '',
+		mode          TEXT NOT NULL DEFAULT '',
+		duration      REAL NOT NULL DEFAULT 0,
+		input_tokens  INTEGER NOT NULL DEFAULT 0,
+		output_tokens INTEGER NOT NULL DEFAULT 0,
+		cost          REAL NOT NULL DEFAULT 0,
+		failed        INTEGER NOT NULL DEFAULT 0,
+		at            DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_agent_calls_role_time ON agent_calls(role, at);
+	`)
+
+	// What each epic looked like when it was accepted. Snapshots outlive
+	// branches and run files, so they are never changed or deleted.
+	_, _ = s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS epic_snapshots (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		epic_id      INTEGER NOT NULL REFERENCES tasks(id),
+		title        TEXT NOT NULL DEFAULT '',
+		branch       TEXT NOT NULL DEFAULT '',
+		base_branch  TEXT NOT NULL DEFAULT '',
+		report       TEXT NOT NULL DEFAULT '',
+		created_at   DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_epic_snapshots_epic ON epic_snapshots(epic_id);
+	CREATE TRIGGER IF NOT EXISTS epic_snapshots_no_update BEFORE UPDATE ON epic_snapshots
+	BEGIN SELECT RAISE(ABORT, 'epic snapshots are immutable'); END;
+	CREATE TRIGGER IF NOT EXISTS epic_snapshots_no_delete BEFORE DELETE ON epic_snapshots
+	BEGIN SELECT RAISE(ABORT, 'epic snapshots are immutable'); END;
+	`)
+
+	// Indexes for hive history, which searches runs and reviews across
+	// all epics by agent, verdict and time.
+	_, _ = s.db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_events_time ON events(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_events_agent_time ON events(agent, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_reviews_time ON reviews(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_reviews_agent_time ON reviews(reviewer_agent, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_reviews_verdict_time ON reviews(verdict, timestamp);
+	`)
+
 	// Migrate existing databases: add new columns if missing.
 	s.addColumnIfMissing("tasks", "kind", "TEXT NOT NULL DEFAULT 'task'")
 	s.addColumnIfMissing("tasks", "git_branch", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "adopted_ref", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "base_branch", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "acceptance", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "setup_cmd", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "commits", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "merge_commit", "TEXT DEFAULT ''")
+	s.addColumnIfMissing("tasks", "plan_hash", "TEXT DEFAULT ''")
 
 	return nil
 }
@@ -152,7 +325,7 @@ func (s *Store) createItem(kind TaskKind, title, description, priority string, p
 		priority = "medium"
 	}
 
-	res, err := s.db.Exec(
+	res, err := s.exec(
 		`INSERT INTO tasks (kind, title, description, status, priority, parent_id, created_at, updated_at)
 		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 		string(kind), title, description, string(StatusBacklog), priority, parentID, now, now,
@@ -162,6 +335,7 @@ func (s *Store) createItem(kind TaskKind, title, description, priority string, p
 	}
 
 	id, _ := res.LastInsertId()
+	s.recordStatus(id, StatusBacklog, now)
 
 	label := "Task"
 	if kind == KindEpic {
@@ -183,7 +357,7 @@ func (s *Store) createItem(kind TaskKind, title, description, priority string, p
 }
 
 // taskColumns is the standard column list for task queries.
-const taskColumns = `id, parent_id, kind, title, description, status, assigned_agent, role, priority, blocked_reason, git_branch, created_at, updated_at`
+const taskColumns = `id, parent_id, kind, title, description, status, assigned_agent, role, priority, blocked_reason, git_branch, adopted_ref, base_branch, acceptance, setup_cmd, commits, merge_commit, plan_hash, created_at, updated_at`
 
 // GetTask returns a single task or epic by ID.
 func (s *Store) GetTask(id int64) (*Task, error) {
@@ -257,24 +431,45 @@ func (s *Store) queryTasks(query string, args ...any) ([]Task, error) {
 	return tasks, rows.Err()
 }
 
+// CountTasks returns how many epics and tasks are in each status.
+func (s *Store) CountTasks() ([]TaskCount, error) {
+	rows, err := s.db.Query(`SELECT kind, status, COUNT(*) FROM tasks GROUP BY kind, status ORDER BY kind, status`)
+	if err != nil {
+		return nil, fmt.Errorf("count tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []TaskCount
+	for rows.Next() {
+		var c TaskCount
+		if err := rows.Scan(&c.Kind, &c.Status, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan task count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
 // UpdateTaskStatus changes the status of a task.
 func (s *Store) UpdateTaskStatus(id int64, status TaskStatus) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`UPDATE tasks SET status = ?, updated_at = ? WHERE id = ?`,
 		string(status), now, id,
 	)
 	if err != nil {
 		return fmt.Errorf("update task status: %w", err)
 	}
+	s.recordStatus(id, status, now)
 	s.AddEvent(id, "", "status_changed", fmt.Sprintf("Status changed to %s", status))
+	s.statusChanged(id, status, "")
 	return nil
 }
 
 // AssignTask assigns an agent and role to a task.
 func (s *Store) AssignTask(id int64, agent, role string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`UPDATE tasks SET assigned_agent = ?, role = ?, updated_at = ? WHERE id = ?`,
 		agent, role, now, id,
 	)
@@ -288,28 +483,32 @@ func (s *Store) AssignTask(id int64, agent, role string) error {
 // BlockTask marks a task as blocked with a reason.
 func (s *Store) BlockTask(id int64, reason string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`UPDATE tasks SET status = ?, blocked_reason = ?, updated_at = ? WHERE id = ?`,
 		string(StatusBlocked), reason, now, id,
 	)
 	if err != nil {
 		return fmt.Errorf("block task: %w", err)
 	}
+	s.recordStatus(id, StatusBlocked, now)
 	s.AddEvent(id, "", "blocked", reason)
+	s.statusChanged(id, StatusBlocked, reason)
 	return nil
 }
 
 // UnblockTask resolves a blocker with the user's answer.
 func (s *Store) UnblockTask(id int64, answer string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`UPDATE tasks SET status = ?, blocked_reason = '', updated_at = ? WHERE id = ?`,
 		string(StatusBacklog), now, id,
 	)
 	if err != nil {
 		return fmt.Errorf("unblock task: %w", err)
 	}
+	s.recordStatus(id, StatusBacklog, now)
 	s.AddEvent(id, "user", "unblocked", fmt.Sprintf("User answered: %s", answer))
+	s.statusChanged(id, StatusBacklog, answer)
 	return nil
 }
 
@@ -338,17 +537,39 @@ func (s *Store) GetEvents(taskID int64) ([]Event, error) {
 // AddArtifact records an artifact for a task.
 func (s *Store) AddArtifact(taskID int64, artifactType, filePath string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`INSERT INTO artifacts (task_id, type, file_path, timestamp) VALUES (?, ?, ?, ?)`,
 		taskID, artifactType, filePath, now,
 	)
 	return err
 }
 
+// GetArtifacts returns a task's artifacts, oldest first.
+func (s *Store) GetArtifacts(taskID int64) ([]Artifact, error) {
+	rows, err := s.db.Query(
+		`SELECT id, task_id, type, file_path, timestamp FROM artifacts WHERE task_id = ? ORDER BY id`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	var arts []Artifact
+	for rows.Next() {
+		var a Artifact
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.Type, &a.FilePath, &a.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan artifact: %w", err)
+		}
+		arts = append(arts, a)
+	}
+	return arts, rows.Err()
+}
+
 // AddReview records a review verdict.
 func (s *Store) AddReview(taskID int64, reviewerAgent, verdict, comments string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`INSERT INTO reviews (task_id, reviewer_agent, verdict, comments, timestamp) VALUES (?, ?, ?, ?, ?)`,
 		taskID, reviewerAgent, verdict, comments, now,
 	)
@@ -356,14 +577,108 @@ func (s *Store) AddReview(taskID int64, reviewerAgent, verdict, comments string)
 		return err
 	}
 	s.AddEvent(taskID, reviewerAgent, "reviewed", fmt.Sprintf("Verdict: %s", verdict))
+	if s.reviewHook != nil {
+		s.reviewHook(taskID, reviewerAgent, verdict, comments)
+	}
+	return nil
+}
+
+// GetReviews returns a task's reviews, oldest first.
+func (s *Store) GetReviews(taskID int64) ([]Review, error) {
+	rows, err := s.db.Query(
+		`SELECT id, task_id, reviewer_agent, verdict, comments, timestamp FROM reviews WHERE task_id = ? ORDER BY timestamp, id`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		var r Review
+		if err := rows.Scan(&r.ID, &r.TaskID, &r.ReviewerAgent, &r.Verdict, &r.Comments, &r.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan review: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// RecordTaskFiles stores the files for one coder iteration, replacing
+// anything previously recorded for that iteration.
+func (s *Store) RecordTaskFiles(taskID int64, iteration int, files []TaskFile) error {
+	err := s.withWrite(context.Background(), func(ctx context.Context) error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(`DELETE FROM task_files WHERE task_id = ? AND iteration = ?`, taskID, iteration); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, f := range files {
+			_, err := tx.Exec(
+				`INSERT INTO task_files (task_id, iteration, path, declared, changed, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+				taskID, iteration, f.Path, f.Declared, f.Changed, now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return fmt.Errorf("record task files: %w", err)
+	}
+	return nil
+}
+
+// GetTaskFiles returns the files recorded for the latest coder iteration
+// of a task, sorted by path.
+func (s *Store) GetTaskFiles(taskID int64) ([]TaskFile, error) {
+	rows, err := s.db.Query(
+		`SELECT id, task_id, iteration, path, declared, changed, timestamp FROM task_files
+		 WHERE task_id = ? AND iteration = (SELECT MAX(iteration) FROM task_files WHERE task_id = ?)
+		 ORDER BY path`, taskID, taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get task files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []TaskFile
+	for rows.Next() {
+		var f TaskFile
+		if err := rows.Scan(&f.ID, &f.TaskID, &f.Iteration, &f.Path, &f.Declared, &f.Changed, &f.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan task file: %w", err)
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// SetParent moves a task under another epic or task.
+func (s *Store) SetParent(id, parentID int64) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET parent_id = ?, updated_at = ? WHERE id = ?`,
+		parentID, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set parent: %w", err)
+	}
 	return nil
 }
 
 // SetGitBranch records the git safety branch for an epic or task.
 func (s *Store) SetGitBranch(id int64, branch string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
-		`UPDATE tasks SET git_branch = ?, updated_at = ? WHERE id = ?`,
+	_, err := s.exec(
+		`UPDATE tasks SET git_branch = ?, adopted_ref = '', updated_at = ? WHERE id = ?`,
 		branch, now, id,
 	)
 	if err != nil {
@@ -372,12 +687,341 @@ func (s *Store) SetGitBranch(id int64, branch string) error {
 	return nil
 }
 
+// SetSetupCmd records the command that prepares a new worktree of an
+// epic, overriding setup_cmd from the config. Empty means use the config.
+func (s *Store) SetSetupCmd(id int64, cmd string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET setup_cmd = ?, updated_at = ? WHERE id = ?`,
+		strings.TrimSpace(cmd), now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set setup cmd: %w", err)
+	}
+	return nil
+}
+
+// AppendDescription adds a paragraph to the end of a task's description,
+// e.g. a requirement added to an epic after it was planned.
+func (s *Store) AppendDescription(id int64, text string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET description = CASE WHEN COALESCE(description, '') = '' THEN ? ELSE description || char(10) || char(10) || ? END,
+		 updated_at = ? WHERE id = ?`,
+		strings.TrimSpace(text), strings.TrimSpace(text), now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("append description: %w", err)
+	}
+	return nil
+}
+
+// SetTaskCommits records the existing commits a task was adopted from,
+// oldest first. Reviews of the task look at their diff.
+func (s *Store) SetTaskCommits(id int64, commits []string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET commits = ?, updated_at = ? WHERE id = ?`,
+		strings.Join(commits, " "), now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set task commits: %w", err)
+	}
+	return nil
+}
+
+// SetMergeCommit marks an approved task whose commit (hash) couldn't be
+// merged into the epic branch, or clears the mark with "".
+func (s *Store) SetMergeCommit(id int64, commit string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET merge_commit = ?, updated_at = ? WHERE id = ?`,
+		commit, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set merge commit: %w", err)
+	}
+	return nil
+}
+
+// SetPlanHash records the hash of the plan artifact an epic's tasks are
+// created from, before they are, so a run that stops halfway is picked up
+// from the same plan instead of asking the PM again.
+func (s *Store) SetPlanHash(id int64, hash string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET plan_hash = ?, updated_at = ? WHERE id = ?`,
+		hash, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set plan hash: %w", err)
+	}
+	return nil
+}
+
+// SetBaseBranch records the integration branch an epic is diffed against
+// and merged into. Empty means auto-detect (main/master).
+func (s *Store) SetBaseBranch(id int64, branch string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET base_branch = ?, updated_at = ? WHERE id = ?`,
+		branch, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set base branch: %w", err)
+	}
+	return nil
+}
+
+// SetAcceptance records a task's acceptance criteria, one line each.
+func (s *Store) SetAcceptance(id int64, criteria []string) error {
+	lines := make([]string, 0, len(criteria))
+	for _, c := range criteria {
+		if c = strings.Join(strings.Fields(c), " "); c != "" {
+			lines = append(lines, c)
+		}
+	}
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET acceptance = ?, updated_at = ? WHERE id = ?`,
+		strings.Join(lines, "\n"), now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set acceptance: %w", err)
+	}
+	return nil
+}
+
+// AdoptGitBranch records an existing branch as the safety branch, along
+// with the commit it pointed at, so rejecting the epic only discards the
+// work hive added on top.
+func (s *Store) AdoptGitBranch(id int64, branch, ref string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE tasks SET git_branch = ?, adopted_ref = ?, updated_at = ? WHERE id = ?`,
+		branch, ref, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("adopt git branch: %w", err)
+	}
+	return nil
+}
+
+// --- Auto-stash tracking ---
+
+// RecordStash records a stash hive made of the user's changes on branch
+// before switching to a task's safety branch.
+func (s *Store) RecordStash(taskID int64, ref, branch string) (int64, error) {
+	now := time.Now().UTC()
+	res, err := s.exec(
+		`INSERT INTO stashes (task_id, stash_ref, branch, status, created_at) VALUES (?, ?, ?, 'stashed', ?)`,
+		taskID, ref, branch, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("record stash: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return id, nil
+}
+
+// MarkStashRestored marks a stash as applied back to the working tree.
+func (s *Store) MarkStashRestored(id int64) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`UPDATE stashes SET status = 'restored', restored_at = ? WHERE id = ?`,
+		now, id,
+	)
+	return err
+}
+
+// ListPendingStashes returns stashes that were never restored, newest
+// first. Pass taskID 0 for all tasks.
+func (s *Store) ListPendingStashes(taskID int64) ([]Stash, error) {
+	query := `SELECT id, task_id, stash_ref, branch, status, created_at FROM stashes WHERE status = 'stashed'`
+	var args []any
+	if taskID != 0 {
+		query += ` AND task_id = ?`
+		args = append(args, taskID)
+	}
+	query += ` ORDER BY id DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list stashes: %w", err)
+	}
+	defer rows.Close()
+
+	var stashes []Stash
+	for rows.Next() {
+		var st Stash
+		if err := rows.Scan(&st.ID, &st.TaskID, &st.Ref, &st.Branch, &st.Status, &st.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan stash: %w", err)
+		}
+		stashes = append(stashes, st)
+	}
+	return stashes, rows.Err()
+}
+
+// SaveHandoff records the handoff for a finished task, replacing any
+// earlier one (a task can be re-run).
+func (s *Store) SaveHandoff(taskID int64, notes, files []string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`INSERT OR REPLACE INTO task_handoffs (task_id, notes, files, created_at) VALUES (?, ?, ?, ?)`,
+		taskID, strings.Join(notes, "\n"), strings.Join(files, "\n"), now,
+	)
+	if err != nil {
+		return fmt.Errorf("save handoff: %w", err)
+	}
+	return nil
+}
+
+// ListEpicHandoffs returns the handoffs of an epic's tasks in the order
+// they were finished.
+func (s *Store) ListEpicHandoffs(epicID int64) ([]Handoff, error) {
+	rows, err := s.db.Query(
+		`SELECT h.task_id, t.title, h.notes, h.files, h.created_at
+		 FROM task_handoffs h JOIN tasks t ON t.id = h.task_id
+		 WHERE t.parent_id = ? ORDER BY h.created_at, h.task_id`, epicID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	var handoffs []Handoff
+	for rows.Next() {
+		var h Handoff
+		var notes, files string
+		if err := rows.Scan(&h.TaskID, &h.Title, &notes, &files, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan handoff: %w", err)
+		}
+		h.Notes = splitLines(notes)
+		h.Files = splitLines(files)
+		handoffs = append(handoffs, h)
+	}
+	return handoffs, rows.Err()
+}
+
+// splitLines splits newline-joined values, returning nil for "".
+func splitLines(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, "\n")
+}
+
+// --- Watchers ---
+
+// StatusHook is called after a task's status changes through
+// UpdateTaskStatus, BlockTask or UnblockTask. detail is the blocker reason
+// or the user's answer, when there is one.
+type StatusHook func(taskID int64, status TaskStatus, detail string)
+
+// SetStatusHook installs fn to be called on status changes. Bulk resets
+// (ResetStaleTasks, ResetFailedTasks) don't call it.
+func (s *Store) SetStatusHook(fn StatusHook) {
+	s.statusHook = fn
+}
+
+// ReviewHook is called after AddReview records a review.
+type ReviewHook func(taskID int64, reviewer, verdict, comments string)
+
+// SetReviewHook installs fn to be called on new reviews.
+func (s *Store) SetReviewHook(fn ReviewHook) {
+	s.reviewHook = fn
+}
+
+func (s *Store) statusChanged(id int64, status TaskStatus, detail string) {
+	if s.statusHook != nil {
+		s.statusHook(id, status, detail)
+	}
+}
+
+// AddWatcher subscribes target to status changes of a task. Adding the
+// same target twice is a no-op.
+func (s *Store) AddWatcher(taskID int64, target string) error {
+	now := time.Now().UTC()
+	_, err := s.exec(
+		`INSERT OR IGNORE INTO watchers (task_id, target, created_at) VALUES (?, ?, ?)`,
+		taskID, target, now,
+	)
+	if err != nil {
+		return fmt.Errorf("add watcher: %w", err)
+	}
+	return nil
+}
+
+// RemoveWatcher unsubscribes target from a task, or every target when
+// target is empty. Returns the number of watchers removed.
+func (s *Store) RemoveWatcher(taskID int64, target string) (int, error) {
+	query := `DELETE FROM watchers WHERE task_id = ?`
+	args := []any{taskID}
+	if target != "" {
+		query += ` AND target = ?`
+		args = append(args, target)
+	}
+	res, err := s.exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("remove watcher: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ListWatchers returns the watchers of a task, oldest first.
+func (s *Store) ListWatchers(taskID int64) ([]Watcher, error) {
+	rows, err := s.db.Query(
+		`SELECT id, task_id, target, created_at FROM watchers WHERE task_id = ? ORDER BY id`,
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list watchers: %w", err)
+	}
+	defer rows.Close()
+
+	var watchers []Watcher
+	for rows.Next() {
+		var w Watcher
+		if err := rows.Scan(&w.ID, &w.TaskID, &w.Target, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan watcher: %w", err)
+		}
+		watchers = append(watchers, w)
+	}
+	return watchers, rows.Err()
+}
+
+// WatchTargets returns the distinct targets to notify about a task: its
+// own watchers and those of its parent epic.
+func (s *Store) WatchTargets(taskID int64) ([]string, error) {
+	rows, err := s.db.Query(
+		`SELECT target FROM watchers
+		 WHERE task_id = ? OR task_id = (SELECT parent_id FROM tasks WHERE id = ?)
+		 GROUP BY target ORDER BY MIN(id)`,
+		taskID, taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("watch targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("scan watcher: %w", err)
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
 // --- Pipeline run tracking ---
 
 // StartPipelineRun records a new pipeline run.
 func (s *Store) StartPipelineRun(epicID int64, maxLoops, parallel int) (int64, error) {
 	now := time.Now().UTC()
-	res, err := s.db.Exec(
+	res, err := s.exec(
 		`INSERT INTO pipeline_runs (epic_id, status, max_loops, parallel, started_at)
 		 VALUES (?, 'running', ?, ?, ?)`,
 		epicID, maxLoops, parallel, now,
@@ -392,7 +1036,7 @@ func (s *Store) StartPipelineRun(epicID int64, maxLoops, parallel int) (int64, e
 // EndPipelineRun marks a pipeline run as completed or failed.
 func (s *Store) EndPipelineRun(runID int64, status string) error {
 	now := time.Now().UTC()
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		`UPDATE pipeline_runs SET status = ?, ended_at = ? WHERE id = ?`,
 		status, now, runID,
 	)
@@ -450,29 +1094,87 @@ func (s *Store) ListInterruptedRuns() ([]PipelineRun, error) {
 	return runs, rows.Err()
 }
 
+// SaveCheckpoint records where a task is in its fix loop in a pipeline
+// run, replacing the run's previous checkpoint for the task.
+func (s *Store) SaveCheckpoint(c TaskCheckpoint) error {
+	_, err := s.exec(
+		`INSERT INTO pipeline_task_runs (run_id, task_id, iteration, phase, code_artifact, review_artifact, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (run_id, task_id) DO UPDATE SET
+		   iteration = excluded.iteration, phase = excluded.phase,
+		   code_artifact = excluded.code_artifact, review_artifact = excluded.review_artifact,
+		   updated_at = excluded.updated_at`,
+		c.RunID, c.TaskID, c.Iteration, c.Phase, c.CodeArtifact, c.ReviewArtifact, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ListCheckpoints returns the checkpoints of a pipeline run by task ID.
+func (s *Store) ListCheckpoints(runID int64) (map[int64]TaskCheckpoint, error) {
+	rows, err := s.db.Query(
+		`SELECT run_id, task_id, iteration, phase, code_artifact, review_artifact, updated_at
+		 FROM pipeline_task_runs WHERE run_id = ?`, runID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[int64]TaskCheckpoint{}
+	for rows.Next() {
+		var c TaskCheckpoint
+		if err := rows.Scan(&c.RunID, &c.TaskID, &c.Iteration, &c.Phase, &c.CodeArtifact, &c.ReviewArtifact, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan checkpoint: %w", err)
+		}
+		out[c.TaskID] = c
+	}
+	return out, rows.Err()
+}
+
 // ResetStaleTasks finds tasks stuck in in_progress or review status
 // (likely from a crash) and resets them to backlog.
 func (s *Store) ResetStaleTasks(epicID int64) (int, error) {
+	return s.resetTasks(epicID, StatusInProgress, StatusReview)
+}
+
+// ResetFailedTasks moves an epic's failed tasks back to backlog so the
+// next pipeline run tries them again. Returns the number of tasks reset.
+func (s *Store) ResetFailedTasks(epicID int64) (int, error) {
+	return s.resetTasks(epicID, StatusFailed)
+}
+
+// resetTasks moves an epic's tasks in any of statuses back to backlog.
+func (s *Store) resetTasks(epicID int64, statuses ...TaskStatus) (int, error) {
+	var ids []int64
+	for _, st := range statuses {
+		tasks, err := s.queryTasks(`SELECT `+taskColumns+` FROM tasks WHERE parent_id = ? AND status = ?`, epicID, string(st))
+		if err != nil {
+			return 0, fmt.Errorf("reset tasks: %w", err)
+		}
+		for _, t := range tasks {
+			ids = append(ids, t.ID)
+		}
+	}
+
 	now := time.Now().UTC()
-	res, err := s.db.Exec(
-		`UPDATE tasks SET status = ?, updated_at = ?
-		 WHERE parent_id = ? AND status IN (?, ?)`,
-		string(StatusBacklog), now, epicID,
-		string(StatusInProgress), string(StatusReview),
-	)
-	if err != nil {
-		return 0, fmt.Errorf("reset stale tasks: %w", err)
+	for _, id := range ids {
+		if _, err := s.exec(`UPDATE tasks SET status = ?, updated_at = ? WHERE id = ?`, string(StatusBacklog), now, id); err != nil {
+			return 0, fmt.Errorf("reset tasks: %w", err)
+		}
+		s.recordStatus(id, StatusBacklog, now)
 	}
-	n, _ := res.RowsAffected()
-	return int(n), nil
+	return len(ids), nil
 }
 
-// AddEvent records an event for a task.
+// AddEvent records an event for a task. Secrets in content are masked.
 func (s *Store) AddEvent(taskID int64, agent, eventType, content string) {
 	now := time.Now().UTC()
-	s.db.Exec(
+	s.exec(
 		`INSERT INTO events (task_id, agent, event_type, content, timestamp) VALUES (?, ?, ?, ?, ?)`,
-		taskID, agent, eventType, content, now,
+		taskID, agent, eventType, redact.String(content), now,
 	)
 }
 
@@ -480,14 +1182,17 @@ func (s *Store) AddEvent(taskID int64, agent, eventType, content string) {
 func scanTask(row *sql.Row) (*Task, error) {
 	var t Task
 	var parentID sql.NullInt64
+	var acceptance, commits string
 	err := row.Scan(
 		&t.ID, &parentID, &t.Kind, &t.Title, &t.Description, &t.Status,
 		&t.AssignedAgent, &t.Role, &t.Priority, &t.BlockedReason,
-		&t.GitBranch, &t.CreatedAt, &t.UpdatedAt,
+		&t.GitBranch, &t.AdoptedRef, &t.BaseBranch, &acceptance, &t.SetupCmd, &commits, &t.MergeCommit, &t.PlanHash, &t.CreatedAt, &t.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan task: %w", err)
 	}
+	t.Acceptance = splitLines(acceptance)
+	t.Commits = strings.Fields(commits)
 	if parentID.Valid {
 		t.ParentID = &parentID.Int64
 	}
@@ -498,14 +1203,17 @@ func scanTask(row *sql.Row) (*Task, error) {
 func scanTaskRows(rows *sql.Rows) (*Task, error) {
 	var t Task
 	var parentID sql.NullInt64
+	var acceptance, commits string
 	err := rows.Scan(
 		&t.ID, &parentID, &t.Kind, &t.Title, &t.Description, &t.Status,
 		&t.AssignedAgent, &t.Role, &t.Priority, &t.BlockedReason,
-		&t.GitBranch, &t.CreatedAt, &t.UpdatedAt,
+		&t.GitBranch, &t.AdoptedRef, &t.BaseBranch, &acceptance, &t.SetupCmd, &commits, &t.MergeCommit, &t.PlanHash, &t.CreatedAt, &t.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan task: %w", err)
 	}
+	t.Acceptance = splitLines(acceptance)
+	t.Commits = strings.Fields(commits)
 	if parentID.Valid {
 		t.ParentID = &parentID.Int64
 	}
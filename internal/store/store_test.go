@@ -1,8 +1,10 @@
 package store
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -172,6 +174,32 @@ func TestUpdateTaskStatus(t *testing.T) {
 	}
 }
 
+func TestCountTasks(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "")
+	s.CreateTask("A", "", "", &epic.ID)
+	b, _ := s.CreateTask("B", "", "", &epic.ID)
+	s.UpdateTaskStatus(b.ID, StatusFailed)
+
+	counts, err := s.CountTasks()
+	if err != nil {
+		t.Fatalf("CountTasks: %v", err)
+	}
+	want := []TaskCount{
+		{KindEpic, StatusBacklog, 1},
+		{KindTask, StatusBacklog, 1},
+		{KindTask, StatusFailed, 1},
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("expected %v, got %v", want, counts)
+	}
+	for i := range want {
+		if counts[i] != want[i] {
+			t.Errorf("count %d: expected %v, got %v", i, want[i], counts[i])
+		}
+	}
+}
+
 func TestAssignTask(t *testing.T) {
 	s := testStore(t)
 
@@ -265,6 +293,14 @@ func TestAddArtifact(t *testing.T) {
 	if err := s.AddArtifact(task.ID, "diff", "/tmp/test.diff"); err != nil {
 		t.Fatalf("AddArtifact: %v", err)
 	}
+
+	arts, err := s.GetArtifacts(task.ID)
+	if err != nil {
+		t.Fatalf("GetArtifacts: %v", err)
+	}
+	if len(arts) != 1 || arts[0].Type != "diff" || arts[0].FilePath != "/tmp/test.diff" {
+		t.Errorf("unexpected artifacts %+v", arts)
+	}
 }
 
 func TestAddReview(t *testing.T) {
@@ -287,6 +323,15 @@ func TestAddReview(t *testing.T) {
 	if !found {
 		t.Error("expected 'reviewed' event after AddReview")
 	}
+
+	s.AddReview(task.ID, "claude-reviewer", "reject", "Missing tests")
+	reviews, err := s.GetReviews(task.ID)
+	if err != nil {
+		t.Fatalf("GetReviews: %v", err)
+	}
+	if len(reviews) != 2 || reviews[0].Verdict != "approve" || reviews[1].ReviewerAgent != "claude-reviewer" {
+		t.Errorf("unexpected reviews %+v", reviews)
+	}
 }
 
 // --- Epic/Task Kind tests ---
@@ -383,6 +428,24 @@ func TestListTasksByEpic(t *testing.T) {
 	}
 }
 
+func TestSetParent(t *testing.T) {
+	s := testStore(t)
+
+	a, _ := s.CreateEpic("A", "", "high")
+	b, _ := s.CreateEpic("B", "", "high")
+	task, _ := s.CreateTask("Sub", "", "high", &b.ID)
+
+	if err := s.SetParent(task.ID, a.ID); err != nil {
+		t.Fatalf("SetParent: %v", err)
+	}
+	if tasks, _ := s.ListTasksByEpic(a.ID); len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Errorf("expected the task under A, got %+v", tasks)
+	}
+	if tasks, _ := s.ListTasksByEpic(b.ID); len(tasks) != 0 {
+		t.Errorf("expected B to be empty, got %+v", tasks)
+	}
+}
+
 func TestListOnlyTasks(t *testing.T) {
 	s := testStore(t)
 
@@ -420,6 +483,168 @@ func TestSetGitBranch(t *testing.T) {
 	}
 }
 
+func TestAdoptGitBranch(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Adopt test", "", "high")
+
+	if err := s.AdoptGitBranch(epic.ID, "feature/login", "abc123"); err != nil {
+		t.Fatalf("AdoptGitBranch: %v", err)
+	}
+	got, _ := s.GetTask(epic.ID)
+	if got.GitBranch != "feature/login" || got.AdoptedRef != "abc123" {
+		t.Errorf("expected adopted feature/login@abc123, got %q@%q", got.GitBranch, got.AdoptedRef)
+	}
+
+	// Switching back to a hive branch clears the adopted ref.
+	s.SetGitBranch(epic.ID, "hive/epic-1")
+	got, _ = s.GetTask(epic.ID)
+	if got.AdoptedRef != "" {
+		t.Errorf("expected adopted_ref cleared, got %q", got.AdoptedRef)
+	}
+}
+
+func TestSetBaseBranch(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Gitflow epic", "", "high")
+	if err := s.SetBaseBranch(epic.ID, "develop"); err != nil {
+		t.Fatalf("SetBaseBranch: %v", err)
+	}
+
+	got, _ := s.GetTask(epic.ID)
+	if got.BaseBranch != "develop" {
+		t.Errorf("expected base_branch 'develop', got %q", got.BaseBranch)
+	}
+}
+
+func TestSetMergeCommit(t *testing.T) {
+	s := testStore(t)
+
+	task, _ := s.CreateTask("Conflicting", "", "medium", nil)
+	if err := s.SetMergeCommit(task.ID, "abc1234"); err != nil {
+		t.Fatalf("SetMergeCommit: %v", err)
+	}
+	if got, _ := s.GetTask(task.ID); got.MergeCommit != "abc1234" {
+		t.Errorf("expected merge_commit 'abc1234', got %q", got.MergeCommit)
+	}
+
+	s.SetMergeCommit(task.ID, "")
+	if tasks, _ := s.ListTasks(""); tasks[0].MergeCommit != "" {
+		t.Errorf("expected merge_commit cleared, got %q", tasks[0].MergeCommit)
+	}
+}
+
+func TestSetPlanHash(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Planned", "", "")
+	if err := s.SetPlanHash(epic.ID, "9f86d08"); err != nil {
+		t.Fatalf("SetPlanHash: %v", err)
+	}
+	if got, _ := s.GetTask(epic.ID); got.PlanHash != "9f86d08" {
+		t.Errorf("expected plan_hash '9f86d08', got %q", got.PlanHash)
+	}
+}
+
+func TestSetSetupCmd(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Frontend epic", "", "high")
+	if err := s.SetSetupCmd(epic.ID, "  npm ci\n"); err != nil {
+		t.Fatalf("SetSetupCmd: %v", err)
+	}
+
+	got, _ := s.GetTask(epic.ID)
+	if got.SetupCmd != "npm ci" {
+		t.Errorf("expected setup_cmd 'npm ci', got %q", got.SetupCmd)
+	}
+}
+
+func TestAppendDescription(t *testing.T) {
+	s := testStore(t)
+
+	empty, _ := s.CreateEpic("Empty", "", "medium")
+	s.AppendDescription(empty.ID, "  Also export CSV\n")
+	if got, _ := s.GetTask(empty.ID); got.Description != "Also export CSV" {
+		t.Errorf("unexpected description: %q", got.Description)
+	}
+
+	epic, _ := s.CreateEpic("Reports", "Monthly reports.", "medium")
+	if err := s.AppendDescription(epic.ID, "Also export CSV"); err != nil {
+		t.Fatalf("AppendDescription: %v", err)
+	}
+	if got, _ := s.GetTask(epic.ID); got.Description != "Monthly reports.\n\nAlso export CSV" {
+		t.Errorf("unexpected description: %q", got.Description)
+	}
+}
+
+func TestSetTaskCommits(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Adopted", "", "medium")
+	task, _ := s.CreateTask("Add c", "", "medium", &epic.ID)
+	if err := s.SetTaskCommits(task.ID, []string{"aaa111", "bbb222"}); err != nil {
+		t.Fatalf("SetTaskCommits: %v", err)
+	}
+
+	got, _ := s.GetTask(task.ID)
+	if len(got.Commits) != 2 || got.Commits[0] != "aaa111" || got.Commits[1] != "bbb222" {
+		t.Errorf("unexpected commits: %v", got.Commits)
+	}
+	if tasks, _ := s.ListTasksByEpic(epic.ID); len(tasks) != 1 || len(tasks[0].Commits) != 2 {
+		t.Errorf("commits not listed: %+v", tasks)
+	}
+}
+
+func TestSetAcceptance(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Auth", "", "high")
+	task, _ := s.CreateTask("Login", "", "high", &epic.ID)
+	if err := s.SetAcceptance(task.ID, []string{"returns 401 on bad\n  credentials", "", "sets a session cookie"}); err != nil {
+		t.Fatalf("SetAcceptance: %v", err)
+	}
+
+	got, _ := s.GetTask(task.ID)
+	want := []string{"returns 401 on bad credentials", "sets a session cookie"}
+	if strings.Join(got.Acceptance, "|") != strings.Join(want, "|") {
+		t.Errorf("acceptance = %q, want %q", got.Acceptance, want)
+	}
+	tasks, _ := s.ListTasksByEpic(epic.ID)
+	if len(tasks) != 1 || len(tasks[0].Acceptance) != 2 {
+		t.Errorf("listed tasks should carry their criteria, got %+v", tasks)
+	}
+}
+
+func TestRecordStash_PendingAndRestored(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Stash test", "", "high")
+	other, _ := s.CreateEpic("Other", "", "low")
+
+	id, err := s.RecordStash(epic.ID, "abc123", "main")
+	if err != nil {
+		t.Fatalf("RecordStash: %v", err)
+	}
+	s.RecordStash(other.ID, "def456", "develop")
+
+	pending, _ := s.ListPendingStashes(epic.ID)
+	if len(pending) != 1 || pending[0].Ref != "abc123" || pending[0].Branch != "main" {
+		t.Fatalf("expected one pending stash abc123 on main, got %+v", pending)
+	}
+	if all, _ := s.ListPendingStashes(0); len(all) != 2 {
+		t.Fatalf("expected 2 pending stashes overall, got %d", len(all))
+	}
+
+	if err := s.MarkStashRestored(id); err != nil {
+		t.Fatalf("MarkStashRestored: %v", err)
+	}
+	if pending, _ = s.ListPendingStashes(epic.ID); len(pending) != 0 {
+		t.Errorf("expected no pending stashes after restore, got %+v", pending)
+	}
+}
+
 func TestGetTask_ReturnsKind(t *testing.T) {
 	s := testStore(t)
 
@@ -468,6 +693,36 @@ func TestStartPipelineRun(t *testing.T) {
 	}
 }
 
+func TestCheckpoints(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Pipeline epic", "", "high")
+	task, _ := s.CreateTask("Login", "", "", &epic.ID)
+	other, _ := s.CreateTask("Logout", "", "", &epic.ID)
+	runID, _ := s.StartPipelineRun(epic.ID, 3, 1)
+	laterRun, _ := s.StartPipelineRun(epic.ID, 3, 1)
+
+	s.SaveCheckpoint(TaskCheckpoint{RunID: runID, TaskID: task.ID, Iteration: 1, Phase: PhaseCode})
+	if err := s.SaveCheckpoint(TaskCheckpoint{RunID: runID, TaskID: task.ID, Iteration: 2, Phase: PhaseReview, CodeArtifact: "task-2-auto-code-iter2.md", ReviewArtifact: "task-2-auto-review-iter1.md"}); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+	s.SaveCheckpoint(TaskCheckpoint{RunID: runID, TaskID: other.ID, Iteration: 1, Phase: PhaseDone})
+	s.SaveCheckpoint(TaskCheckpoint{RunID: laterRun, TaskID: task.ID, Iteration: 1, Phase: PhaseCode})
+
+	cps, err := s.ListCheckpoints(runID)
+	if err != nil {
+		t.Fatalf("ListCheckpoints: %v", err)
+	}
+	if len(cps) != 2 {
+		t.Fatalf("expected one checkpoint per task, got %+v", cps)
+	}
+	if c := cps[task.ID]; c.Iteration != 2 || c.Phase != PhaseReview || c.CodeArtifact != "task-2-auto-code-iter2.md" || c.ReviewArtifact != "task-2-auto-review-iter1.md" {
+		t.Errorf("expected the latest checkpoint, got %+v", c)
+	}
+	if cps[other.ID].Phase != PhaseDone {
+		t.Errorf("unexpected checkpoint for #%d: %+v", other.ID, cps[other.ID])
+	}
+}
+
 func TestGetActivePipelineRun(t *testing.T) {
 	s := testStore(t)
 
@@ -633,6 +888,64 @@ func TestResetStaleTasks(t *testing.T) {
 	}
 }
 
+func TestResetFailedTasks(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Retry epic", "", "high")
+	epicID := epic.ID
+
+	failed, _ := s.CreateTask("Failed task", "", "high", &epicID)
+	done, _ := s.CreateTask("Done task", "", "low", &epicID)
+	s.UpdateTaskStatus(failed.ID, StatusFailed)
+	s.UpdateTaskStatus(done.ID, StatusDone)
+
+	count, err := s.ResetFailedTasks(epicID)
+	if err != nil || count != 1 {
+		t.Fatalf("expected 1 reset task, got %d, %v", count, err)
+	}
+	if got, _ := s.GetTask(failed.ID); got.Status != StatusBacklog {
+		t.Errorf("expected failed task back in backlog, got %s", got.Status)
+	}
+	if got, _ := s.GetTask(done.ID); got.Status != StatusDone {
+		t.Errorf("expected done task untouched, got %s", got.Status)
+	}
+}
+
+func TestSaveHandoff_ListEpicHandoffs(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Handoff epic", "", "high")
+	epicID := epic.ID
+	other, _ := s.CreateEpic("Other epic", "", "low")
+	otherID := other.ID
+
+	t1, _ := s.CreateTask("Add login", "", "high", &epicID)
+	t2, _ := s.CreateTask("Add logout", "", "high", &epicID)
+	t3, _ := s.CreateTask("Elsewhere", "", "low", &otherID)
+
+	s.SaveHandoff(t1.ID, []string{"Added auth.Login"}, []string{"auth/login.go"})
+	s.SaveHandoff(t2.ID, nil, []string{"auth/logout.go"})
+	s.SaveHandoff(t3.ID, []string{"unrelated"}, nil)
+
+	// A re-run replaces the earlier handoff.
+	s.SaveHandoff(t1.ID, []string{"Added auth.Login", "Added auth.Session"}, []string{"auth/login.go"})
+
+	got, err := s.ListEpicHandoffs(epicID)
+	if err != nil {
+		t.Fatalf("ListEpicHandoffs: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 handoffs, got %+v", got)
+	}
+	byID := map[int64]Handoff{got[0].TaskID: got[0], got[1].TaskID: got[1]}
+	if h := byID[t1.ID]; len(h.Notes) != 2 || h.Title != "Add login" || h.Files[0] != "auth/login.go" {
+		t.Errorf("unexpected handoff for #%d: %+v", t1.ID, h)
+	}
+	if h := byID[t2.ID]; h.Notes != nil || len(h.Files) != 1 {
+		t.Errorf("unexpected handoff for #%d: %+v", t2.ID, h)
+	}
+}
+
 func TestResetStaleTasks_NoneToReset(t *testing.T) {
 	s := testStore(t)
 
@@ -679,3 +992,135 @@ func TestGetActivePipelineRun_ReturnsMostRecent(t *testing.T) {
 		t.Errorf("expected parallel 2 from most recent, got %d", run.Parallel)
 	}
 }
+
+func TestRecordTaskFiles_LatestIteration(t *testing.T) {
+	s := testStore(t)
+
+	task, _ := s.CreateTask("Files task", "", "high", nil)
+
+	s.RecordTaskFiles(task.ID, 1, []TaskFile{
+		{Path: "old.go", Declared: true, Changed: false},
+	})
+	err := s.RecordTaskFiles(task.ID, 2, []TaskFile{
+		{Path: "b.go", Declared: true, Changed: true},
+		{Path: "a.go", Declared: false, Changed: true},
+	})
+	if err != nil {
+		t.Fatalf("RecordTaskFiles: %v", err)
+	}
+
+	files, err := s.GetTaskFiles(task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskFiles: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files from latest iteration, got %d", len(files))
+	}
+	if files[0].Path != "a.go" || files[0].Declared || !files[0].Changed {
+		t.Errorf("unexpected first file: %+v", files[0])
+	}
+	if files[1].Iteration != 2 {
+		t.Errorf("expected iteration 2, got %d", files[1].Iteration)
+	}
+
+	// Re-recording an iteration replaces it.
+	s.RecordTaskFiles(task.ID, 2, []TaskFile{{Path: "c.go", Changed: true}})
+	files, _ = s.GetTaskFiles(task.ID)
+	if len(files) != 1 || files[0].Path != "c.go" {
+		t.Errorf("expected iteration 2 to be replaced, got %+v", files)
+	}
+}
+
+func TestGetTaskFiles_Empty(t *testing.T) {
+	s := testStore(t)
+
+	task, _ := s.CreateTask("No files", "", "high", nil)
+	files, err := s.GetTaskFiles(task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskFiles: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files, got %d", len(files))
+	}
+}
+
+func TestWatchers(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Auth", "", "high")
+	task, _ := s.CreateTask("Login", "", "medium", &epic.ID)
+	other, _ := s.CreateTask("Unrelated", "", "medium", nil)
+
+	s.AddWatcher(epic.ID, "slack:#team")
+	s.AddWatcher(task.ID, "slack:@alice")
+	s.AddWatcher(task.ID, "slack:#team")
+	if err := s.AddWatcher(task.ID, "slack:@alice"); err != nil {
+		t.Fatalf("adding a watcher twice should be a no-op, got %v", err)
+	}
+
+	watchers, _ := s.ListWatchers(task.ID)
+	if len(watchers) != 2 {
+		t.Fatalf("expected 2 watchers on the task, got %d", len(watchers))
+	}
+
+	targets, _ := s.WatchTargets(task.ID)
+	if len(targets) != 2 || targets[0] != "slack:#team" || targets[1] != "slack:@alice" {
+		t.Errorf("expected the epic's and task's targets once each, got %v", targets)
+	}
+	if targets, _ := s.WatchTargets(other.ID); len(targets) != 0 {
+		t.Errorf("expected no targets for an unwatched task, got %v", targets)
+	}
+
+	if n, _ := s.RemoveWatcher(task.ID, "slack:@alice"); n != 1 {
+		t.Errorf("expected 1 watcher removed, got %d", n)
+	}
+	if n, _ := s.RemoveWatcher(epic.ID, ""); n != 1 {
+		t.Errorf("expected all epic watchers removed, got %d", n)
+	}
+	if targets, _ := s.WatchTargets(task.ID); len(targets) != 1 {
+		t.Errorf("expected 1 target left, got %v", targets)
+	}
+}
+
+func TestStatusHook(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Test", "", "medium", nil)
+
+	var got []string
+	s.SetStatusHook(func(id int64, status TaskStatus, detail string) {
+		got = append(got, fmt.Sprintf("%d:%s:%s", id, status, detail))
+	})
+
+	s.UpdateTaskStatus(task.ID, StatusInProgress)
+	s.BlockTask(task.ID, "which DB?")
+	s.UnblockTask(task.ID, "Postgres")
+
+	want := []string{
+		fmt.Sprintf("%d:in_progress:", task.ID),
+		fmt.Sprintf("%d:blocked:which DB?", task.ID),
+		fmt.Sprintf("%d:backlog:Postgres", task.ID),
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestReviewHook(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Test", "", "medium", nil)
+
+	var got []string
+	s.SetReviewHook(func(id int64, reviewer, verdict, comments string) {
+		got = append(got, fmt.Sprintf("%d:%s:%s:%s", id, reviewer, verdict, comments))
+	})
+
+	s.AddReview(task.ID, "claude", "reject", "missing tests")
+	s.AddReview(task.ID, "claude", "approve", "LGTM")
+
+	want := []string{
+		fmt.Sprintf("%d:claude:reject:missing tests", task.ID),
+		fmt.Sprintf("%d:claude:approve:LGTM", task.ID),
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
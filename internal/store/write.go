@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// DefaultWriteTimeout bounds how long a write waits for its turn,
+// including retries while another process holds the database lock.
+const DefaultWriteTimeout = 30 * time.Second
+
+// ErrWriteTimeout is returned when a write could not get the database in
+// time.
+var ErrWriteTimeout = errors.New("store: timed out waiting to write")
+
+// SetWriteTimeout changes how long writes wait before giving up with
+// ErrWriteTimeout. Zero restores DefaultWriteTimeout.
+func (s *Store) SetWriteTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultWriteTimeout
+	}
+	s.writeTimeout = d
+}
+
+// withWrite runs fn while holding the store's write slot. SQLite allows a
+// single writer, so parallel workers take turns here instead of racing
+// into "database is locked". Waiting respects ctx and the write timeout;
+// a busy database (another hive process writing) is retried with backoff
+// until the same deadline.
+func (s *Store) withWrite(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.writeTimeout)
+	defer cancel()
+
+	select {
+	case s.writeSlot <- struct{}{}:
+	case <-ctx.Done():
+		return writeErr(ctx)
+	}
+	defer func() { <-s.writeSlot }()
+
+	backoff := 10 * time.Millisecond
+	for {
+		err := fn(ctx)
+		if err == nil || !isBusy(err) {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return writeErr(ctx)
+		}
+		if backoff < 250*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
+
+// exec runs a single write statement through withWrite.
+func (s *Store) exec(query string, args ...any) (sql.Result, error) {
+	return s.execContext(context.Background(), query, args...)
+}
+
+// execContext is exec with a caller-supplied context.
+func (s *Store) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := s.withWrite(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+// writeErr reports why ctx ended: a deadline is ErrWriteTimeout,
+// cancellation is passed through.
+func writeErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrWriteTimeout
+	}
+	return ctx.Err()
+}
+
+// isBusy reports whether err is SQLite refusing a write because the
+// database is locked by another connection.
+func isBusy(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "SQLITE_BUSY")
+}
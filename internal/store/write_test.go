@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentWorkers_NoLockErrors(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Epic", "", "")
+
+	const workers, writes = 16, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*writes)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			task, err := s.CreateTask(fmt.Sprintf("task %d", w), "", "", &epic.ID)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for i := 0; i < writes; i++ {
+				if err := s.UpdateTaskStatus(task.ID, StatusInProgress); err != nil {
+					errs <- err
+				}
+				if err := s.AddArtifact(task.ID, "code", fmt.Sprintf("a-%d.md", i)); err != nil {
+					errs <- err
+				}
+				if err := s.RecordTaskFiles(task.ID, i, []TaskFile{{Path: "x.go", Changed: true}}); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("write failed: %v", err)
+	}
+	tasks, _ := s.ListTasksByEpic(epic.ID)
+	if len(tasks) != workers {
+		t.Errorf("expected %d tasks, got %d", workers, len(tasks))
+	}
+}
+
+func TestConcurrentStores_RetryBusy(t *testing.T) {
+	// Two handles on one file stand in for two hive processes, which the
+	// in-process write slot can't serialize.
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	a, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer a.Close()
+	b, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer b.Close()
+
+	task, _ := a.CreateTask("shared", "", "", nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for _, s := range []*Store{a, b} {
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(s *Store) {
+				defer wg.Done()
+				for i := 0; i < 25; i++ {
+					if err := s.AddArtifact(task.ID, "code", "x.md"); err != nil {
+						errs <- err
+					}
+				}
+			}(s)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("write failed: %v", err)
+	}
+	arts, _ := a.GetArtifacts(task.ID)
+	if len(arts) != 200 {
+		t.Errorf("expected 200 artifacts, got %d", len(arts))
+	}
+}
+
+func TestNew_SetsBusyTimeout(t *testing.T) {
+	s := testStore(t)
+	// Several connections, so more than the first one is checked.
+	for i := 0; i < 3; i++ {
+		var ms int
+		if err := s.db.QueryRow("PRAGMA busy_timeout").Scan(&ms); err != nil {
+			t.Fatalf("busy_timeout: %v", err)
+		}
+		if ms != int(busyTimeout.Milliseconds()) {
+			t.Fatalf("busy_timeout = %dms, want %dms", ms, busyTimeout.Milliseconds())
+		}
+	}
+}
+
+func TestWrite_TimesOutWaitingForSlot(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Task", "", "", nil)
+	s.SetWriteTimeout(50 * time.Millisecond)
+
+	// Hold the write slot as a stuck writer would.
+	s.writeSlot <- struct{}{}
+	defer func() { <-s.writeSlot }()
+
+	start := time.Now()
+	err := s.AddArtifact(task.ID, "code", "x.md")
+	if !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("expected ErrWriteTimeout, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("timeout took too long: %s", time.Since(start))
+	}
+}
+
+func TestWrite_RespectsCancel(t *testing.T) {
+	s := testStore(t)
+	s.writeSlot <- struct{}{}
+	defer func() { <-s.writeSlot }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.execContext(ctx, `SELECT 1`); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	batchSize     = 256
+	flushInterval = 5 * time.Second
+)
+
+// Init starts exporting spans to the OTLP/HTTP endpoint, e.g.
+// "http://localhost:4318" (/v1/traces is appended unless the endpoint
+// already names a path). headers are sent with every export, for
+// collectors that need an API key. The returned function flushes pending
+// spans and stops exporting; call it before exiting.
+func Init(endpoint string, headers map[string]string, serviceName string) (shutdown func(), err error) {
+	if endpoint == "" {
+		return func() {}, nil
+	}
+	url, err := tracesURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &exporter{
+		url:     url,
+		headers: headers,
+		service: serviceName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		done:    make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.loop()
+
+	mu.Lock()
+	exp = e
+	mu.Unlock()
+
+	return func() {
+		mu.Lock()
+		if exp == e {
+			exp = nil
+		}
+		mu.Unlock()
+		close(e.done)
+		e.wg.Wait()
+		e.flush()
+	}, nil
+}
+
+// tracesURL appends the OTLP traces path to a bare endpoint.
+func tracesURL(endpoint string) (string, error) {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return "", fmt.Errorf("tracing endpoint %q must start with http:// or https://", endpoint)
+	}
+	rest := endpoint[strings.Index(endpoint, "://")+3:]
+	if i := strings.IndexByte(rest, '/'); i < 0 || rest[i:] == "/" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces", nil
+	}
+	return endpoint, nil
+}
+
+// exporter batches ended spans and posts them to the collector.
+type exporter struct {
+	url     string
+	headers map[string]string
+	service string
+	client  *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (e *exporter) add(s *Span) {
+	e.mu.Lock()
+	e.pending = append(e.pending, s)
+	full := len(e.pending) >= batchSize
+	e.mu.Unlock()
+	if full {
+		go e.flush()
+	}
+}
+
+func (e *exporter) loop() {
+	defer e.wg.Done()
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			e.flush()
+		case <-e.done:
+			return
+		}
+	}
+}
+
+// flush sends pending spans. Export errors are dropped: tracing must never
+// fail or slow down a pipeline.
+func (e *exporter) flush() {
+	e.mu.Lock()
+	batch := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(encode(e.service, batch))
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// OTLP JSON encoding of ExportTraceServiceRequest. IDs are hex and
+// timestamps are decimal strings, per the OTLP/JSON spec.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 1 = ok, 2 = error
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+func encode(service string, spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		o := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              1, // internal
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttrs(s.attrs),
+		}
+		if s.parentID != [8]byte{} {
+			o.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		if s.errMsg != "" {
+			o.Status = otlpStatus{Code: 2, Message: s.errMsg}
+		}
+		s.mu.Unlock()
+		out = append(out, o)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttrs([]Attr{String("service.name", service)})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "hive"}, Spans: out}},
+	}}}
+}
+
+func encodeAttrs(attrs []Attr) []otlpKeyValue {
+	var out []otlpKeyValue
+	for _, a := range attrs {
+		var v map[string]any
+		switch x := a.Value.(type) {
+		case string:
+			v = map[string]any{"stringValue": x}
+		case bool:
+			v = map[string]any{"boolValue": x}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(x)}
+		case int64:
+			v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+		case float64:
+			v = map[string]any{"doubleValue": x}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(x)}
+		}
+		out = append(out, otlpKeyValue{Key: a.Key, Value: v})
+	}
+	return out
+}
@@ -0,0 +1,212 @@
+// Package tracing records spans for pipeline runs, tasks, agent calls and
+// git operations and exports them over OTLP/HTTP (JSON encoding), so any
+// OpenTelemetry collector can show where a long epic run spent its time.
+//
+// Tracing is off until Init is called with an endpoint; until then every
+// Start returns a nil span and all span methods are no-ops.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Span is one timed operation. A nil *Span is valid and does nothing.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+	taskID   int64
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []Attr
+	errMsg string
+	ended  bool
+}
+
+// Attr is a span attribute. Value is a string, bool, int, int64 or float64.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr { return Attr{key, value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int64) Attr { return Attr{key, value} }
+
+var (
+	mu       sync.Mutex
+	exp      *exporter
+	root     *Span
+	tasks    = make(map[int64]*Span)
+	spanKey  = struct{ name string }{"hive-span"}
+	randLock sync.Mutex
+)
+
+// Enabled reports whether spans are being exported.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return exp != nil
+}
+
+// Start begins a span. Its parent is the span in ctx, else the running
+// pipeline span (see StartPipeline). The returned context carries the new
+// span for nested calls.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	mu.Lock()
+	defer mu.Unlock()
+	return startLocked(ctx, name, attrs)
+}
+
+func startLocked(ctx context.Context, name string, attrs []Attr) (context.Context, *Span) {
+	if exp == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	sp := &Span{name: name, start: time.Now(), attrs: attrs}
+	parent := FromContext(ctx)
+	if parent == nil {
+		parent = root
+	}
+	if parent != nil {
+		sp.traceID = parent.traceID
+		sp.parentID = parent.spanID
+	} else {
+		randomBytes(sp.traceID[:])
+	}
+	randomBytes(sp.spanID[:])
+	return context.WithValue(ctx, spanKey, sp), sp
+}
+
+// StartPipeline begins the span for a whole pipeline run. Until it ends,
+// spans started without a parent in their context nest under it.
+func StartPipeline(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	mu.Lock()
+	defer mu.Unlock()
+	ctx, sp := startLocked(ctx, name, attrs)
+	if sp != nil {
+		root = sp
+	}
+	return ctx, sp
+}
+
+// StartTask begins the span for one task. Agent calls for the task find
+// it through TaskContext, so callers don't have to thread a context.
+func StartTask(ctx context.Context, taskID int64, title string) (context.Context, *Span) {
+	mu.Lock()
+	defer mu.Unlock()
+	ctx, sp := startLocked(ctx, "task", []Attr{Int("hive.task.id", taskID), String("hive.task.title", title)})
+	if sp != nil {
+		sp.taskID = taskID
+		tasks[taskID] = sp
+	}
+	return ctx, sp
+}
+
+// TaskContext returns ctx, carrying the running span of taskID when ctx
+// has no span of its own.
+func TaskContext(ctx context.Context, taskID int64) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if FromContext(ctx) != nil {
+		return ctx
+	}
+	mu.Lock()
+	sp := tasks[taskID]
+	mu.Unlock()
+	if sp == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey, sp)
+}
+
+// FromContext returns the span carried by ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	sp, _ := ctx.Value(spanKey).(*Span)
+	return sp
+}
+
+// SetAttr adds or replaces an attribute.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].Key == key {
+			s.attrs[i].Value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, Attr{key, value})
+}
+
+// Fail marks the span as failed with msg.
+func (s *Span) Fail(msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = msg
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export. Ending twice is a no-op.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	mu.Lock()
+	if root == s {
+		root = nil
+	}
+	if s.taskID != 0 && tasks[s.taskID] == s {
+		delete(tasks, s.taskID)
+	}
+	e := exp
+	mu.Unlock()
+
+	if e != nil {
+		e.add(s)
+	}
+}
+
+// TraceID returns the span's trace ID in hex, or "" for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+func randomBytes(b []byte) {
+	randLock.Lock()
+	defer randLock.Unlock()
+	rand.Read(b)
+}
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestStart_DisabledIsNoop(t *testing.T) {
+	ctx, sp := Start(context.Background(), "x")
+	if sp != nil {
+		t.Fatal("expected nil span while tracing is off")
+	}
+	sp.SetAttr("k", "v")
+	sp.Fail("boom")
+	sp.End()
+	if FromContext(ctx) != nil {
+		t.Error("context should carry no span")
+	}
+}
+
+func TestExport_NestsTaskAndAgentSpans(t *testing.T) {
+	var mu sync.Mutex
+	var got []otlpSpan
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req otlpRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		mu.Lock()
+		header = r.Header.Get("X-Api-Key")
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				got = append(got, ss.Spans...)
+			}
+		}
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	shutdown, err := Init(srv.URL, map[string]string{"X-Api-Key": "secret"}, "hive")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	_, pipeline := StartPipeline(context.Background(), "hive.auto", Int("hive.task.id", 1))
+	_, task := StartTask(context.Background(), 7, "Add login")
+	_, call := Start(TaskContext(context.Background(), 7), "agent.coder", String("hive.agent", "claude"))
+	call.Fail("exit 1")
+	call.End()
+	task.End()
+	_, orphan := Start(context.Background(), "git.commit")
+	orphan.End()
+	pipeline.End()
+	shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if header != "secret" {
+		t.Errorf("header not sent, got %q", header)
+	}
+	byName := map[string]otlpSpan{}
+	for _, s := range got {
+		byName[s.Name] = s
+	}
+	if len(byName) != 4 {
+		t.Fatalf("expected 4 spans, got %d: %+v", len(got), got)
+	}
+
+	root := byName["hive.auto"]
+	if root.ParentSpanID != "" {
+		t.Error("pipeline span should have no parent")
+	}
+	if byName["task"].ParentSpanID != root.SpanID {
+		t.Error("task span should nest under the pipeline")
+	}
+	if byName["agent.coder"].ParentSpanID != byName["task"].SpanID {
+		t.Error("agent span should nest under its task")
+	}
+	if byName["git.commit"].ParentSpanID != root.SpanID {
+		t.Error("span without context should nest under the pipeline")
+	}
+	for _, s := range got {
+		if s.TraceID != root.TraceID {
+			t.Errorf("span %s has a different trace ID", s.Name)
+		}
+	}
+	if st := byName["agent.coder"].Status; st.Code != 2 || st.Message != "exit 1" {
+		t.Errorf("agent span status = %+v", st)
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	cases := map[string]string{
+		"http://localhost:4318":              "http://localhost:4318/v1/traces",
+		"http://localhost:4318/":             "http://localhost:4318/v1/traces",
+		"https://otel.example.com/v1/traces": "https://otel.example.com/v1/traces",
+	}
+	for in, want := range cases {
+		got, err := tracesURL(in)
+		if err != nil || got != want {
+			t.Errorf("tracesURL(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := tracesURL("localhost:4318"); err == nil {
+		t.Error("expected error for endpoint without scheme")
+	}
+}
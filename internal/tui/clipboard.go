@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copiedMsg reports a finished clipboard copy.
+type copiedMsg struct {
+	what string
+	err  error
+}
+
+// copyCmd puts text on the system clipboard; what names it in the status
+// bar.
+func copyCmd(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return copiedMsg{what: what, err: copyText(text)}
+	}
+}
+
+// copyText uses the platform clipboard (pbcopy, wl-copy, xclip, ...) when
+// there is one. Over SSH that would be the remote machine's clipboard, so
+// there, and when no clipboard tool is installed, it sends the text to the
+// terminal as an OSC52 sequence, which most terminals copy locally.
+func copyText(text string) error {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" && !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+	_, err := os.Stdout.WriteString(osc52(text))
+	return err
+}
+
+// osc52 wraps text in the OSC52 set-clipboard sequence, passed through
+// tmux or screen when running inside one.
+func osc52(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
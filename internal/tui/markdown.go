@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	mdHeadingStyle = lipgloss.NewStyle().Bold(true).Foreground(clrHighlight)
+	mdCodeStyle    = lipgloss.NewStyle().Foreground(clrCyan)
+	mdQuoteStyle   = lipgloss.NewStyle().Foreground(clrSubtle).Italic(true)
+	mdBoldStyle    = lipgloss.NewStyle().Bold(true)
+	mdAddStyle     = lipgloss.NewStyle().Foreground(clrGreen)
+	mdDelStyle     = lipgloss.NewStyle().Foreground(clrRed)
+	mdHunkStyle    = lipgloss.NewStyle().Foreground(clrBlue)
+
+	mdBold       = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdInlineCode = regexp.MustCompile("`([^`]+)`")
+)
+
+// renderMarkdown styles an agent's Markdown output for the terminal:
+// headings, lists, quotes and fenced code, which is colored as a diff
+// when it is one. Prose is wrapped to width; code is left as is.
+func renderMarkdown(text string, width int) string {
+	wrap := lipgloss.NewStyle().Width(max(width, 20))
+	var b strings.Builder
+	inCode, diffFence := false, false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			diffFence = inCode && strings.HasPrefix(strings.TrimPrefix(trimmed, "```"), "diff")
+			b.WriteString(dimStyle.Render(line) + "\n")
+			continue
+		}
+		if inCode {
+			if diffFence {
+				b.WriteString(renderDiffLine(line) + "\n")
+			} else {
+				b.WriteString(mdCodeStyle.Render(line) + "\n")
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			b.WriteString(mdHeadingStyle.Render(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))) + "\n")
+		case strings.HasPrefix(trimmed, ">"):
+			b.WriteString(mdQuoteStyle.Render(wrap.Render("│ "+strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))) + "\n")
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			b.WriteString(indent + "• " + renderInline(trimmed[2:]) + "\n")
+		case trimmed == "":
+			b.WriteString("\n")
+		default:
+			b.WriteString(wrap.Render(renderInline(line)) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// renderInline styles **bold** and `code` spans within a line.
+func renderInline(s string) string {
+	s = mdBold.ReplaceAllStringFunc(s, func(m string) string {
+		return mdBoldStyle.Render(mdBold.FindStringSubmatch(m)[1])
+	})
+	return mdInlineCode.ReplaceAllStringFunc(s, func(m string) string {
+		return mdCodeStyle.Render(mdInlineCode.FindStringSubmatch(m)[1])
+	})
+}
+
+// renderDiff colors a unified diff, e.g. a suggestion patch.
+func renderDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = renderDiffLine(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderDiffLine(l string) string {
+	switch {
+	case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+		return mdBoldStyle.Render(l)
+	case strings.HasPrefix(l, "@@"):
+		return mdHunkStyle.Render(l)
+	case strings.HasPrefix(l, "+"):
+		return mdAddStyle.Render(l)
+	case strings.HasPrefix(l, "-"):
+		return mdDelStyle.Render(l)
+	}
+	return l
+}
@@ -3,24 +3,33 @@ package tui
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/git"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 )
 
 // screen represents which view the TUI is showing.
 type screen int
 
 const (
-	screenGrid    screen = iota // Epic card grid (main)
-	screenEpic                  // Drill-down into a single epic
-	screenDiff                  // Diff viewer for an epic
-	screenHistory               // Epic history / timeline
+	screenGrid      screen = iota // Epic card grid (main)
+	screenEpic                    // Drill-down into a single epic
+	screenDiff                    // Diff viewer for an epic
+	screenHistory                 // Epic history / timeline
+	screenArtifacts               // A task's saved agent outputs
 )
 
 // popup represents an active overlay dialog.
@@ -57,10 +66,44 @@ type epicCard struct {
 	PhasesDone [numPhases]bool // Which phases are complete
 	HasBlocker bool
 	BlockerMsg string
+	NeedsHuman string // "#id: reason" of a task the fix loop gave up on
 	LogLine    string // Most recent log line
 	Events     []store.Event
+	Group      epicGroup    // Board section
+	Run        *runProgress // Set while a pipeline runs on the epic
 }
 
+// runProgress is where a running pipeline is, from its checkpoints.
+type runProgress struct {
+	TaskID    int64 // 0 until a task's fix loop starts
+	Iteration int
+	MaxLoops  int
+	Phase     string // store.PhaseCode or store.PhaseReview
+	Agent     string // The task's coder
+}
+
+// pipelineProc is a hive auto the TUI started.
+type pipelineProc struct {
+	cmd       *exec.Cmd
+	taskID    int64
+	logPath   string
+	cancelled bool
+}
+
+// epicGroup is the section of the board an epic is shown in. Sections are
+// in the order they are shown, the epics that need a human first.
+type epicGroup int
+
+const (
+	groupBlocked  epicGroup = iota // The epic or one of its tasks waits for an answer
+	groupAwaiting                  // Every task done, waiting to be accepted
+	groupActive                    // Being planned or worked on
+	groupDone                      // Accepted, rejected or cancelled
+	numGroups
+)
+
+var groupLabels = [numGroups]string{"Blocked", "Awaiting accept", "Active", "Done"}
+
 // Model is the top-level bubbletea model for the hive TUI.
 type Model struct {
 	store   *store.Store
@@ -73,9 +116,11 @@ type Model struct {
 	popup  popup
 
 	// Grid state (main screen).
-	epics    []epicCard
-	cursor   int // Selected epic index
-	gridCols int // Number of columns in the grid
+	epics     []epicCard
+	cursor    int // Selected epic index; -1 when every section is collapsed
+	gridCols  int // Number of columns in the grid
+	collapsed [numGroups]bool
+	expanded  map[int64]bool // Cards showing their mini-log, by epic ID
 
 	// Epic drill-down state.
 	epicDetail *epicCard
@@ -84,22 +129,46 @@ type Model struct {
 	// Diff viewer.
 	diffViewport viewport.Model
 	diffContent  string
+	diffPatch    string // Raw diff, for copying
 	diffEpicID   int64
 
 	// History viewer.
 	historyViewport viewport.Model
 	historyContent  string
 
+	// Artifacts viewer for one task.
+	artifactsViewport viewport.Model
+	artifactTaskID    int64
+	artifactList      []store.Artifact
+	artifactIdx       int // Artifact on screen
+
 	// Text inputs for popups.
 	textInput    textinput.Model
-	textInput2   textinput.Model // For description fields
-	inputFocused int             // 0=first, 1=second
+	textArea     textarea.Model // Multi-line field: descriptions, answers, fix requests
+	inputFocused int            // 0=first, 1=second
+
+	// Inline blocker answer in the epic detail screen.
+	answerArea     textarea.Model
+	answerTaskID   int64 // Blocked task being answered; 0 = none
+	continueTaskID int64 // Answered task offered a pipeline run; 0 = none
 
 	// Popup context.
 	popupTaskID    int64 // Which task the popup is about
 	popupEpicID    int64 // Which epic the popup is about
 	createPriority string
 
+	// Merge preview for the accept popup.
+	mergeChecked   bool     // Preview finished
+	mergeConflicts []string // Files that would conflict
+	mergeErr       error
+
+	// ":" command line.
+	palette       textinput.Model
+	paletteActive bool
+
+	// hive auto runs started from the TUI, by epic ID.
+	procs map[int64]*pipelineProc
+
 	// Status bar message.
 	statusMsg  string
 	statusTime time.Time
@@ -117,25 +186,45 @@ func New(s *store.Store, workDir string) Model {
 	ti.CharLimit = 500
 	ti.Width = 50
 
-	ti2 := textinput.New()
-	ti2.Placeholder = "Description (optional)..."
-	ti2.CharLimit = 500
-	ti2.Width = 50
+	pa := textarea.New()
+	pa.ShowLineNumbers = false
+	pa.CharLimit = 0
+	pa.SetWidth(52)
+	pa.SetHeight(6)
+
+	ta := textarea.New()
+	ta.Placeholder = "Your answer..."
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetWidth(60)
+	ta.SetHeight(4)
+
+	pl := textinput.New()
+	pl.Prompt = ":"
+	pl.Placeholder = "answer 12 use postgres"
+	pl.CharLimit = 0
 
 	vp := viewport.New(80, 20)
 	hp := viewport.New(80, 20)
+	av := viewport.New(80, 20)
 
 	return Model{
-		store:           s,
-		workDir:         workDir,
-		screen:          screenGrid,
-		popup:           popupNone,
-		gridCols:        2,
-		textInput:       ti,
-		textInput2:      ti2,
-		diffViewport:    vp,
-		historyViewport: hp,
-		createPriority:  "high",
+		store:             s,
+		workDir:           workDir,
+		screen:            screenGrid,
+		popup:             popupNone,
+		gridCols:          2,
+		collapsed:         [numGroups]bool{groupDone: true},
+		textInput:         ti,
+		palette:           pl,
+		textArea:          pa,
+		answerArea:        ta,
+		diffViewport:      vp,
+		historyViewport:   hp,
+		artifactsViewport: av,
+		createPriority:    "high",
+		procs:             map[int64]*pipelineProc{},
+		expanded:          map[int64]bool{},
 	}
 }
 
@@ -158,6 +247,7 @@ type tickMsg time.Time
 type diffLoadedMsg struct {
 	epicID  int64
 	content string
+	patch   string
 }
 
 type historyLoadedMsg struct {
@@ -165,8 +255,21 @@ type historyLoadedMsg struct {
 	content string
 }
 
+type artifactsLoadedMsg struct {
+	taskID int64
+	arts   []store.Artifact
+	err    error
+}
+
 type autoStartedMsg struct {
 	epicID int64
+	proc   *pipelineProc
+	err    error
+}
+
+type autoExitedMsg struct {
+	epicID int64
+	proc   *pipelineProc
 	err    error
 }
 
@@ -175,6 +278,12 @@ type acceptDoneMsg struct {
 	err    error
 }
 
+type mergePreviewMsg struct {
+	epicID    int64
+	conflicts []string
+	err       error
+}
+
 type rejectDoneMsg struct {
 	epicID int64
 	reason string
@@ -243,19 +352,58 @@ func (m Model) loadEpics() tea.Cmd {
 			// Load and sort events from epic + tasks for better log/history.
 			card.Events = m.eventsForEpic(e.ID, tasks)
 
+			for _, t := range tasks {
+				if t.Status == store.StatusNeedsHuman {
+					reason, _ := worker.NeedsHumanReason(t.ID, card.Events)
+					card.NeedsHuman = fmt.Sprintf("#%d: %s", t.ID, reason)
+					break
+				}
+			}
+
 			// Pick the most recent event for the log line.
 			if len(card.Events) > 0 {
 				latest := card.Events[len(card.Events)-1]
 				card.LogLine = formatLogLine(latest)
 			}
 
+			card.Run = m.runProgress(e.ID, tasks)
+			card.Group = cardGroup(&card)
 			cards = append(cards, card)
 		}
+		sort.SliceStable(cards, func(i, j int) bool { return cards[i].Group < cards[j].Group })
 
 		return epicsLoadedMsg{epics: cards}
 	}
 }
 
+// runProgress returns where the pipeline running on an epic is, or nil
+// when none is: the task whose checkpoint moved last, its iteration and
+// phase.
+func (m Model) runProgress(epicID int64, tasks []store.Task) *runProgress {
+	run, err := m.store.GetActivePipelineRun(epicID)
+	if err != nil || run == nil {
+		return nil
+	}
+	p := &runProgress{MaxLoops: run.MaxLoops}
+	cps, _ := m.store.ListCheckpoints(run.ID)
+	var latest store.TaskCheckpoint
+	for _, cp := range cps {
+		if cp.Phase != store.PhaseDone && cp.UpdatedAt.After(latest.UpdatedAt) {
+			latest = cp
+		}
+	}
+	if latest.TaskID == 0 {
+		return p
+	}
+	p.TaskID, p.Iteration, p.Phase = latest.TaskID, latest.Iteration, latest.Phase
+	for _, t := range tasks {
+		if t.ID == latest.TaskID {
+			p.Agent = t.AssignedAgent
+		}
+	}
+	return p
+}
+
 func (m Model) loadDiff(epicID int64) tea.Cmd {
 	return func() tea.Msg {
 		epic, err := m.store.GetTask(epicID)
@@ -268,7 +416,7 @@ func (m Model) loadDiff(epicID int64) tea.Cmd {
 			return diffLoadedMsg{epicID: epicID, content: "Not a git repository."}
 		}
 
-		baseBranch, err := safety.BaseBranch()
+		baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
 		if err != nil {
 			return diffLoadedMsg{epicID: epicID, content: "Cannot determine base branch."}
 		}
@@ -290,7 +438,7 @@ func (m Model) loadDiff(epicID int64) tea.Cmd {
 		}
 		content += diff
 
-		return diffLoadedMsg{epicID: epicID, content: content}
+		return diffLoadedMsg{epicID: epicID, content: content, patch: diff}
 	}
 }
 
@@ -314,7 +462,7 @@ func (m Model) loadHistory(epicID int64) tea.Cmd {
 		if epic.GitBranch != "" {
 			safety := git.New(m.workDir)
 			if safety.IsGitRepo() {
-				baseBranch, _ := safety.BaseBranch()
+				baseBranch, _ := safety.BaseBranchFor(epic.BaseBranch)
 				commits, err := safety.LogCommits(baseBranch, epic.GitBranch)
 				if err == nil && commits != "" {
 					content += "Commits:\n"
@@ -341,6 +489,14 @@ func (m Model) loadHistory(epicID int64) tea.Cmd {
 	}
 }
 
+// loadArtifacts lists the artifacts saved for a task, oldest first.
+func (m Model) loadArtifacts(taskID int64) tea.Cmd {
+	return func() tea.Msg {
+		arts, err := m.store.GetArtifacts(taskID)
+		return artifactsLoadedMsg{taskID: taskID, arts: arts, err: err}
+	}
+}
+
 func (m Model) eventsForEpic(epicID int64, tasks []store.Task) []store.Event {
 	events, _ := m.store.GetEvents(epicID)
 	for _, t := range tasks {
@@ -355,6 +511,27 @@ func (m Model) eventsForEpic(epicID int64, tasks []store.Task) []store.Event {
 	return events
 }
 
+// previewMerge dry-runs the epic's merge so the accept popup can warn
+// about conflicts before anything is changed.
+func (m Model) previewMerge(epicID int64) tea.Cmd {
+	return func() tea.Msg {
+		epic, err := m.store.GetTask(epicID)
+		if err != nil {
+			return mergePreviewMsg{epicID: epicID, err: err}
+		}
+		safety := git.New(m.workDir)
+		if !safety.IsGitRepo() || epic.GitBranch == "" {
+			return mergePreviewMsg{epicID: epicID}
+		}
+		baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
+		if err != nil {
+			return mergePreviewMsg{epicID: epicID, err: err}
+		}
+		conflicts, err := safety.MergeConflicts(baseBranch, epic.GitBranch)
+		return mergePreviewMsg{epicID: epicID, conflicts: conflicts, err: err}
+	}
+}
+
 func (m Model) doAccept(epicID int64) tea.Cmd {
 	return func() tea.Msg {
 		epic, err := m.store.GetTask(epicID)
@@ -377,7 +554,7 @@ func (m Model) doAccept(epicID int64) tea.Cmd {
 			return acceptDoneMsg{epicID: epicID}
 		}
 
-		baseBranch, err := safety.BaseBranch()
+		baseBranch, err := safety.BaseBranchFor(epic.BaseBranch)
 		if err != nil {
 			return acceptDoneMsg{epicID: epicID, err: err}
 		}
@@ -386,8 +563,10 @@ func (m Model) doAccept(epicID int64) tea.Cmd {
 			return acceptDoneMsg{epicID: epicID, err: err}
 		}
 
-		// Cleanup branch.
-		safety.DeleteBranch(epic.GitBranch, false)
+		// Cleanup branch — adopted branches belong to the user and stay.
+		if epic.AdoptedRef == "" {
+			safety.DeleteBranch(epic.GitBranch, false)
+		}
 		m.store.UpdateTaskStatus(epicID, store.StatusDone)
 		m.store.AddEvent(epicID, "user", "accepted", "Epic accepted and merged")
 
@@ -404,9 +583,15 @@ func (m Model) doReject(epicID int64, reason string) tea.Cmd {
 
 		safety := git.New(m.workDir)
 		if safety.IsGitRepo() && epic.GitBranch != "" {
-			baseBranch, _ := safety.BaseBranch()
-			if err := safety.RejectBranch(baseBranch, epic.GitBranch); err != nil {
-				return rejectDoneMsg{epicID: epicID, err: err}
+			if epic.AdoptedRef != "" {
+				if err := safety.ResetBranch(epic.GitBranch, epic.AdoptedRef); err != nil {
+					return rejectDoneMsg{epicID: epicID, err: err}
+				}
+			} else {
+				baseBranch, _ := safety.BaseBranchFor(epic.BaseBranch)
+				if err := safety.RejectBranch(baseBranch, epic.GitBranch); err != nil {
+					return rejectDoneMsg{epicID: epicID, err: err}
+				}
 			}
 		}
 
@@ -421,6 +606,107 @@ func (m Model) doReject(epicID int64, reason string) tea.Cmd {
 	}
 }
 
+// liveTail returns the last n lines of a task's live log, which agents
+// write to as they work (hive auto --stream, which the TUI starts).
+func (m Model) liveTail(taskID int64, n int) []string {
+	f, err := os.Open(filepath.Join(m.workDir, artifacts.RunsDir, fmt.Sprintf("task-%d.log", taskID)))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil
+	}
+	// The last few KB hold more than enough lines.
+	offset := max(info.Size()-8192, 0)
+	buf := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	if offset > 0 && len(lines) > 1 {
+		lines = lines[1:] // Cut off mid-line.
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// startAuto runs hive auto on a task or epic in the background, writing
+// to a log in the runs directory instead of the screen. It runs in a
+// process group of its own so cancelling it stops its agents too.
+func (m Model) startAuto(epicID, taskID int64, args ...string) tea.Cmd {
+	return func() tea.Msg {
+		exe, err := os.Executable()
+		if err != nil {
+			return autoStartedMsg{epicID: epicID, err: err}
+		}
+		logPath := filepath.Join(m.workDir, artifacts.RunsDir, fmt.Sprintf("tui-auto-%d.log", taskID))
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			return autoStartedMsg{epicID: epicID, err: err}
+		}
+		out, err := os.Create(logPath)
+		if err != nil {
+			return autoStartedMsg{epicID: epicID, err: err}
+		}
+
+		// The run is started in the background, with no terminal to ask on.
+		cmd := exec.Command(exe, append([]string{"auto", strconv.FormatInt(taskID, 10), "--stream", "--yes"}, args...)...)
+		cmd.Dir = m.workDir
+		cmd.Stdout = out
+		cmd.Stderr = out
+		detach(cmd)
+		if err := cmd.Start(); err != nil {
+			out.Close()
+			return autoStartedMsg{epicID: epicID, err: err}
+		}
+		out.Close() // The child has its own copy.
+		return autoStartedMsg{epicID: epicID, proc: &pipelineProc{cmd: cmd, taskID: taskID, logPath: logPath}}
+	}
+}
+
+// waitAuto reports when a hive auto the TUI started exits. A cancelled
+// run is marked interrupted and its tasks that were in progress go back
+// to the backlog, as hive resume would, so running the epic again picks
+// them up.
+func (m Model) waitAuto(epicID int64, proc *pipelineProc) tea.Cmd {
+	return func() tea.Msg {
+		err := proc.cmd.Wait()
+		if proc.cancelled {
+			if run, _ := m.store.GetActivePipelineRun(epicID); run != nil {
+				m.store.EndPipelineRun(run.ID, "interrupted")
+			}
+			m.store.ResetStaleTasks(epicID)
+			m.store.AddEvent(epicID, "user", "cancelled", "Pipeline cancelled from the TUI")
+		}
+		return autoExitedMsg{epicID: epicID, proc: proc, err: err}
+	}
+}
+
+// cancelAuto interrupts the hive auto the TUI runs on an epic.
+func (m *Model) cancelAuto(epicID int64) {
+	proc := m.procs[epicID]
+	if proc == nil {
+		m.setStatus("No pipeline started here is running on E#" + itoa(int(epicID)))
+		return
+	}
+	if proc.cancelled {
+		return
+	}
+	proc.cancelled = true
+	if proc.cmd == nil {
+		// Still starting: interrupted as soon as it has.
+		return
+	}
+	if err := interrupt(proc.cmd); err != nil {
+		m.setStatus("Could not cancel hive auto: " + err.Error())
+		return
+	}
+	m.setStatus("Cancelling hive auto on E#" + itoa(int(epicID)) + "...")
+}
+
 func (m Model) doCreateFixTask(epicID int64, description string) tea.Cmd {
 	return func() tea.Msg {
 		_, err := m.store.CreateTask(description, "", "high", &epicID)
@@ -432,6 +718,56 @@ func (m Model) doCreateFixTask(epicID int64, description string) tea.Cmd {
 	}
 }
 
+// showArtifact puts the artifact at i on screen: Markdown rendered,
+// patches colored, images only named.
+func (m *Model) showArtifact(i int) {
+	if len(m.artifactList) == 0 {
+		m.artifactsViewport.SetContent(dimStyle.Render("No artifacts saved for #" + itoa(int(m.artifactTaskID)) + " yet."))
+		return
+	}
+	m.artifactIdx = max(0, min(i, len(m.artifactList)-1))
+	a := m.artifactList[m.artifactIdx]
+	path := artifacts.New(m.store, m.workDir).Resolve(a.FilePath)
+
+	var content string
+	switch data, err := os.ReadFile(path); {
+	case err != nil:
+		content = errorStyle.Render("Cannot read " + a.FilePath + ": " + err.Error())
+	case a.Type == artifacts.TypeImage:
+		content = dimStyle.Render("Image attached to the task: " + path)
+	case strings.HasSuffix(a.FilePath, ".patch"):
+		content = renderDiff(string(data))
+	default:
+		content = renderMarkdown(string(data), m.artifactsViewport.Width-2)
+	}
+	m.artifactsViewport.SetContent(content)
+	m.artifactsViewport.GotoTop()
+}
+
+var iterPattern = regexp.MustCompile(`iter(\d+)`)
+
+// artifactIter returns the fix-loop iteration an artifact was saved in,
+// from its name, or 0 when it isn't one of an iteration's.
+func artifactIter(a store.Artifact) int {
+	if m := iterPattern.FindStringSubmatch(filepath.Base(a.FilePath)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+// findArtifact returns the index of the first artifact of the given type
+// and iteration, searching from i in steps of dir, or -1.
+func (m Model) findArtifact(from, dir int, typ string, iter int) int {
+	for i := from; i >= 0 && i < len(m.artifactList); i += dir {
+		a := m.artifactList[i]
+		if a.Type == typ && (iter == 0 || artifactIter(a) == iter) {
+			return i
+		}
+	}
+	return -1
+}
+
 // --- Helpers ---
 
 func computePhase(epic store.Task, tasks []store.Task, hasArchitectSpec bool) (epicPhase, [numPhases]bool) {
@@ -501,6 +837,19 @@ func computePhase(epic store.Task, tasks []store.Task, hasArchitectSpec bool) (e
 	return phaseCode, done
 }
 
+// cardGroup returns the board section of an epic.
+func cardGroup(card *epicCard) epicGroup {
+	switch {
+	case card.Epic.Status == store.StatusDone || card.Epic.Status == store.StatusFailed || card.Epic.Status == store.StatusCancelled:
+		return groupDone
+	case card.HasBlocker, card.NeedsHuman != "":
+		return groupBlocked
+	case allTasksDone(card):
+		return groupAwaiting
+	}
+	return groupActive
+}
+
 func formatLogLine(e store.Event) string {
 	agent := ""
 	if e.Agent != "" {
@@ -542,16 +891,112 @@ func (m *Model) selectedTask() *store.Task {
 	return nil
 }
 
-func (m *Model) clampGridCursor() {
-	if len(m.epics) == 0 {
-		m.cursor = 0
+// groupEpics returns the indexes in m.epics of a section's epics.
+func (m Model) groupEpics(g epicGroup) []int {
+	var idx []int
+	for i := range m.epics {
+		if m.epics[i].Group == g {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// gridRows lays out the cards of the expanded sections as they are shown:
+// rows of up to gridCols indexes into m.epics, each section starting a
+// new row.
+func (m Model) gridRows() [][]int {
+	cols := max(m.gridCols, 1)
+	var rows [][]int
+	for g := epicGroup(0); g < numGroups; g++ {
+		if m.collapsed[g] {
+			continue
+		}
+		idx := m.groupEpics(g)
+		for i := 0; i < len(idx); i += cols {
+			rows = append(rows, idx[i:min(i+cols, len(idx))])
+		}
+	}
+	return rows
+}
+
+// moveGrid moves the cursor by rows or, in reading order, by cards.
+func (m *Model) moveGrid(dRow, dCard int) {
+	rows := m.gridRows()
+	var flat []int
+	r, c, at := -1, 0, 0
+	for i, row := range rows {
+		for j, idx := range row {
+			if idx == m.cursor {
+				r, c, at = i, j, len(flat)
+			}
+			flat = append(flat, idx)
+		}
+	}
+	if r < 0 {
+		m.clampGridCursor()
+		return
+	}
+	if dCard != 0 {
+		m.cursor = flat[max(0, min(at+dCard, len(flat)-1))]
 		return
 	}
-	if m.cursor < 0 {
-		m.cursor = 0
+	r = max(0, min(r+dRow, len(rows)-1))
+	m.cursor = rows[r][min(c, len(rows[r])-1)]
+}
+
+// nextSection moves the cursor to the first card of the next expanded
+// section, wrapping around.
+func (m *Model) nextSection() {
+	cur := epicGroup(-1)
+	if e := m.selectedEpic(); e != nil {
+		cur = e.Group
+	}
+	for i := epicGroup(1); i <= numGroups; i++ {
+		g := (cur + i + numGroups) % numGroups
+		if idx := m.groupEpics(g); len(idx) > 0 && !m.collapsed[g] {
+			m.cursor = idx[0]
+			return
+		}
+	}
+}
+
+// toggleSection collapses or expands a section, keeping the cursor on a
+// card that is shown.
+func (m *Model) toggleSection(g epicGroup) {
+	m.collapsed[g] = !m.collapsed[g]
+	if !m.collapsed[g] && len(m.groupEpics(g)) > 0 {
+		m.cursor = m.groupEpics(g)[0]
+	}
+	m.clampGridCursor()
+}
+
+// selectEpic puts the cursor on the epic with the given ID, if it is
+// still on the board.
+func (m *Model) selectEpic(id int64) {
+	for i := range m.epics {
+		if m.epics[i].Epic.ID == id {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// clampGridCursor keeps the cursor on a card that is shown: the first one
+// when the selected epic is gone or its section collapsed, none when no
+// card is shown.
+func (m *Model) clampGridCursor() {
+	rows := m.gridRows()
+	for _, row := range rows {
+		for _, idx := range row {
+			if idx == m.cursor {
+				return
+			}
+		}
 	}
-	if m.cursor >= len(m.epics) {
-		m.cursor = len(m.epics) - 1
+	m.cursor = -1
+	if len(rows) > 0 {
+		m.cursor = rows[0][0]
 	}
 }
 
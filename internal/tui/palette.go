@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// paletteCommands is the help the palette shows for an unknown command.
+const paletteCommands = "answer <task> <text> · accept <epic> · reject <epic> [reason] · fix <epic> <text> · auto <epic|task> · open <epic>"
+
+// openPalette starts the ":" command line.
+func (m Model) openPalette() (tea.Model, tea.Cmd) {
+	m.palette.Reset()
+	m.palette.Focus()
+	m.paletteActive = true
+	return m, textinput.Blink
+}
+
+// handlePaletteKey edits the command line; enter runs it.
+func (m Model) handlePaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.paletteActive = false
+		m.palette.Blur()
+		return m, nil
+	case "enter":
+		line := m.palette.Value()
+		m.paletteActive = false
+		m.palette.Blur()
+		return m.runCommand(line)
+	case "backspace":
+		// Backspace on an empty line closes it, as in vim.
+		if m.palette.Value() == "" {
+			m.paletteActive = false
+			m.palette.Blur()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.palette, cmd = m.palette.Update(msg)
+	return m, cmd
+}
+
+// runCommand runs a palette command through the same actions as the keys
+// and popups: ":answer 12 use postgres", ":accept 5", ":auto 7".
+func (m Model) runCommand(line string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return m, nil
+	}
+	name := fields[0]
+	if len(fields) < 2 {
+		m.setStatus("Error: usage: " + paletteCommands)
+		return m, nil
+	}
+	id, err := strconv.ParseInt(strings.TrimLeft(fields[1], "#E"), 10, 64)
+	if err != nil {
+		m.setStatus("Error: " + name + ": not an ID: " + fields[1])
+		return m, nil
+	}
+	// The rest of the line, spacing kept.
+	rest := strings.TrimSpace(strings.TrimSpace(line)[len(name):])
+	text := strings.TrimSpace(rest[len(fields[1]):])
+
+	switch name {
+	case "answer":
+		if text == "" {
+			m.setStatus("Error: usage: answer <task> <text>")
+			return m, nil
+		}
+		if t, err := m.store.GetTask(id); err != nil || (t.Status != store.StatusBlocked && t.Status != store.StatusNeedsHuman) {
+			m.setStatus("Error: #" + itoa(int(id)) + " is not blocked")
+			return m, nil
+		}
+		if err := m.store.UnblockTask(id, text); err != nil {
+			m.setStatus("Error: " + err.Error())
+			return m, nil
+		}
+		m.setStatus("Resolved blocker on #" + itoa(int(id)))
+		return m.answered(id)
+
+	case "accept":
+		if m.epicCard(id) == nil {
+			m.setStatus("Error: no epic E#" + itoa(int(id)))
+			return m, nil
+		}
+		return m, m.doAccept(id)
+
+	case "reject":
+		if m.epicCard(id) == nil {
+			m.setStatus("Error: no epic E#" + itoa(int(id)))
+			return m, nil
+		}
+		return m, m.doReject(id, text)
+
+	case "fix":
+		if m.epicCard(id) == nil {
+			m.setStatus("Error: no epic E#" + itoa(int(id)))
+			return m, nil
+		}
+		if text == "" {
+			m.setStatus("Error: usage: fix <epic> <what needs fixing>")
+			return m, nil
+		}
+		return m, m.doCreateFixTask(id, text)
+
+	case "auto":
+		if card := m.epicCard(id); card != nil {
+			return m.runPipeline(card, id)
+		}
+		// A task: continue its pipeline, as after answering a blocker.
+		t, err := m.store.GetTask(id)
+		if err != nil || t.ParentID == nil || m.epicCard(*t.ParentID) == nil {
+			m.setStatus("Error: no epic or task #" + itoa(int(id)))
+			return m, nil
+		}
+		return m.runPipeline(m.epicCard(*t.ParentID), id, "--skip-plan")
+
+	case "open":
+		card := m.epicCard(id)
+		if card == nil {
+			m.setStatus("Error: no epic E#" + itoa(int(id)))
+			return m, nil
+		}
+		m.epicDetail = card
+		m.taskCursor = 0
+		m.screen = screenEpic
+		return m, nil
+	}
+
+	m.setStatus("Error: unknown command " + name + ": " + paletteCommands)
+	return m, nil
+}
+
+// epicCard returns the card of the epic with the given ID, or nil.
+func (m Model) epicCard(id int64) *epicCard {
+	for i := range m.epics {
+		if m.epics[i].Epic.ID == id {
+			return &m.epics[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package tui
+
+import "os/exec"
+
+// detach does nothing on this platform.
+func detach(cmd *exec.Cmd) {}
+
+// interrupt kills the command; its agents may outlive it.
+func interrupt(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package tui
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd in a process group of its own, so interrupting it
+// reaches the agents it runs too.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interrupt sends SIGINT to the process group of a command started with
+// detach.
+func interrupt(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
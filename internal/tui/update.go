@@ -1,12 +1,14 @@
 package tui
 
 import (
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/imkarma/hive/internal/git"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 )
 
 // Update implements tea.Model.
@@ -43,6 +45,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.diffViewport.Height = vh
 		m.historyViewport.Width = vw
 		m.historyViewport.Height = vh
+		m.artifactsViewport.Width = vw
+		m.artifactsViewport.Height = vh - 2 // Room for the artifact tabs.
+		if m.screen == screenArtifacts {
+			m.showArtifact(m.artifactIdx)
+		}
+		m.answerArea.SetWidth(m.answerWidth())
+		m.textArea.SetWidth(m.popupWidth() - 6)
 		return m, nil
 
 	case epicsLoadedMsg:
@@ -51,7 +60,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.refreshing = false
 			return m, nil
 		}
+		// Epics move between sections: keep the same one selected.
+		var selected int64
+		if e := m.selectedEpic(); e != nil {
+			selected = e.Epic.ID
+		}
 		m.epics = msg.epics
+		m.selectEpic(selected)
 		m.clampGridCursor()
 		// If we're in epic detail, refresh it too.
 		if m.screen == screenEpic && m.epicDetail != nil {
@@ -62,10 +77,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 			}
+			// Answered elsewhere, e.g. with hive answer: close the field.
+			if m.answerTaskID != 0 && !m.stillBlocked(m.answerTaskID) {
+				m.answerArea.Blur()
+				m.answerTaskID = 0
+			}
 		}
 		m.refreshing = false
 		return m, nil
 
+	case autoStartedMsg:
+		if msg.err != nil {
+			delete(m.procs, msg.epicID)
+			m.setStatus("Could not start hive auto: " + msg.err.Error())
+			return m, nil
+		}
+		if p := m.procs[msg.epicID]; p != nil && p.cancelled {
+			// Cancelled while starting.
+			msg.proc.cancelled = true
+			interrupt(msg.proc.cmd)
+		}
+		m.procs[msg.epicID] = msg.proc
+		m.setStatus("Running hive auto " + itoa(int(msg.proc.taskID)) + " — log: " + msg.proc.logPath)
+		return m, tea.Batch(m.loadEpics(), m.waitAuto(msg.epicID, msg.proc))
+
+	case autoExitedMsg:
+		delete(m.procs, msg.epicID)
+		id := itoa(int(msg.proc.taskID))
+		switch {
+		case msg.proc.cancelled:
+			m.setStatus("Cancelled hive auto " + id + " — press a to run the epic again")
+		case msg.err != nil:
+			m.setStatus("Failed: hive auto " + id + ": " + msg.err.Error() + " — log: " + msg.proc.logPath)
+		default:
+			m.setStatus("hive auto " + id + " finished")
+		}
+		return m, m.loadEpics()
+
 	case createFixDoneMsg:
 		if msg.err != nil {
 			m.setStatus("Failed to create fix task: " + msg.err.Error())
@@ -74,8 +122,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.setStatus("Created fix task for E#" + itoa(int(msg.epicID)))
 		return m, m.loadEpics()
 
+	case copiedMsg:
+		if msg.err != nil {
+			m.setStatus("Could not copy " + msg.what + ": " + msg.err.Error())
+		} else {
+			m.setStatus("Copied " + msg.what)
+		}
+		return m, nil
+
 	case diffLoadedMsg:
 		m.diffContent = msg.content
+		m.diffPatch = msg.patch
 		m.diffEpicID = msg.epicID
 		m.diffViewport.SetContent(msg.content)
 		m.diffViewport.GotoTop()
@@ -89,6 +146,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.screen = screenHistory
 		return m, nil
 
+	case artifactsLoadedMsg:
+		if msg.err != nil {
+			m.setStatus("Failed to load artifacts: " + msg.err.Error())
+			return m, nil
+		}
+		m.artifactTaskID = msg.taskID
+		m.artifactList = msg.arts
+		// Open on the latest output, the one most likely wanted.
+		m.showArtifact(len(msg.arts) - 1)
+		m.screen = screenArtifacts
+		return m, nil
+
 	case acceptDoneMsg:
 		if msg.err != nil {
 			m.setStatus("Accept failed: " + msg.err.Error())
@@ -99,6 +168,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.screen = screenGrid
 		return m, m.loadEpics()
 
+	case mergePreviewMsg:
+		// Ignore a stale preview for a popup that was closed or reopened.
+		if m.popup == popupConfirmAccept && msg.epicID == m.popupEpicID {
+			m.mergeChecked = true
+			m.mergeConflicts = msg.conflicts
+			m.mergeErr = msg.err
+		}
+		return m, nil
+
 	case rejectDoneMsg:
 		if msg.err != nil {
 			m.setStatus("Reject failed: " + msg.err.Error())
@@ -129,6 +207,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 	}
 
+	// Cursor blink for the answer field.
+	if m.answerTaskID != 0 {
+		var cmd tea.Cmd
+		m.answerArea, cmd = m.answerArea.Update(msg)
+		return m, cmd
+	}
+	if m.paletteActive {
+		var cmd tea.Cmd
+		m.palette, cmd = m.palette.Update(msg)
+		return m, cmd
+	}
+	if m.textArea.Focused() {
+		var cmd tea.Cmd
+		m.textArea, cmd = m.textArea.Update(msg)
+		return m, cmd
+	}
+
 	// Forward to viewport if in diff/history view.
 	if m.screen == screenDiff {
 		var cmd tea.Cmd
@@ -140,11 +235,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.historyViewport, cmd = m.historyViewport.Update(msg)
 		return m, cmd
 	}
+	if m.screen == screenArtifacts {
+		var cmd tea.Cmd
+		m.artifactsViewport, cmd = m.artifactsViewport.Update(msg)
+		return m, cmd
+	}
 
 	return m, nil
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.paletteActive {
+		return m.handlePaletteKey(msg)
+	}
+	// The inline answer field and the offer after it take every key.
+	if m.screen == screenEpic && m.answerTaskID != 0 {
+		return m.handleAnswerKey(msg)
+	}
+	if m.screen == screenEpic && m.continueTaskID != 0 {
+		return m.handleContinueKey(msg)
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		if m.screen == screenGrid {
@@ -156,6 +267,11 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "esc":
 		return m.goBack()
+
+	case ":":
+		if m.screen == screenGrid || m.screen == screenEpic {
+			return m.openPalette()
+		}
 	}
 
 	switch m.screen {
@@ -167,6 +283,8 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleDiffKey(msg)
 	case screenHistory:
 		return m.handleHistoryKey(msg)
+	case screenArtifacts:
+		return m.handleArtifactsKey(msg)
 	}
 
 	return m, nil
@@ -178,7 +296,7 @@ func (m Model) goBack() (tea.Model, tea.Cmd) {
 		m.screen = screenGrid
 		m.epicDetail = nil
 		return m, m.loadEpics()
-	case screenDiff, screenHistory:
+	case screenDiff, screenHistory, screenArtifacts:
 		// Go back to epic detail if we drilled down, or grid.
 		if m.epicDetail != nil {
 			m.screen = screenEpic
@@ -197,17 +315,19 @@ func (m Model) handleGridKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	// Navigation.
 	case "j", "down":
-		m.cursor += m.gridCols
-		m.clampGridCursor()
+		m.moveGrid(1, 0)
 	case "k", "up":
-		m.cursor -= m.gridCols
-		m.clampGridCursor()
+		m.moveGrid(-1, 0)
 	case "h", "left":
-		m.cursor--
-		m.clampGridCursor()
+		m.moveGrid(0, -1)
 	case "l", "right":
-		m.cursor++
-		m.clampGridCursor()
+		m.moveGrid(0, 1)
+	case "tab":
+		m.nextSection()
+
+	// Collapse or expand a section.
+	case "1", "2", "3", "4":
+		m.toggleSection(epicGroup(msg.String()[0] - '1'))
 
 	// Drill-down into epic.
 	case "enter", " ":
@@ -218,34 +338,43 @@ func (m Model) handleGridKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-	// Run auto on selected epic.
+	// Run auto on selected epic, or copy the command to run it elsewhere.
 	case "a":
 		if e := m.selectedEpic(); e != nil {
-			m.setStatus("Run in terminal: hive auto " + itoa(int(e.Epic.ID)) + " --skip-plan")
+			return m.runPipeline(e, e.Epic.ID)
+		}
+	case "A":
+		if e := m.selectedEpic(); e != nil {
+			cmd := "hive auto " + itoa(int(e.Epic.ID)) + " --skip-plan"
+			return m, copyCmd(cmd, cmd)
+		}
+	case "X":
+		if e := m.selectedEpic(); e != nil {
+			m.cancelAuto(e.Epic.ID)
+		}
+
+	// Expand the card in place: its tasks and latest events.
+	case "x":
+		if e := m.selectedEpic(); e != nil {
+			m.expanded[e.Epic.ID] = !m.expanded[e.Epic.ID]
 		}
 
 	// Resolve blocker.
 	case "r":
-		if e := m.selectedEpic(); e != nil && e.HasBlocker {
-			// Find the blocked task.
+		if e := m.selectedEpic(); e != nil && (e.HasBlocker || e.NeedsHuman != "") {
+			// Find the blocked task, or one that needs a human.
 			for _, t := range e.Tasks {
-				if t.Status == store.StatusBlocked {
+				if t.Status == store.StatusBlocked || t.Status == store.StatusNeedsHuman {
 					m.popupTaskID = t.ID
 					m.popup = popupResolve
-					m.textInput.Reset()
-					m.textInput.Placeholder = "Your answer..."
-					m.textInput.Focus()
-					return m, textinput.Blink
+					return m, m.openTextArea("Your answer...")
 				}
 			}
 			// Epic itself might be blocked.
 			if e.Epic.Status == store.StatusBlocked {
 				m.popupTaskID = e.Epic.ID
 				m.popup = popupResolve
-				m.textInput.Reset()
-				m.textInput.Placeholder = "Your answer..."
-				m.textInput.Focus()
-				return m, textinput.Blink
+				return m, m.openTextArea("Your answer...")
 			}
 		}
 
@@ -259,9 +388,7 @@ func (m Model) handleGridKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Accept epic.
 	case "y":
 		if e := m.selectedEpic(); e != nil {
-			m.popupEpicID = e.Epic.ID
-			m.popup = popupConfirmAccept
-			return m, nil
+			return m.openAcceptConfirm(e.Epic.ID)
 		}
 
 	// Reject epic.
@@ -288,8 +415,9 @@ func (m Model) handleGridKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.textInput.Reset()
 		m.textInput.Placeholder = "Epic title..."
 		m.textInput.Focus()
-		m.textInput2.Reset()
-		m.textInput2.Placeholder = "Description (optional)..."
+		m.textArea.Reset()
+		m.textArea.Placeholder = "Description (optional)..."
+		m.textArea.SetWidth(m.popupWidth() - 6)
 		m.inputFocused = 0
 		m.createPriority = "high"
 		return m, textinput.Blink
@@ -318,15 +446,19 @@ func (m Model) handleEpicKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.taskCursor--
 		m.clampTaskCursor()
 
-	// Resolve blocker on selected task.
+	// The selected task's saved agent outputs.
+	case "enter":
+		if t := m.selectedTask(); t != nil {
+			return m, m.loadArtifacts(t.ID)
+		}
+
+	// Answer the selected task's blocker inline.
 	case "r":
-		if t := m.selectedTask(); t != nil && t.Status == store.StatusBlocked {
-			m.popupTaskID = t.ID
-			m.popup = popupResolve
-			m.textInput.Reset()
-			m.textInput.Placeholder = "Your answer..."
-			m.textInput.Focus()
-			return m, textinput.Blink
+		if t := m.selectedTask(); t != nil && (t.Status == store.StatusBlocked || t.Status == store.StatusNeedsHuman) {
+			m.answerTaskID = t.ID
+			m.answerArea.Reset()
+			m.answerArea.SetWidth(m.answerWidth())
+			return m, m.answerArea.Focus()
 		}
 
 	// Diff for the whole epic.
@@ -339,9 +471,7 @@ func (m Model) handleEpicKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// Accept the epic.
 	case "y":
-		m.popupEpicID = m.epicDetail.Epic.ID
-		m.popup = popupConfirmAccept
-		return m, nil
+		return m.openAcceptConfirm(m.epicDetail.Epic.ID)
 
 	// Reject the epic.
 	case "n":
@@ -352,9 +482,23 @@ func (m Model) handleEpicKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.textInput.Focus()
 		return m, textinput.Blink
 
-	// Run auto on this epic.
+	// Run auto on this epic, copy the command, or cancel the run.
 	case "a":
-		m.setStatus("Run in terminal: hive auto " + itoa(int(m.epicDetail.Epic.ID)) + " --skip-plan")
+		return m.runPipeline(m.epicDetail, m.epicDetail.Epic.ID)
+	case "A":
+		cmd := "hive auto " + itoa(int(m.epicDetail.Epic.ID)) + " --skip-plan"
+		return m, copyCmd(cmd, cmd)
+	case "X":
+		m.cancelAuto(m.epicDetail.Epic.ID)
+
+	// Copy the selected task's blocker question.
+	case "C":
+		t := m.selectedTask()
+		if t == nil || t.Status != store.StatusBlocked || t.BlockedReason == "" {
+			m.setStatus("Select a blocked task to copy its question")
+			return m, nil
+		}
+		return m, copyCmd("#"+itoa(int(t.ID))+"'s question", t.BlockedReason)
 
 	case "esc", "backspace":
 		m.screen = screenGrid
@@ -365,15 +509,108 @@ func (m Model) handleEpicKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleAnswerKey edits the inline answer to a blocker. Enter starts a
+// new line; ctrl+s submits.
+func (m Model) handleAnswerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.answerArea.Blur()
+		m.answerTaskID = 0
+		return m, nil
+	case "ctrl+s":
+		answer := strings.TrimSpace(m.answerArea.Value())
+		if answer == "" {
+			m.setStatus("Answer cannot be empty")
+			return m, nil
+		}
+		if err := m.store.UnblockTask(m.answerTaskID, answer); err != nil {
+			m.setStatus("Error: " + err.Error())
+			return m, nil
+		}
+		m.answerArea.Blur()
+		m.setStatus("Resolved blocker on #" + itoa(int(m.answerTaskID)))
+		m.continueTaskID, m.answerTaskID = m.answerTaskID, 0
+		return m.answered(m.continueTaskID)
+	}
+
+	var cmd tea.Cmd
+	m.answerArea, cmd = m.answerArea.Update(msg)
+	return m, cmd
+}
+
+// handleContinueKey answers the offer to continue the pipeline for a task
+// whose blocker was just answered. Any other key declines it and does
+// what it normally does.
+func (m Model) handleContinueKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	taskID := m.continueTaskID
+	m.continueTaskID = 0
+	switch msg.String() {
+	case "y":
+		if m.epicDetail != nil {
+			return m.runPipeline(m.epicDetail, taskID, "--skip-plan")
+		}
+	case "n", "esc":
+		return m, nil
+	}
+	return m.handleKey(msg)
+}
+
+// runPipeline starts hive auto on the epic of card, or on one of its
+// tasks, unless a pipeline already runs on the epic.
+func (m Model) runPipeline(card *epicCard, taskID int64, args ...string) (tea.Model, tea.Cmd) {
+	epicID := card.Epic.ID
+	if m.procs[epicID] != nil || card.Run != nil {
+		m.setStatus("A pipeline is already running on E#" + itoa(int(epicID)))
+		return m, nil
+	}
+	// Held until it has started, so a second press doesn't start another.
+	m.procs[epicID] = &pipelineProc{taskID: taskID}
+	m.setStatus("Starting hive auto " + itoa(int(taskID)) + "...")
+	return m, m.startAuto(epicID, taskID, args...)
+}
+
+// answered reloads the board after a blocker is answered and, when the
+// epic's pipeline paused on it (auto.pause_on_block) and nothing else
+// waits, runs the pipeline again.
+func (m Model) answered(taskID int64) (tea.Model, tea.Cmd) {
+	epic := worker.ResumeEpic(m.store, taskID)
+	if epic == nil || m.epicCard(epic.ID) == nil {
+		return m, m.loadEpics()
+	}
+	m.continueTaskID = 0
+	next, cmd := m.runPipeline(m.epicCard(epic.ID), epic.ID)
+	return next, tea.Batch(cmd, m.loadEpics())
+}
+
+// stillBlocked reports whether a task of the epic on screen is blocked.
+func (m Model) stillBlocked(taskID int64) bool {
+	for _, t := range m.epicDetail.Tasks {
+		if t.ID == taskID {
+			return t.Status == store.StatusBlocked || t.Status == store.StatusNeedsHuman
+		}
+	}
+	return false
+}
+
+// answerWidth fits the answer field in the epic detail screen.
+func (m Model) answerWidth() int {
+	w := m.width - 10
+	if w > 80 {
+		w = 80
+	}
+	if w < 20 {
+		w = 20
+	}
+	return w
+}
+
 // --- Diff view keys ---
 
 func (m Model) handleDiffKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y":
 		// Accept from diff view.
-		m.popupEpicID = m.diffEpicID
-		m.popup = popupConfirmAccept
-		return m, nil
+		return m.openAcceptConfirm(m.diffEpicID)
 
 	case "n":
 		// Reject from diff view.
@@ -384,14 +621,18 @@ func (m Model) handleDiffKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.textInput.Focus()
 		return m, textinput.Blink
 
+	case "C":
+		if m.diffPatch == "" {
+			m.setStatus("No diff to copy")
+			return m, nil
+		}
+		return m, copyCmd("the diff of E#"+itoa(int(m.diffEpicID)), m.diffPatch)
+
 	case "e":
 		// Request changes.
 		m.popupEpicID = m.diffEpicID
 		m.popup = popupRequestFix
-		m.textInput.Reset()
-		m.textInput.Placeholder = "What needs fixing..."
-		m.textInput.Focus()
-		return m, textinput.Blink
+		return m, m.openTextArea("What needs fixing...")
 
 	case "esc", "q", "backspace":
 		return m.goBack()
@@ -416,6 +657,63 @@ func (m Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// --- Artifacts view keys ---
+
+func (m Model) handleArtifactsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "backspace":
+		return m.goBack()
+
+	// Step through every artifact, oldest to newest.
+	case "h", "left":
+		m.showArtifact(m.artifactIdx - 1)
+		return m, nil
+	case "l", "right":
+		m.showArtifact(m.artifactIdx + 1)
+		return m, nil
+
+	// Flip between an iteration's code and its review.
+	case "tab":
+		if len(m.artifactList) == 0 {
+			return m, nil
+		}
+		a := m.artifactList[m.artifactIdx]
+		other := map[string]string{"code": "review", "review": "code"}[a.Type]
+		if other == "" {
+			m.setStatus("Not a code or review artifact")
+			return m, nil
+		}
+		i := m.findArtifact(0, 1, other, artifactIter(a))
+		if i < 0 {
+			m.setStatus("No " + other + " for this iteration")
+			return m, nil
+		}
+		m.showArtifact(i)
+		return m, nil
+
+	// The previous or next iteration of the same kind.
+	case "[", "]":
+		if len(m.artifactList) == 0 {
+			return m, nil
+		}
+		dir := 1
+		if msg.String() == "[" {
+			dir = -1
+		}
+		i := m.findArtifact(m.artifactIdx+dir, dir, m.artifactList[m.artifactIdx].Type, 0)
+		if i < 0 {
+			m.setStatus("No more " + m.artifactList[m.artifactIdx].Type + " artifacts")
+			return m, nil
+		}
+		m.showArtifact(i)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.artifactsViewport, cmd = m.artifactsViewport.Update(msg)
+	return m, cmd
+}
+
 // --- Popup keys ---
 
 func (m Model) handlePopupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -434,25 +732,41 @@ func (m Model) handlePopupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openTextArea clears the popup's multi-line field and focuses it.
+func (m *Model) openTextArea(placeholder string) tea.Cmd {
+	m.textInput.Blur()
+	m.textArea.Reset()
+	m.textArea.Placeholder = placeholder
+	m.textArea.SetWidth(m.popupWidth() - 6)
+	return m.textArea.Focus()
+}
+
+// closePopup hides the popup and releases its text fields.
+func (m *Model) closePopup() {
+	m.popup = popupNone
+	m.textInput.Blur()
+	m.textArea.Blur()
+}
+
 func (m Model) handleResolvePopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
-		m.popup = popupNone
+		m.closePopup()
 		return m, nil
-	case "enter":
-		answer := m.textInput.Value()
+	case "ctrl+s":
+		answer := strings.TrimSpace(m.textArea.Value())
 		if answer == "" {
 			m.setStatus("Answer cannot be empty")
 			return m, nil
 		}
 		m.store.UnblockTask(m.popupTaskID, answer)
-		m.popup = popupNone
+		m.closePopup()
 		m.setStatus("Resolved blocker on #" + itoa(int(m.popupTaskID)))
-		return m, m.loadEpics()
+		return m.answered(m.popupTaskID)
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.textArea, cmd = m.textArea.Update(msg)
 	return m, cmd
 }
 
@@ -475,38 +789,37 @@ func (m Model) handleRejectPopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleRequestFixPopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
-		m.popup = popupNone
+		m.closePopup()
 		return m, nil
-	case "enter":
-		desc := m.textInput.Value()
+	case "ctrl+s":
+		desc := strings.TrimSpace(m.textArea.Value())
 		if desc == "" {
 			m.setStatus("Description cannot be empty")
 			return m, nil
 		}
-		m.popup = popupNone
+		m.closePopup()
 		return m, m.doCreateFixTask(m.popupEpicID, desc)
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.textArea, cmd = m.textArea.Update(msg)
 	return m, cmd
 }
 
 func (m Model) handleCreateEpicPopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
-		m.popup = popupNone
+		m.closePopup()
 		return m, nil
 	case "tab":
 		if m.inputFocused == 0 {
 			m.textInput.Blur()
-			m.textInput2.Focus()
 			m.inputFocused = 1
-		} else {
-			m.textInput2.Blur()
-			m.textInput.Focus()
-			m.inputFocused = 0
+			return m, m.textArea.Focus()
 		}
+		m.textArea.Blur()
+		m.textInput.Focus()
+		m.inputFocused = 0
 		return m, textinput.Blink
 	case "ctrl+p":
 		switch m.createPriority {
@@ -519,12 +832,19 @@ func (m Model) handleCreateEpicPopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case "enter":
-		title := m.textInput.Value()
+		// The title is one line: enter moves on to the description.
+		if m.inputFocused == 0 {
+			m.textInput.Blur()
+			m.inputFocused = 1
+			return m, m.textArea.Focus()
+		}
+	case "ctrl+s":
+		title := strings.TrimSpace(m.textInput.Value())
 		if title == "" {
 			m.setStatus("Title cannot be empty")
 			return m, nil
 		}
-		desc := m.textInput2.Value()
+		desc := strings.TrimSpace(m.textArea.Value())
 		epic, err := m.store.CreateEpic(title, desc, m.createPriority)
 		if err != nil {
 			m.setStatus("Error: " + err.Error())
@@ -543,21 +863,31 @@ func (m Model) handleCreateEpicPopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		m.popup = popupNone
+		m.closePopup()
 		m.setStatus("Created epic E#" + itoa(int(epic.ID)) + ": " + title)
 		return m, m.loadEpics()
 	}
 
-	// Forward to the active text input.
+	// Forward to the active field.
 	var cmd tea.Cmd
 	if m.inputFocused == 0 {
 		m.textInput, cmd = m.textInput.Update(msg)
 	} else {
-		m.textInput2, cmd = m.textInput2.Update(msg)
+		m.textArea, cmd = m.textArea.Update(msg)
 	}
 	return m, cmd
 }
 
+// openAcceptConfirm shows the accept popup and starts the merge preview.
+func (m Model) openAcceptConfirm(epicID int64) (tea.Model, tea.Cmd) {
+	m.popupEpicID = epicID
+	m.popup = popupConfirmAccept
+	m.mergeChecked = false
+	m.mergeConflicts = nil
+	m.mergeErr = nil
+	return m, m.previewMerge(epicID)
+}
+
 func (m Model) handleConfirmAcceptPopup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y", "enter":
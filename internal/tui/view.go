@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/worker"
 )
 
 // --- Color palette ---
@@ -86,6 +87,17 @@ func (m Model) View() string {
 		content = m.viewDiff()
 	case screenHistory:
 		content = m.viewHistory()
+	case screenArtifacts:
+		content = m.viewArtifacts()
+	}
+
+	// The command line goes at the bottom, as in vim; on the epic screen,
+	// which has no status bar, so does the outcome of a command.
+	switch {
+	case m.paletteActive:
+		content += "\n" + m.palette.View()
+	case (m.screen == screenEpic || m.screen == screenArtifacts) && m.statusMsg != "":
+		content += "\n" + m.statusLine()
 	}
 
 	// Overlay popup if active.
@@ -107,6 +119,9 @@ func (m Model) viewGrid() string {
 	count := len(m.epics)
 	header := titleStyle.Render("hive board")
 	header += dimStyle.Render(fmt.Sprintf(" — %d epics", count))
+	if waiting := len(m.groupEpics(groupBlocked)) + len(m.groupEpics(groupAwaiting)); waiting > 0 {
+		header += lipgloss.NewStyle().Foreground(clrYellow).Render(fmt.Sprintf(" · %d need you", waiting))
+	}
 
 	rightHelp := footerKeyStyle.Render("c") + footerDescStyle.Render(" new  ") +
 		footerKeyStyle.Render("q") + footerDescStyle.Render(" quit")
@@ -144,25 +159,29 @@ func (m Model) viewGrid() string {
 		}
 	}
 
-	for i := 0; i < len(m.epics); i += cols {
-		var rowCards []string
-		for j := 0; j < cols && i+j < len(m.epics); j++ {
-			idx := i + j
-			card := m.renderEpicCard(&m.epics[idx], idx == m.cursor, cardWidth)
-			rowCards = append(rowCards, card)
+	// One section per group, the ones that need a human first.
+	for g := epicGroup(0); g < numGroups; g++ {
+		idx := m.groupEpics(g)
+		if len(idx) == 0 {
+			continue
+		}
+		b.WriteString(m.renderSectionHeader(g, len(idx)) + "\n")
+		if m.collapsed[g] {
+			continue
+		}
+		for i := 0; i < len(idx); i += cols {
+			var rowCards []string
+			for _, n := range idx[i:min(i+cols, len(idx))] {
+				rowCards = append(rowCards, m.renderEpicCard(&m.epics[n], n == m.cursor, cardWidth))
+			}
+			b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, rowCards...))
+			b.WriteString("\n")
 		}
-		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, rowCards...))
-		b.WriteString("\n")
 	}
 
 	// Status bar.
 	if m.statusMsg != "" {
-		b.WriteString("\n")
-		if strings.HasPrefix(strings.ToLower(m.statusMsg), "failed") || strings.HasPrefix(strings.ToLower(m.statusMsg), "error") {
-			b.WriteString(errorStyle.Render("  " + m.statusMsg))
-		} else {
-			b.WriteString(statusStyle.Render("  " + m.statusMsg))
-		}
+		b.WriteString("\n" + m.statusLine())
 	}
 
 	// Footer.
@@ -172,6 +191,30 @@ func (m Model) viewGrid() string {
 	return b.String()
 }
 
+// renderSectionHeader renders a section's title line, e.g.
+// "1 ▾ Blocked (2)", with its number as the key that collapses it.
+func (m Model) renderSectionHeader(g epicGroup, count int) string {
+	color := map[epicGroup]lipgloss.AdaptiveColor{
+		groupBlocked: clrRed, groupAwaiting: clrGreen, groupActive: clrBlue, groupDone: clrSubtle,
+	}[g]
+	arrow := "▾"
+	if m.collapsed[g] {
+		arrow = "▸"
+	}
+	return " " + footerKeyStyle.Render(itoa(int(g)+1)) + " " +
+		lipgloss.NewStyle().Bold(true).Foreground(color).Render(arrow+" "+groupLabels[g]) +
+		dimStyle.Render(fmt.Sprintf(" (%d)", count))
+}
+
+// statusLine renders the status message, in red when it reports an
+// error.
+func (m Model) statusLine() string {
+	if strings.HasPrefix(strings.ToLower(m.statusMsg), "failed") || strings.HasPrefix(strings.ToLower(m.statusMsg), "error") {
+		return errorStyle.Render("  " + m.statusMsg)
+	}
+	return statusStyle.Render("  " + m.statusMsg)
+}
+
 func (m Model) renderEpicCard(card *epicCard, selected bool, width int) string {
 	var content strings.Builder
 
@@ -213,6 +256,10 @@ func (m Model) renderEpicCard(card *epicCard, selected bool, width int) string {
 	if card.HasBlocker {
 		blockerMsg := truncate(card.BlockerMsg, width-6)
 		content.WriteString(lipgloss.NewStyle().Foreground(clrRed).Render("⚠ BLOCKED ") + blockerMsg)
+	} else if card.NeedsHuman != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(clrYellow).Render("✋ NEEDS HUMAN ") + truncate(card.NeedsHuman, width-20))
+	} else if run := m.runLine(card); run != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(clrBlue).Render(truncate(run, width-6)))
 	} else if card.Phase == phaseAccept && allTasksDone(card) && card.Epic.Status != store.StatusDone {
 		content.WriteString(lipgloss.NewStyle().Foreground(clrGreen).Render("✓ Ready — review & accept"))
 	} else if card.Epic.Status == store.StatusDone {
@@ -221,6 +268,10 @@ func (m Model) renderEpicCard(card *epicCard, selected bool, width int) string {
 		content.WriteString(dimStyle.Render(truncate(card.LogLine, width-6)))
 	}
 
+	if m.expanded[card.Epic.ID] {
+		content.WriteString(m.renderMiniLog(card, width-6))
+	}
+
 	// Pick card style.
 	style := epicCardStyle.Width(width)
 	if selected {
@@ -234,6 +285,54 @@ func (m Model) renderEpicCard(card *epicCard, selected bool, width int) string {
 	return style.Render(content.String())
 }
 
+// miniLogEvents is how many of an epic's latest events an expanded card
+// shows.
+const miniLogEvents = 5
+
+// renderMiniLog renders what an expanded card adds: a line per task and
+// the epic's latest events.
+func (m Model) renderMiniLog(card *epicCard, width int) string {
+	var b strings.Builder
+	if len(card.Tasks) > 0 {
+		b.WriteString("\n")
+		for _, t := range card.Tasks {
+			id := fmt.Sprintf("#%d ", t.ID)
+			b.WriteString("\n" + taskDot(t.Status) + " " + lipgloss.NewStyle().Foreground(clrCyan).Render(id) +
+				truncate(t.Title, width-len(id)-2))
+		}
+	}
+	if n := len(card.Events); n > 0 {
+		b.WriteString("\n")
+		for _, ev := range card.Events[max(0, n-miniLogEvents):] {
+			ts := ev.Timestamp.Local().Format("15:04") + " "
+			b.WriteString("\n" + dimStyle.Render(ts) + truncate(formatLogLine(ev), width-len(ts)))
+		}
+	}
+	return b.String()
+}
+
+// runLine describes the pipeline running on an epic, e.g.
+// "▶ #12 iter 2/3 · claude coding", or is empty when none is.
+func (m Model) runLine(card *epicCard) string {
+	p := card.Run
+	switch {
+	case p == nil && m.procs[card.Epic.ID] != nil:
+		return "▶ starting..."
+	case p == nil:
+		return ""
+	case p.TaskID == 0:
+		return "▶ planning..."
+	}
+	doing := "coding"
+	if p.Phase == store.PhaseReview {
+		doing = "in review"
+	}
+	if p.Agent != "" && p.Phase != store.PhaseReview {
+		doing = p.Agent + " " + doing
+	}
+	return fmt.Sprintf("▶ #%d iter %d/%d · %s", p.TaskID, p.Iteration, p.MaxLoops, doing)
+}
+
 func (m Model) renderPipeline(card *epicCard) string {
 	var parts []string
 
@@ -290,14 +389,19 @@ func (m Model) renderPhaseLabels(card *epicCard) string {
 func (m Model) gridFooter() string {
 	keys := []struct{ key, desc string }{
 		{"↑↓←→", "navigate"},
+		{"tab", "next section"},
+		{"1-4", "fold section"},
 		{"enter", "open epic"},
-		{"a", "auto cmd"},
+		{"a", "run auto"},
+		{"X", "cancel run"},
+		{"x", "expand"},
 		{"r", "resolve"},
 		{"d", "diff"},
 		{"y", "accept"},
 		{"n", "reject"},
 		{"H", "history"},
 		{"c", "new epic"},
+		{":", "command"},
 		{"R", "refresh"},
 	}
 	return renderFooter(keys)
@@ -327,7 +431,15 @@ func (m Model) viewEpicDetail() string {
 
 	// Pipeline tracker (same as card but full width).
 	b.WriteString("  " + m.renderPipeline(e) + "\n")
-	b.WriteString("  " + m.renderPhaseLabels(e) + "\n\n")
+	b.WriteString("  " + m.renderPhaseLabels(e) + "\n")
+	if run := m.runLine(e); run != "" {
+		b.WriteString("  " + lipgloss.NewStyle().Foreground(clrBlue).Render(run))
+		if proc := m.procs[e.Epic.ID]; proc != nil {
+			b.WriteString(dimStyle.Render("  log: " + proc.logPath))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	// Task list.
 	if len(e.Tasks) == 0 {
@@ -339,6 +451,17 @@ func (m Model) viewEpicDetail() string {
 			selected := i == m.taskCursor
 			line := m.renderTaskLine(t, selected)
 			b.WriteString(line + "\n")
+			b.WriteString(m.renderAnswer(t))
+		}
+	}
+
+	// Live output of the selected task's agent.
+	if t := m.selectedTask(); t != nil && (t.Status == store.StatusInProgress || t.Status == store.StatusReview) {
+		if lines := m.liveTail(t.ID, 8); len(lines) > 0 {
+			b.WriteString("\n" + lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("  Live #%d:", t.ID)) + "\n")
+			for _, line := range lines {
+				b.WriteString("    " + dimStyle.Render(truncate(strings.TrimSpace(line), 70)) + "\n")
+			}
 		}
 	}
 
@@ -357,7 +480,7 @@ func (m Model) viewEpicDetail() string {
 			if ev.Agent != "" {
 				agent = lipgloss.NewStyle().Foreground(clrCyan).Render(ev.Agent) + " "
 			}
-			content := truncate(ev.Content, 60)
+			content := truncate(strings.Join(strings.Fields(ev.Content), " "), 60)
 			b.WriteString(fmt.Sprintf("    %s %s%s\n", ts, agent, content))
 		}
 	}
@@ -366,12 +489,17 @@ func (m Model) viewEpicDetail() string {
 	b.WriteString("\n")
 	keys := []struct{ key, desc string }{
 		{"↑↓", "select task"},
-		{"r", "resolve"},
+		{"enter", "artifacts"},
+		{"r", "answer / guide"},
 		{"d", "diff"},
 		{"y", "accept"},
 		{"n", "reject"},
 		{"H", "history"},
-		{"a", "auto cmd"},
+		{"a", "run auto"},
+		{"X", "cancel run"},
+		{"A", "copy auto cmd"},
+		{"C", "copy question"},
+		{":", "command"},
 		{"esc", "back"},
 	}
 	b.WriteString(renderFooter(keys))
@@ -379,25 +507,29 @@ func (m Model) viewEpicDetail() string {
 	return b.String()
 }
 
-func (m Model) renderTaskLine(t store.Task, selected bool) string {
-	// Status dot.
-	var dot string
-	switch t.Status {
+// taskDot is the colored dot for a task status.
+func taskDot(status store.TaskStatus) string {
+	switch status {
 	case store.StatusDone:
-		dot = lipgloss.NewStyle().Foreground(clrGreen).Render("●")
+		return lipgloss.NewStyle().Foreground(clrGreen).Render("●")
 	case store.StatusInProgress:
-		dot = lipgloss.NewStyle().Foreground(clrBlue).Render("◉")
+		return lipgloss.NewStyle().Foreground(clrBlue).Render("◉")
 	case store.StatusBlocked:
-		dot = lipgloss.NewStyle().Foreground(clrRed).Render("●")
+		return lipgloss.NewStyle().Foreground(clrRed).Render("●")
 	case store.StatusReview:
-		dot = lipgloss.NewStyle().Foreground(clrYellow).Render("◉")
+		return lipgloss.NewStyle().Foreground(clrYellow).Render("◉")
 	case store.StatusFailed:
-		dot = lipgloss.NewStyle().Foreground(clrRed).Render("✗")
+		return lipgloss.NewStyle().Foreground(clrRed).Render("✗")
+	case store.StatusNeedsHuman:
+		return lipgloss.NewStyle().Foreground(clrYellow).Render("!")
 	case store.StatusCancelled:
-		dot = dimStyle.Render("—")
-	default:
-		dot = dimStyle.Render("○")
+		return dimStyle.Render("—")
 	}
+	return dimStyle.Render("○")
+}
+
+func (m Model) renderTaskLine(t store.Task, selected bool) string {
+	dot := taskDot(t.Status)
 
 	// ID + title.
 	id := lipgloss.NewStyle().Foreground(clrCyan).Render(fmt.Sprintf("#%d", t.ID))
@@ -420,15 +552,51 @@ func (m Model) renderTaskLine(t store.Task, selected bool) string {
 
 	line := fmt.Sprintf("  %s %s %s %-40s %-12s %s", cursor, dot, id, title, statusStr, agent)
 
-	// Add blocker reason if blocked.
+	// The blocker's question, in full, so it can be answered right here.
 	if t.Status == store.StatusBlocked && t.BlockedReason != "" {
-		reason := truncate(t.BlockedReason, 50)
-		line += "\n      " + lipgloss.NewStyle().Foreground(clrRed).Render("⚠ "+reason)
+		question := lipgloss.NewStyle().Foreground(clrRed).Width(m.answerWidth()).Render("⚠ " + t.BlockedReason)
+		for _, l := range strings.Split(question, "\n") {
+			line += "\n      " + l
+		}
+	}
+	// Why the fix loop gave up, and what the reviewer objected to.
+	if t.Status == store.StatusNeedsHuman && m.epicDetail != nil {
+		reason, objections := worker.NeedsHumanReason(t.ID, m.epicDetail.Events)
+		text := "✋ " + reason
+		for i, o := range objections {
+			if i == 5 {
+				text += fmt.Sprintf("\n  ... and %d more", len(objections)-5)
+				break
+			}
+			text += "\n  - " + o
+		}
+		for _, l := range strings.Split(lipgloss.NewStyle().Foreground(clrYellow).Width(m.answerWidth()).Render(text), "\n") {
+			line += "\n      " + l
+		}
 	}
 
 	return line
 }
 
+// renderAnswer shows the answer field under the task being answered, or
+// the offer to continue its pipeline once answered.
+func (m Model) renderAnswer(t store.Task) string {
+	switch t.ID {
+	case m.answerTaskID:
+		var b strings.Builder
+		for _, l := range strings.Split(m.answerArea.View(), "\n") {
+			b.WriteString("      " + l + "\n")
+		}
+		b.WriteString("      " + footerDescStyle.Render("ctrl+s submit • enter new line • esc cancel") + "\n")
+		return b.String()
+	case m.continueTaskID:
+		return "      " + statusStyle.Render("✓ Answered.") + " Continue the pipeline for #" + itoa(int(t.ID)) + " now? " +
+			footerKeyStyle.Render("y") + footerDescStyle.Render(" run hive auto  ") +
+			footerKeyStyle.Render("n") + footerDescStyle.Render(" later") + "\n"
+	}
+	return ""
+}
+
 // ════════════════════════════════════════════════
 // DIFF VIEW
 // ════════════════════════════════════════════════
@@ -449,6 +617,7 @@ func (m Model) viewDiff() string {
 		{"y", "accept"},
 		{"n", "reject"},
 		{"e", "request fix"},
+		{"C", "copy diff"},
 		{"esc", "back"},
 	}
 	b.WriteString(renderFooter(keys))
@@ -478,6 +647,52 @@ func (m Model) viewHistory() string {
 	return b.String()
 }
 
+// ════════════════════════════════════════════════
+// ARTIFACTS VIEW — a task's saved agent outputs
+// ════════════════════════════════════════════════
+
+func (m Model) viewArtifacts() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Artifacts"))
+	b.WriteString("  ")
+	b.WriteString(dimStyle.Render(fmt.Sprintf("#%d", m.artifactTaskID)))
+	b.WriteString("\n\n")
+
+	// One tab per artifact, the one on screen highlighted.
+	var tabs []string
+	for i, a := range m.artifactList {
+		label := a.Type
+		if n := artifactIter(a); n > 0 {
+			label += fmt.Sprintf(" %d", n)
+		}
+		if i == m.artifactIdx {
+			tabs = append(tabs, footerKeyStyle.Render("["+label+"]"))
+		} else {
+			tabs = append(tabs, dimStyle.Render(label))
+		}
+	}
+	if len(m.artifactList) > 0 {
+		a := m.artifactList[m.artifactIdx]
+		b.WriteString("  " + strings.Join(tabs, " ") + "\n")
+		b.WriteString("  " + dimStyle.Render(a.FilePath+"  "+a.Timestamp.Local().Format("2006-01-02 15:04")) + "\n")
+	}
+
+	b.WriteString(m.artifactsViewport.View())
+	b.WriteString("\n\n")
+
+	keys := []struct{ key, desc string }{
+		{"↑↓", "scroll"},
+		{"←→", "prev/next"},
+		{"tab", "code ↔ review"},
+		{"[ ]", "prev/next iteration"},
+		{"esc", "back"},
+	}
+	b.WriteString(renderFooter(keys))
+
+	return b.String()
+}
+
 // ════════════════════════════════════════════════
 // POPUPS
 // ════════════════════════════════════════════════
@@ -520,14 +735,19 @@ func (m Model) viewResolvePopup() string {
 
 	// Find the blocked task to show the question.
 	task, _ := m.store.GetTask(m.popupTaskID)
-	if task != nil {
+	if task != nil && task.Status == store.StatusNeedsHuman {
+		events, _ := m.store.GetEvents(task.ID)
+		reason, _ := worker.NeedsHumanReason(task.ID, events)
+		q := lipgloss.NewStyle().Foreground(clrYellow).Render(reason)
+		b.WriteString(fmt.Sprintf("#%d needs a human:\n%s\n\nYour guidance goes to the coder for another round.\n\n", task.ID, q))
+	} else if task != nil {
 		q := lipgloss.NewStyle().Foreground(clrRed).Render(task.BlockedReason)
 		b.WriteString(fmt.Sprintf("#%d asks:\n%s\n\n", task.ID, q))
 	}
 
 	b.WriteString("Your answer:\n")
-	b.WriteString(m.textInput.View() + "\n\n")
-	b.WriteString(footerDescStyle.Render("enter submit • esc cancel"))
+	b.WriteString(m.textArea.View() + "\n\n")
+	b.WriteString(footerDescStyle.Render("ctrl+s submit • enter newline • esc cancel"))
 
 	return m.popupBoxStyle().Render(b.String())
 }
@@ -554,8 +774,8 @@ func (m Model) viewRequestFixPopup() string {
 
 	b.WriteString("Describe what needs fixing.\nThis creates a new task and re-runs the pipeline.\n\n")
 	b.WriteString("What needs fixing:\n")
-	b.WriteString(m.textInput.View() + "\n\n")
-	b.WriteString(footerDescStyle.Render("enter create task • esc cancel"))
+	b.WriteString(m.textArea.View() + "\n\n")
+	b.WriteString(footerDescStyle.Render("ctrl+s create task • enter newline • esc cancel"))
 
 	return m.popupBoxStyle().Render(b.String())
 }
@@ -570,7 +790,7 @@ func (m Model) viewCreateEpicPopup() string {
 	b.WriteString(m.textInput.View() + "\n\n")
 
 	b.WriteString("Description:\n")
-	b.WriteString(m.textInput2.View() + "\n\n")
+	b.WriteString(m.textArea.View() + "\n\n")
 
 	priStyle := lipgloss.NewStyle().Bold(true)
 	switch m.createPriority {
@@ -583,7 +803,7 @@ func (m Model) viewCreateEpicPopup() string {
 	}
 	b.WriteString(fmt.Sprintf("Priority: %s\n\n", priStyle.Render(m.createPriority)))
 
-	b.WriteString(footerDescStyle.Render("enter create • tab switch • ctrl+p priority • esc cancel"))
+	b.WriteString(footerDescStyle.Render("ctrl+s create • tab switch • ctrl+p priority • esc cancel"))
 
 	return m.popupBoxStyle().Render(b.String())
 }
@@ -597,6 +817,27 @@ func (m Model) viewConfirmAcceptPopup() string {
 	b.WriteString("Merge safety branch into main?\n")
 	b.WriteString("This is permanent.\n\n")
 
+	switch {
+	case !m.mergeChecked:
+		b.WriteString(footerDescStyle.Render("Checking for conflicts...") + "\n\n")
+	case m.mergeErr != nil:
+		b.WriteString(footerDescStyle.Render("Could not preview merge: "+m.mergeErr.Error()) + "\n\n")
+	case len(m.mergeConflicts) > 0:
+		warn := lipgloss.NewStyle().Bold(true).Foreground(clrRed)
+		b.WriteString(warn.Render(fmt.Sprintf("⚠ This merge will conflict in %d file(s):", len(m.mergeConflicts))) + "\n")
+		const maxShown = 5
+		for i, f := range m.mergeConflicts {
+			if i == maxShown {
+				b.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.mergeConflicts)-maxShown))
+				break
+			}
+			b.WriteString("  " + f + "\n")
+		}
+		b.WriteString("\n")
+	default:
+		b.WriteString(lipgloss.NewStyle().Foreground(clrGreen).Render("✓ Merges cleanly") + "\n\n")
+	}
+
 	b.WriteString(footerKeyStyle.Render("y") + footerDescStyle.Render(" confirm  ") +
 		footerKeyStyle.Render("n") + footerDescStyle.Render(" cancel"))
 
@@ -604,6 +845,11 @@ func (m Model) viewConfirmAcceptPopup() string {
 }
 
 func (m Model) popupBoxStyle() lipgloss.Style {
+	return popupStyle.Width(m.popupWidth())
+}
+
+// popupWidth fits popups to the terminal.
+func (m Model) popupWidth() int {
 	w := 60
 	if m.width > 0 {
 		w = m.width - 12
@@ -614,7 +860,7 @@ func (m Model) popupBoxStyle() lipgloss.Style {
 			w = 84
 		}
 	}
-	return popupStyle.Width(w)
+	return w
 }
 
 // ════════════════════════════════════════════════
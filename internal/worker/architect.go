@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// maxSpecEvent is how much of a spec goes into the architect_spec event;
+// the full output is in the task's architect artifact.
+const maxSpecEvent = 4000
+
+// RunArchitect runs the architect on a task. Its spec is saved as an
+// artifact and as an "architect_spec" event, which the coder's prompt
+// includes. Returns "done", "blocked" or "failed", and the response when
+// the agent ran.
+func RunArchitect(s *store.Store, arts *artifacts.Manager, ctxBuilder *agentctx.Builder, task *store.Task, name string, agentCfg config.Agent, workDir string, stream func(string)) (string, *agent.Response) {
+	prompt, err := ctxBuilder.BuildPrompt(task, roles.Architect)
+	if err != nil {
+		return "failed", nil
+	}
+	runner, err := NewRunner(name, agentCfg)
+	if err != nil {
+		return "failed", nil
+	}
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID:     task.ID,
+		Prompt:     prompt,
+		WorkDir:    workDir,
+		TimeoutSec: agentCfg.DefaultTimeout(),
+		Stream:     stream,
+	})
+	if err != nil {
+		return "failed", nil
+	}
+
+	arts.Save(task.ID, "architect", artifacts.Name(task.ID, "architect"), resp.Output)
+
+	if b := agent.ParseBlocked(resp.Output); b != "" {
+		s.BlockTask(task.ID, b)
+		return "blocked", resp
+	}
+
+	spec := resp.Output
+	if len(spec) > maxSpecEvent {
+		spec = spec[:maxSpecEvent] + "\n\n... (spec truncated, see full output in artifacts)"
+	}
+	s.AddEvent(task.ID, name, "architect_spec", spec)
+	return "done", resp
+}
+
+// NeedsArchitect reports whether the architect researches a task: it is
+// still open.
+func NeedsArchitect(task store.Task) bool {
+	switch task.Status {
+	case store.StatusDone, store.StatusBlocked, store.StatusCancelled:
+		return false
+	}
+	return true
+}
+
+// Architect runs the pool's architect on the tasks that need it (see
+// NeedsArchitect), up to MaxWorkers at a time, before any of them is
+// coded. The architect only reads the code, so all of them work in the
+// main directory. Prompts come from ctxBuilder, or a builder with the
+// pool's roles if it is nil. Tasks that don't need it are left out of the
+// results.
+func (p *Pool) Architect(tasks []store.Task, ctxBuilder *agentctx.Builder) []TaskResult {
+	if p.archName == "" {
+		return nil
+	}
+	if ctxBuilder == nil {
+		ctxBuilder = agentctx.New(p.store).WithRoles(p.roles).WithWorkDir(p.workDir)
+	}
+	workers := p.maxWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	ran := make([]*TaskResult, len(tasks))
+
+	for i, task := range tasks {
+		if !NeedsArchitect(task) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, t store.Task) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			p.emit(t.ID, fmt.Sprintf("%s researching #%d %s...", p.archName, t.ID, t.Title))
+			status, resp := RunArchitect(p.store, p.arts, ctxBuilder, &t, p.archName, p.archCfg, p.workDir, p.agentStream(t.ID))
+			r := TaskResult{TaskID: t.ID, Title: t.Title, Status: status}
+			if resp != nil {
+				r.Duration = time.Duration(resp.Duration * float64(time.Second))
+				if resp.Truncated {
+					msg := fmt.Sprintf("The spec hit the token limit after %d continuation(s); it may be incomplete", resp.Continuations)
+					p.store.AddEvent(t.ID, p.archName, "truncated", msg)
+					r.Log = append(r.Log, msg)
+				}
+			}
+			p.emit(t.ID, "architect: "+status)
+			ran[idx] = &r
+		}(i, task)
+	}
+	wg.Wait()
+
+	var results []TaskResult
+	for _, r := range ran {
+		if r != nil {
+			results = append(results, *r)
+		}
+	}
+	return results
+}
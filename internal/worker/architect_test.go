@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestPool_Architect(t *testing.T) {
+	s := testStore(t)
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "architect.md"), []byte("## Spec\nAdd greet() to main.go\n"), 0644)
+	os.WriteFile(filepath.Join(fixtures, "architect-task3-1.md"), []byte("BLOCKED: Which locale?\n"), 0644)
+
+	spec, _ := s.CreateTask("Add greeting", "", "", nil)
+	done, _ := s.CreateTask("Already done", "", "", nil)
+	blocks, _ := s.CreateTask("Localize greeting", "", "", nil)
+	s.UpdateTaskStatus(done.ID, store.StatusDone)
+	tasks, _ := s.ListTasks("")
+
+	pool := NewPool(PoolConfig{
+		Store:         s,
+		WorkDir:       t.TempDir(),
+		MaxWorkers:    3,
+		ArchitectName: "arch",
+		ArchitectCfg:  config.Agent{Role: "architect", Mode: "fake", Fixtures: fixtures},
+		LogDir:        t.TempDir(),
+	})
+	results := pool.Architect(tasks, nil)
+	if len(results) != 2 {
+		t.Fatalf("expected the two open tasks researched, got %+v", results)
+	}
+	status := map[int64]string{}
+	for _, r := range results {
+		status[r.TaskID] = r.Status
+	}
+	if status[spec.ID] != "done" || status[blocks.ID] != "blocked" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	events, _ := s.GetEvents(spec.ID)
+	if last := events[len(events)-1]; last.Type != "architect_spec" || !strings.Contains(last.Content, "Add greet()") {
+		t.Errorf("expected the spec recorded for the coder, got %+v", last)
+	}
+	if got, _ := s.GetTask(blocks.ID); got.Status != store.StatusBlocked || got.BlockedReason != "Which locale?" {
+		t.Errorf("expected #%d blocked, got %+v", blocks.ID, got)
+	}
+
+	if NewPool(PoolConfig{Store: s}).Architect(tasks, nil) != nil {
+		t.Error("a pool without an architect should do nothing")
+	}
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+// BackoffDelay returns the wait before fix-loop iteration n (see
+// config.Backoff); none without a config. With jitter, for parallel
+// workers, up to a quarter more is added at random.
+func BackoffDelay(cfg *config.Config, iteration int, jitter bool) time.Duration {
+	if cfg == nil {
+		return 0
+	}
+	d := cfg.Backoff.Delay(iteration)
+	if jitter && d > 0 {
+		d += time.Duration(rand.Int63n(int64(d)/4 + 1))
+	}
+	return d
+}
+
+// WaitBackoff sleeps for d, calling tick with the time left, in whole
+// seconds, when it starts and every interval after that.
+func WaitBackoff(d, every time.Duration, tick func(left time.Duration)) {
+	deadline := time.Now().Add(d)
+	for left := d; left > 0; left = time.Until(deadline) {
+		tick(left.Round(time.Second))
+		time.Sleep(min(left, every))
+	}
+}
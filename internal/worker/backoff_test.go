@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	cfg := &config.Config{Backoff: config.Backoff{InitialSec: 10, MaxSec: 30}}
+	for iteration, want := range map[int]time.Duration{1: 0, 2: 10 * time.Second, 3: 20 * time.Second, 4: 30 * time.Second, 9: 30 * time.Second} {
+		if got := BackoffDelay(cfg, iteration, false); got != want {
+			t.Errorf("iteration %d: got %s, want %s", iteration, got, want)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if got := BackoffDelay(cfg, 2, true); got < 10*time.Second || got > 12500*time.Millisecond {
+			t.Fatalf("jittered delay out of range: %s", got)
+		}
+	}
+	if got := BackoffDelay(nil, 5, true); got != 0 {
+		t.Errorf("no config: got %s", got)
+	}
+	if got := BackoffDelay(&config.Config{}, 5, true); got != 0 {
+		t.Errorf("off by default: got %s", got)
+	}
+}
+
+func TestWaitBackoff(t *testing.T) {
+	var ticks []time.Duration
+	start := time.Now()
+	WaitBackoff(50*time.Millisecond, 20*time.Millisecond, func(left time.Duration) { ticks = append(ticks, left) })
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("returned after %s", elapsed)
+	}
+	if len(ticks) < 3 {
+		t.Errorf("expected a tick per interval, got %v", ticks)
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"os"
+	"sync"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Checkpoints records where each task of a pipeline run is in its fix
+// loop, and, when the run resumes an interrupted one, where that run left
+// each task. A nil *Checkpoints records nothing and resumes nothing.
+type Checkpoints struct {
+	store  *store.Store
+	arts   *artifacts.Manager
+	runID  int64
+	mu     sync.Mutex
+	resume map[int64]store.TaskCheckpoint
+}
+
+// NewCheckpoints returns the checkpoints of pipeline run runID; resume
+// holds the interrupted run's checkpoints by task, or is nil.
+func NewCheckpoints(s *store.Store, arts *artifacts.Manager, runID int64, resume map[int64]store.TaskCheckpoint) *Checkpoints {
+	c := &Checkpoints{store: s, arts: arts, runID: runID, resume: map[int64]store.TaskCheckpoint{}}
+	for id, cp := range resume {
+		c.resume[id] = cp
+	}
+	return c
+}
+
+// Save records that a task reached phase in an iteration. code and review
+// are the artifact names of the iteration's coder output and the last
+// review, if any.
+func (c *Checkpoints) Save(taskID int64, iteration int, phase, code, review string) {
+	if c == nil || c.runID == 0 {
+		return
+	}
+	c.store.SaveCheckpoint(store.TaskCheckpoint{
+		RunID: c.runID, TaskID: taskID, Iteration: iteration, Phase: phase,
+		CodeArtifact: code, ReviewArtifact: review,
+	})
+}
+
+// ResumePoint is where a fix loop continues after a crash.
+type ResumePoint struct {
+	Iteration int
+	Coder     *agent.Response // The iteration's coder output, when the coder had finished
+}
+
+// Resume returns where the interrupted run left a task, once: the loop
+// continues at that iteration instead of the first. If the coder had
+// finished the iteration and reuseCode is set (its changes are still in
+// the working tree), its output is returned so the loop goes straight to
+// checks and review. ok is false when the task starts from scratch.
+func (c *Checkpoints) Resume(taskID int64, reuseCode bool) (ResumePoint, bool) {
+	if c == nil {
+		return ResumePoint{}, false
+	}
+	c.mu.Lock()
+	cp, ok := c.resume[taskID]
+	delete(c.resume, taskID)
+	c.mu.Unlock()
+	if !ok || cp.Phase == store.PhaseDone || cp.Iteration < 1 {
+		return ResumePoint{}, false
+	}
+
+	rp := ResumePoint{Iteration: cp.Iteration}
+	if cp.Phase == store.PhaseReview && reuseCode && cp.CodeArtifact != "" {
+		if data, err := os.ReadFile(c.arts.Path(cp.CodeArtifact)); err == nil {
+			rp.Coder = &agent.Response{Output: string(data)}
+		}
+	}
+	return rp, true
+}
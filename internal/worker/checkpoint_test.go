@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestCheckpoints_Resume(t *testing.T) {
+	s := testStore(t)
+	arts := artifacts.New(s, t.TempDir())
+	epic, _ := s.CreateEpic("Auth", "", "")
+	coded, _ := s.CreateTask("Login form", "", "", &epic.ID)
+	coding, _ := s.CreateTask("Logout", "", "", &epic.ID)
+	finished, _ := s.CreateTask("Sessions", "", "", &epic.ID)
+
+	runID, _ := s.StartPipelineRun(epic.ID, 3, 1)
+	old := NewCheckpoints(s, arts, runID, nil)
+	code := artifacts.Name(coded.ID, "auto-code", "iter2")
+	arts.Save(coded.ID, "code", code, "wrote the form")
+	old.Save(coded.ID, 2, store.PhaseReview, code, "")
+	old.Save(coding.ID, 3, store.PhaseCode, "", "")
+	old.Save(finished.ID, 0, store.PhaseDone, "", "")
+
+	saved, err := s.ListCheckpoints(runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, _ := s.StartPipelineRun(epic.ID, 3, 1)
+	cps := NewCheckpoints(s, arts, next, saved)
+
+	rp, ok := cps.Resume(coded.ID, true)
+	if !ok || rp.Iteration != 2 || rp.Coder == nil || rp.Coder.Output != "wrote the form" {
+		t.Errorf("coded task: %+v %v", rp, ok)
+	}
+	if _, ok := cps.Resume(coded.ID, true); ok {
+		t.Error("a task resumes only once")
+	}
+	if rp, ok := cps.Resume(coding.ID, true); !ok || rp.Iteration != 3 || rp.Coder != nil {
+		t.Errorf("task mid-coder: %+v %v", rp, ok)
+	}
+	if _, ok := cps.Resume(finished.ID, true); ok {
+		t.Error("a finished loop starts from scratch")
+	}
+
+	again := NewCheckpoints(s, arts, next, saved)
+	if rp, _ := again.Resume(coded.ID, false); rp.Iteration != 2 || rp.Coder != nil {
+		t.Errorf("without reuseCode the coder runs again: %+v", rp)
+	}
+
+	var none *Checkpoints
+	none.Save(coded.ID, 1, store.PhaseCode, "", "")
+	if _, ok := none.Resume(coded.ID, true); ok {
+		t.Error("nil checkpoints resume nothing")
+	}
+}
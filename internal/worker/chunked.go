@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// ChunkedReview reviews a diff too large for one prompt part by part (see
+// agentctx.Builder.ReviewChunks) and combines the reviews. req carries
+// everything but the prompt and messages, which are built for each part.
+// Each part's review is saved as a "review" artifact named with label;
+// the returned response holds the combined review (see CombineReviews),
+// which parses like a single one.
+func ChunkedReview(runner agent.Runner, arts *artifacts.Manager, ctxBuilder *agentctx.Builder, task *store.Task, chunks []agentctx.DiffChunk, req agent.Request, checklist []config.ChecklistItem, label string, logf func(string, ...any)) (*agent.Response, error) {
+	combined := &agent.Response{}
+	outputs := make([]string, len(chunks))
+	for i, c := range chunks {
+		logf("  reviewing %s", c.Label())
+		cb := ctxBuilder.ForChunk(c)
+		req.Prompt, _ = cb.BuildReviewPrompt(task)
+		req.Messages, _ = cb.BuildReviewMessages(task)
+		resp, err := runner.Run(context.Background(), req)
+		if err != nil {
+			return nil, fmt.Errorf("review of %s: %w", c.Label(), err)
+		}
+		arts.Save(task.ID, "review", artifacts.Name(task.ID, label, fmt.Sprintf("part%d", c.Part)), resp.Output)
+		outputs[i] = resp.Output
+		combined.Duration += resp.Duration
+		combined.InputTokens += resp.InputTokens
+		combined.OutputTokens += resp.OutputTokens
+		combined.Truncated = combined.Truncated || resp.Truncated
+	}
+	combined.Output = CombineReviews(chunks, outputs, checklist, task.Acceptance)
+	return combined, nil
+}
+
+// CombineReviews merges the reviews of a diff's parts into one: any part
+// rejected rejects the whole, and it is approved only when every part
+// is. Comments are tagged with their part. A checklist item or acceptance
+// criterion is FAIL if any part failed it, else PASS if any part passed
+// it, else N/A if a part answered that.
+func CombineReviews(chunks []agentctx.DiffChunk, outputs []string, checklist []config.ChecklistItem, criteria []string) string {
+	verdict := "APPROVE"
+	var summary, comments, suggestions []string
+	checks := make([]agent.ChecklistAnswer, len(checklist))
+	accepted := make([]agent.ChecklistAnswer, len(criteria))
+	for i, out := range outputs {
+		c := chunks[i]
+		r := agent.ParseReview(out)
+		switch {
+		case r.Verdict == "REJECT":
+			verdict = "REJECT"
+		case r.Verdict == "" && verdict == "APPROVE":
+			verdict = ""
+		}
+		v := strings.ToLower(r.Verdict)
+		if v == "" {
+			v = "no verdict"
+		}
+		summary = append(summary, fmt.Sprintf("%s: %s", c.Label(), v))
+		for _, comment := range r.Comments {
+			comments = append(comments, fmt.Sprintf("- [part %d] %s", c.Part, comment))
+		}
+		suggestions = append(suggestions, r.Suggestions...)
+		mergeAnswers(checks, agent.ParseChecklist(out, checklist), c.Part)
+		mergeAnswers(accepted, agent.ParseAcceptance(out, criteria), c.Part)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The diff was too large for one review, so it was reviewed in %d parts:\n%s\n\n", len(chunks), strings.Join(summary, "\n"))
+	if verdict != "" {
+		fmt.Fprintf(&sb, "VERDICT: %s\n\n", verdict)
+	}
+	if len(comments) > 0 {
+		sb.WriteString("COMMENTS:\n" + strings.Join(comments, "\n") + "\n\n")
+	}
+	if len(checklist) > 0 {
+		sb.WriteString("CHECKLIST:\n")
+		for i, a := range checks {
+			writeAnswer(&sb, checklist[i].Item, a)
+		}
+		sb.WriteString("\n")
+	}
+	if len(criteria) > 0 {
+		sb.WriteString("ACCEPTANCE:\n")
+		for i, a := range accepted {
+			writeAnswer(&sb, agent.CriterionLabel(i), a)
+		}
+		sb.WriteString("\n")
+	}
+	for _, s := range suggestions {
+		sb.WriteString("```diff\n" + strings.TrimRight(s, "\n") + "\n```\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// answerRank orders answers for merging: a FAIL anywhere wins.
+var answerRank = map[string]int{"": 0, "N/A": 1, "PASS": 2, "FAIL": 3}
+
+func mergeAnswers(into, answers []agent.ChecklistAnswer, part int) {
+	for i, a := range answers {
+		if answerRank[a.Answer] <= answerRank[into[i].Answer] {
+			continue
+		}
+		into[i].Answer, into[i].Note = a.Answer, a.Note
+		if a.Answer == "FAIL" {
+			into[i].Note = strings.TrimSpace(fmt.Sprintf("%s (part %d)", a.Note, part))
+		}
+	}
+}
+
+// writeAnswer writes one merged entry; unanswered entries are left out,
+// so they count as unanswered again when the combined review is parsed.
+func writeAnswer(sb *strings.Builder, name string, a agent.ChecklistAnswer) {
+	if a.Answer == "" {
+		return
+	}
+	line := fmt.Sprintf("- %s: %s", name, a.Answer)
+	if a.Note != "" {
+		line += " — " + a.Note
+	}
+	sb.WriteString(line + "\n")
+}
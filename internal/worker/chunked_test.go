@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	agentctx "github.com/imkarma/hive/internal/context"
+)
+
+func TestCombineReviews(t *testing.T) {
+	chunks := []agentctx.DiffChunk{
+		{Part: 1, Parts: 2, Files: []string{"auth/login.go"}},
+		{Part: 2, Parts: 2, Files: []string{"README.md"}},
+	}
+	checklist := []config.ChecklistItem{{Item: "tests added"}, {Item: "docs updated"}}
+	outputs := []string{
+		"VERDICT: APPROVE\n\nCHECKLIST:\n- tests added: PASS\n- docs updated: N/A\n\nACCEPTANCE:\n- AC1: PASS\n",
+		"VERDICT: REJECT\n\nCOMMENTS:\n- install steps are stale\n\nCHECKLIST:\n- tests added: N/A\n- docs updated: FAIL — README still says make\n\nACCEPTANCE:\n- AC1: N/A\n",
+	}
+
+	combined := CombineReviews(chunks, outputs, checklist, []string{"Login works"})
+	review := agent.CheckAcceptance(agent.ParseReviewWithChecklist(combined, checklist), combined, []string{"Login works"})
+	if review.Verdict != "REJECT" {
+		t.Errorf("one rejected part rejects the whole:\n%s", combined)
+	}
+	if review.Checklist[0].Answer != "PASS" || review.Checklist[1].Answer != "FAIL" || !strings.Contains(review.Checklist[1].Note, "(part 2)") {
+		t.Errorf("checklist: %+v", review.Checklist)
+	}
+	if review.Acceptance[0].Answer != "PASS" {
+		t.Errorf("acceptance: %+v", review.Acceptance)
+	}
+	if !strings.Contains(strings.Join(review.Comments, "\n"), "[part 2] install steps are stale") {
+		t.Errorf("comments: %v", review.Comments)
+	}
+
+	approved := CombineReviews(chunks, []string{"VERDICT: APPROVE", "VERDICT: APPROVE"}, nil, nil)
+	if r := agent.ParseReview(approved); r.Verdict != "APPROVE" {
+		t.Errorf("every part approved:\n%s", approved)
+	}
+	unclear := CombineReviews(chunks, []string{"VERDICT: APPROVE", "Hmm, hard to say."}, nil, nil)
+	if r := agent.ParseReview(unclear); r.Verdict != "" {
+		t.Errorf("a part without a verdict leaves it unclear:\n%s", unclear)
+	}
+}
+
+func TestChunkedReview(t *testing.T) {
+	s := testStore(t)
+	root := t.TempDir()
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "reviewer-1.md"), []byte("VERDICT: APPROVE\n"), 0644)
+	os.WriteFile(filepath.Join(fixtures, "reviewer-2.md"), []byte("VERDICT: REJECT\n\nCOMMENTS:\n- missing test\n"), 0644)
+	runner := agent.NewFakeRunner("gpt", config.Agent{Role: "reviewer", Mode: "fake", Fixtures: fixtures})
+
+	task, _ := s.CreateTask("Auth", "", "", nil)
+	chunks := []agentctx.DiffChunk{
+		{Part: 1, Parts: 2, Files: []string{"a.go"}, Diff: "diff --git a/a.go b/a.go\n"},
+		{Part: 2, Parts: 2, Files: []string{"b.go"}, Diff: "diff --git a/b.go b/b.go\n"},
+	}
+	arts := artifacts.New(s, root)
+	resp, err := ChunkedReview(runner, arts, agentctx.New(s), task, chunks, agent.Request{TaskID: task.ID}, nil, "auto-review-iter1", func(string, ...any) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := agent.ParseReview(resp.Output); r.Verdict != "REJECT" || len(r.Comments) != 1 {
+		t.Errorf("combined review: %+v\n%s", r, resp.Output)
+	}
+	if _, err := os.Stat(arts.Path(artifacts.Name(task.ID, "auto-review-iter1", "part2"))); err != nil {
+		t.Errorf("each part's review is saved: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// conflictContext is how many lines around a conflicted region the coder
+// is shown.
+const conflictContext = 5
+
+// resolveConflict has the task's coder resolve an approved commit that
+// doesn't cherry-pick onto the epic branch: the commit is replayed in the
+// task's worktree at the epic branch's tip, the coder gets the conflicted
+// regions and what the tasks that changed those files were for, and the
+// result is merged again. Returns nil once it is on the epic branch. The
+// caller holds the merge lock, so the epic branch doesn't move meanwhile.
+func (p *Pool) resolveConflict(ctx context.Context, task *store.Task, wtPath string, conflict *git.ConflictError, logf func(string, ...any)) error {
+	wt := git.New(wtPath).WithContext(ctx)
+	files, err := wt.ReplayCommit(p.epicBranch, conflict.Commit)
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		if err := p.runResolver(task, wtPath, files, logf); err != nil {
+			wt.AbortReplay()
+			return err
+		}
+	}
+	return git.New(p.workDir).WithContext(ctx).MergeWorktreeChanges(wtPath, task.ID, task.Title)
+}
+
+// runResolver runs the coder on the conflicted files in wtPath and checks
+// that it left no conflict markers behind.
+func (p *Pool) runResolver(task *store.Task, wtPath string, files []string, logf func(string, ...any)) error {
+	coderName, coderCfg := p.coderFor(task)
+	if coderCfg.Mode == "api" {
+		return fmt.Errorf("%s is an API agent and can't edit the files", coderName)
+	}
+	runner, err := NewRunner(coderName, coderCfg)
+	if err != nil {
+		return fmt.Errorf("could not create coder %s: %w", coderName, err)
+	}
+
+	conflicted := make([]agentctx.ConflictFile, 0, len(files))
+	for _, f := range files {
+		data, _ := os.ReadFile(filepath.Join(wtPath, f))
+		conflicted = append(conflicted, agentctx.ConflictFile{Path: f, Hunks: git.ConflictHunks(string(data), conflictContext)})
+	}
+	var others []store.Task
+	ids, _ := git.New(p.workDir).TaskIDsTouching(p.epicBranch, files, 20)
+	for _, id := range ids {
+		if id == task.ID {
+			continue
+		}
+		if t, err := p.store.GetTask(id); err == nil {
+			others = append(others, *t)
+		}
+	}
+
+	logf("merge conflict in %s, %s resolving...", strings.Join(files, ", "), coderName)
+	prompt := agentctx.New(p.store).WithRoles(p.roles).WithWorkDir(wtPath).BuildConflictPrompt(task, others, conflicted)
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID: task.ID, Prompt: prompt, WorkDir: wtPath, TimeoutSec: coderCfg.DefaultTimeout(),
+		Stream: p.agentStream(task.ID),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", coderName, err)
+	}
+	p.arts.Save(task.ID, "output", artifacts.Name(task.ID, coderName, "merge-conflict"), resp.Output)
+	if resp.ExitCode != 0 {
+		return fmt.Errorf("%s exited with code %d", coderName, resp.ExitCode)
+	}
+	if b := agent.ParseBlocked(resp.Output); b != "" {
+		return fmt.Errorf("%s needs a decision: %s", coderName, b)
+	}
+	for _, f := range files {
+		if data, _ := os.ReadFile(filepath.Join(wtPath, f)); git.HasConflictMarkers(string(data)) {
+			return fmt.Errorf("%s left conflict markers in %s", coderName, f)
+		}
+	}
+	p.store.AddEvent(task.ID, coderName, "merge_resolved", "Resolved merge conflicts in "+strings.Join(files, ", "))
+	logf("  conflicts resolved (%.1fs)", resp.Duration)
+	return nil
+}
+
+// MarkNeedsMerge blocks an approved task whose work couldn't be merged
+// into the epic branch, even by the coder, and records where the work is
+// — the commit, or the task branch kept with git.task_branches — so it can
+// be merged by hand. Answering the blocker redoes the task on the epic
+// branch as it is now.
+func MarkNeedsMerge(s *store.Store, task *store.Task, epicBranch string, conflict *git.ConflictError) {
+	s.SetMergeCommit(task.ID, conflict.Commit)
+	s.AddEvent(task.ID, "git", "needs_merge", conflict.Error())
+	fix := fmt.Sprintf("Cherry-pick %s onto %s", conflict.Commit, epicBranch)
+	if conflict.Branch != "" {
+		fix = fmt.Sprintf("Run git rebase %s %s", epicBranch, conflict.Branch)
+	}
+	s.BlockTask(task.ID, fmt.Sprintf("needs merge: %v. %s and resolve it by hand, or answer to redo the task on the current epic branch",
+		conflict, fix))
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// conflictSetup leaves an epic branch and a worktree whose task commit
+// conflicts with it in shared.txt, and returns a pool for the epic and the
+// cherry-pick's conflict.
+func conflictSetup(t *testing.T, s *store.Store, task *store.Task, fixtures string) (*Pool, string, *git.ConflictError) {
+	t.Helper()
+	dir := initTestRepo(t)
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".hive/\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "shared.txt"), []byte("base\n"), 0644)
+	run(dir, "add", ".")
+	run(dir, "commit", "-m", "init")
+	run(dir, "checkout", "-b", "hive/epic-1")
+
+	wtPath := filepath.Join(dir, ".hive", "worktrees", "wt-1")
+	safety := git.New(dir)
+	if err := safety.AddDetachedWorktree(wtPath, "hive/epic-1"); err != nil {
+		t.Fatalf("AddDetachedWorktree: %v", err)
+	}
+	t.Cleanup(func() { safety.RemoveWorktree(wtPath) })
+
+	// Another task lands on the epic branch while this one works.
+	other, _ := s.CreateTask("Say hello", "Greets in shared.txt", "medium", nil)
+	os.WriteFile(filepath.Join(dir, "shared.txt"), []byte("hello\n"), 0644)
+	safety.CommitAll(fmt.Sprintf("hive: task #%d — %s", other.ID, other.Title))
+
+	os.WriteFile(filepath.Join(wtPath, "shared.txt"), []byte("goodbye\n"), 0644)
+	err := safety.MergeWorktreeChanges(wtPath, task.ID, task.Title)
+	var conflict *git.ConflictError
+	if !errors.As(err, &conflict) || conflict.Commit == "" || len(conflict.Files) != 1 {
+		t.Fatalf("expected a cherry-pick conflict, got %v", err)
+	}
+	if safety.HasUncommittedChanges() {
+		t.Fatal("expected the epic branch left clean after the conflict")
+	}
+
+	pool := NewPool(PoolConfig{
+		Store:      s,
+		WorkDir:    dir,
+		EpicBranch: "hive/epic-1",
+		MaxWorkers: 1,
+		CoderName:  "coder",
+		CoderCfg:   config.Agent{Role: "coder", Mode: "fake", Fixtures: fixtures},
+		ReviewName: "reviewer",
+		ReviewCfg:  config.Agent{Role: "reviewer", Mode: "fake", Fixtures: fixtures},
+		LogDir:     t.TempDir(),
+	})
+	return pool, wtPath, conflict
+}
+
+func TestResolveConflict(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Say goodbye", "", "medium", nil)
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "coder.md"), []byte("=== file: shared.txt\nhello\ngoodbye\n=== end\nResolved.\n"), 0644)
+
+	pool, wtPath, conflict := conflictSetup(t, s, task, fixtures)
+	var log []string
+	logf := func(format string, args ...any) { log = append(log, format) }
+	if err := pool.resolveConflict(context.Background(), task, wtPath, conflict, logf); err != nil {
+		t.Fatalf("resolveConflict: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(pool.workDir, "shared.txt"))
+	if string(data) != "hello\ngoodbye\n" {
+		t.Errorf("expected the resolution on the epic branch, got %q", data)
+	}
+	events, _ := s.GetEvents(task.ID)
+	if last := events[len(events)-1]; last.Type != "merge_resolved" {
+		t.Errorf("last event: %+v", last)
+	}
+}
+
+func TestResolveConflict_FallsBackToNeedsMerge(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Say goodbye", "", "medium", nil)
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "coder.md"), []byte("BLOCKED: keep hello or goodbye?\n"), 0644)
+
+	pool, wtPath, conflict := conflictSetup(t, s, task, fixtures)
+	err := pool.resolveConflict(context.Background(), task, wtPath, conflict, func(string, ...any) {})
+	if err == nil || !strings.Contains(err.Error(), "keep hello or goodbye") {
+		t.Fatalf("expected the coder's question, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(wtPath, "shared.txt")); git.HasConflictMarkers(string(data)) {
+		t.Error("expected the worktree's replay aborted")
+	}
+
+	MarkNeedsMerge(s, task, "hive/epic-1", conflict)
+	got, _ := s.GetTask(task.ID)
+	if got.Status != store.StatusBlocked || got.MergeCommit != conflict.Commit || !strings.Contains(got.BlockedReason, "needs merge") {
+		t.Errorf("task: status=%s merge_commit=%q reason=%q", got.Status, got.MergeCommit, got.BlockedReason)
+	}
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// EstimatePlan is what a pipeline run is set to do, for Estimate.
+type EstimatePlan struct {
+	Tasks     []store.Task
+	MaxLoops  int
+	Parallel  int
+	Architect bool // The architect researches each task first
+	Tester    bool // A tester runs once per task
+	Docs      bool // The docs agent runs once the tasks are done
+}
+
+// RoleEstimate is how many calls one role makes in a run, and what a
+// call of that role took on average before.
+type RoleEstimate struct {
+	Role        string
+	MinCalls    int
+	MaxCalls    int
+	AvgDuration float64 // Seconds; 0 without history
+	AvgCost     float64 // USD
+	Known       bool    // The role has calls in the history
+}
+
+// RunEstimate is the projected size of a pipeline run: from every task
+// passing review the first time (Min) to every task using all its fix
+// loops (Max). Roles without history count toward the calls but not the
+// duration or cost.
+type RunEstimate struct {
+	Tasks       int
+	Roles       []RoleEstimate
+	MinCalls    int
+	MaxCalls    int
+	MinDuration time.Duration // Wall time, with tasks spread over the workers
+	MaxDuration time.Duration
+	MinCost     float64
+	MaxCost     float64
+}
+
+// Unknown returns the roles with calls in the run but no history.
+func (e RunEstimate) Unknown() []string {
+	var unknown []string
+	for _, r := range e.Roles {
+		if !r.Known && r.MaxCalls > 0 {
+			unknown = append(unknown, r.Role)
+		}
+	}
+	return unknown
+}
+
+// Estimate projects a run from the average calls in stats (see
+// store.AgentCallStats). Only tasks that still have work are counted.
+func Estimate(plan EstimatePlan, stats map[string]store.CallStats) RunEstimate {
+	n := 0
+	architect := 0
+	for _, t := range plan.Tasks {
+		switch t.Status {
+		case store.StatusDone, store.StatusCancelled, store.StatusBlocked, store.StatusNeedsHuman:
+			continue
+		}
+		n++
+		if plan.Architect && NeedsArchitect(t) {
+			architect++
+		}
+	}
+	loops := plan.MaxLoops
+	if loops < 1 {
+		loops = 1
+	}
+
+	e := RunEstimate{Tasks: n}
+	add := func(role string, min, max int) {
+		if max == 0 {
+			return
+		}
+		st, ok := stats[role]
+		e.Roles = append(e.Roles, RoleEstimate{
+			Role: role, MinCalls: min, MaxCalls: max,
+			AvgDuration: st.AvgDuration, AvgCost: st.AvgCost, Known: ok,
+		})
+	}
+	add(roles.Architect, architect, architect)
+	add(roles.Coder, n, n*loops)
+	if plan.Tester {
+		add(roles.Tester, n, n)
+	}
+	add(roles.Reviewer, n, n*loops)
+	docs := 0
+	if plan.Docs && n > 0 {
+		docs = 1
+		add(roles.Docs, 1, 1)
+	}
+
+	workers := plan.Parallel
+	if workers > n {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	var minTask, maxTask, minDocs, maxDocs float64
+	for _, r := range e.Roles {
+		e.MinCalls += r.MinCalls
+		e.MaxCalls += r.MaxCalls
+		e.MinCost += float64(r.MinCalls) * r.AvgCost
+		e.MaxCost += float64(r.MaxCalls) * r.AvgCost
+		if r.Role == roles.Docs {
+			minDocs, maxDocs = float64(docs)*r.AvgDuration, float64(docs)*r.AvgDuration
+			continue
+		}
+		minTask += float64(r.MinCalls) * r.AvgDuration
+		maxTask += float64(r.MaxCalls) * r.AvgDuration
+	}
+	// Tasks run side by side on the workers; docs run alone at the end.
+	e.MinDuration = seconds(minTask/float64(workers) + minDocs)
+	e.MaxDuration = seconds(maxTask/float64(workers) + maxDocs)
+	return e
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestEstimate(t *testing.T) {
+	plan := EstimatePlan{
+		Tasks: []store.Task{
+			{ID: 1, Status: store.StatusBacklog},
+			{ID: 2, Status: store.StatusBacklog},
+			{ID: 3, Status: store.StatusDone},
+		},
+		MaxLoops: 3, Parallel: 2, Architect: true, Docs: true,
+	}
+	stats := map[string]store.CallStats{
+		"coder":    {Calls: 10, AvgDuration: 60, AvgCost: 0.10},
+		"reviewer": {Calls: 10, AvgDuration: 30, AvgCost: 0.05},
+		"docs":     {Calls: 1, AvgDuration: 20},
+	}
+
+	e := Estimate(plan, stats)
+	if e.Tasks != 2 {
+		t.Errorf("counted %d tasks, want the 2 not done", e.Tasks)
+	}
+	if e.MinCalls != 7 || e.MaxCalls != 15 {
+		t.Errorf("calls = %d..%d, want 7..15", e.MinCalls, e.MaxCalls)
+	}
+	if e.MinDuration != 110*time.Second || e.MaxDuration != 290*time.Second {
+		t.Errorf("duration = %s..%s, want 1m50s..4m50s", e.MinDuration, e.MaxDuration)
+	}
+	if math.Abs(e.MinCost-0.30) > 1e-9 || math.Abs(e.MaxCost-0.90) > 1e-9 {
+		t.Errorf("cost = %.2f..%.2f, want 0.30..0.90", e.MinCost, e.MaxCost)
+	}
+	if got := strings.Join(e.Unknown(), ","); got != "architect" {
+		t.Errorf("unknown roles = %q, want architect", got)
+	}
+}
+
+func TestEstimate_NothingToDo(t *testing.T) {
+	e := Estimate(EstimatePlan{Tasks: []store.Task{{Status: store.StatusDone}}, MaxLoops: 3, Docs: true}, nil)
+	if e.MaxCalls != 0 || e.MaxDuration != 0 || len(e.Roles) != 0 {
+		t.Errorf("expected an empty estimate, got %+v", e)
+	}
+}
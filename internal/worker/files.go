@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// FilesCheck is the result of cross-checking a coder's FILES_CHANGED list
+// against git.
+type FilesCheck struct {
+	Declared []string // Files the coder listed
+	Changed  []string // Files git shows as changed
+	Missing  []string // Declared but unchanged
+	Empty    bool     // Git repo with no changes at all
+}
+
+// CheckFilesChanged parses FILES_CHANGED from a coder's output, compares
+// it with the working tree in workDir and records the result for the
+// iteration. Files the coder claims but git doesn't show are reported in
+// a "files_mismatch" event. Outside a git repo only the declared list is
+// recorded, with Changed unknown (false).
+func CheckFilesChanged(s *store.Store, task *store.Task, iteration int, coderName, output, workDir string) FilesCheck {
+	fc := FilesCheck{Declared: agent.ParseFilesChanged(output)}
+
+	safety := git.New(workDir)
+	inRepo := safety.IsGitRepo()
+	if inRepo {
+		fc.Changed, _ = safety.ChangedFiles()
+		fc.Empty = len(fc.Changed) == 0
+	}
+
+	changed := make(map[string]bool, len(fc.Changed))
+	for _, f := range fc.Changed {
+		changed[f] = true
+	}
+	declared := make(map[string]bool, len(fc.Declared))
+	for _, f := range fc.Declared {
+		declared[f] = true
+		if inRepo && !changed[f] {
+			fc.Missing = append(fc.Missing, f)
+		}
+	}
+
+	paths := make(map[string]bool)
+	for f := range declared {
+		paths[f] = true
+	}
+	for f := range changed {
+		paths[f] = true
+	}
+	var files []store.TaskFile
+	for p := range paths {
+		files = append(files, store.TaskFile{Path: p, Declared: declared[p], Changed: changed[p]})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	s.RecordTaskFiles(task.ID, iteration, files)
+
+	if len(fc.Missing) > 0 {
+		s.AddEvent(task.ID, coderName, "files_mismatch",
+			fmt.Sprintf("Declared in FILES_CHANGED but unchanged in git: %s", strings.Join(fc.Missing, ", ")))
+	}
+	return fc
+}
+
+// EmptyDiffFeedback is the rejection recorded when a coder iteration left
+// the working tree unchanged.
+func (fc FilesCheck) EmptyDiffFeedback(iteration int) string {
+	msg := fmt.Sprintf("REJECTED (iter %d): no changes were made — git shows an empty diff.", iteration)
+	if len(fc.Declared) > 0 {
+		msg += fmt.Sprintf("\nFILES_CHANGED claimed %s, but none of them changed. Actually edit the files.", strings.Join(fc.Declared, ", "))
+	}
+	return msg
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+func testStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "test"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	return dir
+}
+
+func TestCheckFilesChanged_Mismatch(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	task, _ := s.CreateTask("Files", "", "high", nil)
+
+	os.WriteFile(filepath.Join(dir, "real.go"), []byte("package main\n"), 0644)
+	output := "Done.\nFILES_CHANGED:\n- real.go\n- claimed.go\n"
+
+	fc := CheckFilesChanged(s, task, 1, "coder", output, dir)
+	if fc.Empty {
+		t.Fatal("expected non-empty diff")
+	}
+	if len(fc.Missing) != 1 || fc.Missing[0] != "claimed.go" {
+		t.Fatalf("expected claimed.go missing, got %v", fc.Missing)
+	}
+
+	files, _ := s.GetTaskFiles(task.ID)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 recorded files, got %+v", files)
+	}
+
+	events, _ := s.GetEvents(task.ID)
+	found := false
+	for _, e := range events {
+		if e.Type == "files_mismatch" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected files_mismatch event")
+	}
+}
+
+func TestCheckFilesChanged_EmptyDiff(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	task, _ := s.CreateTask("Nothing", "", "high", nil)
+
+	fc := CheckFilesChanged(s, task, 1, "coder", "FILES_CHANGED:\n- main.go\n", dir)
+	if !fc.Empty {
+		t.Fatal("expected empty diff")
+	}
+	if msg := fc.EmptyDiffFeedback(1); msg == "" {
+		t.Error("expected feedback")
+	}
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// RecordHandoff saves what an approved task passes on to later tasks in
+// its epic: the HANDOFF notes from the coder's last output and the files
+// its last iteration changed. Standalone tasks have no one to hand off to
+// and are skipped.
+func RecordHandoff(s *store.Store, task *store.Task, coderOutput string) {
+	if task.ParentID == nil {
+		return
+	}
+
+	var changed, declared []string
+	files, _ := s.GetTaskFiles(task.ID)
+	for _, f := range files {
+		if f.Changed {
+			changed = append(changed, f.Path)
+		}
+		if f.Declared {
+			declared = append(declared, f.Path)
+		}
+	}
+	// Outside git only the coder's own list is known.
+	if len(changed) == 0 {
+		changed = declared
+	}
+
+	notes := agent.ParseHandoff(coderOutput)
+	if len(notes) == 0 && len(changed) == 0 {
+		return
+	}
+	s.SaveHandoff(task.ID, notes, changed)
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestRecordHandoff(t *testing.T) {
+	s := testStore(t)
+
+	epic, _ := s.CreateEpic("Epic", "", "high")
+	epicID := epic.ID
+	task, _ := s.CreateTask("Add login", "", "high", &epicID)
+	s.RecordTaskFiles(task.ID, 1, []store.TaskFile{
+		{Path: "auth.go", Declared: true, Changed: true},
+		{Path: "ghost.go", Declared: true},
+	})
+
+	RecordHandoff(s, task, "Done.\n\nHANDOFF:\n- Added auth.Login\n\nFILES_CHANGED:\n- auth.go\n")
+
+	got, _ := s.ListEpicHandoffs(epicID)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 handoff, got %+v", got)
+	}
+	if len(got[0].Notes) != 1 || got[0].Notes[0] != "Added auth.Login" {
+		t.Errorf("unexpected notes: %v", got[0].Notes)
+	}
+	if len(got[0].Files) != 1 || got[0].Files[0] != "auth.go" {
+		t.Errorf("expected only git-changed files, got %v", got[0].Files)
+	}
+}
+
+func TestRecordHandoff_StandaloneTask(t *testing.T) {
+	s := testStore(t)
+
+	task, _ := s.CreateTask("Loose task", "", "high", nil)
+	RecordHandoff(s, task, "HANDOFF:\n- something\n")
+
+	if got, _ := s.ListEpicHandoffs(0); len(got) != 0 {
+		t.Fatalf("expected no handoff for a task without epic, got %+v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+// pausedPrefix starts the blocker of an epic whose pipeline paused
+// because one of its tasks blocked (auto.pause_on_block).
+const pausedPrefix = "paused: "
+
+// PauseEpic blocks an epic because task, one of its tasks, is blocked or
+// needs a human, so the epic shows as waiting for an answer until it has
+// one.
+func PauseEpic(s *store.Store, epicID int64, task *store.Task) {
+	why := task.BlockedReason
+	if task.Status == store.StatusNeedsHuman || why == "" {
+		why = "needs a human"
+	}
+	s.BlockTask(epicID, fmt.Sprintf("%s#%d is blocked: %s", pausedPrefix, task.ID, why))
+}
+
+// IsPaused reports whether an epic is blocked by PauseEpic rather than a
+// question of its own.
+func IsPaused(epic *store.Task) bool {
+	return epic.Status == store.StatusBlocked && strings.HasPrefix(epic.BlockedReason, pausedPrefix)
+}
+
+// ResumeEpic unpauses the epic of a task that was just answered, once
+// none of its tasks is blocked or needs a human any more, and returns it
+// so the caller can run its pipeline again. While another task still
+// waits, the epic stays paused on that one and ResumeEpic returns nil.
+func ResumeEpic(s *store.Store, taskID int64) *store.Task {
+	task, err := s.GetTask(taskID)
+	if err != nil || task.ParentID == nil {
+		return nil
+	}
+	epic, err := s.GetTask(*task.ParentID)
+	if err != nil || !IsPaused(epic) {
+		return nil
+	}
+	tasks, _ := s.ListTasksByEpic(epic.ID)
+	for i := range tasks {
+		if tasks[i].Status == store.StatusBlocked || tasks[i].Status == store.StatusNeedsHuman {
+			PauseEpic(s, epic.ID, &tasks[i])
+			return nil
+		}
+	}
+	s.UnblockTask(epic.ID, fmt.Sprintf("#%d answered, pipeline resumed", taskID))
+	epic, _ = s.GetTask(epic.ID)
+	return epic
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestPauseAndResumeEpic(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Auth", "", "")
+	a, _ := s.CreateTask("Login form", "", "", &epic.ID)
+	b, _ := s.CreateTask("Sessions", "", "", &epic.ID)
+	s.BlockTask(a.ID, "Which session store?")
+	s.BlockTask(b.ID, "Cookie name?")
+
+	a, _ = s.GetTask(a.ID)
+	PauseEpic(s, epic.ID, a)
+	epic, _ = s.GetTask(epic.ID)
+	if !IsPaused(epic) || !strings.Contains(epic.BlockedReason, "Which session store?") {
+		t.Fatalf("epic not paused on #%d: %s %q", a.ID, epic.Status, epic.BlockedReason)
+	}
+
+	// Answering one task moves the pause on to the other.
+	s.UnblockTask(a.ID, "redis")
+	if got := ResumeEpic(s, a.ID); got != nil {
+		t.Fatal("resumed while #2 is still blocked")
+	}
+	epic, _ = s.GetTask(epic.ID)
+	if !IsPaused(epic) || !strings.Contains(epic.BlockedReason, "Cookie name?") {
+		t.Errorf("epic should now wait for #%d: %q", b.ID, epic.BlockedReason)
+	}
+
+	s.UnblockTask(b.ID, "sid")
+	got := ResumeEpic(s, b.ID)
+	if got == nil || got.Status == store.StatusBlocked {
+		t.Fatalf("epic not resumed: %+v", got)
+	}
+
+	// A blocker of the epic's own is not a pause.
+	s.BlockTask(epic.ID, "Which provider?")
+	s.BlockTask(a.ID, "Again?")
+	s.UnblockTask(a.ID, "yes")
+	if ResumeEpic(s, a.ID) != nil {
+		t.Error("resumed an epic blocked on its own question")
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/perf"
+)
+
+// RunPerfGate runs the configured benchmark command in workDir and compares
+// the results to the recorded baseline. It reports nothing when perf is not
+// configured or no baseline has been recorded yet.
+func RunPerfGate(cfg *config.Config, workDir string) ([]perf.Regression, error) {
+	if cfg.Perf.Cmd == "" {
+		return nil, nil
+	}
+
+	baseline, err := perf.LoadBaseline(cfg.Perf.BaselinePath())
+	if err != nil || baseline == nil {
+		return nil, err
+	}
+
+	out, err := perf.Run(cfg.Perf.Cmd, workDir, cfg.Perf.CmdTimeout())
+	if err != nil {
+		return nil, err
+	}
+	return perf.Compare(baseline, perf.Parse(out), cfg.Perf.TolerancePct()), nil
+}
+
+// PerfFindings formats regressions as HIGH review findings, one per line,
+// in the same shape reviewers use for COMMENTS.
+func PerfFindings(regs []perf.Regression) string {
+	var sb strings.Builder
+	for _, r := range regs {
+		sb.WriteString("- [HIGH] perf: " + r.String() + "\n")
+	}
+	return sb.String()
+}
@@ -5,27 +5,42 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
 	"github.com/imkarma/hive/internal/config"
 	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/disk"
 	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/langs"
+	"github.com/imkarma/hive/internal/redact"
+	"github.com/imkarma/hive/internal/roles"
 	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/tracing"
 )
 
 // TaskResult holds the outcome of a single task execution.
 type TaskResult struct {
 	TaskID   int64
 	Title    string
-	Status   string // "done", "blocked", "failed"
+	Status   string // "done", "blocked", "failed", or "skipped" (see PoolConfig.StopOnBlock)
 	Duration time.Duration
 	Error    error
 	Log      []string // Collected log messages.
+
+	// With git.task_branches: the task's branch, and the files that kept
+	// it from rebasing onto the epic branch, if any. A conflicted branch
+	// is kept for merging by hand.
+	Branch    string
+	Conflicts []string
 }
 
 // Pool manages parallel task execution.
@@ -40,9 +55,20 @@ type Pool struct {
 	coderCfg    config.Agent
 	reviewName  string
 	reviewCfg   config.Agent
-	useWorktree bool // Whether to use git worktrees for isolation.
+	archName    string
+	archCfg     config.Agent
+	worktrees   *WorktreePool // Worktrees for parallel tasks; nil without git or a branch
+	roles       *roles.Registry
+	arts        *artifacts.Manager
+	logDir      string
+	onLog       func(taskID int64, line string)
+	stream      bool
+	checkpoints *Checkpoints
+	stopOnBlock bool
+	stopped     atomic.Bool // A task blocked under stopOnBlock
 
 	mu      sync.Mutex
+	logMu   sync.Mutex
 	results []TaskResult
 }
 
@@ -52,21 +78,72 @@ type PoolConfig struct {
 	Config     *config.Config
 	WorkDir    string
 	EpicBranch string
+
+	// EpicID is the epic (or lone task) whose branch is being worked on.
+	// Its parallel tasks get worktrees of their own under
+	// .hive/worktrees/epic-<id>, so runs on other epics don't reset them.
+	EpicID int64
+
 	MaxWorkers int
 	MaxLoops   int
 	CoderName  string
 	CoderCfg   config.Agent
 	ReviewName string
 	ReviewCfg  config.Agent
+
+	// ArchitectName, if set, is the agent Architect runs on the tasks
+	// before they are coded.
+	ArchitectName string
+	ArchitectCfg  config.Agent
+
+	// SetupCmd prepares each new worktree before agents start, e.g.
+	// "npm ci": the epic's setup command or setup_cmd from the config.
+	SetupCmd string
+
+	// LogDir receives a task-N.log per task, written as the task runs
+	// (default: the runs directory under WorkDir).
+	LogDir string
+	// OnLog, if set, is called with every log line as it happens. Calls
+	// are serialized, so it can print directly.
+	OnLog func(taskID int64, line string)
+	// Stream adds the agents' output to the log line by line as they
+	// write it, instead of only their results.
+	Stream bool
+	// Checkpoints, if set, records where each task is in its fix loop,
+	// and continues tasks where an interrupted run left them.
+	Checkpoints *Checkpoints
+	// StopOnBlock starts no more tasks once one is blocked or needs a
+	// human (auto.pause_on_block). Tasks already running finish; the
+	// rest are "skipped" and left as they were.
+	StopOnBlock bool
 }
 
 // NewPool creates a new worker pool.
 func NewPool(pc PoolConfig) *Pool {
 	// Determine if we can use worktrees (need git repo and a branch).
-	useWorktree := false
+	var worktrees *WorktreePool
 	if pc.EpicBranch != "" {
 		safety := git.New(pc.WorkDir)
-		useWorktree = safety.IsGitRepo()
+		if safety.IsGitRepo() {
+			dir := filepath.Join(pc.WorkDir, ".hive", "worktrees")
+			if pc.EpicID != 0 {
+				dir = filepath.Join(dir, fmt.Sprintf("epic-%d", pc.EpicID))
+			}
+			worktrees = NewWorktreePool(safety, dir, pc.EpicBranch, pc.SetupCmd)
+		}
+	}
+
+	reg := roles.Builtin()
+	if pc.Config != nil {
+		reg = roles.NewRegistry(pc.Config.Roles)
+	}
+
+	// Artifacts always go to the project's .hive, even for tasks running
+	// in a worktree.
+	arts := artifacts.New(pc.Store, pc.WorkDir)
+	logDir := pc.LogDir
+	if logDir == "" {
+		logDir = arts.Dir()
 	}
 
 	return &Pool{
@@ -80,7 +157,63 @@ func NewPool(pc PoolConfig) *Pool {
 		coderCfg:    pc.CoderCfg,
 		reviewName:  pc.ReviewName,
 		reviewCfg:   pc.ReviewCfg,
-		useWorktree: useWorktree,
+		archName:    pc.ArchitectName,
+		archCfg:     pc.ArchitectCfg,
+		worktrees:   worktrees,
+		roles:       reg,
+		arts:        arts,
+		logDir:      logDir,
+		onLog:       pc.OnLog,
+		stream:      pc.Stream,
+		checkpoints: pc.Checkpoints,
+		stopOnBlock: pc.StopOnBlock,
+	}
+}
+
+// noteBlocked stops the pool from starting tasks after r, if it blocked
+// and the pool stops on blocks.
+func (p *Pool) noteBlocked(r TaskResult) {
+	if p.stopOnBlock && r.Status == "blocked" {
+		p.stopped.Store(true)
+	}
+}
+
+// skipped is the result of a task the pool didn't start after a block.
+func skipped(t store.Task) TaskResult {
+	return TaskResult{TaskID: t.ID, Title: t.Title, Status: "skipped", Log: []string{"Not started: an earlier task blocked"}}
+}
+
+// taskBranches reports whether parallel tasks get branches of their own.
+func (p *Pool) taskBranches() bool {
+	return p.cfg != nil && p.cfg.Git.TaskBranches
+}
+
+// LogPath returns the live log file for a task.
+func (p *Pool) LogPath(taskID int64) string {
+	return filepath.Join(p.logDir, fmt.Sprintf("task-%d.log", taskID))
+}
+
+// agentStream returns the Request.Stream that logs an agent's output
+// for a task, or nil when the pool doesn't stream.
+func (p *Pool) agentStream(taskID int64) func(string) {
+	if !p.stream {
+		return nil
+	}
+	return func(line string) { p.emit(taskID, "  │ "+line) }
+}
+
+// emit appends a line to the task's log file and passes it to OnLog.
+func (p *Pool) emit(taskID int64, line string) {
+	line = redact.String(line)
+	p.logMu.Lock()
+	defer p.logMu.Unlock()
+
+	if f, err := os.OpenFile(p.LogPath(taskID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+		fmt.Fprintf(f, "%s %s\n", time.Now().Format("15:04:05"), line)
+		f.Close()
+	}
+	if p.onLog != nil {
+		p.onLog(taskID, line)
 	}
 }
 
@@ -98,7 +231,12 @@ func (p *Pool) Run(tasks []store.Task) []TaskResult {
 func (p *Pool) runSequential(tasks []store.Task) []TaskResult {
 	var results []TaskResult
 	for _, task := range tasks {
-		r := p.executeTask(task, p.workDir, false)
+		if p.stopped.Load() {
+			results = append(results, skipped(task))
+			continue
+		}
+		r := p.executeTask(task, p.workDir, nil)
+		p.noteBlocked(r)
 		results = append(results, r)
 	}
 	return results
@@ -122,6 +260,16 @@ func (p *Pool) runParallel(tasks []store.Task) []TaskResult {
 			}
 			continue
 		}
+		if task.Status == store.StatusNeedsHuman {
+			results[i] = TaskResult{
+				TaskID: task.ID,
+				Title:  task.Title,
+				Status: "blocked",
+				Log:    []string{"Needs a human: answer it with guidance to retry"},
+			}
+			p.noteBlocked(results[i])
+			continue
+		}
 		if task.Status == store.StatusBlocked {
 			results[i] = TaskResult{
 				TaskID: task.ID,
@@ -129,6 +277,7 @@ func (p *Pool) runParallel(tasks []store.Task) []TaskResult {
 				Status: "blocked",
 				Log:    []string{fmt.Sprintf("Blocked: %s", task.BlockedReason)},
 			}
+			p.noteBlocked(results[i])
 			continue
 		}
 		if task.AssignedAgent == "" {
@@ -141,71 +290,160 @@ func (p *Pool) runParallel(tasks []store.Task) []TaskResult {
 			continue
 		}
 
-		wg.Add(1)
 		sem <- struct{}{} // Acquire worker slot.
+		// Waiting for the slot is when a running task may have blocked.
+		if p.stopped.Load() {
+			<-sem
+			results[i] = skipped(task)
+			continue
+		}
+		wg.Add(1)
 
 		go func(idx int, t store.Task) {
 			defer wg.Done()
 			defer func() { <-sem }() // Release worker slot.
 
-			var taskWorkDir string
-			var usingWorktree bool
-
-			if p.useWorktree && p.coderCfg.Mode == "cli" {
-				// Create a worktree for this task.
-				wtPath := git.WorktreePath(p.workDir, t.ID)
-				safety := git.New(p.workDir)
-
-				if err := safety.AddWorktree(wtPath, p.epicBranch); err == nil {
-					taskWorkDir = wtPath
-					usingWorktree = true
-					defer func() {
-						safety.RemoveWorktree(wtPath)
-						os.RemoveAll(wtPath)
-						safety.PruneWorktrees()
-					}()
-				} else {
-					// Fall back to main workdir.
-					taskWorkDir = p.workDir
+			ctx, span := tracing.StartTask(context.Background(), t.ID, t.Title)
+			defer span.End()
+
+			taskWorkDir := p.workDir
+			var wt *Worktree
+			branch := ""
+			if _, coderCfg := p.coderFor(&t); p.worktrees != nil && coderCfg.Mode != "api" {
+				acquired, err := p.worktrees.Acquire()
+				switch {
+				case err == nil:
+					wt = acquired
+					taskWorkDir = wt.Path
+					defer p.worktrees.Release(wt)
+					if p.taskBranches() {
+						branch = git.TaskBranchName(p.epicBranch, t.ID)
+						if err := git.New(wt.Path).StartTaskBranch(branch); err != nil {
+							p.emit(t.ID, fmt.Sprintf("branch: %v; working detached", err))
+							branch = ""
+						}
+					}
+				case errors.Is(err, disk.ErrLowSpace):
+					// Sharing the main directory with other tasks is no way out.
+					p.emit(t.ID, err.Error())
+					FailTask(p.store, t.ID, "hive", ConfigProblem, err.Error())
+					results[idx] = TaskResult{TaskID: t.ID, Title: t.Title, Status: "failed", Log: []string{err.Error()}, Error: err}
+					return
+				default:
+					// Fall back to the main workdir.
+					p.emit(t.ID, fmt.Sprintf("worktree: %v; working in the main directory", err))
 				}
-			} else {
-				taskWorkDir = p.workDir
 			}
 
-			r := p.executeTask(t, taskWorkDir, usingWorktree)
+			r := p.executeTask(t, taskWorkDir, wt)
+			span.SetAttr("hive.task.status", r.Status)
+			if r.Status == "failed" {
+				span.Fail("task failed")
+			}
 
 			// If using worktree, merge changes back.
-			if usingWorktree && r.Status == "done" {
-				safety := git.New(p.workDir)
+			if wt != nil && r.Status == "done" {
+				safety := git.New(p.workDir).WithContext(ctx)
+				logf := func(format string, args ...any) {
+					line := fmt.Sprintf(format, args...)
+					r.Log = append(r.Log, line)
+					p.emit(t.ID, line)
+				}
+				var conflict *git.ConflictError
 				p.mu.Lock()
-				err := safety.MergeWorktreeChanges(taskWorkDir, t.ID, t.Title)
+				var err error
+				if branch != "" {
+					r.Branch = branch
+					err = safety.MergeTaskBranch(taskWorkDir, branch, p.epicBranch, t.ID, t.Title)
+				} else {
+					err = safety.MergeWorktreeChanges(taskWorkDir, t.ID, t.Title)
+					if errors.As(err, &conflict) {
+						if resolveErr := p.resolveConflict(ctx, &t, taskWorkDir, conflict, logf); resolveErr != nil {
+							logf("could not resolve: %v", resolveErr)
+						} else {
+							err = nil
+						}
+					}
+				}
 				p.mu.Unlock()
-				if err != nil {
-					r.Log = append(r.Log, fmt.Sprintf("merge failed: %v", err))
+				line := "merged into epic branch"
+				switch {
+				case errors.As(err, &conflict) && conflict.Branch != "":
+					r.Status, r.Conflicts = "blocked", conflict.Files
+					MarkNeedsMerge(p.store, &t, p.epicBranch, conflict)
+					line = fmt.Sprintf("merge conflict: %v; branch %s kept, task needs merging", err, branch)
+				case conflict != nil && err != nil:
+					r.Status, r.Conflicts = "blocked", conflict.Files
+					MarkNeedsMerge(p.store, &t, p.epicBranch, conflict)
+					line = fmt.Sprintf("merge conflict: %v; task needs merging", conflict)
+				case err != nil:
+					line = fmt.Sprintf("merge failed: %v", err)
 					// Don't change status — code was written, merge just failed.
-				} else {
-					r.Log = append(r.Log, "merged into epic branch")
+				case t.MergeCommit != "":
+					p.store.SetMergeCommit(t.ID, "")
 				}
+				logf("%s", line)
 			}
 
 			results[idx] = r
+			p.noteBlocked(r)
 		}(i, task)
 	}
 
 	wg.Wait()
+	if p.worktrees != nil {
+		p.worktrees.Close(p.cfg != nil && p.cfg.Git.KeepWorktrees)
+	}
 	return results
 }
 
-// executeTask runs the fix loop for a single task.
-func (p *Pool) executeTask(task store.Task, workDir string, isolated bool) TaskResult {
+// executeTask runs the fix loop for a single task, in wt when it has a
+// worktree of its own.
+func (p *Pool) executeTask(task store.Task, workDir string, wt *Worktree) TaskResult {
+	isolated := wt != nil
 	start := time.Now()
 	var log []string
 
+	os.MkdirAll(p.logDir, 0755)
+	p.emit(task.ID, fmt.Sprintf("=== #%d %s ===", task.ID, task.Title))
 	logf := func(format string, args ...any) {
-		log = append(log, fmt.Sprintf(format, args...))
+		line := fmt.Sprintf(format, args...)
+		log = append(log, line)
+		p.emit(task.ID, line)
+	}
+
+	// A fresh worktree has no installed dependencies; a reused one keeps
+	// what setup installed.
+	if isolated && p.worktrees.NeedsSetup(wt) {
+		setup := p.worktrees.setupCmd
+		logf("setup: %s", setup)
+		ok, output, leftovers := RunSetup(setup, workDir)
+		if !ok {
+			logf("setup failed:\n%s", output)
+			p.store.AddEvent(task.ID, "setup", "comment", fmt.Sprintf("Worktree setup failed (%s):\n%s", setup, output))
+			FailTask(p.store, task.ID, "setup", ConfigProblem, fmt.Sprintf("setup_cmd failed: %s", setup))
+			return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log, Error: fmt.Errorf("setup_cmd failed")}
+		}
+		p.worktrees.SetupDone(wt)
+		if len(leftovers) > 0 {
+			logf("setup: ⚠ %d file(s) it created aren't ignored by git and would be committed with the task, e.g. %s", len(leftovers), leftovers[0])
+		}
 	}
 
-	ctxBuilder := agentctx.New(p.store)
+	coderName, coderCfg := p.coderFor(&task)
+	ctxBuilder := agentctx.New(p.store).WithRoles(p.roles).WithWorkDir(workDir)
+	var checklist []config.ChecklistItem
+	if p.cfg != nil {
+		checklist = p.cfg.Review.Checklist
+		ctxBuilder.WithChecklist(p.cfg.Review.Items())
+		if p.cfg.Review.Blind {
+			ctxBuilder.WithBlind(p.cfg.AgentRoles())
+		}
+		if p.cfg.History.InlineArtifacts {
+			ctxBuilder.WithInlineOutputs(p.arts, p.cfg.History.InlineBudget())
+		}
+		ctxBuilder.WithLanguageHints(langs.Resolve(workDir, p.cfg.Languages, p.cfg.LanguageHints))
+	}
 
 	// No reviewer — just run coder once.
 	if p.reviewName == "" {
@@ -219,50 +457,86 @@ func (p *Pool) executeTask(task store.Task, workDir string, isolated bool) TaskR
 		}
 	}
 
-	coderRunner, err := agent.NewRunner(p.coderName, p.coderCfg)
+	coderRunner, err := NewRunner(coderName, coderCfg)
 	if err != nil {
+		FailTask(p.store, task.ID, "hive", ConfigProblem, fmt.Sprintf("could not create coder %s: %v", coderName, err))
 		logf("failed to create coder: %v", err)
 		return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log, Error: err}
 	}
 
-	reviewerRunner, err := agent.NewRunner(p.reviewName, p.reviewCfg)
+	reviewerRunner, err := NewRunner(p.reviewName, p.reviewCfg)
 	if err != nil {
+		FailTask(p.store, task.ID, "hive", ConfigProblem, fmt.Sprintf("could not create reviewer %s: %v", p.reviewName, err))
 		logf("failed to create reviewer: %v", err)
 		return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log, Error: err}
 	}
 
-	for iteration := 1; iteration <= p.maxLoops; iteration++ {
+	var applied *agent.Response // A reviewer patch standing in for the next coder run
+	var rejections RejectionTracker
+	var diffGuard DiffGuard
+
+	// A worktree starts from the epic branch, so a resumed task redoes the
+	// iteration it crashed in rather than reusing the coder's output.
+	first, lastReview := 1, ""
+	if rp, ok := p.checkpoints.Resume(task.ID, false); ok {
+		first = rp.Iteration
+		logf("resuming at iteration %d", first)
+	}
+	defer p.checkpoints.Save(task.ID, 0, store.PhaseDone, "", "")
+	for iteration := first; iteration <= p.maxLoops; iteration++ {
+		if iteration > first {
+			if d := BackoffDelay(p.cfg, iteration, true); d > 0 {
+				logf("waiting %s before iteration %d (backoff)", d.Round(time.Second), iteration)
+				WaitBackoff(d, 10*time.Second, func(left time.Duration) {
+					if left < d.Round(time.Second) {
+						logf("  %s left", left)
+					}
+				})
+			}
+		}
+
 		// Re-fetch task for latest context.
 		task2, _ := p.store.GetTask(task.ID)
 		if task2 != nil {
 			task = *task2
 		}
 
+		// === TESTER (before_code) ===
+		if iteration == 1 && p.cfg != nil && p.cfg.Testing.TesterStage() == string(roles.StageBeforeCode) {
+			if RunTester(p.store, p.arts, p.cfg, p.roles, &task, workDir, !isolated, logf).Status == StageBlocked {
+				return TaskResult{TaskID: task.ID, Title: task.Title, Status: "blocked", Duration: time.Since(start), Log: log}
+			}
+		}
+
 		// === CODER ===
 		p.store.UpdateTaskStatus(task.ID, store.StatusInProgress)
-		logf("[%d/%d] %s coding...", iteration, p.maxLoops, p.coderName)
-
-		coderPrompt, _ := ctxBuilder.BuildPrompt(&task, "coder")
-		coderResp, err := coderRunner.Run(context.Background(), agent.Request{
-			TaskID: task.ID, Prompt: coderPrompt, WorkDir: workDir, TimeoutSec: p.coderCfg.DefaultTimeout(),
-		})
-		if err != nil {
-			p.store.UpdateTaskStatus(task.ID, store.StatusFailed)
-			logf("coder error: %v", err)
-			return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log, Error: err}
+		p.checkpoints.Save(task.ID, iteration, store.PhaseCode, "", lastReview)
+		coderResp := applied
+		if applied != nil {
+			applied = nil
+			logf("[%d/%d] reviewer patch applied, skipping coder", iteration, p.maxLoops)
+		} else {
+			logf("[%d/%d] %s coding...", iteration, p.maxLoops, coderName)
+
+			coderPrompt, _ := ctxBuilder.BuildPrompt(&task, roles.Coder)
+			coderMsgs, _ := ctxBuilder.BuildMessages(&task, roles.Coder)
+			coderResp, err = coderRunner.Run(context.Background(), agent.Request{
+				TaskID: task.ID, Prompt: coderPrompt, Messages: coderMsgs, WorkDir: workDir, TimeoutSec: coderCfg.DefaultTimeout(),
+				Stream: p.agentStream(task.ID),
+			})
+			if err != nil {
+				FailRun(p.store, task.ID, coderName, coderResp, err)
+				logf("coder error: %v", err)
+				return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log, Error: err}
+			}
 		}
 
 		// Save artifact.
-		artifactPath := fmt.Sprintf(".hive/runs/task-%d-parallel-code-iter%d.md", task.ID, iteration)
-		os.MkdirAll(".hive/runs", 0755)
-		os.WriteFile(artifactPath, []byte(coderResp.Output), 0644)
-		p.store.AddArtifact(task.ID, "code", artifactPath)
-
-		preview := coderResp.Output
-		if len(preview) > 200 {
-			preview = preview[:200] + "..."
-		}
-		p.store.AddEvent(task.ID, p.coderName, "agent_output", preview)
+		codeName := artifacts.Name(task.ID, "parallel-code", fmt.Sprintf("iter%d", iteration))
+		p.arts.Save(task.ID, "code", codeName, coderResp.Output)
+		p.checkpoints.Save(task.ID, iteration, store.PhaseReview, codeName, lastReview)
+
+		p.store.AddEvent(task.ID, coderName, "agent_output", p.history().Preview(coderResp.Output))
 
 		logf("  %.1fs", coderResp.Duration)
 
@@ -274,50 +548,152 @@ func (p *Pool) executeTask(task store.Task, workDir string, isolated bool) TaskR
 		}
 
 		if coderResp.ExitCode != 0 {
-			p.store.UpdateTaskStatus(task.ID, store.StatusFailed)
+			FailRun(p.store, task.ID, coderName, coderResp, nil)
 			logf("  exit code %d", coderResp.ExitCode)
 			return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log}
 		}
 
-		// === REVIEWER ===
-		p.store.UpdateTaskStatus(task.ID, store.StatusReview)
-		logf("  %s reviewing...", p.reviewName)
-
-		reviewPrompt, _ := ctxBuilder.BuildReviewPrompt(&task)
-		reviewResp, err := reviewerRunner.Run(context.Background(), agent.Request{
-			TaskID: task.ID, Prompt: reviewPrompt, WorkDir: workDir, TimeoutSec: p.reviewCfg.DefaultTimeout(),
-		})
-		if err != nil {
-			logf("  reviewer error: %v", err)
+		// === FILES CHANGED (cross-check with git) ===
+		if fc := CheckFilesChanged(p.store, &task, iteration, coderName, coderResp.Output, workDir); fc.Empty {
+			p.store.UpdateTaskStatus(task.ID, store.StatusBacklog)
+			p.store.AddEvent(task.ID, "git", "reviewed", fc.EmptyDiffFeedback(iteration))
+			logf("  empty diff, back to coder")
 			continue
+		} else if len(fc.Missing) > 0 {
+			logf("  %d declared file(s) unchanged: %s", len(fc.Missing), strings.Join(fc.Missing, ", "))
+		}
+		if r := diffGuard.Check(p.store, &task, iteration, workDir); r != nil {
+			logf("  undid %d of %d changed line(s) from iteration %d", r.Undone, r.Changed, r.Previous)
 		}
 
-		// Save artifact.
-		reviewPath := fmt.Sprintf(".hive/runs/task-%d-parallel-review-iter%d.md", task.ID, iteration)
-		os.WriteFile(reviewPath, []byte(reviewResp.Output), 0644)
-		p.store.AddArtifact(task.ID, "review", reviewPath)
+		// === TESTER (after_code) + test gate ===
+		if p.cfg != nil {
+			if iteration == 1 && p.cfg.Testing.TesterStage() == string(roles.StageAfterCode) {
+				if RunTester(p.store, p.arts, p.cfg, p.roles, &task, workDir, !isolated, logf).Status == StageBlocked {
+					return TaskResult{TaskID: task.ID, Title: task.Title, Status: "blocked", Duration: time.Since(start), Log: log}
+				}
+			}
+			if f := RunTestGate(p.cfg, workDir); f != nil {
+				p.store.UpdateTaskStatus(task.ID, store.StatusBacklog)
+				p.store.AddEvent(task.ID, f.Step, "reviewed", f.Feedback(iteration))
+				logf("  %s failed: %s", f.Step, f.Cmd)
+				continue
+			}
 
-		review := agent.ParseReview(reviewResp.Output)
+			// === PERF GATE ===
+			regs, err := RunPerfGate(p.cfg, workDir)
+			if err != nil {
+				logf("  perf: %v", err)
+			} else if len(regs) > 0 && p.cfg.Perf.FlagOnly() {
+				p.store.AddEvent(task.ID, "perf", "perf_regression", "Benchmarks regressed:\n"+PerfFindings(regs))
+				logf("  perf: %d regression(s) flagged to reviewer", len(regs))
+			} else if len(regs) > 0 {
+				p.store.UpdateTaskStatus(task.ID, store.StatusBacklog)
+				p.store.AddEvent(task.ID, "perf", "reviewed",
+					fmt.Sprintf("REJECTED (iter %d): benchmarks regressed\n%s", iteration, PerfFindings(regs)))
+				logf("  perf: %d regression(s), back to coder", len(regs))
+				continue
+			}
+		}
+
+		// === CUSTOM ROLES (after_code) ===
+		if p.cfg != nil {
+			stage := RunRoleStage(p.store, p.arts, p.cfg, p.roles, roles.StageAfterCode, &task, workDir, logf)
+			switch stage.Status {
+			case StageBlocked:
+				return TaskResult{TaskID: task.ID, Title: task.Title, Status: "blocked", Duration: time.Since(start), Log: log}
+			case StageRejected:
+				p.store.UpdateTaskStatus(task.ID, store.StatusBacklog)
+				continue
+			}
+		}
+
+		// === REVIEWER ===
+		p.store.UpdateTaskStatus(task.ID, store.StatusReview)
+
+		// A small docs, test or comment-only change is approved without a
+		// review call (review.skip_trivial).
+		var reviewResp *agent.Response
+		var review agent.ParsedReview
+		verdictBy := p.reviewName
+		if reason, ok := SkipReview(p.store, p.cfg, &task, workDir); ok {
+			logf("  review skipped: %s", reason)
+			verdictBy, review = "hive", agent.ParsedReview{Verdict: "APPROVE"}
+			reviewResp = &agent.Response{Output: "Review skipped: " + reason}
+		} else {
+			logf("  %s reviewing...", p.reviewName)
+
+			reviewPrompt, _ := ctxBuilder.BuildReviewPrompt(&task)
+			reviewMsgs, _ := ctxBuilder.BuildReviewMessages(&task)
+			if chunks := ctxBuilder.ReviewChunks(&task); len(chunks) > 1 {
+				// Too large for one prompt: review it part by part.
+				reviewResp, err = ChunkedReview(reviewerRunner, p.arts, ctxBuilder, &task, chunks, agent.Request{
+					TaskID: task.ID, WorkDir: workDir, TimeoutSec: p.reviewCfg.DefaultTimeout(), Stream: p.agentStream(task.ID),
+					Format: agent.FormatReview,
+				}, checklist, fmt.Sprintf("parallel-review-iter%d", iteration), logf)
+			} else {
+				reviewResp, err = reviewerRunner.Run(context.Background(), agent.Request{
+					TaskID: task.ID, Prompt: reviewPrompt, Messages: reviewMsgs, WorkDir: workDir, TimeoutSec: p.reviewCfg.DefaultTimeout(),
+					Stream: p.agentStream(task.ID), Format: agent.FormatReview,
+				})
+			}
+			if err != nil {
+				logf("  reviewer error: %v", err)
+				continue
+			}
+
+			// Save artifact.
+			lastReview = artifacts.Name(task.ID, "parallel-review", fmt.Sprintf("iter%d", iteration))
+			p.arts.Save(task.ID, "review", lastReview, reviewResp.Output)
+
+			review = agent.CheckAcceptance(agent.ParseReviewWithChecklist(reviewResp.Output, checklist), reviewResp.Output, task.Acceptance)
+			SaveSuggestions(p.arts, task.ID, review, fmt.Sprintf("parallel-iter%d", iteration))
+
+			// An unclear or reversed verdict goes to a second reviewer, whose
+			// verdict stands.
+			if reason := TiebreakReason(p.store, task.ID, review); reason != "" {
+				req := agent.Request{TaskID: task.ID, Prompt: reviewPrompt, Messages: reviewMsgs, WorkDir: workDir, Format: agent.FormatReview}
+				if name, second, out, ok := Tiebreak(p.store, p.arts, p.cfg, &task, p.reviewName, review, req, reason, fmt.Sprintf("parallel-iter%d", iteration), logf); ok {
+					resp := *reviewResp
+					resp.Output = out
+					verdictBy, review, reviewResp = name, second, &resp
+				}
+			}
+		}
+
+		repeated := rejections.Add(iteration, review, reviewResp.Output)
 
 		switch review.Verdict {
 		case "APPROVE":
-			p.store.AddReview(task.ID, p.reviewName, "approve", reviewResp.Output)
-			p.store.UpdateTaskStatus(task.ID, store.StatusDone)
+			p.store.AddReview(task.ID, verdictBy, "approve", reviewResp.Output)
 			logf("  APPROVED (%.1fs)", reviewResp.Duration)
 
+			// === CUSTOM ROLES (after_review) ===
+			if p.cfg != nil {
+				stage := RunRoleStage(p.store, p.arts, p.cfg, p.roles, roles.StageAfterReview, &task, workDir, logf)
+				if stage.Status == StageBlocked {
+					return TaskResult{TaskID: task.ID, Title: task.Title, Status: "blocked", Duration: time.Since(start), Log: log}
+				}
+			}
+			p.store.UpdateTaskStatus(task.ID, store.StatusDone)
+			RecordHandoff(p.store, &task, coderResp.Output)
+
 			// If not isolated, commit in-place.
 			if !isolated {
-				safety := git.New(workDir)
+				safety := git.New(workDir).WithContext(tracing.TaskContext(context.Background(), task.ID))
 				if safety.IsGitRepo() {
 					msg := fmt.Sprintf("hive: task #%d — %s", task.ID, task.Title)
 					safety.CommitAll(msg)
+					for _, sub := range safety.ModifiedSubmodules() {
+						logf("  submodule %s changed — not committed", sub)
+					}
 				}
 			}
 
 			return TaskResult{TaskID: task.ID, Title: task.Title, Status: "done", Duration: time.Since(start), Log: log}
 
 		case "REJECT":
-			p.store.AddReview(task.ID, p.reviewName, "reject", reviewResp.Output)
+			p.store.AddReview(task.ID, verdictBy, "reject", reviewResp.Output)
 			p.store.UpdateTaskStatus(task.ID, store.StatusBacklog)
 			logf("  REJECTED (%.1fs)", reviewResp.Duration)
 			for _, c := range review.Comments {
@@ -327,37 +703,49 @@ func (p *Pool) executeTask(task store.Task, workDir string, isolated bool) TaskR
 			for _, c := range review.Comments {
 				comments.WriteString("- " + c + "\n")
 			}
-			p.store.AddEvent(task.ID, p.reviewName, "reviewed",
+			p.store.AddEvent(task.ID, verdictBy, "reviewed",
 				fmt.Sprintf("REJECTED (iter %d):\n%s", iteration, comments.String()))
+			if repeated {
+				rejections.MarkNeedsHuman(p.store, &task, verdictBy)
+				logf("  same objections as the last review, stopping: needs a human")
+				return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log}
+			}
+			if applied = ApplySuggestions(p.store, p.cfg, &task, verdictBy, review, workDir); applied != nil {
+				logf("  applying the reviewer's patch, skipping coder")
+			}
 
 		default:
 			logf("  no verdict (%.1fs)", reviewResp.Duration)
-			p.store.AddEvent(task.ID, p.reviewName, "reviewed", "No clear verdict")
+			p.store.AddEvent(task.ID, verdictBy, "reviewed", "No clear verdict")
 		}
 	}
 
-	p.store.UpdateTaskStatus(task.ID, store.StatusFailed)
-	logf("max iterations reached")
+	MarkMaxLoops(p.store, task.ID, p.maxLoops)
+	logf("max iterations reached: needs a human")
 	return TaskResult{TaskID: task.ID, Title: task.Title, Status: "failed", Duration: time.Since(start), Log: log}
 }
 
 // runCoder runs coder agent once without review.
 func (p *Pool) runCoder(ctxBuilder *agentctx.Builder, task *store.Task, workDir string, logf func(string, ...any)) string {
-	runner, err := agent.NewRunner(p.coderName, p.coderCfg)
+	coderName, coderCfg := p.coderFor(task)
+	runner, err := NewRunner(coderName, coderCfg)
 	if err != nil {
+		FailTask(p.store, task.ID, "hive", ConfigProblem, fmt.Sprintf("could not create coder %s: %v", coderName, err))
 		logf("failed to create coder: %v", err)
 		return "failed"
 	}
 
 	p.store.UpdateTaskStatus(task.ID, store.StatusInProgress)
-	logf("%s coding...", p.coderName)
+	logf("%s coding...", coderName)
 
-	prompt, _ := ctxBuilder.BuildPrompt(task, "coder")
+	prompt, _ := ctxBuilder.BuildPrompt(task, roles.Coder)
+	msgs, _ := ctxBuilder.BuildMessages(task, roles.Coder)
 	resp, err := runner.Run(context.Background(), agent.Request{
-		TaskID: task.ID, Prompt: prompt, WorkDir: workDir, TimeoutSec: p.coderCfg.DefaultTimeout(),
+		TaskID: task.ID, Prompt: prompt, Messages: msgs, WorkDir: workDir, TimeoutSec: coderCfg.DefaultTimeout(),
+		Stream: p.agentStream(task.ID),
 	})
 	if err != nil {
-		p.store.UpdateTaskStatus(task.ID, store.StatusFailed)
+		FailRun(p.store, task.ID, coderName, resp, err)
 		logf("error: %v", err)
 		return "failed"
 	}
@@ -369,7 +757,7 @@ func (p *Pool) runCoder(ctxBuilder *agentctx.Builder, task *store.Task, workDir
 	}
 
 	if resp.ExitCode != 0 {
-		p.store.UpdateTaskStatus(task.ID, store.StatusFailed)
+		FailRun(p.store, task.ID, coderName, resp, nil)
 		logf("exit code %d", resp.ExitCode)
 		return "failed"
 	}
@@ -378,3 +766,28 @@ func (p *Pool) runCoder(ctxBuilder *agentctx.Builder, task *store.Task, workDir
 	logf("done (%.1fs)", resp.Duration)
 	return "done"
 }
+
+// history returns the history settings; the defaults without a config.
+func (p *Pool) history() config.History {
+	if p.cfg == nil {
+		return config.History{}
+	}
+	return p.cfg.History
+}
+
+// coderFor returns the coder for a task: the agent it is assigned to when
+// that is another coder (e.g. one an owners route picked), else the pool's.
+func (p *Pool) coderFor(task *store.Task) (string, config.Agent) {
+	if p.cfg == nil || task.AssignedAgent == "" || task.AssignedAgent == p.coderName {
+		return p.coderName, p.coderCfg
+	}
+	a, ok := p.cfg.Agents[task.AssignedAgent]
+	if !ok || a.Role != roles.Coder {
+		return p.coderName, p.coderCfg
+	}
+	// Like the pool's own coder, it runs unattended.
+	if a.Mode == "cli" {
+		a.AutoAccept = true
+	}
+	return task.AssignedAgent, a
+}
@@ -1,9 +1,17 @@
 package worker
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/imkarma/hive/internal/chaos"
+	"github.com/imkarma/hive/internal/config"
 	"github.com/imkarma/hive/internal/store"
 )
 
@@ -83,3 +91,207 @@ func TestPool_RunParallel_SkipsDoneAndBlocked(t *testing.T) {
 		t.Errorf("task 3: expected failed (no agent), got %s", results[2].Status)
 	}
 }
+
+func TestPool_WritesTaskLogAndStreams(t *testing.T) {
+	var streamed []string
+	pool := NewPool(PoolConfig{
+		Store:      testStore(t),
+		WorkDir:    t.TempDir(),
+		MaxWorkers: 2,
+		MaxLoops:   1,
+		CoderName:  "broken",
+		ReviewName: "reviewer",
+		LogDir:     t.TempDir(),
+		OnLog: func(taskID int64, line string) {
+			streamed = append(streamed, line)
+		},
+	})
+
+	r := pool.executeTask(store.Task{ID: 7, Title: "Log me"}, t.TempDir(), nil)
+	if r.Status != "failed" {
+		t.Fatalf("expected failed, got %s", r.Status)
+	}
+
+	data, err := os.ReadFile(pool.LogPath(7))
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	log := string(data)
+	if !strings.Contains(log, "=== #7 Log me ===") || !strings.Contains(log, "failed to create coder") {
+		t.Errorf("unexpected log file:\n%s", log)
+	}
+	if len(streamed) != 2 || !strings.Contains(streamed[1], "failed to create coder") {
+		t.Errorf("unexpected streamed lines: %v", streamed)
+	}
+}
+
+func TestPool_FakeAgents_RejectThenApprove(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("hi\n"), 0644)
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "init"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "reviewer-1.md"), []byte("VERDICT: REJECT\n- handle the empty case\n"), 0644)
+
+	task, _ := s.CreateTask("Add greeting", "", "medium", nil)
+	s.AssignTask(task.ID, "coder", "coder")
+	task, _ = s.GetTask(task.ID)
+
+	pool := NewPool(PoolConfig{
+		Store:      s,
+		WorkDir:    dir,
+		MaxWorkers: 1,
+		MaxLoops:   3,
+		CoderName:  "coder",
+		CoderCfg:   config.Agent{Role: "coder", Mode: "fake", Fixtures: fixtures},
+		ReviewName: "reviewer",
+		ReviewCfg:  config.Agent{Role: "reviewer", Mode: "fake", Fixtures: fixtures},
+		LogDir:     t.TempDir(),
+		Stream:     true,
+	})
+	results := pool.Run([]store.Task{*task})
+	if len(results) != 1 || results[0].Status != "done" {
+		t.Fatalf("expected done, got %+v", results)
+	}
+
+	events, _ := s.GetEvents(task.ID)
+	rejected := false
+	for _, e := range events {
+		rejected = rejected || (e.Type == "reviewed" && strings.Contains(e.Content, "handle the empty case"))
+	}
+	if !rejected {
+		t.Error("expected the first review's rejection recorded")
+	}
+	if log, _ := os.ReadFile(pool.LogPath(task.ID)); !strings.Contains(string(log), "│ - handle the empty case") {
+		t.Errorf("expected the reviewer's output streamed to the log, got:\n%s", log)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "fake", fmt.Sprintf("task-%d.txt", task.ID)))
+	if err != nil || string(data) != "change 2\n" {
+		t.Errorf("expected the second coder change, got %q (%v)", data, err)
+	}
+	cmd := exec.Command("git", "log", "--oneline")
+	cmd.Dir = dir
+	if out, _ := cmd.Output(); !strings.Contains(string(out), "hive: task #1") {
+		t.Errorf("expected the task committed, got log:\n%s", out)
+	}
+}
+
+func TestPool_CoderFor(t *testing.T) {
+	cfg := &config.Config{Agents: map[string]config.Agent{
+		"coder":    {Role: "coder", Mode: "cli", Cmd: "claude"},
+		"payments": {Role: "coder", Mode: "cli", Cmd: "claude"},
+		"reviewer": {Role: "reviewer", Mode: "cli", Cmd: "codex"},
+	}}
+	pool := NewPool(PoolConfig{Config: cfg, CoderName: "coder", CoderCfg: cfg.Agents["coder"]})
+
+	tests := []struct {
+		assigned string
+		want     string
+	}{
+		{"", "coder"},
+		{"coder", "coder"},
+		{"payments", "payments"},
+		{"reviewer", "coder"}, // not a coder
+		{"gone", "coder"},     // not in config
+	}
+	for _, tc := range tests {
+		name, agentCfg := pool.coderFor(&store.Task{AssignedAgent: tc.assigned})
+		if name != tc.want {
+			t.Errorf("assigned %q: got %s, want %s", tc.assigned, name, tc.want)
+		}
+		if name == "payments" && !agentCfg.AutoAccept {
+			t.Error("a routed CLI coder should run with auto_accept")
+		}
+	}
+}
+
+func TestPool_BranchConflictBlocksTask(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".hive/\n"), 0644)
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "init"}, {"checkout", "-b", "hive/epic-1"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+
+	var tasks []store.Task
+	for _, title := range []string{"Add greeting", "Add farewell"} {
+		task, _ := s.CreateTask(title, "", "medium", nil)
+		s.AssignTask(task.ID, "coder", "coder")
+		task, _ = s.GetTask(task.ID)
+		tasks = append(tasks, *task)
+	}
+
+	cfg := &config.Config{}
+	cfg.Git.TaskBranches = true
+	pool := NewPool(PoolConfig{
+		Store:      s,
+		Config:     cfg,
+		WorkDir:    dir,
+		EpicBranch: "hive/epic-1",
+		MaxWorkers: 2,
+		MaxLoops:   1,
+		CoderName:  "coder",
+		CoderCfg:   config.Agent{Role: "coder", Mode: "fake", Fixtures: t.TempDir()},
+		ReviewName: "reviewer",
+		ReviewCfg:  config.Agent{Role: "reviewer", Mode: "fake", Fixtures: t.TempDir()},
+		LogDir:     t.TempDir(),
+	})
+
+	chaos.Init(chaos.Config{chaos.MergeConflict: 1}, 1)
+	defer chaos.Init(nil, 0)
+	results := pool.Run(tasks)
+
+	for _, r := range results {
+		if r.Status != "blocked" || r.Branch == "" || len(r.Conflicts) == 0 {
+			t.Errorf("#%d: expected blocked with its branch kept, got %+v", r.TaskID, r)
+		}
+		got, _ := s.GetTask(r.TaskID)
+		if got.Status != store.StatusBlocked || !strings.Contains(got.BlockedReason, "needs merge") || !strings.Contains(got.BlockedReason, r.Branch) {
+			t.Errorf("#%d: status=%s reason=%q", r.TaskID, got.Status, got.BlockedReason)
+		}
+	}
+}
+
+func TestPool_WorktreesPerEpic(t *testing.T) {
+	dir := initTestRepo(t)
+	pools := []*Pool{
+		NewPool(PoolConfig{WorkDir: dir, EpicBranch: "hive/epic-3", EpicID: 3}),
+		NewPool(PoolConfig{WorkDir: dir, EpicBranch: "hive/epic-4", EpicID: 4}),
+	}
+	if pools[0].worktrees == nil || pools[1].worktrees == nil {
+		t.Fatal("expected worktree pools in a git repo")
+	}
+	if got, want := pools[0].worktrees.dir, filepath.Join(dir, ".hive", "worktrees", "epic-3"); got != want {
+		t.Errorf("worktree dir = %s, want %s", got, want)
+	}
+	if pools[0].worktrees.dir == pools[1].worktrees.dir {
+		t.Errorf("two epics share %s", pools[0].worktrees.dir)
+	}
+}
+
+func TestPool_StopOnBlock(t *testing.T) {
+	pool := NewPool(PoolConfig{Store: testStore(t), WorkDir: t.TempDir(), MaxWorkers: 2, StopOnBlock: true})
+	results := pool.Run([]store.Task{
+		{ID: 1, Title: "Blocked", Status: store.StatusBlocked, BlockedReason: "which DB?"},
+		{ID: 2, Title: "Waiting", Status: store.StatusBacklog, AssignedAgent: "coder"},
+		{ID: 3, Title: "Finished", Status: store.StatusDone},
+	})
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Status)
+	}
+	if want := []string{"blocked", "skipped", "done"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Thresholds for flagging an iteration as undoing the previous one: at
+// least minUndoneLines and half of the previous iteration's changes must
+// be gone, with less new work than was removed. Rewrites that replace
+// old code with new code of similar size don't trip it.
+const (
+	minUndoneLines = 5
+	undoneRatio    = 0.5
+)
+
+// DiffGuard compares each fix-loop iteration's working-tree diff with the
+// previous one, to catch a coder that reverts work the reviewer already
+// accepted or asked for.
+type DiffGuard struct {
+	prev     map[diffLine]bool
+	prevIter int
+	hasPrev  bool
+}
+
+// diffLine is one changed line: the file, whether it was added or
+// removed, and its trimmed content.
+type diffLine struct {
+	file  string
+	added bool
+	text  string
+}
+
+func (l diffLine) String() string {
+	sign := "-"
+	if l.added {
+		sign = "+"
+	}
+	return fmt.Sprintf("%s: %s%s", l.file, sign, l.text)
+}
+
+// Regression is an iteration that undid much of the previous one.
+type Regression struct {
+	Iteration int
+	Previous  int      // The iteration whose changes were undone
+	Undone    int      // Changed lines of Previous that are no longer changed
+	Changed   int      // Changed lines in Previous
+	Fresh     int      // Changed lines new in Iteration
+	Files     []string // Files with undone lines
+	Samples   []string // A few of the undone lines
+}
+
+// Summary describes the regression for the task's event history, where
+// the reviewer sees it.
+func (r Regression) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Iteration %d undid %d of %d changed lines from iteration %d (in %s) and added only %d new ones. ",
+		r.Iteration, r.Undone, r.Changed, r.Previous, strings.Join(r.Files, ", "), r.Fresh)
+	sb.WriteString("Check that previously requested or approved changes were not reverted.\n")
+	for _, l := range r.Samples {
+		sb.WriteString("  " + l + "\n")
+	}
+	return sb.String()
+}
+
+// Check diffs the working tree in workDir and compares it with the last
+// checked iteration. A regression is recorded as a "diff_regression"
+// event and returned; otherwise Check returns nil. Outside a git repo it
+// does nothing.
+func (g *DiffGuard) Check(s *store.Store, task *store.Task, iteration int, workDir string) *Regression {
+	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return nil
+	}
+	diff, err := safety.WorkingDiff()
+	if err != nil {
+		return nil
+	}
+	cur := parseDiffLines(diff)
+	prev, prevIter, hasPrev := g.prev, g.prevIter, g.hasPrev
+	g.prev, g.prevIter, g.hasPrev = cur, iteration, true
+	if !hasPrev {
+		return nil
+	}
+
+	r := compareIterations(prev, cur)
+	if r == nil {
+		return nil
+	}
+	r.Iteration, r.Previous = iteration, prevIter
+	s.AddEvent(task.ID, "git", "diff_regression", r.Summary())
+	return r
+}
+
+// compareIterations returns a Regression (without iteration numbers) when
+// cur undoes enough of prev.
+func compareIterations(prev, cur map[diffLine]bool) *Regression {
+	var undone []diffLine
+	for l := range prev {
+		if !cur[l] {
+			undone = append(undone, l)
+		}
+	}
+	fresh := 0
+	for l := range cur {
+		if !prev[l] {
+			fresh++
+		}
+	}
+	if len(undone) < minUndoneLines || float64(len(undone)) < undoneRatio*float64(len(prev)) || fresh >= len(undone) {
+		return nil
+	}
+
+	sort.Slice(undone, func(i, j int) bool {
+		if undone[i].file != undone[j].file {
+			return undone[i].file < undone[j].file
+		}
+		return undone[i].text < undone[j].text
+	})
+	r := &Regression{Undone: len(undone), Changed: len(prev), Fresh: fresh}
+	files := make(map[string]bool)
+	for i, l := range undone {
+		if !files[l.file] {
+			files[l.file] = true
+			r.Files = append(r.Files, l.file)
+		}
+		if i < 10 {
+			r.Samples = append(r.Samples, l.String())
+		}
+	}
+	return r
+}
+
+// parseDiffLines collects the changed lines of a unified diff. Lines
+// without letters or digits (braces, blank lines) are skipped: they match
+// too easily to say anything about overlap.
+func parseDiffLines(diff string) map[diffLine]bool {
+	lines := make(map[diffLine]bool)
+	file, inHunk := "", false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk && strings.HasPrefix(line, "--- "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			if name := strings.TrimPrefix(line, "+++ "); name != "/dev/null" {
+				file = strings.TrimPrefix(name, "b/")
+			}
+		case inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			text := strings.TrimSpace(line[1:])
+			if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				lines[diffLine{file: file, added: line[0] == '+', text: text}] = true
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffGuard_Revert(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	task, _ := s.CreateTask("Guard", "", "high", nil)
+
+	var orig strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&orig, "line %d\n", i)
+	}
+	path := filepath.Join(dir, "main.txt")
+	os.WriteFile(path, []byte(orig.String()), 0644)
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "init"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+
+	var guard DiffGuard
+	os.WriteFile(path, []byte(strings.ReplaceAll(orig.String(), "line", "fixed line")), 0644)
+	if r := guard.Check(s, task, 1, dir); r != nil {
+		t.Fatalf("first iteration flagged: %+v", r)
+	}
+
+	// The coder puts half of it back.
+	lines := strings.SplitAfter(orig.String(), "\n")
+	half := strings.Join(lines[:5], "") + strings.ReplaceAll(strings.Join(lines[5:], ""), "line", "fixed line")
+	os.WriteFile(path, []byte(half), 0644)
+	r := guard.Check(s, task, 2, dir)
+	if r == nil {
+		t.Fatal("expected a regression")
+	}
+	if r.Previous != 1 || r.Undone != 10 || r.Changed != 20 || r.Fresh != 0 {
+		t.Errorf("unexpected regression %+v", r)
+	}
+	if len(r.Files) != 1 || r.Files[0] != "main.txt" {
+		t.Errorf("files = %v", r.Files)
+	}
+
+	events, _ := s.GetEvents(task.ID)
+	found := false
+	for _, e := range events {
+		if e.Type == "diff_regression" && strings.Contains(e.Content, "undid 10 of 20") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no diff_regression event in %+v", events)
+	}
+}
+
+func TestCompareIterations(t *testing.T) {
+	prev := parseDiffLines("diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -1,6 +1,6 @@\n" +
+		"-old one\n-old two\n-old three\n+new one\n+new two\n+new three\n }\n")
+
+	// Same change plus more: progress, not a regression.
+	more := parseDiffLines("diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -1,6 +1,7 @@\n" +
+		"-old one\n-old two\n-old three\n+new one\n+new two\n+new three\n+new four\n")
+	if r := compareIterations(prev, more); r != nil {
+		t.Errorf("progress flagged: %+v", r)
+	}
+
+	// A full rewrite replaces old work with as much new work.
+	rewrite := parseDiffLines("diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -1,6 +1,6 @@\n" +
+		"-old one\n-old two\n-old three\n+other one\n+other two\n+other three\n")
+	if r := compareIterations(prev, rewrite); r != nil {
+		t.Errorf("rewrite flagged: %+v", r)
+	}
+
+	// Everything reverted.
+	if r := compareIterations(prev, parseDiffLines("")); r == nil || r.Undone != 6 || len(r.Samples) != 6 {
+		t.Errorf("revert not flagged: %+v", r)
+	}
+}
@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Rejection is one reviewer rejection in a fix loop.
+type Rejection struct {
+	Iteration int
+	Review    agent.ParsedReview
+	Output    string
+}
+
+// RejectionTracker notices when the reviewer rejects with essentially the
+// same objections twice in a row. Another coder iteration against the
+// same objections is unlikely to go differently, so the loop should stop
+// and hand the task to a human instead of burning its remaining budget.
+type RejectionTracker struct {
+	prev, last *Rejection
+}
+
+// Add records a review and reports whether it is a rejection repeating
+// the previous one. Any other verdict breaks the streak.
+func (t *RejectionTracker) Add(iteration int, review agent.ParsedReview, output string) bool {
+	if review.Verdict != "REJECT" {
+		t.prev, t.last = nil, nil
+		return false
+	}
+	t.prev, t.last = t.last, &Rejection{Iteration: iteration, Review: review, Output: output}
+	return t.prev != nil && agent.SameRejection(t.prev.Review, review, t.prev.Output, output)
+}
+
+// MarkNeedsHuman hands a task to a human after Add reported a repeated
+// rejection, with both reviews as the summary.
+func (t *RejectionTracker) MarkNeedsHuman(s *store.Store, task *store.Task, reviewer string) {
+	NeedHuman(s, task.ID, reviewer, NeedsHumanSummary(*t.prev, *t.last))
+}
+
+// NeedHuman stops a task the fix loop can't get further with on its own.
+// The summary goes in a "needs_human" event: its first line says why, the
+// rest is what the human needs, such as the reviewer's objections.
+func NeedHuman(s *store.Store, taskID int64, actor, summary string) {
+	s.AddEvent(taskID, actor, "needs_human", summary)
+	s.UpdateTaskStatus(taskID, store.StatusNeedsHuman)
+}
+
+// MarkMaxLoops hands a task that ran out of iterations to a human, with
+// every objection the reviewer raised along the way, each once.
+func MarkMaxLoops(s *store.Store, taskID int64, maxLoops int) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%d) without an approval. Needs a human.\n", ReasonMaxLoops, maxLoops)
+	reviews, _ := s.GetReviews(taskID)
+	seen := map[string]bool{}
+	for _, r := range reviews {
+		if r.Verdict != "reject" {
+			continue
+		}
+		for _, c := range agent.ParseReview(r.Comments).Comments {
+			if key := strings.ToLower(strings.Join(strings.Fields(c), " ")); !seen[key] {
+				if len(seen) == 0 {
+					sb.WriteString("\n## Reviewer objections\n")
+				}
+				seen[key] = true
+				sb.WriteString("- " + c + "\n")
+			}
+		}
+	}
+	NeedHuman(s, taskID, "hive", sb.String())
+}
+
+// NeedsHumanReason reads back why a task needs a human from its latest
+// "needs_human" event in events: the first line, and the objections
+// listed under it.
+func NeedsHumanReason(taskID int64, events []store.Event) (reason string, objections []string) {
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.TaskID != taskID || e.Type != "needs_human" {
+			continue
+		}
+		first, rest, _ := strings.Cut(e.Content, "\n")
+		for _, line := range strings.Split(rest, "\n") {
+			if c, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+				objections = append(objections, c)
+			}
+		}
+		return first, objections
+	}
+	return "", nil
+}
+
+// NeedsHumanSummary explains why the loop stopped, quoting both reviews.
+func NeedsHumanSummary(prev, cur Rejection) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Stopped at iteration %d: the reviewer rejected with the same objections twice in a row. Needs a human.\n", cur.Iteration)
+	for _, r := range []Rejection{prev, cur} {
+		fmt.Fprintf(&sb, "\n## Review (iter %d)\n", r.Iteration)
+		if len(r.Review.Comments) == 0 {
+			sb.WriteString(truncate(strings.TrimSpace(r.Output), 2000) + "\n")
+			continue
+		}
+		for _, c := range r.Review.Comments {
+			sb.WriteString("- " + c + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
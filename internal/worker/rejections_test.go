@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestRejectionTracker(t *testing.T) {
+	reject := agent.ParsedReview{Verdict: "REJECT", Comments: []string{"[HIGH] api.go:12: missing auth check on DELETE"}}
+	other := agent.ParsedReview{Verdict: "REJECT", Comments: []string{"[HIGH] db.go:3: connection never closed"}}
+
+	var tr RejectionTracker
+	if tr.Add(1, reject, "") {
+		t.Error("the first rejection can't be a repeat")
+	}
+	if tr.Add(2, other, "") {
+		t.Error("a different rejection isn't a repeat")
+	}
+	if !tr.Add(3, other, "") {
+		t.Error("expected the same rejection twice in a row to be a repeat")
+	}
+
+	tr = RejectionTracker{}
+	tr.Add(1, reject, "")
+	tr.Add(2, agent.ParsedReview{}, "no verdict")
+	if tr.Add(3, reject, "") {
+		t.Error("a review without a verdict should break the streak")
+	}
+}
+
+func TestRejectionTracker_MarkNeedsHuman(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Add delete endpoint", "", "high", nil)
+	reject := agent.ParsedReview{Verdict: "REJECT", Comments: []string{"[HIGH] api.go:12: missing auth check on DELETE"}}
+
+	var tr RejectionTracker
+	tr.Add(1, reject, "")
+	tr.Add(2, reject, "")
+	tr.MarkNeedsHuman(s, task, "codex")
+
+	got, _ := s.GetTask(task.ID)
+	if got.Status != store.StatusNeedsHuman {
+		t.Errorf("expected needs_human, got %s", got.Status)
+	}
+	events, _ := s.GetEvents(task.ID)
+	last := events[len(events)-2] // Followed by status_changed.
+	if last.Type != "needs_human" {
+		t.Fatalf("expected a needs_human event, got %s", last.Type)
+	}
+	for _, want := range []string{"## Review (iter 1)", "## Review (iter 2)", "missing auth check"} {
+		if !strings.Contains(last.Content, want) {
+			t.Errorf("summary missing %q:\n%s", want, last.Content)
+		}
+	}
+}
+
+func TestMarkMaxLoops(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Add delete endpoint", "", "high", nil)
+	s.AddReview(task.ID, "codex", "reject", "COMMENTS:\n- [HIGH] api.go:12: missing auth check\n\nVERDICT: REJECT")
+	s.AddReview(task.ID, "codex", "reject", "COMMENTS:\n- [HIGH] api.go:12: missing  auth check\n- [LOW] no test for 404\n\nVERDICT: REJECT")
+	s.AddReview(task.ID, "codex", "approve", "COMMENTS:\n- [LOW] naming\n\nVERDICT: APPROVE")
+
+	MarkMaxLoops(s, task.ID, 3)
+
+	got, _ := s.GetTask(task.ID)
+	if got.Status != store.StatusNeedsHuman {
+		t.Errorf("expected needs_human, got %s", got.Status)
+	}
+	events, _ := s.GetEvents(task.ID)
+	reason, objections := NeedsHumanReason(task.ID, events)
+	if !strings.HasPrefix(reason, ReasonMaxLoops+" (3)") {
+		t.Errorf("reason: %q", reason)
+	}
+	want := []string{"[HIGH] api.go:12: missing auth check", "[LOW] no test for 404"}
+	if strings.Join(objections, "|") != strings.Join(want, "|") {
+		t.Errorf("objections: got %q, want %q", objections, want)
+	}
+	if r, _ := NeedsHumanReason(task.ID+1, events); r != "" {
+		t.Errorf("another task's reason: %q", r)
+	}
+}
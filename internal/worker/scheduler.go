@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/config"
+)
+
+// Scheduler holds the budget of calls to each provider (config
+// providers): how many may run at once and how many may start per
+// minute. Every runner made with NewRunner takes its slot from the same
+// scheduler, so parallel workers share a provider's budget instead of
+// each running into its rate limit.
+type Scheduler struct {
+	mu        sync.Mutex
+	limits    map[string]config.ProviderLimit
+	providers map[string]*providerUsage
+	changed   chan struct{} // Closed and replaced whenever a slot frees up
+	now       func() time.Time
+}
+
+// providerUsage is what a provider's calls use of its budget.
+type providerUsage struct {
+	running int
+	starts  []time.Time // Calls started in the last minute, oldest first
+}
+
+// NewScheduler creates a scheduler with the given limits.
+func NewScheduler(limits map[string]config.ProviderLimit) *Scheduler {
+	return &Scheduler{
+		limits:    limits,
+		providers: map[string]*providerUsage{},
+		changed:   make(chan struct{}),
+		now:       time.Now,
+	}
+}
+
+// scheduler is the process's scheduler, shared by every pipeline
+// goroutine.
+var scheduler = NewScheduler(nil)
+
+// SetProviderLimits changes the limits of the shared scheduler. Calls
+// already running keep their slots; reloading the config mid-run takes
+// effect for the calls after it.
+func SetProviderLimits(limits map[string]config.ProviderLimit) {
+	scheduler.SetLimits(limits)
+}
+
+// SetLimits changes the scheduler's limits.
+func (s *Scheduler) SetLimits(limits map[string]config.ProviderLimit) {
+	s.mu.Lock()
+	s.limits = limits
+	s.broadcast()
+	s.mu.Unlock()
+}
+
+// Acquire waits until a call to provider fits its budget and takes a
+// slot for it. The returned release gives the slot back; it must be
+// called once the call is done.
+func (s *Scheduler) Acquire(ctx context.Context, provider string) (release func(), err error) {
+	for {
+		s.mu.Lock()
+		limit := s.limits[provider]
+		if limit.Concurrency <= 0 && limit.RPM <= 0 {
+			s.mu.Unlock()
+			return func() {}, nil
+		}
+		u := s.usage(provider)
+		now := s.now()
+		for len(u.starts) > 0 && now.Sub(u.starts[0]) >= time.Minute {
+			u.starts = u.starts[1:]
+		}
+
+		var wait time.Duration // Until a start leaves the window; 0 to wait for a release
+		switch {
+		case limit.RPM > 0 && len(u.starts) >= limit.RPM:
+			wait = u.starts[0].Add(time.Minute).Sub(now)
+		case limit.Concurrency > 0 && u.running >= limit.Concurrency:
+		default:
+			u.running++
+			if limit.RPM > 0 {
+				u.starts = append(u.starts, now)
+			}
+			s.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { s.release(provider) }) }, nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-changed:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *Scheduler) release(provider string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u := s.providers[provider]; u != nil && u.running > 0 {
+		u.running--
+	}
+	s.broadcast()
+}
+
+// broadcast wakes every waiting Acquire. s.mu must be held.
+func (s *Scheduler) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Scheduler) usage(provider string) *providerUsage {
+	u := s.providers[provider]
+	if u == nil {
+		u = &providerUsage{}
+		s.providers[provider] = u
+	}
+	return u
+}
+
+// NewRunner creates an agent runner whose calls take their slot from the
+// shared scheduler first. Pipelines use it instead of agent.NewRunner.
+func NewRunner(name string, cfg config.Agent) (agent.Runner, error) {
+	r, err := agent.NewRunner(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledRunner{Runner: r, provider: cfg.ProviderKey(), sched: scheduler}, nil
+}
+
+// scheduledRunner waits for its provider's budget before each call.
+type scheduledRunner struct {
+	agent.Runner
+	provider string
+	sched    *Scheduler
+}
+
+func (r *scheduledRunner) Run(ctx context.Context, req agent.Request) (*agent.Response, error) {
+	start := time.Now()
+	release, err := r.sched.Acquire(ctx, r.provider)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a %s slot: %w", r.provider, err)
+	}
+	defer release()
+	if waited := time.Since(start); waited >= time.Second && req.Stream != nil {
+		req.Stream(fmt.Sprintf("(waited %s for a %s slot)", waited.Round(time.Second), r.provider))
+	}
+	return r.Runner.Run(ctx, req)
+}
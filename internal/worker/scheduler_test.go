@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestScheduler_Concurrency(t *testing.T) {
+	s := NewScheduler(map[string]config.ProviderLimit{"anthropic": {Concurrency: 2}})
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.Acquire(context.Background(), "anthropic")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			release()
+		}()
+	}
+	wg.Wait()
+	if peak.Load() != 2 {
+		t.Errorf("expected at most 2 calls at once, peaked at %d", peak.Load())
+	}
+}
+
+func TestScheduler_RPM(t *testing.T) {
+	s := NewScheduler(map[string]config.ProviderLimit{"openai": {RPM: 2}})
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		release, err := s.Acquire(context.Background(), "openai")
+		if err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+		release()
+	}
+
+	// The third call in the same minute waits.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, "openai"); err == nil {
+		t.Fatal("expected the third call in a minute to wait")
+	}
+
+	// A minute later it goes.
+	now = now.Add(time.Minute)
+	if _, err := s.Acquire(context.Background(), "openai"); err != nil {
+		t.Fatalf("expected a call after a minute, got %v", err)
+	}
+}
+
+func TestScheduler_UnlimitedAndOtherProviders(t *testing.T) {
+	s := NewScheduler(map[string]config.ProviderLimit{"openai": {Concurrency: 1}})
+	hold, _ := s.Acquire(context.Background(), "openai")
+	defer hold()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, provider := range []string{"anthropic", "claude"} {
+		if _, err := s.Acquire(ctx, provider); err != nil {
+			t.Errorf("%s has no limit, got %v", provider, err)
+		}
+	}
+}
+
+func TestScheduler_SetLimitsWakesWaiters(t *testing.T) {
+	s := NewScheduler(map[string]config.ProviderLimit{"openai": {Concurrency: 1}})
+	hold, _ := s.Acquire(context.Background(), "openai")
+	defer hold()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(context.Background(), "openai")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.SetLimits(map[string]config.ProviderLimit{"openai": {Concurrency: 2}})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("raising the limit should let the waiting call go")
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/imkarma/hive/internal/git"
+)
+
+// SetupTimeout bounds a worktree's setup command; installing dependencies
+// from scratch can take a while.
+const SetupTimeout = 15 * time.Minute
+
+// RunSetup runs an epic's setup command (setup_cmd) in a fresh worktree,
+// so agents start with dependencies installed instead of discovering
+// they're missing. An empty command passes. On failure the tail of the
+// output is returned.
+//
+// Files the command leaves that git doesn't ignore would be merged with
+// the task's changes, so they are returned too, for a warning.
+func RunSetup(cmdline, dir string) (ok bool, output string, leftovers []string) {
+	if cmdline == "" {
+		return true, "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SetupTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdline)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		output = string(out)
+		if ctx.Err() == context.DeadlineExceeded {
+			output += fmt.Sprintf("\n(timed out after %s)", SetupTimeout)
+		}
+		const maxTail = 3000
+		if len(output) > maxTail {
+			output = "...\n" + output[len(output)-maxTail:]
+		}
+		return false, output, nil
+	}
+	leftovers, _ = git.New(dir).ChangedFiles()
+	return true, "", leftovers
+}
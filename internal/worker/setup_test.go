@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSetup(t *testing.T) {
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("deps/\n"), 0644)
+
+	if ok, _, _ := RunSetup("", dir); !ok {
+		t.Error("no setup command should pass")
+	}
+
+	ok, _, leftovers := RunSetup("mkdir -p deps && touch deps/lib.a built.txt", dir)
+	if !ok {
+		t.Fatal("setup should pass")
+	}
+	if strings.Join(leftovers, ",") != ".gitignore,built.txt" {
+		t.Errorf("expected only files git doesn't ignore, got %v", leftovers)
+	}
+
+	ok, output, _ := RunSetup("echo registry unreachable; exit 1", dir)
+	if ok || !strings.Contains(output, "registry unreachable") {
+		t.Errorf("expected failure with output, got ok=%v %q", ok, output)
+	}
+}
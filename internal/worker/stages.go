@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Stage outcomes returned by RunRoleStage.
+const (
+	StagePassed   = "passed"   // Every role ran (or none were configured)
+	StageBlocked  = "blocked"  // A role asked a question; the task is now blocked
+	StageRejected = "rejected" // A verdict role rejected the changes
+)
+
+// StageResult is the outcome of running the custom roles at one stage.
+type StageResult struct {
+	Status string
+	Role   string // Role that blocked or rejected
+	Detail string // Blocker question or rejection comments
+}
+
+// RunRoleStage runs every custom role registered for stage on a task, in
+// name order. Each role is run by the first agent (by name) configured
+// with that role; roles without an agent are skipped. The first role to
+// block or reject stops the stage.
+//
+// Output is saved as a run artifact plus a "role_output" event so the
+// coder and reviewer see it in their context on the next prompt.
+func RunRoleStage(s *store.Store, arts *artifacts.Manager, cfg *config.Config, reg *roles.Registry, stage roles.Stage, task *store.Task, workDir string, logf func(string, ...any)) StageResult {
+	ctxBuilder := agentctx.New(s).WithRoles(reg)
+	if cfg.Review.Blind {
+		ctxBuilder.WithBlind(cfg.AgentRoles())
+	}
+
+	for _, role := range reg.ByStage(stage) {
+		agentName, agentCfg, ok := agentForRole(cfg, role.Name)
+		if !ok {
+			logf("%s: no agent with this role, skipping", role.Name)
+			continue
+		}
+		// Stage roles run inside the pipeline, never interactively.
+		if agentCfg.Mode == "cli" {
+			agentCfg.AutoAccept = true
+		}
+
+		runner, err := NewRunner(agentName, agentCfg)
+		if err != nil {
+			logf("%s: %v", role.Name, err)
+			continue
+		}
+
+		var prompt string
+		if stage == roles.StageBeforeCode {
+			prompt, err = ctxBuilder.BuildPrompt(task, role.Name)
+		} else {
+			prompt, err = ctxBuilder.BuildDiffPrompt(task, role.Name)
+		}
+		if err != nil {
+			logf("%s: build prompt: %v", role.Name, err)
+			continue
+		}
+
+		logf("%s (%s) running...", agentName, role.Name)
+		resp, err := runner.Run(context.Background(), agent.Request{
+			TaskID: task.ID, Prompt: prompt, WorkDir: workDir, TimeoutSec: agentCfg.DefaultTimeout(),
+		})
+		if err != nil {
+			logf("%s: error: %v", role.Name, err)
+			continue
+		}
+
+		if _, err := arts.Save(task.ID, role.Name, artifacts.Name(task.ID, string(stage), role.Name), resp.Output); err != nil {
+			logf("%s: save output: %v", role.Name, err)
+		}
+
+		if b := agent.ParseBlocked(resp.Output); b != "" {
+			s.BlockTask(task.ID, b)
+			logf("%s: BLOCKED: %s", role.Name, b)
+			return StageResult{Status: StageBlocked, Role: role.Name, Detail: b}
+		}
+
+		if role.Verdict {
+			review := agent.ParseReview(resp.Output)
+			if review.Verdict == "REJECT" {
+				s.AddReview(task.ID, agentName, "reject", resp.Output)
+				var comments strings.Builder
+				for _, c := range review.Comments {
+					comments.WriteString("- " + c + "\n")
+				}
+				s.AddEvent(task.ID, agentName, "reviewed",
+					fmt.Sprintf("REJECTED by %s:\n%s", role.Name, comments.String()))
+				logf("%s: REJECTED (%.1fs)", role.Name, resp.Duration)
+				return StageResult{Status: StageRejected, Role: role.Name, Detail: comments.String()}
+			}
+			logf("%s: %s (%.1fs)", role.Name, verdictLabel(review.Verdict), resp.Duration)
+			continue
+		}
+
+		output := resp.Output
+		if len(output) > 4000 {
+			output = output[:4000] + "\n... (truncated)"
+		}
+		s.AddEvent(task.ID, agentName, "role_output", fmt.Sprintf("[%s]\n%s", role.Name, output))
+		logf("%s: done (%.1fs)", role.Name, resp.Duration)
+	}
+
+	return StageResult{Status: StagePassed}
+}
+
+// agentForRole returns the first agent, by name, configured with role.
+func agentForRole(cfg *config.Config, role string) (string, config.Agent, bool) {
+	names := make([]string, 0, len(cfg.Agents))
+	for name, a := range cfg.Agents {
+		if a.Role == role {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", config.Agent{}, false
+	}
+	sort.Strings(names)
+	return names[0], cfg.Agents[names[0]], true
+}
+
+func verdictLabel(verdict string) string {
+	if verdict == "" {
+		return "no verdict"
+	}
+	return verdict
+}
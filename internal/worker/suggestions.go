@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// SaveSuggestions stores the patches a review suggests as one "suggestion"
+// artifact, for hive review apply. label distinguishes runs, e.g. "iter2".
+// Returns the artifact path, or "" when the review suggested nothing.
+func SaveSuggestions(arts *artifacts.Manager, taskID int64, review agent.ParsedReview, label string) string {
+	if len(review.Suggestions) == 0 {
+		return ""
+	}
+	path, _ := arts.Save(taskID, "suggestion", artifacts.PatchName(taskID, "suggestion", label), JoinPatches(review.Suggestions))
+	return path
+}
+
+// JoinPatches concatenates patches into one that git apply accepts.
+func JoinPatches(patches []string) string {
+	var sb strings.Builder
+	for _, p := range patches {
+		sb.WriteString(strings.TrimRight(p, "\n") + "\n")
+	}
+	return sb.String()
+}
+
+// ApplySuggestions applies the patches of a rejecting review when
+// review.auto_apply is on and they are trivial (review.trivial_lines).
+// On success it returns a stand-in coder response listing the changed
+// files, so the fix loop can go straight to the gates and the next review
+// without a coder run. It returns nil when nothing was applied.
+func ApplySuggestions(s *store.Store, cfg *config.Config, task *store.Task, reviewer string, review agent.ParsedReview, workDir string) *agent.Response {
+	if cfg == nil || !cfg.Review.AutoApply || len(review.Suggestions) == 0 {
+		return nil
+	}
+	patch := JoinPatches(review.Suggestions)
+	size := agent.PatchSize(patch)
+	if size > cfg.Review.TrivialLimit() {
+		return nil
+	}
+
+	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return nil
+	}
+	if err := safety.ApplyPatch(patch, true); err != nil {
+		s.AddEvent(task.ID, reviewer, "suggestion_skipped", fmt.Sprintf("Suggested patch doesn't apply: %v", err))
+		return nil
+	}
+	if err := safety.ApplyPatch(patch, false); err != nil {
+		s.AddEvent(task.ID, reviewer, "suggestion_skipped", fmt.Sprintf("Suggested patch failed: %v", err))
+		return nil
+	}
+
+	files := agent.PatchFiles(patch)
+	s.AddEvent(task.ID, reviewer, "suggestion_applied",
+		fmt.Sprintf("Applied %d-line reviewer patch to %s", size, strings.Join(files, ", ")))
+
+	var out strings.Builder
+	out.WriteString("Applied the reviewer's suggested patch instead of a coder run.\n\n")
+	out.WriteString("```diff\n" + patch + "```\n\n")
+	out.WriteString("FILES_CHANGED:\n")
+	for _, f := range files {
+		out.WriteString("- " + f + "\n")
+	}
+	return &agent.Response{Output: out.String()}
+}
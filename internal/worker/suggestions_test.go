@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+)
+
+const suggestedPatch = "--- a/auth.go\n+++ b/auth.go\n@@ -1,3 +1,3 @@\n package auth\n \n-const maxTries = 0\n+const maxTries = 3\n"
+
+func TestApplySuggestions(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, "auth.go"), []byte("package auth\n\nconst maxTries = 0\n"), 0644)
+	task, _ := s.CreateTask("Fix retries", "", "high", nil)
+	review := agent.ParsedReview{Verdict: "REJECT", Suggestions: []string{suggestedPatch}}
+
+	off := &config.Config{}
+	if ApplySuggestions(s, off, task, "codex", review, dir) != nil {
+		t.Fatal("should not apply without review.auto_apply")
+	}
+
+	tooBig := &config.Config{Review: config.Review{AutoApply: true, TrivialLines: 1}}
+	if ApplySuggestions(s, tooBig, task, "codex", review, dir) != nil {
+		t.Fatal("should not apply a patch above trivial_lines")
+	}
+
+	on := &config.Config{Review: config.Review{AutoApply: true}}
+	resp := ApplySuggestions(s, on, task, "codex", review, dir)
+	if resp == nil {
+		t.Fatal("expected the trivial patch to be applied")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "auth.go")); !strings.Contains(string(data), "maxTries = 3") {
+		t.Errorf("patch not applied: %q", data)
+	}
+	if files := agent.ParseFilesChanged(resp.Output); len(files) != 1 || files[0] != "auth.go" {
+		t.Errorf("stand-in coder output should declare auth.go, got %v", files)
+	}
+
+	// Applying again fails the check and leaves an event instead.
+	if ApplySuggestions(s, on, task, "codex", review, dir) != nil {
+		t.Error("an already-applied patch should not apply again")
+	}
+	events, _ := s.GetEvents(task.ID)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	joined := strings.Join(types, ",")
+	if !strings.Contains(joined, "suggestion_applied") || !strings.Contains(joined, "suggestion_skipped") {
+		t.Errorf("expected applied and skipped events, got %v", types)
+	}
+}
+
+func TestSaveSuggestions(t *testing.T) {
+	s := testStore(t)
+	root := t.TempDir()
+	task, _ := s.CreateTask("Fix retries", "", "high", nil)
+	arts := artifacts.New(s, root)
+
+	if path := SaveSuggestions(arts, task.ID, agent.ParsedReview{}, "iter1"); path != "" {
+		t.Errorf("expected nothing saved without suggestions, got %s", path)
+	}
+
+	path := SaveSuggestions(arts, task.ID, agent.ParsedReview{Suggestions: []string{suggestedPatch}}, "iter1")
+	if !strings.HasSuffix(path, "task-1-suggestion-iter1.patch") {
+		t.Errorf("unexpected path %s", path)
+	}
+	saved, _ := s.GetArtifacts(task.ID)
+	if len(saved) != 1 || saved[0].Type != "suggestion" {
+		t.Errorf("expected one suggestion artifact, got %+v", saved)
+	}
+}
@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	agentctx "github.com/imkarma/hive/internal/context"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+	"github.com/imkarma/hive/internal/workspace"
+)
+
+// RunTester has the tester agent write or extend tests for a task. It runs
+// at the configured testing stage; before_code gets the task prompt,
+// after_code also sees the coder's diff. When commit is true the files the
+// tester touched are committed on their own, separate from the code.
+//
+// Returns StagePassed when there is no tester agent or it finished, and
+// StageBlocked when it asked a question.
+func RunTester(s *store.Store, arts *artifacts.Manager, cfg *config.Config, reg *roles.Registry, task *store.Task, workDir string, commit bool, logf func(string, ...any)) StageResult {
+	agentName, agentCfg, ok := agentForRole(cfg, roles.Tester)
+	if !ok {
+		return StageResult{Status: StagePassed}
+	}
+	if agentCfg.Mode == "cli" {
+		agentCfg.AutoAccept = true
+	}
+
+	runner, err := NewRunner(agentName, agentCfg)
+	if err != nil {
+		logf("tester: %v", err)
+		return StageResult{Status: StagePassed}
+	}
+
+	ctxBuilder := agentctx.New(s).WithRoles(reg)
+	if cfg.Review.Blind {
+		ctxBuilder.WithBlind(cfg.AgentRoles())
+	}
+	var prompt string
+	if cfg.Testing.TesterStage() == string(roles.StageBeforeCode) {
+		prompt, err = ctxBuilder.BuildPrompt(task, roles.Tester)
+	} else {
+		prompt, err = ctxBuilder.BuildDiffPrompt(task, roles.Tester)
+	}
+	if err != nil {
+		logf("tester: build prompt: %v", err)
+		return StageResult{Status: StagePassed}
+	}
+
+	// Remember what was already dirty so only the tester's files are
+	// committed as tests.
+	safety := git.New(workDir)
+	before := map[string]bool{}
+	if commit {
+		files, _ := safety.ChangedFiles()
+		for _, f := range files {
+			before[f] = true
+		}
+	}
+
+	logf("%s writing tests...", agentName)
+	resp, err := runner.Run(context.Background(), agent.Request{
+		TaskID: task.ID, Prompt: prompt, WorkDir: workDir, TimeoutSec: agentCfg.DefaultTimeout(),
+	})
+	if err != nil {
+		logf("tester: error: %v", err)
+		return StageResult{Status: StagePassed}
+	}
+
+	if _, err := arts.Save(task.ID, "tests", artifacts.Name(task.ID, "tests"), resp.Output); err != nil {
+		logf("tester: save output: %v", err)
+	}
+
+	if b := agent.ParseBlocked(resp.Output); b != "" {
+		s.BlockTask(task.ID, b)
+		logf("tester: BLOCKED: %s", b)
+		return StageResult{Status: StageBlocked, Role: roles.Tester, Detail: b}
+	}
+
+	output := resp.Output
+	if len(output) > 4000 {
+		output = output[:4000] + "\n... (truncated)"
+	}
+	s.AddEvent(task.ID, agentName, "role_output", fmt.Sprintf("[%s]\n%s", roles.Tester, output))
+	logf("tests written (%.1fs)", resp.Duration)
+
+	if commit && safety.IsGitRepo() {
+		files, _ := safety.ChangedFiles()
+		var added []string
+		for _, f := range files {
+			if !before[f] {
+				added = append(added, f)
+			}
+		}
+		msg := fmt.Sprintf("hive: tests for task #%d — %s", task.ID, task.Title)
+		if committed, err := safety.CommitPaths(msg, added); err != nil {
+			logf("tester: commit: %v", err)
+		} else if committed {
+			logf("committed %d test file(s)", len(added))
+		}
+	}
+
+	return StageResult{Status: StagePassed}
+}
+
+// GateFailure is why the test gate failed: the step that failed
+// ("verify" or "tests"), its command, and the tail of its output.
+type GateFailure struct {
+	Step   string
+	Cmd    string
+	Output string
+}
+
+// Feedback is the failure as the coder sees it in the task's events.
+func (f *GateFailure) Feedback(iteration int) string {
+	return fmt.Sprintf("%s FAILED (iter %d): %s\n```\n%s\n```", strings.ToUpper(f.Step), iteration, f.Cmd, f.Output)
+}
+
+// RunTestGate runs the test gate in workDir: testing.verify, the quick
+// check (a build, a vet), then testing.cmd. A step passes when it has no
+// command. Returns nil when the gate passes; on failure the tail of the
+// output is kept so it can be fed back to the coder.
+//
+// With testing.package_cmd in a workspace, only the packages changed in
+// the working tree are tested; see TestGateCommands.
+func RunTestGate(cfg *config.Config, workDir string) *GateFailure {
+	if v := cfg.Testing.Verify; v != "" {
+		if ok, out := runGate(cfg, workDir, v, []string{v}); !ok {
+			return &GateFailure{Step: "verify", Cmd: v, Output: out}
+		}
+	}
+	if c := cfg.Testing.Cmd; c != "" {
+		if ok, out := runGate(cfg, workDir, c, TestGateCommands(cfg, workDir)); !ok {
+			return &GateFailure{Step: "tests", Cmd: c, Output: out}
+		}
+	}
+	return nil
+}
+
+// runGate runs cmds in workDir until one fails, within testing.timeout.
+// Output of a command other than main starts with the command.
+func runGate(cfg *config.Config, workDir, main string, cmds []string) (bool, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Testing.CmdTimeout())*time.Second)
+	defer cancel()
+
+	for _, c := range cmds {
+		cmd := exec.CommandContext(ctx, "sh", "-c", c)
+		cmd.Dir = workDir
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			continue
+		}
+
+		output := string(out)
+		if ctx.Err() == context.DeadlineExceeded {
+			output += fmt.Sprintf("\n(timed out after %ds)", cfg.Testing.CmdTimeout())
+		}
+		const maxTail = 3000
+		if len(output) > maxTail {
+			output = "...\n" + output[len(output)-maxTail:]
+		}
+		if len(cmds) > 1 || c != main {
+			output = "$ " + c + "\n" + output
+		}
+		return false, output
+	}
+	return true, ""
+}
+
+// TestGateCommands returns the commands the test gate runs: testing.cmd,
+// or testing.package_cmd once per changed workspace package when every
+// changed file (outside .hive) is in one. A clean tree, a change outside
+// the packages or a repo that isn't a workspace runs testing.cmd.
+func TestGateCommands(cfg *config.Config, workDir string) []string {
+	full := []string{cfg.Testing.Cmd}
+	if cfg.Testing.PackageCmd == "" {
+		return full
+	}
+	ws := workspace.Detect(workDir)
+	if ws == nil {
+		return full
+	}
+	changed, err := git.New(workDir).ChangedFiles()
+	if err != nil {
+		return full
+	}
+	var files []string
+	for _, f := range changed {
+		if !strings.HasPrefix(f, ".hive/") {
+			files = append(files, f)
+		}
+	}
+	pkgs, whole := ws.Affected(files)
+	if !whole || len(pkgs) == 0 {
+		return full
+	}
+	cmds := make([]string, len(pkgs))
+	for i, p := range pkgs {
+		cmds[i] = strings.NewReplacer("{dir}", p.Dir, "{name}", p.Name).Replace(cfg.Testing.PackageCmd)
+	}
+	return cmds
+}
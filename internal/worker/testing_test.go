@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+)
+
+func TestRunTestGate_NoCmdPasses(t *testing.T) {
+	if f := RunTestGate(&config.Config{}, t.TempDir()); f != nil {
+		t.Fatalf("expected pass, got %+v", f)
+	}
+}
+
+func TestRunTestGate_Passing(t *testing.T) {
+	cfg := &config.Config{Testing: config.Testing{Verify: "true", Cmd: "true"}}
+	if f := RunTestGate(cfg, t.TempDir()); f != nil {
+		t.Fatalf("expected passing gate, got %+v", f)
+	}
+}
+
+func TestRunTestGate_FailingReturnsOutput(t *testing.T) {
+	cfg := &config.Config{Testing: config.Testing{Cmd: "echo 'FAIL: TestLogin'; exit 1"}}
+	f := RunTestGate(cfg, t.TempDir())
+	if f == nil {
+		t.Fatal("expected failing test command")
+	}
+	if f.Step != "tests" || !strings.Contains(f.Output, "FAIL: TestLogin") {
+		t.Errorf("expected the tests step with its output, got %+v", f)
+	}
+	if fb := f.Feedback(2); !strings.HasPrefix(fb, "TESTS FAILED (iter 2): echo") {
+		t.Errorf("unexpected feedback %q", fb)
+	}
+}
+
+func TestRunTestGate_VerifyFirst(t *testing.T) {
+	cfg := &config.Config{Testing: config.Testing{Verify: "echo 'undefined: Login'; exit 2", Cmd: "touch ran"}}
+	dir := t.TempDir()
+	f := RunTestGate(cfg, dir)
+	if f == nil || f.Step != "verify" || !strings.Contains(f.Output, "undefined: Login") {
+		t.Errorf("expected failing verify with its output, got %+v", f)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("tests ran after verify failed")
+	}
+}
+
+func TestRunTester_ReportsArtifactErrors(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Login form", "", "", nil)
+	cfg := &config.Config{Agents: map[string]config.Agent{
+		"qa": {Role: "tester", Mode: "fake", Fixtures: t.TempDir()},
+	}}
+	// The project root is a file, so the runs directory can't be created.
+	root := filepath.Join(t.TempDir(), "not-a-dir")
+	os.WriteFile(root, nil, 0644)
+
+	var lines []string
+	logf := func(format string, args ...any) { lines = append(lines, fmt.Sprintf(format, args...)) }
+	if r := RunTester(s, artifacts.New(s, root), cfg, roles.Builtin(), task, t.TempDir(), false, logf); r.Status != StagePassed {
+		t.Fatalf("expected the stage to pass, got %+v", r)
+	}
+	if !slices.ContainsFunc(lines, func(l string) bool { return strings.HasPrefix(l, "tester: save output: ") }) {
+		t.Errorf("expected the failed write to be logged, got %q", lines)
+	}
+}
+
+func TestTestGateCommands_Workspace(t *testing.T) {
+	dir := initTestRepo(t)
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write("go.work", "go 1.22\n\nuse (\n\t./api\n\t./web\n)\n")
+	write("api/go.mod", "module example.com/api\n")
+	write("web/go.mod", "module example.com/web\n")
+	write(".hive/runs/task-1.log", "ignored\n")
+	cmd := exec.Command("sh", "-c", "git add go.work api web && git commit -qm init")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("commit: %s", out)
+	}
+
+	cfg := &config.Config{Testing: config.Testing{Cmd: "go test ./...", PackageCmd: "cd {dir} && go test ./... # {name}"}}
+	if got := TestGateCommands(cfg, dir); len(got) != 1 || got[0] != "go test ./..." {
+		t.Errorf("clean tree: got %v, want the full command", got)
+	}
+
+	write("api/handler.go", "package api\n")
+	got := TestGateCommands(cfg, dir)
+	if len(got) != 1 || got[0] != "cd api && go test ./... # example.com/api" {
+		t.Errorf("one package changed: got %v", got)
+	}
+
+	write("Makefile", "all:\n")
+	if got := TestGateCommands(cfg, dir); len(got) != 1 || got[0] != "go test ./..." {
+		t.Errorf("root file changed: got %v, want the full command", got)
+	}
+
+	cfg.Testing.PackageCmd = ""
+	if got := TestGateCommands(cfg, dir); len(got) != 1 || got[0] != "go test ./..." {
+		t.Errorf("no package_cmd: got %v", got)
+	}
+}
+
+func TestRunTestGate_PackageFailureNamesCommand(t *testing.T) {
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, "go.work"), []byte("use ./a\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "a"), 0755)
+	os.WriteFile(filepath.Join(dir, "a", "x.go"), []byte("package a\n"), 0644)
+	cmd := exec.Command("sh", "-c", "git add go.work && git commit -qm init")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("commit: %s", out)
+	}
+
+	cfg := &config.Config{Testing: config.Testing{Cmd: "true", PackageCmd: "echo broken in {dir}; exit 1"}}
+	f := RunTestGate(cfg, dir)
+	if f == nil || !strings.HasPrefix(f.Output, "$ echo broken in a; exit 1\n") || !strings.Contains(f.Output, "broken in a") {
+		t.Errorf("expected the package command to fail, got %+v", f)
+	}
+}
+
+func TestAgentForRole_FirstByName(t *testing.T) {
+	cfg := &config.Config{Agents: map[string]config.Agent{
+		"zed":   {Role: "tester"},
+		"alice": {Role: "tester"},
+		"bob":   {Role: "coder"},
+	}}
+
+	name, _, ok := agentForRole(cfg, "tester")
+	if !ok || name != "alice" {
+		t.Fatalf("expected alice, got %q (ok=%v)", name, ok)
+	}
+	if _, _, ok := agentForRole(cfg, "docs"); ok {
+		t.Fatal("expected no docs agent")
+	}
+}
+
+func TestRunPerfGate_NoBaseline(t *testing.T) {
+	cfg := &config.Config{Perf: config.Perf{
+		Cmd:      "echo BenchmarkA-8 10 500 ns/op",
+		Baseline: filepath.Join(t.TempDir(), "missing.txt"),
+	}}
+	regs, err := RunPerfGate(cfg, t.TempDir())
+	if err != nil || len(regs) != 0 {
+		t.Fatalf("expected no regressions without baseline, got %v, %v", regs, err)
+	}
+}
+
+func TestRunPerfGate_Regression(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "baseline.txt")
+	os.WriteFile(baseline, []byte("BenchmarkA-8 10 100 ns/op\n"), 0644)
+
+	cfg := &config.Config{Perf: config.Perf{
+		Cmd:      "echo BenchmarkA-8 10 500 ns/op",
+		Baseline: baseline,
+	}}
+	regs, err := RunPerfGate(cfg, t.TempDir())
+	if err != nil {
+		t.Fatalf("RunPerfGate: %v", err)
+	}
+	if len(regs) != 1 || regs[0].Name != "BenchmarkA" {
+		t.Fatalf("expected BenchmarkA regression, got %v", regs)
+	}
+	if !strings.Contains(PerfFindings(regs), "[HIGH] perf: BenchmarkA") {
+		t.Errorf("unexpected findings: %q", PerfFindings(regs))
+	}
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/roles"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// TiebreakReason reports why a review needs a second opinion, or "" if
+// it doesn't: the reviewer gave no clear verdict, or it rejects a task
+// it approved before. Call it before the review is recorded.
+func TiebreakReason(s *store.Store, taskID int64, review agent.ParsedReview) string {
+	switch review.Verdict {
+	case "":
+		return "no clear verdict"
+	case "REJECT":
+		reviews, _ := s.GetReviews(taskID)
+		if n := len(reviews); n > 0 && reviews[n-1].Verdict == "approve" {
+			return "the verdict flipped from APPROVE to REJECT"
+		}
+	}
+	return ""
+}
+
+// Tiebreaker returns the reviewer that settles disagreements with
+// primary: review.tiebreaker when set, else the first other reviewer (by
+// name) from a different provider, so it doesn't share the primary's
+// blind spots.
+func Tiebreaker(cfg *config.Config, primary string) (string, config.Agent, bool) {
+	if cfg == nil {
+		return "", config.Agent{}, false
+	}
+	if name := cfg.Review.Tiebreaker; name != "" {
+		a, ok := cfg.Agents[name]
+		return name, a, ok && name != primary
+	}
+	vendor := agentVendor(cfg.Agents[primary])
+	names := make([]string, 0, len(cfg.Agents))
+	for name, a := range cfg.Agents {
+		if a.Role == roles.Reviewer && name != primary && agentVendor(a) != vendor {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", config.Agent{}, false
+	}
+	sort.Strings(names)
+	return names[0], cfg.Agents[names[0]], true
+}
+
+// agentVendor is who runs an agent's model: the API provider, or the CLI.
+func agentVendor(a config.Agent) string {
+	if a.Provider != "" {
+		return a.Provider
+	}
+	return a.Cmd
+}
+
+// Tiebreak has the tiebreaker review the same request the primary
+// reviewer got and records both reviews in a "tiebreak" event. It returns
+// the tiebreaker's name, review and output, and ok=false when there is no
+// tiebreaker or it gave no clear verdict either — the caller then keeps
+// the primary review.
+func Tiebreak(s *store.Store, arts *artifacts.Manager, cfg *config.Config, task *store.Task, primary string, first agent.ParsedReview, req agent.Request, reason, label string, logf func(string, ...any)) (string, agent.ParsedReview, string, bool) {
+	name, agentCfg, ok := Tiebreaker(cfg, primary)
+	if !ok {
+		return "", agent.ParsedReview{}, "", false
+	}
+	if agentCfg.Mode == "cli" {
+		agentCfg.AutoAccept = true
+	}
+	runner, err := NewRunner(name, agentCfg)
+	if err != nil {
+		logf("tiebreak: %v", err)
+		return "", agent.ParsedReview{}, "", false
+	}
+
+	logf("tiebreak: %s, asking %s for a second review", reason, name)
+	req.TimeoutSec = agentCfg.DefaultTimeout()
+	resp, err := runner.Run(context.Background(), req)
+	if err != nil || resp.Error != nil {
+		if err == nil {
+			err = resp.Error
+		}
+		logf("tiebreak: %s failed: %v", name, err)
+		return "", agent.ParsedReview{}, "", false
+	}
+	arts.Save(task.ID, "review", artifacts.Name(task.ID, "tiebreak", label), resp.Output)
+
+	second := agent.CheckAcceptance(agent.ParseReviewWithChecklist(resp.Output, cfg.Review.Checklist), resp.Output, task.Acceptance)
+	s.AddEvent(task.ID, name, "tiebreak", TiebreakSummary(reason, primary, first, name, second))
+	if second.Verdict == "" {
+		logf("tiebreak: %s gave no clear verdict either", name)
+		return name, second, resp.Output, false
+	}
+	logf("tiebreak: %s says %s", name, second.Verdict)
+	return name, second, resp.Output, true
+}
+
+// TiebreakSummary quotes both reviews for the "tiebreak" event.
+func TiebreakSummary(reason, primary string, first agent.ParsedReview, second string, review agent.ParsedReview) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Second review (%s): %s says %s, %s says %s.\n", reason, primary, verdictLabel(first.Verdict), second, verdictLabel(review.Verdict))
+	for _, r := range []struct {
+		name   string
+		review agent.ParsedReview
+	}{{primary, first}, {second, review}} {
+		fmt.Fprintf(&sb, "\n## %s: %s\n", r.name, verdictLabel(r.review.Verdict))
+		for _, c := range r.review.Comments {
+			sb.WriteString("- " + c + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/artifacts"
+	"github.com/imkarma/hive/internal/config"
+)
+
+func TestTiebreakReason(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Add login", "", "high", nil)
+	reject := agent.ParsedReview{Verdict: "REJECT"}
+
+	if got := TiebreakReason(s, task.ID, agent.ParsedReview{}); got != "no clear verdict" {
+		t.Errorf("no verdict: got %q", got)
+	}
+	if got := TiebreakReason(s, task.ID, reject); got != "" {
+		t.Errorf("a first rejection needs no tiebreak, got %q", got)
+	}
+	s.AddReview(task.ID, "codex", "approve", "LGTM")
+	if got := TiebreakReason(s, task.ID, reject); !strings.Contains(got, "flipped") {
+		t.Errorf("rejecting an approved task: got %q", got)
+	}
+	if got := TiebreakReason(s, task.ID, agent.ParsedReview{Verdict: "APPROVE"}); got != "" {
+		t.Errorf("approving again needs no tiebreak, got %q", got)
+	}
+}
+
+func TestTiebreaker(t *testing.T) {
+	cfg := &config.Config{Agents: map[string]config.Agent{
+		"gpt":    {Role: "reviewer", Mode: "api", Provider: "openai"},
+		"gpt-2":  {Role: "reviewer", Mode: "api", Provider: "openai"},
+		"claude": {Role: "reviewer", Mode: "cli", Cmd: "claude"},
+		"coder":  {Role: "coder", Mode: "cli", Cmd: "codex"},
+	}}
+	if name, _, ok := Tiebreaker(cfg, "gpt"); !ok || name != "claude" {
+		t.Errorf("expected the reviewer from another provider, got %q %v", name, ok)
+	}
+	delete(cfg.Agents, "claude")
+	if name, _, ok := Tiebreaker(cfg, "gpt"); ok {
+		t.Errorf("a reviewer from the same provider is no tiebreaker, got %q", name)
+	}
+	cfg.Review.Tiebreaker = "gpt-2"
+	if name, _, ok := Tiebreaker(cfg, "gpt"); !ok || name != "gpt-2" {
+		t.Errorf("review.tiebreaker should win, got %q %v", name, ok)
+	}
+	if _, _, ok := Tiebreaker(cfg, "gpt-2"); ok {
+		t.Error("the primary can't break its own tie")
+	}
+}
+
+func TestTiebreak(t *testing.T) {
+	s := testStore(t)
+	task, _ := s.CreateTask("Add login", "", "high", nil)
+	fixtures := t.TempDir()
+	os.WriteFile(filepath.Join(fixtures, "reviewer.md"), []byte("VERDICT: APPROVE\n\nCOMMENTS:\n- [LOW] naming\n"), 0644)
+	cfg := &config.Config{Agents: map[string]config.Agent{
+		"gpt":    {Role: "reviewer", Mode: "api", Provider: "openai"},
+		"second": {Role: "reviewer", Mode: "fake", Fixtures: fixtures},
+	}}
+	first := agent.ParsedReview{Comments: []string{"hard to say"}}
+
+	var logged []string
+	logf := func(format string, args ...any) { logged = append(logged, format) }
+	name, review, out, ok := Tiebreak(s, artifacts.New(s, t.TempDir()), cfg, task, "gpt", first,
+		agent.Request{TaskID: task.ID, Prompt: "review", WorkDir: t.TempDir()}, "no clear verdict", "iter1", logf)
+	if !ok || name != "second" || review.Verdict != "APPROVE" || !strings.Contains(out, "VERDICT: APPROVE") {
+		t.Fatalf("unexpected tiebreak: %q %+v %v", name, review, ok)
+	}
+
+	events, _ := s.GetEvents(task.ID)
+	var summary string
+	for _, e := range events {
+		if e.Type == "tiebreak" {
+			summary = e.Content
+		}
+	}
+	for _, want := range []string{"gpt says no verdict, second says APPROVE", "## gpt: no verdict\n- hard to say", "## second: APPROVE\n- [LOW] naming"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("tiebreak event missing %q:\n%s", want, summary)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// Failure categories of the triage report.
+const (
+	NeedsHuman    = "needs human"    // The loop can't get further on its own
+	Retryable     = "retryable"      // Running the task again may well succeed
+	ConfigProblem = "config problem" // Nothing will succeed until the setup is fixed
+)
+
+// ReasonMaxLoops starts the reason of a task that ran out of iterations.
+const ReasonMaxLoops = "max iterations reached"
+
+// FailTask marks a task failed, recording the category and reason in a
+// "failed" event for the triage report.
+func FailTask(s *store.Store, taskID int64, actor, category, reason string) {
+	s.AddEvent(taskID, actor, "failed", fmt.Sprintf("[%s] %s", category, reason))
+	s.UpdateTaskStatus(taskID, store.StatusFailed)
+}
+
+// FailRun fails a task whose agent run failed: err from Run, or resp with
+// a non-zero exit code.
+func FailRun(s *store.Store, taskID int64, agentName string, resp *agent.Response, err error) {
+	category, reason := RunFailure(agentName, resp, err)
+	FailTask(s, taskID, agentName, category, reason)
+}
+
+// RunFailure classifies a failed agent run. An agent that can't start or
+// is refused by the API (missing command, bad key, unknown model) is a
+// config problem; timeouts, rate limits, server errors and plain non-zero
+// exits are worth retrying.
+func RunFailure(agentName string, resp *agent.Response, err error) (category, reason string) {
+	if err == nil && resp != nil {
+		err = resp.Error
+	}
+	code := 0
+	if resp != nil {
+		code = resp.ExitCode
+	}
+
+	switch {
+	case err != nil && strings.Contains(err.Error(), agentName):
+		reason = err.Error() // CLI errors name the agent and its exit code
+	case err != nil:
+		reason = fmt.Sprintf("%s failed: %v", agentName, err)
+	default:
+		reason = fmt.Sprintf("%s exited with code %d", agentName, code)
+	}
+	reason = truncate(reason, 1000)
+
+	msg := ""
+	if err != nil {
+		msg = strings.ToLower(err.Error())
+	}
+	switch {
+	case errors.Is(err, exec.ErrNotFound), strings.Contains(msg, "executable file not found"), code == 127:
+		return ConfigProblem, reason
+	case code == 401, code == 403, code == 404:
+		return ConfigProblem, reason
+	case strings.Contains(msg, "is not set"), strings.Contains(msg, "offline mode forbids"):
+		return ConfigProblem, reason
+	}
+	return Retryable, reason
+}
+
+// NextStep is a command the triage report suggests, and what it is for.
+type NextStep struct {
+	Command string
+	Why     string
+}
+
+// Failure is one task in the triage report.
+type Failure struct {
+	TaskID   int64
+	Title    string
+	Status   store.TaskStatus
+	Category string
+	Reason   string
+	Review   string // The last review or gate feedback, if any
+	Next     []NextStep
+}
+
+// Triage explains why tasks failed or stopped, from their events, and
+// suggests what to run next. Tasks that neither failed nor are blocked
+// are left out. maxLoops is the iteration limit of the run.
+func Triage(s *store.Store, tasks []store.Task, maxLoops int) []Failure {
+	var out []Failure
+	for _, t := range tasks {
+		if cur, err := s.GetTask(t.ID); err == nil {
+			t = *cur
+		}
+		if t.Status != store.StatusFailed && t.Status != store.StatusBlocked && t.Status != store.StatusNeedsHuman {
+			continue
+		}
+		events, _ := s.GetEvents(t.ID)
+		f := Failure{TaskID: t.ID, Title: t.Title, Status: t.Status}
+
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].Type == "reviewed" {
+				f.Review = truncate(strings.TrimSpace(events[i].Content), 1500)
+				break
+			}
+		}
+
+		if t.Status == store.StatusBlocked {
+			f.Category, f.Reason = NeedsHuman, "blocked: "+t.BlockedReason
+		} else {
+			f.Category, f.Reason = Retryable, "no reason recorded"
+			for i := len(events) - 1; i >= 0; i-- {
+				e := events[i]
+				if e.Type == "needs_human" {
+					f.Category = NeedsHuman
+					f.Reason, _, _ = strings.Cut(e.Content, "\n")
+					break
+				}
+				if e.Type == "failed" {
+					f.Category, f.Reason = parseFailed(e.Content)
+					break
+				}
+			}
+		}
+		f.Next = nextSteps(f, maxLoops)
+		out = append(out, f)
+	}
+	return out
+}
+
+// parseFailed reads back the content FailTask wrote.
+func parseFailed(content string) (category, reason string) {
+	if rest, ok := strings.CutPrefix(content, "["); ok {
+		if category, reason, ok := strings.Cut(rest, "] "); ok {
+			return category, reason
+		}
+	}
+	return Retryable, content
+}
+
+func nextSteps(f Failure, maxLoops int) []NextStep {
+	id := f.TaskID
+	retry := fmt.Sprintf("hive auto %d --skip-plan", id)
+	switch {
+	case f.Status == store.StatusBlocked:
+		return []NextStep{
+			{fmt.Sprintf(`hive answer %d "..."`, id), "answer the question"},
+			{retry, "continue the task"},
+		}
+	case f.Category == NeedsHuman:
+		steps := []NextStep{{fmt.Sprintf("hive log %d", id), "read the reviews the coder couldn't satisfy"}}
+		if f.Status == store.StatusNeedsHuman {
+			steps = append(steps, NextStep{fmt.Sprintf(`hive answer %d "..."`, id), "retry it with your guidance"})
+		}
+		if strings.HasPrefix(f.Reason, ReasonMaxLoops) && maxLoops > 0 {
+			steps = append(steps, NextStep{fmt.Sprintf("%s --max-loops %d", retry, maxLoops*2), "give it more iterations"})
+		}
+		return append(steps,
+			NextStep{fmt.Sprintf("hive open %d", id), "look at the latest output"},
+			NextStep{fmt.Sprintf("hive task done %d", id), "after finishing it by hand"},
+		)
+	case f.Category == ConfigProblem:
+		return []NextStep{
+			{"hive config validate", "check the agents' config"},
+			{"hive doctor", "check the environment"},
+			{retry, "run it again once fixed"},
+		}
+	case strings.HasPrefix(f.Reason, ReasonMaxLoops) && maxLoops > 0:
+		return []NextStep{
+			{fmt.Sprintf("hive log %d", id), "see what the reviewer kept rejecting"},
+			{fmt.Sprintf("%s --max-loops %d", retry, maxLoops*2), "give it more iterations"},
+		}
+	}
+	return []NextStep{
+		{fmt.Sprintf("hive log %d", id), "see the error"},
+		{retry, "run it again"},
+	}
+}
+
+// TriageReport renders failures as a markdown artifact for the task or
+// epic the pipeline ran on.
+func TriageReport(root *store.Task, failures []Failure) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Triage: #%d %s\n\n", root.ID, root.Title)
+
+	counts := map[string]int{}
+	for _, f := range failures {
+		counts[f.Category]++
+	}
+	var parts []string
+	for _, c := range []string{NeedsHuman, Retryable, ConfigProblem} {
+		if counts[c] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[c], c))
+		}
+	}
+	fmt.Fprintf(&sb, "%d task(s) did not finish: %s.\n", len(failures), strings.Join(parts, ", "))
+
+	for _, f := range failures {
+		fmt.Fprintf(&sb, "\n## #%d %s\n\n", f.TaskID, f.Title)
+		fmt.Fprintf(&sb, "- Status: %s\n", f.Status)
+		fmt.Fprintf(&sb, "- Category: %s\n", f.Category)
+		fmt.Fprintf(&sb, "- Reason: %s\n", f.Reason)
+		if f.Review != "" {
+			sb.WriteString("\n### Last review\n\n" + f.Review + "\n")
+		}
+		sb.WriteString("\n### Next\n\n")
+		for _, n := range f.Next {
+			fmt.Fprintf(&sb, "- `%s` (%s)\n", n.Command, n.Why)
+		}
+	}
+	return sb.String()
+}
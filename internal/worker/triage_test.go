@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/agent"
+	"github.com/imkarma/hive/internal/store"
+)
+
+func TestRunFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *agent.Response
+		err  error
+		want string
+	}{
+		{"missing command", nil, fmt.Errorf("start: %w", exec.ErrNotFound), ConfigProblem},
+		{"command not found", &agent.Response{ExitCode: 127}, nil, ConfigProblem},
+		{"bad API key", &agent.Response{ExitCode: 401, Error: errors.New("API returned status 401: invalid key")}, nil, ConfigProblem},
+		{"rate limited", &agent.Response{ExitCode: 429, Error: errors.New("API returned status 429")}, nil, Retryable},
+		{"timeout", &agent.Response{ExitCode: -1}, errors.New("agent claude timed out after 600s"), Retryable},
+		{"plain exit", &agent.Response{ExitCode: 2}, nil, Retryable},
+	}
+	for _, tt := range tests {
+		if got, _ := RunFailure("claude", tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if _, reason := RunFailure("claude", &agent.Response{ExitCode: 2}, nil); reason != "claude exited with code 2" {
+		t.Errorf("reason: %q", reason)
+	}
+}
+
+func TestTriage(t *testing.T) {
+	s := testStore(t)
+	epic, _ := s.CreateEpic("Auth", "", "")
+	exited, _ := s.CreateTask("Login form", "", "", &epic.ID)
+	stuck, _ := s.CreateTask("Rate limits", "", "", &epic.ID)
+	blocked, _ := s.CreateTask("Sessions", "", "", &epic.ID)
+	done, _ := s.CreateTask("Logout", "", "", &epic.ID)
+	repeated, _ := s.CreateTask("Tokens", "", "", &epic.ID)
+	exhausted, _ := s.CreateTask("Audit log", "", "", &epic.ID)
+
+	FailRun(s, exited.ID, "claude", &agent.Response{ExitCode: 401, Error: errors.New("API returned status 401")}, nil)
+	s.AddEvent(stuck.ID, "gpt", "reviewed", "REJECTED (iter 3):\n- no tests\n")
+	FailTask(s, stuck.ID, "hive", Retryable, ReasonMaxLoops+" (3)")
+	s.BlockTask(blocked.ID, "Which session store?")
+	s.UpdateTaskStatus(done.ID, store.StatusDone)
+	s.AddEvent(repeated.ID, "gpt", "needs_human", "Stopped at iteration 2: the reviewer rejected with the same objections twice in a row. Needs a human.\n\n## Review (iter 1)\n")
+	s.UpdateTaskStatus(repeated.ID, store.StatusFailed)
+	MarkMaxLoops(s, exhausted.ID, 3)
+
+	tasks, _ := s.ListTasksByEpic(epic.ID)
+	failures := Triage(s, tasks, 3)
+	if len(failures) != 5 {
+		t.Fatalf("expected 5 failures, got %+v", failures)
+	}
+	byID := map[int64]Failure{}
+	for _, f := range failures {
+		byID[f.TaskID] = f
+	}
+
+	if f := byID[exited.ID]; f.Category != ConfigProblem || !strings.Contains(f.Reason, "status 401") || f.Next[0].Command != "hive config validate" {
+		t.Errorf("exited: %+v", f)
+	}
+	if f := byID[stuck.ID]; f.Category != Retryable || !strings.Contains(f.Review, "no tests") || f.Next[1].Command != fmt.Sprintf("hive auto %d --skip-plan --max-loops 6", stuck.ID) {
+		t.Errorf("stuck: %+v", f)
+	}
+	if f := byID[blocked.ID]; f.Category != NeedsHuman || f.Reason != "blocked: Which session store?" || !strings.HasPrefix(f.Next[0].Command, "hive answer") {
+		t.Errorf("blocked: %+v", f)
+	}
+	if f := byID[repeated.ID]; f.Category != NeedsHuman || strings.Contains(f.Reason, "\n") {
+		t.Errorf("repeated: %+v", f)
+	}
+
+	if f := byID[exhausted.ID]; f.Category != NeedsHuman || f.Status != store.StatusNeedsHuman || len(f.Next) != 5 ||
+		f.Next[1].Command != fmt.Sprintf(`hive answer %d "..."`, exhausted.ID) || f.Next[2].Command != fmt.Sprintf("hive auto %d --skip-plan --max-loops 6", exhausted.ID) {
+		t.Errorf("exhausted: %+v", f)
+	}
+
+	report := TriageReport(epic, failures)
+	for _, want := range []string{"# Triage: #1 Auth", "5 task(s) did not finish: 3 needs human, 1 retryable, 1 config problem.", "### Last review", "- `hive doctor` (check the environment)"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package worker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/imkarma/hive/internal/config"
+	"github.com/imkarma/hive/internal/git"
+	"github.com/imkarma/hive/internal/store"
+)
+
+// docExts are the extensions of documentation files.
+var docExts = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".txt": true, ".adoc": true}
+
+// commentPrefixes are the comment markers lines start with, by file
+// extension, for diffs that only touch comments. "* " and "*" alone are
+// the middle lines of block comments.
+var commentPrefixes = commentMarkers()
+
+func commentMarkers() map[string][]string {
+	m := make(map[string][]string)
+	for _, ext := range []string{".go", ".js", ".jsx", ".ts", ".tsx", ".java", ".kt", ".scala", ".swift", ".c", ".h", ".cc", ".cpp", ".hpp", ".cs", ".rs", ".php"} {
+		m[ext] = []string{"//", "/*", "* ", "*/"}
+	}
+	for _, ext := range []string{".py", ".rb", ".sh", ".bash", ".yaml", ".yml", ".toml", ".pl", ".r"} {
+		m[ext] = []string{"#"}
+	}
+	for _, ext := range []string{".sql", ".lua", ".hs"} {
+		m[ext] = []string{"--"}
+	}
+	for _, ext := range []string{".html", ".xml", ".vue", ".svelte"} {
+		m[ext] = []string{"<!--"}
+	}
+	return m
+}
+
+// SkipReview reports whether the reviewer can be left out of this
+// iteration of a task (review.skip_trivial), and why. It can when the
+// working-tree diff in workDir has at most review.skip_lines changed
+// lines and every changed file is documentation, a test, one of
+// review.skip_paths, or only had comments changed. A task that a reviewer
+// has rejected before, or that has acceptance criteria or a required
+// checklist item to answer, always gets a review. When it skips, the
+// reason is recorded as a "review_skipped" event.
+func SkipReview(s *store.Store, cfg *config.Config, task *store.Task, workDir string) (string, bool) {
+	if cfg == nil || !cfg.Review.SkipTrivial || len(task.Acceptance) > 0 {
+		return "", false
+	}
+	for _, item := range cfg.Review.Checklist {
+		if item.IsRequired() {
+			return "", false
+		}
+	}
+	if reviews, _ := s.GetReviews(task.ID); hasRejection(reviews) {
+		return "", false
+	}
+	safety := git.New(workDir)
+	if !safety.IsGitRepo() {
+		return "", false
+	}
+	diff, err := safety.WorkingDiff()
+	if err != nil {
+		return "", false
+	}
+	reason, ok := TrivialDiff(diff, cfg.Review.SkipLimit(), cfg.Review.SkipPaths)
+	if !ok {
+		return "", false
+	}
+	s.AddEvent(task.ID, "hive", "review_skipped", "Review skipped: "+reason)
+	return reason, true
+}
+
+func hasRejection(reviews []store.Review) bool {
+	for _, r := range reviews {
+		if r.Verdict == "reject" {
+			return true
+		}
+	}
+	return false
+}
+
+// TrivialDiff reports whether a unified diff is small and low-risk enough
+// to approve unreviewed: at most maxLines changed lines, each changed file
+// being documentation, a test, matched by one of paths, or changed only
+// in its comments. The reason describes the diff, e.g. "4 changed line(s)
+// in docs: README.md".
+func TrivialDiff(diff string, maxLines int, paths []string) (string, bool) {
+	files := diffFiles(diff)
+	if len(files) == 0 {
+		return "", false
+	}
+	total := 0
+	kinds := make(map[string]bool)
+	names := make([]string, 0, len(files))
+	for name, lines := range files {
+		total += len(lines)
+		kind := lowRiskKind(name, lines, paths)
+		if kind == "" {
+			return "", false
+		}
+		kinds[kind] = true
+		names = append(names, name)
+	}
+	if total > maxLines {
+		return "", false
+	}
+
+	var kindList []string
+	for k := range kinds {
+		kindList = append(kindList, k)
+	}
+	sort.Strings(kindList)
+	sort.Strings(names)
+	if len(names) > 5 {
+		names = append(names[:5], fmt.Sprintf("and %d more", len(names)-5))
+	}
+	return fmt.Sprintf("%d changed line(s) in %s: %s", total, strings.Join(kindList, ", "), strings.Join(names, ", ")), true
+}
+
+// lowRiskKind names why a changed file is low-risk ("docs", "tests",
+// "skip_paths", "comments"), or returns "" when it isn't.
+func lowRiskKind(name string, lines []string, paths []string) string {
+	base := path.Base(name)
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case matchesSkipPath(name, paths):
+		return "skip_paths"
+	case docExts[ext] || inDir(name, "docs", "doc"):
+		return "docs"
+	case isTestFile(name, base):
+		return "tests"
+	}
+
+	// A file without changed lines (binary, mode only) can't be checked.
+	prefixes := commentPrefixes[ext]
+	if len(lines) == 0 || len(prefixes) == 0 {
+		return ""
+	}
+	for _, l := range lines {
+		text := strings.TrimSpace(l)
+		if text == "" || text == "*" {
+			continue
+		}
+		comment := false
+		for _, p := range prefixes {
+			if strings.HasPrefix(text, p) {
+				comment = true
+				break
+			}
+		}
+		if !comment {
+			return ""
+		}
+	}
+	return "comments"
+}
+
+func isTestFile(name, base string) bool {
+	if inDir(name, "test", "tests", "__tests__", "spec", "testdata") {
+		return true
+	}
+	for _, p := range []string{"*_test.*", "test_*.py", "*.test.*", "*.spec.*", "*Test.java", "*Test.kt"} {
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inDir reports whether name is under a directory called one of dirs.
+func inDir(name string, dirs ...string) bool {
+	parts := strings.Split(name, "/")
+	for _, p := range parts[:len(parts)-1] {
+		for _, d := range dirs {
+			if p == d {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesSkipPath matches name against review.skip_paths: a glob matches
+// the path or its base name, and a pattern ending in "/" matches
+// everything under that directory.
+func matchesSkipPath(name string, paths []string) bool {
+	for _, p := range paths {
+		if dir, ok := strings.CutSuffix(p, "/"); ok {
+			if strings.HasPrefix(name, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// diffFiles collects the added and removed lines of a unified diff by
+// file.
+func diffFiles(diff string) map[string][]string {
+	files := make(map[string][]string)
+	file, inHunk := "", false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+			// Binary files and mode changes have no ---/+++ lines; take
+			// the name from the header so they still count.
+			if _, b, ok := strings.Cut(line, " b/"); ok {
+				file = b
+				if _, seen := files[file]; !seen {
+					files[file] = nil
+				}
+			}
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			if name := strings.TrimPrefix(line, "+++ "); name != "/dev/null" {
+				file = strings.TrimPrefix(name, "b/")
+			}
+		case !inHunk && strings.HasPrefix(line, "--- "):
+			if name := strings.TrimPrefix(line, "--- "); name != "/dev/null" {
+				file = strings.TrimPrefix(name, "a/")
+			}
+		case inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			files[file] = append(files[file], line[1:])
+		}
+	}
+	return files
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkarma/hive/internal/config"
+)
+
+const commentDiff = `diff --git a/auth.go b/auth.go
+--- a/auth.go
++++ b/auth.go
+@@ -1,3 +1,3 @@
+ package auth
+-// Login checks a password.
++// Login checks a password against the stored hash.
+ func Login() {}
+`
+
+func TestTrivialDiff(t *testing.T) {
+	docs := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1 +1,2 @@\n # Auth\n+Run make.\n"
+	code := strings.Replace(commentDiff, "+// Login checks a password against the stored hash.", "+func Logout() {}", 1)
+	tests := []struct {
+		name  string
+		diff  string
+		paths []string
+		want  bool
+	}{
+		{"docs", docs, nil, true},
+		{"comments", commentDiff, nil, true},
+		{"code", code, nil, false},
+		{"code in skip_paths", code, []string{"auth.go"}, true},
+		{"docs and code", docs + code, nil, false},
+		{"test file", strings.ReplaceAll(code, "auth.go", "auth_test.go"), nil, true},
+		{"binary", "diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n", nil, false},
+		{"empty", "", nil, false},
+	}
+	for _, tt := range tests {
+		if _, got := TrivialDiff(tt.diff, 20, tt.paths); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	big := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1 +1,30 @@\n" + strings.Repeat("+line\n", 30)
+	if _, ok := TrivialDiff(big, 20, nil); ok {
+		t.Error("a diff over the limit needs a review")
+	}
+	if reason, _ := TrivialDiff(docs+commentDiff, 20, nil); reason != "3 changed line(s) in comments, docs: README.md, auth.go" {
+		t.Errorf("reason: %q", reason)
+	}
+}
+
+func TestSkipReview(t *testing.T) {
+	s := testStore(t)
+	dir := initTestRepo(t)
+	task, _ := s.CreateTask("Document install", "", "", nil)
+	os.WriteFile(filepath.Join(dir, "INSTALL.md"), []byte("Run make.\n"), 0644)
+
+	cfg := &config.Config{}
+	if _, ok := SkipReview(s, cfg, task, dir); ok {
+		t.Fatal("skip_trivial is off by default")
+	}
+	cfg.Review.SkipTrivial = true
+	if _, ok := SkipReview(s, cfg, task, dir); !ok {
+		t.Fatal("expected a docs-only change to skip review")
+	}
+	events, _ := s.GetEvents(task.ID)
+	if last := events[len(events)-1]; last.Type != "review_skipped" || !strings.Contains(last.Content, "INSTALL.md") {
+		t.Errorf("event: %+v", last)
+	}
+
+	s.AddReview(task.ID, "gpt", "reject", "VERDICT: REJECT")
+	if _, ok := SkipReview(s, cfg, task, dir); ok {
+		t.Error("a rejected task always goes back to the reviewer")
+	}
+
+	other, _ := s.CreateTask("Document usage", "", "", nil)
+	other.Acceptance = []string{"Mentions make"}
+	if _, ok := SkipReview(s, cfg, other, dir); ok {
+		t.Error("acceptance criteria need a reviewer")
+	}
+}
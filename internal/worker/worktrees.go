@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/imkarma/hive/internal/disk"
+	"github.com/imkarma/hive/internal/git"
+)
+
+// Worktree is one slot of a WorktreePool.
+type Worktree struct {
+	Path string
+}
+
+// WorktreePool hands parallel tasks prepared worktrees and takes them
+// back, instead of creating and removing one per task. A returned
+// worktree is reset to the epic branch for the next task but keeps its
+// ignored files, so dependencies installed by setup_cmd are reused.
+// There are never more slots than tasks running at once.
+type WorktreePool struct {
+	repo     *git.Safety
+	dir      string // Where the slots live, e.g. .hive/worktrees/epic-3
+	ref      string // What every task starts from: the epic branch
+	setupCmd string
+
+	mu   sync.Mutex
+	free []*Worktree
+	all  []*Worktree
+}
+
+// NewWorktreePool creates a pool of worktrees of repo under dir, each
+// starting from ref. Slots left in dir by an earlier run are reused.
+func NewWorktreePool(repo *git.Safety, dir, ref, setupCmd string) *WorktreePool {
+	return &WorktreePool{repo: repo, dir: dir, ref: ref, setupCmd: setupCmd}
+}
+
+// Acquire returns a worktree at the current tip of the epic branch: a
+// free slot, reset, or a new one.
+func (wp *WorktreePool) Acquire() (*Worktree, error) {
+	wp.mu.Lock()
+	var wt *Worktree
+	if n := len(wp.free); n > 0 {
+		wt, wp.free = wp.free[n-1], wp.free[:n-1]
+	} else {
+		wt = &Worktree{Path: filepath.Join(wp.dir, fmt.Sprintf("slot-%d", len(wp.all)+1))}
+		wp.all = append(wp.all, wt)
+	}
+	wp.mu.Unlock()
+
+	if err := wp.ready(wt); err != nil {
+		wp.Release(wt)
+		return nil, err
+	}
+	return wt, nil
+}
+
+// ready resets a slot for a task, or (re)creates it when it doesn't exist
+// or can't be reset.
+func (wp *WorktreePool) ready(wt *Worktree) error {
+	// A worktree has a .git file pointing back at the repository.
+	if info, err := os.Stat(filepath.Join(wt.Path, ".git")); err == nil && info.Mode().IsRegular() {
+		if err := git.New(wt.Path).ResetWorktree(wp.ref); err == nil {
+			return nil
+		}
+		wp.repo.RemoveWorktree(wt.Path)
+	}
+	os.RemoveAll(wt.Path)
+	os.Remove(wp.markerPath(wt))
+	wp.repo.PruneWorktrees()
+	if err := disk.Check(0); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(wp.dir, 0755); err != nil {
+		return fmt.Errorf("create worktree dir: %w", err)
+	}
+	return wp.repo.AddDetachedWorktree(wt.Path, wp.ref)
+}
+
+// Release gives a worktree back for the next task.
+func (wp *WorktreePool) Release(wt *Worktree) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.free = append(wp.free, wt)
+}
+
+// NeedsSetup reports whether setup_cmd has yet to run in a worktree. A
+// changed command runs again.
+func (wp *WorktreePool) NeedsSetup(wt *Worktree) bool {
+	if wp.setupCmd == "" {
+		return false
+	}
+	done, err := os.ReadFile(wp.markerPath(wt))
+	return err != nil || string(done) != wp.setupCmd
+}
+
+// SetupDone records that setup_cmd ran in a worktree.
+func (wp *WorktreePool) SetupDone(wt *Worktree) {
+	os.WriteFile(wp.markerPath(wt), []byte(wp.setupCmd), 0644)
+}
+
+// markerPath is where a slot records its setup, next to (not in) the
+// worktree, so resetting the worktree doesn't remove it.
+func (wp *WorktreePool) markerPath(wt *Worktree) string {
+	return wt.Path + ".setup"
+}
+
+// Close removes every worktree, unless keep is set: then they stay for
+// the next run, dependencies and all.
+func (wp *WorktreePool) Close(keep bool) {
+	if keep {
+		return
+	}
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	for _, wt := range wp.all {
+		wp.repo.RemoveWorktree(wt.Path)
+		os.RemoveAll(wt.Path)
+		os.Remove(wp.markerPath(wt))
+	}
+	wp.repo.PruneWorktrees()
+	wp.all, wp.free = nil, nil
+}
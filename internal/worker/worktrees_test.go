@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/imkarma/hive/internal/git"
+)
+
+func TestWorktreePool(t *testing.T) {
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".hive/\ndeps/\n"), 0644)
+	repo := git.New(dir)
+	repo.CommitAll("initial")
+	if out, err := exec.Command("git", "-C", dir, "checkout", "-q", "-b", "hive/epic-1").CombinedOutput(); err != nil {
+		t.Fatalf("checkout: %s", out)
+	}
+
+	wp := NewWorktreePool(repo, filepath.Join(dir, ".hive", "worktrees"), "hive/epic-1", "make deps")
+	a, err := wp.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	b, err := wp.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if a.Path == b.Path {
+		t.Fatal("tasks running at once need their own worktrees")
+	}
+	if !wp.NeedsSetup(a) {
+		t.Error("a new worktree needs setup")
+	}
+	wp.SetupDone(a)
+	os.MkdirAll(filepath.Join(a.Path, "deps"), 0755)
+	os.WriteFile(filepath.Join(a.Path, "task.go"), []byte("package task\n"), 0644)
+	wp.Release(a)
+
+	again, err := wp.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if again.Path != a.Path {
+		t.Fatalf("expected the released worktree back, got %s", again.Path)
+	}
+	if wp.NeedsSetup(again) {
+		t.Error("a reused worktree keeps its setup")
+	}
+	if _, err := os.Stat(filepath.Join(again.Path, "task.go")); err == nil {
+		t.Error("the previous task's files should be gone")
+	}
+	if _, err := os.Stat(filepath.Join(again.Path, "deps")); err != nil {
+		t.Error("ignored files should survive the reset")
+	}
+
+	wp.Release(again)
+	wp.Release(b)
+	wp.Close(false)
+	if _, err := os.Stat(a.Path); !os.IsNotExist(err) {
+		t.Error("Close should remove the worktrees")
+	}
+}
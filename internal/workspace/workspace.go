@@ -0,0 +1,295 @@
+// Package workspace detects monorepo layouts — Go workspaces (go.work),
+// npm/yarn/pnpm workspaces and Cargo workspaces — and maps changed files
+// to the packages they belong to, so gates can run only what a task
+// affects.
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workspace kinds.
+const (
+	KindGo    = "go"
+	KindNPM   = "npm"
+	KindCargo = "cargo"
+)
+
+// Package is one member of a workspace.
+type Package struct {
+	Name string // Module path, package or crate name; the directory if unknown
+	Dir  string // Relative to the workspace root, slash-separated
+}
+
+// Workspace is a detected monorepo layout.
+type Workspace struct {
+	Kind     string
+	Packages []Package
+}
+
+// Detect looks for a workspace definition at root: go.work, then
+// pnpm-workspace.yaml or a package.json with workspaces, then a
+// Cargo.toml with [workspace]. It
+// returns nil when root isn't a workspace.
+func Detect(root string) *Workspace {
+	for _, detect := range []func(string) *Workspace{detectGo, detectNPM, detectCargo} {
+		if w := detect(root); w != nil && len(w.Packages) > 0 {
+			sort.Slice(w.Packages, func(i, j int) bool { return w.Packages[i].Dir < w.Packages[j].Dir })
+			return w
+		}
+	}
+	return nil
+}
+
+// Affected returns the packages the changed files belong to, and whether
+// every file belongs to one. A file outside all packages (a root config,
+// a shared script) can affect anything, so whole=false means the caller
+// should check everything.
+func (w *Workspace) Affected(files []string) (pkgs []Package, whole bool) {
+	seen := map[string]bool{}
+	whole = true
+	for _, f := range files {
+		p, ok := w.owner(filepath.ToSlash(f))
+		if !ok {
+			whole = false
+			continue
+		}
+		if !seen[p.Dir] {
+			seen[p.Dir] = true
+			pkgs = append(pkgs, p)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Dir < pkgs[j].Dir })
+	return pkgs, whole
+}
+
+// owner is the innermost package containing file.
+func (w *Workspace) owner(file string) (Package, bool) {
+	var best Package
+	found := false
+	for _, p := range w.Packages {
+		if p.Dir == "." {
+			// A package at the root contains everything; it is only
+			// the owner when nothing more specific is.
+			if !found {
+				best, found = p, true
+			}
+			continue
+		}
+		if strings.HasPrefix(file, p.Dir+"/") && (!found || best.Dir == "." || len(p.Dir) > len(best.Dir)) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+var (
+	goUseLineRe  = regexp.MustCompile(`^use\s+(\S+)`)
+	goModuleRe   = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	tomlStringRe = regexp.MustCompile(`"([^"]*)"`)
+)
+
+func detectGo(root string) *Workspace {
+	data, err := os.ReadFile(filepath.Join(root, "go.work"))
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			dirs = append(dirs, strings.Trim(line, `"`))
+		case line == "use (":
+			inBlock = true
+		case goUseLineRe.MatchString(line):
+			dirs = append(dirs, strings.Trim(goUseLineRe.FindStringSubmatch(line)[1], `"`))
+		}
+	}
+
+	w := &Workspace{Kind: KindGo}
+	for _, dir := range dirs {
+		dir = cleanDir(dir)
+		name := dir
+		if mod, err := os.ReadFile(filepath.Join(root, dir, "go.mod")); err == nil {
+			if m := goModuleRe.FindSubmatch(mod); m != nil {
+				name = string(m[1])
+			}
+		}
+		w.Packages = append(w.Packages, Package{Name: name, Dir: dir})
+	}
+	return w
+}
+
+func detectNPM(root string) *Workspace {
+	patterns, ok := npmPatterns(root)
+	if !ok {
+		return nil
+	}
+	w := &Workspace{Kind: KindNPM}
+	for _, dir := range expand(root, patterns, "package.json") {
+		name := dir
+		if pkg, err := os.ReadFile(filepath.Join(root, dir, "package.json")); err == nil {
+			var p struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(pkg, &p) == nil && p.Name != "" {
+				name = p.Name
+			}
+		}
+		w.Packages = append(w.Packages, Package{Name: name, Dir: dir})
+	}
+	return w
+}
+
+// npmPatterns reads the workspace globs from package.json — a list, or
+// {"packages": [...]} as yarn allows — or from pnpm-workspace.yaml.
+func npmPatterns(root string) ([]string, bool) {
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		var ws struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &ws) == nil && len(ws.Packages) > 0 {
+			return ws.Packages, true
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, false
+	}
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &manifest) != nil || len(manifest.Workspaces) == 0 {
+		return nil, false
+	}
+	var patterns []string
+	if json.Unmarshal(manifest.Workspaces, &patterns) == nil {
+		return patterns, true
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if json.Unmarshal(manifest.Workspaces, &obj) != nil {
+		return nil, false
+	}
+	return obj.Packages, true
+}
+
+func detectCargo(root string) *Workspace {
+	data, err := os.ReadFile(filepath.Join(root, "Cargo.toml"))
+	if err != nil {
+		return nil
+	}
+	members := tomlArray(string(data), "workspace", "members")
+	if members == nil {
+		return nil
+	}
+	w := &Workspace{Kind: KindCargo}
+	for _, dir := range expand(root, members, "Cargo.toml") {
+		name := dir
+		if crate, err := os.ReadFile(filepath.Join(root, dir, "Cargo.toml")); err == nil {
+			if n := tomlArray(string(crate), "package", "name"); len(n) == 1 {
+				name = n[0]
+			}
+		}
+		w.Packages = append(w.Packages, Package{Name: name, Dir: dir})
+	}
+	return w
+}
+
+// tomlArray reads the strings of key in [section] — a string array,
+// possibly over several lines, or a single string. It is just enough TOML
+// for Cargo manifests.
+func tomlArray(data, section, key string) []string {
+	inSection := false
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "["+section+"]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(k) != key {
+			continue
+		}
+		v = stripTOMLComment(strings.TrimSpace(v))
+		for strings.HasPrefix(v, "[") && !strings.Contains(v, "]") && i+1 < len(lines) {
+			i++
+			v += " " + stripTOMLComment(lines[i])
+		}
+		var out []string
+		for _, m := range tomlStringRe.FindAllStringSubmatch(v, -1) {
+			out = append(out, m[1])
+		}
+		if out == nil {
+			out = []string{}
+		}
+		return out
+	}
+	return nil
+}
+
+func stripTOMLComment(s string) string {
+	var sb strings.Builder
+	quoted := false
+	for _, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == '#' && !quoted {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// expand resolves member globs to the directories that have a manifest.
+// "**" is treated as "*"; negated patterns are ignored.
+func expand(root string, patterns []string, manifest string) []string {
+	seen := map[string]bool{}
+	var dirs []string
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+		pattern = strings.ReplaceAll(cleanDir(pattern), "**", "*")
+		matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, manifest)); err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(root, m)
+			if err != nil {
+				continue
+			}
+			if rel = filepath.ToSlash(rel); !seen[rel] {
+				seen[rel] = true
+				dirs = append(dirs, rel)
+			}
+		}
+	}
+	return dirs
+}
+
+func cleanDir(dir string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(dir)), "./"), "/")
+}
@@ -0,0 +1,126 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetect_GoWork(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"go.work":          "go 1.22\n\nuse (\n\t./api // the server\n\t./tools/gen\n)\nuse ./web\n",
+		"api/go.mod":       "module example.com/api\n\ngo 1.22\n",
+		"tools/gen/go.mod": "module example.com/gen\n",
+	})
+	w := Detect(root)
+	if w == nil || w.Kind != KindGo {
+		t.Fatalf("expected a go workspace, got %+v", w)
+	}
+	want := []Package{
+		{Name: "example.com/api", Dir: "api"},
+		{Name: "example.com/gen", Dir: "tools/gen"},
+		{Name: "web", Dir: "web"},
+	}
+	if !reflect.DeepEqual(w.Packages, want) {
+		t.Errorf("packages = %+v, want %+v", w.Packages, want)
+	}
+}
+
+func TestDetect_NPM(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["packages/*", "apps/web"]}`,
+		"packages/ui/package.json": `{"name": "@acme/ui"}`,
+		"packages/docs/README.md":  "not a package",
+		"apps/web/package.json":    `{"name": "web"}`,
+	})
+	w := Detect(root)
+	if w == nil || w.Kind != KindNPM {
+		t.Fatalf("expected an npm workspace, got %+v", w)
+	}
+	want := []Package{{Name: "web", Dir: "apps/web"}, {Name: "@acme/ui", Dir: "packages/ui"}}
+	if !reflect.DeepEqual(w.Packages, want) {
+		t.Errorf("packages = %+v, want %+v", w.Packages, want)
+	}
+
+	// Yarn's object form and pnpm-workspace.yaml.
+	root = writeFiles(t, map[string]string{
+		"package.json":           `{"workspaces": {"packages": ["libs/*"]}}`,
+		"libs/core/package.json": `{"name": "core"}`,
+	})
+	if w := Detect(root); w == nil || len(w.Packages) != 1 || w.Packages[0].Name != "core" {
+		t.Errorf("yarn workspaces: got %+v", w)
+	}
+	root = writeFiles(t, map[string]string{
+		"package.json":          `{"name": "root"}`,
+		"pnpm-workspace.yaml":   "packages:\n  - 'svc/*'\n",
+		"svc/auth/package.json": `{"name": "auth"}`,
+	})
+	if w := Detect(root); w == nil || len(w.Packages) != 1 || w.Packages[0].Dir != "svc/auth" {
+		t.Errorf("pnpm workspace: got %+v", w)
+	}
+}
+
+func TestDetect_Cargo(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"Cargo.toml":               "[workspace]\nmembers = [\n  \"crates/*\", # all crates\n  \"cli\",\n]\n\n[profile.release]\nlto = true\n",
+		"crates/parser/Cargo.toml": "[package]\nname = \"acme-parser\"\nversion = \"0.1.0\"\n",
+		"cli/Cargo.toml":           "[package]\nname = \"acme\"\n",
+	})
+	w := Detect(root)
+	if w == nil || w.Kind != KindCargo {
+		t.Fatalf("expected a cargo workspace, got %+v", w)
+	}
+	want := []Package{{Name: "acme", Dir: "cli"}, {Name: "acme-parser", Dir: "crates/parser"}}
+	if !reflect.DeepEqual(w.Packages, want) {
+		t.Errorf("packages = %+v, want %+v", w.Packages, want)
+	}
+}
+
+func TestDetect_NotAWorkspace(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"package.json": `{"name": "app"}`,
+		"Cargo.toml":   "[package]\nname = \"app\"\n",
+	})
+	if w := Detect(root); w != nil {
+		t.Errorf("expected no workspace, got %+v", w)
+	}
+}
+
+func TestAffected(t *testing.T) {
+	w := &Workspace{Kind: KindGo, Packages: []Package{
+		{Name: "api", Dir: "api"},
+		{Name: "api-client", Dir: "api/client"},
+		{Name: "web", Dir: "web"},
+	}}
+
+	pkgs, whole := w.Affected([]string{"api/server.go", "api/client/client.go", "api/handler.go"})
+	if !whole || len(pkgs) != 2 || pkgs[0].Dir != "api" || pkgs[1].Dir != "api/client" {
+		t.Errorf("got %+v, %v", pkgs, whole)
+	}
+
+	if _, whole := w.Affected([]string{"web/app.go", "Makefile"}); whole {
+		t.Error("a file outside the packages should make whole false")
+	}
+
+	// A root member owns only what no other member does.
+	w.Packages = append(w.Packages, Package{Name: "root", Dir: "."})
+	pkgs, whole = w.Affected([]string{"Makefile", "web/app.go"})
+	if !whole || len(pkgs) != 2 || pkgs[0].Dir != "." || pkgs[1].Dir != "web" {
+		t.Errorf("root member: got %+v, %v", pkgs, whole)
+	}
+}